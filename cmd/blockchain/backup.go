@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/backup"
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/blockchain"
+)
+
+// storeFlags selects an object store for backups or the block archive.
+// Credentials come from the environment so they never appear in the process list:
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY for S3, GCS_ACCESS_TOKEN for GCS
+// (without it a token is requested from the GCE metadata server).
+type storeFlags struct {
+	name       string
+	dir        *string
+	s3Endpoint *string
+	s3Region   *string
+	s3Bucket   *string
+	s3Prefix   *string
+	gcsBucket  *string
+	gcsPrefix  *string
+}
+
+// registerStoreFlags adds the flags of a store named name (e.g. "backup") to fs
+func registerStoreFlags(fs *flag.FlagSet, name, defaultPrefix string) *storeFlags {
+	return &storeFlags{
+		name:       name,
+		dir:        fs.String(name+"-dir", "", "Local directory used for "+name+"s when no bucket is configured (default <datadir>/"+name+"s)"),
+		s3Endpoint: fs.String(name+"-s3-endpoint", "", "S3-compatible endpoint for "+name+"s, e.g. https://s3.eu-central-1.amazonaws.com"),
+		s3Region:   fs.String(name+"-s3-region", "us-east-1", "S3 region"),
+		s3Bucket:   fs.String(name+"-s3-bucket", "", "S3 bucket for "+name+"s"),
+		s3Prefix:   fs.String(name+"-s3-prefix", defaultPrefix, "Key prefix in the S3 bucket"),
+		gcsBucket:  fs.String(name+"-gcs-bucket", "", "Google Cloud Storage bucket for "+name+"s"),
+		gcsPrefix:  fs.String(name+"-gcs-prefix", defaultPrefix, "Object name prefix in the GCS bucket"),
+	}
+}
+
+// openStore returns the S3 or GCS store when a bucket is configured, otherwise the local directory store
+func (f *storeFlags) openStore() (backup.Store, error) {
+	switch {
+	case *f.s3Bucket != "" && *f.gcsBucket != "":
+		return nil, errors.New("configure either an S3 or a GCS bucket, not both")
+	case *f.s3Bucket != "":
+		return backup.NewS3Store(backup.S3Config{
+			Endpoint:  *f.s3Endpoint,
+			Region:    *f.s3Region,
+			Bucket:    *f.s3Bucket,
+			Prefix:    *f.s3Prefix,
+			AccessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		})
+	case *f.gcsBucket != "":
+		return backup.NewGCSStore(backup.GCSConfig{
+			Bucket:      *f.gcsBucket,
+			Prefix:      *f.gcsPrefix,
+			AccessToken: os.Getenv("GCS_ACCESS_TOKEN"),
+		})
+	case *f.dir != "":
+		return backup.NewLocalStore(*f.dir)
+	default:
+		return backup.NewLocalStore(filepath.Join(blockchain.GetBlockchainDataPath(), f.name+"s"))
+	}
+}
+
+// runBackupCommand implements "blockchain backup list|verify|restore".
+// Restores must be run while the node is stopped.
+func runBackupCommand(args []string) {
+	backupCmd := flag.NewFlagSet("backup", flag.ExitOnError)
+	storeFlags := registerStoreFlags(backupCmd, "backup", "confirmix/backups/")
+	nameFlag := backupCmd.String("name", "", "Backup to verify or restore (default: the newest)")
+	dataDirFlag := backupCmd.String("datadir", "", "Data directory of the node, which holds its backups and is restored into (default \"data\")")
+
+	if len(args) < 1 {
+		fmt.Println("Expected 'list', 'verify' or 'restore'")
+		os.Exit(1)
+	}
+	action := args[0]
+	backupCmd.Parse(args[1:])
+	blockchain.SetDataDir(*dataDirFlag)
+	dataDir := blockchain.GetBlockchainDataPath()
+
+	store, err := storeFlags.openStore()
+	if err != nil {
+		log.Fatalf("Failed to open backup store: %v", err)
+	}
+
+	backups, err := backup.List(store)
+	if err != nil {
+		log.Fatalf("Failed to list backups: %v", err)
+	}
+
+	name := *nameFlag
+	if name == "" && len(backups) > 0 {
+		name = backups[0].Name
+	}
+
+	switch action {
+	case "list":
+		fmt.Printf("Backups in %s:\n", store.Location())
+		for _, b := range backups {
+			fmt.Printf("  %s  height=%d  size=%d  created=%s\n", b.Name, b.Height, b.Size, time.Unix(b.CreatedAt, 0).UTC().Format(time.RFC3339))
+		}
+		if len(backups) == 0 {
+			fmt.Println("  (none)")
+		}
+
+	case "verify":
+		if name == "" {
+			log.Fatalf("No backups found in %s", store.Location())
+		}
+		manifest, err := backup.Verify(store, name)
+		if err != nil {
+			log.Fatalf("Backup %s is invalid: %v", name, err)
+		}
+		fmt.Printf("Backup %s is valid: height %d, block %s\n", name, manifest.Height, manifest.Hash)
+
+	case "restore":
+		if name == "" {
+			log.Fatalf("No backups found in %s", store.Location())
+		}
+		manifest, err := backup.Restore(store, name, dataDir)
+		if err != nil {
+			log.Fatalf("Failed to restore backup %s: %v", name, err)
+		}
+		fmt.Printf("Restored %s into %s (height %d). Start the node to resume from it.\n", name, dataDir, manifest.Height)
+
+	default:
+		fmt.Printf("Unknown backup action '%s'; expected 'list', 'verify' or 'restore'\n", action)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/blockchain"
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/keystore"
+)
+
+// runKeysCommand implements "blockchain keys list|generate|import|rotate|audit".
+// The node reads its keys on startup, so it must be restarted after a change.
+func runKeysCommand(args []string) {
+	keysCmd := flag.NewFlagSet("keys", flag.ExitOnError)
+	dataDirFlag := keysCmd.String("datadir", "", "Data directory of the node (default \"data\")")
+	keystoreFlag := keysCmd.String("keystore", "", "Keystore directory (default <datadir>/keys)")
+	roleFlag := keysCmd.String("role", "", "Key role: node, validator or admin")
+	keyFlag := keysCmd.String("key", "", "Private key to import (hex, PEM or WIF)")
+	formatFlag := keysCmd.String("format", string(blockchain.KeyFormatAuto), "Format of the imported key: auto, hex, pem or wif")
+	retiredFlag := keysCmd.Bool("retired", false, "Also list rotated-out keys")
+	configFlag := keysCmd.String("config", "", "Configuration file to audit for a private key (default <datadir>/config.json)")
+	pruneFlag := keysCmd.Bool("prune", false, "With audit: delete retired keys and move a config private key into the keystore")
+
+	if len(args) < 1 {
+		fmt.Println("Expected 'list', 'generate', 'import', 'rotate' or 'audit'")
+		os.Exit(1)
+	}
+	action := args[0]
+	keysCmd.Parse(args[1:])
+	blockchain.SetDataDir(*dataDirFlag)
+
+	keystoreDir := *keystoreFlag
+	if keystoreDir == "" {
+		keystoreDir = keystore.DefaultDir()
+	}
+	ks, err := keystore.Open(keystoreDir)
+	if err != nil {
+		log.Fatalf("Failed to open keystore: %v", err)
+	}
+
+	// role returns the -role flag, which every action but list requires
+	role := func() keystore.Role {
+		role, err := keystore.ParseRole(*roleFlag)
+		if err != nil {
+			log.Fatalf("Invalid -role: %v", err)
+		}
+		return role
+	}
+
+	switch action {
+	case "list":
+		infos, err := ks.List()
+		if err != nil {
+			log.Fatalf("Failed to list keys: %v", err)
+		}
+		fmt.Printf("Keys in %s:\n", ks.Dir())
+		for _, info := range infos {
+			printKeyInfo(info)
+		}
+		if len(infos) == 0 {
+			fmt.Println("  (none)")
+		}
+		if *retiredFlag {
+			retired, err := ks.Retired()
+			if err != nil {
+				log.Fatalf("Failed to list retired keys: %v", err)
+			}
+			fmt.Println("Retired keys:")
+			for _, info := range retired {
+				printKeyInfo(info)
+			}
+			if len(retired) == 0 {
+				fmt.Println("  (none)")
+			}
+		}
+
+	case "generate":
+		key, err := ks.Generate(role())
+		if err != nil {
+			log.Fatalf("Failed to generate key: %v", err)
+		}
+		fmt.Printf("Generated %s key\n", key.Role)
+		printKeyInfo(key.Info())
+
+	case "import":
+		if *keyFlag == "" {
+			log.Fatalf("-key is required for import")
+		}
+		key, err := ks.Import(role(), *keyFlag, blockchain.KeyFormat(*formatFlag))
+		if err != nil {
+			log.Fatalf("Failed to import key: %v", err)
+		}
+		fmt.Printf("Imported %s key\n", key.Role)
+		printKeyInfo(key.Info())
+
+	case "rotate":
+		old, key, err := ks.Rotate(role())
+		if err != nil {
+			log.Fatalf("Failed to rotate key: %v", err)
+		}
+		fmt.Printf("Rotated %s key; the old key was moved to %s/retired\n", key.Role, ks.Dir())
+		printKeyInfo(old.Info())
+		printKeyInfo(key.Info())
+		if key.Role == keystore.RoleValidator {
+			fmt.Println("The validator address changed; register the new address before restarting the node.")
+		}
+
+	case "audit":
+		configPath := *configFlag
+		if configPath == "" {
+			configPath = filepath.Join(blockchain.GetBlockchainDataPath(), "config.json")
+		}
+		auditKeys(ks, configPath, *pruneFlag)
+
+	default:
+		fmt.Printf("Unknown keys action '%s'; expected 'list', 'generate', 'import', 'rotate' or 'audit'\n", action)
+		os.Exit(1)
+	}
+}
+
+func printKeyInfo(info keystore.Info) {
+	fmt.Printf("  %-9s  address=%s  node=%s  created=%s\n", info.Role, info.Address, info.NodeAddress, info.CreatedAt.Format(time.RFC3339))
+}
+
+// auditKeys reports the private keys kept on disk outside the active
+// keystore: rotated-out keys, a legacy key left in the config file and the
+// key files of wallets in the data directory. With prune the retired keys
+// are deleted and the config key is moved into the keystore.
+func auditKeys(ks *keystore.Keystore, configPath string, prune bool) {
+	infos, err := ks.List()
+	if err != nil {
+		log.Fatalf("Failed to list keys: %v", err)
+	}
+	fmt.Printf("Active keys in %s (needed):\n", ks.Dir())
+	for _, info := range infos {
+		printKeyInfo(info)
+	}
+
+	retired, err := ks.Retired()
+	if err != nil {
+		log.Fatalf("Failed to list retired keys: %v", err)
+	}
+	fmt.Printf("Retired keys (unnecessary): %d\n", len(retired))
+	for _, info := range retired {
+		printKeyInfo(info)
+	}
+	if prune && len(retired) > 0 {
+		if _, err := ks.PurgeRetired(); err != nil {
+			log.Fatalf("Failed to delete retired keys: %v", err)
+		}
+		fmt.Printf("  deleted %d retired keys\n", len(retired))
+	}
+
+	fmt.Printf("Config file %s: ", configPath)
+	switch found, err := auditConfigKey(ks, configPath, prune); {
+	case err != nil:
+		log.Fatalf("Failed to audit the config file: %v", err)
+	case !found:
+		fmt.Println("no private key")
+	case prune:
+		fmt.Println("private key moved into the keystore and removed from the file")
+	default:
+		fmt.Println("holds a private key (unnecessary); run with -prune to move it into the keystore")
+	}
+
+	keyFiles, _ := filepath.Glob(filepath.Join(blockchain.GetBlockchainDataPath(), "key_*.json"))
+	fmt.Printf("Wallet key files in %s: %d\n", blockchain.GetBlockchainDataPath(), len(keyFiles))
+	if len(keyFiles) > 0 {
+		fmt.Println("  Whether they are needed depends on the chain state; audit and prune them")
+		fmt.Println("  on the running node with POST /api/admin/keys/audit and /api/admin/keys/prune.")
+	}
+}
+
+// auditConfigKey reports whether the config file holds a private key. With
+// prune the key is imported for the roles that have none and removed from
+// the file; the other settings are kept as they are.
+func auditConfigKey(ks *keystore.Keystore, path string, prune bool) (bool, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return false, err
+	}
+	var privateKey string
+	if raw, exists := fields["private_key_pem"]; exists {
+		json.Unmarshal(raw, &privateKey)
+	}
+	if privateKey == "" || !prune {
+		return privateKey != "", nil
+	}
+
+	imported, err := ks.ImportMissing(privateKey, blockchain.KeyFormatPEM)
+	if err != nil {
+		return true, fmt.Errorf("failed to move the private key into the keystore: %v", err)
+	}
+	if len(imported) > 0 {
+		fmt.Printf("imported as the %v key; ", imported)
+	}
+	delete(fields, "private_key_pem")
+	data, err = json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return true, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return true, err
+	}
+	return true, ioutil.WriteFile(path, data, info.Mode().Perm())
+}
@@ -2,76 +2,214 @@ package main
 
 import (
 	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/rand"
-	"crypto/x509"
 	"encoding/json"
-	"encoding/pem"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math/big"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/backup"
 	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/blockchain"
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/chaos"
 	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/consensus"
 	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/network"
 	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/api"
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/keystore"
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/logging"
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/replica"
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/signer"
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/watchdog"
 )
 
 // NodeConfig represents the node configuration
 type NodeConfig struct {
 	Address           string   `json:"address"`
 	Port              int      `json:"port"`
-	PrivateKeyPEM     string   `json:"private_key_pem"`
+	APIPort           int      `json:"api_port,omitempty"` // HTTP API port, 8080 when unset
+	DataDir           string   `json:"datadir,omitempty"`  // chain state, keys, backups and logs; "data" when unset
+	PrivateKeyPEM     string   `json:"private_key_pem,omitempty"` // legacy single key, moved into the keystore on startup
 	IsValidator       bool     `json:"is_validator"`
 	HumanProof        string   `json:"human_proof"`
 	PeerAddresses     []string `json:"peer_addresses"`
 	GovernanceEnabled bool     `json:"governance_enabled"` // Whether to enable governance features
 	ValidatorMode     string   `json:"validator_mode"`     // Validator approval mode: admin, hybrid, governance, automatic
 	AdminAddress      string   `json:"admin_address"`      // Admin address for validator approvals (in admin mode)
+	ChainID           string   `json:"chain_id"`           // Network identifier bound into every signature
+	Observer          bool     `json:"observer,omitempty"` // Read-only node without validator or admin keys
+
+	// Primary whose block stream a read replica follows instead of gossip; implies observer mode
+	Replica *replica.Config `json:"replica,omitempty"`
+
+	// System and treasury addresses left out of the rich list (/api/stats/richlist)
+	RichListExclude []string `json:"richlist_exclude,omitempty"`
+
+	// Heights at which consensus rules activate, recorded in the genesis block (must match across the network)
+	FeatureActivations map[blockchain.Feature]uint64 `json:"feature_activations,omitempty"`
+
+	// Block reward emission recorded in the genesis block; the built-in schedule when absent
+	RewardSchedule *blockchain.RewardSchedule `json:"reward_schedule,omitempty"`
+
+	// Where block signatures are produced: the keystore (default), an HSM via PKCS#11, or AWS/GCP KMS
+	ValidatorSigner *signer.Config `json:"validator_signer,omitempty"`
+
+	// Settings below can be changed without a restart (SIGHUP or the admin reload endpoint)
+	LogLevel    string              `json:"log_level,omitempty"`    // debug, info, warn, error
+	CORSOrigins []string            `json:"cors_origins,omitempty"` // allowed API origins; empty or "*" allows all
+	P2PLimits   *network.RateLimits `json:"p2p_limits,omitempty"`   // inbound connection and message rate limits
+
+	// Networks allowed to reach /api/admin/*, validator approval and multisig execution
+	AdminAccess *api.AdminAccess `json:"admin_access,omitempty"`
+
+	// Profiling (/api/admin/debug/pprof/*) and runtime (/api/debug/runtime) endpoints, off when absent
+	Debug *api.DebugEndpoints `json:"debug,omitempty"`
+
+	// Gzip/deflate encoding of large API responses, on with the defaults when absent
+	Compression *api.Compression `json:"compression,omitempty"`
+
+	// Per-route API timeouts and circuit breakers keyed by "METHOD /path/template"
+	// ("*" for all other routes); entries override the built-in defaults
+	RoutePolicies map[string]api.RoutePolicy `json:"route_policies,omitempty"`
 }
 
 func main() {
 	// Define command line flags
 	nodeCmd := flag.NewFlagSet("node", flag.ExitOnError)
 	validatorFlag := nodeCmd.Bool("validator", false, "Run as a validator")
+	observerFlag := nodeCmd.Bool("observer", false, "Run as a read-only observer: sync and serve reads, never sign, mine or accept writes")
+	replicaOfFlag := nodeCmd.String("replica-of", "", "Run as a read replica of the primary node at this API URL, ingesting blocks only from its block stream (implies -observer)")
+	replicaKeyFlag := nodeCmd.String("replica-key", "", "API key with the replica scope on the primary")
 	addressFlag := nodeCmd.String("address", "127.0.0.1", "Node address")
 	portFlag := nodeCmd.Int("port", 8000, "Node port")
+	apiPortFlag := nodeCmd.Int("api-port", 8080, "HTTP API port")
+	dataDirFlag := nodeCmd.String("datadir", "", "Directory for the chain state, keys, backups and logs (overrides the config file; default \"data\")")
 	configFlag := nodeCmd.String("config", "", "Configuration file path")
+	keystoreFlag := nodeCmd.String("keystore", "", "Directory holding the node, validator and admin keys (default <datadir>/keys)")
+	signerFlag := nodeCmd.String("validator-signer", "", "Validator signer driver: local, pkcs11, awskms or gcpkms (overrides the config file)")
+	signerKeyFlag := nodeCmd.String("validator-signer-key", "", "Key of the validator signer: PKCS#11 object ID, AWS KMS key ID/ARN or GCP key version name")
+	pkcs11ModuleFlag := nodeCmd.String("pkcs11-module", "", "PKCS#11 library of the HSM holding the validator key")
 	peersFlag := nodeCmd.String("peers", "", "Comma-separated list of peer addresses")
 	pohVerifyFlag := nodeCmd.Bool("poh-verify", false, "Enable PoH verification")
+	pohURLFlag := nodeCmd.String("poh-url", "", "Base URL of an external PoH provider, e.g. a poh-simulator at http://localhost:8090")
+	pohAPIKeyFlag := nodeCmd.String("poh-api-key", "", "API key for the external PoH provider")
+	pohLocalSimulatorFlag := nodeCmd.Bool("poh-local-simulator", false, "Use the in-process PoH simulator instead of an external provider")
 	governanceFlag := nodeCmd.Bool("governance", false, "Enable governance features")
 	validatorModeFlag := nodeCmd.String("validator-mode", "admin", "Validator approval mode: admin, hybrid, governance, automatic")
 	adminAddressFlag := nodeCmd.String("admin", "", "Admin address for validator approvals (in admin mode)")
+	minStakeFlag := nodeCmd.String("validator-min-stake", "", "Balance (in base units) a validator must lock when approved; empty disables staking")
+	sigWorkersFlag := nodeCmd.Int("sig-workers", 0, "Goroutines verifying transaction signatures during block import (0 = one per CPU)")
+	chainIDFlag := nodeCmd.String("chain-id", blockchain.DefaultChainID, "Chain ID bound into transaction and block signatures (must match across the network)")
+	maxBlockTxsFlag := nodeCmd.Int("max-block-txs", blockchain.DefaultMaxBlockTransactions, "Maximum transactions per block besides the reward (must match across the network)")
+	minTransferFlag := nodeCmd.Uint64("min-transfer", 0, "Smallest transfer value accepted into the mempool (0 = no minimum)")
+	dustThresholdFlag := nodeCmd.Uint64("dust-threshold", 0, "Smallest non-zero balance a transfer may leave on the sender or create for the recipient (0 = disabled)")
+	gcOnSnapshotFlag := nodeCmd.Bool("gc-on-snapshot", false, "Remove empty accounts from the state before every snapshot")
+	gcKeepFlag := nodeCmd.String("gc-keep", "", "Comma-separated addresses that account compaction never removes")
+	richListExcludeFlag := nodeCmd.String("richlist-exclude", "", "Comma-separated system or treasury addresses left out of the rich list (overrides the config file)")
+	drainTimeoutFlag := nodeCmd.Duration("drain-timeout", 30*time.Second, "How long shutdown waits for API requests in flight")
+	persistMempoolFlag := nodeCmd.Bool("persist-mempool", true, "Save pending transactions on shutdown and restore them, validated again, on startup")
+	pprofFlag := nodeCmd.Bool("debug-pprof", false, "Serve pprof profiles under /api/admin/debug/pprof/ to API keys with the admin scope")
+	runtimeStatsFlag := nodeCmd.Bool("debug-runtime", false, "Serve goroutine, heap and GC statistics at /api/debug/runtime")
+	requireAPIKeyFlag := nodeCmd.Bool("require-api-key", false, "Refuse API requests without a valid X-API-Key header (health checks and signed admin requests excepted)")
+	exitDefaults := consensus.DefaultExitConfig()
+	epochLengthFlag := nodeCmd.Uint64("validator-epoch-length", exitDefaults.EpochLength, "Blocks per epoch; exiting validators leave the set and epoch rewards are paid out at epoch boundaries")
+	treasuryFlag := nodeCmd.String("epoch-reward-treasury", "", "Address receiving the treasury share of epoch rewards (must match across the network)")
+	treasuryShareFlag := nodeCmd.Uint64("epoch-reward-treasury-share", 0, "Treasury share of epoch rewards in basis points (must match across the network)")
+	minValidatorsFlag := nodeCmd.Int("min-validators", 0, "Active validator count below which the node enters emergency mode (0 = disabled)")
+	exitCooldownFlag := nodeCmd.Uint64("validator-exit-cooldown", exitDefaults.CooldownEpochs, "Epochs an exiting validator keeps validating")
+	commissionDefaults := consensus.DefaultCommissionConfig()
+	maxCommissionFlag := nodeCmd.Uint64("validator-max-commission", commissionDefaults.MaxRate, "Highest validator commission on delegated rewards in basis points, until changed by governance")
+	commissionNoticeFlag := nodeCmd.Uint64("validator-commission-notice", commissionDefaults.NoticeEpochs, "Epochs between announcing a commission raise and it taking effect, until changed by governance")
+	stakingDefaults := consensus.DefaultStakingConfig()
+	stakingFlag := nodeCmd.Bool("staking", true, "Let token holders delegate to validators and share in their rewards")
+	unbondingFlag := nodeCmd.Uint64("staking-unbonding-epochs", stakingDefaults.UnbondingEpochs, "Epochs undelegated tokens stay locked")
+	bftFlag := nodeCmd.Bool("bft", false, "Exchange pre-votes and pre-commits with the validators and finalize blocks with commit certificates")
+	minDelegationFlag := nodeCmd.String("staking-min-delegation", "", "Smallest amount (in base units) that can be delegated at once; empty allows any")
+	logFileFlag := nodeCmd.String("log-file", "", "Write logs to this file, relative paths inside the data directory (enables log rotation via the admin API)")
+	logLevelFlag := nodeCmd.String("log-level", "info", "Log level: debug, info, warn, error")
+	p2pDefaults := network.DefaultP2PConfig()
+	maxInboundFlag := nodeCmd.Int("p2p-max-inbound", p2pDefaults.MaxInboundConns, "Maximum concurrent inbound P2P connections")
+	maxInboundPerHostFlag := nodeCmd.Int("p2p-max-inbound-per-host", p2pDefaults.MaxInboundPerHost, "Maximum concurrent inbound P2P connections per host")
+	maxOutboundFlag := nodeCmd.Int("p2p-max-outbound", p2pDefaults.MaxOutboundPeers, "Maximum number of outbound peers")
+	maxMessageSizeFlag := nodeCmd.Int64("p2p-max-message-size", p2pDefaults.MaxMessageSize, "Maximum P2P message size in bytes")
+	messageRateFlag := nodeCmd.Float64("p2p-message-rate", p2pDefaults.MessagesPerSecond, "Allowed P2P messages per second per host (0 disables rate limiting)")
+	messageBurstFlag := nodeCmd.Int("p2p-message-burst", p2pDefaults.MessageBurst, "Allowed P2P message burst per host")
+	maxClockOffsetFlag := nodeCmd.Duration("p2p-max-clock-offset", p2pDefaults.MaxClockOffset, "Largest clock difference to the peers before warning and refusing validator mode (0 disables the check)")
+	backupDefaults := backup.DefaultConfig()
+	backupIntervalFlag := nodeCmd.Duration("backup-interval", backupDefaults.Interval, "Time between automatic state backups (0 disables them)")
+	backupKeepFlag := nodeCmd.Int("backup-keep", backupDefaults.KeepLast, "Number of most recent backups to keep (0 keeps all)")
+	backupMaxAgeFlag := nodeCmd.Duration("backup-max-age", backupDefaults.MaxAge, "Remove backups older than this, always keeping the newest (0 disables)")
+	backupStoreFlags := registerStoreFlags(nodeCmd, "backup", "confirmix/backups/")
+	watchdogDefaults := watchdog.DefaultConfig()
+	watchdogIntervalFlag := nodeCmd.Duration("watchdog-interval", watchdogDefaults.CheckInterval, "Time between chain health checks (0 disables the watchdog)")
+	watchdogStallFlag := nodeCmd.Int("watchdog-stall-factor", watchdogDefaults.StallFactor, "Block times without a block, while transactions are pending, before a stall alert")
+	watchdogForkChecksFlag := nodeCmd.Int("watchdog-fork-checks", watchdogDefaults.ForkChecks, "Consecutive health checks with conflicting peer blocks before a fork alert (0 disables)")
+	watchdogMempoolFlag := nodeCmd.Int("watchdog-max-mempool", watchdogDefaults.MaxMempool, "Pending transactions above which a mempool overflow alert is raised (0 disables)")
+	watchdogWebhookFlag := nodeCmd.String("watchdog-webhook", "", "URL receiving watchdog alerts as JSON POST requests")
+	watchdogRecoverFlag := nodeCmd.Bool("watchdog-auto-recover", false, "Resync from peers and rotate peers when block production stalls or the node forks")
+	chaosDefaults := chaos.DefaultConfig()
+	chaosFlag := nodeCmd.Bool("chaos", false, "Soak test: inject random disk delays, P2P message drops and clock skew and check chain invariants (development networks only)")
+	chaosSeedFlag := nodeCmd.Int64("chaos-seed", 0, "Seed of the injected faults, to repeat a run (0 picks one)")
+	chaosDiskDelayRateFlag := nodeCmd.Float64("chaos-disk-delay-rate", chaosDefaults.DiskDelayRate, "Share of state file writes that are delayed")
+	chaosMaxDiskDelayFlag := nodeCmd.Duration("chaos-max-disk-delay", chaosDefaults.MaxDiskDelay, "Longest delay of a state file write")
+	chaosDropRateFlag := nodeCmd.Float64("chaos-drop-rate", chaosDefaults.MessageDropRate, "Share of received P2P messages that are dropped")
+	chaosClockSkewFlag := nodeCmd.Duration("chaos-max-clock-skew", chaosDefaults.MaxClockSkew, "Largest offset of the node clock, changed every minute")
+	chaosInvariantIntervalFlag := nodeCmd.Duration("chaos-invariant-interval", chaosDefaults.InvariantInterval, "Time between chain invariant checks")
+	chaosHaltFlag := nodeCmd.Bool("chaos-halt", false, "Exit on the first violated chain invariant")
+	archiveDefaults := blockchain.DefaultArchiveConfig()
+	archiveFlag := nodeCmd.Bool("archive", false, "Offload old block bodies to the archive store and fetch them on demand")
+	archiveKeepRecentFlag := nodeCmd.Uint64("archive-keep-recent", archiveDefaults.KeepRecent, "Most recent blocks kept in full on local disk")
+	archiveSegmentSizeFlag := nodeCmd.Uint64("archive-segment-size", archiveDefaults.SegmentSize, "Blocks per archived object (do not change once blocks are archived)")
+	archiveCacheFlag := nodeCmd.Int("archive-cache-segments", archiveDefaults.CacheSegments, "Archived segments kept in memory after a historical query")
+	archiveIntervalFlag := nodeCmd.Duration("archive-interval", 10*time.Minute, "Time between archiving runs")
+	archiveStoreFlags := registerStoreFlags(nodeCmd, "archive", "confirmix/archive/")
 
 	// Parse command line arguments
 	if len(os.Args) < 2 {
-		fmt.Println("Expected 'node' subcommand")
+		fmt.Println("Expected 'node', 'backup', 'keys' or 'storage' subcommand")
 		os.Exit(1)
 	}
 
 	switch os.Args[1] {
 	case "node":
 		nodeCmd.Parse(os.Args[2:])
+	case "backup":
+		runBackupCommand(os.Args[2:])
+		return
+	case "keys":
+		runKeysCommand(os.Args[2:])
+		return
+	case "storage":
+		runStorageCommand(os.Args[2:])
+		return
 	default:
-		fmt.Println("Expected 'node' subcommand")
+		fmt.Println("Expected 'node', 'backup', 'keys' or 'storage' subcommand")
 		os.Exit(1)
 	}
 
+	// Configure logging
+	if level, err := logging.ParseLevel(*logLevelFlag); err == nil {
+		logging.SetLevel(level)
+	} else {
+		log.Printf("Warning: %v, defaulting to 'info'", err)
+	}
+
 	// Load or create configuration
 	config := &NodeConfig{
 		Address:           *addressFlag,
 		Port:              *portFlag,
+		APIPort:           *apiPortFlag,
 		IsValidator:       *validatorFlag,
 		PeerAddresses:     []string{},
 		GovernanceEnabled: *governanceFlag,
 		ValidatorMode:     *validatorModeFlag,
 		AdminAddress:      *adminAddressFlag,
+		ChainID:           *chainIDFlag,
 	}
 
 	if *configFlag != "" {
@@ -85,24 +223,154 @@ func main() {
 		}
 	}
 
+	// Every component keeps its state in the data directory, so nodes with
+	// their own directories and ports can run side by side on one machine
+	if *dataDirFlag != "" {
+		config.DataDir = *dataDirFlag
+	}
+	blockchain.SetDataDir(config.DataDir)
+	dataDir := blockchain.GetBlockchainDataPath()
+	if *logFileFlag != "" {
+		logFile := *logFileFlag
+		if !filepath.IsAbs(logFile) {
+			logFile = filepath.Join(dataDir, logFile)
+		}
+		if err := logging.SetOutputFile(logFile); err != nil {
+			log.Fatalf("Failed to open log file: %v", err)
+		}
+	}
+	log.Printf("Using data directory %s", dataDir)
+
 	// Parse peer addresses
 	if *peersFlag != "" {
 		config.PeerAddresses = strings.Split(*peersFlag, ",")
 	}
 
-	// Create or load private key
-	privateKey, err := loadOrCreatePrivateKey(config)
+	// A read replica follows its primary's block stream and serves reads only
+	if *replicaOfFlag != "" {
+		if config.Replica == nil {
+			config.Replica = replica.DefaultConfig()
+		}
+		config.Replica.PrimaryURL = *replicaOfFlag
+	}
+	if *replicaKeyFlag != "" && config.Replica != nil {
+		config.Replica.APIKey = *replicaKeyFlag
+	}
+	if config.Replica != nil {
+		config.Observer = true
+	}
+
+	// An observer syncs and serves reads only, so it never validates
+	if *observerFlag {
+		config.Observer = true
+	}
+	if config.Observer && config.IsValidator {
+		log.Printf("Warning: Observer mode ignores the validator setting")
+		config.IsValidator = false
+	}
+
+	// The diagnostics flags switch endpoints on in addition to the config file
+	if *pprofFlag || *runtimeStatsFlag {
+		if config.Debug == nil {
+			config.Debug = &api.DebugEndpoints{}
+		}
+		config.Debug.Pprof = config.Debug.Pprof || *pprofFlag
+		config.Debug.Runtime = config.Debug.Runtime || *runtimeStatsFlag
+	}
+
+	// Load the node, validator and admin keys; an observer only needs its node identity
+	roles := keystore.Roles
+	if config.Observer {
+		roles = []keystore.Role{keystore.RoleNode}
+	}
+	keystoreDir := *keystoreFlag
+	if keystoreDir == "" {
+		keystoreDir = keystore.DefaultDir()
+	}
+	keys, err := loadKeys(keystoreDir, config, roles)
 	if err != nil {
-		log.Fatalf("Failed to load or create private key: %v", err)
+		log.Fatalf("Failed to load keys: %v", err)
+	}
+
+	// Create the validator signer; the validator address follows its public key
+	var validatorSigner signer.Signer
+	var validatorPrivateKey *ecdsa.PrivateKey
+	nodeAddress := keys[keystore.RoleNode].NodeAddress()
+	if config.Observer {
+		log.Printf("Observer mode: no validator signer, mining and admin writes are disabled")
+	} else {
+		validatorKey := keys[keystore.RoleValidator]
+		validatorPrivateKey = validatorKey.PrivateKey
+		signerConfig := signer.DefaultConfig()
+		if config.ValidatorSigner != nil {
+			signerConfig = *config.ValidatorSigner
+		}
+		if *signerFlag != "" {
+			signerConfig.Driver = *signerFlag
+		}
+		if *signerKeyFlag != "" {
+			signerConfig.KeyID = *signerKeyFlag
+		}
+		if *pkcs11ModuleFlag != "" {
+			signerConfig.PKCS11Module = *pkcs11ModuleFlag
+		}
+		validatorSigner, err = signer.New(signerConfig, validatorKey.PrivateKey)
+		if err != nil {
+			log.Fatalf("Failed to create validator signer: %v", err)
+		}
+		nodeAddress = keystore.NodeAddress(validatorSigner.Public())
+		log.Printf("Validator signer: %s (validator %s)", validatorSigner.Driver(), nodeAddress)
+	}
+
+	// Bind signatures to this network before anything is signed
+	if err := blockchain.SetChainID(config.ChainID); err != nil {
+		log.Fatalf("Invalid chain ID: %v", err)
+	}
+	log.Printf("Chain ID: %s", config.ChainID)
+	if err := blockchain.SetGenesisActivations(config.FeatureActivations); err != nil {
+		log.Fatalf("Invalid feature activations: %v", err)
+	}
+	if config.RewardSchedule != nil {
+		if err := blockchain.SetGenesisRewardSchedule(*config.RewardSchedule); err != nil {
+			log.Fatalf("Invalid reward schedule: %v", err)
+		}
 	}
 
-	// Create node address from public key
-	publicKey := privateKey.PublicKey
-	publicKeyBytes := elliptic.Marshal(publicKey.Curve, publicKey.X, publicKey.Y)
-	nodeAddress := fmt.Sprintf("%x", publicKeyBytes[:10]) // Use first 10 bytes of public key as address
+	// Refuse to start on corrupted state, which would be overwritten
+	if report := blockchain.CheckStorage(dataDir); report.Corrupted {
+		printStorageReport(report)
+		log.Fatalf("Storage integrity check failed: %s", report.Summary())
+	}
 
 	// Create blockchain
 	bc := blockchain.NewBlockchain()
+	bc.SetMaxBlockTransactions(*maxBlockTxsFlag)
+	if err := bc.SetEpochRewardConfig(blockchain.EpochRewardConfig{
+		EpochLength:     *epochLengthFlag,
+		TreasuryAddress: *treasuryFlag,
+		TreasuryShare:   *treasuryShareFlag,
+	}); err != nil {
+		log.Fatalf("Invalid epoch reward settings: %v", err)
+	}
+	bc.SetSignatureWorkers(*sigWorkersFlag)
+	bc.SetDustPolicy(blockchain.DustPolicy{MinTransfer: *minTransferFlag, MinBalance: *dustThresholdFlag})
+	bc.SetCompactAccountsOnSnapshot(*gcOnSnapshotFlag)
+	if config.RewardSchedule != nil && *config.RewardSchedule != bc.RewardSchedule() {
+		log.Printf("Warning: reward_schedule is ignored, this chain keeps the schedule of its genesis block: %+v", bc.RewardSchedule())
+	}
+	if *gcKeepFlag != "" {
+		bc.SetAccountGCExemptions(strings.Split(*gcKeepFlag, ","))
+	}
+	if *richListExcludeFlag != "" {
+		config.RichListExclude = strings.Split(*richListExcludeFlag, ",")
+	}
+	bc.SetRichListExclusions(config.RichListExclude)
+	bc.SetPersistMempool(*persistMempoolFlag)
+	if restored, dropped, err := bc.LoadMempool(); err != nil {
+		log.Printf("Warning: Failed to restore the transaction pool: %v", err)
+	} else if restored > 0 || dropped > 0 {
+		log.Printf("Restored %d pending transactions, %d no longer valid were rejected", restored, dropped)
+	}
 
 	// Set up validator management
 	var validationMode consensus.ValidationMode
@@ -122,6 +390,28 @@ func main() {
 
 	// Initialize ValidatorManager with empty admin list (genesis will be added later)
 	validatorManager := consensus.NewValidatorManager(bc, []string{}, validationMode)
+	if *minStakeFlag != "" {
+		minStake, ok := new(big.Int).SetString(*minStakeFlag, 10)
+		if !ok || minStake.Sign() < 0 {
+			log.Fatalf("Invalid validator minimum stake: %s", *minStakeFlag)
+		}
+		validatorManager.SetMinStake(minStake)
+		log.Printf("Validators must lock a stake of %s", minStake.String())
+	}
+	exitConfig := consensus.DefaultExitConfig()
+	exitConfig.EpochLength = *epochLengthFlag
+	exitConfig.CooldownEpochs = *exitCooldownFlag
+	validatorManager.SetExitConfig(exitConfig)
+	commissionConfig := consensus.DefaultCommissionConfig()
+	commissionConfig.MaxRate = *maxCommissionFlag
+	commissionConfig.NoticeEpochs = *commissionNoticeFlag
+	if err := validatorManager.SetCommissionConfig(commissionConfig); err != nil {
+		log.Fatalf("Invalid validator commission settings: %v", err)
+	}
+	validatorManager.StartExitProcessor(30 * time.Second)
+	validatorManager.StartEvidenceProcessor(30 * time.Second)
+	validatorManager.SetMinValidators(*minValidatorsFlag)
+	validatorManager.StartEmergencyMonitor(30 * time.Second)
 	
 	// Add initial admin if specified
 	if config.AdminAddress != "" {
@@ -148,51 +438,222 @@ func main() {
 	if config.GovernanceEnabled {
 		governanceConfig := consensus.DefaultGovernanceConfig()
 		governanceSystem = consensus.NewGovernance(bc, validatorManager, tokenSystem, governanceConfig)
+		bc.SetGovernanceReader(governanceSystem)
 		log.Printf("Governance system initialized with default configuration")
 	}
 
+	// Initialize the delegation module
+	var stakingModule *consensus.Staking
+	if *stakingFlag {
+		stakingConfig := consensus.DefaultStakingConfig()
+		stakingConfig.UnbondingEpochs = *unbondingFlag
+		if *minDelegationFlag != "" {
+			minDelegation, ok := new(big.Int).SetString(*minDelegationFlag, 10)
+			if !ok || minDelegation.Sign() < 0 {
+				log.Fatalf("Invalid minimum delegation: %s", *minDelegationFlag)
+			}
+			stakingConfig.MinDelegation = minDelegation
+		}
+		stakingModule = consensus.NewStaking(bc, validatorManager, stakingConfig)
+	}
+
 	// Create consensus engine
-	hybridConsensus := consensus.NewHybridConsensus(bc, privateKey, nodeAddress, 15*time.Second)
+	consensusConfig := consensus.DefaultHybridConsensusConfig()
+	consensusConfig.BlockTime = 15 * time.Second
+	if *pohURLFlag != "" || *pohLocalSimulatorFlag {
+		consensusConfig.UseExternalPoh = true
+		consensusConfig.ExternalPohBaseURL = *pohURLFlag
+		consensusConfig.ExternalPohAPIKey = *pohAPIKeyFlag
+		consensusConfig.UsePoHSimulator = *pohLocalSimulatorFlag
+		if *pohLocalSimulatorFlag {
+			log.Printf("Using the in-process PoH simulator")
+		} else {
+			log.Printf("Using external PoH provider at %s", *pohURLFlag)
+		}
+	}
+	hybridConsensus := consensus.NewHybridConsensusWithConfig(bc, validatorPrivateKey, nodeAddress, consensusConfig)
+	if validatorSigner != nil {
+		hybridConsensus.SetSigner(validatorSigner)
+	}
+	// Degrade block production instead of halting while the validator set is too small
+	validatorManager.OnEmergencyChange(func(status consensus.EmergencyStatus) {
+		hybridConsensus.SetEmergencyMode(status.Active)
+	})
+	hybridConsensus.SetEmergencyMode(validatorManager.InEmergency())
 
 	// Create P2P network node
-	p2pNode := network.NewP2PNode(config.Address, config.Port, bc)
+	p2pConfig := network.DefaultP2PConfig()
+	p2pConfig.MaxInboundConns = *maxInboundFlag
+	p2pConfig.MaxInboundPerHost = *maxInboundPerHostFlag
+	p2pConfig.MaxOutboundPeers = *maxOutboundFlag
+	p2pConfig.MaxMessageSize = *maxMessageSizeFlag
+	p2pConfig.MessagesPerSecond = *messageRateFlag
+	p2pConfig.MessageBurst = *messageBurstFlag
+	p2pConfig.MaxClockOffset = *maxClockOffsetFlag
+	p2pNode := network.NewP2PNodeWithConfig(config.Address, config.Port, bc, p2pConfig)
+	p2pNode.SetNodeID(keys[keystore.RoleNode].NodeAddress())
+
+	// Inject faults and check invariants on soak test networks
+	if *chaosFlag {
+		injector, err := chaos.New(bc, &chaos.Config{
+			Seed:              *chaosSeedFlag,
+			DiskDelayRate:     *chaosDiskDelayRateFlag,
+			MaxDiskDelay:      *chaosMaxDiskDelayFlag,
+			MessageDropRate:   *chaosDropRateFlag,
+			MaxClockSkew:      *chaosClockSkewFlag,
+			SkewInterval:      chaosDefaults.SkewInterval,
+			InvariantInterval: *chaosInvariantIntervalFlag,
+			HaltOnViolation:   *chaosHaltFlag,
+		})
+		if err != nil {
+			log.Fatalf("Invalid chaos settings: %v", err)
+		}
+		if err := injector.Start(p2pNode); err != nil {
+			log.Fatalf("Failed to start chaos injection: %v", err)
+		}
+		defer injector.Stop()
+	}
 
-	// Initialize node
-	initializeNode(config, hybridConsensus, p2pNode, *pohVerifyFlag, validatorManager)
+	// Register the admin key so requests signed with it verify, then initialize
+	// the node; an observer has neither admin nor validator duties
+	if !config.Observer {
+		adminKey := keys[keystore.RoleAdmin]
+		bc.AddKeyPair(adminKey.Address(), adminKey.KeyPair())
+		initializeNode(config, hybridConsensus, p2pNode, *pohVerifyFlag, validatorManager, adminKey.Address())
+	}
 
-	// Start P2P node
-	err = p2pNode.Start()
-	if err != nil {
-		log.Fatalf("Failed to start P2P node: %v", err)
+	// Vote on blocks with the other validators; observers only collect votes
+	var bftEngine *consensus.BFT
+	if *bftFlag {
+		bftEngine = consensus.NewBFT(bc, nodeAddress, validatorSigner, p2pNode.BroadcastVote, nil)
+		p2pNode.OnVote(bftEngine.HandleVote)
+		bftEngine.Start()
+		defer bftEngine.Stop()
+	}
+
+	// A read replica takes blocks from its primary only and stays off the gossip network
+	var chainReplica *replica.Replica
+	if config.Replica != nil {
+		chainReplica, err = replica.New(bc, config.Replica)
+		if err != nil {
+			log.Fatalf("Invalid replica settings: %v", err)
+		}
+		if err := chainReplica.Start(); err != nil {
+			log.Fatalf("Failed to start replica: %v", err)
+		}
+		defer chainReplica.Stop()
+	} else {
+		// Start P2P node
+		err = p2pNode.Start()
+		if err != nil {
+			log.Fatalf("Failed to start P2P node: %v", err)
+		}
+		defer p2pNode.Stop()
 	}
-	defer p2pNode.Stop()
 
 	// Save configuration
 	saveConfig(config)
 
+	// Reconnect to peers from the persistent address book, falling back to the configured bootstrap peers.
+	// This measures the peers' clock offsets, which are checked before producing blocks.
+	if chainReplica == nil {
+		p2pNode.ReconnectKnownPeers(config.PeerAddresses)
+	}
+
 	// Handle node startup based on configuration
 	if config.IsValidator {
-		err = hybridConsensus.StartMining()
-		if err != nil {
-			log.Printf("Failed to start mining: %v", err)
-			if *pohVerifyFlag {
-				// Initiate human verification if needed
-				initiateHumanVerification(hybridConsensus)
+		if err := p2pNode.CheckClock(); err != nil {
+			log.Printf("Refusing validator mode: %v. Synchronize the system clock (e.g. with NTP) and restart the node", err)
+		} else {
+			err = hybridConsensus.StartMining()
+			if err != nil {
+				log.Printf("Failed to start mining: %v", err)
+				if *pohVerifyFlag {
+					// Initiate human verification if needed
+					initiateHumanVerification(hybridConsensus)
+				}
 			}
 		}
 	}
 
-	// Connect to known peers
-	for _, peerAddr := range config.PeerAddresses {
-		err = p2pNode.ConnectToPeer(peerAddr)
+	// Start API server if enabled
+	apiPort := config.APIPort
+	if apiPort == 0 {
+		apiPort = 8080 // Default API port
+	}
+	webServer := api.NewWebServer(bc, hybridConsensus, validatorManager, governanceSystem, apiPort)
+	webServer.SetP2PNode(p2pNode)
+	webServer.SetStaking(stakingModule)
+	webServer.SetBFT(bftEngine)
+	if chainReplica != nil {
+		webServer.SetReplica(chainReplica)
+	}
+	webServer.SetNodeConfig(config)
+	webServer.SetObserverMode(config.Observer)
+	webServer.SetRequireAPIKey(*requireAPIKeyFlag)
+
+	// Apply reloadable settings from the config file and reload them on SIGHUP
+	reloadPath := *configFlag
+	if reloadPath == "" {
+		reloadPath = filepath.Join(blockchain.GetBlockchainDataPath(), "config.json")
+	}
+	reloader := newConfigReloader(reloadPath, config, webServer, p2pNode)
+	if _, err := reloader.apply(config); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	webServer.SetConfigReloader(reloader.reload)
+	reloader.watchSignals()
+
+	// Offload old block ranges to the archive store
+	if *archiveFlag {
+		archiveStore, err := archiveStoreFlags.openStore()
 		if err != nil {
-			log.Printf("Failed to connect to peer %s: %v", peerAddr, err)
+			log.Fatalf("Failed to open archive store: %v", err)
 		}
+		bc.SetArchive(archiveStore, &blockchain.ArchiveConfig{
+			SegmentSize:   *archiveSegmentSizeFlag,
+			KeepRecent:    *archiveKeepRecentFlag,
+			CacheSegments: *archiveCacheFlag,
+		})
+		bc.StartArchiveRoutine(*archiveIntervalFlag)
+		log.Printf("Archiving blocks older than the latest %d to %s", *archiveKeepRecentFlag, archiveStore.Location())
+	}
+
+	// Start automatic backups
+	if *backupIntervalFlag > 0 {
+		backupStore, err := backupStoreFlags.openStore()
+		if err != nil {
+			log.Fatalf("Failed to open backup store: %v", err)
+		}
+		backupScheduler := backup.NewScheduler(bc, backupStore, &backup.Config{
+			Interval: *backupIntervalFlag,
+			KeepLast: *backupKeepFlag,
+			MaxAge:   *backupMaxAgeFlag,
+		})
+		if err := backupScheduler.Start(); err != nil {
+			log.Fatalf("Failed to start backup scheduler: %v", err)
+		}
+		defer backupScheduler.Stop()
+		webServer.SetBackupScheduler(backupScheduler)
+	}
+
+	// Watch chain health so outages raise alerts before users notice them
+	if *watchdogIntervalFlag > 0 {
+		chainWatchdog := watchdog.New(bc, p2pNode, &watchdog.Config{
+			CheckInterval: *watchdogIntervalFlag,
+			BlockTime:     consensusConfig.BlockTime,
+			StallFactor:   *watchdogStallFlag,
+			ForkChecks:    *watchdogForkChecksFlag,
+			MaxMempool:    *watchdogMempoolFlag,
+			AlertWebhook:  *watchdogWebhookFlag,
+			AutoRecover:   *watchdogRecoverFlag,
+		})
+		if err := chainWatchdog.Start(); err != nil {
+			log.Fatalf("Failed to start the watchdog: %v", err)
+		}
+		defer chainWatchdog.Stop()
+		webServer.SetWatchdog(chainWatchdog)
 	}
-	
-	// Start API server if enabled
-	apiPort := 8080 // Default API port
-	webServer := api.NewWebServer(bc, hybridConsensus, validatorManager, governanceSystem, apiPort)
 	go func() {
 		if err := webServer.Start(); err != nil {
 			log.Printf("API server error: %v", err)
@@ -202,50 +663,55 @@ func main() {
 
 	// Wait for interrupt signal
 	interruptChan := make(chan os.Signal, 1)
-	signal.Notify(interruptChan, os.Interrupt)
+	signal.Notify(interruptChan, os.Interrupt, syscall.SIGTERM)
 	<-interruptChan
 
-	// Cleanup
+	// Cleanup: finish the writes in flight before the state is saved
+	log.Printf("Shutting down, draining API requests")
+	if err := webServer.Drain(*drainTimeoutFlag); err != nil {
+		log.Printf("Warning: API shutdown: %v", err)
+	}
 	hybridConsensus.StopMining()
+	if err := bc.SaveToDisk(); err != nil {
+		log.Printf("Warning: Failed to save the blockchain state: %v", err)
+	}
+	if err := bc.SaveMempool(); err != nil {
+		log.Printf("Warning: Failed to save the transaction pool: %v", err)
+	}
 	fmt.Println("Blockchain node stopped")
 }
 
-// loadOrCreatePrivateKey loads an existing private key or creates a new one
-func loadOrCreatePrivateKey(config *NodeConfig) (*ecdsa.PrivateKey, error) {
+// loadKeys opens the keystore and returns the key of each given role, generating
+// missing ones. A private key left in the config by an older version is moved
+// into the keystore for the roles that have no key, so the node keeps its
+// addresses.
+func loadKeys(dir string, config *NodeConfig, roles []keystore.Role) (map[keystore.Role]*keystore.Key, error) {
+	ks, err := keystore.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+
 	if config.PrivateKeyPEM != "" {
-		// Load existing private key
-		block, _ := pem.Decode([]byte(config.PrivateKeyPEM))
-		if block == nil {
-			return nil, fmt.Errorf("failed to decode PEM block containing private key")
+		imported, err := ks.ImportMissing(config.PrivateKeyPEM, blockchain.KeyFormatPEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate private key from config: %v", err)
 		}
+		if len(imported) > 0 {
+			log.Printf("Moved the private key from the config into %s as the %v key", ks.Dir(), imported)
+		}
+		config.PrivateKeyPEM = ""
+	}
 
-		privateKey, err := x509.ParseECPrivateKey(block.Bytes)
+	keys := make(map[keystore.Role]*keystore.Key, len(roles))
+	for _, role := range roles {
+		key, err := ks.LoadOrGenerate(role)
 		if err != nil {
 			return nil, err
 		}
-
-		return privateKey, nil
-	}
-
-	// Create new private key
-	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	if err != nil {
-		return nil, err
+		keys[role] = key
+		log.Printf("Using %s key %s", role, key.Address())
 	}
-
-	// Encode private key to PEM
-	privateKeyBytes, err := x509.MarshalECPrivateKey(privateKey)
-	if err != nil {
-		return nil, err
-	}
-
-	privateKeyPEM := pem.EncodeToMemory(&pem.Block{
-		Type:  "EC PRIVATE KEY",
-		Bytes: privateKeyBytes,
-	})
-
-	config.PrivateKeyPEM = string(privateKeyPEM)
-	return privateKey, nil
+	return keys, nil
 }
 
 // saveConfig saves the node configuration to a file
@@ -256,9 +722,8 @@ func saveConfig(config *NodeConfig) {
 		return
 	}
 
-	// Create data directory if it doesn't exist
-	dataDir := "data"
-	os.MkdirAll(dataDir, 0755)
+	// The data directory is created if it doesn't exist
+	dataDir := blockchain.GetBlockchainDataPath()
 
 	configFile := filepath.Join(dataDir, "config.json")
 	err = ioutil.WriteFile(configFile, configData, 0644)
@@ -268,15 +733,12 @@ func saveConfig(config *NodeConfig) {
 }
 
 // initializeNode initializes the node based on configuration
-func initializeNode(config *NodeConfig, hybridConsensus *consensus.HybridConsensus, p2pNode *network.P2PNode, pohVerify bool, validatorManager *consensus.ValidatorManager) {
-	// Get genesis address from blockchain
-	genesisAddress := hybridConsensus.GetNodeAddress()
-	
-	// Initialize genesis address as admin
-	if err := validatorManager.InitializeFirstAdmin(genesisAddress); err != nil {
-		log.Printf("Failed to initialize genesis address as admin: %v", err)
+func initializeNode(config *NodeConfig, hybridConsensus *consensus.HybridConsensus, p2pNode *network.P2PNode, pohVerify bool, validatorManager *consensus.ValidatorManager, adminAddress string) {
+	// Initialize the admin key's address as admin
+	if err := validatorManager.InitializeFirstAdmin(adminAddress); err != nil {
+		log.Printf("Failed to initialize admin key address as admin: %v", err)
 	} else {
-		log.Printf("Genesis address initialized as admin: %s", genesisAddress)
+		log.Printf("Admin key address initialized as admin: %s", adminAddress)
 	}
 	
 	if config.IsValidator {
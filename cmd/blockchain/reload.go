@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/api"
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/logging"
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/network"
+)
+
+// configReloader re-reads the node configuration file and applies the settings
+// that can change while the node runs. Every setting is validated before any is
+// applied, so a bad file leaves the running configuration untouched.
+type configReloader struct {
+	path      string
+	current   *NodeConfig
+	webServer *api.WebServer
+	p2pNode   *network.P2PNode
+	mu        sync.Mutex
+}
+
+func newConfigReloader(path string, current *NodeConfig, webServer *api.WebServer, p2pNode *network.P2PNode) *configReloader {
+	return &configReloader{
+		path:      path,
+		current:   current,
+		webServer: webServer,
+		p2pNode:   p2pNode,
+	}
+}
+
+// watchSignals reloads the configuration whenever the process receives SIGHUP
+func (r *configReloader) watchSignals() {
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		for range hupChan {
+			log.Printf("SIGHUP received, reloading %s", r.path)
+			if _, err := r.reload(); err != nil {
+				log.Printf("Config reload failed, keeping the running configuration: %v", err)
+			}
+		}
+	}()
+}
+
+// reload reads the config file and applies its reloadable settings
+func (r *configReloader) reload() (interface{}, error) {
+	data, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", r.path, err)
+	}
+
+	var next NodeConfig
+	if err := json.Unmarshal(data, &next); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", r.path, err)
+	}
+
+	applied, err := r.apply(&next)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Configuration reloaded from %s: %v", r.path, applied)
+	return applied, nil
+}
+
+// apply validates the reloadable settings of next and then swaps them in.
+// It returns the names of the settings that were applied.
+func (r *configReloader) apply(next *NodeConfig) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Validate everything first
+	var level logging.Level
+	if next.LogLevel != "" {
+		parsed, err := logging.ParseLevel(next.LogLevel)
+		if err != nil {
+			return nil, err
+		}
+		level = parsed
+	}
+	if next.P2PLimits != nil {
+		if err := next.P2PLimits.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid p2p_limits: %v", err)
+		}
+	}
+	for _, peer := range next.PeerAddresses {
+		if _, _, err := net.SplitHostPort(peer); err != nil {
+			return nil, fmt.Errorf("invalid peer address %q: %v", peer, err)
+		}
+	}
+	if err := api.ValidateCORSOrigins(next.CORSOrigins); err != nil {
+		return nil, err
+	}
+	if err := api.ValidateRoutePolicies(next.RoutePolicies); err != nil {
+		return nil, fmt.Errorf("invalid route_policies: %v", err)
+	}
+	if next.AdminAccess != nil {
+		if err := api.ValidateAdminAccess(*next.AdminAccess); err != nil {
+			return nil, fmt.Errorf("invalid admin_access: %v", err)
+		}
+	}
+	if next.Compression != nil {
+		if err := api.ValidateCompression(*next.Compression); err != nil {
+			return nil, fmt.Errorf("invalid compression: %v", err)
+		}
+	}
+
+	// Then apply
+	applied := []string{}
+	if next.LogLevel != "" {
+		logging.SetLevel(level)
+		applied = append(applied, "log_level")
+	}
+	r.webServer.SetCORSOrigins(next.CORSOrigins)
+	applied = append(applied, "cors_origins")
+	r.webServer.SetRoutePolicies(next.RoutePolicies)
+	applied = append(applied, "route_policies")
+	r.webServer.SetAdminAccess(next.AdminAccess)
+	applied = append(applied, "admin_access")
+	r.webServer.SetDebugEndpoints(next.Debug)
+	applied = append(applied, "debug")
+	r.webServer.SetCompression(next.Compression)
+	applied = append(applied, "compression")
+	if next.P2PLimits != nil && r.p2pNode != nil {
+		r.p2pNode.SetRateLimits(*next.P2PLimits)
+		applied = append(applied, "p2p_limits")
+	}
+	if r.p2pNode != nil && !reflect.DeepEqual(next.PeerAddresses, r.current.PeerAddresses) {
+		connected := make(map[string]bool)
+		for _, peer := range r.p2pNode.GetPeers() {
+			connected[peer] = true
+		}
+		for _, peer := range next.PeerAddresses {
+			if connected[peer] {
+				continue
+			}
+			if err := r.p2pNode.ConnectToPeer(peer); err != nil {
+				log.Printf("Failed to connect to configured peer %s: %v", peer, err)
+			}
+		}
+		applied = append(applied, "peer_addresses")
+	}
+
+	// Settings that only take effect on restart are reported, not applied
+	if next.Address != "" && (next.Address != r.current.Address || next.Port != r.current.Port) {
+		log.Printf("Warning: Address and port changes in %s take effect after a restart", r.path)
+	}
+
+	r.current.LogLevel = next.LogLevel
+	r.current.CORSOrigins = next.CORSOrigins
+	r.current.RoutePolicies = next.RoutePolicies
+	r.current.AdminAccess = next.AdminAccess
+	r.current.Debug = next.Debug
+	r.current.Compression = next.Compression
+	r.current.P2PLimits = next.P2PLimits
+	r.current.PeerAddresses = next.PeerAddresses
+	return applied, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/blockchain"
+)
+
+// runStorageCommand implements "blockchain storage check|reindex". The node
+// must not be running while the state is reindexed.
+func runStorageCommand(args []string) {
+	storageCmd := flag.NewFlagSet("storage", flag.ExitOnError)
+	dataDirFlag := storageCmd.String("datadir", "", "Data directory of the node (default \"data\")")
+
+	if len(args) < 1 {
+		fmt.Println("Expected 'check' or 'reindex'")
+		os.Exit(1)
+	}
+	action := args[0]
+	storageCmd.Parse(args[1:])
+	blockchain.SetDataDir(*dataDirFlag)
+	dataDir := blockchain.GetBlockchainDataPath()
+
+	switch action {
+	case "check":
+		report := blockchain.CheckStorage(dataDir)
+		printStorageReport(report)
+		if report.Corrupted {
+			os.Exit(1)
+		}
+
+	case "reindex":
+		bc, err := blockchain.ReindexStorage(dataDir)
+		if err != nil {
+			log.Fatalf("Failed to reindex %s: %v", dataDir, err)
+		}
+		fmt.Printf("Rebuilt the state in %s from its blocks (height %d). Start the node to resume from it.\n", dataDir, bc.GetChainHeight())
+
+	default:
+		fmt.Printf("Unknown storage action '%s'; expected 'check' or 'reindex'\n", action)
+		os.Exit(1)
+	}
+}
+
+// printStorageReport prints the result of an integrity check
+func printStorageReport(report *blockchain.StorageReport) {
+	fmt.Printf("State files in %s:\n", report.DataDir)
+	for _, file := range report.Files {
+		line := fmt.Sprintf("  %-16s %s", file.Name, file.Status)
+		if file.Detail != "" {
+			line += ": " + file.Detail
+		}
+		fmt.Println(line)
+		if len(file.BadRecords) > 0 {
+			fmt.Printf("    invalid: %s\n", strings.Join(file.BadRecords, ", "))
+		}
+	}
+	if !report.Verified {
+		fmt.Println("No checksums were recorded; files were only parsed.")
+	}
+	if !report.Corrupted {
+		fmt.Println("State is intact.")
+		return
+	}
+	fmt.Println("State is corrupted. To recover:")
+	for _, option := range report.Recovery {
+		fmt.Printf("  - %s\n", option)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/consensussim"
+)
+
+func main() {
+	name := flag.String("scenario", "all", "Scenario to run, or \"all\"")
+	list := flag.Bool("list", false, "List the built-in scenarios and exit")
+	seed := flag.Int64("seed", 0, "Seed for network jitter (0 keeps each scenario's own seed)")
+	verbose := flag.Bool("v", false, "Trace the run on virtual time")
+	asJSON := flag.Bool("json", false, "Print the results as JSON")
+	flag.Parse()
+
+	if *list {
+		for _, scenario := range consensussim.Scenarios() {
+			fmt.Printf("%-20s %s\n", scenario.Name, scenario.Description)
+		}
+		return
+	}
+
+	scenarios := consensussim.Scenarios()
+	if *name != "all" {
+		scenario, exists := consensussim.Lookup(*name)
+		if !exists {
+			log.Fatalf("Unknown scenario %q, see -list", *name)
+		}
+		scenarios = []consensussim.Scenario{scenario}
+	}
+
+	var results []*consensussim.Result
+	failed := 0
+	for _, scenario := range scenarios {
+		if *seed != 0 {
+			scenario.Seed = *seed
+		}
+		sim, err := consensussim.New(scenario)
+		if err != nil {
+			log.Fatalf("Scenario %s: %v", scenario.Name, err)
+		}
+		if *verbose {
+			sim.Logf = func(format string, args ...interface{}) {
+				log.Printf("%s: "+format, append([]interface{}{scenario.Name}, args...)...)
+			}
+		}
+
+		result := sim.Run()
+		results = append(results, result)
+		if !result.Passed() {
+			failed++
+		}
+		if !*asJSON {
+			printResult(result)
+		}
+	}
+
+	if *asJSON {
+		out, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(out))
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// printResult prints the outcome of a scenario
+func printResult(r *consensussim.Result) {
+	verdict := "PASS"
+	if !r.Passed() {
+		verdict = "FAIL"
+	}
+	fmt.Printf("%s %s (%s simulated, %d forks, %d messages, %d dropped)\n",
+		verdict, r.Scenario, r.Duration, r.Forks, r.Delivered, r.Dropped)
+	for _, node := range r.Nodes {
+		state := "online"
+		if !node.Online {
+			state = "offline"
+		}
+		fmt.Printf("  %-12s %-7s height %-4d tip %.12s  produced %-3d forks %-2d reorgs %d (max %d)\n",
+			node.Address, state, node.Height, node.Tip, node.Produced, node.Forks, node.Reorgs, node.MaxReorg)
+	}
+	for _, v := range r.Violations {
+		fmt.Printf("  VIOLATION %s at %s: %s\n", v.Property, v.At, v.Detail)
+	}
+}
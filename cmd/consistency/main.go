@@ -0,0 +1,388 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stateDigest mirrors the node's GET /api/state/digest response
+type stateDigest struct {
+	Height     uint64   `json:"height"`
+	BlockHash  string   `json:"blockHash"`
+	StateHash  string   `json:"stateHash"`
+	Accounts   int      `json:"accounts"`
+	Validators []string `json:"validators"`
+}
+
+// NodeReport is what one node reported
+type NodeReport struct {
+	API        string   `json:"api"`
+	Error      string   `json:"error,omitempty"`
+	Height     uint64   `json:"height"`
+	BlockHash  string   `json:"blockHash,omitempty"`
+	StateHash  string   `json:"stateHash,omitempty"`
+	Accounts   int      `json:"accounts"`
+	Validators []string `json:"validators,omitempty"`
+}
+
+// Divergence is one disagreement between nodes
+type Divergence struct {
+	Kind   string            `json:"kind"` // height, block_hash, state_hash or validator_set
+	Height *uint64           `json:"height,omitempty"`
+	Detail string            `json:"detail"`
+	Values map[string]string `json:"values"` // node API -> the value it reported
+}
+
+// Report is the result of a consistency check
+type Report struct {
+	Nodes            []NodeReport `json:"nodes"`
+	CommonHeight     uint64       `json:"commonHeight"`
+	SampledHeights   []uint64     `json:"sampledHeights"`
+	EarliestMismatch *uint64      `json:"earliestMismatch,omitempty"` // first height whose block hashes differ
+	Divergences      []Divergence `json:"divergences"`
+	Consistent       bool         `json:"consistent"`
+}
+
+// checker queries the nodes under comparison
+type checker struct {
+	apis   []string
+	client *http.Client
+}
+
+func main() {
+	nodes := flag.String("nodes", "", "Comma-separated API base URLs of the nodes to compare, e.g. http://a:8080/api,http://b:8080/api")
+	samples := flag.Int("samples", 16, "Number of heights at which block hashes are compared")
+	maxLag := flag.Uint64("max-lag", 2, "Height difference between nodes tolerated before it is reported")
+	timeout := flag.Duration("timeout", 10*time.Second, "Timeout of each API request")
+	asJSON := flag.Bool("json", false, "Print the report as JSON")
+	flag.Parse()
+
+	var apis []string
+	for _, api := range strings.Split(*nodes, ",") {
+		if api = strings.TrimSuffix(strings.TrimSpace(api), "/"); api != "" {
+			apis = append(apis, api)
+		}
+	}
+	if len(apis) < 2 {
+		fmt.Println("Error: At least two nodes are required")
+		fmt.Println("Usage: consistency -nodes=<api_url>,<api_url>[,...] [-samples=16] [-max-lag=2] [-json]")
+		os.Exit(1)
+	}
+
+	c := &checker{apis: apis, client: &http.Client{Timeout: *timeout}}
+	report, err := c.run(*samples, *maxLag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		printReport(report)
+	}
+	if !report.Consistent {
+		os.Exit(2)
+	}
+}
+
+// run compares the nodes. It fails only when fewer than two nodes answer.
+func (c *checker) run(samples int, maxLag uint64) (*Report, error) {
+	report := &Report{Nodes: make([]NodeReport, len(c.apis)), Divergences: []Divergence{}}
+	c.each(c.apis, func(i int, api string) {
+		node := NodeReport{API: api}
+		var digest stateDigest
+		if err := c.get(api+"/state/digest", &digest); err != nil {
+			node.Error = err.Error()
+		} else {
+			node.Height, node.BlockHash, node.StateHash = digest.Height, digest.BlockHash, digest.StateHash
+			node.Accounts, node.Validators = digest.Accounts, digest.Validators
+		}
+		report.Nodes[i] = node
+	})
+
+	var live []NodeReport
+	for _, node := range report.Nodes {
+		if node.Error == "" {
+			live = append(live, node)
+		}
+	}
+	if len(live) < 2 {
+		return nil, fmt.Errorf("only %d of %d nodes answered", len(live), len(c.apis))
+	}
+
+	c.compareHeights(report, live, maxLag)
+	if err := c.compareBlocks(report, live, samples); err != nil {
+		return nil, err
+	}
+	compareStates(report, live)
+	compareValidators(report, live)
+	report.Consistent = len(report.Divergences) == 0
+	return report, nil
+}
+
+// compareHeights reports nodes lagging the highest node by more than maxLag
+func (c *checker) compareHeights(report *Report, live []NodeReport, maxLag uint64) {
+	lowest, highest := live[0].Height, live[0].Height
+	values := make(map[string]string, len(live))
+	for _, node := range live {
+		if node.Height < lowest {
+			lowest = node.Height
+		}
+		if node.Height > highest {
+			highest = node.Height
+		}
+		values[node.API] = fmt.Sprint(node.Height)
+	}
+	report.CommonHeight = lowest
+	if highest-lowest > maxLag {
+		report.Divergences = append(report.Divergences, Divergence{
+			Kind:   "height",
+			Detail: fmt.Sprintf("heights differ by %d blocks (tolerance %d)", highest-lowest, maxLag),
+			Values: values,
+		})
+	}
+}
+
+// compareBlocks compares block hashes at sampled heights up to the common
+// height. Blocks are hash-linked, so once two chains differ they differ at
+// every later height, and the earliest mismatch is found by bisecting between
+// the last agreeing sample and the first disagreeing one.
+func (c *checker) compareBlocks(report *Report, live []NodeReport, samples int) error {
+	apis := make([]string, len(live))
+	for i, node := range live {
+		apis[i] = node.API
+	}
+
+	report.SampledHeights = sampleHeights(report.CommonHeight, samples)
+	agreed, haveAgreed := uint64(0), false
+	for _, height := range report.SampledHeights {
+		hashes, err := c.blockHashes(apis, height)
+		if err != nil {
+			return err
+		}
+		if allEqual(hashes) {
+			agreed, haveAgreed = height, true
+			continue
+		}
+
+		// Bisect (agreed, height] for the first disagreeing height
+		lo, hi := agreed, height
+		if !haveAgreed {
+			lo, hi = 0, 0
+		}
+		for lo+1 < hi {
+			mid := lo + (hi-lo)/2
+			midHashes, err := c.blockHashes(apis, mid)
+			if err != nil {
+				return err
+			}
+			if allEqual(midHashes) {
+				lo = mid
+			} else {
+				hi, hashes = mid, midHashes
+			}
+		}
+
+		earliest := hi
+		report.EarliestMismatch = &earliest
+		report.Divergences = append(report.Divergences, Divergence{
+			Kind:   "block_hash",
+			Height: &earliest,
+			Detail: fmt.Sprintf("chains diverge at block %d", earliest),
+			Values: hashes,
+		})
+		return nil
+	}
+	return nil
+}
+
+// compareStates compares state hashes of nodes at the same height. Nodes at
+// different heights cannot be compared.
+func compareStates(report *Report, live []NodeReport) {
+	byHeight := make(map[uint64]map[string]string)
+	for _, node := range live {
+		if byHeight[node.Height] == nil {
+			byHeight[node.Height] = make(map[string]string)
+		}
+		byHeight[node.Height][node.API] = node.StateHash
+	}
+
+	heights := make([]uint64, 0, len(byHeight))
+	for height := range byHeight {
+		heights = append(heights, height)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	for _, height := range heights {
+		hashes := byHeight[height]
+		if len(hashes) < 2 || allEqual(hashes) {
+			continue
+		}
+		h := height
+		report.Divergences = append(report.Divergences, Divergence{
+			Kind:   "state_hash",
+			Height: &h,
+			Detail: fmt.Sprintf("state differs between nodes at height %d", height),
+			Values: hashes,
+		})
+	}
+}
+
+// compareValidators compares the active validator sets
+func compareValidators(report *Report, live []NodeReport) {
+	sets := make(map[string]string, len(live))
+	for _, node := range live {
+		sets[node.API] = strings.Join(node.Validators, ",")
+	}
+	if !allEqual(sets) {
+		report.Divergences = append(report.Divergences, Divergence{
+			Kind:   "validator_set",
+			Detail: "nodes disagree on the active validator set",
+			Values: sets,
+		})
+	}
+}
+
+// blockHashes fetches the hash of the block at height from every node
+func (c *checker) blockHashes(apis []string, height uint64) (map[string]string, error) {
+	hashes := make(map[string]string, len(apis))
+	errs := make([]error, len(apis))
+	var mu sync.Mutex
+	c.each(apis, func(i int, api string) {
+		var block struct {
+			Hash string `json:"Hash"`
+		}
+		if err := c.get(fmt.Sprintf("%s/blocks/%d", api, height), &block); err != nil {
+			errs[i] = fmt.Errorf("%s: block %d: %w", api, height, err)
+			return
+		}
+		mu.Lock()
+		hashes[api] = block.Hash
+		mu.Unlock()
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
+
+// each calls fn for every API concurrently and waits for all calls
+func (c *checker) each(apis []string, fn func(i int, api string)) {
+	var wg sync.WaitGroup
+	for i, api := range apis {
+		wg.Add(1)
+		go func(i int, api string) {
+			defer wg.Done()
+			fn(i, api)
+		}(i, api)
+	}
+	wg.Wait()
+}
+
+// get decodes the JSON response of a GET request into v
+func (c *checker) get(url string, v interface{}) error {
+	resp, err := c.client.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to connect to API: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// sampleHeights spreads n heights evenly over [0, top], always including both ends
+func sampleHeights(top uint64, n int) []uint64 {
+	if n < 2 {
+		n = 2
+	}
+	if uint64(n) > top+1 {
+		n = int(top + 1)
+	}
+	heights := make([]uint64, 0, n)
+	for i := 0; i < n; i++ {
+		var height uint64
+		if n > 1 {
+			height = top * uint64(i) / uint64(n-1)
+		}
+		if len(heights) == 0 || heights[len(heights)-1] != height {
+			heights = append(heights, height)
+		}
+	}
+	return heights
+}
+
+// allEqual reports whether every value in the map is the same
+func allEqual(values map[string]string) bool {
+	first, set := "", false
+	for _, v := range values {
+		if !set {
+			first, set = v, true
+		} else if v != first {
+			return false
+		}
+	}
+	return true
+}
+
+// printReport prints a report for operators
+func printReport(report *Report) {
+	fmt.Println("Nodes:")
+	for _, node := range report.Nodes {
+		if node.Error != "" {
+			fmt.Printf("  %-32s UNREACHABLE: %s\n", node.API, node.Error)
+			continue
+		}
+		fmt.Printf("  %-32s height %-8d block %s  state %s  validators %d\n",
+			node.API, node.Height, short(node.BlockHash), short(node.StateHash), len(node.Validators))
+	}
+	fmt.Printf("Common height:     %d\n", report.CommonHeight)
+	fmt.Printf("Sampled heights:   %d (%d..%d)\n", len(report.SampledHeights),
+		report.SampledHeights[0], report.SampledHeights[len(report.SampledHeights)-1])
+	if report.EarliestMismatch != nil {
+		fmt.Printf("Earliest mismatch: block %d\n", *report.EarliestMismatch)
+	}
+
+	if report.Consistent {
+		fmt.Println("Result:            consistent")
+		return
+	}
+	fmt.Printf("Result:            %d divergence(s)\n", len(report.Divergences))
+	for _, d := range report.Divergences {
+		fmt.Printf("\n[%s] %s\n", d.Kind, d.Detail)
+		apis := make([]string, 0, len(d.Values))
+		for api := range d.Values {
+			apis = append(apis, api)
+		}
+		sort.Strings(apis)
+		for _, api := range apis {
+			value := d.Values[api]
+			if d.Kind != "validator_set" {
+				value = short(value)
+			}
+			fmt.Printf("  %-32s %s\n", api, value)
+		}
+	}
+}
+
+// short abbreviates a hash for display
+func short(hash string) string {
+	if len(hash) > 16 {
+		return hash[:16] + "…"
+	}
+	return hash
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/ConfirmixLabs/Confirmix-Labs/pkg/pohsim"
+)
+
+func main() {
+	defaults := pohsim.DefaultConfig()
+
+	// Command line flags
+	port := flag.Int("port", defaults.Port, "Port to listen on")
+	apiKey := flag.String("api-key", "", "API key clients must send as a bearer token (empty disables the check)")
+	latency := flag.Duration("latency", 0, "Latency added to every request, e.g. 200ms")
+	jitter := flag.Duration("jitter", 0, "Random extra latency up to this value")
+	failureRate := flag.Float64("failure-rate", 0, "Fraction of API requests answered with 503 (0-1)")
+	rejectRate := flag.Float64("reject-rate", 0, "Fraction of verifications rejected as not human (0-1)")
+	autoVerify := flag.Bool("auto-verify", false, "Complete verifications immediately on initiate")
+	tokenTTL := flag.Duration("token-ttl", defaults.TokenTTL, "How long an unfinished verification stays valid (0 never expires)")
+	statePath := flag.String("state", defaults.StatePath, "File to persist verification state to (empty disables persistence)")
+	flag.Parse()
+
+	if *failureRate < 0 || *failureRate > 1 || *rejectRate < 0 || *rejectRate > 1 {
+		log.Fatalf("failure-rate and reject-rate must be between 0 and 1")
+	}
+
+	config := &pohsim.Config{
+		Port:        *port,
+		APIKey:      *apiKey,
+		Latency:     *latency,
+		Jitter:      *jitter,
+		FailureRate: *failureRate,
+		RejectRate:  *rejectRate,
+		AutoVerify:  *autoVerify,
+		TokenTTL:    *tokenTTL,
+		StatePath:   *statePath,
+	}
+
+	server := pohsim.NewServer(config)
+	if err := server.ListenAndServe(); err != nil {
+		log.Fatalf("PoH simulator stopped: %v", err)
+	}
+}
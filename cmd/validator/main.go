@@ -21,16 +21,24 @@ type ValidatorInfo struct {
 }
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "status" {
+		runStatus(os.Args[2:])
+		return
+	}
+
 	// Command line flags
 	address := flag.String("address", "", "Validator address (wallet address to use for validation)")
 	apiURL := flag.String("api", "http://localhost:8080/api", "API base URL of the blockchain node")
 	interval := flag.Int("interval", 10, "Validation interval in seconds")
+	maxBatch := flag.Int("max-batch", 0, "Maximum transactions per mined block, 0 for the node's block capacity")
 	flag.Parse()
 
 	// Validate inputs
 	if *address == "" {
 		fmt.Println("Error: Validator address is required")
 		fmt.Println("Usage: validator -address=<validator_address> [-api=<api_url>] [-interval=<seconds>]")
+		fmt.Println("       validator status -address=<validator_address> [-api=<api_url>] [-json]")
 		os.Exit(1)
 	}
 
@@ -46,6 +54,7 @@ func main() {
 
 	// Create validator
 	validatorNode := validator.NewValidator(*apiURL, *address, time.Duration(*interval)*time.Second)
+	validatorNode.SetMaxBatch(*maxBatch)
 
 	// Start validator
 	if err := validatorNode.Start(); err != nil {
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// validatorStatus mirrors the node's GET /api/validators/status/{address} response
+type validatorStatus struct {
+	Address          string     `json:"address"`
+	Registered       bool       `json:"registered"`
+	Status           string     `json:"status"`
+	InActiveSet      bool       `json:"inActiveSet"`
+	ApprovedBy       string     `json:"approvedBy"`
+	JoinedAt         *time.Time `json:"joinedAt"`
+	PerformanceScore float64    `json:"performanceScore"`
+	TotalBlocks      uint64     `json:"totalBlocks"`
+	LastActive       *time.Time `json:"lastActive"`
+	HumanProof       struct {
+		Verified         bool   `json:"verified"`
+		ExpiresAt        int64  `json:"expiresAt"`
+		SecondsRemaining int64  `json:"secondsRemaining"`
+		OnChain          bool   `json:"onChain"`
+		Provider         string `json:"provider"`
+		RecordedAt       uint64 `json:"recordedAt"`
+	} `json:"humanProof"`
+	NextSlot *struct {
+		TurnsAway  int   `json:"turnsAway"`
+		EtaSeconds int64 `json:"etaSeconds"`
+	} `json:"nextSlot"`
+	LastBlock *struct {
+		Index     uint64 `json:"index"`
+		Hash      string `json:"hash"`
+		Timestamp int64  `json:"timestamp"`
+		Age       int64  `json:"ageSeconds"`
+	} `json:"lastBlock"`
+	ChainHeight uint64 `json:"chainHeight"`
+	IsLocalNode bool   `json:"isLocalNode"`
+}
+
+// runStatus implements `validator status`: a one-shot health view of a validator.
+// It exits with 1 when the node cannot be queried and with 2 when the
+// validator cannot produce blocks.
+func runStatus(args []string) {
+	statusCmd := flag.NewFlagSet("status", flag.ExitOnError)
+	address := statusCmd.String("address", "", "Validator address to inspect")
+	apiURL := statusCmd.String("api", "http://localhost:8080/api", "API base URL of the blockchain node")
+	asJSON := statusCmd.Bool("json", false, "Print the raw status as JSON")
+	statusCmd.Parse(args)
+
+	if *address == "" {
+		fmt.Println("Error: Validator address is required")
+		fmt.Println("Usage: validator status -address=<validator_address> [-api=<api_url>] [-json]")
+		os.Exit(1)
+	}
+
+	status, raw, err := fetchValidatorStatus(*apiURL, *address)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		os.Stdout.Write(raw)
+	} else {
+		printStatus(status)
+	}
+	if !status.InActiveSet || !status.HumanProof.Verified && status.IsLocalNode {
+		os.Exit(2)
+	}
+}
+
+// fetchValidatorStatus queries the node for the status of address
+func fetchValidatorStatus(apiURL, address string) (*validatorStatus, []byte, error) {
+	statusURL := fmt.Sprintf("%s/validators/status/%s", strings.TrimSuffix(apiURL, "/"), address)
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(statusURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode status: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, raw)
+	}
+
+	var status validatorStatus
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode status: %w", err)
+	}
+	return &status, append(raw, '\n'), nil
+}
+
+// printStatus prints a validator status for operators
+func printStatus(s *validatorStatus) {
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+
+	fmt.Printf("Validator:        %s\n", s.Address)
+	if s.IsLocalNode {
+		fmt.Printf("                  (this node's validator)\n")
+	}
+	fmt.Printf("Registration:     %s\n", s.Status)
+	if s.ApprovedBy != "" {
+		fmt.Printf("Approved by:      %s\n", s.ApprovedBy)
+	}
+	if s.JoinedAt != nil {
+		fmt.Printf("Joined:           %s\n", s.JoinedAt.Format(time.RFC3339))
+	}
+	fmt.Printf("Active set:       %s\n", yesNo(s.InActiveSet))
+
+	switch {
+	case s.HumanProof.Verified:
+		expires := time.Unix(s.HumanProof.ExpiresAt, 0)
+		fmt.Printf("PoH proof:        valid until %s (in %s)\n", expires.Format(time.RFC3339),
+			(time.Duration(s.HumanProof.SecondsRemaining) * time.Second).String())
+	case s.HumanProof.ExpiresAt > 0:
+		fmt.Printf("PoH proof:        EXPIRED at %s\n", time.Unix(s.HumanProof.ExpiresAt, 0).Format(time.RFC3339))
+	default:
+		fmt.Printf("PoH proof:        no verification known to the node\n")
+	}
+	if s.HumanProof.OnChain {
+		fmt.Printf("On-chain proof:   recorded at block %d", s.HumanProof.RecordedAt)
+		if s.HumanProof.Provider != "" {
+			fmt.Printf(" (provider %s)", s.HumanProof.Provider)
+		}
+		fmt.Println()
+	} else {
+		fmt.Printf("On-chain proof:   not recorded\n")
+	}
+
+	if s.NextSlot != nil {
+		fmt.Printf("Next slot:        in %d turns (~%ds)\n", s.NextSlot.TurnsAway, s.NextSlot.EtaSeconds)
+	} else {
+		fmt.Printf("Next slot:        not scheduled\n")
+	}
+	fmt.Printf("Performance:      %.1f/100\n", s.PerformanceScore)
+	fmt.Printf("Blocks produced:  %d\n", s.TotalBlocks)
+	if s.LastBlock != nil {
+		fmt.Printf("Last block:       #%d %s (%s ago)\n", s.LastBlock.Index, s.LastBlock.Hash,
+			(time.Duration(s.LastBlock.Age) * time.Second).String())
+	} else {
+		fmt.Printf("Last block:       none produced\n")
+	}
+	fmt.Printf("Chain height:     %d\n", s.ChainHeight)
+}
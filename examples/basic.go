@@ -78,12 +78,9 @@ func main() {
 		log.Fatalf("Failed to register validator 2: %v", err)
 	}
 
+	// Both engines pick up the new validators from the chain's validator set
 	fmt.Println("Both validators registered")
 
-	// Update validator list
-	consensus1.UpdateValidatorList([]string{validator1Address, validator2Address})
-	consensus2.UpdateValidatorList([]string{validator1Address, validator2Address})
-
 	// Create a transaction
 	tx := &blockchain.Transaction{
 		ID:        "tx1",
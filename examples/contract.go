@@ -102,7 +102,6 @@ func main() {
 		log.Fatalf("Failed to register validator: %v", err)
 	}
 
-	validatorConsensus.UpdateValidatorList([]string{validatorAddress})
 	fmt.Println("Validator registered and ready")
 
 	// Create a contract deployment transaction
@@ -89,9 +89,6 @@ func main() {
 
 	fmt.Println("Registered as validator")
 
-	// Update validator list
-	validatorConsensus.UpdateValidatorList([]string{validatorAddress})
-
 	// Create a transaction
 	tx := blockchain.NewTransaction(
 		userAddress,
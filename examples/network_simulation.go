@@ -103,6 +103,7 @@ func main() {
 	// Human verification and validator registration
 	fmt.Println("\nPerforming human verification for validators...")
 	var validatorAddresses []string
+	var validatorProofs []string
 	
 	for i, node := range nodes {
 		if node.IsValidator {
@@ -129,16 +130,25 @@ func main() {
 			}
 			
 			validatorAddresses = append(validatorAddresses, node.Address)
+			validatorProofs = append(validatorProofs, proofToken)
 			fmt.Printf("Node %d (Address=%s) verified as human and registered as validator\n", 
 				i, node.Address)
 		}
 	}
 	
-	// Update validator list on all nodes
-	fmt.Println("\nUpdating validator lists on all nodes...")
+	// Register the validators on every node's chain; the consensus engines
+	// follow their chain's validator set
+	fmt.Println("\nRegistering validators on all nodes...")
 	for i, node := range nodes {
-		node.ConsensusEngine.UpdateValidatorList(validatorAddresses)
-		fmt.Printf("Updated validator list on node %d\n", i)
+		for j, address := range validatorAddresses {
+			if address == node.Address {
+				continue
+			}
+			if err := node.Blockchain.RegisterValidator(address, validatorProofs[j]); err != nil {
+				log.Printf("Failed to register validator %s on node %d: %v", address, i, err)
+			}
+		}
+		fmt.Printf("Registered validators on node %d\n", i)
 	}
 	
 	// Create transactions from non-validator nodes
@@ -4,4 +4,4 @@ go 1.24.1
 
 require github.com/gorilla/mux v1.8.1
 
-require github.com/google/uuid v1.6.0 // indirect
+require github.com/google/uuid v1.6.0
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"confirmix/pkg/blockchain"
+)
+
+// resolveRecipient returns the recipient of a transfer from from: to when
+// given, otherwise the address of the contact named in the address book of
+// the request
+func (ws *WebServer) resolveRecipient(r *http.Request, from, to, contact string) (string, error) {
+	switch {
+	case to != "" && contact != "":
+		return "", fmt.Errorf("%w: give either a recipient address or a contact, not both", blockchain.ErrInvalidContact)
+	case contact == "":
+		return to, nil
+	}
+	owner, err := ws.requestOwner(r, from)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := ws.wallets.ResolveContact(owner, contact)
+	if err != nil {
+		return "", err
+	}
+	return resolved.Address, nil
+}
+
+// getContacts handles GET /api/wallet/contacts, listing the address book of
+// the API key or of the unlocked wallet given with ?wallet=
+func (ws *WebServer) getContacts(w http.ResponseWriter, r *http.Request) {
+	owner, err := ws.requestOwner(r, r.URL.Query().Get("wallet"))
+	if err != nil {
+		writeError(w, err, http.StatusUnauthorized)
+		return
+	}
+	contacts := ws.wallets.Contacts(owner)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"contacts": contacts,
+		"count":    len(contacts),
+	})
+}
+
+// saveContact handles POST /api/wallet/contacts, adding a contact or
+// replacing the contact of the same name
+func (ws *WebServer) saveContact(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Wallet  string `json:"wallet,omitempty"` // address book owner for requests without an API key
+		Name    string `json:"name"`
+		Address string `json:"address"`
+		Note    string `json:"note,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
+		return
+	}
+	owner, err := ws.requestOwner(r, req.Wallet)
+	if err != nil {
+		writeError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	contact, err := ws.wallets.SaveContact(owner, blockchain.Contact{
+		Name:    req.Name,
+		Address: req.Address,
+		Note:    req.Note,
+	})
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, contact)
+}
+
+// removeContact handles POST /api/wallet/contacts/remove
+func (ws *WebServer) removeContact(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Wallet string `json:"wallet,omitempty"`
+		Name   string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
+		return
+	}
+	owner, err := ws.requestOwner(r, req.Wallet)
+	if err != nil {
+		writeError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	if err := ws.wallets.RemoveContact(owner, req.Name); err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"name":    req.Name,
+	})
+}
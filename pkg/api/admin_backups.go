@@ -0,0 +1,103 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"confirmix/pkg/backup"
+)
+
+// Actions that must be signed for the backup endpoints
+const (
+	ActionBackupList   = "node_backup_list"
+	ActionBackupCreate = "node_backup_create"
+	ActionBackupVerify = "node_backup_verify"
+)
+
+// errBackupsDisabled is returned when the node runs without a backup scheduler
+var errBackupsDisabled = errors.New("backups are not configured on this node")
+
+// SetBackupScheduler attaches the backup scheduler managed through the admin API
+func (ws *WebServer) SetBackupScheduler(scheduler *backup.Scheduler) {
+	ws.node.backups = scheduler
+}
+
+// listBackups handles listing the backups held by the configured store
+func (ws *WebServer) listBackups(w http.ResponseWriter, r *http.Request) {
+	if req := ws.decodeAdminRequest(w, r, ActionBackupList); req == nil {
+		return
+	}
+	if ws.node.backups == nil {
+		writeError(w, errBackupsDisabled, http.StatusServiceUnavailable)
+		return
+	}
+
+	backups, err := backup.List(ws.node.backups.Store())
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to list backups: %w", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"scheduler": ws.node.backups.Status(),
+		"backups":   backups,
+		"count":     len(backups),
+	})
+}
+
+// createBackup handles taking a backup immediately, outside the schedule
+func (ws *WebServer) createBackup(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionBackupCreate)
+	if req == nil {
+		return
+	}
+	if ws.node.backups == nil {
+		writeError(w, errBackupsDisabled, http.StatusServiceUnavailable)
+		return
+	}
+
+	info, err := ws.node.backups.RunOnce()
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to create backup: %w", err), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Admin %s created backup %s", req.AdminAddress, info.Name)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"backup": info,
+	})
+}
+
+// verifyBackup handles checking the integrity of a stored backup.
+// Data: {"name": "backup-..."}
+func (ws *WebServer) verifyBackup(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionBackupVerify)
+	if req == nil {
+		return
+	}
+	if ws.node.backups == nil {
+		writeError(w, errBackupsDisabled, http.StatusServiceUnavailable)
+		return
+	}
+
+	name := req.Data["name"]
+	if name == "" {
+		writeError(w, errors.New("missing backup name in request data"), http.StatusBadRequest)
+		return
+	}
+
+	manifest, err := backup.Verify(ws.node.backups.Store(), name)
+	if err != nil {
+		writeError(w, fmt.Errorf("backup verification failed: %w", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":   "valid",
+		"name":     name,
+		"manifest": manifest,
+	})
+}
@@ -0,0 +1,456 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"confirmix/pkg/backup"
+	"confirmix/pkg/logging"
+	"confirmix/pkg/network"
+	"confirmix/pkg/replica"
+	"confirmix/pkg/signer"
+	"confirmix/pkg/types"
+	"confirmix/pkg/watchdog"
+)
+
+// Actions that must be signed for the node management endpoints.
+// The action is part of the signed message, so a signature for one action
+// cannot be replayed against another endpoint.
+const (
+	ActionNodeSnapshot     = "node_snapshot"
+	ActionNodeMining       = "node_mining"
+	ActionNodeRotateLogs   = "node_rotate_logs"
+	ActionNodeLogLevel     = "node_log_level"
+	ActionNodeBanPeer      = "node_ban_peer"
+	ActionNodeUnbanPeer    = "node_unban_peer"
+	ActionNodeViewConfig   = "node_view_config"
+	ActionNodeReloadConfig = "node_reload_config"
+	ActionNodeCompact      = "node_compact_accounts"
+)
+
+// redactedValue replaces secret values in the config view
+const redactedValue = "[REDACTED]"
+
+// secretKeyFragments mark config keys whose values must never be returned
+var secretKeyFragments = []string{"private", "secret", "password", "passphrase", "token", "api_key", "apikey", "mnemonic", "seed"}
+
+// nodeControl holds the runtime switches and handles used by the node management API
+type nodeControl struct {
+	p2pNode      *network.P2PNode
+	nodeConfig   interface{}
+	backups      *backup.Scheduler
+	watchdog     *watchdog.Watchdog
+	replica      *replica.Replica // set on a read replica, see replica.go
+	reloadConfig func() (interface{}, error)
+	miningPaused int32 // 1 when /api/mine is disabled by an admin
+}
+
+// SetP2PNode attaches the P2P node so admins can manage peers through the API
+func (ws *WebServer) SetP2PNode(node *network.P2PNode) {
+	ws.node.p2pNode = node
+}
+
+// SetNodeConfig attaches the node configuration exposed (redacted) by the config endpoint
+func (ws *WebServer) SetNodeConfig(config interface{}) {
+	ws.node.nodeConfig = config
+}
+
+// SetConfigReloader attaches the function that reloads the node configuration.
+// It is called by the reload endpoint and returns a summary of the applied changes.
+func (ws *WebServer) SetConfigReloader(reload func() (interface{}, error)) {
+	ws.node.reloadConfig = reload
+}
+
+// isMiningPaused reports whether an admin has disabled mining on this node
+func (ws *WebServer) isMiningPaused() bool {
+	return atomic.LoadInt32(&ws.node.miningPaused) == 1
+}
+
+// decodeAdminRequest decodes and verifies a signed admin request for the given action.
+// It writes the error response itself and returns nil when the request is rejected.
+func (ws *WebServer) decodeAdminRequest(w http.ResponseWriter, r *http.Request, action string) *types.SignedRequest {
+	if !ws.acceptsAdminRequests(w, r) {
+		return nil
+	}
+
+	var req types.SignedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("invalid request body"), http.StatusBadRequest)
+		return nil
+	}
+
+	if req.Action != action {
+		writeError(w, fmt.Errorf("%w: expected action %q, got %q", errInvalidAdminSignature, action, req.Action), http.StatusUnauthorized)
+		return nil
+	}
+
+	if !ws.verifyAdminRequest(w, r, &req) {
+		return nil
+	}
+
+	if req.Data == nil {
+		req.Data = map[string]string{}
+	}
+	return &req
+}
+
+// writeJSON writes v as a JSON response with the given status, amounts as
+// strings of base units
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(withStringAmounts(v))
+}
+
+// nodeSnapshot handles saving the chain state and taking a snapshot copy of it
+func (ws *WebServer) nodeSnapshot(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionNodeSnapshot)
+	if req == nil {
+		return
+	}
+
+	dir, err := ws.blockchain.Snapshot()
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to create snapshot: %w", err), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Admin %s created snapshot %s", req.AdminAddress, dir)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":   "success",
+		"snapshot": dir,
+		"height":   ws.blockchain.GetChainHeight(),
+	})
+}
+
+// nodeCompactAccounts handles removing empty accounts from the persisted state
+func (ws *WebServer) nodeCompactAccounts(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionNodeCompact)
+	if req == nil {
+		return
+	}
+
+	report, err := ws.blockchain.CompactAccounts()
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to compact accounts: %w", err), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Admin %s compacted accounts: %d removed", req.AdminAddress, report.Removed)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"report": report,
+	})
+}
+
+// nodeMining handles enabling or disabling block production on this node.
+// Data: {"enabled": "true"|"false"}
+func (ws *WebServer) nodeMining(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionNodeMining)
+	if req == nil {
+		return
+	}
+
+	enabled, err := parseBoolField(req.Data, "enabled")
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	// Also start/stop the local consensus loop if this node runs one
+	if ws.consensusEngine != nil {
+		if enabled && !ws.consensusEngine.IsMining() {
+			if err := ws.consensusEngine.StartMining(); err != nil {
+				// Mining stays off while the validator signer is unreachable
+				if errors.Is(err, signer.ErrUnavailable) {
+					writeError(w, err, http.StatusServiceUnavailable)
+					return
+				}
+				log.Printf("Consensus mining not started: %v", err)
+			}
+		} else if !enabled && ws.consensusEngine.IsMining() {
+			ws.consensusEngine.StopMining()
+		}
+	}
+
+	if enabled {
+		atomic.StoreInt32(&ws.node.miningPaused, 0)
+	} else {
+		atomic.StoreInt32(&ws.node.miningPaused, 1)
+	}
+
+	log.Printf("Admin %s set mining enabled=%v", req.AdminAddress, enabled)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":        "success",
+		"miningEnabled": enabled,
+	})
+}
+
+// nodeRotateLogs handles rotating the node log file
+func (ws *WebServer) nodeRotateLogs(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionNodeRotateLogs)
+	if req == nil {
+		return
+	}
+
+	rotated, err := logging.Rotate()
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, logging.ErrNoLogFile) {
+			status = http.StatusConflict
+		}
+		writeError(w, err, status)
+		return
+	}
+
+	log.Printf("Admin %s rotated logs (previous file: %s)", req.AdminAddress, rotated)
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":  "success",
+		"rotated": rotated,
+		"current": logging.OutputFile(),
+	})
+}
+
+// nodeLogLevel handles changing the log level at runtime.
+// Data: {"level": "debug"|"info"|"warn"|"error"}
+func (ws *WebServer) nodeLogLevel(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionNodeLogLevel)
+	if req == nil {
+		return
+	}
+
+	level, err := logging.ParseLevel(req.Data["level"])
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	previous := logging.GetLevel()
+	logging.SetLevel(level)
+
+	log.Printf("Admin %s changed log level from %s to %s", req.AdminAddress, previous, level)
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":   "success",
+		"previous": previous.String(),
+		"level":    level.String(),
+	})
+}
+
+// nodeBanPeer handles banning a peer.
+// Data: {"peer": "host[:port]", "duration": "1h" (optional, permanent when empty)}
+func (ws *WebServer) nodeBanPeer(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionNodeBanPeer)
+	if req == nil {
+		return
+	}
+
+	if ws.node.p2pNode == nil {
+		writeError(w, errors.New("P2P networking is not enabled on this node"), http.StatusServiceUnavailable)
+		return
+	}
+
+	peer := strings.TrimSpace(req.Data["peer"])
+	if peer == "" {
+		writeError(w, errors.New("missing peer in request data"), http.StatusBadRequest)
+		return
+	}
+
+	var duration time.Duration
+	if d := req.Data["duration"]; d != "" {
+		parsed, err := time.ParseDuration(d)
+		if err != nil {
+			writeError(w, fmt.Errorf("invalid duration: %w", err), http.StatusBadRequest)
+			return
+		}
+		duration = parsed
+	}
+
+	ws.node.p2pNode.BanPeer(peer, duration)
+
+	log.Printf("Admin %s banned peer %s", req.AdminAddress, peer)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"peer":   peer,
+		"banned": ws.node.p2pNode.BannedPeers(),
+	})
+}
+
+// nodeUnbanPeer handles lifting a peer ban.
+// Data: {"peer": "host[:port]"}
+func (ws *WebServer) nodeUnbanPeer(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionNodeUnbanPeer)
+	if req == nil {
+		return
+	}
+
+	if ws.node.p2pNode == nil {
+		writeError(w, errors.New("P2P networking is not enabled on this node"), http.StatusServiceUnavailable)
+		return
+	}
+
+	peer := strings.TrimSpace(req.Data["peer"])
+	if !ws.node.p2pNode.UnbanPeer(peer) {
+		writeError(w, fmt.Errorf("peer %s is not banned", peer), http.StatusNotFound)
+		return
+	}
+
+	log.Printf("Admin %s unbanned peer %s", req.AdminAddress, peer)
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status": "success",
+		"peer":   peer,
+	})
+}
+
+// nodeViewConfig returns the node configuration and runtime state with secrets redacted
+func (ws *WebServer) nodeViewConfig(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionNodeViewConfig)
+	if req == nil {
+		return
+	}
+
+	config, err := redactConfig(ws.node.nodeConfig)
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to render config: %w", err), http.StatusInternalServerError)
+		return
+	}
+
+	runtime := map[string]interface{}{
+		"apiPort":       ws.port,
+		"logLevel":      logging.GetLevel().String(),
+		"logFile":       logging.OutputFile(),
+		"miningEnabled": !ws.isMiningPaused(),
+	}
+	if ws.consensusEngine != nil {
+		runtime["consensusMining"] = ws.consensusEngine.IsMining()
+	}
+	runtime["corsOrigins"] = ws.CORSOrigins()
+	runtime["routePolicies"] = ws.RoutePolicies()
+	runtime["circuitBreakers"] = ws.CircuitBreakers()
+	runtime["upgrades"] = ws.blockchain.Upgrades()
+	runtime["dustPolicy"] = ws.blockchain.DustPolicy()
+	runtime["observer"] = ws.observer
+	runtime["adminAccess"] = ws.AdminAccess()
+	runtime["validatorEmergency"] = ws.validatorManager.EmergencyStatus()
+	if ws.node.watchdog != nil {
+		runtime["watchdog"] = ws.node.watchdog.Status()
+	}
+	if ws.node.p2pNode != nil {
+		runtime["nodeId"] = ws.node.p2pNode.NodeID()
+		runtime["peers"] = ws.node.p2pNode.GetPeers()
+		runtime["knownPeers"] = ws.node.p2pNode.PeerStore().List()
+		runtime["bannedPeers"] = ws.node.p2pNode.BannedPeers()
+		runtime["p2pLimits"] = ws.node.p2pNode.RateLimits()
+		offset, measured := ws.node.p2pNode.ClockOffset()
+		perPeer := map[string]string{}
+		for peer, peerOffset := range ws.node.p2pNode.ClockOffsets() {
+			perPeer[peer] = peerOffset.String()
+		}
+		runtime["clock"] = map[string]interface{}{
+			"medianOffset": offset.String(),
+			"peers":        measured,
+			"offsets":      perPeer,
+			"inSync":       ws.node.p2pNode.CheckClock() == nil,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"config":  config,
+		"runtime": runtime,
+	})
+}
+
+// redactConfig converts config into a generic JSON map and replaces every secret value
+func redactConfig(config interface{}) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	if config == nil {
+		return result, nil
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	redactMap(result)
+	return result, nil
+}
+
+// redactMap walks a decoded JSON object and redacts secret keys in place
+func redactMap(m map[string]interface{}) {
+	for key, value := range m {
+		if isSecretKey(key) {
+			if value != nil && value != "" {
+				m[key] = redactedValue
+			}
+			continue
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			redactMap(v)
+		case []interface{}:
+			for _, item := range v {
+				if nested, ok := item.(map[string]interface{}); ok {
+					redactMap(nested)
+				}
+			}
+		}
+	}
+}
+
+// isSecretKey reports whether a config key names a secret
+func isSecretKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, fragment := range secretKeyFragments {
+		if strings.Contains(lower, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBoolField reads a required boolean from signed request data
+func parseBoolField(data map[string]string, field string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(data[field])) {
+	case "true", "1", "yes", "on":
+		return true, nil
+	case "false", "0", "no", "off":
+		return false, nil
+	default:
+		return false, fmt.Errorf("missing or invalid %q in request data", field)
+	}
+}
+
+// nodeReloadConfig handles re-reading the reloadable part of the node configuration,
+// the same as sending SIGHUP to the node. Nothing is applied if validation fails.
+func (ws *WebServer) nodeReloadConfig(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionNodeReloadConfig)
+	if req == nil {
+		return
+	}
+	if ws.node.reloadConfig == nil {
+		writeError(w, errors.New("config reload is not available on this node"), http.StatusServiceUnavailable)
+		return
+	}
+
+	applied, err := ws.node.reloadConfig()
+	if err != nil {
+		writeError(w, fmt.Errorf("config reload failed: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Admin %s reloaded the node configuration", req.AdminAddress)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":  "success",
+		"applied": applied,
+	})
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"time"
+
+	"confirmix/pkg/types"
+)
+
+// adminRequestMaxAge bounds how far the timestamp of a signed admin request
+// may be from the node's clock, in either direction
+const adminRequestMaxAge = 5 * time.Minute
+
+// errAdminRequestReplayed is returned for a signed admin request that was already accepted
+var errAdminRequestReplayed = errors.New("request was already used")
+
+// adminReplayState remembers the signed admin requests accepted within
+// adminRequestMaxAge, so a captured request cannot be sent again. Older
+// requests are refused by their timestamp and are forgotten.
+type adminReplayState struct {
+	mu   sync.Mutex
+	used map[[sha256.Size]byte]int64 // signed message hash -> request timestamp
+}
+
+// checkAdminTimestamp refuses requests signed too long ago or dated in the future
+func checkAdminTimestamp(timestamp int64, now time.Time) error {
+	age := now.Unix() - timestamp
+	switch {
+	case age > int64(adminRequestMaxAge/time.Second):
+		return errors.New("request expired")
+	case -age > int64(adminRequestMaxAge/time.Second):
+		return errors.New("request is dated in the future")
+	}
+	return nil
+}
+
+// markUsed records an accepted request. It fails when the same signed
+// message was accepted before.
+func (s *adminReplayState) markUsed(req *types.SignedRequest, now time.Time) error {
+	key := sha256.Sum256([]byte(req.SigningMessage()))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.used == nil {
+		s.used = make(map[[sha256.Size]byte]int64)
+	}
+	for seen, timestamp := range s.used {
+		if checkAdminTimestamp(timestamp, now) != nil {
+			delete(s.used, seen)
+		}
+	}
+	if _, exists := s.used[key]; exists {
+		return errAdminRequestReplayed
+	}
+	s.used[key] = req.Timestamp
+	return nil
+}
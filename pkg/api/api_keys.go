@@ -0,0 +1,261 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/replica"
+
+	"github.com/gorilla/mux"
+)
+
+// APIKeyHeader carries the API key of a request
+const APIKeyHeader = "X-API-Key"
+
+// Actions that must be signed for the API key endpoints
+const (
+	ActionAPIKeyList   = "node_apikey_list"
+	ActionAPIKeyCreate = "node_apikey_create"
+	ActionAPIKeyRevoke = "node_apikey_revoke"
+)
+
+// txSubmitRoutes are the POST routes open to keys with the tx-submit scope.
+// Other writes need the admin scope.
+var txSubmitRoutes = map[string]bool{
+	"/api/transactions":                 true,
+	"/api/wallet/transfer":              true,
+	"/api/wallet/sign":                  true,
+	"/api/wallet/unlock":                true,
+	"/api/wallet/lock":                  true,
+	"/api/wallet/passphrase":            true,
+	"/api/wallet/contacts":              true,
+	"/api/wallet/contacts/remove":       true,
+	"/api/payment-requests":             true,
+	"/api/payment-requests/{id}/cancel": true,
+	"/api/bridge/lock":                  true,
+	"/api/bridge/release":               true,
+	"/api/oracles/authorize":            true,
+	"/api/oracle/{feed}":                true,
+	"/api/staking/delegate":             true,
+	"/api/staking/undelegate":           true,
+	"/api/staking/claim":                true,
+	"/api/multisig/transaction/create":  true,
+	"/api/multisig/transaction/sign":    true,
+	"/api/multisig/transaction/execute": true,
+	"/api/proposals/create":             true,
+	"/api/proposals/vote":               true,
+}
+
+// apiKeyContextKey stores the authenticated key in the request context
+type apiKeyContextKey struct{}
+
+// apiKeyState holds the API key policy and the per-key rate limiters
+type apiKeyState struct {
+	mu       sync.Mutex
+	required bool                     // refuse requests without a key
+	buckets  map[string]*apiKeyBucket // key ID -> limiter
+}
+
+// apiKeyBucket is a token bucket refilled at the key's per-minute rate. It holds
+// up to a minute's allowance.
+type apiKeyBucket struct {
+	tokens   float64
+	lastFill time.Time
+}
+
+// SetRequireAPIKey makes every request except health checks and signed admin
+// requests carry a valid API key. Requests with a key are metered and limited
+// whether or not keys are required.
+func (ws *WebServer) SetRequireAPIKey(required bool) {
+	ws.apiKeys.mu.Lock()
+	defer ws.apiKeys.mu.Unlock()
+	ws.apiKeys.required = required
+}
+
+// allowAPIKeyRequest takes a token from the key's bucket
+func (ws *WebServer) allowAPIKeyRequest(key blockchain.APIKey) bool {
+	if key.RateLimit <= 0 {
+		return true
+	}
+	ws.apiKeys.mu.Lock()
+	defer ws.apiKeys.mu.Unlock()
+
+	now := time.Now()
+	capacity := float64(key.RateLimit)
+	if ws.apiKeys.buckets == nil {
+		ws.apiKeys.buckets = make(map[string]*apiKeyBucket)
+	}
+	bucket, exists := ws.apiKeys.buckets[key.ID]
+	if !exists {
+		bucket = &apiKeyBucket{tokens: capacity, lastFill: now}
+		ws.apiKeys.buckets[key.ID] = bucket
+	}
+	bucket.tokens += now.Sub(bucket.lastFill).Minutes() * capacity
+	if bucket.tokens > capacity {
+		bucket.tokens = capacity
+	}
+	bucket.lastFill = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// requiredScope returns the scope a request needs
+func requiredScope(r *http.Request, template string) string {
+	switch {
+	case strings.HasPrefix(template, "/api/admin/"):
+		return blockchain.APIScopeAdmin
+	case template == replica.StreamPath:
+		return blockchain.APIScopeReplica
+	case r.Method == http.MethodGet || r.Method == http.MethodHead || observerReadRoutes[template]:
+		return blockchain.APIScopeRead
+	case txSubmitRoutes[template]:
+		return blockchain.APIScopeTxSubmit
+	}
+	return blockchain.APIScopeAdmin
+}
+
+// checkAPIKey authenticates, scopes, rate limits and meters requests carrying an API key
+func (ws *WebServer) checkAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		template := r.URL.Path
+		if route := mux.CurrentRoute(r); route != nil {
+			if t, err := route.GetPathTemplate(); err == nil {
+				template = t
+			}
+		}
+
+		secret := r.Header.Get(APIKeyHeader)
+		if secret == "" {
+			ws.apiKeys.mu.Lock()
+			required := ws.apiKeys.required
+			ws.apiKeys.mu.Unlock()
+			// Signed admin requests authenticate with their signature
+			if !required || template == "/api/health" || strings.HasPrefix(template, "/api/admin/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			writeErrorCode(w, http.StatusUnauthorized, CodeInvalidAPIKey, "an API key is required in the "+APIKeyHeader+" header")
+			return
+		}
+
+		key, err := ws.keys.AuthenticateAPIKey(secret)
+		if err != nil {
+			writeErrorCode(w, http.StatusUnauthorized, CodeInvalidAPIKey, "the API key is unknown or revoked")
+			return
+		}
+		if scope := requiredScope(r, template); !key.HasScope(scope) {
+			ws.keys.RecordAPIKeyUsage(key.ID, blockchain.APIKeyDenied)
+			writeErrorCode(w, http.StatusForbidden, CodeAPIKeyScope, fmt.Sprintf("the API key lacks the %q scope", scope))
+			return
+		}
+		if !ws.allowAPIKeyRequest(key) {
+			ws.keys.RecordAPIKeyUsage(key.ID, blockchain.APIKeyThrottled)
+			w.Header().Set("Retry-After", "1")
+			writeErrorCode(w, http.StatusTooManyRequests, CodeRateLimited,
+				fmt.Sprintf("the API key is limited to %d requests per minute", key.RateLimit))
+			return
+		}
+		ws.keys.RecordAPIKeyUsage(key.ID, blockchain.APIKeyServed)
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key.ID)))
+	})
+}
+
+// getAPIKeyUsage handles GET /api/apikeys/usage, returning the scopes, limit
+// and usage of the key making the request
+func (ws *WebServer) getAPIKeyUsage(w http.ResponseWriter, r *http.Request) {
+	id, _ := r.Context().Value(apiKeyContextKey{}).(string)
+	if id == "" {
+		writeErrorCode(w, http.StatusUnauthorized, CodeInvalidAPIKey, "send the API key in the "+APIKeyHeader+" header")
+		return
+	}
+	key, exists := ws.keys.GetAPIKey(id)
+	if !exists {
+		writeError(w, fmt.Errorf("%w: %s", blockchain.ErrAPIKeyNotFound, id), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, key)
+}
+
+// listAPIKeys handles listing every API key with its usage
+func (ws *WebServer) listAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if req := ws.decodeAdminRequest(w, r, ActionAPIKeyList); req == nil {
+		return
+	}
+	keys := ws.keys.APIKeys()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys":  keys,
+		"count": len(keys),
+	})
+}
+
+// createAPIKey handles issuing an API key. The secret is only returned here.
+// Data: {"name": "...", "scopes": "read,tx-submit", "rate_limit": "600" (requests per minute, optional; -1 for unlimited)}
+func (ws *WebServer) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionAPIKeyCreate)
+	if req == nil {
+		return
+	}
+
+	scopes, err := blockchain.ParseAPIScopes(req.Data["scopes"])
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	rateLimit := 0
+	if raw := req.Data["rate_limit"]; raw != "" {
+		if rateLimit, err = strconv.Atoi(raw); err != nil {
+			writeError(w, errors.New("rate_limit must be an integer"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	secret, key, err := ws.keys.CreateAPIKey(req.Data["name"], scopes, rateLimit, req.AdminAddress)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Admin %s created API key %s (%s) with scopes %v", req.AdminAddress, key.ID, key.Name, key.Scopes)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"key":    key,
+		"secret": secret,
+	})
+}
+
+// revokeAPIKey handles revoking an API key.
+// Data: {"id": "..."}
+func (ws *WebServer) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionAPIKeyRevoke)
+	if req == nil {
+		return
+	}
+
+	key, err := ws.keys.RevokeAPIKey(req.Data["id"])
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	ws.apiKeys.mu.Lock()
+	delete(ws.apiKeys.buckets, key.ID)
+	ws.apiKeys.mu.Unlock()
+
+	log.Printf("Admin %s revoked API key %s (%s)", req.AdminAddress, key.ID, key.Name)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"key":    key,
+	})
+}
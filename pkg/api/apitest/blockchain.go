@@ -0,0 +1,1848 @@
+// Package apitest provides an in-memory implementation of the services the
+// API handlers use, so the API can be served and exercised without a full
+// node. It keeps no state on disk and runs no consensus: transactions stay
+// pending until MineBlock, signatures are not checked except where the
+// wallet types check them themselves, and unusual views such as receipts or
+// supply are derived from the in-memory blocks only.
+package apitest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"confirmix/pkg/api"
+	"confirmix/pkg/blockchain"
+)
+
+var _ api.BlockchainService = (*Blockchain)(nil)
+
+// DefaultMaxBlockTransactions is the block capacity of a new fake
+const DefaultMaxBlockTransactions = 100
+
+// Blockchain is an in-memory stand-in for *blockchain.Blockchain. Its zero
+// value is not usable; create it with NewBlockchain.
+type Blockchain struct {
+	mu sync.Mutex
+
+	delay    time.Duration
+	failures map[string]error
+
+	blocks        []*blockchain.Block
+	pending       []*blockchain.Transaction
+	rejected      []blockchain.RejectedTransaction
+	maxTxs        int
+	reward        *big.Int
+	balances      map[string]*big.Int
+	history       map[string][]blockchain.BalancePoint
+	keyPairs      map[string]*blockchain.KeyPair
+	validators    map[string]string // address -> human proof
+	evidence      []blockchain.EvidenceRecord
+	events        []blockchain.ChainEvent
+	pendingEvents []blockchain.ChainEvent
+	labels        map[string]blockchain.AddressLabel
+	contacts      map[string]map[string]blockchain.Contact // owner -> lower-case name -> contact
+	payments      map[string]blockchain.PaymentRequest
+	passphrases   map[string]string
+	unlocked      map[string]time.Time
+	controls      map[string]blockchain.WalletControls
+	wallets       map[string]*blockchain.MultiSigWallet
+	apiKeys       map[string]blockchain.APIKey
+	apiSecrets    map[string]string // secret -> key ID
+	saves         int
+}
+
+// NewBlockchain returns a fake holding only a genesis block
+func NewBlockchain() *Blockchain {
+	genesis := &blockchain.Block{Index: 0, Timestamp: time.Now().Unix(), Validator: "genesis"}
+	genesis.Hash = genesis.CalculateHash()
+	return &Blockchain{
+		failures:    make(map[string]error),
+		blocks:      []*blockchain.Block{genesis},
+		maxTxs:      DefaultMaxBlockTransactions,
+		reward:      big.NewInt(1),
+		balances:    make(map[string]*big.Int),
+		history:     make(map[string][]blockchain.BalancePoint),
+		keyPairs:    make(map[string]*blockchain.KeyPair),
+		validators:  make(map[string]string),
+		labels:      make(map[string]blockchain.AddressLabel),
+		contacts:    make(map[string]map[string]blockchain.Contact),
+		payments:    make(map[string]blockchain.PaymentRequest),
+		passphrases: make(map[string]string),
+		unlocked:    make(map[string]time.Time),
+		controls:    make(map[string]blockchain.WalletControls),
+		wallets:     make(map[string]*blockchain.MultiSigWallet),
+		apiKeys:     make(map[string]blockchain.APIKey),
+		apiSecrets:  make(map[string]string),
+	}
+}
+
+// Services returns the API services backed by the fake, without a validator
+// manager or governance system
+func (f *Blockchain) Services() api.Services {
+	return api.BlockchainServices(f, nil, nil)
+}
+
+// SetDelay makes every call sleep for d first, to exercise route timeouts
+func (f *Blockchain) SetDelay(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.delay = d
+}
+
+// Fail makes every call of the named method that can fail return err. A nil
+// err clears the failure.
+func (f *Blockchain) Fail(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.failures, method)
+		return
+	}
+	f.failures[method] = err
+}
+
+// SetBalance sets the balance of an address
+func (f *Blockchain) SetBalance(address string, balance *big.Int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.balances[address] = new(big.Int).Set(balance)
+}
+
+// SetMaxBlockTransactions sets the block capacity
+func (f *Blockchain) SetMaxBlockTransactions(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.maxTxs = n
+}
+
+// Saves returns how many times SaveToDisk was called
+func (f *Blockchain) Saves() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.saves
+}
+
+// MineBlock moves up to MaxBlockTransactions pending transactions into a new
+// block by validator, applies their transfers and returns the block
+func (f *Blockchain) MineBlock(validator string) *blockchain.Block {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := len(f.pending)
+	if n > f.maxTxs {
+		n = f.maxTxs
+	}
+	txs := f.pending[:n]
+	f.pending = append([]*blockchain.Transaction{}, f.pending[n:]...)
+
+	block := blockchain.NewBlock(uint64(len(f.blocks)), txs, f.blocks[len(f.blocks)-1].Hash, validator, f.validators[validator])
+	f.appendBlockLocked(block)
+	return block
+}
+
+// RecordEvent adds a chain event, committed with the next block like the real journal
+func (f *Blockchain) RecordEvent(eventType, subject string, data map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pendingEvents = append(f.pendingEvents, blockchain.ChainEvent{
+		Seq:       uint64(len(f.events) + len(f.pendingEvents) + 1),
+		Type:      eventType,
+		Subject:   subject,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// enter waits for the configured delay and returns the failure configured
+// for method, if any. The caller must not hold f.mu.
+func (f *Blockchain) enter(method string) error {
+	f.mu.Lock()
+	delay, err := f.delay, f.failures[method]
+	f.mu.Unlock()
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	return err
+}
+
+// appendBlockLocked adds a block and applies its transfers. The caller must hold f.mu.
+func (f *Blockchain) appendBlockLocked(block *blockchain.Block) {
+	for _, tx := range block.Transactions {
+		tx.Status = "confirmed"
+		tx.BlockIndex = int64(block.Index)
+		tx.BlockHash = block.Hash
+		value := new(big.Int).SetUint64(tx.Value)
+		if tx.From != "" {
+			f.balanceLocked(tx.From).Sub(f.balances[tx.From], value)
+			f.recordLocked(tx.From, block.Index)
+		}
+		f.balanceLocked(tx.To).Add(f.balances[tx.To], value)
+		f.recordLocked(tx.To, block.Index)
+		f.payRequestLocked(block, tx)
+	}
+	f.blocks = append(f.blocks, block)
+	for _, event := range f.pendingEvents {
+		event.BlockIndex, event.BlockHash = block.Index, block.Hash
+		f.events = append(f.events, event)
+	}
+	f.pendingEvents = nil
+}
+
+// payRequestLocked counts tx towards the payment request its data names, if
+// any. The caller must hold f.mu.
+func (f *Blockchain) payRequestLocked(block *blockchain.Block, tx *blockchain.Transaction) {
+	id := strings.TrimPrefix(string(tx.Data), blockchain.PaymentRequestDataPrefix)
+	request, exists := f.payments[id]
+	if !exists || id == string(tx.Data) || tx.To != request.Recipient ||
+		(request.Status != blockchain.PaymentRequestPending && request.Status != blockchain.PaymentRequestPartial) {
+		return
+	}
+	request.Payments = append(request.Payments, blockchain.PaymentMatch{
+		TxID: tx.ID, From: tx.From, Value: tx.Value, BlockIndex: block.Index, Timestamp: block.Timestamp,
+	})
+	request.Paid += tx.Value
+	request.Status = blockchain.PaymentRequestPartial
+	if request.Paid >= request.Amount {
+		request.Status = blockchain.PaymentRequestPaid
+		request.PaidAt = block.Timestamp
+	}
+	f.payments[id] = request
+}
+
+func (f *Blockchain) balanceLocked(address string) *big.Int {
+	balance, exists := f.balances[address]
+	if !exists {
+		balance = big.NewInt(0)
+		f.balances[address] = balance
+	}
+	return balance
+}
+
+func (f *Blockchain) recordLocked(address string, height uint64) {
+	f.history[address] = append(f.history[address], blockchain.BalancePoint{
+		Height:  height,
+		Balance: new(big.Int).Set(f.balances[address]),
+	})
+}
+
+// Chain
+
+func (f *Blockchain) GetChainHeight() uint64 {
+	f.enter("GetChainHeight")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return uint64(len(f.blocks) - 1)
+}
+
+func (f *Blockchain) GetLatestBlock() *blockchain.Block {
+	f.enter("GetLatestBlock")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.blocks[len(f.blocks)-1]
+}
+
+func (f *Blockchain) GetBlockByIndex(index uint64) (*blockchain.Block, error) {
+	if err := f.enter("GetBlockByIndex"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if index >= uint64(len(f.blocks)) {
+		return nil, fmt.Errorf("%w: %d", blockchain.ErrBlockNotFound, index)
+	}
+	return f.blocks[index], nil
+}
+
+func (f *Blockchain) GetHeaders(from uint64, count int) []blockchain.BlockHeader {
+	f.enter("GetHeaders")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	headers := []blockchain.BlockHeader{}
+	for i := from; i < uint64(len(f.blocks)) && len(headers) < count; i++ {
+		headers = append(headers, f.blocks[i].Header())
+	}
+	return headers
+}
+
+func (f *Blockchain) GetBlockReceipt(index uint64) (*blockchain.BlockReceipt, error) {
+	block, err := f.GetBlockByIndex(index)
+	if err != nil {
+		return nil, err
+	}
+	return &blockchain.BlockReceipt{
+		BlockIndex: block.Index,
+		BlockHash:  block.Hash,
+		Validator:  block.Validator,
+		TxCount:    len(block.Transactions),
+		Minted:     "0",
+	}, nil
+}
+
+// VerifyBlockReport only recomputes the hash and checks the linkage
+func (f *Blockchain) VerifyBlockReport(block *blockchain.Block) *blockchain.BlockVerificationReport {
+	f.enter("VerifyBlockReport")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	report := &blockchain.BlockVerificationReport{
+		Index:        block.Index,
+		Hash:         block.Hash,
+		ComputedHash: block.CalculateHash(),
+		Height:       uint64(len(f.blocks)) - 1,
+		Valid:        true,
+		Transactions: []blockchain.TxVerification{},
+	}
+	hash := blockchain.VerificationCheck{Name: blockchain.CheckHash, Result: blockchain.CheckPassed, Expected: report.ComputedHash, Actual: block.Hash}
+	if hash.Expected != hash.Actual {
+		hash.Result, report.Valid = blockchain.CheckFailed, false
+	}
+	linkage := blockchain.VerificationCheck{Name: blockchain.CheckLinkage, Result: blockchain.CheckSkipped}
+	if block.Index > 0 && block.Index <= uint64(len(f.blocks)) {
+		linkage.Result, linkage.Expected, linkage.Actual = blockchain.CheckPassed, f.blocks[block.Index-1].Hash, block.PrevHash
+		if linkage.Expected != linkage.Actual {
+			linkage.Result, report.Valid = blockchain.CheckFailed, false
+		}
+	}
+	report.Checks = []blockchain.VerificationCheck{hash, linkage}
+	return report
+}
+
+// BridgeStatus reports the bridge as active, with the escrow balance and the
+// releases confirmed by mined blocks
+func (f *Blockchain) BridgeStatus() blockchain.BridgeStatus {
+	f.enter("BridgeStatus")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status := blockchain.BridgeStatus{
+		Active:            true,
+		Escrow:            blockchain.BridgeEscrowAddress,
+		Locked:            "0",
+		Validators:        len(f.validators),
+		RequiredApprovals: len(f.validators)*2/3 + 1,
+	}
+	if balance, exists := f.balances[blockchain.BridgeEscrowAddress]; exists {
+		status.Locked = balance.String()
+	}
+	for _, block := range f.blocks {
+		for _, tx := range block.Transactions {
+			if tx.Type == blockchain.BridgeReleaseTxType {
+				status.Releases++
+			}
+		}
+	}
+	return status
+}
+
+func (f *Blockchain) BridgeReleaseTx(sourceChain, sourceTx string) (string, bool) {
+	f.enter("BridgeReleaseTx")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, block := range f.blocks {
+		for _, tx := range block.Transactions {
+			if tx.Type != blockchain.BridgeReleaseTxType {
+				continue
+			}
+			if release, err := blockchain.ParseBridgeRelease(tx); err == nil && release.SourceChain == sourceChain && release.SourceTx == sourceTx {
+				return tx.ID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Oracles replays the oracle authorizations of mined blocks; senders are not
+// checked to be validators
+func (f *Blockchain) Oracles() []blockchain.OracleInfo {
+	f.enter("Oracles")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	oracles := make(map[string]blockchain.OracleInfo)
+	for _, block := range f.blocks {
+		for _, tx := range block.Transactions {
+			auth, err := blockchain.ParseOracleAuthorization(tx)
+			if err != nil {
+				continue
+			}
+			if auth.Revoke {
+				delete(oracles, auth.Oracle)
+				continue
+			}
+			oracles[auth.Oracle] = blockchain.OracleInfo{Oracle: auth.Oracle, Feeds: auth.Feeds,
+				AuthorizedBy: tx.From, TxID: tx.ID, BlockIndex: block.Index}
+		}
+	}
+	list := make([]blockchain.OracleInfo, 0, len(oracles))
+	for _, oracle := range oracles {
+		list = append(list, oracle)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Oracle < list[j].Oracle })
+	return list
+}
+
+// oracleFeedsLocked collects the data points of mined blocks per feed, oldest first
+func (f *Blockchain) oracleFeedsLocked() map[string][]blockchain.OracleRecord {
+	feeds := make(map[string][]blockchain.OracleRecord)
+	for _, block := range f.blocks {
+		for _, tx := range block.Transactions {
+			point, err := blockchain.ParseOracleDataPoint(tx)
+			if err != nil {
+				continue
+			}
+			feeds[point.Feed] = append(feeds[point.Feed], blockchain.OracleRecord{Feed: point.Feed, Value: point.Value,
+				ObservedAt: point.ObservedAt, Oracle: tx.From, TxID: tx.ID, BlockIndex: block.Index, Timestamp: block.Timestamp})
+		}
+	}
+	return feeds
+}
+
+func (f *Blockchain) OracleFeeds() []blockchain.OracleFeedSummary {
+	f.enter("OracleFeeds")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	summaries := []blockchain.OracleFeedSummary{}
+	for feed, history := range f.oracleFeedsLocked() {
+		summaries = append(summaries, blockchain.OracleFeedSummary{Feed: feed, Latest: history[len(history)-1], Points: len(history)})
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Feed < summaries[j].Feed })
+	return summaries
+}
+
+func (f *Blockchain) OracleHistory(feed string, limit int) ([]blockchain.OracleRecord, error) {
+	if err := f.enter("OracleHistory"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	history, exists := f.oracleFeedsLocked()[feed]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", blockchain.ErrOracleFeedNotFound, feed)
+	}
+	if limit <= 0 || limit > len(history) {
+		limit = len(history)
+	}
+	records := make([]blockchain.OracleRecord, 0, limit)
+	for i := len(history) - 1; i >= len(history)-limit; i-- {
+		records = append(records, history[i])
+	}
+	return records, nil
+}
+
+// VerifyOracleTransaction only checks the payload; any sender may authorize
+// oracles and post data
+func (f *Blockchain) VerifyOracleTransaction(tx *blockchain.Transaction) error {
+	if err := f.enter("VerifyOracleTransaction"); err != nil {
+		return err
+	}
+	if _, err := blockchain.ParseOracleAuthorization(tx); err == nil {
+		return nil
+	}
+	_, err := blockchain.ParseOracleDataPoint(tx)
+	return err
+}
+
+// CircuitBreaker replays the circuit breaker actions of mined blocks; senders
+// are not checked to be validators
+func (f *Blockchain) CircuitBreaker() blockchain.CircuitBreakerStatus {
+	f.enter("CircuitBreaker")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status := blockchain.CircuitBreakerStatus{History: []blockchain.CircuitBreakerRecord{}}
+	for _, block := range f.blocks {
+		for _, tx := range block.Transactions {
+			action, err := blockchain.ParseCircuitBreakerAction(tx)
+			if err != nil {
+				continue
+			}
+			status.History = append(status.History, blockchain.CircuitBreakerRecord{
+				CircuitBreakerAction: *action,
+				Validator:            tx.From,
+				TxID:                 tx.ID,
+				BlockIndex:           block.Index,
+				Timestamp:            block.Timestamp,
+			})
+		}
+	}
+	if n := len(status.History); n > 0 {
+		since := status.History[n-1]
+		status.Halted, status.Since = since.Halt, &since
+	}
+	return status
+}
+
+func (f *Blockchain) Halted() bool {
+	return f.CircuitBreaker().Halted
+}
+
+// VerifyCircuitBreakerTransaction only checks the payload
+func (f *Blockchain) VerifyCircuitBreakerTransaction(tx *blockchain.Transaction) error {
+	if err := f.enter("VerifyCircuitBreakerTransaction"); err != nil {
+		return err
+	}
+	_, err := blockchain.ParseCircuitBreakerAction(tx)
+	return err
+}
+
+func (f *Blockchain) VerifyValidatorSlash(tx *blockchain.Transaction) error {
+	if err := f.enter("VerifyValidatorSlash"); err != nil {
+		return err
+	}
+	_, _, err := blockchain.ParseValidatorSlash(tx)
+	return err
+}
+
+func (f *Blockchain) VerifyStakingTransaction(tx *blockchain.Transaction) error {
+	if err := f.enter("VerifyStakingTransaction"); err != nil {
+		return err
+	}
+	_, _, err := blockchain.ParseStakingRequest(tx)
+	return err
+}
+
+func (f *Blockchain) VerifyCommissionTransaction(tx *blockchain.Transaction) error {
+	if err := f.enter("VerifyCommissionTransaction"); err != nil {
+		return err
+	}
+	_, err := blockchain.ParseCommissionRequest(tx)
+	return err
+}
+
+func (f *Blockchain) BlockUtilization(block *blockchain.Block) blockchain.BlockUtilization {
+	f.enter("BlockUtilization")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	usage := blockchain.BlockUtilization{TxCount: len(block.Transactions), MaxTxs: f.maxTxs}
+	if f.maxTxs > 0 {
+		usage.TxFill = float64(usage.TxCount) / float64(f.maxTxs)
+	}
+	return usage
+}
+
+func (f *Blockchain) UtilizationStats(window int) blockchain.UtilizationStats {
+	f.enter("UtilizationStats")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	blocks := f.blocks
+	if window > 0 && window < len(blocks) {
+		blocks = blocks[len(blocks)-window:]
+	}
+	stats := blockchain.UtilizationStats{
+		Blocks:    len(blocks),
+		FromIndex: blocks[0].Index,
+		ToIndex:   blocks[len(blocks)-1].Index,
+		MaxTxs:    f.maxTxs,
+	}
+	total := 0
+	for _, block := range blocks {
+		count := len(block.Transactions)
+		total += count
+		if count > stats.PeakTxCount {
+			stats.PeakTxCount = count
+		}
+		if f.maxTxs > 0 && count >= f.maxTxs {
+			stats.FullBlocks++
+		}
+	}
+	stats.AvgTxCount = float64(total) / float64(len(blocks))
+	if f.maxTxs > 0 {
+		stats.AvgTxFill = stats.AvgTxCount / float64(f.maxTxs)
+	}
+	return stats
+}
+
+// TxLatencyStats returns empty histograms; the fake does not time transactions
+func (f *Blockchain) TxLatencyStats() blockchain.TxLatencyStats {
+	f.enter("TxLatencyStats")
+	empty := blockchain.LatencyHistogram{
+		Buckets: blockchain.LatencyBuckets,
+		Counts:  make([]uint64, len(blockchain.LatencyBuckets)),
+	}
+	return blockchain.TxLatencyStats{Inclusion: empty, Broadcast: empty}
+}
+
+func (f *Blockchain) AddBlock(block *blockchain.Block) error {
+	if err := f.enter("AddBlock"); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	latest := f.blocks[len(f.blocks)-1]
+	if block.Index != latest.Index+1 {
+		return fmt.Errorf("%w: expected %d, got %d", blockchain.ErrInvalidBlockIndex, latest.Index+1, block.Index)
+	}
+	if block.PrevHash != latest.Hash {
+		return blockchain.ErrInvalidPrevHash
+	}
+
+	// Drop the block's transactions from the mempool
+	included := make(map[string]bool, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		included[tx.ID] = true
+	}
+	pending := f.pending[:0]
+	for _, tx := range f.pending {
+		if !included[tx.ID] {
+			pending = append(pending, tx)
+		}
+	}
+	f.pending = pending
+	f.appendBlockLocked(block)
+	return nil
+}
+
+// AttachRewards pays the fixed reward of the fake to the validator; the fake
+// has no epoch rewards
+func (f *Blockchain) AttachRewards(block *blockchain.Block) {
+	f.enter("AttachRewards")
+	f.mu.Lock()
+	reward := new(big.Int).Set(f.reward)
+	f.mu.Unlock()
+	block.AttachReward(reward)
+}
+
+// AttachCommitCertificate does nothing; the fake has no validator voting
+func (f *Blockchain) AttachCommitCertificate(block *blockchain.Block) {
+	f.enter("AttachCommitCertificate")
+}
+
+// Finality reports that no block is final; the fake has no validator voting
+func (f *Blockchain) Finality() blockchain.FinalityStatus {
+	f.enter("Finality")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return blockchain.FinalityStatus{Quorum: blockchain.VoteQuorum(len(f.validators))}
+}
+
+// Transactions
+
+func (f *Blockchain) AddTransaction(tx *blockchain.Transaction) error {
+	if err := f.enter("AddTransaction"); err != nil {
+		return err
+	}
+	if tx == nil {
+		return blockchain.ErrNilTransaction
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, _, found := f.findLocked(tx.ID); found {
+		return fmt.Errorf("%w: %s", blockchain.ErrTxExists, tx.ID)
+	}
+	tx.Status = "pending"
+	f.pending = append(f.pending, tx)
+	return nil
+}
+
+// findLocked looks a transaction up in the blocks and the mempool. The caller must hold f.mu.
+func (f *Blockchain) findLocked(id string) (*blockchain.Transaction, *blockchain.Block, bool) {
+	for _, block := range f.blocks {
+		for _, tx := range block.Transactions {
+			if tx.ID == id {
+				return tx, block, true
+			}
+		}
+	}
+	for _, tx := range f.pending {
+		if tx.ID == id {
+			return tx, nil, true
+		}
+	}
+	return nil, nil, false
+}
+
+func (f *Blockchain) GetPendingTransactions() []*blockchain.Transaction {
+	f.enter("GetPendingTransactions")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*blockchain.Transaction{}, f.pending...)
+}
+
+func (f *Blockchain) PendingByLane() map[string]int {
+	f.enter("PendingByLane")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	lanes := make(map[string]int)
+	for _, tx := range f.pending {
+		lanes[tx.Type]++
+	}
+	return lanes
+}
+
+func (f *Blockchain) MaxBlockTransactions() int {
+	f.enter("MaxBlockTransactions")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.maxTxs
+}
+
+func (f *Blockchain) SelectTransactions(limit int) []*blockchain.Transaction {
+	f.enter("SelectTransactions")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if limit > len(f.pending) || limit <= 0 {
+		limit = len(f.pending)
+	}
+	return append([]*blockchain.Transaction{}, f.pending[:limit]...)
+}
+
+func (f *Blockchain) RejectTransactions(rejections []blockchain.TxRejection) error {
+	if err := f.enter("RejectTransactions"); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	reasons := make(map[string]string, len(rejections))
+	for _, rejection := range rejections {
+		reasons[rejection.ID] = rejection.Reason
+	}
+	pending := f.pending[:0]
+	for _, tx := range f.pending {
+		reason, rejected := reasons[tx.ID]
+		if !rejected {
+			pending = append(pending, tx)
+			continue
+		}
+		f.rejected = append(f.rejected, blockchain.RejectedTransaction{
+			Transaction: tx,
+			Reason:      reason,
+			RejectedAt:  time.Now().Unix(),
+			Height:      uint64(len(f.blocks) - 1),
+		})
+	}
+	f.pending = pending
+	return nil
+}
+
+func (f *Blockchain) RejectedTransactions(address string, limit int) []blockchain.RejectedTransaction {
+	f.enter("RejectedTransactions")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var rejected []blockchain.RejectedTransaction
+	for i := len(f.rejected) - 1; i >= 0 && (limit <= 0 || len(rejected) < limit); i-- {
+		tx := f.rejected[i].Transaction
+		if address == "" || tx.From == address || tx.To == address {
+			rejected = append(rejected, f.rejected[i])
+		}
+	}
+	return rejected
+}
+
+func (f *Blockchain) GetRejectedTransaction(txID string) (blockchain.RejectedTransaction, bool) {
+	f.enter("GetRejectedTransaction")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, rejected := range f.rejected {
+		if rejected.Transaction.ID == txID {
+			return rejected, true
+		}
+	}
+	return blockchain.RejectedTransaction{}, false
+}
+
+func (f *Blockchain) GetTransactionStatuses(ids []string) []blockchain.TxStatus {
+	f.enter("GetTransactionStatuses")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	statuses := make([]blockchain.TxStatus, 0, len(ids))
+	for _, id := range ids {
+		status := blockchain.TxStatus{ID: id, Status: "not_found"}
+		if _, block, found := f.findLocked(id); found {
+			status.Status = "pending"
+			if block != nil {
+				index := int64(block.Index)
+				status.Status, status.BlockIndex, status.BlockHash = "confirmed", &index, block.Hash
+			}
+		} else {
+			for _, rejected := range f.rejected {
+				if rejected.Transaction.ID == id {
+					status.Status, status.Reason = "rejected", rejected.Reason
+				}
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (f *Blockchain) FindTransaction(id string) (*blockchain.Transaction, error) {
+	if err := f.enter("FindTransaction"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tx, _, found := f.findLocked(id)
+	if !found {
+		return nil, fmt.Errorf("%w: %s", blockchain.ErrTxNotFound, id)
+	}
+	return tx, nil
+}
+
+func (f *Blockchain) SearchTransactions(prefix string, limit int) []*blockchain.Transaction {
+	f.enter("SearchTransactions")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var matches []*blockchain.Transaction
+	for _, block := range f.blocks {
+		for _, tx := range block.Transactions {
+			if strings.HasPrefix(tx.ID, prefix) && (limit <= 0 || len(matches) < limit) {
+				matches = append(matches, tx)
+			}
+		}
+	}
+	return matches
+}
+
+func (f *Blockchain) RevertTransaction(hash string) error {
+	if err := f.enter("RevertTransaction"); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, tx := range f.pending {
+		if tx.ID == hash {
+			f.pending = append(f.pending[:i], f.pending[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", blockchain.ErrTxNotFound, hash)
+}
+
+// Validators
+
+func (f *Blockchain) GetValidators() []blockchain.ValidatorInfo {
+	f.enter("GetValidators")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	validators := make([]blockchain.ValidatorInfo, 0, len(f.validators))
+	for address, proof := range f.validators {
+		validators = append(validators, blockchain.ValidatorInfo{Address: address, HumanProof: proof})
+	}
+	sort.Slice(validators, func(i, j int) bool { return validators[i].Address < validators[j].Address })
+	return validators
+}
+
+func (f *Blockchain) IsValidator(address string) bool {
+	f.enter("IsValidator")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, exists := f.validators[address]
+	return exists
+}
+
+func (f *Blockchain) AddValidator(address string, humanProof string) error {
+	if err := f.enter("AddValidator"); err != nil {
+		return err
+	}
+	if humanProof == "" {
+		return blockchain.ErrHumanProofRequired
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, exists := f.validators[address]; exists {
+		return fmt.Errorf("%w: %s", blockchain.ErrValidatorExists, address)
+	}
+	f.validators[address] = humanProof
+	return nil
+}
+
+func (f *Blockchain) LastBlockBy(validator string) (*blockchain.Block, bool) {
+	f.enter("LastBlockBy")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.blocks) - 1; i >= 0; i-- {
+		if f.blocks[i].Validator == validator {
+			return f.blocks[i], true
+		}
+	}
+	return nil, false
+}
+
+func (f *Blockchain) GetHumanProof(address string) string {
+	f.enter("GetHumanProof")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.validators[address]
+}
+
+func (f *Blockchain) GetHumanProofRecord(address string) (*blockchain.HumanProofRecord, bool) {
+	f.enter("GetHumanProofRecord")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	proof, exists := f.validators[address]
+	if !exists {
+		return nil, false
+	}
+	return &blockchain.HumanProofRecord{Address: address, Proof: proof}, true
+}
+
+func (f *Blockchain) GetHumanProofRegistry() []blockchain.HumanProofRecord {
+	f.enter("GetHumanProofRegistry")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	records := make([]blockchain.HumanProofRecord, 0, len(f.validators))
+	for address, proof := range f.validators {
+		records = append(records, blockchain.HumanProofRecord{Address: address, Proof: proof})
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Address < records[j].Address })
+	return records
+}
+
+// GetHumanProofAttestation finds nothing: the fake attests no proofs
+func (f *Blockchain) GetHumanProofAttestation(address string) (*blockchain.HumanProofRegistration, bool) {
+	f.enter("GetHumanProofAttestation")
+	return nil, false
+}
+
+func (f *Blockchain) VerifyEvidence(tx *blockchain.Transaction) (*blockchain.DoubleSignEvidence, error) {
+	if err := f.enter("VerifyEvidence"); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: the fake blockchain does not verify evidence", blockchain.ErrInvalidEvidence)
+}
+
+func (f *Blockchain) Events(fromBlock, afterSeq uint64, eventType string, limit int) []blockchain.ChainEvent {
+	f.enter("Events")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	events := []blockchain.ChainEvent{}
+	for _, event := range f.events {
+		if len(events) >= limit {
+			break
+		}
+		if event.BlockIndex >= fromBlock && event.Seq > afterSeq && (eventType == "" || event.Type == eventType) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func (f *Blockchain) PendingEvents() []blockchain.ChainEvent {
+	f.enter("PendingEvents")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]blockchain.ChainEvent{}, f.pendingEvents...)
+}
+
+func (f *Blockchain) GetEvidence() []blockchain.EvidenceRecord {
+	f.enter("GetEvidence")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]blockchain.EvidenceRecord{}, f.evidence...)
+}
+
+// Chain metadata
+
+func (f *Blockchain) GetAllAddresses() []string {
+	f.enter("GetAllAddresses")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	addresses := make([]string, 0, len(f.balances))
+	for address := range f.balances {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+func (f *Blockchain) NextUpgrade() (blockchain.UpgradePlan, bool) {
+	f.enter("NextUpgrade")
+	return blockchain.UpgradePlan{}, false
+}
+
+func (f *Blockchain) RequiredUpgrade() (blockchain.UpgradePlan, bool) {
+	f.enter("RequiredUpgrade")
+	return blockchain.UpgradePlan{}, false
+}
+
+func (f *Blockchain) Upgrades() []blockchain.UpgradePlan {
+	f.enter("Upgrades")
+	return []blockchain.UpgradePlan{}
+}
+
+func (f *Blockchain) Activations() []blockchain.Activation {
+	f.enter("Activations")
+	return []blockchain.Activation{}
+}
+
+func (f *Blockchain) DustPolicy() blockchain.DustPolicy {
+	f.enter("DustPolicy")
+	return blockchain.DustPolicy{}
+}
+
+func (f *Blockchain) GetArchiveStatus() blockchain.ArchiveStatus {
+	f.enter("GetArchiveStatus")
+	return blockchain.ArchiveStatus{}
+}
+
+func (f *Blockchain) GetGenesisInfo() (*blockchain.GenesisInfo, error) {
+	if err := f.enter("GetGenesisInfo"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	genesis := f.blocks[0]
+	return &blockchain.GenesisInfo{
+		Hash:        genesis.Hash,
+		Timestamp:   genesis.Timestamp,
+		Validator:   genesis.Validator,
+		TotalSupply: "0",
+	}, nil
+}
+
+func (f *Blockchain) Supply() blockchain.Supply {
+	f.enter("Supply")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	total := big.NewInt(0)
+	for _, balance := range f.balances {
+		total.Add(total, balance)
+	}
+	return blockchain.Supply{
+		Height:     uint64(len(f.blocks) - 1),
+		Genesis:    "0",
+		Minted:     "0",
+		Total:      total.String(),
+		NextReward: f.reward.String(),
+	}
+}
+
+func (f *Blockchain) RichList(limit int) blockchain.RichList {
+	f.enter("RichList")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	total := big.NewInt(0)
+	var holders []string
+	for address, balance := range f.balances {
+		total.Add(total, balance)
+		if balance.Sign() > 0 {
+			holders = append(holders, address)
+		}
+	}
+	sort.Slice(holders, func(i, j int) bool {
+		if c := f.balances[holders[i]].Cmp(f.balances[holders[j]]); c != 0 {
+			return c > 0
+		}
+		return holders[i] < holders[j]
+	})
+
+	list := blockchain.RichList{
+		Height:      uint64(len(f.blocks) - 1),
+		TotalSupply: total.String(),
+		Holders:     len(holders),
+		Accounts:    []blockchain.RichListEntry{},
+	}
+	for i, address := range holders {
+		if i >= limit {
+			break
+		}
+		share, _ := new(big.Float).Quo(new(big.Float).SetInt(f.balances[address]), new(big.Float).SetInt(total)).Float64()
+		list.Accounts = append(list.Accounts, blockchain.RichListEntry{
+			Rank:    i + 1,
+			Address: address,
+			Balance: f.balances[address].String(),
+			Share:   share,
+			Label:   f.labels[address].Label,
+		})
+	}
+	return list
+}
+
+func (f *Blockchain) EpochRewardConfig() blockchain.EpochRewardConfig {
+	f.enter("EpochRewardConfig")
+	return blockchain.DefaultEpochRewardConfig()
+}
+
+func (f *Blockchain) EpochRewardSummary(epoch uint64) (*blockchain.EpochRewardSummary, error) {
+	if err := f.enter("EpochRewardSummary"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	length := blockchain.DefaultEpochRewardConfig().EpochLength
+	start := epoch * length
+	if start >= uint64(len(f.blocks)) {
+		return nil, fmt.Errorf("%w: epoch %d", blockchain.ErrBlockNotFound, epoch)
+	}
+	return &blockchain.EpochRewardSummary{
+		Epoch:              epoch,
+		StartHeight:        start,
+		EndHeight:          start + length - 1,
+		DistributionHeight: start + length,
+		Total:              "0",
+		Treasury:           "0",
+		Validators:         []blockchain.ValidatorEpochReward{},
+	}, nil
+}
+
+func (f *Blockchain) BlockProduction(epoch uint64) (*blockchain.BlockProduction, error) {
+	if err := f.enter("BlockProduction"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	length := blockchain.DefaultEpochRewardConfig().EpochLength
+	start := epoch * length
+	if start >= uint64(len(f.blocks)) {
+		return nil, fmt.Errorf("%w: epoch %d", blockchain.ErrBlockNotFound, epoch)
+	}
+	return &blockchain.BlockProduction{
+		Epoch:       epoch,
+		StartHeight: start,
+		EndHeight:   start + length - 1,
+		Quota:       int(length),
+		Validators:  []blockchain.ValidatorProduction{},
+	}, nil
+}
+
+func (f *Blockchain) StateDigest() blockchain.StateDigest {
+	f.enter("StateDigest")
+	return blockchain.StateDigest{}
+}
+
+// Persistence
+
+func (f *Blockchain) SaveToDisk() error {
+	if err := f.enter("SaveToDisk"); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.saves++
+	return nil
+}
+
+func (f *Blockchain) SaveMempool() error {
+	return f.enter("SaveMempool")
+}
+
+func (f *Blockchain) Snapshot() (string, error) {
+	if err := f.enter("Snapshot"); err != nil {
+		return "", err
+	}
+	return "snapshot-" + time.Now().UTC().Format("20060102T150405Z"), nil
+}
+
+func (f *Blockchain) CompactAccounts() (blockchain.AccountGCReport, error) {
+	if err := f.enter("CompactAccounts"); err != nil {
+		return blockchain.AccountGCReport{}, err
+	}
+	return blockchain.AccountGCReport{}, nil
+}
+
+// Wallets
+
+func (f *Blockchain) CreateAccount(address string, initialBalance *big.Int) error {
+	if err := f.enter("CreateAccount"); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, exists := f.balances[address]; exists {
+		return fmt.Errorf("%w: %s", blockchain.ErrAccountExists, address)
+	}
+	if initialBalance == nil {
+		initialBalance = big.NewInt(0)
+	}
+	f.balances[address] = new(big.Int).Set(initialBalance)
+	return nil
+}
+
+func (f *Blockchain) AddKeyPair(address string, keyPair *blockchain.KeyPair) {
+	f.enter("AddKeyPair")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keyPairs[address] = keyPair
+}
+
+func (f *Blockchain) GetKeyPair(address string) (*blockchain.KeyPair, bool) {
+	f.enter("GetKeyPair")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keyPair, exists := f.keyPairs[address]
+	return keyPair, exists
+}
+
+func (f *Blockchain) AuditKeys() (*blockchain.KeyAudit, error) {
+	if err := f.enter("AuditKeys"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	audit := &blockchain.KeyAudit{Entries: []blockchain.KeyAuditEntry{}}
+	// The fake tracks no key uses, so every key is unnecessary
+	for address, keyPair := range f.keyPairs {
+		entry := blockchain.KeyAuditEntry{
+			Address:     address,
+			KeyType:     keyPair.Type().String(),
+			InMemory:    true,
+			Private:     keyPair.Signer() != nil,
+			Uses:        []string{},
+			Unnecessary: true,
+		}
+		audit.Entries = append(audit.Entries, entry)
+		if entry.Private {
+			audit.Private++
+		}
+	}
+	sort.Slice(audit.Entries, func(i, j int) bool { return audit.Entries[i].Address < audit.Entries[j].Address })
+	audit.Total = len(audit.Entries)
+	audit.Unnecessary = len(audit.Entries)
+	return audit, nil
+}
+
+func (f *Blockchain) PruneKeys(addresses []string, exportDir string) (*blockchain.KeyPruneReport, error) {
+	if err := f.enter("PruneKeys"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	report := &blockchain.KeyPruneReport{ExportDir: exportDir, Migrated: []string{}, Deleted: []string{}}
+	for _, address := range addresses {
+		if _, exists := f.keyPairs[address]; !exists {
+			return nil, fmt.Errorf("%w: %s", blockchain.ErrKeyPairNotFound, address)
+		}
+	}
+	for _, address := range addresses {
+		delete(f.keyPairs, address)
+		if exportDir != "" {
+			report.Migrated = append(report.Migrated, address)
+		}
+		report.Deleted = append(report.Deleted, address)
+	}
+	return report, nil
+}
+
+func (f *Blockchain) GetBalance(address string) (*big.Int, error) {
+	if err := f.enter("GetBalance"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	balance, exists := f.balances[address]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", blockchain.ErrAccountNotFound, address)
+	}
+	return new(big.Int).Set(balance), nil
+}
+
+func (f *Blockchain) GetBalanceAtHeight(address string, height uint64) (*big.Int, error) {
+	if err := f.enter("GetBalanceAtHeight"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if height >= uint64(len(f.blocks)) {
+		return nil, fmt.Errorf("%w: %d", blockchain.ErrHeightNotReached, height)
+	}
+	balance := big.NewInt(0)
+	for _, point := range f.history[address] {
+		if point.Height > height {
+			break
+		}
+		balance = point.Balance
+	}
+	return new(big.Int).Set(balance), nil
+}
+
+func (f *Blockchain) GetBalanceHistory(address string) []blockchain.BalancePoint {
+	f.enter("GetBalanceHistory")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]blockchain.BalancePoint{}, f.history[address]...)
+}
+
+func (f *Blockchain) GetAccountStatement(address string, from, to int64) (*blockchain.AccountStatement, error) {
+	if err := f.enter("GetAccountStatement"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	balance, exists := f.balances[address]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", blockchain.ErrAccountNotFound, address)
+	}
+	return &blockchain.AccountStatement{
+		Address:        address,
+		From:           from,
+		To:             to,
+		OpeningBalance: balance.String(),
+		TotalCredits:   "0",
+		TotalDebits:    "0",
+		ClosingBalance: balance.String(),
+		Entries:        []blockchain.StatementEntry{},
+	}, nil
+}
+
+func (f *Blockchain) SpendByCategory(address string, from, to int64) (*blockchain.SpendReport, error) {
+	if err := f.enter("SpendByCategory"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, exists := f.balances[address]; !exists {
+		return nil, fmt.Errorf("%w: %s", blockchain.ErrAccountNotFound, address)
+	}
+	return &blockchain.SpendReport{
+		Address:    address,
+		From:       from,
+		To:         to,
+		Total:      "0",
+		Categories: []blockchain.CategorySpend{},
+		Months:     []blockchain.MonthlySpend{},
+	}, nil
+}
+
+func (f *Blockchain) BuildBalanceProof(address string, from, to int64) (*blockchain.BalanceProof, error) {
+	if err := f.enter("BuildBalanceProof"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	balance, exists := f.balances[address]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", blockchain.ErrAccountNotFound, address)
+	}
+	return &blockchain.BalanceProof{
+		Address:        address,
+		From:           from,
+		To:             to,
+		OpeningBalance: balance.String(),
+		OpeningLocked:  "0",
+		ClosingBalance: balance.String(),
+		ClosingLocked:  "0",
+		Entries:        []blockchain.BalanceProofEntry{},
+	}, nil
+}
+
+// Custodial wallet protections
+
+func (f *Blockchain) SignWithUnlockedWallet(tx *blockchain.Transaction) error {
+	if err := f.enter("SignWithUnlockedWallet"); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, protected := f.passphrases[tx.From]; protected && time.Now().After(f.unlocked[tx.From]) {
+		return fmt.Errorf("%w: %s", blockchain.ErrWalletLocked, tx.From)
+	}
+	keyPair, exists := f.keyPairs[tx.From]
+	if !exists {
+		return fmt.Errorf("%w: %s", blockchain.ErrKeyPairNotFound, tx.From)
+	}
+	return tx.SignWith(keyPair.Signer())
+}
+
+func (f *Blockchain) SetWalletPassphrase(address, passphrase, current string) error {
+	if err := f.enter("SetWalletPassphrase"); err != nil {
+		return err
+	}
+	if passphrase == "" {
+		return blockchain.ErrInvalidPassphrase
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if existing, protected := f.passphrases[address]; protected && existing != current {
+		return blockchain.ErrWrongPassphrase
+	}
+	f.passphrases[address] = passphrase
+	delete(f.unlocked, address)
+	return nil
+}
+
+func (f *Blockchain) HasWalletPassphrase(address string) bool {
+	f.enter("HasWalletPassphrase")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, protected := f.passphrases[address]
+	return protected
+}
+
+func (f *Blockchain) UnlockWallet(address, passphrase string, duration time.Duration) (time.Time, error) {
+	if err := f.enter("UnlockWallet"); err != nil {
+		return time.Time{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing, protected := f.passphrases[address]
+	if !protected {
+		return time.Time{}, fmt.Errorf("%w: %s", blockchain.ErrNoPassphrase, address)
+	}
+	if existing != passphrase {
+		return time.Time{}, blockchain.ErrWrongPassphrase
+	}
+	until := time.Now().Add(duration)
+	f.unlocked[address] = until
+	return until, nil
+}
+
+func (f *Blockchain) LockWallet(address string) {
+	f.enter("LockWallet")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.unlocked, address)
+}
+
+func (f *Blockchain) WalletUnlockedUntil(address string) (time.Time, bool) {
+	f.enter("WalletUnlockedUntil")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	until, exists := f.unlocked[address]
+	if !exists || time.Now().After(until) {
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+func (f *Blockchain) GetWalletControls(address string) (blockchain.WalletControls, bool) {
+	f.enter("GetWalletControls")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	controls, exists := f.controls[address]
+	return controls, exists
+}
+
+func (f *Blockchain) SetWalletControls(controls blockchain.WalletControls) (blockchain.WalletControls, error) {
+	if err := f.enter("SetWalletControls"); err != nil {
+		return blockchain.WalletControls{}, err
+	}
+	if controls.Address == "" {
+		return blockchain.WalletControls{}, fmt.Errorf("%w: address is required", blockchain.ErrInvalidWalletControls)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	controls.UpdatedAt = time.Now().Unix()
+	f.controls[controls.Address] = controls
+	return controls, nil
+}
+
+func (f *Blockchain) ReserveWalletSpend(address string, value uint64) (func(), error) {
+	if err := f.enter("ReserveWalletSpend"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	controls, exists := f.controls[address]
+	if !exists {
+		return func() {}, nil
+	}
+	if controls.DailyLimit > 0 && controls.Spent+value > controls.DailyLimit {
+		return nil, fmt.Errorf("%w: %d of %d already spent today", blockchain.ErrSpendingLimit, controls.Spent, controls.DailyLimit)
+	}
+	controls.Spent += value
+	f.controls[address] = controls
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		controls := f.controls[address]
+		controls.Spent -= value
+		f.controls[address] = controls
+	}, nil
+}
+
+func (f *Blockchain) VerifyWalletTOTP(address, code string) error {
+	return f.enter("VerifyWalletTOTP")
+}
+
+// Address labels
+
+func (f *Blockchain) AddressLabels(category string) []blockchain.AddressLabel {
+	f.enter("AddressLabels")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	labels := []blockchain.AddressLabel{}
+	for _, label := range f.labels {
+		if category == "" || label.Category == category {
+			labels = append(labels, label)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Address < labels[j].Address })
+	return labels
+}
+
+func (f *Blockchain) GetAddressLabel(address string) (blockchain.AddressLabel, bool) {
+	f.enter("GetAddressLabel")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	label, exists := f.labels[address]
+	return label, exists
+}
+
+func (f *Blockchain) SetAddressLabel(label blockchain.AddressLabel) (blockchain.AddressLabel, error) {
+	if err := f.enter("SetAddressLabel"); err != nil {
+		return blockchain.AddressLabel{}, err
+	}
+	if label.Address == "" || label.Label == "" {
+		return blockchain.AddressLabel{}, fmt.Errorf("%w: address and label are required", blockchain.ErrInvalidLabel)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	label.Source = "admin"
+	label.UpdatedAt = time.Now().Unix()
+	f.labels[label.Address] = label
+	return label, nil
+}
+
+func (f *Blockchain) RemoveAddressLabel(address string) error {
+	if err := f.enter("RemoveAddressLabel"); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, exists := f.labels[address]; !exists {
+		return fmt.Errorf("%w: %s", blockchain.ErrLabelNotFound, address)
+	}
+	delete(f.labels, address)
+	return nil
+}
+
+// Address books
+
+func (f *Blockchain) Contacts(owner string) []blockchain.Contact {
+	f.enter("Contacts")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	contacts := []blockchain.Contact{}
+	for _, contact := range f.contacts[owner] {
+		contacts = append(contacts, contact)
+	}
+	sort.Slice(contacts, func(i, j int) bool {
+		return strings.ToLower(contacts[i].Name) < strings.ToLower(contacts[j].Name)
+	})
+	return contacts
+}
+
+func (f *Blockchain) SaveContact(owner string, contact blockchain.Contact) (blockchain.Contact, error) {
+	if err := f.enter("SaveContact"); err != nil {
+		return blockchain.Contact{}, err
+	}
+	if owner == "" || contact.Name == "" || contact.Address == "" {
+		return blockchain.Contact{}, fmt.Errorf("%w: owner, name and address are required", blockchain.ErrInvalidContact)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.contacts[owner] == nil {
+		f.contacts[owner] = make(map[string]blockchain.Contact)
+	}
+	now := time.Now().Unix()
+	key := strings.ToLower(contact.Name)
+	contact.CreatedAt = now
+	if existing, exists := f.contacts[owner][key]; exists {
+		contact.CreatedAt = existing.CreatedAt
+	}
+	contact.UpdatedAt = now
+	f.contacts[owner][key] = contact
+	return contact, nil
+}
+
+func (f *Blockchain) RemoveContact(owner, name string) error {
+	if err := f.enter("RemoveContact"); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := strings.ToLower(name)
+	if _, exists := f.contacts[owner][key]; !exists {
+		return fmt.Errorf("%w: %s", blockchain.ErrContactNotFound, name)
+	}
+	delete(f.contacts[owner], key)
+	return nil
+}
+
+func (f *Blockchain) ResolveContact(owner, name string) (blockchain.Contact, error) {
+	if err := f.enter("ResolveContact"); err != nil {
+		return blockchain.Contact{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	contact, exists := f.contacts[owner][strings.ToLower(name)]
+	if !exists {
+		return blockchain.Contact{}, fmt.Errorf("%w: %s", blockchain.ErrContactNotFound, name)
+	}
+	return contact, nil
+}
+
+// Payment requests
+
+func (f *Blockchain) CreatePaymentRequest(owner, recipient string, amount uint64, memo string, ttl time.Duration) (blockchain.PaymentRequest, error) {
+	if err := f.enter("CreatePaymentRequest"); err != nil {
+		return blockchain.PaymentRequest{}, err
+	}
+	if recipient == "" || amount == 0 {
+		return blockchain.PaymentRequest{}, fmt.Errorf("%w: recipient and amount are required", blockchain.ErrInvalidPaymentRequest)
+	}
+	if ttl == 0 {
+		ttl = blockchain.DefaultPaymentRequestTTL
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	request := blockchain.PaymentRequest{
+		ID:        fmt.Sprintf("request-%d", len(f.payments)+1),
+		Recipient: recipient,
+		Amount:    amount,
+		Memo:      memo,
+		CreatedBy: owner,
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+		Status:    blockchain.PaymentRequestPending,
+		Payments:  []blockchain.PaymentMatch{},
+	}
+	f.payments[request.ID] = request
+	return request, nil
+}
+
+func (f *Blockchain) GetPaymentRequest(id string) (blockchain.PaymentRequest, error) {
+	if err := f.enter("GetPaymentRequest"); err != nil {
+		return blockchain.PaymentRequest{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	request, exists := f.payments[id]
+	if !exists {
+		return blockchain.PaymentRequest{}, fmt.Errorf("%w: %s", blockchain.ErrPaymentRequestNotFound, id)
+	}
+	return request, nil
+}
+
+func (f *Blockchain) PaymentRequests(recipient, status string) []blockchain.PaymentRequest {
+	f.enter("PaymentRequests")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	requests := []blockchain.PaymentRequest{}
+	for _, request := range f.payments {
+		if (recipient == "" || request.Recipient == recipient) && (status == "" || request.Status == status) {
+			requests = append(requests, request)
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].ID < requests[j].ID })
+	return requests
+}
+
+func (f *Blockchain) CancelPaymentRequest(owner, id string) (blockchain.PaymentRequest, error) {
+	if err := f.enter("CancelPaymentRequest"); err != nil {
+		return blockchain.PaymentRequest{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	request, exists := f.payments[id]
+	if !exists || request.CreatedBy != owner {
+		return blockchain.PaymentRequest{}, fmt.Errorf("%w: %s", blockchain.ErrPaymentRequestNotFound, id)
+	}
+	if request.Status != blockchain.PaymentRequestPending && request.Status != blockchain.PaymentRequestPartial {
+		return blockchain.PaymentRequest{}, fmt.Errorf("%w: %s is %s", blockchain.ErrPaymentRequestClosed, id, request.Status)
+	}
+	request.Status = blockchain.PaymentRequestCancelled
+	f.payments[id] = request
+	return request, nil
+}
+
+// Multi-signature wallets
+
+func (f *Blockchain) CreateMultiSigWallet(address string, owners []string, requiredSigs int) error {
+	if err := f.enter("CreateMultiSigWallet"); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, exists := f.wallets[address]; exists {
+		return blockchain.ErrMultiSigWalletExists
+	}
+	wallet, err := blockchain.NewMultiSigWallet(address, owners, requiredSigs)
+	if err != nil {
+		return err
+	}
+	f.wallets[address] = wallet
+	return nil
+}
+
+func (f *Blockchain) GetMultiSigWallet(address string) (*blockchain.MultiSigWallet, error) {
+	if err := f.enter("GetMultiSigWallet"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	wallet, exists := f.wallets[address]
+	if !exists {
+		return nil, blockchain.ErrMultiSigWalletNotFound
+	}
+	return wallet, nil
+}
+
+func (f *Blockchain) CreateMultiSigTransaction(walletAddress, from, to string, value string, data []byte, txType string) (*blockchain.MultiSigTransaction, error) {
+	wallet, err := f.GetMultiSigWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.CreateTransaction(from, to, value, data, txType)
+}
+
+func (f *Blockchain) SignMultiSigTransaction(walletAddress, txID, signer string, signature string) error {
+	wallet, err := f.GetMultiSigWallet(walletAddress)
+	if err != nil {
+		return err
+	}
+	keyPair, exists := f.GetKeyPair(signer)
+	if !exists {
+		if !wallet.IsOwner(signer) {
+			return fmt.Errorf("%w: signer %s", blockchain.ErrNotOwner, signer)
+		}
+		return fmt.Errorf("%w: owner %s has no registered key", blockchain.ErrKeyPairNotFound, signer)
+	}
+	return wallet.SignTransaction(txID, signer, signature, keyPair.Public())
+}
+
+func (f *Blockchain) ExecuteMultiSigTransaction(walletAddress, txID string) error {
+	wallet, err := f.GetMultiSigWallet(walletAddress)
+	if err != nil {
+		return err
+	}
+	tx, err := wallet.ExecuteTransaction(txID)
+	if err != nil {
+		return err
+	}
+	return f.AddTransaction(tx)
+}
+
+func (f *Blockchain) GetMultiSigTransactionStatus(walletAddress, txID string) (string, error) {
+	wallet, err := f.GetMultiSigWallet(walletAddress)
+	if err != nil {
+		return "", err
+	}
+	return wallet.GetTransactionStatus(txID)
+}
+
+func (f *Blockchain) GetMultiSigPendingTransactions(walletAddress string) ([]*blockchain.MultiSigTransaction, error) {
+	wallet, err := f.GetMultiSigWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.GetPendingTransactions(), nil
+}
+
+func (f *Blockchain) MultiSigSigningHash(walletAddress, txID string) ([]byte, error) {
+	wallet, err := f.GetMultiSigWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	for _, tx := range wallet.GetPendingTransactions() {
+		if tx.ID == txID {
+			return tx.SigningHash(walletAddress)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", blockchain.ErrTxNotFound, txID)
+}
+
+func (f *Blockchain) SetMultiSigThresholdKey(walletAddress, groupKey string, approvals map[string]string) error {
+	if err := f.enter("SetMultiSigThresholdKey"); err != nil {
+		return err
+	}
+	return errors.New("the fake blockchain does not support threshold keys")
+}
+
+func (f *Blockchain) SubmitMultiSigThresholdSignature(walletAddress, txID, signature string) error {
+	wallet, err := f.GetMultiSigWallet(walletAddress)
+	if err != nil {
+		return err
+	}
+	return wallet.SubmitThresholdSignature(txID, signature)
+}
+
+// API keys
+
+func (f *Blockchain) APIKeys() []blockchain.APIKey {
+	f.enter("APIKeys")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]blockchain.APIKey, 0, len(f.apiKeys))
+	for _, key := range f.apiKeys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys
+}
+
+func (f *Blockchain) GetAPIKey(id string) (blockchain.APIKey, bool) {
+	f.enter("GetAPIKey")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key, exists := f.apiKeys[id]
+	return key, exists
+}
+
+func (f *Blockchain) CreateAPIKey(name string, scopes []string, rateLimit int, createdBy string) (string, blockchain.APIKey, error) {
+	if err := f.enter("CreateAPIKey"); err != nil {
+		return "", blockchain.APIKey{}, err
+	}
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", blockchain.APIKey{}, err
+	}
+	secret := hex.EncodeToString(raw)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := blockchain.APIKey{
+		ID:        fmt.Sprintf("key-%d", len(f.apiKeys)+1),
+		Name:      name,
+		Scopes:    scopes,
+		RateLimit: rateLimit,
+		Hint:      secret[len(secret)-4:],
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().Unix(),
+	}
+	f.apiKeys[key.ID] = key
+	f.apiSecrets[secret] = key.ID
+	return secret, key, nil
+}
+
+func (f *Blockchain) RevokeAPIKey(id string) (blockchain.APIKey, error) {
+	if err := f.enter("RevokeAPIKey"); err != nil {
+		return blockchain.APIKey{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key, exists := f.apiKeys[id]
+	if !exists {
+		return blockchain.APIKey{}, fmt.Errorf("%w: %s", blockchain.ErrAPIKeyNotFound, id)
+	}
+	key.RevokedAt = time.Now().Unix()
+	f.apiKeys[id] = key
+	return key, nil
+}
+
+func (f *Blockchain) AuthenticateAPIKey(secret string) (blockchain.APIKey, error) {
+	if err := f.enter("AuthenticateAPIKey"); err != nil {
+		return blockchain.APIKey{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key, exists := f.apiKeys[f.apiSecrets[secret]]
+	if !exists || key.RevokedAt != 0 {
+		return blockchain.APIKey{}, blockchain.ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+func (f *Blockchain) RecordAPIKeyUsage(id string, outcome blockchain.APIKeyOutcome) {
+	f.enter("RecordAPIKeyUsage")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key, exists := f.apiKeys[id]
+	if !exists {
+		return
+	}
+	switch outcome {
+	case blockchain.APIKeyServed:
+		key.Usage.Requests++
+	case blockchain.APIKeyThrottled:
+		key.Usage.Throttled++
+	case blockchain.APIKeyDenied:
+		key.Usage.Denied++
+	}
+	key.Usage.LastUsedAt = time.Now().Unix()
+	f.apiKeys[id] = key
+}
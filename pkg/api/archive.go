@@ -0,0 +1,11 @@
+package api
+
+import (
+	"net/http"
+)
+
+// getArchiveStatus handles GET /api/archive, reporting how much of the chain
+// has been offloaded to remote storage
+func (ws *WebServer) getArchiveStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ws.blockchain.GetArchiveStatus())
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// getBalanceAtHeight answers GET /api/wallet/balance/{address}?height=N with the
+// balance the address held after block N was applied
+func (ws *WebServer) getBalanceAtHeight(w http.ResponseWriter, address, heightParam string) {
+	height, err := strconv.ParseUint(heightParam, 10, 64)
+	if err != nil {
+		writeError(w, errors.New("height must be a non-negative integer"), http.StatusBadRequest)
+		return
+	}
+
+	balance, err := ws.wallets.GetBalanceAtHeight(address, height)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"address": address,
+		"height":  height,
+		"balance": balance.String(),
+	})
+}
+
+// getBalanceHistory handles GET /api/wallet/balance/{address}/history,
+// listing every block that changed the balance of the address
+func (ws *WebServer) getBalanceHistory(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+
+	type point struct {
+		Height  uint64 `json:"height"`
+		Balance string `json:"balance"`
+	}
+	history := ws.wallets.GetBalanceHistory(address)
+	points := make([]point, len(history))
+	for i, p := range history {
+		points[i] = point{Height: p.Height, Balance: p.Balance.String()}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"address": address,
+		"history": points,
+	})
+}
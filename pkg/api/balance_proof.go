@@ -0,0 +1,53 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// errNoNodeKey is returned when the node has no key to sign balance proofs with
+var errNoNodeKey = errors.New("node has no signing key")
+
+// getBalanceProof handles GET /api/address/{address}/balance-proof?from=&to=,
+// every balance-affecting event of an address with block references and
+// running balances, signed with the key of this node for support disputes
+func (ws *WebServer) getBalanceProof(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	query := r.URL.Query()
+
+	from, err := parseStatementTime(query.Get("from"), false)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	to, err := parseStatementTime(query.Get("to"), true)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if ws.consensusEngine == nil {
+		writeError(w, errNoNodeKey, http.StatusServiceUnavailable)
+		return
+	}
+	signer := ws.consensusEngine.GetNodeAddress()
+	keyPair, exists := ws.wallets.GetKeyPair(signer)
+	if !exists {
+		writeError(w, fmt.Errorf("%w: %s", errNoNodeKey, signer), http.StatusServiceUnavailable)
+		return
+	}
+
+	proof, err := ws.wallets.BuildBalanceProof(address, from, to)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	if err := proof.Sign(signer, keyPair); err != nil {
+		writeError(w, fmt.Errorf("failed to sign balance proof: %w", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, proof)
+}
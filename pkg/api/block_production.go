@@ -0,0 +1,34 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// getBlockProduction handles GET /api/validators/production/{epoch}: the
+// blocks each validator produced in an epoch against its block quota.
+// "current" selects the epoch of the chain tip.
+func (ws *WebServer) getBlockProduction(w http.ResponseWriter, r *http.Request) {
+	raw := mux.Vars(r)["epoch"]
+	var epoch uint64
+	if raw == "current" {
+		epoch = ws.blockchain.GetChainHeight() / ws.blockchain.EpochRewardConfig().EpochLength
+	} else {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			writeError(w, errors.New("invalid epoch"), http.StatusBadRequest)
+			return
+		}
+		epoch = parsed
+	}
+
+	production, err := ws.blockchain.BlockProduction(epoch)
+	if err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+	writeLightJSON(w, r, http.StatusOK, production)
+}
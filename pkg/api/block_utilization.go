@@ -0,0 +1,25 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// maxUtilizationWindow caps the blocks one utilization request aggregates
+const maxUtilizationWindow = 10000
+
+// getBlockUtilization handles GET /api/blocks/utilization, aggregating how much
+// of the block capacity recent blocks used. ?last= sets the window (default 100).
+func (ws *WebServer) getBlockUtilization(w http.ResponseWriter, r *http.Request) {
+	window := 0
+	if raw := r.URL.Query().Get("last"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxUtilizationWindow {
+			writeError(w, errors.New("last must be between 1 and 10000"), http.StatusBadRequest)
+			return
+		}
+		window = parsed
+	}
+	writeJSON(w, http.StatusOK, ws.blockchain.UtilizationStats(window))
+}
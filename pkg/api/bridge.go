@@ -0,0 +1,160 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/units"
+
+	"github.com/google/uuid"
+)
+
+// getBridgeStatus handles GET /api/bridge, reporting the escrow balance and
+// the approvals a release needs. Relayers follow the bridge_lock events, e.g.
+// with GET /api/events?type=bridge_lock.
+func (ws *WebServer) getBridgeStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ws.blockchain.BridgeStatus())
+}
+
+// bridgeLock handles POST /api/bridge/lock, locking ConX of a custodied
+// wallet in the bridge escrow for an address on another chain. The wallet
+// must be unlocked and pass its wallet controls, as for transfers. Wallets
+// the node does not hold submit a signed bridge_lock transaction to
+// /api/transactions instead.
+func (ws *WebServer) bridgeLock(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		From          string `json:"from"`
+		Value         units.Uint64 `json:"value"`
+		TargetChain   string `json:"targetChain"`
+		TargetAddress string `json:"targetAddress"`
+		OTP           string `json:"otp,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
+		return
+	}
+	if req.From == "" || req.Value == 0 {
+		writeError(w, errors.New("Missing required fields"), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := blockchain.NewBridgeLockTransaction(uuid.New().String(), req.From, uint64(req.Value), blockchain.BridgeLock{
+		TargetChain:   req.TargetChain,
+		TargetAddress: req.TargetAddress,
+	})
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	if err := ws.signWithSession(r, tx); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	release, err := ws.authorizeCustodialSpend(tx, req.OTP)
+	if err != nil {
+		writeError(w, err, http.StatusForbidden)
+		return
+	}
+	if err := ws.blockchain.AddTransaction(tx); err != nil {
+		release()
+		writeError(w, fmt.Errorf("failed to submit bridge lock: %w", err), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusCreated, tx)
+}
+
+// bridgeReleaseQuery reads the release a query describes
+func bridgeReleaseQuery(r *http.Request) (sourceChain, sourceTx, to string, value uint64, err error) {
+	query := r.URL.Query()
+	sourceChain, sourceTx, to = query.Get("sourceChain"), query.Get("sourceTx"), query.Get("to")
+	if sourceChain == "" || sourceTx == "" {
+		return "", "", "", 0, fmt.Errorf("%w: sourceChain and sourceTx are required", blockchain.ErrInvalidBridgeTransfer)
+	}
+	if raw := query.Get("value"); raw != "" {
+		if value, err = strconv.ParseUint(raw, 10, 64); err != nil {
+			return "", "", "", 0, fmt.Errorf("%w: invalid value %q", blockchain.ErrInvalidBridgeTransfer, raw)
+		}
+	}
+	return sourceChain, sourceTx, to, value, nil
+}
+
+// getBridgeReleaseHash handles GET /api/bridge/release/hash?sourceChain=&sourceTx=&to=&value=,
+// returning the digest validators sign to approve a release
+func (ws *WebServer) getBridgeReleaseHash(w http.ResponseWriter, r *http.Request) {
+	sourceChain, sourceTx, to, value, err := bridgeReleaseQuery(r)
+	if err == nil && (to == "" || value == 0) {
+		err = fmt.Errorf("%w: to and value are required", blockchain.ErrInvalidBridgeTransfer)
+	}
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	hash, err := blockchain.BridgeReleaseHash(sourceChain, sourceTx, to, value)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"hash":              hex.EncodeToString(hash),
+		"requiredApprovals": ws.blockchain.BridgeStatus().RequiredApprovals,
+	})
+}
+
+// getBridgeReleaseStatus handles GET /api/bridge/release/status?sourceChain=&sourceTx=,
+// telling relayers whether a source transaction was released already
+func (ws *WebServer) getBridgeReleaseStatus(w http.ResponseWriter, r *http.Request) {
+	sourceChain, sourceTx, _, _, err := bridgeReleaseQuery(r)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	txID, released := ws.blockchain.BridgeReleaseTx(sourceChain, sourceTx)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sourceChain": sourceChain,
+		"sourceTx":    sourceTx,
+		"released":    released,
+		"txId":        txID,
+	})
+}
+
+// bridgeRelease handles POST /api/bridge/release. A relayer submits the
+// release of a burned wrapped asset with the validator approvals it
+// collected; the release is checked like any bridge transaction and added to
+// the pool.
+func (ws *WebServer) bridgeRelease(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SourceChain string            `json:"sourceChain"`
+		SourceTx    string            `json:"sourceTx"`
+		To          string            `json:"to"`
+		Value       units.Uint64      `json:"value"`
+		Approvals   map[string]string `json:"approvals"` // validator address -> hex signature over the release hash
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
+		return
+	}
+	if req.To == "" || req.Value == 0 {
+		writeError(w, errors.New("Missing required fields"), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := blockchain.NewBridgeReleaseTransaction(blockchain.BridgeRelease{
+		SourceChain: req.SourceChain,
+		SourceTx:    req.SourceTx,
+		Approvals:   req.Approvals,
+	}, req.To, uint64(req.Value))
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	if err := ws.blockchain.AddTransaction(tx); err != nil {
+		writeError(w, fmt.Errorf("failed to submit bridge release: %w", err), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusCreated, tx)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"confirmix/pkg/blockchain"
+
+	"github.com/google/uuid"
+)
+
+// Actions that must be signed to pause and resume the chain
+const (
+	ActionCircuitBreakerPause  = "circuit_breaker_pause"
+	ActionCircuitBreakerResume = "circuit_breaker_resume"
+)
+
+// getCircuitBreaker handles GET /api/circuit-breaker, reporting whether the
+// chain is paused and the pauses and resumes confirmed so far
+func (ws *WebServer) getCircuitBreaker(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ws.blockchain.CircuitBreaker())
+}
+
+// pauseChain handles POST /api/admin/circuit-breaker/pause
+func (ws *WebServer) pauseChain(w http.ResponseWriter, r *http.Request) {
+	ws.tripCircuitBreaker(w, r, ActionCircuitBreakerPause, true)
+}
+
+// resumeChain handles POST /api/admin/circuit-breaker/resume
+func (ws *WebServer) resumeChain(w http.ResponseWriter, r *http.Request) {
+	ws.tripCircuitBreaker(w, r, ActionCircuitBreakerResume, false)
+}
+
+// tripCircuitBreaker submits a signed admin's pause or resume as a circuit
+// breaker transaction of a validator whose key this node holds: the one in
+// Data["validator"], or this node's own. The action takes effect on every
+// node once a block confirms it.
+func (ws *WebServer) tripCircuitBreaker(w http.ResponseWriter, r *http.Request, action string, halt bool) {
+	req := ws.decodeAdminRequest(w, r, action)
+	if req == nil {
+		return
+	}
+
+	reason := strings.TrimSpace(req.Data["reason"])
+	if reason == "" {
+		writeError(w, errors.New("a reason is required in request data"), http.StatusBadRequest)
+		return
+	}
+	validator := req.Data["validator"]
+	if validator == "" && ws.consensusEngine != nil {
+		validator = ws.consensusEngine.GetNodeAddress()
+	}
+	if validator == "" {
+		writeError(w, errors.New("validator is required in request data"), http.StatusBadRequest)
+		return
+	}
+	keyPair, exists := ws.wallets.GetKeyPair(validator)
+	if !exists {
+		writeError(w, fmt.Errorf("%w: validator %s", blockchain.ErrKeyPairNotFound, validator), http.StatusNotFound)
+		return
+	}
+
+	tx, err := blockchain.NewCircuitBreakerTransaction(uuid.New().String(), validator, blockchain.CircuitBreakerAction{
+		Halt:   halt,
+		Reason: reason,
+		Admin:  req.AdminAddress,
+	})
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	if err := tx.SignWith(keyPair.Signer()); err != nil {
+		writeError(w, fmt.Errorf("failed to sign circuit breaker transaction: %w", err), http.StatusInternalServerError)
+		return
+	}
+	if err := ws.blockchain.AddTransaction(tx); err != nil {
+		writeError(w, fmt.Errorf("failed to submit circuit breaker transaction: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Admin %s submitted circuit breaker transaction %s (halt=%v) through validator %s: %s",
+		req.AdminAddress, tx.ID, halt, validator, reason)
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"status": "submitted",
+		"halt":   halt,
+		"txId":   tx.ID,
+	})
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultCompressionMinSize is the smallest response compressed by default.
+// Below about a kilobyte the encoding overhead outweighs the savings.
+const DefaultCompressionMinSize = 1024
+
+// Compression configures the gzip and deflate encoding of API responses.
+// Responses are compressed once they reach MinSize bytes, in the encoding
+// the client prefers; smaller ones and event streams are sent as they are.
+// Compression is on by default.
+type Compression struct {
+	Disabled bool `json:"disabled,omitempty"`
+	MinSize  int  `json:"min_size,omitempty"` // bytes, DefaultCompressionMinSize when 0
+	Level    int  `json:"level,omitempty"`    // 1 (fastest) to 9 (smallest), the library default when 0
+}
+
+// ValidateCompression checks compression settings without applying them
+func ValidateCompression(c Compression) error {
+	if c.MinSize < 0 {
+		return fmt.Errorf("compression min_size must not be negative, got %d", c.MinSize)
+	}
+	if c.Level < 0 || c.Level > gzip.BestCompression {
+		return fmt.Errorf("compression level must be between 1 and %d, got %d", gzip.BestCompression, c.Level)
+	}
+	return nil
+}
+
+// compressionState holds the settings in effect; they are swapped as a whole on reload
+type compressionState struct {
+	settings atomic.Value // Compression
+}
+
+// load returns the settings in effect with defaults filled in
+func (c *compressionState) load() Compression {
+	settings, _ := c.settings.Load().(Compression)
+	if settings.MinSize == 0 {
+		settings.MinSize = DefaultCompressionMinSize
+	}
+	if settings.Level == 0 {
+		settings.Level = gzip.DefaultCompression
+	}
+	return settings
+}
+
+// SetCompression validates and replaces the response compression settings.
+// A nil value restores the defaults.
+func (ws *WebServer) SetCompression(c *Compression) error {
+	if c == nil {
+		c = &Compression{}
+	}
+	if err := ValidateCompression(*c); err != nil {
+		return err
+	}
+	ws.compression.settings.Store(*c)
+	return nil
+}
+
+// Compression returns the response compression settings in effect
+func (ws *WebServer) Compression() Compression {
+	return ws.compression.load()
+}
+
+// acceptedEncoding picks the response encoding from the Accept-Encoding
+// header: gzip when accepted, then deflate, or "" for none
+func acceptedEncoding(r *http.Request) string {
+	accepted := make(map[string]bool)
+	for _, item := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params := item, ""
+		if i := strings.Index(item, ";"); i >= 0 {
+			name, params = item[:i], item[i+1:]
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if q := strings.TrimSpace(params); strings.HasPrefix(q, "q=") {
+			if weight, err := strconv.ParseFloat(q[2:], 64); err == nil && weight == 0 {
+				continue
+			}
+		}
+		accepted[name] = true
+	}
+	switch {
+	case accepted["gzip"] || accepted["*"]:
+		return "gzip"
+	case accepted["deflate"]:
+		return "deflate"
+	}
+	return ""
+}
+
+// incompressibleTypes are content types sent as they are: streams that must
+// reach the client as they are written, and data that is already compressed
+var incompressibleTypes = []string{
+	"text/event-stream",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zip",
+	"application/octet-stream",
+	"image/",
+	"video/",
+	"audio/",
+}
+
+// compressible reports whether a response with these headers may be compressed
+func compressible(header http.Header) bool {
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	for _, prefix := range incompressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// addVary adds value to the Vary header unless it is already listed
+func addVary(header http.Header, value string) {
+	for _, existing := range header.Values("Vary") {
+		for _, field := range strings.Split(existing, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), value) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", value)
+}
+
+// compressResponses encodes large responses with gzip or deflate for the
+// clients that accept it, see Compression
+func (ws *WebServer) compressResponses(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		settings := ws.compression.load()
+		encoding := acceptedEncoding(r)
+		if settings.Disabled || encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, settings: settings}
+		defer cw.finish()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter buffers the start of a response until it reaches the
+// minimum size, then compresses the rest on the fly. Smaller responses,
+// flushed streams and incompressible content pass through unchanged.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	settings Compression
+	status   int
+	buf      bytes.Buffer
+	encoder  io.WriteCloser // set once compressing
+	direct   bool           // passing the response through
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.status != 0 {
+		return
+	}
+	cw.status = status
+	// Bodiless responses and incompressible content are not held back
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified ||
+		!compressible(cw.Header()) {
+		cw.passThrough()
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	switch {
+	case cw.direct:
+		return cw.ResponseWriter.Write(b)
+	case cw.encoder != nil:
+		return cw.encoder.Write(b)
+	}
+
+	cw.buf.Write(b)
+	if cw.buf.Len() >= cw.settings.MinSize {
+		if err := cw.startCompression(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush sends what was written so far. A response flushed before it reached
+// the minimum size is a stream and passes through uncompressed.
+func (cw *compressWriter) Flush() {
+	if cw.encoder == nil && !cw.direct {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		cw.passThrough()
+	}
+	if flusher, ok := cw.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// startCompression sends the headers of a compressed response and the buffered start of the body
+func (cw *compressWriter) startCompression() error {
+	header := cw.Header()
+	header.Set("Content-Encoding", cw.encoding)
+	header.Del("Content-Length")
+	addVary(header, "Accept-Encoding")
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	var err error
+	if cw.encoding == "gzip" {
+		cw.encoder, err = gzip.NewWriterLevel(cw.ResponseWriter, cw.settings.Level)
+	} else {
+		cw.encoder, err = flate.NewWriter(cw.ResponseWriter, cw.settings.Level)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = cw.encoder.Write(cw.buf.Bytes())
+	cw.buf.Reset()
+	return err
+}
+
+// passThrough sends the status and the buffered body as they are and the rest of the response directly
+func (cw *compressWriter) passThrough() {
+	cw.direct = true
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if cw.buf.Len() > 0 {
+		cw.ResponseWriter.Write(cw.buf.Bytes())
+		cw.buf.Reset()
+	}
+}
+
+// finish completes the response once the handler returns
+func (cw *compressWriter) finish() {
+	switch {
+	case cw.encoder != nil:
+		cw.encoder.Close()
+	case !cw.direct && cw.status != 0:
+		cw.passThrough()
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+)
+
+// corsPolicy is an immutable set of allowed origins; it is swapped as a whole on reload
+type corsPolicy struct {
+	allowAll bool
+	origins  map[string]bool
+	list     []string
+}
+
+// corsState holds the current CORS policy. The zero value allows every origin.
+type corsState struct {
+	policy atomic.Value // *corsPolicy
+}
+
+// load returns the current policy
+func (c *corsState) load() *corsPolicy {
+	if policy, ok := c.policy.Load().(*corsPolicy); ok {
+		return policy
+	}
+	return &corsPolicy{allowAll: true, list: []string{"*"}}
+}
+
+// allowedOrigin returns the Access-Control-Allow-Origin value for a request origin,
+// or "" when the origin is not allowed
+func (p *corsPolicy) allowedOrigin(origin string) string {
+	if p.allowAll {
+		return "*"
+	}
+	if origin != "" && p.origins[origin] {
+		return origin
+	}
+	return ""
+}
+
+// newCORSPolicy validates origins and builds a policy. An empty list or "*" allows every origin.
+func newCORSPolicy(origins []string) (*corsPolicy, error) {
+	policy := &corsPolicy{origins: make(map[string]bool)}
+	if len(origins) == 0 {
+		policy.allowAll = true
+		policy.list = []string{"*"}
+		return policy, nil
+	}
+
+	for _, origin := range origins {
+		if origin == "*" {
+			policy.allowAll = true
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return nil, fmt.Errorf("invalid CORS origin %q: expected scheme://host[:port]", origin)
+		}
+		normalized := u.Scheme + "://" + u.Host
+		if !policy.origins[normalized] {
+			policy.origins[normalized] = true
+			policy.list = append(policy.list, normalized)
+		}
+	}
+	if policy.allowAll {
+		policy.list = []string{"*"}
+	}
+	return policy, nil
+}
+
+// ValidateCORSOrigins checks a list of origins without applying it
+func ValidateCORSOrigins(origins []string) error {
+	_, err := newCORSPolicy(origins)
+	return err
+}
+
+// SetCORSOrigins validates and atomically replaces the allowed CORS origins
+func (ws *WebServer) SetCORSOrigins(origins []string) error {
+	policy, err := newCORSPolicy(origins)
+	if err != nil {
+		return err
+	}
+	ws.cors.policy.Store(policy)
+	return nil
+}
+
+// CORSOrigins returns the allowed CORS origins
+func (ws *WebServer) CORSOrigins() []string {
+	return append([]string(nil), ws.cors.load().list...)
+}
+
+// enableCORS applies the CORS policy to all routes
+func (ws *WebServer) enableCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed := ws.cors.load().allowedOrigin(r.Header.Get("Origin"))
+
+		cw := &corsResponseWriter{ResponseWriter: w, origin: allowed}
+		cw.apply()
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept-Language, X-API-Key, If-None-Match, If-Modified-Since")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Last-Modified, X-Next-Cursor")
+		w.Header().Set("Access-Control-Max-Age", "3600")
+
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// corsResponseWriter makes the policy authoritative: handlers that set their own
+// Access-Control-Allow-Origin header have it replaced before the response is sent
+type corsResponseWriter struct {
+	http.ResponseWriter
+	origin      string
+	wroteHeader bool
+}
+
+// apply sets the allowed origin on the response headers
+func (cw *corsResponseWriter) apply() {
+	header := cw.ResponseWriter.Header()
+	if cw.origin == "" {
+		header.Del("Access-Control-Allow-Origin")
+		return
+	}
+	header.Set("Access-Control-Allow-Origin", cw.origin)
+	if cw.origin != "*" {
+		header.Add("Vary", "Origin")
+	}
+}
+
+func (cw *corsResponseWriter) WriteHeader(status int) {
+	if !cw.wroteHeader {
+		cw.wroteHeader = true
+		header := cw.ResponseWriter.Header()
+		if header.Get("Access-Control-Allow-Origin") != cw.origin {
+			if cw.origin == "" {
+				header.Del("Access-Control-Allow-Origin")
+			} else {
+				header.Set("Access-Control-Allow-Origin", cw.origin)
+			}
+		}
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *corsResponseWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush keeps streaming endpoints (server-sent events) working through the wrapper
+func (cw *corsResponseWriter) Flush() {
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
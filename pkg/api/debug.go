@@ -0,0 +1,169 @@
+package api
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// pprofRoute is the prefix of the profiling endpoints. It is under /api/admin/
+// so the admin access list applies and API keys need the admin scope.
+const pprofRoute = "/api/admin/debug/pprof/"
+
+// DebugEndpoints switches the diagnostics endpoints on. Both are off by default.
+type DebugEndpoints struct {
+	Pprof   bool `json:"pprof,omitempty"`   // /api/admin/debug/pprof/*, for API keys with the admin scope
+	Runtime bool `json:"runtime,omitempty"` // /api/debug/runtime
+}
+
+// debugState holds the current switches; it is swapped as a whole on reload
+type debugState struct {
+	endpoints atomic.Value // DebugEndpoints
+}
+
+// load returns the switches in effect
+func (d *debugState) load() DebugEndpoints {
+	endpoints, _ := d.endpoints.Load().(DebugEndpoints)
+	return endpoints
+}
+
+// SetDebugEndpoints switches the profiling and runtime endpoints on or off.
+// A nil value switches both off.
+func (ws *WebServer) SetDebugEndpoints(endpoints *DebugEndpoints) {
+	if endpoints == nil {
+		endpoints = &DebugEndpoints{}
+	}
+	ws.debug.endpoints.Store(*endpoints)
+}
+
+// DebugEndpoints returns the diagnostics endpoints in effect
+func (ws *WebServer) DebugEndpoints() DebugEndpoints {
+	return ws.debug.load()
+}
+
+// RuntimeStats describes the Go runtime of the node
+type RuntimeStats struct {
+	GoVersion  string    `json:"goVersion"`
+	GOOS       string    `json:"goos"`
+	GOARCH     string    `json:"goarch"`
+	NumCPU     int       `json:"numCpu"`
+	GOMAXPROCS int       `json:"gomaxprocs"`
+	Goroutines int       `json:"goroutines"`
+	CgoCalls   int64     `json:"cgoCalls"`
+	Heap       HeapStats `json:"heap"`
+	GC         GCStats   `json:"gc"`
+	Timestamp  int64     `json:"timestamp"`
+}
+
+// HeapStats is the heap usage in bytes
+type HeapStats struct {
+	Alloc       uint64 `json:"alloc"`      // bytes of live and not yet swept objects
+	Sys         uint64 `json:"sys"`        // bytes obtained from the OS for the heap
+	Idle        uint64 `json:"idle"`       // bytes in unused spans
+	InUse       uint64 `json:"inUse"`      // bytes in spans holding objects
+	Released    uint64 `json:"released"`   // bytes returned to the OS
+	Objects     uint64 `json:"objects"`    // live objects
+	TotalAlloc  uint64 `json:"totalAlloc"` // bytes allocated since the node started
+	Mallocs     uint64 `json:"mallocs"`
+	Frees       uint64 `json:"frees"`
+	StackInUse  uint64 `json:"stackInUse"`
+	TotalFromOS uint64 `json:"totalFromOs"` // every byte the runtime obtained from the OS
+}
+
+// GCStats summarizes garbage collection since the node started
+type GCStats struct {
+	Cycles       uint32  `json:"cycles"`
+	Forced       uint32  `json:"forced"`
+	NextTarget   uint64  `json:"nextTarget"` // heap size that triggers the next cycle
+	LastCycle    int64   `json:"lastCycle,omitempty"`
+	LastPauseNs  uint64  `json:"lastPauseNs"`
+	PauseTotalNs uint64  `json:"pauseTotalNs"`
+	CPUFraction  float64 `json:"cpuFraction"` // share of CPU time spent in GC
+}
+
+// readRuntimeStats collects the runtime statistics. ReadMemStats stops the
+// world briefly, so the endpoint is off unless switched on.
+func readRuntimeStats() RuntimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := RuntimeStats{
+		GoVersion:  runtime.Version(),
+		GOOS:       runtime.GOOS,
+		GOARCH:     runtime.GOARCH,
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Goroutines: runtime.NumGoroutine(),
+		CgoCalls:   runtime.NumCgoCall(),
+		Heap: HeapStats{
+			Alloc:       mem.HeapAlloc,
+			Sys:         mem.HeapSys,
+			Idle:        mem.HeapIdle,
+			InUse:       mem.HeapInuse,
+			Released:    mem.HeapReleased,
+			Objects:     mem.HeapObjects,
+			TotalAlloc:  mem.TotalAlloc,
+			Mallocs:     mem.Mallocs,
+			Frees:       mem.Frees,
+			StackInUse:  mem.StackInuse,
+			TotalFromOS: mem.Sys,
+		},
+		GC: GCStats{
+			Cycles:       mem.NumGC,
+			Forced:       mem.NumForcedGC,
+			NextTarget:   mem.NextGC,
+			PauseTotalNs: mem.PauseTotalNs,
+			CPUFraction:  mem.GCCPUFraction,
+		},
+		Timestamp: time.Now().Unix(),
+	}
+	if mem.NumGC > 0 {
+		stats.GC.LastCycle = time.Unix(0, int64(mem.LastGC)).Unix()
+		stats.GC.LastPauseNs = mem.PauseNs[(mem.NumGC+255)%256]
+	}
+	return stats
+}
+
+// getRuntimeStats handles GET /api/debug/runtime, returning goroutine counts,
+// heap usage and GC statistics
+func (ws *WebServer) getRuntimeStats(w http.ResponseWriter, r *http.Request) {
+	if !ws.debug.load().Runtime {
+		writeErrorCode(w, http.StatusNotFound, CodeNotFound, "the runtime endpoint is disabled on this node")
+		return
+	}
+	writeJSON(w, http.StatusOK, readRuntimeStats())
+}
+
+// servePprof handles /api/admin/debug/pprof/ and the profiles under it, e.g.
+// /api/admin/debug/pprof/heap or /api/admin/debug/pprof/profile?seconds=30.
+// Profiles are not signed admin requests, so they need an API key with the
+// admin scope.
+func (ws *WebServer) servePprof(w http.ResponseWriter, r *http.Request) {
+	if !ws.debug.load().Pprof {
+		writeErrorCode(w, http.StatusNotFound, CodeNotFound, "profiling is disabled on this node")
+		return
+	}
+	if id, _ := r.Context().Value(apiKeyContextKey{}).(string); id == "" {
+		writeErrorCode(w, http.StatusUnauthorized, CodeInvalidAPIKey, "profiling requires an API key with the admin scope in the "+APIKeyHeader+" header")
+		return
+	}
+
+	switch profile := mux.Vars(r)["profile"]; profile {
+	case "":
+		pprof.Index(w, r)
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Handler(profile).ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// getEpochRewards handles GET /api/rewards/epochs/{epoch}: what the blocks of
+// an epoch earned per validator and, once the first block of the next epoch
+// is applied, how it was paid out to validators, delegators and the treasury.
+// "current" selects the epoch of the chain tip.
+func (ws *WebServer) getEpochRewards(w http.ResponseWriter, r *http.Request) {
+	raw := mux.Vars(r)["epoch"]
+	var epoch uint64
+	if raw == "current" {
+		epoch = ws.blockchain.GetChainHeight() / ws.blockchain.EpochRewardConfig().EpochLength
+	} else {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			writeError(w, errors.New("invalid epoch"), http.StatusBadRequest)
+			return
+		}
+		epoch = parsed
+	}
+
+	summary, err := ws.blockchain.EpochRewardSummary(epoch)
+	if err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+	writeLightJSON(w, r, http.StatusOK, summary)
+}
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"confirmix/pkg/backup"
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/consensus"
+	"confirmix/pkg/signer"
+	"confirmix/pkg/units"
+)
+
+// ErrorCode is a stable, machine-readable identifier for an API failure.
+// Clients should switch on the code instead of matching error text.
+type ErrorCode string
+
+const (
+	CodeInternal            ErrorCode = "INTERNAL_ERROR"
+	CodeBadRequest          ErrorCode = "BAD_REQUEST"
+	CodeUnauthorized        ErrorCode = "UNAUTHORIZED"
+	CodeNotFound            ErrorCode = "NOT_FOUND"
+	CodeConflict            ErrorCode = "CONFLICT"
+	CodeUnavailable         ErrorCode = "SERVICE_UNAVAILABLE"
+	CodeTimeout             ErrorCode = "TIMEOUT"
+	CodeInsufficientBalance ErrorCode = "INSUFFICIENT_BALANCE"
+	CodeInsufficientLocked  ErrorCode = "INSUFFICIENT_LOCKED_BALANCE"
+	CodeAccountNotFound     ErrorCode = "ACCOUNT_NOT_FOUND"
+	CodeAccountExists       ErrorCode = "ACCOUNT_EXISTS"
+	CodeSelfTransfer        ErrorCode = "SELF_TRANSFER"
+	CodeTxExists            ErrorCode = "TX_EXISTS"
+	CodeTxNotFound          ErrorCode = "TX_NOT_FOUND"
+	CodeNoPendingTxs        ErrorCode = "NO_PENDING_TRANSACTIONS"
+	CodeInvalidSignature    ErrorCode = "INVALID_SIGNATURE"
+	CodeBlockNotFound       ErrorCode = "BLOCK_NOT_FOUND"
+	CodeInvalidBlock        ErrorCode = "INVALID_BLOCK"
+	CodeUnknownValidator    ErrorCode = "UNKNOWN_VALIDATOR"
+	CodeValidatorExists     ErrorCode = "VALIDATOR_EXISTS"
+	CodeHumanProofRequired  ErrorCode = "HUMAN_PROOF_REQUIRED"
+	CodeKeyPairNotFound     ErrorCode = "KEY_PAIR_NOT_FOUND"
+	CodeMultiSigNotFound    ErrorCode = "MULTISIG_WALLET_NOT_FOUND"
+	CodeMultiSigExists      ErrorCode = "MULTISIG_WALLET_EXISTS"
+	CodeNotOwner            ErrorCode = "NOT_OWNER"
+	CodeAlreadySigned       ErrorCode = "ALREADY_SIGNED"
+	CodeNotEnoughSignatures ErrorCode = "NOT_ENOUGH_SIGNATURES"
+	CodeContractNotFound    ErrorCode = "CONTRACT_NOT_FOUND"
+	CodeVerificationExpired ErrorCode = "VERIFICATION_EXPIRED"
+	CodeVerificationFailed  ErrorCode = "VERIFICATION_FAILED"
+	CodeUpgradeRequired     ErrorCode = "UPGRADE_REQUIRED"
+	CodeDust                ErrorCode = "DUST_TRANSACTION"
+	CodeReadOnly            ErrorCode = "READ_ONLY_NODE"
+	CodeInvalidAPIKey       ErrorCode = "INVALID_API_KEY"
+	CodeAPIKeyScope         ErrorCode = "API_KEY_SCOPE_DENIED"
+	CodeRateLimited         ErrorCode = "RATE_LIMITED"
+	CodeAccessDenied        ErrorCode = "ACCESS_DENIED"
+	CodeWalletLocked        ErrorCode = "WALLET_LOCKED"
+	CodeSpendingLimit       ErrorCode = "SPENDING_LIMIT_EXCEEDED"
+	CodeSecondFactor        ErrorCode = "SECOND_FACTOR_REQUIRED"
+	CodeMaintenance         ErrorCode = "MAINTENANCE"
+	CodeBlockQuota          ErrorCode = "BLOCK_QUOTA_EXCEEDED"
+	CodeChainHalted         ErrorCode = "CHAIN_HALTED"
+)
+
+// errInvalidAdminSignature is returned when a signed admin request fails verification
+var errInvalidAdminSignature = errors.New("invalid signature")
+
+// errNoValidatorManager is returned by validator and admin routes on a node
+// served without a validator manager
+var errNoValidatorManager = errors.New("validator management is not available on this node")
+
+// errorMapping ties a sentinel error to its API code and HTTP status
+type errorMapping struct {
+	err    error
+	code   ErrorCode
+	status int
+}
+
+// errorMappings is the single place where domain errors are translated
+// into API error codes and HTTP statuses. The first match wins.
+var errorMappings = []errorMapping{
+	{errInvalidAdminSignature, CodeUnauthorized, http.StatusUnauthorized},
+	{blockchain.ErrInsufficientBalance, CodeInsufficientBalance, http.StatusUnprocessableEntity},
+	{blockchain.ErrInsufficientLocked, CodeInsufficientLocked, http.StatusUnprocessableEntity},
+	{blockchain.ErrAccountNotFound, CodeAccountNotFound, http.StatusNotFound},
+	{blockchain.ErrAccountExists, CodeAccountExists, http.StatusConflict},
+	{blockchain.ErrSelfTransfer, CodeSelfTransfer, http.StatusBadRequest},
+	{blockchain.ErrReservedAddress, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrMaxSupplyReached, CodeConflict, http.StatusConflict},
+	{blockchain.ErrNilTransaction, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrTxExists, CodeTxExists, http.StatusConflict},
+	{blockchain.ErrTxNotFound, CodeTxNotFound, http.StatusNotFound},
+	{blockchain.ErrNoPendingTxs, CodeNoPendingTxs, http.StatusBadRequest},
+	{blockchain.ErrTxNotSigned, CodeInvalidSignature, http.StatusBadRequest},
+	{blockchain.ErrInvalidSignature, CodeInvalidSignature, http.StatusBadRequest},
+	{blockchain.ErrWrongChainID, CodeInvalidSignature, http.StatusBadRequest},
+	{blockchain.ErrUnsupportedSigScheme, CodeInvalidSignature, http.StatusBadRequest},
+	{blockchain.ErrBlockNotFound, CodeBlockNotFound, http.StatusNotFound},
+	{blockchain.ErrInvalidBlockIndex, CodeInvalidBlock, http.StatusBadRequest},
+	{blockchain.ErrInvalidPrevHash, CodeInvalidBlock, http.StatusBadRequest},
+	{blockchain.ErrInvalidHumanProof, CodeInvalidBlock, http.StatusBadRequest},
+	{blockchain.ErrInvalidBlockSignature, CodeInvalidBlock, http.StatusBadRequest},
+	{blockchain.ErrInvalidReward, CodeInvalidBlock, http.StatusBadRequest},
+	{blockchain.ErrInvalidEpochReward, CodeInvalidBlock, http.StatusBadRequest},
+	{blockchain.ErrBlockTooLarge, CodeInvalidBlock, http.StatusBadRequest},
+	{blockchain.ErrBlockQuotaExceeded, CodeBlockQuota, http.StatusConflict},
+	{blockchain.ErrInvalidGenesisAllocation, CodeInvalidBlock, http.StatusBadRequest},
+	{blockchain.ErrInvalidEvidence, CodeBadRequest, http.StatusUnprocessableEntity},
+	{blockchain.ErrEvidenceExists, CodeConflict, http.StatusConflict},
+	{blockchain.ErrUnknownValidator, CodeUnknownValidator, http.StatusNotFound},
+	{blockchain.ErrValidatorExists, CodeValidatorExists, http.StatusConflict},
+	{blockchain.ErrHumanProofRequired, CodeHumanProofRequired, http.StatusBadRequest},
+	{blockchain.ErrKeyPairNotFound, CodeKeyPairNotFound, http.StatusNotFound},
+	{blockchain.ErrKeyInUse, CodeConflict, http.StatusConflict},
+	{blockchain.ErrMultiSigWalletNotFound, CodeMultiSigNotFound, http.StatusNotFound},
+	{blockchain.ErrMultiSigWalletExists, CodeMultiSigExists, http.StatusConflict},
+	{blockchain.ErrNotOwner, CodeNotOwner, http.StatusForbidden},
+	{blockchain.ErrAlreadySigned, CodeAlreadySigned, http.StatusConflict},
+	{blockchain.ErrNotEnoughSignatures, CodeNotEnoughSignatures, http.StatusConflict},
+	{blockchain.ErrThresholdKeyNotSet, CodeConflict, http.StatusConflict},
+	{blockchain.ErrInvalidThresholdKey, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrContractNotFound, CodeContractNotFound, http.StatusNotFound},
+	{blockchain.ErrArchiveUnavailable, CodeUnavailable, http.StatusServiceUnavailable},
+	{blockchain.ErrHeightNotReached, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrUpgradeRequired, CodeUpgradeRequired, http.StatusServiceUnavailable},
+	{blockchain.ErrInvalidUpgrade, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrUnknownFeature, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrInvalidActivation, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrOutOfGas, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrHostPermission, CodeUnauthorized, http.StatusForbidden},
+	{blockchain.ErrHostUnavailable, CodeUnavailable, http.StatusServiceUnavailable},
+	{blockchain.ErrCallDepthExceeded, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrContractReverted, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrInvalidContract, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrLabelNotFound, CodeNotFound, http.StatusNotFound},
+	{blockchain.ErrInvalidLabel, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrContactNotFound, CodeNotFound, http.StatusNotFound},
+	{blockchain.ErrInvalidContact, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrPaymentRequestNotFound, CodeNotFound, http.StatusNotFound},
+	{blockchain.ErrInvalidPaymentRequest, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrPaymentRequestClosed, CodeConflict, http.StatusConflict},
+	{blockchain.ErrInvalidBridgeTransfer, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrBridgeReleased, CodeConflict, http.StatusConflict},
+	{blockchain.ErrInvalidOracleData, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrChainHalted, CodeChainHalted, http.StatusServiceUnavailable},
+	{blockchain.ErrInvalidCircuitBreaker, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrOracleNotAuthorized, CodeUnauthorized, http.StatusForbidden},
+	{blockchain.ErrOracleFeedNotFound, CodeNotFound, http.StatusNotFound},
+	{blockchain.ErrInvalidVote, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrInvalidCommitCertificate, CodeInvalidBlock, http.StatusBadRequest},
+	{blockchain.ErrDust, CodeDust, http.StatusBadRequest},
+	{blockchain.ErrInvalidMemo, CodeBadRequest, http.StatusBadRequest},
+	{units.ErrInvalidAmount, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrAPIKeyNotFound, CodeNotFound, http.StatusNotFound},
+	{blockchain.ErrInvalidAPIKey, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrWalletLocked, CodeWalletLocked, http.StatusForbidden},
+	{blockchain.ErrWrongPassphrase, CodeUnauthorized, http.StatusUnauthorized},
+	{errWalletSessionRequired, CodeUnauthorized, http.StatusUnauthorized},
+	{errUnlockRateLimited, CodeRateLimited, http.StatusTooManyRequests},
+	{blockchain.ErrNoPassphrase, CodeConflict, http.StatusConflict},
+	{blockchain.ErrInvalidPassphrase, CodeBadRequest, http.StatusBadRequest},
+	{blockchain.ErrSpendingLimit, CodeSpendingLimit, http.StatusForbidden},
+	{blockchain.ErrSecondFactorRequired, CodeSecondFactor, http.StatusUnauthorized},
+	{blockchain.ErrSecondFactorFailed, CodeSecondFactor, http.StatusForbidden},
+	{blockchain.ErrInvalidWalletControls, CodeBadRequest, http.StatusBadRequest},
+	{consensus.ErrPoHSessionNotFound, CodeNotFound, http.StatusNotFound},
+	{consensus.ErrProposalNotFound, CodeNotFound, http.StatusNotFound},
+	{consensus.ErrCommissionTooHigh, CodeBadRequest, http.StatusBadRequest},
+	{consensus.ErrCommissionReplayed, CodeConflict, http.StatusConflict},
+	{consensus.ErrDelegationNotFound, CodeNotFound, http.StatusNotFound},
+	{consensus.ErrNoRewards, CodeConflict, http.StatusConflict},
+	{consensus.ErrPoHSessionExpired, CodeVerificationExpired, http.StatusGone},
+	{consensus.ErrPoHSessionClosed, CodeConflict, http.StatusConflict},
+	{consensus.ErrPoHTooManyAttempts, CodeVerificationFailed, http.StatusForbidden},
+	{consensus.ErrPoHResponseTooFast, CodeVerificationFailed, http.StatusUnprocessableEntity},
+	{consensus.ErrPoHVerificationFailed, CodeVerificationFailed, http.StatusUnprocessableEntity},
+	{consensus.ErrPoHUnsupportedMethod, CodeBadRequest, http.StatusBadRequest},
+	{consensus.ErrPoHRateLimited, CodeRateLimited, http.StatusTooManyRequests},
+	{backup.ErrObjectNotFound, CodeNotFound, http.StatusNotFound},
+	{backup.ErrInvalidBackup, CodeBadRequest, http.StatusUnprocessableEntity},
+	{signer.ErrUnavailable, CodeUnavailable, http.StatusServiceUnavailable},
+}
+
+// ErrorResponse is the JSON body returned for every failed API request.
+// Error is localized from the message catalog; Detail carries the untranslated
+// error text and is omitted for internal errors so server details do not leak.
+type ErrorResponse struct {
+	Error  string    `json:"error"`
+	Code   ErrorCode `json:"code"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// classifyError returns the API code and HTTP status for an error,
+// falling back to the given status and code when the error is not a known domain error
+func classifyError(err error, fallbackStatus int, fallbackCode ErrorCode) (ErrorCode, int) {
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			return m.code, m.status
+		}
+	}
+	return fallbackCode, fallbackStatus
+}
+
+// writeError writes err as a JSON ErrorResponse.
+// Known domain errors are mapped to their own code and status; anything else uses fallbackStatus.
+func writeError(w http.ResponseWriter, err error, fallbackStatus int) {
+	fallbackCode := CodeInternal
+	switch fallbackStatus {
+	case http.StatusBadRequest:
+		fallbackCode = CodeBadRequest
+	case http.StatusUnauthorized:
+		fallbackCode = CodeUnauthorized
+	case http.StatusNotFound:
+		fallbackCode = CodeNotFound
+	case http.StatusConflict:
+		fallbackCode = CodeConflict
+	case http.StatusServiceUnavailable:
+		fallbackCode = CodeUnavailable
+	case http.StatusGatewayTimeout:
+		fallbackCode = CodeTimeout
+	}
+
+	code, status := classifyError(err, fallbackStatus, fallbackCode)
+	writeErrorCode(w, status, code, err.Error())
+}
+
+// writeErrorCode writes an ErrorResponse with an explicit status, code and detail message
+func writeErrorCode(w http.ResponseWriter, status int, code ErrorCode, detail string) {
+	if code == CodeInternal {
+		log.Printf("Internal API error (status %d): %s", status, detail)
+		detail = ""
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error:  Message(responseLocale(w), code),
+		Code:   code,
+		Detail: detail,
+	})
+}
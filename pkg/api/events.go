@@ -0,0 +1,71 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// maxEventsPerPage caps the events returned by one request
+const maxEventsPerPage = 1000
+
+// getEvents handles GET /api/events, listing the chain event journal:
+// validator set changes, governance executions, slashing and parameter
+// changes, oldest first. Query: fromBlock (default 0), type, limit
+// (default 100), cursor (see pagination.go), pending=true to also list events
+// waiting for the next block.
+func (ws *WebServer) getEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var fromBlock uint64
+	if raw := query.Get("fromBlock"); raw != "" {
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			writeError(w, errors.New("fromBlock must be a block index"), http.StatusBadRequest)
+			return
+		}
+		fromBlock = n
+	}
+
+	limit := 100
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > maxEventsPerPage {
+			writeError(w, errors.New("limit must be between 1 and 1000"), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	order, cursor, err := parsePage(r, cursorEvents, orderAsc)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	if order != orderAsc {
+		writeError(w, errors.New("events are listed oldest first only"), http.StatusBadRequest)
+		return
+	}
+	var afterSeq uint64
+	if cursor != nil {
+		afterSeq = cursor.Seq
+	}
+
+	eventType := query.Get("type")
+	events := ws.blockchain.Events(fromBlock, afterSeq, eventType, limit)
+	response := map[string]interface{}{
+		"fromBlock": fromBlock,
+		"events":    events,
+	}
+	if eventType != "" {
+		response["type"] = eventType
+	}
+	if len(events) >= limit {
+		last := events[len(events)-1]
+		response["nextCursor"] = pageCursor{Kind: cursorEvents, Order: orderAsc, Block: last.BlockIndex, Seq: last.Seq}.encode()
+	}
+	if query.Get("pending") == "true" {
+		response["pending"] = ws.blockchain.PendingEvents()
+	}
+	writeLightJSON(w, r, http.StatusOK, response)
+}
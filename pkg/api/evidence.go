@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"confirmix/pkg/blockchain"
+)
+
+// getEvidence handles GET /api/evidence, listing the double-signing evidence recorded on chain
+func (ws *WebServer) getEvidence(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ws.blockchain.GetEvidence())
+}
+
+// submitEvidence handles POST /api/evidence.
+// Body: {"reporter": "...", "first": {...}, "second": {...}} where first and second are
+// signed headers (see Block.SignedHeader) of one validator at one height.
+// The evidence is verified and queued; the validator is suspended once it is included in a block.
+func (ws *WebServer) submitEvidence(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Reporter string                  `json:"reporter"`
+		First    blockchain.SignedHeader `json:"first"`
+		Second   blockchain.SignedHeader `json:"second"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("invalid request body"), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := blockchain.NewDoubleSignEvidenceTransaction(req.Reporter, &blockchain.DoubleSignEvidence{
+		First:  req.First,
+		Second: req.Second,
+	})
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	if err := ws.blockchain.AddTransaction(tx); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, tx)
+}
@@ -0,0 +1,14 @@
+package api
+
+import (
+	"net/http"
+)
+
+// getFeatures handles GET /api/features, returning the consensus rules this
+// node implements and the heights they activate at
+func (ws *WebServer) getFeatures(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"height":   ws.blockchain.GetChainHeight(),
+		"features": ws.blockchain.Activations(),
+	})
+}
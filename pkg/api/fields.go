@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// parseFieldSelection reads the comma separated ?fields= parameter.
+// It returns nil when no selection was requested.
+func parseFieldSelection(r *http.Request) map[string]bool {
+	raw := r.URL.Query().Get("fields")
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+
+	fields := make(map[string]bool)
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f != "" {
+			fields[f] = true
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// selectFields keeps only the requested keys of a JSON object, or of every
+// object in a JSON array. Keys are matched case-insensitively so that
+// ?fields=hash,index works with the capitalized block fields.
+func selectFields(v interface{}, fields map[string]bool) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+
+	switch value := generic.(type) {
+	case map[string]interface{}:
+		return filterObject(value, fields), nil
+	case []interface{}:
+		for i, item := range value {
+			if obj, ok := item.(map[string]interface{}); ok {
+				value[i] = filterObject(obj, fields)
+			}
+		}
+		return value, nil
+	default:
+		return generic, nil
+	}
+}
+
+// filterObject drops every key that was not requested
+func filterObject(obj map[string]interface{}, fields map[string]bool) map[string]interface{} {
+	filtered := make(map[string]interface{}, len(fields))
+	for key, value := range obj {
+		if fields[strings.ToLower(key)] {
+			filtered[key] = value
+		}
+	}
+	return filtered
+}
+
+// writeLightJSON writes v as JSON, applying ?fields= selection. Used by list
+// and detail views that mobile clients poll frequently; compressResponses
+// encodes them when they are large. Amounts are sent as strings of base units.
+func writeLightJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	v = withStringAmounts(v)
+	if fields := parseFieldSelection(r); fields != nil {
+		selected, err := selectFields(v, fields)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+		v = selected
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+
+	"confirmix/pkg/consensus"
+)
+
+// SetBFT attaches the validator voting layer whose rounds GET /api/finality reports
+func (ws *WebServer) SetBFT(bft *consensus.BFT) {
+	ws.bft = bft
+}
+
+// getFinality handles GET /api/finality, returning the newest block made
+// final by a commit certificate and, when the node takes part in voting, the
+// tallies of the rounds in progress
+func (ws *WebServer) getFinality(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{
+		"finality": ws.blockchain.Finality(),
+		"voting":   ws.bft != nil,
+	}
+	if ws.bft != nil {
+		response["rounds"] = ws.bft.Rounds()
+	}
+	writeJSON(w, http.StatusOK, response)
+}
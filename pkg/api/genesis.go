@@ -0,0 +1,16 @@
+package api
+
+import (
+	"net/http"
+)
+
+// getGenesis handles GET /api/genesis, returning the genesis block and the
+// initial supply allocations it recorded
+func (ws *WebServer) getGenesis(w http.ResponseWriter, r *http.Request) {
+	info, err := ws.blockchain.GetGenesisInfo()
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
@@ -0,0 +1,842 @@
+package api_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"confirmix/pkg/api"
+	"confirmix/pkg/api/apitest"
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/types"
+)
+
+// adminAddress is the only admin of the validator manager of a test server
+const adminAddress = "admin"
+
+// The handler tables cover the routes that move funds or grant access: chain
+// reads and transfers, wallet unlocks and signing, staking, the bridge, API
+// keys, multisig wallets, validators, admins and governance. Every other route
+// is only sent a bare request, by TestRoutesWithoutOptionalServices.
+func TestMain(m *testing.M) {
+	// The handlers log every request; keep the test output readable
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testEnv is a server backed by a fresh fake blockchain. Unless it is bare,
+// it also has a validator manager, a governance system and a staking module.
+type testEnv struct {
+	fake       *apitest.Blockchain
+	validators *stubValidators
+	governance *stubGovernance
+	staking    *stubStaking
+	server     *api.WebServer
+	session    string // sent as a bearer token when set, see unlocked
+	apiKey     string // sent in the API key header when set
+}
+
+func newTestEnv(t *testing.T, bare bool) *testEnv {
+	t.Helper()
+	env := &testEnv{fake: apitest.NewBlockchain()}
+	services := env.fake.Services()
+	if !bare {
+		env.validators = newStubValidators(env.fake, adminAddress)
+		env.governance = newStubGovernance()
+		env.staking = newStubStaking(env.fake)
+		services.Validators = env.validators
+		services.Governance = env.governance
+		services.Staking = env.staking
+	}
+	env.server = api.NewWebServerWithServices(services, nil, 0)
+	env.addKey(t, adminAddress)
+	return env
+}
+
+// addKey gives the node a key for address and returns it
+func (env *testEnv) addKey(t *testing.T, address string) *blockchain.KeyPair {
+	t.Helper()
+	keyPair, err := blockchain.NewKeyPair()
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	env.fake.AddKeyPair(address, keyPair)
+	return keyPair
+}
+
+// do serves one request
+func (env *testEnv) do(method, path, body string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if env.session != "" {
+		req.Header.Set("Authorization", "Bearer "+env.session)
+	}
+	if env.apiKey != "" {
+		req.Header.Set(api.APIKeyHeader, env.apiKey)
+	}
+	rec := httptest.NewRecorder()
+	env.server.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+// adminNonce tells apart the signed requests of a test run
+var adminNonce int64
+
+// signAdmin completes req as a fresh request of its admin, dated now unless
+// it has a timestamp, signs it with the admin's key and returns it as JSON
+func (env *testEnv) signAdmin(t *testing.T, req types.SignedRequest, tamper func(*types.SignedRequest)) string {
+	t.Helper()
+	if req.AdminAddress == "" {
+		req.AdminAddress = adminAddress
+	}
+	if req.Timestamp == 0 {
+		req.Timestamp = time.Now().Unix()
+	}
+	req.Nonce = fmt.Sprint(atomic.AddInt64(&adminNonce, 1))
+
+	keyPair, exists := env.fake.GetKeyPair(req.AdminAddress)
+	if !exists {
+		keyPair = env.addKey(t, req.AdminAddress)
+	}
+	digest := sha256.Sum256([]byte(req.SigningMessage()))
+	signature, err := keyPair.Signer().Sign(digest[:])
+	if err != nil {
+		t.Fatalf("signing admin request: %v", err)
+	}
+	req.Signature = hex.EncodeToString(signature)
+	if tamper != nil {
+		tamper(&req)
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("encoding admin request: %v", err)
+	}
+	return string(body)
+}
+
+// handlerCase is one request against a fresh testEnv
+type handlerCase struct {
+	name   string
+	bare   bool // serve without a validator manager or governance
+	setup  func(t *testing.T, env *testEnv)
+	method string
+	path   string
+	body   string
+	// signed, when set, is sent as the body after signAdmin completes it;
+	// tamper then changes it after signing
+	signed *types.SignedRequest
+	tamper func(*types.SignedRequest)
+
+	status int
+	code   api.ErrorCode // the error code of a failed request, if checked
+	check  func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder)
+}
+
+func runHandlerCases(t *testing.T, cases []handlerCase) {
+	t.Helper()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			env := newTestEnv(t, tc.bare)
+			if tc.setup != nil {
+				tc.setup(t, env)
+			}
+			body := tc.body
+			if tc.signed != nil {
+				body = env.signAdmin(t, *tc.signed, tc.tamper)
+			}
+
+			rec := env.do(tc.method, tc.path, body)
+			if rec.Code != tc.status {
+				t.Fatalf("%s %s: status %d, want %d; body: %s", tc.method, tc.path, rec.Code, tc.status, rec.Body)
+			}
+			if tc.code != "" {
+				var resp api.ErrorResponse
+				decode(t, rec, &resp)
+				if resp.Code != tc.code {
+					t.Fatalf("%s %s: code %s, want %s; detail: %s", tc.method, tc.path, resp.Code, tc.code, resp.Detail)
+				}
+			}
+			if tc.check != nil {
+				tc.check(t, env, rec)
+			}
+		})
+	}
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding response %q: %v", rec.Body, err)
+	}
+}
+
+// fund gives address a key held by the node and a balance
+func fund(address string, balance int64) func(t *testing.T, env *testEnv) {
+	return func(t *testing.T, env *testEnv) {
+		env.addKey(t, address)
+		env.fake.SetBalance(address, big.NewInt(balance))
+	}
+}
+
+// testPassphrase protects the wallets the tests unlock
+const testPassphrase = "correct horse battery"
+
+// unlocked funds address like fund, protects it with testPassphrase and
+// unlocks it, keeping the session for the requests that follow
+func unlocked(address string, balance int64) func(t *testing.T, env *testEnv) {
+	return func(t *testing.T, env *testEnv) {
+		fund(address, balance)(t, env)
+		if err := env.fake.SetWalletPassphrase(address, testPassphrase, ""); err != nil {
+			t.Fatal(err)
+		}
+		rec := env.do("POST", "/api/wallet/unlock", fmt.Sprintf(`{"address": %q, "passphrase": %q, "duration": 300}`, address, testPassphrase))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unlocking %s: status %d; body: %s", address, rec.Code, rec.Body)
+		}
+		var resp struct {
+			SessionToken string `json:"sessionToken"`
+		}
+		decode(t, rec, &resp)
+		if resp.SessionToken == "" {
+			t.Fatalf("unlocking %s issued no session token", address)
+		}
+		env.session = resp.SessionToken
+	}
+}
+
+func TestChainHandlers(t *testing.T) {
+	runHandlerCases(t, []handlerCase{
+		{
+			name:   "status",
+			method: "GET", path: "/api/status",
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				var status struct {
+					Status string `json:"status"`
+					Height uint64 `json:"height"`
+				}
+				decode(t, rec, &status)
+				if status.Status != "online" || status.Height != 0 {
+					t.Fatalf("status %+v, want online at height 0", status)
+				}
+			},
+		},
+		{
+			name: "blocks newest first",
+			setup: func(t *testing.T, env *testEnv) {
+				env.fake.MineBlock("validator-1")
+				env.fake.MineBlock("validator-2")
+			},
+			method: "GET", path: "/api/blocks?limit=2",
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				var blocks []struct{ Index uint64 }
+				decode(t, rec, &blocks)
+				if len(blocks) != 2 || blocks[0].Index != 2 || blocks[1].Index != 1 {
+					t.Fatalf("blocks %+v, want indexes 2 and 1", blocks)
+				}
+				if rec.Header().Get("X-Next-Cursor") == "" {
+					t.Fatalf("a full page carries no continuation")
+				}
+			},
+		},
+		{
+			name:   "blocks with a bad order",
+			method: "GET", path: "/api/blocks?order=sideways",
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "blocks with a bad cursor",
+			method: "GET", path: "/api/blocks?cursor=not-a-cursor",
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "block by index",
+			method: "GET", path: "/api/blocks/0",
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				if rec.Header().Get("ETag") == "" {
+					t.Fatalf("block served without an ETag")
+				}
+			},
+		},
+		{
+			name:   "block index not a number",
+			method: "GET", path: "/api/blocks/first",
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "block past the chain height",
+			method: "GET", path: "/api/blocks/7",
+			status: http.StatusNotFound, code: api.CodeBlockNotFound,
+		},
+		{
+			name:   "block in an unavailable archive",
+			setup:  func(t *testing.T, env *testEnv) { env.fake.Fail("GetBlockByIndex", blockchain.ErrArchiveUnavailable) },
+			method: "GET", path: "/api/blocks/0",
+			status: http.StatusServiceUnavailable, code: api.CodeUnavailable,
+		},
+		{
+			name:   "transfer signed with an unlocked wallet",
+			setup:  unlocked("alice", 100),
+			method: "POST", path: "/api/transactions",
+			body:   `{"from": "alice", "to": "bob", "value": "40"}`,
+			status: http.StatusCreated,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				var tx blockchain.Transaction
+				decode(t, rec, &tx)
+				if len(tx.Signature) == 0 {
+					t.Fatalf("transaction %s was not signed", tx.ID)
+				}
+				if pending := env.fake.GetPendingTransactions(); len(pending) != 1 || pending[0].ID != tx.ID {
+					t.Fatalf("pending transactions %v, want only %s", pending, tx.ID)
+				}
+			},
+		},
+		{
+			name:   "transfer with a malformed body",
+			method: "POST", path: "/api/transactions",
+			body:   `{"from": `,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "transfer without a recipient",
+			setup:  fund("alice", 100),
+			method: "POST", path: "/api/transactions",
+			body:   `{"from": "alice", "value": 1}`,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "transfer of nothing",
+			setup:  fund("alice", 100),
+			method: "POST", path: "/api/transactions",
+			body:   `{"from": "alice", "to": "bob", "value": 0}`,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "transfer above the balance",
+			setup:  fund("alice", 10),
+			method: "POST", path: "/api/transactions",
+			body:   `{"from": "alice", "to": "bob", "value": 11}`,
+			status: http.StatusUnprocessableEntity, code: api.CodeInsufficientBalance,
+		},
+		{
+			name:   "unsigned transfer without a wallet session",
+			setup:  fund("alice", 100),
+			method: "POST", path: "/api/transactions",
+			body:   `{"from": "alice", "to": "bob", "value": 1}`,
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+		},
+		{
+			name: "unsigned transfer with the session of another wallet",
+			setup: func(t *testing.T, env *testEnv) {
+				unlocked("alice", 100)(t, env)
+				fund("carol", 100)(t, env)
+			},
+			method: "POST", path: "/api/transactions",
+			body:   `{"from": "carol", "to": "bob", "value": 1}`,
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+		},
+		{
+			name:   "client signature without the signed id",
+			setup:  fund("alice", 100),
+			method: "POST", path: "/api/transactions",
+			body:   `{"from": "alice", "to": "bob", "value": 1, "signature": "c2lnbmF0dXJl"}`,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				if pending := env.fake.GetPendingTransactions(); len(pending) != 0 {
+					t.Fatalf("rejected transfer reached the pool: %v", pending)
+				}
+			},
+		},
+		{
+			name: "transfer refused by the pool",
+			setup: func(t *testing.T, env *testEnv) {
+				unlocked("alice", 100)(t, env)
+				env.fake.Fail("AddTransaction", blockchain.ErrTxExists)
+			},
+			method: "POST", path: "/api/transactions",
+			body:   `{"from": "alice", "to": "bob", "value": 1}`,
+			status: http.StatusConflict, code: api.CodeTxExists,
+		},
+		{
+			name: "transaction by id",
+			setup: func(t *testing.T, env *testEnv) {
+				tx := &blockchain.Transaction{ID: "tx-1", From: "alice", To: "bob", Value: 5, Type: "regular"}
+				if err := env.fake.AddTransaction(tx); err != nil {
+					t.Fatal(err)
+				}
+			},
+			method: "GET", path: "/api/transactions/tx-1",
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				var tx blockchain.Transaction
+				decode(t, rec, &tx)
+				if tx.ID != "tx-1" || tx.Value != 5 {
+					t.Fatalf("transaction %+v, want tx-1 of 5", tx)
+				}
+			},
+		},
+		{
+			name:   "unknown transaction",
+			method: "GET", path: "/api/transactions/0123456789abcdef",
+			status: http.StatusNotFound, code: api.CodeTxNotFound,
+		},
+		{
+			name:   "mine without a validator",
+			method: "POST", path: "/api/mine",
+			body:   `{}`,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "mine with a negative batch",
+			method: "POST", path: "/api/mine",
+			body:   `{"validator": "validator-1", "maxTransactions": -1}`,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+	})
+}
+
+func TestWalletHandlers(t *testing.T) {
+	runHandlerCases(t, []handlerCase{
+		{
+			name:   "create wallet",
+			method: "POST", path: "/api/wallet/create",
+			status: http.StatusCreated,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				var wallet struct {
+					Address string `json:"address"`
+					KeyType string `json:"keyType"`
+				}
+				decode(t, rec, &wallet)
+				if _, exists := env.fake.GetKeyPair(wallet.Address); !exists {
+					t.Fatalf("no key held for new wallet %q", wallet.Address)
+				}
+				if env.fake.Saves() == 0 {
+					t.Fatalf("new wallet was not saved")
+				}
+			},
+		},
+		{
+			name:   "create wallet of an unknown key type",
+			method: "POST", path: "/api/wallet/create?keyType=rsa",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "balance",
+			setup:  fund("alice", 42),
+			method: "GET", path: "/api/wallet/balance/alice",
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				var balance struct {
+					Balance string `json:"balance"`
+				}
+				decode(t, rec, &balance)
+				if balance.Balance != "42" {
+					t.Fatalf("balance %s, want 42", balance.Balance)
+				}
+			},
+		},
+		{
+			name:   "balance of an unknown address",
+			method: "GET", path: "/api/wallet/balance/nobody",
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				if !strings.Contains(rec.Body.String(), `"balance":"0"`) {
+					t.Fatalf("unknown address reported %s, want a zero balance", rec.Body)
+				}
+			},
+		},
+		{
+			name:   "sign with an unlocked wallet",
+			setup:  unlocked("alice", 100),
+			method: "POST", path: "/api/wallet/sign",
+			body:   `{"from": "alice", "to": "bob", "value": "1"}`,
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				var tx blockchain.Transaction
+				decode(t, rec, &tx)
+				if len(tx.Signature) == 0 {
+					t.Fatalf("transaction %s was not signed", tx.ID)
+				}
+			},
+		},
+		{
+			name:   "sign without a wallet session",
+			setup:  fund("alice", 100),
+			method: "POST", path: "/api/wallet/sign",
+			body:   `{"from": "alice", "to": "bob", "value": "1"}`,
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+		},
+		{
+			name: "sign after the wallet was locked",
+			setup: func(t *testing.T, env *testEnv) {
+				unlocked("alice", 100)(t, env)
+				if rec := env.do("POST", "/api/wallet/lock", `{"address": "alice"}`); rec.Code != http.StatusOK {
+					t.Fatalf("locking: status %d", rec.Code)
+				}
+			},
+			method: "POST", path: "/api/wallet/sign",
+			body:   `{"from": "alice", "to": "bob", "value": "1"}`,
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+		},
+		{
+			name: "unlock with a wrong passphrase",
+			setup: func(t *testing.T, env *testEnv) {
+				fund("alice", 100)(t, env)
+				if err := env.fake.SetWalletPassphrase("alice", testPassphrase, ""); err != nil {
+					t.Fatal(err)
+				}
+			},
+			method: "POST", path: "/api/wallet/unlock",
+			body:   `{"address": "alice", "passphrase": "guess"}`,
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+		},
+		{
+			name:   "register validator",
+			setup:  fund("val-1", 0),
+			method: "POST", path: "/api/validators/register",
+			body:   `{"address": "val-1", "humanProof": "proof-1"}`,
+			status: http.StatusCreated,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				if !env.fake.IsValidator("val-1") {
+					t.Fatalf("val-1 was not registered")
+				}
+			},
+		},
+		{
+			name:   "register validator without a human proof",
+			setup:  fund("val-1", 0),
+			method: "POST", path: "/api/validators/register",
+			body:   `{"address": "val-1"}`,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "register validator without a key",
+			method: "POST", path: "/api/validators/register",
+			body:   `{"address": "val-1", "humanProof": "proof-1"}`,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name: "register a validator twice",
+			setup: func(t *testing.T, env *testEnv) {
+				fund("val-1", 0)(t, env)
+				if err := env.fake.AddValidator("val-1", "proof-1"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			method: "POST", path: "/api/validators/register",
+			body:   `{"address": "val-1", "humanProof": "proof-1"}`,
+			status: http.StatusConflict, code: api.CodeValidatorExists,
+		},
+		{
+			name: "register validator on a failing store",
+			setup: func(t *testing.T, env *testEnv) {
+				fund("val-1", 0)(t, env)
+				env.fake.Fail("AddValidator", errors.New("disk full"))
+			},
+			method: "POST", path: "/api/validators/register",
+			body:   `{"address": "val-1", "humanProof": "proof-1"}`,
+			status: http.StatusInternalServerError, code: api.CodeInternal,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				if strings.Contains(rec.Body.String(), "disk full") {
+					t.Fatalf("internal error leaked: %s", rec.Body)
+				}
+			},
+		},
+	})
+}
+
+func TestWalletSessionBelongsToItsClient(t *testing.T) {
+	env := newTestEnv(t, false)
+	unlocked("alice", 100)(t, env)
+
+	req := httptest.NewRequest("POST", "/api/wallet/sign", strings.NewReader(`{"from": "alice", "to": "bob", "value": "1"}`))
+	req.RemoteAddr = "198.51.100.7:4000"
+	req.Header.Set("Authorization", "Bearer "+env.session)
+	rec := httptest.NewRecorder()
+	env.server.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("session sent from another client: status %d, want %d; body: %s", rec.Code, http.StatusUnauthorized, rec.Body)
+	}
+}
+
+func TestWalletUnlockFailuresAreRateLimited(t *testing.T) {
+	env := newTestEnv(t, false)
+	fund("alice", 100)(t, env)
+	if err := env.fake.SetWalletPassphrase("alice", testPassphrase, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if rec := env.do("POST", "/api/wallet/unlock", `{"address": "alice", "passphrase": "guess"}`); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("wrong passphrase %d: status %d, want %d", i+1, rec.Code, http.StatusUnauthorized)
+		}
+	}
+
+	// Even the right passphrase waits until the failures age out
+	rec := env.do("POST", "/api/wallet/unlock", fmt.Sprintf(`{"address": "alice", "passphrase": %q, "duration": 300}`, testPassphrase))
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("unlock after repeated failures: status %d, Retry-After %q; want 429 with Retry-After", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestMultiSigHandlers(t *testing.T) {
+	owners := func(t *testing.T, env *testEnv) {
+		if err := env.fake.CreateMultiSigWallet("treasury", []string{"alice", "bob", "carol"}, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runHandlerCases(t, []handlerCase{
+		{
+			name:   "wallet",
+			setup:  owners,
+			method: "GET", path: "/api/multisig/wallet/treasury",
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				if !strings.Contains(rec.Body.String(), "carol") {
+					t.Fatalf("wallet %s does not list its owners", rec.Body)
+				}
+			},
+		},
+		{
+			name:   "unknown wallet",
+			method: "GET", path: "/api/multisig/wallet/treasury",
+			status: http.StatusNotFound, code: api.CodeMultiSigNotFound,
+		},
+		{
+			name:   "create wallet without an admin signature",
+			method: "POST", path: "/api/multisig/wallet/create",
+			body:   `{"address": "treasury", "owners": ["alice", "bob"], "requiredSigs": 2, "adminAddress": "admin", "signature": "00"}`,
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				if _, err := env.fake.GetMultiSigWallet("treasury"); err == nil {
+					t.Fatalf("wallet was created without a valid signature")
+				}
+			},
+		},
+		{
+			name:   "transaction from an unknown wallet",
+			method: "POST", path: "/api/multisig/transaction/create",
+			body:   `{"walletAddress": "treasury", "from": "treasury", "to": "dave", "value": "5"}`,
+			status: http.StatusNotFound, code: api.CodeMultiSigNotFound,
+		},
+	})
+}
+
+func TestBridgeHandlers(t *testing.T) {
+	runHandlerCases(t, []handlerCase{
+		{
+			name:   "status",
+			method: "GET", path: "/api/bridge",
+			status: http.StatusOK,
+		},
+		{
+			name:   "lock with an unlocked wallet",
+			setup:  unlocked("alice", 100),
+			method: "POST", path: "/api/bridge/lock",
+			body:   `{"from": "alice", "value": "25", "targetChain": "ethereum", "targetAddress": "0x00000000000000000000000000000000000000aa"}`,
+			status: http.StatusCreated,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				var tx blockchain.Transaction
+				decode(t, rec, &tx)
+				if len(tx.Signature) == 0 || tx.Type != blockchain.BridgeLockTxType {
+					t.Fatalf("transaction %+v, want a signed bridge lock", tx)
+				}
+				if pending := env.fake.GetPendingTransactions(); len(pending) != 1 || pending[0].ID != tx.ID {
+					t.Fatalf("pending transactions %v, want only %s", pending, tx.ID)
+				}
+			},
+		},
+		{
+			name:   "lock without a wallet session",
+			setup:  fund("alice", 100),
+			method: "POST", path: "/api/bridge/lock",
+			body:   `{"from": "alice", "value": "25", "targetChain": "ethereum", "targetAddress": "0x00000000000000000000000000000000000000aa"}`,
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				if pending := env.fake.GetPendingTransactions(); len(pending) != 0 {
+					t.Fatalf("refused lock reached the pool: %v", pending)
+				}
+			},
+		},
+		{
+			name:   "lock of nothing",
+			setup:  unlocked("alice", 100),
+			method: "POST", path: "/api/bridge/lock",
+			body:   `{"from": "alice", "value": "0", "targetChain": "ethereum", "targetAddress": "0x00000000000000000000000000000000000000aa"}`,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "release hash without a source transaction",
+			method: "GET", path: "/api/bridge/release/hash?sourceChain=ethereum&to=alice&value=5",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "release status of an unreleased transfer",
+			method: "GET", path: "/api/bridge/release/status?sourceChain=ethereum&sourceTx=0xabc",
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				var status struct {
+					Released bool `json:"released"`
+				}
+				decode(t, rec, &status)
+				if status.Released {
+					t.Fatalf("unknown source transaction reported as released")
+				}
+			},
+		},
+	})
+}
+
+// withAPIKey creates a key with scopes and rateLimit and sends it with the
+// requests that follow. required makes every request need a key.
+func withAPIKey(scopes []string, rateLimit int, required bool) func(t *testing.T, env *testEnv) {
+	return func(t *testing.T, env *testEnv) {
+		secret, _, err := env.fake.CreateAPIKey("test", scopes, rateLimit, adminAddress)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env.apiKey = secret
+		env.server.SetRequireAPIKey(required)
+	}
+}
+
+func TestAPIKeyHandlers(t *testing.T) {
+	readOnly := []string{blockchain.APIScopeRead}
+	submit := []string{blockchain.APIScopeRead, blockchain.APIScopeTxSubmit}
+	runHandlerCases(t, []handlerCase{
+		{
+			name:   "read without a required key",
+			setup:  func(t *testing.T, env *testEnv) { env.server.SetRequireAPIKey(true) },
+			method: "GET", path: "/api/blocks",
+			status: http.StatusUnauthorized, code: api.CodeInvalidAPIKey,
+		},
+		{
+			name:   "health without a required key",
+			setup:  func(t *testing.T, env *testEnv) { env.server.SetRequireAPIKey(true) },
+			method: "GET", path: "/api/health",
+			status: http.StatusOK,
+		},
+		{
+			name:   "read with a read key",
+			setup:  withAPIKey(readOnly, 0, true),
+			method: "GET", path: "/api/blocks",
+			status: http.StatusOK,
+		},
+		{
+			name:   "unknown key",
+			setup:  func(t *testing.T, env *testEnv) { env.apiKey = "not-a-key" },
+			method: "GET", path: "/api/blocks",
+			status: http.StatusUnauthorized, code: api.CodeInvalidAPIKey,
+		},
+		{
+			name: "transfer with a read key",
+			setup: func(t *testing.T, env *testEnv) {
+				unlocked("alice", 100)(t, env)
+				withAPIKey(readOnly, 0, false)(t, env)
+			},
+			method: "POST", path: "/api/transactions",
+			body:   `{"from": "alice", "to": "bob", "value": 1}`,
+			status: http.StatusForbidden, code: api.CodeAPIKeyScope,
+		},
+		{
+			name: "delegate with a transaction key",
+			setup: func(t *testing.T, env *testEnv) {
+				unlocked("alice", 100)(t, env)
+				withAPIKey(submit, 0, true)(t, env)
+			},
+			method: "POST", path: "/api/staking/delegate",
+			body:   `{"delegator": "alice", "validator": "val-1", "amount": "40"}`,
+			status: http.StatusAccepted,
+		},
+		{
+			name: "admin route with a transaction key",
+			setup: func(t *testing.T, env *testEnv) {
+				withAPIKey(submit, 0, false)(t, env)
+			},
+			method: "POST", path: "/api/admin/apikeys",
+			signed: &types.SignedRequest{Action: api.ActionAPIKeyList},
+			status: http.StatusForbidden, code: api.CodeAPIKeyScope,
+		},
+		{
+			name: "key over its rate limit",
+			setup: func(t *testing.T, env *testEnv) {
+				withAPIKey(readOnly, 1, true)(t, env)
+				if rec := env.do("GET", "/api/blocks", ""); rec.Code != http.StatusOK {
+					t.Fatalf("first request: status %d", rec.Code)
+				}
+			},
+			method: "GET", path: "/api/blocks",
+			status: http.StatusTooManyRequests, code: api.CodeRateLimited,
+		},
+		{
+			name:   "usage of the key",
+			setup:  withAPIKey(readOnly, 0, false),
+			method: "GET", path: "/api/apikeys/usage",
+			status: http.StatusOK,
+		},
+		{
+			name:   "usage without a key",
+			method: "GET", path: "/api/apikeys/usage",
+			status: http.StatusUnauthorized, code: api.CodeInvalidAPIKey,
+		},
+		{
+			name:   "create key",
+			method: "POST", path: "/api/admin/apikeys/create",
+			signed: &types.SignedRequest{Action: api.ActionAPIKeyCreate, Data: map[string]string{"name": "ci", "scopes": "read"}},
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				var resp struct {
+					Secret string `json:"secret"`
+				}
+				decode(t, rec, &resp)
+				if _, err := env.fake.AuthenticateAPIKey(resp.Secret); err != nil {
+					t.Fatalf("created key does not authenticate: %v", err)
+				}
+			},
+		},
+		{
+			name:   "create key signed by a non-admin",
+			method: "POST", path: "/api/admin/apikeys/create",
+			signed: &types.SignedRequest{AdminAddress: "mallory", Action: api.ActionAPIKeyCreate, Data: map[string]string{"name": "ci", "scopes": "admin"}},
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				if keys := env.fake.APIKeys(); len(keys) != 0 {
+					t.Fatalf("keys %v created by a non-admin", keys)
+				}
+			},
+		},
+		{
+			name: "create key from a client outside the admin access list",
+			setup: func(t *testing.T, env *testEnv) {
+				if err := env.server.SetAdminAccess(&api.AdminAccess{Allow: []string{"10.0.0.0/8"}}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			method: "POST", path: "/api/admin/apikeys/create",
+			signed: &types.SignedRequest{Action: api.ActionAPIKeyCreate, Data: map[string]string{"name": "ci", "scopes": "read"}},
+			status: http.StatusForbidden, code: api.CodeAccessDenied,
+		},
+	})
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultHeaderCount = 20
+	maxHeaderCount     = 500
+	maxStreamBacklog   = 100
+	// headerPollInterval is how often the stream checks for new blocks
+	headerPollInterval = time.Second
+	// streamKeepAlive is how often an idle stream sends a comment so proxies keep it open
+	streamKeepAlive = 15 * time.Second
+)
+
+// parseCount reads a positive integer query parameter, capped at max
+func parseCount(r *http.Request, name string, def, max int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	if n > max {
+		n = max
+	}
+	return n, nil
+}
+
+// getHeaders handles GET /api/headers?from=&count=.
+// Without from, the latest count headers are returned.
+func (ws *WebServer) getHeaders(w http.ResponseWriter, r *http.Request) {
+	count, err := parseCount(r, "count", defaultHeaderCount, maxHeaderCount)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	height := ws.blockchain.GetChainHeight()
+	var from uint64
+	if raw := r.URL.Query().Get("from"); raw != "" {
+		from, err = strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			writeError(w, errors.New("from must be a block index"), http.StatusBadRequest)
+			return
+		}
+	} else if height+1 > uint64(count) {
+		from = height + 1 - uint64(count)
+	}
+
+	headers := ws.blockchain.GetHeaders(from, count)
+	writeNegotiated(w, r, http.StatusOK, map[string]interface{}{
+		"height":  height,
+		"headers": headers,
+	}, headerRange{height: height, headers: headers})
+}
+
+// streamHeaders handles GET /api/headers/stream?latest=N as Server-Sent Events.
+// It sends the latest N headers and then every new header as blocks are added.
+func (ws *WebServer) streamHeaders(w http.ResponseWriter, r *http.Request) {
+	latest, err := parseCount(r, "latest", 10, maxStreamBacklog)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, errors.New("streaming is not supported"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	height := ws.blockchain.GetChainHeight()
+	next := uint64(0)
+	if height+1 > uint64(latest) {
+		next = height + 1 - uint64(latest)
+	}
+
+	poll := time.NewTicker(headerPollInterval)
+	defer poll.Stop()
+	lastWrite := time.Now()
+
+	for {
+		headers := ws.blockchain.GetHeaders(next, maxStreamBacklog)
+		for _, header := range headers {
+			data, err := json.Marshal(header)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: header\ndata: %s\n\n", header.Index, data); err != nil {
+				return
+			}
+			next = header.Index + 1
+		}
+
+		if len(headers) > 0 {
+			flusher.Flush()
+			lastWrite = time.Now()
+		} else if time.Since(lastWrite) >= streamKeepAlive {
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+			lastWrite = time.Now()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-poll.C:
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+const (
+	// immutableCacheControl lets browsers and CDNs keep a response for a year
+	// without revalidating. Used for confirmed transactions, which never change.
+	immutableCacheControl = "public, max-age=31536000, immutable"
+	// blockCacheControl is used for blocks. A block never changes, but its
+	// utilization is measured against the current block capacity, so caches
+	// revalidate it daily by ETag.
+	blockCacheControl = "public, max-age=86400"
+)
+
+// cacheVary lists the request headers that select the representation of a
+// cached response. They are sent with 304s too, as the full response would.
+var cacheVary = []string{"Accept", "Accept-Encoding"}
+
+// setBlockCacheHeaders sets the caching headers of a block response served as
+// contentType and answers conditional requests. It returns true when a 304 was written.
+func setBlockCacheHeaders(w http.ResponseWriter, r *http.Request, block *blockchain.Block, utilization blockchain.BlockUtilization, contentType string) bool {
+	// The capacity and pruning state are part of the tag since they change what is served for the block
+	tag := fmt.Sprintf("%s.%d.%d", block.Hash, utilization.MaxTxs, len(block.Transactions))
+	return setCacheHeaders(w, r, representationETag(w, tag, contentType), time.Unix(block.Timestamp, 0), blockCacheControl)
+}
+
+// setTransactionCacheHeaders sets the caching headers of a transaction response
+// served as contentType and answers conditional requests. Only transactions in a
+// block are cached; pending ones still change status. It returns true when a 304
+// was written.
+func (ws *WebServer) setTransactionCacheHeaders(w http.ResponseWriter, r *http.Request, tx *blockchain.Transaction, contentType string) bool {
+	status := ws.blockchain.GetTransactionStatuses([]string{tx.ID})[0]
+	if status.BlockHash == "" || status.BlockIndex == nil {
+		w.Header().Set("Cache-Control", "no-cache")
+		return false
+	}
+
+	var modified time.Time
+	if headers := ws.blockchain.GetHeaders(uint64(*status.BlockIndex), 1); len(headers) == 1 {
+		modified = time.Unix(headers[0].Timestamp, 0)
+	}
+	tag := fmt.Sprintf("%s.%s", tx.ID, status.BlockHash)
+	return setCacheHeaders(w, r, representationETag(w, tag, contentType), modified, immutableCacheControl)
+}
+
+// representationETag returns the weak ETag of tag served as contentType in the
+// response locale. Each representation has its own tag, so a cache never
+// validates one format or language for a client that negotiated another.
+func representationETag(w http.ResponseWriter, tag, contentType string) string {
+	format := strings.TrimPrefix(strings.TrimPrefix(contentType, "application/"), "x-")
+	return fmt.Sprintf(`W/"%s.%s.%s"`, tag, format, responseLocale(w))
+}
+
+// setCacheHeaders sets ETag, Last-Modified and Cache-Control, then writes
+// 304 Not Modified when the request's validators match. A zero modified time
+// omits Last-Modified. It returns true when the 304 was written.
+func setCacheHeaders(w http.ResponseWriter, r *http.Request, etag string, modified time.Time, cacheControl string) bool {
+	header := w.Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", cacheControl)
+	if !modified.IsZero() {
+		header.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	for _, field := range cacheVary {
+		addVary(header, field)
+	}
+
+	if !notModified(r, etag, modified) {
+		return false
+	}
+	header.Del("Content-Type")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// notModified evaluates If-None-Match and, only when that is absent,
+// If-Modified-Since, as RFC 9110 orders them for GET and HEAD requests
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || weakETag(candidate) == weakETag(etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || modified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// weakETag strips the weak prefix so tags compare with the weak comparison
+func weakETag(etag string) string {
+	return strings.TrimPrefix(etag, "W/")
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"confirmix/pkg/blockchain"
+)
+
+// getHumanProofs handles GET /api/validators/proofs and lists the on-chain human proof registry
+func (ws *WebServer) getHumanProofs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ws.blockchain.GetHumanProofRegistry())
+}
+
+// getHumanProof handles GET /api/validators/proofs/{address}
+func (ws *WebServer) getHumanProof(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+
+	record, exists := ws.blockchain.GetHumanProofRecord(address)
+	if !exists {
+		writeErrorCode(w, http.StatusNotFound, CodeNotFound, fmt.Sprintf("no human proof recorded on chain for %s", address))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, record)
+}
+
+// getHumanProofAttestation handles GET /api/validators/proofs/{address}/attestation.
+// It returns the registration this node attested after checking the proof,
+// for the validator to sign with its own key and submit.
+func (ws *WebServer) getHumanProofAttestation(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+
+	reg, exists := ws.blockchain.GetHumanProofAttestation(address)
+	if !exists {
+		writeErrorCode(w, http.StatusNotFound, CodeNotFound, fmt.Sprintf("no attested human proof waiting for %s", address))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reg)
+}
+
+// submitHumanProof handles POST /api/validators/proofs.
+// Body: {"registration": {...}, "id": "...", "timestamp": 0, "signature": "...", "chainId": "...", "sigScheme": 2}
+// where registration is an attested registration (see getHumanProofAttestation)
+// and the signature is the validator's over the human proof transaction.
+// The proof is recorded on chain once the transaction is included in a block.
+func (ws *WebServer) submitHumanProof(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Registration blockchain.HumanProofRegistration `json:"registration"`
+		ID           string                            `json:"id"`
+		Timestamp    int64                             `json:"timestamp"`
+		Signature    []byte                            `json:"signature"`
+		ChainID      string                            `json:"chainId"`
+		SigScheme    int                               `json:"sigScheme"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("invalid request body"), http.StatusBadRequest)
+		return
+	}
+	if req.ID == "" || req.Timestamp == 0 || len(req.Signature) == 0 {
+		writeError(w, errors.New("a registration needs the id, timestamp and signature of the validator"), http.StatusBadRequest)
+		return
+	}
+
+	data, err := json.Marshal(req.Registration)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	tx := blockchain.NewTransaction(req.ID, req.Registration.Address, req.Registration.Address, 0, data)
+	tx.Type = blockchain.HumanProofTxType
+	tx.Timestamp = req.Timestamp
+	tx.Signature = req.Signature
+	tx.ChainID = req.ChainID
+	tx.SigScheme = req.SigScheme
+
+	if err := ws.blockchain.AddTransaction(tx); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, tx)
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Supported locales for user-facing API messages
+const (
+	LocaleEnglish = "en"
+	LocaleTurkish = "tr"
+
+	defaultLocale = LocaleEnglish
+)
+
+// messageCatalog holds the user-facing message for each error code, per locale.
+// English is the reference locale and must contain every code.
+var messageCatalog = map[string]map[ErrorCode]string{
+	LocaleEnglish: {
+		CodeInternal:            "internal server error",
+		CodeBadRequest:          "the request is invalid",
+		CodeUnauthorized:        "the request is not authorized",
+		CodeNotFound:            "the requested resource was not found",
+		CodeConflict:            "the request conflicts with the current state",
+		CodeUnavailable:         "the service is temporarily unavailable",
+		CodeTimeout:             "the request timed out",
+		CodeInsufficientBalance: "insufficient balance",
+		CodeInsufficientLocked:  "insufficient locked balance",
+		CodeAccountNotFound:     "account not found",
+		CodeAccountExists:       "account already exists",
+		CodeSelfTransfer:        "sender and recipient cannot be the same",
+		CodeTxExists:            "transaction already exists",
+		CodeTxNotFound:          "transaction not found",
+		CodeNoPendingTxs:        "there are no pending transactions",
+		CodeInvalidSignature:    "invalid signature",
+		CodeBlockNotFound:       "block not found",
+		CodeInvalidBlock:        "invalid block",
+		CodeUnknownValidator:    "unknown validator",
+		CodeValidatorExists:     "address is already a validator",
+		CodeHumanProofRequired:  "human proof is required",
+		CodeKeyPairNotFound:     "key pair not found",
+		CodeMultiSigNotFound:    "multi-signature wallet not found",
+		CodeMultiSigExists:      "multi-signature wallet already exists",
+		CodeNotOwner:            "address is not an owner of this wallet",
+		CodeAlreadySigned:       "transaction already signed by this owner",
+		CodeNotEnoughSignatures: "not enough signatures",
+		CodeContractNotFound:    "contract not found",
+		CodeVerificationExpired: "the verification session has expired",
+		CodeVerificationFailed:  "human verification failed",
+		CodeUpgradeRequired:     "this node must be upgraded to a newer version",
+		CodeDust:                "the amount is below the dust threshold",
+		CodeReadOnly:            "this node is read-only",
+		CodeInvalidAPIKey:       "a valid API key is required",
+		CodeAPIKeyScope:         "the API key does not allow this request",
+		CodeRateLimited:         "too many requests",
+		CodeAccessDenied:        "access from this address is not allowed",
+		CodeWalletLocked:        "the wallet is locked, unlock it with its passphrase first",
+		CodeSpendingLimit:       "the daily spending limit of the wallet is exceeded",
+		CodeSecondFactor:        "the wallet requires a valid second factor",
+		CodeMaintenance:         "the node is in maintenance, retry later",
+		CodeBlockQuota:          "the validator already produced its share of blocks in this epoch",
+		CodeChainHalted:         "the chain is paused for incident response, retry once it resumes",
+	},
+	LocaleTurkish: {
+		CodeInternal:            "sunucu hatası",
+		CodeBadRequest:          "istek geçersiz",
+		CodeUnauthorized:        "istek yetkili değil",
+		CodeNotFound:            "istenen kaynak bulunamadı",
+		CodeConflict:            "istek mevcut durumla çakışıyor",
+		CodeUnavailable:         "servis geçici olarak kullanılamıyor",
+		CodeTimeout:             "istek zaman aşımına uğradı",
+		CodeInsufficientBalance: "yetersiz bakiye",
+		CodeInsufficientLocked:  "yetersiz kilitli bakiye",
+		CodeAccountNotFound:     "hesap bulunamadı",
+		CodeAccountExists:       "hesap zaten mevcut",
+		CodeSelfTransfer:        "gönderen ve alıcı aynı olamaz",
+		CodeTxExists:            "işlem zaten mevcut",
+		CodeTxNotFound:          "işlem bulunamadı",
+		CodeNoPendingTxs:        "bekleyen işlem yok",
+		CodeInvalidSignature:    "geçersiz imza",
+		CodeBlockNotFound:       "blok bulunamadı",
+		CodeInvalidBlock:        "geçersiz blok",
+		CodeUnknownValidator:    "bilinmeyen validatör",
+		CodeValidatorExists:     "adres zaten bir validatör",
+		CodeHumanProofRequired:  "insan kanıtı gerekli",
+		CodeKeyPairNotFound:     "anahtar çifti bulunamadı",
+		CodeMultiSigNotFound:    "çoklu imza cüzdanı bulunamadı",
+		CodeMultiSigExists:      "çoklu imza cüzdanı zaten mevcut",
+		CodeNotOwner:            "adres bu cüzdanın sahiplerinden biri değil",
+		CodeAlreadySigned:       "işlem bu sahip tarafından zaten imzalandı",
+		CodeNotEnoughSignatures: "yeterli imza yok",
+		CodeContractNotFound:    "kontrat bulunamadı",
+		CodeVerificationExpired: "doğrulama oturumunun süresi doldu",
+		CodeVerificationFailed:  "insan doğrulaması başarısız oldu",
+		CodeUpgradeRequired:     "bu düğüm daha yeni bir sürüme yükseltilmeli",
+		CodeDust:                "tutar toz eşiğinin altında",
+		CodeReadOnly:            "bu düğüm salt okunur",
+		CodeInvalidAPIKey:       "geçerli bir API anahtarı gerekli",
+		CodeAPIKeyScope:         "API anahtarı bu isteğe izin vermiyor",
+		CodeRateLimited:         "çok fazla istek",
+		CodeAccessDenied:        "bu adresten erişime izin verilmiyor",
+		CodeWalletLocked:        "cüzdan kilitli, önce parolasıyla kilidini açın",
+		CodeSpendingLimit:       "cüzdanın günlük harcama limiti aşıldı",
+		CodeSecondFactor:        "cüzdan geçerli bir ikinci doğrulama gerektiriyor",
+		CodeMaintenance:         "düğüm bakımda, daha sonra tekrar deneyin",
+		CodeBlockQuota:          "validatör bu epoch'taki blok payını zaten üretti",
+		CodeChainHalted:         "zincir olay müdahalesi için durduruldu, devam ettiğinde tekrar deneyin",
+	},
+}
+
+// Message returns the catalog message for code in the given locale,
+// falling back to English when the locale or the code is not translated
+func Message(locale string, code ErrorCode) string {
+	if msgs, ok := messageCatalog[locale]; ok {
+		if msg, ok := msgs[code]; ok {
+			return msg
+		}
+	}
+	return messageCatalog[defaultLocale][code]
+}
+
+// parseAcceptLanguage picks the best supported locale from an Accept-Language header.
+// Quality values are honoured and region subtags are ignored ("tr-TR" matches "tr").
+func parseAcceptLanguage(header string) string {
+	best := defaultLocale
+	bestQ := -1.0
+
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		tag := part
+		q := 1.0
+		if i := strings.Index(part, ";"); i >= 0 {
+			tag = strings.TrimSpace(part[:i])
+			param := strings.TrimSpace(part[i+1:])
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		lang := strings.ToLower(tag)
+		if i := strings.Index(lang, "-"); i >= 0 {
+			lang = lang[:i]
+		}
+		if _, ok := messageCatalog[lang]; !ok || q <= 0 {
+			continue
+		}
+		if q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+
+	return best
+}
+
+// localeMiddleware resolves the request locale from Accept-Language and records it
+// in the Content-Language response header, where writeError picks it up
+func localeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Language", parseAcceptLanguage(r.Header.Get("Accept-Language")))
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// responseLocale returns the locale selected for the response being written
+func responseLocale(w http.ResponseWriter) string {
+	if locale := w.Header().Get("Content-Language"); locale != "" {
+		return locale
+	}
+	return defaultLocale
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// Actions that must be signed to audit and prune the keys held by the node
+const (
+	ActionKeysAudit = "keys_audit"
+	ActionKeysPrune = "keys_prune"
+)
+
+// keyArchiveDir is where pruned keys are migrated to, under the data directory
+const keyArchiveDir = "key_archive"
+
+// auditKeys handles POST /api/admin/keys/audit, listing the private keys the
+// node holds and whether each is still needed
+func (ws *WebServer) auditKeys(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionKeysAudit)
+	if req == nil {
+		return
+	}
+
+	audit, err := ws.wallets.AuditKeys()
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to audit keys: %w", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, audit)
+}
+
+// pruneKeys handles POST /api/admin/keys/prune, removing keys the node no
+// longer needs. With migrate the keys are first written to a new directory
+// under <datadir>/key_archive for the operator to move offline.
+// Data: {"addresses": "a,b" (default: every unnecessary key), "migrate": "true"|"false"}
+func (ws *WebServer) pruneKeys(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionKeysPrune)
+	if req == nil {
+		return
+	}
+
+	migrate, err := parseBoolField(req.Data, "migrate")
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	var addresses []string
+	for _, address := range strings.Split(req.Data["addresses"], ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	exportDir := ""
+	if migrate {
+		exportDir = filepath.Join(blockchain.GetBlockchainDataPath(), keyArchiveDir, time.Now().UTC().Format("20060102T150405Z"))
+	}
+
+	report, err := ws.wallets.PruneKeys(addresses, exportDir)
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to prune keys: %w", err), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Admin %s pruned %d keys (%d migrated)", req.AdminAddress, len(report.Deleted), len(report.Migrated))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"report": report,
+	})
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"confirmix/pkg/blockchain"
+
+	"github.com/gorilla/mux"
+)
+
+// Actions that must be signed for the address label endpoints
+const (
+	ActionLabelSet    = "node_label_set"
+	ActionLabelRemove = "node_label_remove"
+)
+
+// getLabels handles GET /api/labels, listing address labels, optionally
+// filtered with ?category=
+func (ws *WebServer) getLabels(w http.ResponseWriter, r *http.Request) {
+	labels := ws.wallets.AddressLabels(r.URL.Query().Get("category"))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"labels": labels,
+		"count":  len(labels),
+	})
+}
+
+// getLabel handles GET /api/labels/{address}
+func (ws *WebServer) getLabel(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	label, exists := ws.wallets.GetAddressLabel(address)
+	if !exists {
+		writeError(w, fmt.Errorf("%w: %s", blockchain.ErrLabelNotFound, address), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, label)
+}
+
+// setLabel handles attaching a label to an address.
+// Data: {"address": "...", "label": "...", "category", "description", "url" (optional)}
+func (ws *WebServer) setLabel(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionLabelSet)
+	if req == nil {
+		return
+	}
+
+	label, err := ws.wallets.SetAddressLabel(blockchain.AddressLabel{
+		Address:     req.Data["address"],
+		Label:       req.Data["label"],
+		Category:    req.Data["category"],
+		Description: req.Data["description"],
+		URL:         req.Data["url"],
+		Source:      blockchain.LabelSourceAdmin,
+		UpdatedBy:   req.AdminAddress,
+	})
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Admin %s labeled %s as %q", req.AdminAddress, label.Address, label.Label)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"label":  label,
+	})
+}
+
+// removeLabel handles removing the label of an address.
+// Data: {"address": "..."}
+func (ws *WebServer) removeLabel(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionLabelRemove)
+	if req == nil {
+		return
+	}
+
+	address := req.Data["address"]
+	if err := ws.wallets.RemoveAddressLabel(address); err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Admin %s removed the label of %s", req.AdminAddress, address)
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":  "success",
+		"address": address,
+	})
+}
+
+// addressLabel returns the label of address for embedding in responses, or nil
+func (ws *WebServer) addressLabel(address string) *blockchain.AddressLabel {
+	if label, exists := ws.wallets.GetAddressLabel(address); exists {
+		return &label
+	}
+	return nil
+}
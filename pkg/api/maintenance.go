@@ -0,0 +1,238 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ActionNodeMaintenance must be signed to switch maintenance mode
+const ActionNodeMaintenance = "node_maintenance"
+
+// maintenanceRoute stays reachable in maintenance mode so it can be switched off
+const maintenanceRoute = "/api/admin/node/maintenance"
+
+const (
+	// DefaultMaintenanceRetryAfter is the Retry-After of refused requests when the admin sets none
+	DefaultMaintenanceRetryAfter = 60 * time.Second
+	// maintenanceDrainTimeout bounds how long entering maintenance waits for in-flight writes
+	maintenanceDrainTimeout = 30 * time.Second
+)
+
+// maintenanceState is the maintenance switch and the count of write requests
+// being served. Writes are counted under the read lock, so once the switch is
+// turned on under the write lock no new write can start.
+type maintenanceState struct {
+	mu         sync.RWMutex
+	enabled    bool
+	serveReads bool
+	reason     string
+	since      time.Time
+	retryAfter time.Duration
+	writes     int
+}
+
+// MaintenanceStatus describes the maintenance mode of the node
+type MaintenanceStatus struct {
+	Enabled    bool   `json:"enabled"`
+	ServeReads bool   `json:"serveReads"`
+	Reason     string `json:"reason,omitempty"`
+	Since      int64  `json:"since,omitempty"`
+	RetryAfter int    `json:"retryAfter,omitempty"` // seconds
+	InFlight   int    `json:"inFlightWrites"`
+}
+
+// EnterMaintenance refuses new write requests with 503 and Retry-After, and
+// reads too unless serveReads is set. Requests already running are not
+// interrupted; see DrainWrites.
+func (ws *WebServer) EnterMaintenance(reason string, serveReads bool, retryAfter time.Duration) {
+	if retryAfter <= 0 {
+		retryAfter = DefaultMaintenanceRetryAfter
+	}
+	ws.maintenance.mu.Lock()
+	defer ws.maintenance.mu.Unlock()
+	if !ws.maintenance.enabled {
+		ws.maintenance.since = time.Now()
+	}
+	ws.maintenance.enabled = true
+	ws.maintenance.serveReads = serveReads
+	ws.maintenance.reason = reason
+	ws.maintenance.retryAfter = retryAfter
+}
+
+// ExitMaintenance accepts requests again
+func (ws *WebServer) ExitMaintenance() {
+	ws.maintenance.mu.Lock()
+	defer ws.maintenance.mu.Unlock()
+	ws.maintenance.enabled = false
+	ws.maintenance.reason = ""
+	ws.maintenance.since = time.Time{}
+}
+
+// Maintenance returns the maintenance mode of the node
+func (ws *WebServer) Maintenance() MaintenanceStatus {
+	ws.maintenance.mu.RLock()
+	defer ws.maintenance.mu.RUnlock()
+	status := MaintenanceStatus{
+		Enabled:  ws.maintenance.enabled,
+		InFlight: ws.maintenance.writes,
+	}
+	if status.Enabled {
+		status.ServeReads = ws.maintenance.serveReads
+		status.Reason = ws.maintenance.reason
+		status.Since = ws.maintenance.since.Unix()
+		status.RetryAfter = int(ws.maintenance.retryAfter / time.Second)
+	}
+	return status
+}
+
+// DrainWrites waits until no write request is in flight, for at most timeout.
+// It reports whether every write finished.
+func (ws *WebServer) DrainWrites(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		ws.maintenance.mu.RLock()
+		writes := ws.maintenance.writes
+		ws.maintenance.mu.RUnlock()
+		if writes == 0 {
+			return true
+		}
+		if time.Now().After(deadline) {
+			log.Printf("Warning: %d write requests still running after %s", writes, timeout)
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Drain stops the API for a shutdown: new writes are refused while reads
+// keep being served, in-flight writes get up to timeout to finish, then the
+// server stops
+func (ws *WebServer) Drain(timeout time.Duration) error {
+	ws.EnterMaintenance("node is shutting down", true, DefaultMaintenanceRetryAfter)
+	start := time.Now()
+	ws.DrainWrites(timeout)
+	if ws.server == nil {
+		return nil
+	}
+	remaining := timeout - time.Since(start)
+	if remaining < time.Second {
+		remaining = time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), remaining)
+	defer cancel()
+	return ws.server.Shutdown(ctx)
+}
+
+// maintenanceGate refuses requests while the node is in maintenance and counts
+// the write requests being served
+func (ws *WebServer) maintenanceGate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if routeTemplate(r) == maintenanceRoute {
+			next.ServeHTTP(w, r)
+			return
+		}
+		read := isReadRequest(r)
+
+		ws.maintenance.mu.RLock()
+		enabled, serveReads := ws.maintenance.enabled, ws.maintenance.serveReads
+		retryAfter, reason := ws.maintenance.retryAfter, ws.maintenance.reason
+		if !enabled && !read {
+			ws.maintenance.writes++
+		}
+		ws.maintenance.mu.RUnlock()
+
+		if enabled && (!read || !serveReads) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds()+0.999)))
+			detail := "the node is in maintenance, retry later"
+			if reason != "" {
+				detail = fmt.Sprintf("the node is in maintenance (%s), retry later", reason)
+			}
+			writeErrorCode(w, http.StatusServiceUnavailable, CodeMaintenance, detail)
+			return
+		}
+		if read {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		defer func() {
+			ws.maintenance.mu.Lock()
+			ws.maintenance.writes--
+			ws.maintenance.mu.Unlock()
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// flushState persists the chain state and the transaction pool
+func (ws *WebServer) flushState() error {
+	if err := ws.blockchain.SaveToDisk(); err != nil {
+		return err
+	}
+	return ws.blockchain.SaveMempool()
+}
+
+// nodeMaintenance handles switching maintenance mode. Entering it waits for
+// the writes in flight and flushes the state to disk before answering, so the
+// node can be stopped once the response arrives.
+func (ws *WebServer) nodeMaintenance(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionNodeMaintenance)
+	if req == nil {
+		return
+	}
+
+	enabled, err := parseBoolField(req.Data, "enabled")
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	if !enabled {
+		ws.ExitMaintenance()
+		log.Printf("Admin %s ended maintenance mode", req.AdminAddress)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"status":      "success",
+			"maintenance": ws.Maintenance(),
+		})
+		return
+	}
+
+	serveReads := true
+	if strings.TrimSpace(req.Data["serveReads"]) != "" {
+		if serveReads, err = parseBoolField(req.Data, "serveReads"); err != nil {
+			writeError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+	retryAfter := DefaultMaintenanceRetryAfter
+	if raw := strings.TrimSpace(req.Data["retryAfter"]); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds <= 0 {
+			writeError(w, fmt.Errorf("invalid retryAfter %q, expected a positive number of seconds", raw), http.StatusBadRequest)
+			return
+		}
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	ws.EnterMaintenance(strings.TrimSpace(req.Data["reason"]), serveReads, retryAfter)
+	drained := ws.DrainWrites(maintenanceDrainTimeout)
+	flushErr := ws.flushState()
+	log.Printf("Admin %s started maintenance mode (serve reads: %v, drained: %v)", req.AdminAddress, serveReads, drained)
+
+	response := map[string]interface{}{
+		"status":      "success",
+		"maintenance": ws.Maintenance(),
+		"drained":     drained,
+		"flushed":     flushErr == nil,
+	}
+	if flushErr != nil {
+		log.Printf("Warning: Failed to flush state for maintenance: %v", flushErr)
+		response["flushError"] = flushErr.Error()
+	}
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"confirmix/pkg/blockchain"
+)
+
+// getMetrics handles GET /metrics, exposing the chain height, the pool size
+// and the transaction latency histograms in the Prometheus text format
+func (ws *WebServer) getMetrics(w http.ResponseWriter, r *http.Request) {
+	stats := ws.blockchain.TxLatencyStats()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	writeGauge(w, "confirmix_chain_height", "Index of the newest block", float64(ws.blockchain.GetChainHeight()))
+	writeGauge(w, "confirmix_pending_transactions", "Transactions waiting in the pool", float64(len(ws.blockchain.GetPendingTransactions())))
+	writeGauge(w, "confirmix_tx_lifecycles_open", "Pending transactions whose inclusion is being timed", float64(stats.Tracked))
+	writeHistogram(w, "confirmix_tx_inclusion_latency_seconds", "Time from a transaction entering the pool to the node applying its block", stats.Inclusion)
+	writeHistogram(w, "confirmix_tx_broadcast_latency_seconds", "Time from a transaction entering the pool to the node broadcasting it", stats.Broadcast)
+}
+
+// writeGauge writes a gauge in the Prometheus text format
+func writeGauge(w io.Writer, name, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", name, help, name, name, formatMetric(value))
+}
+
+// writeHistogram writes a histogram in the Prometheus text format
+func writeHistogram(w io.Writer, name, help string, h blockchain.LatencyHistogram) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for i, bound := range h.Buckets {
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", name, formatMetric(bound), h.Counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.Count)
+	fmt.Fprintf(w, "%s_sum %s\n%s_count %d\n", name, formatMetric(h.Sum), name, h.Count)
+}
+
+// formatMetric formats a sample value the shortest way that round-trips
+func formatMetric(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,371 @@
+package api
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// marshalMsgPack encodes v as MessagePack with the same field names and
+// omitempty rules as its JSON encoding, so clients can switch formats without
+// remapping fields. Values with their own JSON encoding (big.Int, time.Time)
+// are converted through it; integers beyond 64 bits become decimal strings.
+func marshalMsgPack(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeMsgPack(&buf, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonNumberType    = reflect.TypeOf(json.Number(""))
+)
+
+func encodeMsgPack(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteByte(0xc0)
+		return nil
+	}
+	if v.Type() == jsonNumberType {
+		return encodeGeneric(buf, v.Interface())
+	}
+
+	if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface && v.CanAddr() && reflect.PtrTo(v.Type()).Implements(jsonMarshalerType) {
+		v = v.Addr()
+	}
+	if v.Type().Implements(jsonMarshalerType) {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		return encodeViaJSON(buf, v.Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		return encodeMsgPack(buf, v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeMsgPackInt(buf, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeMsgPackUint(buf, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		buf.WriteByte(0xcb)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(v.Float()))
+	case reflect.String:
+		writeMsgPackString(buf, v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			writeMsgPackBinary(buf, v.Bytes())
+			return nil
+		}
+		return encodeMsgPackArray(buf, v)
+	case reflect.Array:
+		return encodeMsgPackArray(buf, v)
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		return encodeMsgPackMap(buf, v)
+	case reflect.Struct:
+		return encodeMsgPackStruct(buf, v)
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// encodeViaJSON encodes a value through its JSON form
+func encodeViaJSON(buf *bytes.Buffer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return err
+	}
+	return encodeGeneric(buf, generic)
+}
+
+// encodeGeneric encodes a decoded JSON value, keeping integers exact
+func encodeGeneric(buf *bytes.Buffer, v interface{}) error {
+	number, ok := v.(json.Number)
+	if !ok {
+		return encodeMsgPack(buf, reflect.ValueOf(v))
+	}
+	if i, err := strconv.ParseInt(number.String(), 10, 64); err == nil {
+		writeMsgPackInt(buf, i)
+		return nil
+	}
+	if u, err := strconv.ParseUint(number.String(), 10, 64); err == nil {
+		writeMsgPackUint(buf, u)
+		return nil
+	}
+	if !strings.ContainsAny(number.String(), ".eE") {
+		writeMsgPackString(buf, number.String())
+		return nil
+	}
+	f, err := number.Float64()
+	if err != nil {
+		return err
+	}
+	buf.WriteByte(0xcb)
+	binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+	return nil
+}
+
+func encodeMsgPackArray(buf *bytes.Buffer, v reflect.Value) error {
+	writeMsgPackLength(buf, v.Len(), 0x90, 0xdc, 0xdd)
+	for i := 0; i < v.Len(); i++ {
+		if err := encodeMsgPack(buf, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeMsgPackMap(buf *bytes.Buffer, v reflect.Value) error {
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := msgPackMapKey(iter.Key())
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{key, iter.Value()})
+	}
+	// Sorted like encoding/json so the output is deterministic
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	writeMsgPackLength(buf, len(entries), 0x80, 0xde, 0xdf)
+	for _, e := range entries {
+		writeMsgPackString(buf, e.key)
+		if err := encodeMsgPack(buf, e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// msgPackMapKey converts a map key to a string the way encoding/json does
+func msgPackMapKey(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if key.Type().Implements(textMarshalerType) {
+		text, err := key.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("msgpack: unsupported map key type %s", key.Type())
+}
+
+// msgPackField is a struct field with its JSON name
+type msgPackField struct {
+	name      string
+	value     reflect.Value
+	omitEmpty bool
+}
+
+func encodeMsgPackStruct(buf *bytes.Buffer, v reflect.Value) error {
+	fields := structFields(v)
+	kept := fields[:0]
+	for _, f := range fields {
+		if f.omitEmpty && isEmptyValue(f.value) {
+			continue
+		}
+		kept = append(kept, f)
+	}
+
+	writeMsgPackLength(buf, len(kept), 0x80, 0xde, 0xdf)
+	for _, f := range kept {
+		writeMsgPackString(buf, f.name)
+		if err := encodeMsgPack(buf, f.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structFields lists the exported fields of a struct under their JSON names,
+// flattening untagged embedded structs as encoding/json does
+func structFields(v reflect.Value) []msgPackField {
+	t := v.Type()
+	fields := make([]msgPackField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if sf.Anonymous && name == "" {
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				fields = append(fields, structFields(fv)...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, msgPackField{
+			name:      name,
+			value:     v.Field(i),
+			omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+		})
+	}
+	return fields
+}
+
+// isEmptyValue matches the omitempty rules of encoding/json
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+func writeMsgPackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0:
+		writeMsgPackUint(buf, uint64(i))
+	case i >= -32:
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		binary.Write(buf, binary.BigEndian, int16(i))
+	case i >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		binary.Write(buf, binary.BigEndian, int32(i))
+	default:
+		buf.WriteByte(0xd3)
+		binary.Write(buf, binary.BigEndian, i)
+	}
+}
+
+func writeMsgPackUint(buf *bytes.Buffer, u uint64) {
+	switch {
+	case u <= 0x7f:
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		buf.WriteByte(0xcc)
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		binary.Write(buf, binary.BigEndian, uint16(u))
+	case u <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		binary.Write(buf, binary.BigEndian, uint32(u))
+	default:
+		buf.WriteByte(0xcf)
+		binary.Write(buf, binary.BigEndian, u)
+	}
+}
+
+func writeMsgPackString(buf *bytes.Buffer, s string) {
+	switch n := len(s); {
+	case n <= 31:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdb)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+	buf.WriteString(s)
+}
+
+func writeMsgPackBinary(buf *bytes.Buffer, b []byte) {
+	switch n := len(b); {
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xc4)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xc5)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xc6)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+	buf.Write(b)
+}
+
+// writeMsgPackLength writes an array or map header: the fix form for up to 15
+// entries, then the 16- and 32-bit forms
+func writeMsgPackLength(buf *bytes.Buffer, n int, fix, len16, len32 byte) {
+	switch {
+	case n <= 15:
+		buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(len16)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(len32)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// getThresholdKeyHash handles GET /api/multisig/wallet/{address}/threshold-key/hash?groupKey=...:
+// the digest owners sign to approve registering groupKey as the wallet's threshold key
+func (ws *WebServer) getThresholdKeyHash(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	groupKey := r.URL.Query().Get("groupKey")
+	if groupKey == "" {
+		writeError(w, errors.New("groupKey is required"), http.StatusBadRequest)
+		return
+	}
+
+	wallet, err := ws.multisig.GetMultiSigWallet(address)
+	if err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+	hash, err := wallet.ThresholdKeyHash(groupKey)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"walletAddress": address,
+		"groupKey":      groupKey,
+		"signingHash":   hex.EncodeToString(hash),
+		"requiredSigs":  wallet.GetRequiredSignatures(),
+	})
+}
+
+// setThresholdKey handles POST /api/multisig/wallet/{address}/threshold-key.
+// Body: {"groupKey": "...", "approvals": {"<owner>": "<signature>"}} where each
+// approval is the owner's hex signature over the threshold key hash, from
+// at least the wallet's required number of owners.
+func (ws *WebServer) setThresholdKey(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	var req struct {
+		GroupKey  string            `json:"groupKey"`
+		Approvals map[string]string `json:"approvals"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("invalid request body"), http.StatusBadRequest)
+		return
+	}
+	if req.GroupKey == "" || len(req.Approvals) == 0 {
+		writeError(w, errors.New("groupKey and approvals are required"), http.StatusBadRequest)
+		return
+	}
+
+	if err := ws.multisig.SetMultiSigThresholdKey(address, req.GroupKey, req.Approvals); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":        "success",
+		"walletAddress": address,
+		"groupKey":      req.GroupKey,
+	})
+}
+
+// submitThresholdSignature handles POST /api/multisig/transaction/threshold-sign.
+// Body: {"walletAddress": "...", "txID": "...", "signature": "..."} where
+// signature is the hex aggregated threshold signature over the transaction's
+// signing hash. It approves the transaction in place of owner signatures.
+func (ws *WebServer) submitThresholdSignature(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		WalletAddress string `json:"walletAddress"`
+		TxID          string `json:"txID"`
+		Signature     string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("invalid request body"), http.StatusBadRequest)
+		return
+	}
+	if req.WalletAddress == "" || req.TxID == "" || req.Signature == "" {
+		writeError(w, errors.New("walletAddress, txID and signature are required"), http.StatusBadRequest)
+		return
+	}
+
+	if err := ws.multisig.SubmitMultiSigThresholdSignature(req.WalletAddress, req.TxID, req.Signature); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status": "success",
+		"txID":   req.TxID,
+	})
+}
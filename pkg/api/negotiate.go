@@ -0,0 +1,115 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Response media types offered by the high-volume list endpoints
+const (
+	mediaJSON     = "application/json"
+	mediaMsgPack  = "application/msgpack"
+	mediaProtobuf = "application/x-protobuf"
+)
+
+// mediaAliases maps other common names of the offered media types
+var mediaAliases = map[string]string{
+	"application/x-msgpack":           mediaMsgPack,
+	"application/vnd.msgpack":         mediaMsgPack,
+	"application/protobuf":            mediaProtobuf,
+	"application/vnd.google.protobuf": mediaProtobuf,
+}
+
+// negotiateFormat picks the response media type from the Accept header: the
+// offered type with the highest q value wins, and a type named explicitly beats
+// a wildcard with the same q. Wildcards and clients that accept none of the
+// offered types get JSON.
+func negotiateFormat(r *http.Request) string {
+	best, bestQ, bestExplicit := mediaJSON, -1.0, false
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if alias, ok := mediaAliases[mediaType]; ok {
+			mediaType = alias
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.TrimSpace(key) == "q" {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		explicit := true
+		switch mediaType {
+		case "application/*", "*/*":
+			mediaType, explicit = mediaJSON, false
+		case mediaJSON, mediaMsgPack, mediaProtobuf:
+		default:
+			continue
+		}
+		if q > bestQ || (q == bestQ && explicit && !bestExplicit) {
+			best, bestQ, bestExplicit = mediaType, q, explicit
+		}
+	}
+	return best
+}
+
+// writeNegotiated writes v as JSON, MessagePack or protobuf depending on the
+// Accept header, with the same ?fields= selection as
+// writeLightJSON. pb is the protobuf form of v. Field selection has no
+// protobuf form, so protobuf requests with ?fields= are rejected.
+func writeNegotiated(w http.ResponseWriter, r *http.Request, status int, v interface{}, pb protoMessage) {
+	addVary(w.Header(), "Accept")
+
+	format := negotiateFormat(r)
+	if format == mediaJSON {
+		writeLightJSON(w, r, status, v)
+		return
+	}
+
+	fields := parseFieldSelection(r)
+	if format == mediaProtobuf && fields != nil {
+		writeError(w, errors.New("field selection is not supported for protobuf responses"), http.StatusBadRequest)
+		return
+	}
+
+	var body []byte
+	if format == mediaProtobuf {
+		body = marshalProto(pb)
+	} else {
+		v = withStringAmounts(v)
+		if fields != nil {
+			selected, err := selectFields(v, fields)
+			if err != nil {
+				writeError(w, err, http.StatusInternalServerError)
+				return
+			}
+			v = selected
+		}
+		encoded, err := marshalMsgPack(v)
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+		body = encoded
+	}
+
+	w.Header().Set("Content-Type", format)
+	writeEncoded(w, status, body)
+}
+
+// writeEncoded writes an encoded body; compressResponses compresses it when it is large
+func writeEncoded(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -0,0 +1,16 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+)
+
+// getNetworkStats handles GET /api/network/stats, the traffic and round-trip
+// latency of each peer since the node started, slowest peers first
+func (ws *WebServer) getNetworkStats(w http.ResponseWriter, r *http.Request) {
+	if ws.node.p2pNode == nil {
+		writeError(w, errors.New("P2P networking is not enabled on this node"), http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, http.StatusOK, ws.node.p2pNode.NetworkStats())
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// observerReadRoutes are POST routes that only read state and stay open on an
+// observer node. Signed admin reads are listed too; the writes next to them
+// are refused.
+var observerReadRoutes = map[string]bool{
+	"/api/transactions/status":    true,
+	"/api/verify/block":           true,
+	"/api/admin/node/config":      true,
+	"/api/admin/backups":          true,
+	"/api/admin/apikeys":          true,
+	"/api/admin/wallets/controls": true,
+	pprofRoute + "{profile}":      true, // symbol lookups are POSTed
+}
+
+// SetObserverMode makes the API read-only: every route that submits
+// transactions, mines or changes node state answers 403, while the read API
+// keeps working. It must be called before Start.
+func (ws *WebServer) SetObserverMode(observer bool) {
+	ws.observer = observer
+}
+
+// ObserverMode reports whether the API is read-only
+func (ws *WebServer) ObserverMode() bool {
+	return ws.observer
+}
+
+// routeTemplate returns the path template of the matched route, or the
+// request path when no route matched
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
+
+// isReadRequest reports whether a request only reads state: safe methods and
+// the POST routes of observerReadRoutes
+func isReadRequest(r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+		return true
+	}
+	return observerReadRoutes[routeTemplate(r)]
+}
+
+// rejectObserverWrites refuses write requests on an observer node
+func (ws *WebServer) rejectObserverWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ws.observer || isReadRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		writeErrorCode(w, http.StatusForbidden, CodeReadOnly,
+			"this node is a read-only observer; send writes to a validator or full node")
+	})
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"confirmix/pkg/blockchain"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// maxOraclePointsPerPage caps the history returned by GET /api/oracle/{feed}
+const maxOraclePointsPerPage = 1000
+
+// getOracleFeeds handles GET /api/oracle, listing every feed with its latest data point
+func (ws *WebServer) getOracleFeeds(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"feeds": ws.blockchain.OracleFeeds(),
+	})
+}
+
+// getOracleFeed handles GET /api/oracle/{feed}?limit=N, returning the latest
+// data point of a feed and its history, newest first
+func (ws *WebServer) getOracleFeed(w http.ResponseWriter, r *http.Request) {
+	feed := mux.Vars(r)["feed"]
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > maxOraclePointsPerPage {
+			writeError(w, errors.New("limit must be between 1 and 1000"), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	history, err := ws.blockchain.OracleHistory(feed, limit)
+	if err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"feed":    feed,
+		"latest":  history[0],
+		"history": history,
+	})
+}
+
+// postOracleData handles POST /api/oracle/{feed}, posting a data point signed
+// by a custodied oracle wallet. The wallet must be unlocked. Oracles whose
+// keys the node does not hold submit a signed oracle_data transaction to
+// /api/transactions instead.
+func (ws *WebServer) postOracleData(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Oracle     string `json:"oracle"`
+		Value      string `json:"value"`
+		ObservedAt int64  `json:"observedAt,omitempty"` // defaults to now
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
+		return
+	}
+	if req.Oracle == "" {
+		writeError(w, errors.New("Missing required fields"), http.StatusBadRequest)
+		return
+	}
+	if req.ObservedAt == 0 {
+		req.ObservedAt = time.Now().Unix()
+	}
+
+	tx, err := blockchain.NewOracleDataTransaction(uuid.New().String(), req.Oracle, blockchain.OracleDataPoint{
+		Feed:       mux.Vars(r)["feed"],
+		Value:      req.Value,
+		ObservedAt: req.ObservedAt,
+	})
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	ws.submitOracleTransaction(w, r, tx)
+}
+
+// getOracles handles GET /api/oracles, listing the authorized oracle accounts
+func (ws *WebServer) getOracles(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"oracles": ws.blockchain.Oracles(),
+	})
+}
+
+// authorizeOracle handles POST /api/oracles/authorize, by which a custodied
+// validator wallet grants or revokes an oracle account. The authorization
+// takes effect once the transaction is confirmed.
+func (ws *WebServer) authorizeOracle(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Validator string   `json:"validator"`
+		Oracle    string   `json:"oracle"`
+		Feeds     []string `json:"feeds,omitempty"`
+		Revoke    bool     `json:"revoke,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
+		return
+	}
+	if req.Validator == "" || req.Oracle == "" {
+		writeError(w, errors.New("Missing required fields"), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := blockchain.NewOracleAuthorizeTransaction(uuid.New().String(), req.Validator, blockchain.OracleAuthorization{
+		Oracle: req.Oracle,
+		Feeds:  req.Feeds,
+		Revoke: req.Revoke,
+	})
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	ws.submitOracleTransaction(w, r, tx)
+}
+
+// submitOracleTransaction signs an oracle transaction with its unlocked sender
+// wallet and adds it to the pool
+func (ws *WebServer) submitOracleTransaction(w http.ResponseWriter, r *http.Request, tx *blockchain.Transaction) {
+	if err := ws.signWithSession(r, tx); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	if err := ws.blockchain.AddTransaction(tx); err != nil {
+		writeError(w, fmt.Errorf("failed to submit oracle transaction: %w", err), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusCreated, tx)
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"confirmix/pkg/blockchain"
+)
+
+// Pagination of chain lists. Blocks, confirmed transactions and events are
+// ordered by block index, then by position in the block (transactions) or in
+// the event journal (events). Newest first is "desc", oldest first "asc".
+// A page that may be followed by more carries a continuation token: in the
+// X-Next-Cursor header for endpoints returning a bare list, in "nextCursor"
+// otherwise. Passing it back as ?cursor= resumes right after the last item
+// of the page. The token encodes a chain position, not an offset, so pages
+// neither repeat nor skip items when new blocks arrive, caches refresh or
+// the node restarts. Pending transactions have no chain position and are not
+// paged.
+
+// nextCursorHeader carries the continuation token of bare-list responses
+const nextCursorHeader = "X-Next-Cursor"
+
+// Page orders
+const (
+	orderAsc  = "asc"
+	orderDesc = "desc"
+)
+
+// Kinds of continuation tokens; a token only resumes the list it came from
+const (
+	cursorBlocks = "blocks"
+	cursorTxs    = "txs"
+	cursorEvents = "events"
+)
+
+// errInvalidCursor is returned for malformed or foreign continuation tokens
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is the position of the last item of a page. Tokens are opaque
+// to clients: base64url-encoded JSON.
+type pageCursor struct {
+	Kind  string `json:"k"`
+	Order string `json:"o"`
+	Block uint64 `json:"b,omitempty"`
+	Tx    int    `json:"t,omitempty"` // position in the block's transactions
+	Seq   uint64 `json:"s,omitempty"` // event journal sequence
+}
+
+// encode returns the token of the cursor
+func (c pageCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// parsePage reads the order and cursor query parameters of a list of kind.
+// A cursor keeps the order of the list it came from; an explicit order that
+// contradicts it is rejected. cursor is nil on the first page.
+func parsePage(r *http.Request, kind, defaultOrder string) (order string, cursor *pageCursor, err error) {
+	query := r.URL.Query()
+	order = query.Get("order")
+	if order != "" && order != orderAsc && order != orderDesc {
+		return "", nil, errors.New("order must be asc or desc")
+	}
+
+	if raw := query.Get("cursor"); raw != "" {
+		data, err := base64.RawURLEncoding.DecodeString(raw)
+		if err != nil {
+			return "", nil, errInvalidCursor
+		}
+		cursor = &pageCursor{}
+		if err := json.Unmarshal(data, cursor); err != nil || cursor.Kind != kind || (cursor.Order != orderAsc && cursor.Order != orderDesc) {
+			return "", nil, errInvalidCursor
+		}
+		if order != "" && order != cursor.Order {
+			return "", nil, errors.New("order does not match the cursor")
+		}
+		return cursor.Order, cursor, nil
+	}
+
+	if order == "" {
+		order = defaultOrder
+	}
+	return order, nil, nil
+}
+
+// blockRange returns the first block index of a page and the step towards the
+// next ones, or ok=false when the page starts past either end of the chain
+func blockRange(height uint64, order string, cursor *pageCursor) (start int64, step int64, ok bool) {
+	if order == orderAsc {
+		start, step = 0, 1
+		if cursor != nil {
+			start = int64(cursor.Block) + 1
+		}
+	} else {
+		start, step = int64(height), -1
+		if cursor != nil {
+			start = int64(cursor.Block) - 1
+		}
+	}
+	return start, step, start >= 0 && start <= int64(height)
+}
+
+// confirmedTxPage returns up to limit confirmed transactions in order,
+// starting after cursor, and the cursor of the last one. include filters the
+// transactions; a false result skips one without ending the page. The
+// transactions are copies carrying their status and block.
+func (ws *WebServer) confirmedTxPage(order string, cursor *pageCursor, limit int, include func(*blockchain.Transaction) bool) ([]*blockchain.Transaction, *pageCursor) {
+	txs := make([]*blockchain.Transaction, 0)
+	height := ws.blockchain.GetChainHeight()
+
+	// The block of the cursor may hold more transactions after it
+	index, step := int64(height), int64(-1)
+	if order == orderAsc {
+		index, step = 0, 1
+	}
+	if cursor != nil {
+		index = int64(cursor.Block)
+	}
+
+	var last *pageCursor
+	for ; index >= 0 && index <= int64(height) && len(txs) < limit; index += step {
+		block, err := ws.blockchain.GetBlockByIndex(uint64(index))
+		if err != nil {
+			continue
+		}
+
+		// Positions in block order; descending pages walk them backwards
+		positions := make([]int, 0, len(block.Transactions))
+		for i := range block.Transactions {
+			if cursor != nil && uint64(index) == cursor.Block {
+				if (order == orderAsc && i <= cursor.Tx) || (order == orderDesc && i >= cursor.Tx) {
+					continue
+				}
+			}
+			positions = append(positions, i)
+		}
+		if order == orderDesc {
+			for i, j := 0, len(positions)-1; i < j; i, j = i+1, j-1 {
+				positions[i], positions[j] = positions[j], positions[i]
+			}
+		}
+
+		for _, i := range positions {
+			if len(txs) >= limit {
+				break
+			}
+			tx := block.Transactions[i]
+			last = &pageCursor{Kind: cursorTxs, Order: order, Block: block.Index, Tx: i}
+			if include != nil && !include(tx) {
+				continue
+			}
+			txCopy := *tx
+			txCopy.Status = "confirmed"
+			txCopy.BlockIndex = int64(block.Index)
+			txCopy.BlockHash = block.Hash
+			txs = append(txs, &txCopy)
+		}
+	}
+	return txs, last
+}
+
+// setNextCursor sets the continuation token header of a full page; a page
+// shorter than its limit is the last one
+func setNextCursor(w http.ResponseWriter, cursor *pageCursor, count, limit int) {
+	if cursor != nil && count >= limit {
+		w.Header().Set(nextCursorHeader, cursor.encode())
+	}
+}
+
+// txCursor returns the cursor of a confirmed transaction copy, looking up its
+// position in its block
+func (ws *WebServer) txCursor(tx *blockchain.Transaction, order string) *pageCursor {
+	block, err := ws.blockchain.GetBlockByIndex(uint64(tx.BlockIndex))
+	if err != nil {
+		return nil
+	}
+	for i, blockTx := range block.Transactions {
+		if blockTx.ID == tx.ID {
+			return &pageCursor{Kind: cursorTxs, Order: order, Block: block.Index, Tx: i}
+		}
+	}
+	return nil
+}
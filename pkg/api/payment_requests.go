@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/units"
+
+	"github.com/gorilla/mux"
+)
+
+// PaymentTemplate is the unsigned transaction that pays a payment request.
+// Wallets sign it as is, or with a larger value to settle a partial payment.
+type PaymentTemplate struct {
+	Type  string `json:"type"`
+	To    string `json:"to"`
+	Value uint64 `json:"value"` // the amount still owed
+	Data  string `json:"data"`
+}
+
+// PaymentRequestResponse is a payment request with its shareable URI and the
+// transaction that pays it
+type PaymentRequestResponse struct {
+	blockchain.PaymentRequest
+	URI      string           `json:"uri"`
+	Template *PaymentTemplate `json:"template,omitempty"` // omitted once the request is closed
+}
+
+// paymentRequestResponse builds the response for a payment request
+func paymentRequestResponse(request blockchain.PaymentRequest) PaymentRequestResponse {
+	response := PaymentRequestResponse{PaymentRequest: request, URI: request.URI()}
+	if request.Status == blockchain.PaymentRequestPending || request.Status == blockchain.PaymentRequestPartial {
+		response.Template = &PaymentTemplate{
+			Type:  "regular",
+			To:    request.Recipient,
+			Value: request.Amount - request.Paid,
+			Data:  request.Data(),
+		}
+	}
+	return response
+}
+
+// createPaymentRequest handles POST /api/payment-requests. Requests with an
+// API key belong to the key; others to the recipient, whose custodied wallet
+// must be unlocked, or to the unlocked wallet given as wallet.
+func (ws *WebServer) createPaymentRequest(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Recipient string `json:"recipient"`
+		Amount    units.Uint64 `json:"amount"`
+		Memo      string `json:"memo,omitempty"`
+		ExpiresIn int64  `json:"expiresIn,omitempty"` // seconds; default one day, at most 30 days
+		Wallet    string `json:"wallet,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
+		return
+	}
+	if req.Wallet == "" {
+		req.Wallet = req.Recipient
+	}
+	owner, err := ws.requestOwner(r, req.Wallet)
+	if err != nil {
+		writeError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	request, err := ws.wallets.CreatePaymentRequest(owner, req.Recipient, uint64(req.Amount), req.Memo, time.Duration(req.ExpiresIn)*time.Second)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, paymentRequestResponse(request))
+}
+
+// getPaymentRequest handles GET /api/payment-requests/{id}, returning the
+// request, its fulfillment status and the transaction that pays it
+func (ws *WebServer) getPaymentRequest(w http.ResponseWriter, r *http.Request) {
+	request, err := ws.wallets.GetPaymentRequest(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, paymentRequestResponse(request))
+}
+
+// getPaymentRequests handles GET /api/payment-requests, listing requests
+// newest first, optionally filtered with ?recipient= and ?status=
+func (ws *WebServer) getPaymentRequests(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	requests := ws.wallets.PaymentRequests(query.Get("recipient"), query.Get("status"))
+	response := make([]PaymentRequestResponse, 0, len(requests))
+	for _, request := range requests {
+		response = append(response, paymentRequestResponse(request))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"requests": response,
+		"count":    len(response),
+	})
+}
+
+// cancelPaymentRequest handles POST /api/payment-requests/{id}/cancel. Only
+// the API key or wallet that created the request can cancel it.
+func (ws *WebServer) cancelPaymentRequest(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Wallet string `json:"wallet,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
+		return
+	}
+	id := mux.Vars(r)["id"]
+	if req.Wallet == "" {
+		if request, err := ws.wallets.GetPaymentRequest(id); err == nil {
+			req.Wallet = request.Recipient
+		}
+	}
+	owner, err := ws.requestOwner(r, req.Wallet)
+	if err != nil {
+		writeError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	request, err := ws.wallets.CancelPaymentRequest(owner, id)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, paymentRequestResponse(request))
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"confirmix/pkg/consensus"
+
+	"github.com/gorilla/mux"
+)
+
+// pohSessions returns the PoH session manager, or writes 503 and returns nil when none is available
+func (ws *WebServer) pohSessions(w http.ResponseWriter) *consensus.PoHSessionManager {
+	if ws.consensusEngine == nil || ws.consensusEngine.PoHSessions() == nil {
+		writeErrorCode(w, http.StatusServiceUnavailable, CodeUnavailable, "human verification is not available on this node")
+		return nil
+	}
+	return ws.consensusEngine.PoHSessions()
+}
+
+// initiatePoH handles POST /api/poh/initiate and returns a challenge for the address.
+// Body: {"address": "...", "method": "provider"|"signature"}; provider is the default,
+// signature challenges are only offered where signature-only verification is allowed.
+func (ws *WebServer) initiatePoH(w http.ResponseWriter, r *http.Request) {
+	sessions := ws.pohSessions(w)
+	if sessions == nil {
+		return
+	}
+
+	var req struct {
+		Address string `json:"address"`
+		Method  string `json:"method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("invalid request body"), http.StatusBadRequest)
+		return
+	}
+	if req.Address == "" {
+		writeError(w, errors.New("address is required"), http.StatusBadRequest)
+		return
+	}
+
+	session, err := sessions.Initiate(req.Address, req.Method)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, session)
+}
+
+// submitPoH handles POST /api/poh/submit with the response to a challenge.
+// Body: {"sessionId": "...", "address": "...", "response": "..."}; the response is the
+// hex signature of sha256(challenge) or the token handed back by the PoH provider.
+func (ws *WebServer) submitPoH(w http.ResponseWriter, r *http.Request) {
+	sessions := ws.pohSessions(w)
+	if sessions == nil {
+		return
+	}
+
+	var req struct {
+		SessionID string `json:"sessionId"`
+		Address   string `json:"address"`
+		Response  string `json:"response"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("invalid request body"), http.StatusBadRequest)
+		return
+	}
+	if req.SessionID == "" || req.Address == "" {
+		writeError(w, errors.New("sessionId and address are required"), http.StatusBadRequest)
+		return
+	}
+
+	session, err := sessions.Submit(req.SessionID, req.Address, req.Response)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, session)
+}
+
+// getPoHSession handles GET /api/poh/session/{id}
+func (ws *WebServer) getPoHSession(w http.ResponseWriter, r *http.Request) {
+	sessions := ws.pohSessions(w)
+	if sessions == nil {
+		return
+	}
+
+	session, err := sessions.Get(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+
+	// The proof token is only handed out in the submit response
+	session.ProofToken = ""
+	writeJSON(w, http.StatusOK, session)
+}
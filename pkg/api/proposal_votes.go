@@ -0,0 +1,112 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Page sizes of the proposal voter list
+const (
+	defaultVotesPageSize = 50
+	maxVotesPageSize     = 500
+)
+
+// proposalVoter is one entry of a proposal's voter list
+type proposalVoter struct {
+	Voter     string    `json:"voter"`
+	Power     string    `json:"power"`
+	Direction string    `json:"direction"` // "yes" or "no"
+	VotedAt   time.Time `json:"votedAt"`
+}
+
+// getProposalVotes handles GET /api/proposals/{id}/votes, listing voters by
+// voting power. Query: ?direction=yes|no, ?offset= and ?limit= (at most 500).
+func (ws *WebServer) getProposalVotes(w http.ResponseWriter, r *http.Request) {
+	if ws.governance == nil {
+		writeError(w, errors.New("Governance system not enabled"), http.StatusServiceUnavailable)
+		return
+	}
+	query := r.URL.Query()
+
+	direction := strings.ToLower(query.Get("direction"))
+	if direction != "" && direction != "yes" && direction != "no" {
+		writeError(w, errors.New("direction must be yes or no"), http.StatusBadRequest)
+		return
+	}
+	offset, limit := 0, defaultVotesPageSize
+	if raw := query.Get("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			writeError(w, errors.New("offset must be a non-negative integer"), http.StatusBadRequest)
+			return
+		}
+		offset = n
+	}
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			writeError(w, errors.New("limit must be a positive integer"), http.StatusBadRequest)
+			return
+		}
+		if n > maxVotesPageSize {
+			n = maxVotesPageSize
+		}
+		limit = n
+	}
+
+	votes, err := ws.governance.ProposalVotes(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+
+	voters := make([]proposalVoter, 0, len(votes))
+	for _, vote := range votes {
+		entry := proposalVoter{Voter: vote.Voter, Power: vote.VotingPower.String(), Direction: "no", VotedAt: vote.VotedAt}
+		if vote.InFavor {
+			entry.Direction = "yes"
+		}
+		if direction == "" || entry.Direction == direction {
+			voters = append(voters, entry)
+		}
+	}
+
+	total := len(voters)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"votes":   voters[offset:end],
+		"count":   end - offset,
+		"total":   total,
+		"offset":  offset,
+		"limit":   limit,
+		"hasMore": end < total,
+	})
+}
+
+// getProposalTally handles GET /api/proposals/{id}/tally, returning quorum
+// progress, thresholds, time remaining and the projected outcome
+func (ws *WebServer) getProposalTally(w http.ResponseWriter, r *http.Request) {
+	if ws.governance == nil {
+		writeError(w, errors.New("Governance system not enabled"), http.StatusServiceUnavailable)
+		return
+	}
+
+	tally, err := ws.governance.Tally(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to tally proposal: %w", err), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, tally)
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"encoding/binary"
+
+	"confirmix/pkg/blockchain"
+)
+
+// protoMessage is a response with a protobuf encoding; the schema of every
+// message is published in confirmix.proto next to this file
+type protoMessage interface {
+	marshalProto(p *protoWriter)
+}
+
+// marshalProto encodes a message in the protobuf wire format
+func marshalProto(m protoMessage) []byte {
+	p := &protoWriter{}
+	m.marshalProto(p)
+	return p.buf
+}
+
+// protoWriter appends protobuf fields. Zero values are skipped, as proto3 does.
+type protoWriter struct {
+	buf []byte
+}
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func (p *protoWriter) tag(field, wireType int) {
+	p.buf = binary.AppendUvarint(p.buf, uint64(field)<<3|uint64(wireType))
+}
+
+func (p *protoWriter) uint64Field(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	p.tag(field, wireVarint)
+	p.buf = binary.AppendUvarint(p.buf, v)
+}
+
+// int64Field writes an int64 or int32 field; negative values take ten bytes
+func (p *protoWriter) int64Field(field int, v int64) {
+	p.uint64Field(field, uint64(v))
+}
+
+func (p *protoWriter) stringField(field int, s string) {
+	if s == "" {
+		return
+	}
+	p.tag(field, wireBytes)
+	p.buf = binary.AppendUvarint(p.buf, uint64(len(s)))
+	p.buf = append(p.buf, s...)
+}
+
+func (p *protoWriter) bytesField(field int, b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	p.tag(field, wireBytes)
+	p.buf = binary.AppendUvarint(p.buf, uint64(len(b)))
+	p.buf = append(p.buf, b...)
+}
+
+// messageField writes an embedded message. It is always written, even when
+// empty, so repeated fields keep their element count.
+func (p *protoWriter) messageField(field int, m protoMessage) {
+	sub := &protoWriter{}
+	m.marshalProto(sub)
+	p.tag(field, wireBytes)
+	p.buf = binary.AppendUvarint(p.buf, uint64(len(sub.buf)))
+	p.buf = append(p.buf, sub.buf...)
+}
+
+// marshalProto encodes confirmix.api.v1.BlockSummary
+func (b blockSummary) marshalProto(p *protoWriter) {
+	p.uint64Field(1, b.Index)
+	p.int64Field(2, b.Timestamp)
+	p.stringField(3, b.Hash)
+	p.stringField(4, b.PrevHash)
+	p.stringField(5, b.Validator)
+	p.uint64Field(6, uint64(b.Transactions))
+}
+
+// blockSummaryList encodes confirmix.api.v1.BlockSummaryList
+type blockSummaryList []blockSummary
+
+func (l blockSummaryList) marshalProto(p *protoWriter) {
+	for _, b := range l {
+		p.messageField(1, b)
+	}
+}
+
+// protoTransaction encodes confirmix.api.v1.Transaction
+type protoTransaction struct {
+	tx *blockchain.Transaction
+}
+
+func (t protoTransaction) marshalProto(p *protoWriter) {
+	tx := t.tx
+	p.stringField(1, tx.ID)
+	p.stringField(2, tx.From)
+	p.stringField(3, tx.To)
+	p.uint64Field(4, tx.Value)
+	p.bytesField(5, tx.Data)
+	p.int64Field(6, tx.Timestamp)
+	p.bytesField(7, tx.Signature)
+	p.stringField(8, tx.Type)
+	p.stringField(9, tx.Status)
+	p.int64Field(10, tx.BlockIndex)
+	p.stringField(11, tx.BlockHash)
+	p.stringField(12, tx.ChainID)
+	p.int64Field(13, int64(tx.SigScheme))
+}
+
+// transactionList encodes confirmix.api.v1.TransactionList
+type transactionList []*blockchain.Transaction
+
+func (l transactionList) marshalProto(p *protoWriter) {
+	for _, tx := range l {
+		p.messageField(1, protoTransaction{tx})
+	}
+}
+
+// protoHeader encodes confirmix.api.v1.BlockHeader
+type protoHeader blockchain.BlockHeader
+
+func (h protoHeader) marshalProto(p *protoWriter) {
+	p.uint64Field(1, h.Index)
+	p.stringField(2, h.Hash)
+	p.stringField(3, h.PrevHash)
+	p.stringField(4, h.Validator)
+	p.int64Field(5, h.Timestamp)
+	p.uint64Field(6, uint64(h.TxCount))
+}
+
+// headerRange encodes confirmix.api.v1.HeaderRange
+type headerRange struct {
+	height  uint64
+	headers []blockchain.BlockHeader
+}
+
+func (h headerRange) marshalProto(p *protoWriter) {
+	p.uint64Field(1, h.height)
+	for _, header := range h.headers {
+		p.messageField(2, protoHeader(header))
+	}
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"confirmix/pkg/blockchain"
+
+	"github.com/gorilla/mux"
+)
+
+// getBlockReceipt handles GET /api/blocks/{index}/receipt, listing the
+// accounting entries of a block: minted rewards, genesis allocations and,
+// once the chain charges them, fees
+func (ws *WebServer) getBlockReceipt(w http.ResponseWriter, r *http.Request) {
+	index, err := strconv.ParseUint(mux.Vars(r)["index"], 10, 64)
+	if err != nil {
+		writeError(w, errors.New("invalid block index"), http.StatusBadRequest)
+		return
+	}
+
+	receipt, err := ws.blockchain.GetBlockReceipt(index)
+	if err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, blockchain.ErrArchiveUnavailable) {
+			status = http.StatusServiceUnavailable
+		}
+		writeError(w, err, status)
+		return
+	}
+	writeLightJSON(w, r, http.StatusOK, receipt)
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"confirmix/pkg/replica"
+)
+
+// SetReplica attaches the replica client reported by GET /api/replica on a
+// node following a primary
+func (ws *WebServer) SetReplica(r *replica.Replica) {
+	ws.node.replica = r
+}
+
+// getReplicaStatus handles GET /api/replica, reporting the connection to the
+// primary and the replica's lag
+func (ws *WebServer) getReplicaStatus(w http.ResponseWriter, r *http.Request) {
+	if ws.node.replica == nil {
+		writeError(w, errors.New("this node is not a replica"), http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, http.StatusOK, ws.node.replica.Status())
+}
+
+// streamReplicaBlocks handles GET /api/replica/blocks?from=N, the block stream
+// read replicas follow. It sends every block from N on and then each new block
+// as Server-Sent Events, with the chain height between batches. The request
+// must carry an API key with the replica scope, even when keys are optional.
+func (ws *WebServer) streamReplicaBlocks(w http.ResponseWriter, r *http.Request) {
+	if id, _ := r.Context().Value(apiKeyContextKey{}).(string); id == "" {
+		writeErrorCode(w, http.StatusUnauthorized, CodeInvalidAPIKey, "the block stream needs an API key with the replica scope in the "+APIKeyHeader+" header")
+		return
+	}
+
+	var next uint64
+	if raw := r.URL.Query().Get("from"); raw != "" {
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			writeError(w, errors.New("from must be a block index"), http.StatusBadRequest)
+			return
+		}
+		next = n
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, errors.New("streaming is not supported"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	poll := time.NewTicker(headerPollInterval)
+	defer poll.Stop()
+	lastWrite := time.Now()
+
+	for {
+		height := ws.blockchain.GetChainHeight()
+		sent := 0
+		for ; next <= height && sent < maxStreamBacklog; next++ {
+			block, err := ws.blockchain.GetBlockByIndex(next)
+			if err == nil && len(block.Transactions) < block.TxCount {
+				err = fmt.Errorf("block %d is pruned on this node", next)
+			}
+			if err != nil {
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", err)
+				flusher.Flush()
+				return
+			}
+			data, err := json.Marshal(block)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: block\ndata: %s\n\n", block.Index, data); err != nil {
+				return
+			}
+			sent++
+		}
+
+		if sent > 0 {
+			if _, err := fmt.Fprintf(w, "event: height\ndata: %d\n\n", height); err != nil {
+				return
+			}
+			flusher.Flush()
+			lastWrite = time.Now()
+		} else if time.Since(lastWrite) >= streamKeepAlive {
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+			lastWrite = time.Now()
+		}
+
+		// Send the next batch at once while catching up
+		if next <= height {
+			continue
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-poll.C:
+		}
+	}
+}
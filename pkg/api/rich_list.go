@@ -0,0 +1,29 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"confirmix/pkg/blockchain"
+)
+
+// defaultRichListSize is the number of accounts returned when no limit is given
+const defaultRichListSize = 100
+
+// getRichList handles GET /api/stats/richlist?limit=N, returning the accounts
+// with the highest balances and their share of the total supply. System and
+// treasury addresses configured as excluded are left out.
+func (ws *WebServer) getRichList(w http.ResponseWriter, r *http.Request) {
+	limit := defaultRichListSize
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > blockchain.MaxRichListSize {
+			writeError(w, fmt.Errorf("limit must be between 1 and %d", blockchain.MaxRichListSize), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	writeLightJSON(w, r, http.StatusOK, ws.blockchain.RichList(limit))
+}
@@ -0,0 +1,457 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// DefaultRouteKey selects the policy applied to routes without their own entry
+const DefaultRouteKey = "*"
+
+// RoutePolicy bounds how long a route may run and when its circuit breaker opens.
+// A zero TimeoutMs disables the timeout (streaming routes); a zero FailureThreshold
+// disables the breaker. Timeouts bound reads only: a write cut off with a 504
+// would still commit after the client was told it failed, so routes of other
+// methods run to completion whatever their TimeoutMs.
+type RoutePolicy struct {
+	TimeoutMs        int `json:"timeout_ms"`
+	FailureThreshold int `json:"failure_threshold"` // consecutive failures that open the breaker
+	CooldownMs       int `json:"cooldown_ms"`       // how long an open breaker rejects requests
+}
+
+// Validate checks that the policy is usable
+func (p RoutePolicy) Validate() error {
+	if p.TimeoutMs < 0 || p.FailureThreshold < 0 || p.CooldownMs < 0 {
+		return errors.New("route policy values must not be negative")
+	}
+	if p.FailureThreshold > 0 && p.CooldownMs == 0 {
+		return errors.New("cooldown_ms is required when the circuit breaker is enabled")
+	}
+	return nil
+}
+
+func (p RoutePolicy) timeout() time.Duration {
+	return time.Duration(p.TimeoutMs) * time.Millisecond
+}
+
+func (p RoutePolicy) cooldown() time.Duration {
+	return time.Duration(p.CooldownMs) * time.Millisecond
+}
+
+// DefaultRoutePolicies returns the built-in policies keyed by "METHOD /path/template".
+// Cheap cache-backed reads get short deadlines so clients fail fast, writes that
+// persist state only get breakers, and streams are not bounded at all.
+func DefaultRoutePolicies() map[string]RoutePolicy {
+	standard := RoutePolicy{TimeoutMs: 30000, FailureThreshold: 5, CooldownMs: 30000}
+	return map[string]RoutePolicy{
+		DefaultRouteKey: standard,
+
+		"GET /api/status":                          {TimeoutMs: 1000},
+		"GET /api/health":                          {TimeoutMs: 1000},
+		"GET /api/wallet/balance/{address}/simple": {TimeoutMs: 500, FailureThreshold: 10, CooldownMs: 10000},
+		"GET /api/wallet/balance/{address}":        {TimeoutMs: 1000, FailureThreshold: 10, CooldownMs: 10000},
+		"GET /api/transactions/confirmed":          {TimeoutMs: 3000, FailureThreshold: 5, CooldownMs: 15000},
+		"GET /api/blocks":                          {TimeoutMs: 5000, FailureThreshold: 5, CooldownMs: 15000},
+		"GET /api/blocks/{index}":                  {TimeoutMs: 5000, FailureThreshold: 5, CooldownMs: 15000},
+		"GET /api/validators":                      {TimeoutMs: 5000, FailureThreshold: 5, CooldownMs: 15000},
+		"GET /api/transactions/pending":            {TimeoutMs: 10000, FailureThreshold: 5, CooldownMs: 15000},
+		"POST /api/transactions":                   {FailureThreshold: 5, CooldownMs: 30000},
+		"POST /api/wallet/create":                  {FailureThreshold: 5, CooldownMs: 30000},
+		"GET /api/headers/stream":                  {},
+		"GET /api/replica/blocks":                  {},
+		"GET " + pprofRoute + "{profile}":          {}, // CPU profiles and traces run for ?seconds=
+	}
+}
+
+// ValidateRoutePolicies checks route policy overrides without applying them
+func ValidateRoutePolicies(overrides map[string]RoutePolicy) error {
+	for key, policy := range overrides {
+		if key != DefaultRouteKey {
+			method, path, ok := strings.Cut(key, " ")
+			if !ok || method == "" || !strings.HasPrefix(path, "/") {
+				return fmt.Errorf("invalid route key %q: expected \"METHOD /path/template\" or %q", key, DefaultRouteKey)
+			}
+		}
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("route %q: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// SetRoutePolicies validates overrides, merges them over the built-in policies and
+// swaps them in. Breakers start closed again under the new policies.
+func (ws *WebServer) SetRoutePolicies(overrides map[string]RoutePolicy) error {
+	if err := ValidateRoutePolicies(overrides); err != nil {
+		return err
+	}
+	policies := DefaultRoutePolicies()
+	for key, policy := range overrides {
+		policies[key] = policy
+	}
+
+	ws.guards.mu.Lock()
+	defer ws.guards.mu.Unlock()
+	ws.guards.policies = policies
+	ws.guards.breakers = make(map[string]*circuitBreaker)
+	return nil
+}
+
+// RoutePolicies returns the policies in effect
+func (ws *WebServer) RoutePolicies() map[string]RoutePolicy {
+	ws.guards.mu.Lock()
+	defer ws.guards.mu.Unlock()
+	policies := make(map[string]RoutePolicy, len(ws.guards.policies))
+	for key, policy := range ws.guards.policyMapLocked() {
+		policies[key] = policy
+	}
+	return policies
+}
+
+// BreakerStatus describes a route whose circuit breaker has recorded failures
+type BreakerStatus struct {
+	Route     string    `json:"route"`
+	State     string    `json:"state"`
+	Failures  int       `json:"failures"`
+	OpenUntil time.Time `json:"openUntil,omitempty"`
+}
+
+// CircuitBreakers returns the routes whose breakers are not closed and clean, sorted by route
+func (ws *WebServer) CircuitBreakers() []BreakerStatus {
+	ws.guards.mu.Lock()
+	defer ws.guards.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]BreakerStatus, 0)
+	for route, breaker := range ws.guards.breakers {
+		if breaker.failures == 0 && breaker.openUntil.IsZero() {
+			continue
+		}
+		status := BreakerStatus{Route: route, State: breaker.stateLocked(now), Failures: breaker.failures}
+		if now.Before(breaker.openUntil) {
+			status.OpenUntil = breaker.openUntil
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Route < statuses[j].Route })
+	return statuses
+}
+
+// routeGuards holds the route policies and one circuit breaker per route.
+// A nil policy map means the built-in defaults.
+type routeGuards struct {
+	mu       sync.Mutex
+	policies map[string]RoutePolicy
+	breakers map[string]*circuitBreaker
+}
+
+func (g *routeGuards) policyMapLocked() map[string]RoutePolicy {
+	if g.policies == nil {
+		g.policies = DefaultRoutePolicies()
+	}
+	return g.policies
+}
+
+// policyFor returns the policy of a route, falling back to the default entry
+func (g *routeGuards) policyFor(route string) RoutePolicy {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	policies := g.policyMapLocked()
+	if policy, ok := policies[route]; ok {
+		return policy
+	}
+	return policies[DefaultRouteKey]
+}
+
+// admit asks the route's breaker whether a request may run. When it may not,
+// the returned duration tells the client when to retry.
+func (g *routeGuards) admit(route string, policy RoutePolicy) (bool, time.Duration) {
+	if policy.FailureThreshold == 0 {
+		return true, 0
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.breakers == nil {
+		g.breakers = make(map[string]*circuitBreaker)
+	}
+	breaker, ok := g.breakers[route]
+	if !ok {
+		breaker = &circuitBreaker{}
+		g.breakers[route] = breaker
+	}
+	return breaker.admitLocked(time.Now())
+}
+
+// record reports the outcome of an admitted request to the route's breaker
+func (g *routeGuards) record(route string, policy RoutePolicy, failed bool) {
+	if policy.FailureThreshold == 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	breaker, ok := g.breakers[route]
+	if !ok {
+		return
+	}
+	breaker.recordLocked(time.Now(), policy, failed)
+}
+
+// circuitBreaker opens after FailureThreshold consecutive failures and rejects
+// requests until the cooldown passes. It then lets a single probe through:
+// success closes the breaker, failure opens it again.
+type circuitBreaker struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func (b *circuitBreaker) stateLocked(now time.Time) string {
+	switch {
+	case now.Before(b.openUntil):
+		return "open"
+	case !b.openUntil.IsZero():
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+func (b *circuitBreaker) admitLocked(now time.Time) (bool, time.Duration) {
+	if now.Before(b.openUntil) {
+		return false, b.openUntil.Sub(now)
+	}
+	if !b.openUntil.IsZero() {
+		// Half-open: only one probe at a time
+		if b.probing {
+			return false, time.Second
+		}
+		b.probing = true
+	}
+	return true, 0
+}
+
+func (b *circuitBreaker) recordLocked(now time.Time, policy RoutePolicy, failed bool) {
+	wasProbe := b.probing
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return
+	}
+
+	b.failures++
+	if wasProbe || b.failures >= policy.FailureThreshold {
+		b.openUntil = now.Add(policy.cooldown())
+	}
+}
+
+// routeKey identifies the matched route as "METHOD /path/template"
+func routeKey(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return r.Method + " " + template
+		}
+	}
+	return r.Method + " " + r.URL.Path
+}
+
+// guardRoutes applies the route's policy: requests are rejected with 503 while
+// its breaker is open, reads are cut off with 504 when they overrun the timeout
+// and requests are answered with 500 when the handler panics. Timeouts and 5xx
+// responses count as failures.
+func (ws *WebServer) guardRoutes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeKey(r)
+		policy := ws.guards.policyFor(route)
+
+		if ok, retryAfter := ws.guards.admit(route, policy); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds()+0.999)))
+			writeErrorCode(w, http.StatusServiceUnavailable, CodeUnavailable,
+				fmt.Sprintf("%s is temporarily unavailable after repeated failures", route))
+			return
+		}
+
+		var status int
+		if policy.TimeoutMs == 0 || !isReadMethod(r.Method) {
+			status = serveRecovered(w, r, next, route)
+		} else {
+			status = serveWithTimeout(w, r, next, route, policy.timeout())
+		}
+		ws.guards.record(route, policy, status >= http.StatusInternalServerError)
+	})
+}
+
+// isReadMethod reports whether requests of method leave the node's state alone
+// and can be abandoned at a deadline
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// serveRecovered runs the handler directly, turning a panic into a 500 response
+func serveRecovered(w http.ResponseWriter, r *http.Request, next http.Handler, route string) (status int) {
+	sw := &statusWriter{ResponseWriter: w}
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("PANIC in %s: %v\n%s", route, p, debug.Stack())
+			if sw.status == 0 {
+				writeErrorCode(sw, http.StatusInternalServerError, CodeInternal, fmt.Sprint(p))
+			}
+			status = http.StatusInternalServerError
+		}
+	}()
+
+	next.ServeHTTP(sw, r)
+	if sw.status == 0 {
+		return http.StatusOK
+	}
+	return sw.status
+}
+
+// serveWithTimeout runs the handler with a deadline on its context and buffers its
+// response. If the deadline passes first the client gets a 504 and whatever the
+// handler writes afterwards is discarded.
+func serveWithTimeout(w http.ResponseWriter, r *http.Request, next http.Handler, route string, timeout time.Duration) int {
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	tw := &timeoutWriter{header: w.Header().Clone()}
+	done := make(chan struct{})
+	panicked := make(chan interface{}, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				log.Printf("PANIC in %s: %v\n%s", route, p, debug.Stack())
+				panicked <- p
+			}
+			close(done)
+		}()
+		next.ServeHTTP(tw, r.WithContext(ctx))
+	}()
+
+	select {
+	case <-done:
+		select {
+		case p := <-panicked:
+			writeErrorCode(w, http.StatusInternalServerError, CodeInternal, fmt.Sprint(p))
+			return http.StatusInternalServerError
+		default:
+		}
+		return tw.flushTo(w)
+
+	case <-ctx.Done():
+		tw.expire()
+		if errors.Is(ctx.Err(), context.Canceled) {
+			// The client went away; there is nobody to answer
+			return 0
+		}
+		log.Printf("Timeout after %v in %s", timeout, route)
+		writeErrorCode(w, http.StatusGatewayTimeout, CodeTimeout,
+			fmt.Sprintf("%s did not complete within %v", route, timeout))
+		return http.StatusGatewayTimeout
+	}
+}
+
+// timeoutWriter buffers a handler's response until it is known to finish in time
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	expired     bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.expired || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	tw.status = status
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.expired {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.wroteHeader = true
+		tw.status = http.StatusOK
+	}
+	return tw.buf.Write(b)
+}
+
+// expire discards the buffered response and rejects further writes
+func (tw *timeoutWriter) expire() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.expired = true
+	tw.buf.Reset()
+}
+
+// flushTo copies the buffered headers, status and body to w and returns the status
+func (tw *timeoutWriter) flushTo(w http.ResponseWriter) int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	dst := w.Header()
+	for key := range dst {
+		delete(dst, key)
+	}
+	for key, values := range tw.header {
+		dst[key] = values
+	}
+	status := tw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	w.Write(tw.buf.Bytes())
+	return status
+}
+
+// statusWriter records the status of a response written straight to the client
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	if sw.status == 0 {
+		sw.status = status
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+	return sw.ResponseWriter.Write(b)
+}
+
+// Flush keeps streaming endpoints working through the wrapper
+func (sw *statusWriter) Flush() {
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,217 @@
+package api_test
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"confirmix/pkg/api"
+	"confirmix/pkg/blockchain"
+)
+
+// slowChain makes every call of the fake take delay and bounds route to timeoutMs
+func slowChain(route string, timeoutMs int, delay time.Duration) func(t *testing.T, env *testEnv) {
+	return func(t *testing.T, env *testEnv) {
+		if err := env.server.SetRoutePolicies(map[string]api.RoutePolicy{route: {TimeoutMs: timeoutMs}}); err != nil {
+			t.Fatal(err)
+		}
+		env.fake.SetDelay(delay)
+	}
+}
+
+func TestRouteTimeouts(t *testing.T) {
+	runHandlerCases(t, []handlerCase{
+		{
+			name:   "blocks past the deadline",
+			setup:  slowChain("GET /api/blocks", 20, 200*time.Millisecond),
+			method: "GET", path: "/api/blocks",
+			status: http.StatusGatewayTimeout, code: api.CodeTimeout,
+		},
+		{
+			name:   "blocks within the deadline",
+			setup:  slowChain("GET /api/blocks", 5000, time.Millisecond),
+			method: "GET", path: "/api/blocks",
+			status: http.StatusOK,
+		},
+		{
+			name:   "block by index past the deadline",
+			setup:  slowChain("GET /api/blocks/{index}", 20, 200*time.Millisecond),
+			method: "GET", path: "/api/blocks/0",
+			status: http.StatusGatewayTimeout, code: api.CodeTimeout,
+		},
+		{
+			name:   "balance past the deadline",
+			setup:  slowChain("GET /api/wallet/balance/{address}", 20, 200*time.Millisecond),
+			method: "GET", path: "/api/wallet/balance/alice",
+			status: http.StatusGatewayTimeout, code: api.CodeTimeout,
+		},
+		{
+			// A transfer cut off at its deadline would still be committed
+			name: "transfer past the deadline",
+			setup: func(t *testing.T, env *testEnv) {
+				unlocked("alice", 100)(t, env)
+				slowChain("POST /api/transactions", 20, 50*time.Millisecond)(t, env)
+			},
+			method: "POST", path: "/api/transactions",
+			body:   `{"from": "alice", "to": "bob", "value": 1}`,
+			status: http.StatusCreated,
+		},
+		{
+			name:   "write under the default deadline",
+			setup:  slowChain(api.DefaultRouteKey, 20, 50*time.Millisecond),
+			method: "POST", path: "/api/wallet/create",
+			status: http.StatusCreated,
+		},
+		{
+			name:   "route without a deadline",
+			setup:  slowChain("GET /api/status", 0, 50*time.Millisecond),
+			method: "GET", path: "/api/status",
+			status: http.StatusOK,
+		},
+		{
+			name:   "deadline of another route",
+			setup:  slowChain("GET /api/blocks", 20, 50*time.Millisecond),
+			method: "GET", path: "/api/transactions/confirmed",
+			status: http.StatusOK,
+		},
+	})
+}
+
+func TestCircuitBreakerOpensAfterFailures(t *testing.T) {
+	env := newTestEnv(t, false)
+	if err := env.server.SetRoutePolicies(map[string]api.RoutePolicy{
+		"GET /api/blocks/{index}": {TimeoutMs: 1000, FailureThreshold: 2, CooldownMs: 60000},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	env.fake.Fail("GetBlockByIndex", blockchain.ErrArchiveUnavailable)
+
+	for i := 0; i < 2; i++ {
+		if rec := env.do("GET", "/api/blocks/0", ""); rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("failure %d: status %d, want %d", i+1, rec.Code, http.StatusServiceUnavailable)
+		}
+	}
+
+	// The archive is back, but the open breaker keeps rejecting until its cooldown
+	env.fake.Fail("GetBlockByIndex", nil)
+	rec := env.do("GET", "/api/blocks/0", "")
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("open breaker: status %d, Retry-After %q; want 503 with Retry-After", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := env.do("GET", "/api/blocks", ""); rec.Code != http.StatusOK {
+		t.Fatalf("breaker of another route: status %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	// New policies start with closed breakers
+	if err := env.server.SetRoutePolicies(nil); err != nil {
+		t.Fatal(err)
+	}
+	if rec := env.do("GET", "/api/blocks/0", ""); rec.Code != http.StatusOK {
+		t.Fatalf("after resetting the policies: status %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+// walkRoutes calls fn for every method of every route the server registers,
+// with a path that fills each path variable with "0"
+func walkRoutes(t *testing.T, env *testEnv, fn func(method, template, path string)) {
+	t.Helper()
+	router, ok := env.server.Handler().(*mux.Router)
+	if !ok {
+		t.Fatalf("handler is a %T, not a router", env.server.Handler())
+	}
+	pathVar := regexp.MustCompile(`\{[^}]+\}`)
+
+	routes := 0
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			routes++
+			fn(method, template, pathVar.ReplaceAllString(template, "0"))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if routes == 0 {
+		t.Fatalf("no routes walked")
+	}
+}
+
+// TestRoutesWithoutOptionalServices sends every bounded route a request
+// without parameters or body to a node without a validator manager or
+// governance. Handlers must answer it without failing.
+func TestRoutesWithoutOptionalServices(t *testing.T) {
+	env := newTestEnv(t, true)
+	policies := api.DefaultRoutePolicies()
+
+	walkRoutes(t, env, func(method, template, path string) {
+		// Streams have no deadline and run until the client leaves
+		if policy, exists := policies[method+" "+template]; exists && policy.TimeoutMs == 0 && method == http.MethodGet {
+			return
+		}
+		rec := env.do(method, path, "")
+		if rec.Code >= http.StatusInternalServerError && rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s: status %d; body: %s", method, template, rec.Code, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+}
+
+// TestAdminRoutesAreGuarded checks that the admin access list guards every
+// route that acts on signed admin requests. Such a route left unregistered as
+// an admin route refuses the requests, so a new one fails here until it is.
+func TestAdminRoutesAreGuarded(t *testing.T) {
+	env := newTestEnv(t, true)
+	policies := api.DefaultRoutePolicies()
+	streams := func(method, template string) bool {
+		policy, exists := policies[method+" "+template]
+		return exists && policy.TimeoutMs == 0 && method == http.MethodGet
+	}
+
+	walkRoutes(t, env, func(method, template, path string) {
+		if streams(method, template) {
+			return
+		}
+		if rec := env.do(method, path, "{}"); rec.Code == http.StatusForbidden {
+			var resp api.ErrorResponse
+			decode(t, rec, &resp)
+			if resp.Code == api.CodeAccessDenied {
+				t.Errorf("%s %s verifies admin requests but is not registered as an admin route: %s", method, template, resp.Detail)
+			}
+		}
+	})
+
+	// Once every client is denied, the admin routes refuse them before their handlers run
+	if err := env.server.SetAdminAccess(&api.AdminAccess{Deny: []string{"0.0.0.0/0", "::/0"}}); err != nil {
+		t.Fatal(err)
+	}
+	privileged := map[string]bool{
+		"/api/validators/approve":                    true,
+		"/api/validators/reject":                     true,
+		"/api/validators/suspend":                    true,
+		"/api/validators/slash":                      true,
+		"/api/blockchain/transactions/{hash}/revert": true,
+		"/api/multisig/wallet/create":                true,
+		"/api/multisig/transaction/execute":          true,
+		"/api/debug/runtime":                         true,
+	}
+	walkRoutes(t, env, func(method, template, path string) {
+		if streams(method, template) || (!privileged[template] && !strings.HasPrefix(template, "/api/admin/")) {
+			return
+		}
+		if rec := env.do(method, path, "{}"); rec.Code != http.StatusForbidden {
+			t.Errorf("%s %s from a denied client: status %d, want %d", method, template, rec.Code, http.StatusForbidden)
+		}
+	})
+}
@@ -0,0 +1,309 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Route groups. Every route belongs to exactly one group, and middleware
+// added to a group with UseGroup runs for its routes only, after the
+// server-wide middleware.
+const (
+	GroupChain      = "chain"      // blocks, transactions, mining and chain metadata
+	GroupWallet     = "wallet"     // node-held wallets, balances and address labels
+	GroupValidator  = "validator"  // validators, proof of humanity, evidence and staking
+	GroupGovernance = "governance" // proposals and votes
+	GroupMultiSig   = "multisig"   // multi-signature wallets
+	GroupAdmin      = "admin"      // signed admin requests and API keys
+)
+
+// routeGroup registers the routes of one group on the server router and
+// wraps them with the group's middleware
+type routeGroup struct {
+	name       string
+	router     *mux.Router
+	admin      map[string]bool // privileged path templates, shared by the groups
+	mu         sync.RWMutex
+	middleware []mux.MiddlewareFunc
+}
+
+// handle registers a route of the group
+func (g *routeGroup) handle(path string, handler http.HandlerFunc) *mux.Route {
+	return g.router.Handle(path, g.wrap(handler))
+}
+
+// handleAdmin registers a privileged route of the group. The admin access list
+// guards it, and only such routes accept signed admin requests.
+func (g *routeGroup) handleAdmin(path string, handler http.HandlerFunc) *mux.Route {
+	g.admin[path] = true
+	return g.handle(path, handler)
+}
+
+// use appends middleware to the group
+func (g *routeGroup) use(middleware ...mux.MiddlewareFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.middleware = append(g.middleware, middleware...)
+}
+
+// wrap applies the group's middleware to handler at request time, so
+// middleware added after the routes were registered still applies
+func (g *routeGroup) wrap(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.mu.RLock()
+		middleware := g.middleware
+		g.mu.RUnlock()
+
+		h := handler
+		for i := len(middleware) - 1; i >= 0; i-- {
+			h = middleware[i](h)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Handler returns the handler serving every route with its middleware, for
+// serving the API from another server or exercising it without Start
+func (ws *WebServer) Handler() http.Handler {
+	return ws.router
+}
+
+// UseGroup adds middleware, such as authentication, caching or metrics, to
+// the routes of one group
+func (ws *WebServer) UseGroup(name string, middleware ...mux.MiddlewareFunc) error {
+	group, exists := ws.groups[name]
+	if !exists {
+		return fmt.Errorf("unknown route group: %s", name)
+	}
+	group.use(middleware...)
+	return nil
+}
+
+// RouteGroups returns the names of the route groups
+func (ws *WebServer) RouteGroups() []string {
+	names := make([]string, 0, len(ws.groups))
+	for name := range ws.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// registerRouteGroups creates the route groups and registers their routes
+func (ws *WebServer) registerRouteGroups() {
+	ws.groups = make(map[string]*routeGroup)
+	ws.adminRoutes = make(map[string]bool)
+	for _, group := range []struct {
+		name     string
+		register func(*routeGroup)
+	}{
+		{GroupChain, ws.registerChainRoutes},
+		{GroupWallet, ws.registerWalletRoutes},
+		{GroupValidator, ws.registerValidatorRoutes},
+		{GroupGovernance, ws.registerGovernanceRoutes},
+		{GroupMultiSig, ws.registerMultiSigRoutes},
+		{GroupAdmin, ws.registerAdminRoutes},
+	} {
+		g := &routeGroup{name: group.name, router: ws.router, admin: ws.adminRoutes}
+		ws.groups[group.name] = g
+		group.register(g)
+	}
+}
+
+// registerChainRoutes registers the blockchain, transaction and mining routes
+func (ws *WebServer) registerChainRoutes(g *routeGroup) {
+	g.handle("/api/status", ws.getStatus).Methods("GET")
+	g.handle("/api/version", ws.getVersion).Methods("GET")
+	g.handle("/metrics", ws.getMetrics).Methods("GET")
+	g.handle("/api/network/stats", ws.getNetworkStats).Methods("GET")
+	g.handle("/api/blocks", ws.getBlocks).Methods("GET")
+	g.handle("/api/blocks/utilization", ws.getBlockUtilization).Methods("GET")
+	g.handle("/api/blocks/{index}", ws.getBlockByIndex).Methods("GET")
+	g.handle("/api/blocks/{index}/receipt", ws.getBlockReceipt).Methods("GET")
+	g.handle("/api/verify/block", ws.verifyBlock).Methods("POST")
+	g.handle("/api/finality", ws.getFinality).Methods("GET")
+	g.handle("/api/headers", ws.getHeaders).Methods("GET")
+	g.handle("/api/headers/stream", ws.streamHeaders).Methods("GET")
+	g.handle("/api/replica", ws.getReplicaStatus).Methods("GET")
+	g.handle("/api/replica/blocks", ws.streamReplicaBlocks).Methods("GET")
+	g.handle("/api/transactions", ws.getAllTransactions).Methods("GET")
+	g.handle("/api/transactions/pending", ws.getPendingTransactions).Methods("GET")
+	g.handle("/api/transactions/confirmed", ws.getConfirmedTransactions).Methods("GET")
+	g.handle("/api/transactions", ws.createTransaction).Methods("POST")
+	g.handle("/api/transactions/status", ws.getTransactionStatuses).Methods("POST")
+	g.handle("/api/transactions/lanes", ws.getMempoolLanes).Methods("GET")
+	g.handle("/api/transactions/rejected", ws.getRejectedTransactions).Methods("GET")
+	g.handle("/api/transactions/{id}", ws.getTransaction).Methods("GET")
+	g.handleAdmin("/api/blockchain/transactions/{hash}/revert", ws.revertTransaction).Methods("POST")
+
+	// Bridge
+	g.handle("/api/bridge", ws.getBridgeStatus).Methods("GET")
+	g.handle("/api/bridge/lock", ws.bridgeLock).Methods("POST")
+	g.handle("/api/bridge/release/hash", ws.getBridgeReleaseHash).Methods("GET")
+	g.handle("/api/bridge/release/status", ws.getBridgeReleaseStatus).Methods("GET")
+	g.handle("/api/bridge/release", ws.bridgeRelease).Methods("POST")
+
+	// Oracle
+	g.handle("/api/circuit-breaker", ws.getCircuitBreaker).Methods("GET")
+	g.handle("/api/oracles", ws.getOracles).Methods("GET")
+	g.handle("/api/oracles/authorize", ws.authorizeOracle).Methods("POST")
+	g.handle("/api/oracle", ws.getOracleFeeds).Methods("GET")
+	g.handle("/api/oracle/{feed}", ws.getOracleFeed).Methods("GET")
+	g.handle("/api/oracle/{feed}", ws.postOracleData).Methods("POST")
+
+	// Mining
+	g.handle("/api/mine", ws.mineBlock).Methods("POST")
+
+	// Chain metadata
+	g.handle("/api/archive", ws.getArchiveStatus).Methods("GET")
+	g.handle("/api/genesis", ws.getGenesis).Methods("GET")
+	g.handle("/api/supply", ws.getSupply).Methods("GET")
+	g.handle("/api/rewards/epochs/{epoch}", ws.getEpochRewards).Methods("GET")
+	g.handle("/api/stats/richlist", ws.getRichList).Methods("GET")
+	g.handle("/api/state/digest", ws.getStateDigest).Methods("GET")
+	g.handle("/api/events", ws.getEvents).Methods("GET")
+	g.handle("/api/features", ws.getFeatures).Methods("GET")
+
+	// Amount conversion between base units and ConX
+	g.handle("/api/utils/format", ws.formatUnits).Methods("GET")
+	g.handle("/api/utils/parse", ws.parseUnits).Methods("GET")
+
+	// Health check
+	g.handle("/api/health", ws.getHealthCheck).Methods("GET")
+	g.handle("/api/watchdog", ws.getWatchdog).Methods("GET")
+}
+
+// registerWalletRoutes registers the wallet and address routes
+func (ws *WebServer) registerWalletRoutes(g *routeGroup) {
+	g.handle("/api/wallet/create", ws.createWallet).Methods("POST")
+	g.handle("/api/wallet/import", ws.importWallet).Methods("POST")
+	g.handle("/api/wallet/import/sweep", ws.sweepWallets).Methods("POST")
+	g.handle("/api/wallet/balance/{address}", ws.getWalletBalance).Methods("GET")
+	g.handle("/api/wallet/balance/{address}/simple", ws.getWalletBalanceSimple).Methods("GET")
+	g.handle("/api/wallet/balance/{address}/history", ws.getBalanceHistory).Methods("GET")
+	g.handle("/api/wallet/transfer", ws.transfer).Methods("POST")
+	g.handle("/api/wallet/passphrase", ws.setWalletPassphrase).Methods("POST")
+	g.handle("/api/wallet/unlock", ws.unlockWallet).Methods("POST")
+	g.handle("/api/wallet/lock", ws.lockWallet).Methods("POST")
+	g.handle("/api/wallet/sign", ws.signWalletTransaction).Methods("POST")
+	g.handle("/api/wallet/contacts", ws.getContacts).Methods("GET")
+	g.handle("/api/wallet/contacts", ws.saveContact).Methods("POST")
+	g.handle("/api/wallet/contacts/remove", ws.removeContact).Methods("POST")
+	g.handle("/api/payment-requests", ws.getPaymentRequests).Methods("GET")
+	g.handle("/api/payment-requests", ws.createPaymentRequest).Methods("POST")
+	g.handle("/api/payment-requests/{id}", ws.getPaymentRequest).Methods("GET")
+	g.handle("/api/payment-requests/{id}/cancel", ws.cancelPaymentRequest).Methods("POST")
+
+	// Address routes
+	g.handle("/api/address/{address}/statement", ws.getAccountStatement).Methods("GET")
+	g.handle("/api/address/{address}/spending", ws.getSpendByCategory).Methods("GET")
+	g.handle("/api/address/{address}/balance-proof", ws.getBalanceProof).Methods("GET")
+	g.handle("/api/labels", ws.getLabels).Methods("GET")
+	g.handle("/api/labels/{address}", ws.getLabel).Methods("GET")
+}
+
+// registerValidatorRoutes registers the validator, proof of humanity,
+// evidence and staking routes
+func (ws *WebServer) registerValidatorRoutes(g *routeGroup) {
+	g.handle("/api/validators", ws.getValidators).Methods("GET")
+	g.handle("/api/validators/register", ws.registerValidator).Methods("POST")
+	g.handle("/api/validators/proofs", ws.getHumanProofs).Methods("GET")
+	g.handle("/api/validators/proofs", ws.submitHumanProof).Methods("POST")
+	g.handle("/api/validators/proofs/{address}", ws.getHumanProof).Methods("GET")
+	g.handle("/api/validators/proofs/{address}/attestation", ws.getHumanProofAttestation).Methods("GET")
+	g.handle("/api/validators/status/{address}", ws.getValidatorStatus).Methods("GET")
+	g.handleAdmin("/api/validators/approve", ws.approveValidator).Methods("POST")
+	g.handleAdmin("/api/validators/reject", ws.rejectValidator).Methods("POST")
+	g.handleAdmin("/api/validators/suspend", ws.suspendValidator).Methods("POST")
+	g.handle("/api/validators/stakes", ws.getValidatorStakes).Methods("GET")
+	g.handleAdmin("/api/validators/slash", ws.slashValidator).Methods("POST")
+	g.handle("/api/validators/exit", ws.requestValidatorExit).Methods("POST")
+	g.handle("/api/validators/commission", ws.setValidatorCommission).Methods("POST")
+	g.handle("/api/validators/commission/{address}", ws.getValidatorCommission).Methods("GET")
+	g.handle("/api/validators/emergency", ws.getValidatorEmergency).Methods("GET")
+	g.handle("/api/validators/production/{epoch}", ws.getBlockProduction).Methods("GET")
+	g.handle("/api/evidence", ws.getEvidence).Methods("GET")
+	g.handle("/api/evidence", ws.submitEvidence).Methods("POST")
+
+	// Proof of Humanity challenge-response routes
+	g.handle("/api/poh/initiate", ws.initiatePoH).Methods("POST")
+	g.handle("/api/poh/submit", ws.submitPoH).Methods("POST")
+	g.handle("/api/poh/session/{id}", ws.getPoHSession).Methods("GET")
+
+	// Staking routes
+	g.handle("/api/staking/delegate", ws.delegate).Methods("POST")
+	g.handle("/api/staking/undelegate", ws.undelegate).Methods("POST")
+	g.handle("/api/staking/claim", ws.claimStakingRewards).Methods("POST")
+	g.handle("/api/staking/rewards/{address}", ws.getStakingRewards).Methods("GET")
+	g.handle("/api/staking/validators/{address}", ws.getValidatorDelegations).Methods("GET")
+}
+
+// registerGovernanceRoutes registers the proposal routes
+func (ws *WebServer) registerGovernanceRoutes(g *routeGroup) {
+	g.handle("/api/proposals", ws.listProposals).Methods("GET")
+	g.handle("/api/proposals/{id}", ws.getProposal).Methods("GET")
+	g.handle("/api/proposals/{id}/votes", ws.getProposalVotes).Methods("GET")
+	g.handle("/api/proposals/{id}/tally", ws.getProposalTally).Methods("GET")
+	g.handle("/api/proposals/create", ws.createProposal).Methods("POST")
+	g.handle("/api/proposals/vote", ws.castVote).Methods("POST")
+}
+
+// registerMultiSigRoutes registers the multi-signature wallet routes
+func (ws *WebServer) registerMultiSigRoutes(g *routeGroup) {
+	g.handleAdmin("/api/multisig/wallet/create", ws.createMultiSigWallet).Methods("POST")
+	g.handle("/api/multisig/wallet/{address}", ws.getMultiSigWallet).Methods("GET")
+	g.handle("/api/multisig/wallet/{address}/threshold-key/hash", ws.getThresholdKeyHash).Methods("GET")
+	g.handle("/api/multisig/wallet/{address}/threshold-key", ws.setThresholdKey).Methods("POST")
+	g.handle("/api/multisig/transaction/create", ws.createMultiSigTransaction).Methods("POST")
+	g.handle("/api/multisig/transaction/sign", ws.signMultiSigTransaction).Methods("POST")
+	g.handle("/api/multisig/transaction/threshold-sign", ws.submitThresholdSignature).Methods("POST")
+	g.handleAdmin("/api/multisig/transaction/execute", ws.executeMultiSigTransaction).Methods("POST")
+	g.handle("/api/multisig/transaction/{walletAddress}/{txID}/status", ws.getMultiSigTransactionStatus).Methods("GET")
+	g.handle("/api/multisig/transaction/{walletAddress}/{txID}/hash", ws.getMultiSigSigningHash).Methods("GET")
+	g.handle("/api/multisig/transaction/{walletAddress}/pending", ws.getMultiSigPendingTransactions).Methods("GET")
+}
+
+// registerAdminRoutes registers the signed admin routes and the API key routes
+func (ws *WebServer) registerAdminRoutes(g *routeGroup) {
+	g.handleAdmin("/api/admin/add", ws.addAdmin).Methods("POST")
+	g.handleAdmin("/api/admin/remove", ws.removeAdmin).Methods("POST")
+	g.handleAdmin("/api/admin/list", ws.listAdmins).Methods("GET")
+	g.handleAdmin("/api/admin/wallets/controls", ws.viewWalletControls).Methods("POST")
+	g.handleAdmin("/api/admin/wallets/controls/set", ws.setWalletControls).Methods("POST")
+	g.handleAdmin("/api/admin/labels", ws.setLabel).Methods("POST")
+	g.handleAdmin("/api/admin/labels/remove", ws.removeLabel).Methods("POST")
+
+	// Node management
+	g.handleAdmin("/api/admin/node/snapshot", ws.nodeSnapshot).Methods("POST")
+	g.handleAdmin("/api/admin/node/accounts/compact", ws.nodeCompactAccounts).Methods("POST")
+	g.handleAdmin("/api/admin/keys/audit", ws.auditKeys).Methods("POST")
+	g.handleAdmin("/api/admin/keys/prune", ws.pruneKeys).Methods("POST")
+	g.handleAdmin("/api/admin/node/mining", ws.nodeMining).Methods("POST")
+	g.handleAdmin("/api/admin/circuit-breaker/pause", ws.pauseChain).Methods("POST")
+	g.handleAdmin("/api/admin/circuit-breaker/resume", ws.resumeChain).Methods("POST")
+	g.handleAdmin("/api/admin/node/logs/rotate", ws.nodeRotateLogs).Methods("POST")
+	g.handleAdmin("/api/admin/node/logs/level", ws.nodeLogLevel).Methods("POST")
+	g.handleAdmin("/api/admin/node/peers/ban", ws.nodeBanPeer).Methods("POST")
+	g.handleAdmin("/api/admin/node/peers/unban", ws.nodeUnbanPeer).Methods("POST")
+	g.handleAdmin("/api/admin/node/config", ws.nodeViewConfig).Methods("POST")
+	g.handleAdmin("/api/admin/node/config/reload", ws.nodeReloadConfig).Methods("POST")
+	g.handleAdmin(maintenanceRoute, ws.nodeMaintenance).Methods("POST")
+
+	// Backups and API keys
+	g.handleAdmin("/api/admin/backups", ws.listBackups).Methods("POST")
+	g.handleAdmin("/api/admin/backups/create", ws.createBackup).Methods("POST")
+	g.handleAdmin("/api/admin/backups/verify", ws.verifyBackup).Methods("POST")
+	g.handle("/api/apikeys/usage", ws.getAPIKeyUsage).Methods("GET")
+	g.handleAdmin("/api/admin/apikeys", ws.listAPIKeys).Methods("POST")
+	g.handleAdmin("/api/admin/apikeys/create", ws.createAPIKey).Methods("POST")
+	g.handleAdmin("/api/admin/apikeys/revoke", ws.revokeAPIKey).Methods("POST")
+
+	// Diagnostics, off unless enabled with SetDebugEndpoints
+	g.handleAdmin("/api/debug/runtime", ws.getRuntimeStats).Methods("GET")
+	g.handleAdmin(pprofRoute, ws.servePprof).Methods("GET")
+	g.handleAdmin(pprofRoute+"{profile}", ws.servePprof).Methods("GET", "POST")
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"math/big"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,56 +21,83 @@ import (
 	"confirmix/pkg/consensus"
 	"github.com/google/uuid"
 	"confirmix/pkg/types"
+	"confirmix/pkg/units"
 )
 
 // WebServer represents the web server instance
 type WebServer struct {
-	blockchain      *blockchain.Blockchain
+	blockchain      ChainService
+	wallets         WalletService
+	multisig        MultiSigService
+	keys            APIKeyService
 	consensusEngine *consensus.HybridConsensus
-	validatorManager *consensus.ValidatorManager
-	governance      *consensus.Governance
+	validatorManager ValidatorService
+	governance      GovernanceService
+	staking         StakingService     // Delegation module, see staking.go
+	bft             *consensus.BFT     // Validator voting layer, nil when disabled, see finality.go
 	port           int
 	router         *mux.Router
+	groups         map[string]*routeGroup // Route groups by name, see routes.go
+	adminRoutes    map[string]bool        // Path templates of the privileged routes, see routeGroup.handleAdmin
 	server         *http.Server  // Add server field
-	
-	// Önbellek verileri
+	node           nodeControl   // Node management state (admin API)
+	cors           corsState     // Allowed CORS origins, reloadable at runtime
+	guards         routeGuards   // Per-route timeouts and circuit breakers
+	observer       bool          // Read-only node: write routes are refused
+	apiKeys        apiKeyState   // API key policy and per-key rate limits
+	adminAccess    adminAccessState // Networks allowed to reach admin routes, reloadable at runtime
+	adminReplay    adminReplayState // Signed admin requests already accepted, see admin_replay.go
+	walletSessions walletSessionState // Bearer sessions issued by wallet unlocks, see wallet_sessions.go
+	maintenance    maintenanceState // Maintenance switch and in-flight writes, see maintenance.go
+	debug          debugState       // Profiling and runtime endpoints, see debug.go
+	compression    compressionState // Response compression settings, see compression.go
+	
+	// Cached data
 	validatorsCache      []blockchain.ValidatorInfo
 	validatorsCacheTime  time.Time
 	validatorsCacheMutex sync.RWMutex
 	
-	// İşlemler için önbellek
+	// Cache for transactions
 	transactionsCache      []*blockchain.Transaction
 	transactionsCacheTime  time.Time
 	transactionsCacheMutex sync.RWMutex
 	
-	// Bekleyen işlemler için ayrı önbellek
+	// Separate cache for pending transactions
 	pendingTxCache      []*blockchain.Transaction
 	pendingTxCacheTime  time.Time
 	pendingTxCacheMutex sync.RWMutex
 	
-	// Onaylanmış işlemler için ayrı önbellek
+	// Separate cache for confirmed transactions
 	confirmedTxCache      []*blockchain.Transaction
 	confirmedTxCacheTime  time.Time
 	confirmedTxCacheMutex sync.RWMutex
 	
-	// Genel blockchain önbellekleri
-	
-	// Blok önbelleği - key: block index, value: *blockchain.Block
-	blockCache       sync.Map // thread-safe map
-	blockCacheExpiry sync.Map // ne zaman süresi dolacak
+	// General blockchain caches
+	// Blocks are not cached here: they never change, so GetBlockByIndex is a
+	// slice lookup and repeat reads are served by HTTP caches via their ETags
 	
-	// Bakiye önbelleği - key: address, value: *big.Int
+	// Balance cache - key: address, value: *big.Int
 	balanceCache       sync.Map
 	balanceCacheExpiry sync.Map
 }
 
 // NewWebServer creates a new web server instance
 func NewWebServer(bc *blockchain.Blockchain, ce *consensus.HybridConsensus, vm *consensus.ValidatorManager, gov *consensus.Governance, port int) *WebServer {
+	return NewWebServerWithServices(BlockchainServices(bc, vm, gov), ce, port)
+}
+
+// NewWebServerWithServices creates a web server whose handlers use the given
+// services. ce may be nil when the consensus engine is not running.
+func NewWebServerWithServices(services Services, ce *consensus.HybridConsensus, port int) *WebServer {
 	ws := &WebServer{
-		blockchain:      bc,
+		blockchain:      services.Chain,
+		wallets:         services.Wallets,
+		multisig:        services.MultiSig,
+		keys:            services.APIKeys,
 		consensusEngine: ce,
-		validatorManager: vm,
-		governance:      gov,
+		validatorManager: services.Validators,
+		governance:      services.Governance,
+		staking:         services.Staking,
 		port:           port,
 		router:         mux.NewRouter(),
 	}
@@ -76,79 +105,29 @@ func NewWebServer(bc *blockchain.Blockchain, ce *consensus.HybridConsensus, vm *
 	return ws
 }
 
-// enableCORS enables CORS for all routes
-func enableCORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
-
 // setupRoutes configures the HTTP routes
 func (ws *WebServer) setupRoutes() {
 	ws.router = mux.NewRouter()
 	
 	// Enable CORS for all routes
-	ws.router.Use(enableCORS)
-
-	// Blockchain routes
-	ws.router.HandleFunc("/api/status", ws.getStatus).Methods("GET")
-	ws.router.HandleFunc("/api/blocks", ws.getBlocks).Methods("GET")
-	ws.router.HandleFunc("/api/blocks/{index}", ws.getBlockByIndex).Methods("GET")
-	ws.router.HandleFunc("/api/transactions", ws.getAllTransactions).Methods("GET")
-	ws.router.HandleFunc("/api/transactions/pending", ws.getPendingTransactions).Methods("GET")
-	ws.router.HandleFunc("/api/transactions/confirmed", ws.getConfirmedTransactions).Methods("GET")
-	ws.router.HandleFunc("/api/transactions", ws.createTransaction).Methods("POST")
-	ws.router.HandleFunc("/api/blockchain/transactions/{hash}/revert", ws.revertTransaction).Methods("POST")
-	
-	// Wallet routes
-	ws.router.HandleFunc("/api/wallet/create", ws.createWallet).Methods("POST")
-	ws.router.HandleFunc("/api/wallet/import", ws.importWallet).Methods("POST")
-	ws.router.HandleFunc("/api/wallet/balance/{address}", ws.getWalletBalance).Methods("GET")
-	ws.router.HandleFunc("/api/wallet/balance/{address}/simple", ws.getWalletBalanceSimple).Methods("GET")
-	ws.router.HandleFunc("/api/wallet/transfer", ws.transfer).Methods("POST")
-	
-	// Mining routes
-	ws.router.HandleFunc("/api/mine", ws.mineBlock).Methods("POST")
-	
-	// Validator routes
-	ws.router.HandleFunc("/api/validators", ws.getValidators).Methods("GET")
-	ws.router.HandleFunc("/api/validators/register", ws.registerValidator).Methods("POST")
-	ws.router.HandleFunc("/api/validators/approve", ws.approveValidator).Methods("POST")
-	ws.router.HandleFunc("/api/validators/reject", ws.rejectValidator).Methods("POST")
-	ws.router.HandleFunc("/api/validators/suspend", ws.suspendValidator).Methods("POST")
-	
-	// Admin routes
-	ws.router.HandleFunc("/api/admin/add", ws.addAdmin).Methods("POST")
-	ws.router.HandleFunc("/api/admin/remove", ws.removeAdmin).Methods("POST")
-	ws.router.HandleFunc("/api/admin/list", ws.listAdmins).Methods("GET")
-	
-	// Governance routes
-	ws.router.HandleFunc("/api/proposals", ws.listProposals).Methods("GET")
-	ws.router.HandleFunc("/api/proposals/{id}", ws.getProposal).Methods("GET")
-	ws.router.HandleFunc("/api/proposals/create", ws.createProposal).Methods("POST")
-	ws.router.HandleFunc("/api/proposals/vote", ws.castVote).Methods("POST")
-	
-	// Health check
-	ws.router.HandleFunc("/api/health", ws.getHealthCheck).Methods("GET")
-
-	// Multi-signature routes
-	ws.router.HandleFunc("/api/multisig/wallet/create", ws.createMultiSigWallet).Methods("POST")
-	ws.router.HandleFunc("/api/multisig/wallet/{address}", ws.getMultiSigWallet).Methods("GET")
-	ws.router.HandleFunc("/api/multisig/transaction/create", ws.createMultiSigTransaction).Methods("POST")
-	ws.router.HandleFunc("/api/multisig/transaction/sign", ws.signMultiSigTransaction).Methods("POST")
-	ws.router.HandleFunc("/api/multisig/transaction/execute", ws.executeMultiSigTransaction).Methods("POST")
-	ws.router.HandleFunc("/api/multisig/transaction/{walletAddress}/{txID}/status", ws.getMultiSigTransactionStatus).Methods("GET")
-	ws.router.HandleFunc("/api/multisig/transaction/{walletAddress}/pending", ws.getMultiSigPendingTransactions).Methods("GET")
+	ws.router.Use(ws.enableCORS)
+	// Compress large responses for clients that accept it
+	ws.router.Use(ws.compressResponses)
+	// Select the response locale from Accept-Language
+	ws.router.Use(localeMiddleware)
+	// Restrict admin routes to the configured networks
+	ws.router.Use(ws.restrictAdminAccess)
+	// Authenticate, rate limit and meter requests made with an API key
+	ws.router.Use(ws.checkAPIKey)
+	// Bound every route by its timeout and circuit breaker policy
+	ws.router.Use(ws.guardRoutes)
+	// Refuse writes when the node runs as a read-only observer
+	ws.router.Use(ws.rejectObserverWrites)
+	// Refuse requests in maintenance mode and track the writes in flight
+	ws.router.Use(ws.maintenanceGate)
+
+	// Register the routes by group, see routes.go
+	ws.registerRouteGroups()
 }
 
 // Start starts the web server
@@ -175,7 +154,7 @@ func (ws *WebServer) PreloadCache() {
 	log.Printf("Starting cache preloading (simplified)...")
 	startTime := time.Now()
 	
-	// Önce blokzincirdeki önemli adresleri alarak bakiye önbelleğini dolduralım
+	// Fill the balance cache with the important addresses on the chain first
 	addresses := ws.blockchain.GetAllAddresses()
 	if len(addresses) > 0 {
 		log.Printf("Found %d addresses in blockchain (including genesis and node address)", len(addresses))
@@ -215,64 +194,39 @@ func (ws *WebServer) PreloadCache() {
 		validatorStart := time.Now()
 		log.Printf("Background fetching registered validators...")
 		
-		// Try with a short timeout - but in background to not delay page load
-		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-		defer cancel()
-		
-		// Use a different channel for communication
-		done := make(chan bool, 1)
-		var validators []blockchain.ValidatorInfo
+		// Get validators from blockchain
+		validators := ws.blockchain.GetValidators()
 		
-		go func() {
-			defer func() {
-				if r := recover(); r != nil {
-					log.Printf("PANIC in validator preloading: %v", r)
-				}
-				done <- true
-			}()
-			
-			// Get validators from blockchain
-			validators = ws.blockchain.GetValidators()
+		if len(validators) > 0 {
+			// Update cache
+			ws.validatorsCacheMutex.Lock()
+			ws.validatorsCache = validators
+			ws.validatorsCacheTime = time.Now()
+			ws.validatorsCacheMutex.Unlock()
 			
-			if len(validators) > 0 {
-				// Update cache
-				ws.validatorsCacheMutex.Lock()
-				ws.validatorsCache = validators
-				ws.validatorsCacheTime = time.Now()
-				ws.validatorsCacheMutex.Unlock()
-				
-				log.Printf("Background loaded %d registered validators in %v", 
-					len(validators), time.Since(validatorStart))
-			} else {
-				log.Printf("No registered validators found in blockchain")
-			}
-		}()
-		
-		// Wait for either completion or timeout
-		select {
-		case <-done:
-			// İşlem tamamlandı
-		case <-ctx.Done():
-			log.Printf("Timeout preloading validators: %v", ctx.Err())
+			log.Printf("Background loaded %d registered validators in %v", 
+				len(validators), time.Since(validatorStart))
+		} else {
+			log.Printf("No registered validators found in blockchain")
 		}
 	}()
 	
-	// Boş transaction listeleri oluşturalım - sonra arkaplanda gerçekleri almayı deneriz
+	// Start with empty transaction lists - the real ones are fetched in the background
 	emptyTxs := make([]*blockchain.Transaction, 0)
 	
-	// Boş bekleyen işlem listesi
+	// Empty pending transaction list
 	ws.pendingTxCacheMutex.Lock()
 	ws.pendingTxCache = emptyTxs
 	ws.pendingTxCacheTime = time.Now()
 	ws.pendingTxCacheMutex.Unlock()
 	
-	// Boş onaylanmış işlem listesi
+	// Empty confirmed transaction list
 	ws.confirmedTxCacheMutex.Lock()
 	ws.confirmedTxCache = emptyTxs  
 	ws.confirmedTxCacheTime = time.Now()
 	ws.confirmedTxCacheMutex.Unlock()
 	
-	// Boş tüm işlemler listesi
+	// Empty list of all transactions
 	ws.transactionsCacheMutex.Lock()
 	ws.transactionsCache = emptyTxs
 	ws.transactionsCacheTime = time.Now()
@@ -280,11 +234,11 @@ func (ws *WebServer) PreloadCache() {
 	
 	log.Printf("Initialized empty transaction lists")
 	
-	// Arkaplanda işlemleri getirmeye çalışalım
+	// Try to fetch transactions in the background
 	go func() {
 		txStart := time.Now()
 		
-		// Bekleyen işlemleri al
+		// Get pending transactions
 		pending := ws.blockchain.GetPendingTransactions()
 		pendingWithStatus := make([]*blockchain.Transaction, 0, len(pending))
 		
@@ -294,7 +248,7 @@ func (ws *WebServer) PreloadCache() {
 			pendingWithStatus = append(pendingWithStatus, &txCopy)
 		}
 		
-		// Sadece boş değilse güncelle
+		// Only update if not empty
 		if len(pendingWithStatus) > 0 {
 			ws.pendingTxCacheMutex.Lock()
 			ws.pendingTxCache = pendingWithStatus
@@ -304,14 +258,14 @@ func (ws *WebServer) PreloadCache() {
 			log.Printf("Background loaded %d pending transactions in %v", 
 				len(pendingWithStatus), time.Since(txStart))
 				
-			// Combined transactions listesini de güncelle
+			// Update the combined transactions list as well
 			ws.transactionsCacheMutex.Lock()
-			ws.transactionsCache = pendingWithStatus // Başlangıç için sadece bekleyenler
+			ws.transactionsCache = pendingWithStatus // Only pending ones to start with
 			ws.transactionsCacheTime = time.Now()
 			ws.transactionsCacheMutex.Unlock()
 		}
 		
-		// İşimiz bitti, onaylanmış işlemleri daha sonra lazım olursa getireceğiz
+		// Done; confirmed transactions are fetched later on demand
 		log.Printf("Transaction preloading completed in %v", time.Since(txStart))
 	}()
 	
@@ -334,17 +288,36 @@ func (ws *WebServer) getStatus(w http.ResponseWriter, r *http.Request) {
 	// Create a static status response
 	// Using cached or default values to avoid blockchain calls
 	status := struct {
-		Status   string `json:"status"`
-		Height   uint64 `json:"height"`
-		Uptime   string `json:"uptime"`
-		Version  string `json:"version"`
-		NodeType string `json:"nodeType"`
+		Status          string                  `json:"status"`
+		Height          uint64                  `json:"height"`
+		Uptime          string                  `json:"uptime"`
+		Version         string                  `json:"version"`
+		NodeType        string                  `json:"nodeType"`
+		ChainID         string                  `json:"chainId"`
+		NextUpgrade     *blockchain.UpgradePlan `json:"nextUpgrade,omitempty"`
+		UpgradeRequired bool                    `json:"upgradeRequired"` // an activated upgrade needs a newer version
+		Halted          bool                    `json:"halted"`          // the circuit breaker pauses the chain
 	}{
 		Status:   "online",
 		Height:   ws.blockchain.GetChainHeight(),
 		Uptime:   "active",
-		Version:  "1.0.0",
+		Version:  blockchain.NodeVersion,
 		NodeType: "validator",
+		ChainID:  blockchain.ChainID(),
+	}
+	if plan, ok := ws.blockchain.NextUpgrade(); ok {
+		status.NextUpgrade = &plan
+	}
+	if ws.observer {
+		status.NodeType = "observer"
+	}
+	if _, required := ws.blockchain.RequiredUpgrade(); required {
+		status.Status = "upgrade_required"
+		status.UpgradeRequired = true
+	}
+	if ws.blockchain.Halted() {
+		status.Status = "halted"
+		status.Halted = true
 	}
 	
 	// Always return OK
@@ -352,7 +325,18 @@ func (ws *WebServer) getStatus(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(status)
 }
 
-// getBlocks handles the blocks endpoint
+// blockSummary is a block as listed by the blocks endpoint
+type blockSummary struct {
+	Index        uint64 `json:"Index"`
+	Timestamp    int64  `json:"Timestamp"`
+	Hash         string `json:"Hash"`
+	PrevHash     string `json:"PrevHash"`
+	Validator    string `json:"Validator"`
+	Transactions int    `json:"Transactions"`
+}
+
+// getBlocks handles the blocks endpoint, newest first unless order=asc.
+// Full pages carry a continuation token, see pagination.go.
 func (ws *WebServer) getBlocks(w http.ResponseWriter, r *http.Request) {
 	// Set CORS headers
 	w.Header().Set("Content-Type", "application/json")
@@ -380,127 +364,55 @@ func (ws *WebServer) getBlocks(w http.ResponseWriter, r *http.Request) {
 		limit = 50
 	}
 	
-	// Set a timeout for the handler
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	order, cursor, err := parsePage(r, cursorBlocks, orderDesc)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	
+	log.Printf("Getting blocks from blockchain, limit=%d", limit)
 	
-	// Use a done channel to signal when we're finished
-	done := make(chan bool, 1)
-	blocksChan := make(chan []struct {
-		Index        uint64 `json:"Index"`
-		Timestamp    int64  `json:"Timestamp"`
-		Hash         string `json:"Hash"`
-		PrevHash     string `json:"PrevHash"`
-		Validator    string `json:"Validator"`
-		Transactions int    `json:"Transactions"`
-	}, 1)
+	// Get chain height safely as int (not uint64)
+	chainHeight := int64(ws.blockchain.GetChainHeight())
 	
-	// Do the work in a goroutine
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("PANIC in getBlocks: %v", r)
-			}
-			done <- true
-		}()
-		
-		log.Printf("Getting blocks from blockchain, limit=%d", limit)
-		
-		// Get chain height safely as int (not uint64)
-		chainHeight := int(ws.blockchain.GetChainHeight())
+	// Create result array
+	blocksResponse := make([]blockSummary, 0, limit)
+	
+	// Walk from the first block of the page in the requested order,
+	// never going negative or past the chain height
+	start, step, ok := blockRange(uint64(chainHeight), order, cursor)
+	for i := start; ok && i >= 0 && i <= chainHeight && len(blocksResponse) < limit; i += step {
+		// Convert index to uint64 only when passing to blockchain API
+		blockIndex := uint64(i)
 		
-		// Create result array
-		blocksResponse := make([]struct {
-			Index        uint64 `json:"Index"`
-			Timestamp    int64  `json:"Timestamp"`
-			Hash         string `json:"Hash"`
-			PrevHash     string `json:"PrevHash"`
-			Validator    string `json:"Validator"`
-			Transactions int    `json:"Transactions"`
-		}, 0, limit)
+		block, err := ws.blockchain.GetBlockByIndex(blockIndex)
+		if err != nil {
+			log.Printf("Error getting block at index %d: %v", i, err)
+			continue
+		}
 		
-		// Start from the most recent block and go backwards
-		// Ensure we don't go negative or exceed the chain height
-		for i := chainHeight; i >= 0 && len(blocksResponse) < limit; i-- {
-			// Convert index to uint64 only when passing to blockchain API
-			blockIndex := uint64(i)
-			blockIndexKey := fmt.Sprintf("block_%d", blockIndex)
-			
-			var block *blockchain.Block
-			var err error
-			
-			// First check cache
-			if cachedValue, ok := ws.blockCache.Load(blockIndexKey); ok {
-				if expiryTime, ok := ws.blockCacheExpiry.Load(blockIndexKey); ok {
-					if time.Now().Before(expiryTime.(time.Time)) {
-						// Cached value is still valid
-						block = cachedValue.(*blockchain.Block)
-					}
-				}
-			}
-			
-			// If not in cache, get from blockchain
-			if block == nil {
-				block, err = ws.blockchain.GetBlockByIndex(blockIndex)
-				if err != nil {
-					log.Printf("Error getting block at index %d: %v", i, err)
-					continue
-				}
-				
-				// Cache block for future use (blocks don't change)
-				ws.blockCache.Store(blockIndexKey, block)
-				ws.blockCacheExpiry.Store(blockIndexKey, time.Now().Add(60*time.Second))
-			}
-			
-			// Make sure block has valid Hash field
-			if block != nil {
-				blockHash := block.Hash
-				if blockHash == "" {
-					// Generate a hash if missing
-					blockHash = fmt.Sprintf("block_%d_%d", block.Index, block.Timestamp)
-				}
-				
-				blockResp := struct {
-					Index        uint64 `json:"Index"`
-					Timestamp    int64  `json:"Timestamp"`
-					Hash         string `json:"Hash"`
-					PrevHash     string `json:"PrevHash"`
-					Validator    string `json:"Validator"`
-					Transactions int    `json:"Transactions"`
-				}{
-					Index:        block.Index,
-					Timestamp:    block.Timestamp,
-					Hash:         blockHash,
-					PrevHash:     block.PrevHash,
-					Validator:    block.Validator,
-					Transactions: len(block.Transactions),
-				}
-				
-				blocksResponse = append(blocksResponse, blockResp)
-			}
+		// Make sure block has valid Hash field
+		blockHash := block.Hash
+		if blockHash == "" {
+			// Generate a hash if missing
+			blockHash = fmt.Sprintf("block_%d_%d", block.Index, block.Timestamp)
 		}
 		
-		log.Printf("Retrieved %d blocks", len(blocksResponse))
-		blocksChan <- blocksResponse
-	}()
+		blocksResponse = append(blocksResponse, blockSummary{
+			Index:        block.Index,
+			Timestamp:    block.Timestamp,
+			Hash:         blockHash,
+			PrevHash:     block.PrevHash,
+			Validator:    block.Validator,
+			Transactions: len(block.Transactions),
+		})
+	}
 	
-	// Wait for completion or timeout
-	select {
-	case blocks := <-blocksChan:
-		w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(blocks)
-		
-	case <-done:
-		// No blocks sent, return empty array
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode([]interface{}{})
-		
-	case <-ctx.Done():
-		// Timeout - return what we have
-		log.Printf("Timeout getting blocks: %v", ctx.Err())
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode([]interface{}{})
+	log.Printf("Retrieved %d blocks", len(blocksResponse))
+	if n := len(blocksResponse); n > 0 {
+		setNextCursor(w, &pageCursor{Kind: cursorBlocks, Order: order, Block: blocksResponse[n-1].Index}, n, limit)
 	}
+	writeNegotiated(w, r, http.StatusOK, blocksResponse, blockSummaryList(blocksResponse))
 }
 
 // getPendingTransactions handles the pending transactions endpoint with caching
@@ -528,14 +440,14 @@ func (ws *WebServer) getPendingTransactions(w http.ResponseWriter, r *http.Reque
 		}
 	}
 	
-	// Önbellekteki verileri kontrol edelim (5 saniyeden daha yeni ise)
+	// Check the cached data (if newer than 5 seconds)
 	ws.pendingTxCacheMutex.RLock()
 	cacheAge := time.Since(ws.pendingTxCacheTime)
 	hasCache := len(ws.pendingTxCache) > 0 && cacheAge < 5*time.Second
 	
-	// Eğer önbellekte güncel veri varsa ve istenen limit önbellek boyutundan az veya eşitse, hemen döndürelim
+	// If the cache is fresh and the requested limit fits in it, return immediately
 	if hasCache && limit <= len(ws.pendingTxCache) {
-		// Önbellekten limiti kadar veri alalım
+		// Take up to limit entries from the cache
 		txs := ws.pendingTxCache
 		if limit < len(txs) {
 			txs = txs[:limit]
@@ -543,110 +455,43 @@ func (ws *WebServer) getPendingTransactions(w http.ResponseWriter, r *http.Reque
 		ws.pendingTxCacheMutex.RUnlock()
 		
 		log.Printf("Returning %d pending transactions from cache (age: %v)", len(txs), cacheAge)
-		w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(txs)
+		writeNegotiated(w, r, http.StatusOK, txs, transactionList(txs))
 		return
 	}
 	ws.pendingTxCacheMutex.RUnlock()
 	
-	// Önbellekte veri yoksa veya eski ise veya istenen limit önbellek boyutundan büyükse, yeni veri alalım
-	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second) // Timeout süresini 3 saniyeden 10 saniyeye çıkardık
-	defer cancel()
+	// Cache is empty, stale or smaller than the requested limit, so fetch fresh data
+	startTime := time.Now()
+	log.Printf("Getting pending transactions from blockchain")
 	
-	// Use a done channel to signal when we're finished
-	done := make(chan bool, 1)
-	var pendingTxs []*blockchain.Transaction
-	var err error
+	// Get all pending transactions
+	pendingTxsRaw := ws.blockchain.GetPendingTransactions()
 	
-	// Do the work in a goroutine
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("PANIC in getPendingTransactions: %v", r)
-				err = fmt.Errorf("internal error: %v", r)
-			}
-			done <- true
-		}()
-		
-		startTime := time.Now()
-		log.Printf("Getting pending transactions from blockchain")
-		
-		// Get all pending transactions
-		pendingTxsRaw := ws.blockchain.GetPendingTransactions()
-		
-		// Create a new array to hold our response and make a deep copy to avoid race conditions
-		pendingTxs = make([]*blockchain.Transaction, 0, len(pendingTxsRaw))
-		
-		for _, tx := range pendingTxsRaw {
-			// Create a copy of each transaction
-			txCopy := *tx
-			// Add a status field for pending transactions
-			txCopy.Status = "pending"
-			pendingTxs = append(pendingTxs, &txCopy)
-		}
-		
-		// Önbelleği güncelle
-		ws.pendingTxCacheMutex.Lock()
-		ws.pendingTxCache = pendingTxs
-		ws.pendingTxCacheTime = time.Now()
-		ws.pendingTxCacheMutex.Unlock()
-		
-		log.Printf("Retrieved %d pending transactions in %v", len(pendingTxs), time.Since(startTime))
-	}()
+	// Make a deep copy to avoid race conditions
+	pendingTxs := make([]*blockchain.Transaction, 0, len(pendingTxsRaw))
 	
-	// Wait for either completion or timeout
-	select {
-	case <-done:
-		if err != nil {
-			log.Printf("Error getting pending transactions: %v", err)
-			// Return an empty array instead of an error
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(make([]*blockchain.Transaction, 0))
-			return
-		}
-		
-		// Create empty array if nil
-		if pendingTxs == nil {
-			pendingTxs = make([]*blockchain.Transaction, 0)
-		}
-		
-		// Limit the response if needed
-		if len(pendingTxs) > limit {
-			pendingTxs = pendingTxs[:limit]
-		}
-		
-		// Return the transactions as JSON
-		w.WriteHeader(http.StatusOK)
-		err = json.NewEncoder(w).Encode(pendingTxs)
-		if err != nil {
-			log.Printf("Error encoding pending transactions: %v", err)
-			http.Error(w, "Error encoding response", http.StatusInternalServerError)
-		}
-		return
-		
-	case <-ctx.Done():
-		log.Printf("Timeout getting pending transactions: %v", ctx.Err())
-		
-		// Önbellekte herhangi bir veri varsa, eski de olsa döndürelim
-		ws.pendingTxCacheMutex.RLock()
-		hasCacheData := len(ws.pendingTxCache) > 0
-		cachedTxs := ws.pendingTxCache // Kopyasını alalım
-		if limit < len(cachedTxs) {
-			cachedTxs = cachedTxs[:limit]
-		}
-		ws.pendingTxCacheMutex.RUnlock()
-		
-		if hasCacheData {
-			log.Printf("Returning %d pending transactions from stale cache due to timeout", len(cachedTxs))
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(cachedTxs)
-			return
-		}
-		
-		// Hiç önbellek verisi yoksa boş dizi döndür
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(make([]*blockchain.Transaction, 0))
+	for _, tx := range pendingTxsRaw {
+		// Create a copy of each transaction
+		txCopy := *tx
+		// Add a status field for pending transactions
+		txCopy.Status = "pending"
+		pendingTxs = append(pendingTxs, &txCopy)
+	}
+	
+	// Update the cache
+	ws.pendingTxCacheMutex.Lock()
+	ws.pendingTxCache = pendingTxs
+	ws.pendingTxCacheTime = time.Now()
+	ws.pendingTxCacheMutex.Unlock()
+	
+	log.Printf("Retrieved %d pending transactions in %v", len(pendingTxs), time.Since(startTime))
+	
+	// Limit the response if needed
+	if len(pendingTxs) > limit {
+		pendingTxs = pendingTxs[:limit]
 	}
+	
+	writeNegotiated(w, r, http.StatusOK, pendingTxs, transactionList(pendingTxs))
 }
 
 // createTransaction handles the transaction creation endpoint
@@ -663,147 +508,168 @@ func (ws *WebServer) createTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	// Set a timeout for the handler
-	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
-	defer cancel()
-	
-	// Use a done channel to signal when we're finished
-	done := make(chan bool, 1)
-	var simpleTransaction *blockchain.Transaction
-	var err error
+	// Log the request body for debugging
+	bodyBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading request body: %v", err)
+		writeError(w, fmt.Errorf("error reading request: %v", err), http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+	log.Printf("Received transaction request: %s", string(bodyBytes))
 	
-	// Do the work in a goroutine
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("PANIC in createTransaction: %v", r)
-				err = fmt.Errorf("internal error: %v", r)
-			}
-			done <- true
-		}()
-		
-		// Log the request body for debugging
-		bodyBytes, err := ioutil.ReadAll(r.Body)
-		if err != nil {
-			log.Printf("Error reading request body: %v", err)
-			err = fmt.Errorf("error reading request: %v", err)
-			return
-		}
-		r.Body.Close()
-		
-		// Create a new reader with the same bytes for json.Decode
-		r.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
-		log.Printf("Received transaction request: %s", string(bodyBytes))
-		
 	var tx struct {
-			From  string `json:"from"`
-			To    string `json:"to"`
-			Value uint64 `json:"value"`
-			Data  string `json:"data,omitempty"`
-		}
-		
-		if err = json.NewDecoder(r.Body).Decode(&tx); err != nil {
+		From  string `json:"from"`
+		To    string `json:"to"`
+		Value units.Uint64 `json:"value"` // base units, as a string or a number
+		Data  string `json:"data,omitempty"`
+		Category string `json:"category,omitempty"` // bookkeeping tag stored in the transaction memo
+		Memo     string `json:"memo,omitempty"`
+		OTP      string `json:"otp,omitempty"` // one-time code for wallets with TOTP enabled
+		// A transaction signed by the client carries the ID, timestamp and signing
+		// domain it was signed with; without a signature the node signs it with the
+		// sender's custodied key, which must have been unlocked
+		ID        string `json:"id,omitempty"`
+		Timestamp int64  `json:"timestamp,omitempty"`
+		Signature []byte `json:"signature,omitempty"`
+		ChainID   string `json:"chainId,omitempty"`
+		SigScheme int    `json:"sigScheme,omitempty"`
+	}
+	
+	if err := json.NewDecoder(bytes.NewReader(bodyBytes)).Decode(&tx); err != nil {
 		log.Printf("Transaction decode error: %v", err)
-			err = fmt.Errorf("invalid transaction format: %v", err)
+		writeError(w, fmt.Errorf("invalid transaction format: %v", err), http.StatusBadRequest)
 		return
 	}
 	
-	// Temel doğrulama kontrolleri
+	// Basic validation checks
 	if tx.From == "" {
-			err = errors.New("sender address cannot be empty")
+		writeError(w, errors.New("sender address cannot be empty"), http.StatusBadRequest)
 		return
 	}
 	
 	if tx.To == "" {
-			err = errors.New("recipient address cannot be empty")
+		writeError(w, errors.New("recipient address cannot be empty"), http.StatusBadRequest)
 		return
 	}
 	
 	if tx.Value <= 0 {
-			err = fmt.Errorf("invalid transaction amount: %d", tx.Value)
+		writeError(w, fmt.Errorf("invalid transaction amount: %d", tx.Value), http.StatusBadRequest)
 		return
 	}
 	
 	// Debug logging
-		log.Printf("Creating transaction: From=%s, To=%s, Value=%d", tx.From, tx.To, tx.Value)
-		
-		// Ayrıca kullanıcının bekleyen diğer işlemlerini de kontrol edelim
-		pendingTxs := ws.blockchain.GetPendingTransactions()
-		pendingSpend := uint64(0)
-		
-		for _, pendingTx := range pendingTxs {
-			if pendingTx.From == tx.From {
-				pendingSpend += pendingTx.Value
-			}
+	log.Printf("Creating transaction: From=%s, To=%s, Value=%d", tx.From, tx.To, tx.Value)
+	
+	// Also account for the sender's other pending transactions
+	pendingTxs := ws.blockchain.GetPendingTransactions()
+	pendingSpend := uint64(0)
+	
+	for _, pendingTx := range pendingTxs {
+		if pendingTx.From == tx.From {
+			pendingSpend += pendingTx.Value
 		}
-		
-		// Get sender balance
-		senderBalanceBigInt, err := ws.blockchain.GetBalance(tx.From)
+	}
+	
+	// Get sender balance
+	senderBalanceBigInt, err := ws.wallets.GetBalance(tx.From)
 	if err != nil {
-			log.Printf("Error getting balance for sender %s: %v", tx.From, err)
-			err = fmt.Errorf("cannot get sender balance: %v", err)
+		log.Printf("Error getting balance for sender %s: %v", tx.From, err)
+		writeError(w, fmt.Errorf("cannot get sender balance: %w", err), http.StatusBadRequest)
 		return
 	}
-
-		// Check if sender balance can be represented as uint64
-		if !senderBalanceBigInt.IsUint64() {
-			// Balance is too large for uint64, assume sufficient for this check
-			// Continue processing
-		} else {
-			senderBalance := senderBalanceBigInt.Uint64()
-			
-			// Toplam harcama = bekleyen harcamalar + yeni işlem
-			totalSpend := pendingSpend + tx.Value
-			
-			if totalSpend > senderBalance {
-				log.Printf("Insufficient balance for transaction: required=%d, available=%d, pending=%d, total=%d", 
-					tx.Value, senderBalance, pendingSpend, totalSpend)
-				err = fmt.Errorf("insufficient balance: required=%d, available=%d, pending=%d", 
-					tx.Value, senderBalance, pendingSpend)
-		return
-			}
-		}
+	
+	// Balances too large for uint64 are always sufficient for this check
+	if senderBalanceBigInt.IsUint64() {
+		senderBalance := senderBalanceBigInt.Uint64()
 		
-		// Create a simple transaction
-		simpleTransaction = &blockchain.Transaction{
-			ID:        fmt.Sprintf("%x", time.Now().UnixNano()),
-			From:      tx.From,
-			To:        tx.To,
-			Value:     tx.Value,
-			Timestamp: time.Now().Unix(),
-			Type:      "regular",
-		}
+		// Total spend = pending spend + new transaction
+		totalSpend := pendingSpend + uint64(tx.Value)
 		
-		// Data handling
-	if tx.Data != "" {
-			simpleTransaction.Data = []byte(tx.Data)
+		if totalSpend > senderBalance {
+			log.Printf("Insufficient balance for transaction: required=%d, available=%d, pending=%d, total=%d", 
+				tx.Value, senderBalance, pendingSpend, totalSpend)
+			writeError(w, fmt.Errorf("%w: required=%d, available=%d, pending=%d", 
+				blockchain.ErrInsufficientBalance, tx.Value, senderBalance, pendingSpend), http.StatusBadRequest)
+			return
 		}
-		
-		// Add transaction to pool
-		if err = ws.blockchain.AddTransaction(simpleTransaction); err != nil {
-			log.Printf("Error adding transaction to pool: %v", err)
+	}
+	
+	// Create a simple transaction
+	simpleTransaction := &blockchain.Transaction{
+		ID:        fmt.Sprintf("%x", time.Now().UnixNano()),
+		From:      tx.From,
+		To:        tx.To,
+		Value:     uint64(tx.Value),
+		Timestamp: time.Now().Unix(),
+		Type:      "regular",
+	}
+	
+	// Data handling
+	data, err := transactionMemoData(tx.Data, tx.Category, tx.Memo)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
 		return
 	}
+	simpleTransaction.Data = data
 	
-		log.Printf("Transaction added to pool: %s", simpleTransaction.ID)
-	}()
+	// Client signatures are verified against the sender's key when the
+	// transaction enters the pool
+	if len(tx.Signature) > 0 {
+		if tx.ID == "" || tx.Timestamp == 0 {
+			writeError(w, errors.New("a signed transaction needs the id and timestamp it was signed with"), http.StatusBadRequest)
+			return
+		}
+		simpleTransaction.ID = tx.ID
+		simpleTransaction.Timestamp = tx.Timestamp
+		simpleTransaction.Signature = tx.Signature
+		simpleTransaction.ChainID = tx.ChainID
+		simpleTransaction.SigScheme = tx.SigScheme
+	} else if err := ws.signWithSession(r, simpleTransaction); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
 	
-	// Wait for either completion or timeout
-	select {
-	case <-done:
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+	// Enforce the wallet's spending limit and second factor, whoever signed
+	release, err := ws.authorizeCustodialSpend(simpleTransaction, tx.OTP)
+	if err != nil {
+		writeError(w, err, http.StatusForbidden)
 		return
 	}
+	
+	// Add transaction to pool
+	if err := ws.blockchain.AddTransaction(simpleTransaction); err != nil {
+		release()
+		log.Printf("Error adding transaction to pool: %v", err)
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	
+	log.Printf("Transaction added to pool: %s", simpleTransaction.ID)
+	
+	// Return the transaction
+	writeJSON(w, http.StatusCreated, simpleTransaction)
+}
 
-		// Return the transaction
-	w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(simpleTransaction)
-		
-	case <-ctx.Done():
-		log.Printf("Timeout creating transaction: %v", ctx.Err())
-		http.Error(w, "Request timed out", http.StatusGatewayTimeout)
+// transactionMemoData returns the data of a transfer. A category or memo is
+// stored as a blockchain.TxMemo, which keeps a payment request reference
+// given in data; any other data cannot be combined with them.
+func transactionMemoData(data, category, memo string) ([]byte, error) {
+	category = blockchain.NormalizeTxCategory(category)
+	if category == "" && memo == "" {
+		if data == "" {
+			return nil, nil
+		}
+		return []byte(data), nil
 	}
+
+	txMemo := blockchain.TxMemo{Category: category, Memo: memo}
+	if strings.HasPrefix(data, blockchain.PaymentRequestDataPrefix) {
+		txMemo.Request = strings.TrimPrefix(data, blockchain.PaymentRequestDataPrefix)
+	} else if data != "" {
+		return nil, fmt.Errorf("%w: data cannot be combined with a category or memo", blockchain.ErrInvalidMemo)
+	}
+	return blockchain.EncodeTxMemo(txMemo)
 }
 
 // createWallet handles the wallet creation endpoint
@@ -812,7 +678,7 @@ func (ws *WebServer) createWallet(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "OPTIONS" {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept-Language")
 		w.WriteHeader(http.StatusOK)
 		return
 	}
@@ -821,101 +687,66 @@ func (ws *WebServer) createWallet(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	
-	// Create a context with timeout
-	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
-	defer cancel()
+	// Signature scheme of the new key: ?keyType=p256 (default) or ed25519
+	keyType, err := blockchain.ParseKeyType(r.URL.Query().Get("keyType"))
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	
+	start := time.Now()
+	log.Printf("Starting wallet creation")
+	
+	// Create wallet
+	wallet, err := blockchain.CreateWalletOfType(keyType)
+	if err != nil {
+		log.Printf("Failed to create wallet: %v", err)
+		writeError(w, fmt.Errorf("failed to create wallet: %v", err), http.StatusInternalServerError)
+		return
+	}
+	
+	log.Printf("Wallet created with address: %s", wallet.Address)
 	
-	// Use a done channel to signal when we're finished
-	done := make(chan bool, 1)
-	var response struct {
+	response := struct {
 		Address    string `json:"address"`
 		PublicKey  string `json:"publicKey"`
 		PrivateKey string `json:"privateKey"`
+		KeyType    string `json:"keyType"`
 		
-		Balance    uint64 `json:"balance"`
+		Balance    string `json:"balance"`
 		Success    bool   `json:"success"`
+	}{
+		Address:    wallet.Address,
+		PublicKey:  wallet.KeyPair.GetPublicKeyString(),
+		PrivateKey: wallet.KeyPair.GetPrivateKeyString(),
+		KeyType:    wallet.KeyPair.Type().String(),
+		Balance:    "0", // Start with 0 balance
+		Success:    true,
 	}
-	var err error
 	
-	// Do the wallet creation in a goroutine
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("PANIC in createWallet: %v", r)
-				err = fmt.Errorf("internal error: %v", r)
-			}
-			done <- true
-		}()
-		
-		start := time.Now()
-		log.Printf("Starting wallet creation")
-		
-		// Create wallet
-	wallet, err := blockchain.CreateWallet()
-	if err != nil {
-			log.Printf("Failed to create wallet: %v", err)
-			err = fmt.Errorf("failed to create wallet: %v", err)
-		return
-	}
-		
-		log.Printf("Wallet created with address: %s", wallet.Address)
+	// Save wallet's key pair to blockchain
+	ws.wallets.AddKeyPair(wallet.Address, wallet.KeyPair)
+	log.Printf("Key pair added for address: %s", wallet.Address)
+	
+	// Create account with 0 initial balance
+	initialBalance := big.NewInt(0)
+	if err := ws.wallets.CreateAccount(wallet.Address, initialBalance); err != nil {
+		log.Printf("Warning: Error creating account: %v", err)
+	} else {
+		log.Printf("Account created with initial balance: 0 tokens")
 		
-		// Prepare initial response
-		response = struct {
-			Address    string `json:"address"`
-			PublicKey  string `json:"publicKey"`
-			PrivateKey string `json:"privateKey"`
-			
-			Balance    uint64 `json:"balance"`
-			Success    bool   `json:"success"`
-		}{
-			Address:    wallet.Address,
-			PublicKey:  wallet.KeyPair.GetPublicKeyString(),
-			PrivateKey: wallet.KeyPair.GetPrivateKeyString(),
-			Balance:    0, // Start with 0 balance
-			Success:    true,
+		// Pre-cache the balance
+		ws.balanceCache.Store(wallet.Address, initialBalance)
+		ws.balanceCacheExpiry.Store(wallet.Address, time.Now().Add(60*time.Second))
 	}
 	
-	// Save wallet's key pair to blockchain
-	ws.blockchain.AddKeyPair(wallet.Address, wallet.KeyPair)
-		log.Printf("Key pair added for address: %s", wallet.Address)
-
-		// Create account with 0 initial balance
-		initialBalance := big.NewInt(0)
-		if err := ws.blockchain.CreateAccount(wallet.Address, initialBalance); err != nil {
-			log.Printf("Warning: Error creating account: %v", err)
-		} else {
-			log.Printf("Account created with initial balance: 0 tokens")
-			
-			// Pre-cache the balance
-			ws.balanceCache.Store(wallet.Address, initialBalance)
-			ws.balanceCacheExpiry.Store(wallet.Address, time.Now().Add(60*time.Second))
-		}
-		
-		// Save blockchain state to disk after creating a wallet
-		ws.blockchain.SaveToDisk()
-		log.Printf("Blockchain state saved to disk after wallet creation")
-		
-		log.Printf("Wallet created successfully in %v", time.Since(start))
-	}()
+	// Save blockchain state to disk after creating a wallet
+	ws.blockchain.SaveToDisk()
+	log.Printf("Wallet created successfully in %v", time.Since(start))
 	
-	// Wait for either completion or timeout
-	select {
-	case <-done:
-		if err != nil {
-			log.Printf("Error in wallet creation: %v", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		
-		// Return the wallet information
+	// Return the wallet information
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
-		
-	case <-ctx.Done():
-		log.Printf("Timeout creating wallet: %v", ctx.Err())
-		http.Error(w, "Wallet creation timed out", http.StatusGatewayTimeout)
-	}
 }
 
 // getWalletBalance handles the wallet balance endpoint
@@ -947,15 +778,23 @@ func (ws *WebServer) getWalletBalance(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	// Point-in-time balances come from the balance journal, not the cache
+	if height := r.URL.Query().Get("height"); height != "" {
+		ws.getBalanceAtHeight(w, address, height)
+		return
+	}
+	
 	log.Printf("WALLET BALANCE REQUEST for address: %s", address)
 	
 	// ALWAYS respond with something - default is 0 tokens
 	response := struct {
-		Address string `json:"address"`
-		Balance string `json:"balance"` // Changed to string
+		Address string                   `json:"address"`
+		Balance string                   `json:"balance"` // Changed to string
+		Label   *blockchain.AddressLabel `json:"label,omitempty"`
 	}{
 		Address: address,
 		Balance: "0", // Default balance as string
+		Label:   ws.addressLabel(address),
 	}
 	
 	// Try to get from cache first (fastest)
@@ -973,68 +812,27 @@ func (ws *WebServer) getWalletBalance(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	
-	// Create a context with a very short timeout - frontend is timing out anyway
-	// so we might as well respond quickly with a default value
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-	defer cancel()
+	log.Printf("Checking if address %s exists in blockchain", address)
 	
-	// Use a done channel to signal when we're finished
-	done := make(chan bool, 1)
-	resultChan := make(chan *big.Int, 1)
-	
-	// Do the blockchain lookup in the background
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("PANIC in getWalletBalance for %s: %v", address, r)
-			}
-			done <- true
-		}()
-		
-		log.Printf("Checking if address %s exists in blockchain", address)
-		
-		// Check if the address is known
-		_, keyExists := ws.blockchain.GetKeyPair(address)
-		
-		// Get account balance if possible - only confirmed balance
-	balance, err := ws.blockchain.GetBalance(address)
-		
-	if err != nil {
-			log.Printf("Error getting balance for %s: %v", address, err)
-		return
-	}
+	// Check if the address is known
+	_, keyExists := ws.wallets.GetKeyPair(address)
 	
+	// Get account balance if possible - only confirmed balance
+	balance, err := ws.wallets.GetBalance(address)
+	switch {
+	case err != nil:
+		log.Printf("Error getting balance for %s: %v", address, err)
+	case balance == nil || balance.Sign() <= 0:
 		// Use default for nil/zero/negative balance
-		if balance == nil || balance.Sign() <= 0 {
-			// If key exists but balance is 0, still use default
-			if keyExists {
-				log.Printf("Address %s exists but has zero balance", address)
-			}
-			return
+		if keyExists {
+			log.Printf("Address %s exists but has zero balance", address)
 		}
-		
-		// Got valid balance, send the result
-		resultChan <- balance
+	default:
+		response.Balance = balance.String()
 		
 		// Cache the balance for 30 seconds
 		ws.balanceCache.Store(address, balance)
 		ws.balanceCacheExpiry.Store(address, time.Now().Add(30*time.Second))
-		
-		log.Printf("Retrieved balance for %s: %s in %v", 
-			address, balance.String(), time.Since(startTime))
-	}()
-	
-	// Wait for either completion, result, or timeout
-	select {
-	case result := <-resultChan:
-		// Got a balance result
-		response.Balance = result.String()
-	case <-done:
-		// Done but no result sent - using default
-		log.Printf("No valid balance returned for %s, using default", address)
-	case <-ctx.Done():
-		// Timeout - using default
-		log.Printf("Timeout getting balance for %s, using default", address)
 	}
 	
 	// Always return OK with the response
@@ -1050,17 +848,27 @@ func (ws *WebServer) mineBlock(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	
 	var req struct {
-		Validator string `json:"validator"`
+		Validator       string `json:"validator"`
+		MaxTransactions int    `json:"maxTransactions"` // batch size, 0 = block capacity
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("Error decoding mining request: %v", err)
-		http.Error(w, fmt.Sprintf("invalid request format: %v", err), http.StatusBadRequest)
+		writeError(w, fmt.Errorf("invalid request format: %w", err), http.StatusBadRequest)
 		return
 	}
 	
+	if ws.isMiningPaused() {
+		writeError(w, errors.New("mining is disabled on this node by an administrator"), http.StatusServiceUnavailable)
+		return
+	}
+
 	if req.Validator == "" {
 		log.Printf("Mining request error: validator address is empty")
-		http.Error(w, "validator address is required", http.StatusBadRequest)
+		writeError(w, errors.New("validator address is required"), http.StatusBadRequest)
+		return
+	}
+	if req.MaxTransactions < 0 {
+		writeError(w, errors.New("maxTransactions must not be negative"), http.StatusBadRequest)
 		return
 	}
 	
@@ -1070,7 +878,7 @@ func (ws *WebServer) mineBlock(w http.ResponseWriter, r *http.Request) {
 	// Check if the address is a registered validator
 	if !ws.blockchain.IsValidator(req.Validator) {
 		log.Printf("Unauthorized mining attempt from non-validator address: %s", req.Validator)
-		http.Error(w, fmt.Sprintf("address %s is not a registered validator", req.Validator), http.StatusUnauthorized)
+		writeError(w, fmt.Errorf("address %s is not a registered validator", req.Validator), http.StatusUnauthorized)
 		return
 	}
 	
@@ -1078,12 +886,12 @@ func (ws *WebServer) mineBlock(w http.ResponseWriter, r *http.Request) {
 	validators := ws.blockchain.GetValidators()
 	if len(validators) == 0 {
 		log.Printf("No validators found in the blockchain")
-		http.Error(w, "no validators available", http.StatusBadRequest)
+		writeError(w, errors.New("no validators available"), http.StatusBadRequest)
 		return
 	}
 
 	validatorAddress := validators[0].Address
-	keyPair, exists := ws.blockchain.GetKeyPair(validatorAddress)
+	keyPair, exists := ws.wallets.GetKeyPair(validatorAddress)
 	if !exists {
 		log.Printf("Key pair not found for validator: %s", validatorAddress)
 		
@@ -1091,7 +899,7 @@ func (ws *WebServer) mineBlock(w http.ResponseWriter, r *http.Request) {
 		addresses := ws.blockchain.GetAllAddresses()
 		log.Printf("Available addresses in blockchain: %v", addresses)
 		
-		http.Error(w, fmt.Sprintf("validator's key pair not found for %s", validatorAddress), http.StatusBadRequest)
+		writeError(w, fmt.Errorf("validator's key pair not found for %s", validatorAddress), http.StatusBadRequest)
 		return
 	}
 	log.Printf("Retrieved key pair for validator: %s", validatorAddress)
@@ -1100,43 +908,134 @@ func (ws *WebServer) mineBlock(w http.ResponseWriter, r *http.Request) {
 	humanProof := ws.blockchain.GetHumanProof(req.Validator)
 	if humanProof == "" {
 		log.Printf("Human proof not found for validator: %s", req.Validator)
-		http.Error(w, "validator's human proof not found", http.StatusBadRequest)
+		writeError(w, errors.New("validator's human proof not found"), http.StatusBadRequest)
 		return
 	}
 	log.Printf("Retrieved human proof for validator: %s", req.Validator)
 	
-	// Get pending transactions
-	pendingTxs := ws.blockchain.GetPendingTransactions()
+	// Get pending transactions, system and governance lanes first
+	pendingTxs := ws.blockchain.SelectTransactions(0)
+	maxBlockTxs := ws.blockchain.MaxBlockTransactions()
+	if req.MaxTransactions > 0 && req.MaxTransactions < maxBlockTxs {
+		// Mine a bounded batch; the rest is left for the following rounds
+		maxBlockTxs = req.MaxTransactions
+	}
 	log.Printf("Retrieved %d pending transactions", len(pendingTxs))
 	
+	if len(pendingTxs) == 0 && ws.blockchain.Halted() {
+		writeError(w, blockchain.ErrChainHalted, http.StatusServiceUnavailable)
+		return
+	}
 	if len(pendingTxs) == 0 {
 		log.Printf("No pending transactions to mine for validator: %s", req.Validator)
-		http.Error(w, "no pending transactions to mine", http.StatusBadRequest)
+		writeError(w, fmt.Errorf("%w to mine", blockchain.ErrNoPendingTxs), http.StatusBadRequest)
 		return
 	}
 	
 	// Validate and pre-process all transactions
 	validTxs := []*blockchain.Transaction{}
-	invalidTxs := []*blockchain.Transaction{}
+	invalidTxs := []blockchain.TxRejection{}
 	
-	// Her bir göndericinin bloktaki tüm işlemler sonrası toplam harcamasını takip edelim
+	// Track each sender's total spend across all transactions in the block
 	senderSpends := make(map[string]uint64)
 	senderBalances := make(map[string]uint64)
 	
 	for _, tx := range pendingTxs {
+		// Transactions that do not fit stay pending for the next block
+		if len(validTxs) >= maxBlockTxs {
+			break
+		}
+		
+		// Human proof registrations carry no value and only need a valid payload
+		if tx.Type == blockchain.HumanProofTxType {
+			if _, err := blockchain.ParseHumanProofRegistration(tx); err != nil {
+				log.Printf("Invalid human proof registration %s: %v", tx.ID, err)
+				invalidTxs = append(invalidTxs, blockchain.TxRejection{ID: tx.ID, Reason: fmt.Sprintf("invalid human proof registration: %v", err)})
+				continue
+			}
+			validTxs = append(validTxs, tx)
+			continue
+		}
+		
+		// Evidence carries no value; both headers must be signed by the accused validator
+		if tx.Type == blockchain.SlashEvidenceTxType {
+			if _, err := ws.blockchain.VerifyEvidence(tx); err != nil {
+				log.Printf("Invalid double-signing evidence %s: %v", tx.ID, err)
+				invalidTxs = append(invalidTxs, blockchain.TxRejection{ID: tx.ID, Reason: fmt.Sprintf("invalid double-signing evidence: %v", err)})
+				continue
+			}
+			validTxs = append(validTxs, tx)
+			continue
+		}
+		
+		// Oracle transactions carry no value; the sender must be a validator or an authorized oracle
+		if tx.Type == blockchain.OracleAuthorizeTxType || tx.Type == blockchain.OracleDataTxType {
+			if err := ws.blockchain.VerifyOracleTransaction(tx); err != nil {
+				log.Printf("Invalid oracle transaction %s: %v", tx.ID, err)
+				invalidTxs = append(invalidTxs, blockchain.TxRejection{ID: tx.ID, Reason: fmt.Sprintf("invalid oracle transaction: %v", err)})
+				continue
+			}
+			validTxs = append(validTxs, tx)
+			continue
+		}
+		
+		// Slashes carry no value; the sender must be another validator
+		if tx.Type == blockchain.ValidatorSlashTxType {
+			if err := ws.blockchain.VerifyValidatorSlash(tx); err != nil {
+				log.Printf("Invalid validator slash %s: %v", tx.ID, err)
+				invalidTxs = append(invalidTxs, blockchain.TxRejection{ID: tx.ID, Reason: fmt.Sprintf("invalid validator slash: %v", err)})
+				continue
+			}
+			validTxs = append(validTxs, tx)
+			continue
+		}
+		
+		// Staking requests carry no value; their nonce must be unused
+		if tx.Type == blockchain.StakingDelegateTxType || tx.Type == blockchain.StakingUndelegateTxType || tx.Type == blockchain.StakingClaimTxType {
+			if err := ws.blockchain.VerifyStakingTransaction(tx); err != nil {
+				log.Printf("Invalid staking request %s: %v", tx.ID, err)
+				invalidTxs = append(invalidTxs, blockchain.TxRejection{ID: tx.ID, Reason: fmt.Sprintf("invalid staking request: %v", err)})
+				continue
+			}
+			validTxs = append(validTxs, tx)
+			continue
+		}
+		
+		// Commission changes carry no value; each request is confirmed once
+		if tx.Type == blockchain.ValidatorCommissionTxType {
+			if err := ws.blockchain.VerifyCommissionTransaction(tx); err != nil {
+				log.Printf("Invalid commission change %s: %v", tx.ID, err)
+				invalidTxs = append(invalidTxs, blockchain.TxRejection{ID: tx.ID, Reason: fmt.Sprintf("invalid commission change: %v", err)})
+				continue
+			}
+			validTxs = append(validTxs, tx)
+			continue
+		}
+		
+		// Circuit breaker actions carry no value; the sender must be a validator
+		if tx.Type == blockchain.CircuitBreakerTxType {
+			if err := ws.blockchain.VerifyCircuitBreakerTransaction(tx); err != nil {
+				log.Printf("Invalid circuit breaker transaction %s: %v", tx.ID, err)
+				invalidTxs = append(invalidTxs, blockchain.TxRejection{ID: tx.ID, Reason: fmt.Sprintf("invalid circuit breaker transaction: %v", err)})
+				continue
+			}
+			validTxs = append(validTxs, tx)
+			continue
+		}
+		
 		// Validate transaction basics
 		if tx.From == "" || tx.To == "" || tx.Value <= 0 {
 			log.Printf("Invalid transaction found: From=%s, To=%s, Value=%d", tx.From, tx.To, tx.Value)
-			invalidTxs = append(invalidTxs, tx)
+			invalidTxs = append(invalidTxs, blockchain.TxRejection{ID: tx.ID, Reason: "missing sender or recipient, or zero value"})
 			continue
 		}
 		
 		// Get sender balance if not already cached
 		if _, exists := senderBalances[tx.From]; !exists {
-			balance, err := ws.blockchain.GetBalance(tx.From)
+			balance, err := ws.wallets.GetBalance(tx.From)
 		if err != nil {
 			log.Printf("Warning: Cannot get balance for sender %s: %v", tx.From, err)
-			invalidTxs = append(invalidTxs, tx)
+			invalidTxs = append(invalidTxs, blockchain.TxRejection{ID: tx.ID, Reason: fmt.Sprintf("cannot read sender balance: %v", err)})
 			continue
 			}
 			senderBalances[tx.From] = balance.Uint64()
@@ -1155,18 +1054,18 @@ func (ws *WebServer) mineBlock(w http.ResponseWriter, r *http.Request) {
 		if totalSpentBySender > senderBalance {
 			log.Printf("Warning: Insufficient balance for transaction %s after considering previous txs in block (sender: %s, amount: %d, balance: %d, total spent: %d)",
 				tx.ID, tx.From, tx.Value, senderBalance, totalSpentBySender)
-			invalidTxs = append(invalidTxs, tx)
+			invalidTxs = append(invalidTxs, blockchain.TxRejection{ID: tx.ID, Reason: fmt.Sprintf("insufficient balance: %d needed including earlier transactions in the block, %d available", totalSpentBySender, senderBalance)})
 			continue
 		}
 		
 		// Verify transaction signature
 		if !tx.SimpleVerify() {
 			log.Printf("Warning: Transaction %s has invalid signature", tx.ID)
-			invalidTxs = append(invalidTxs, tx)
+			invalidTxs = append(invalidTxs, blockchain.TxRejection{ID: tx.ID, Reason: "invalid signature"})
 			continue
 		}
 		
-		// Bu işlem geçerli, toplam harcamayı güncelleyelim
+		// Transaction is valid, update the total spend
 		senderSpends[tx.From] = totalSpentBySender
 		validTxs = append(validTxs, tx)
 		log.Printf("Valid transaction found: ID=%s, From=%s, To=%s, Value=%d", 
@@ -1175,7 +1074,10 @@ func (ws *WebServer) mineBlock(w http.ResponseWriter, r *http.Request) {
 	
 	if len(validTxs) == 0 {
 		log.Printf("No valid transactions to mine for validator: %s", req.Validator)
-		http.Error(w, "no valid transactions to mine", http.StatusBadRequest)
+		if err := ws.blockchain.RejectTransactions(invalidTxs); err != nil {
+			log.Printf("Warning: Failed to record rejected transactions: %v", err)
+		}
+		writeError(w, errors.New("no valid transactions to mine"), http.StatusBadRequest)
 		return
 	}
 	
@@ -1189,75 +1091,58 @@ func (ws *WebServer) mineBlock(w http.ResponseWriter, r *http.Request) {
 	
 	newBlock := &blockchain.Block{
 		Index:        lastBlock.Index + 1,
-		Timestamp:    time.Now().Unix(),
+		Timestamp:    blockchain.Now().Unix(),
 		Transactions: validTxs, // Only include valid transactions
 		PrevHash:     lastBlock.Hash,
 		Validator:    req.Validator,
-		HumanProof:   humanProof, // Validatör için saklanan gerçek human proof kullanıyoruz
+		HumanProof:   humanProof, // Use the human proof stored for the validator
 	}
 	
+	// Embed the rewards before hashing so the signed block is final
+	ws.blockchain.AttachRewards(newBlock)
+	ws.blockchain.AttachCommitCertificate(newBlock)
+	
 	// Calculate and set the block hash
 	newBlock.Hash = newBlock.CalculateHash()
 	log.Printf("New block created with hash: %s", newBlock.Hash)
 	
 	// Sign the block
-	if err := newBlock.Sign(keyPair.PrivateKey); err != nil {
+	if err := newBlock.SignWith(keyPair.Signer()); err != nil {
 		log.Printf("Error during block signing by validator %s: %v", req.Validator, err)
-		http.Error(w, fmt.Sprintf("block signing failed: %v", err), http.StatusInternalServerError)
+		writeError(w, fmt.Errorf("block signing failed: %w", err), http.StatusInternalServerError)
 		return
 	}
 	log.Printf("Block successfully signed by validator %s", req.Validator)
 	
 	// Add block to blockchain
 	if err := ws.blockchain.AddBlock(newBlock); err != nil {
-		log.Printf("Error adding block to blockchain: %v", err)
-		http.Error(w, fmt.Sprintf("failed to add block: %v", err), http.StatusInternalServerError)
-		return
+		if !errors.Is(err, blockchain.ErrBlockAppliedWithErrors) {
+			log.Printf("Error adding block to blockchain: %v", err)
+			writeError(w, fmt.Errorf("failed to add block: %w", err), http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Warning: %v", err)
 	}
 	log.Printf("Block #%d successfully added to blockchain", newBlock.Index)
 	
-	// Remove invalid transactions from the pool
-	for _, tx := range invalidTxs {
-		if err := ws.blockchain.RemoveTransaction(tx.ID); err != nil {
-			log.Printf("Warning: Failed to remove invalid transaction %s: %v", tx.ID, err)
-		}
+	// Move invalid transactions from the pool to the rejected store, with the
+	// reason, so senders can find out why they were dropped
+	if err := ws.blockchain.RejectTransactions(invalidTxs); err != nil {
+		log.Printf("Warning: Failed to record rejected transactions: %v", err)
 	}
 	
-	// Process valid transactions and update balances
+	// Balances were applied by AddBlock; report the outcome of each transaction
 	successfulTxs := []*blockchain.Transaction{}
 	failedTxs := []*blockchain.Transaction{}
 	
 	for _, tx := range validTxs {
-		// Final balance check before updating
-		currentBalance, err := ws.blockchain.GetBalance(tx.From)
-		if err != nil {
-			log.Printf("Error checking balance for %s: %v", tx.From, err)
-			failedTxs = append(failedTxs, tx)
-			continue
-		}
-		
-		if currentBalance.Cmp(big.NewInt(int64(tx.Value))) < 0 {
-			log.Printf("Final balance check failed for tx %s: required=%d, available=%d", 
-				tx.ID, tx.Value, currentBalance)
-			failedTxs = append(failedTxs, tx)
-			continue
-		}
-		
-		// Update balances
-		if err := ws.blockchain.UpdateBalances(tx); err != nil {
-			log.Printf("Failed to update balances for transaction %s: %v", tx.ID, err)
-			failedTxs = append(failedTxs, tx)
-		} else {
-			// Transaction successfully processed
+		if tx.Status == "confirmed" {
 			successfulTxs = append(successfulTxs, tx)
 			log.Printf("Successfully processed transaction %s: %d tokens from %s to %s",
 				tx.ID, tx.Value, tx.From, tx.To)
-				
-			// Get and log new balances for verification
-			newSenderBalance, _ := ws.blockchain.GetBalance(tx.From)
-			newReceiverBalance, _ := ws.blockchain.GetBalance(tx.To)
-			log.Printf("Updated balances - Sender %s: %d, Receiver %s: %d", 
-				tx.From, newSenderBalance, tx.To, newReceiverBalance)
+		} else {
+			failedTxs = append(failedTxs, tx)
+			log.Printf("Failed to apply transaction %s", tx.ID)
 		}
 	}
 	
@@ -1271,15 +1156,16 @@ func (ws *WebServer) mineBlock(w http.ResponseWriter, r *http.Request) {
 		SuccessfulTxs     []*blockchain.Transaction  `json:"successfulTransactions"`
 		FailedTxs         []*blockchain.Transaction  `json:"failedTransactions"`
 		InvalidTxs        int                        `json:"invalidTransactions"`
+		RemainingTxs      int                        `json:"remainingTransactions"`
 	}{
 		Block:         newBlock,
 		SuccessfulTxs: successfulTxs,
 		FailedTxs:     failedTxs,
 		InvalidTxs:    len(invalidTxs),
+		RemainingTxs:  len(ws.blockchain.GetPendingTransactions()),
 	}
 	
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, http.StatusCreated, response)
 }
 
 // registerValidator handles the validator registration endpoint
@@ -1292,37 +1178,37 @@ func (ws *WebServer) registerValidator(w http.ResponseWriter, r *http.Request) {
 	}
 	
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err, http.StatusBadRequest)
 		return
 	}
 	
 	// Validate inputs
 	if req.Address == "" {
-		http.Error(w, "address is required", http.StatusBadRequest)
+		writeError(w, errors.New("address is required"), http.StatusBadRequest)
 		return
 	}
 	
 	if req.HumanProof == "" {
-		http.Error(w, "humanProof is required", http.StatusBadRequest)
+		writeError(w, errors.New("humanProof is required"), http.StatusBadRequest)
 		return
 	}
 	
 	// Check if address has a key pair
-	if _, exists := ws.blockchain.GetKeyPair(req.Address); !exists {
-		http.Error(w, "address does not have a registered key pair", http.StatusBadRequest)
+	if _, exists := ws.wallets.GetKeyPair(req.Address); !exists {
+		writeError(w, errors.New("address does not have a registered key pair"), http.StatusBadRequest)
 		return
 	}
 	
 	// Check if already a validator
 	if ws.blockchain.IsValidator(req.Address) {
-		http.Error(w, "address is already a validator", http.StatusConflict)
+		writeError(w, blockchain.ErrValidatorExists, http.StatusConflict)
 		return
 	}
 	
 	// Add as validator
 	if err := ws.blockchain.AddValidator(req.Address, req.HumanProof); err != nil {
 		log.Printf("Failed to register validator: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err, http.StatusInternalServerError)
 		return
 	}
 	
@@ -1351,18 +1237,18 @@ func (ws *WebServer) getValidators(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	// Statik validator listesi - ValidatorInfo struct'ının gerçek yapısına uygun
-	// Sadece zorunlu alanlar olan Address ve HumanProof kullanılıyor
+	// Validator list matching the ValidatorInfo struct
+	// Only the required Address and HumanProof fields are used
 	defaultValidators := []blockchain.ValidatorInfo{}
 	
-	// İlk olarak önbellekteki verileri kontrol edelim (30 saniyeden daha yeni ise)
+	// Check the cached data first (if newer than 30 seconds)
 	ws.validatorsCacheMutex.RLock()
 	cacheAge := time.Since(ws.validatorsCacheTime)
 	hasCache := len(ws.validatorsCache) > 0 && cacheAge < 30*time.Second
 	
-	// Eğer önbellekte güncel veri varsa, hemen döndürelim
+	// If the cache is fresh, return immediately
 	if hasCache {
-		validators := ws.validatorsCache // Kopyasını alalım
+		validators := ws.validatorsCache // Take a copy
 		ws.validatorsCacheMutex.RUnlock()
 		
 		log.Printf("Returning %d validators from cache (age: %v)", len(validators), cacheAge)
@@ -1371,93 +1257,15 @@ func (ws *WebServer) getValidators(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	// Çok eski bile olsa herhangi bir önbellek verisi var mı?
+	// Is there any cached data, however old?
 	staleCacheExists := len(ws.validatorsCache) > 0
 	staleValidators := ws.validatorsCache
 	ws.validatorsCacheMutex.RUnlock()
 	
-	// Asenkron olarak validator listesini güncellemeye çalışalım
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("PANIC in background validator update: %v", r)
-			}
-		}()
-		
-		// 5 saniyelik kısa bir timeout ile deneyelim
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		
-		// Kanallarla iletişim kuralım
-		done := make(chan bool, 1)
-		var validators []blockchain.ValidatorInfo
-		
-		go func() {
-			defer func() {
-				if r := recover(); r != nil {
-					log.Printf("PANIC in validator fetching: %v", r)
-				}
-				done <- true
-			}()
-			
-			start := time.Now()
-			log.Printf("Background fetching validators from blockchain")
-			
-			// Get validators from blockchain
-			allValidators := ws.blockchain.GetValidators()
-			log.Printf("Found %d total validators in blockchain", len(allValidators))
-			
-			// Filter only active validators
-			validators = make([]blockchain.ValidatorInfo, 0)
-			for _, v := range allValidators {
-				// Check if validator is active by checking if they have mined any blocks
-				hasMinedBlocks := false
-				chainHeight := ws.blockchain.GetChainHeight()
-				
-				// Check last 10 blocks for this validator
-				for i := uint64(0); i < 10 && i <= chainHeight; i++ {
-					block, err := ws.blockchain.GetBlockByIndex(i)
-					if err != nil {
-						continue
-					}
-					if block.Validator == v.Address {
-						hasMinedBlocks = true
-						break
-					}
-				}
-				
-				if hasMinedBlocks {
-					validators = append(validators, v)
-					log.Printf("Found active validator: %s", v.Address)
-				} else {
-					log.Printf("Found inactive validator: %s", v.Address)
-				}
-			}
-			
-			if len(validators) > 0 {
-				// Önbelleği güncelle
-				ws.validatorsCacheMutex.Lock()
-				ws.validatorsCache = validators
-				ws.validatorsCacheTime = time.Now()
-				ws.validatorsCacheMutex.Unlock()
-				
-				log.Printf("Background updated validator cache with %d active validators in %v", 
-					len(validators), time.Since(start))
-			} else {
-				log.Printf("No active validators found in blockchain")
-			}
-		}()
-		
-		// Wait for either completion or timeout
-		select {
-		case <-done:
-			// İşlem tamamlandı, önbellek güncellendi
-		case <-ctx.Done():
-			log.Printf("Background validator update timed out: %v", ctx.Err())
-		}
-	}()
-	
-	// Hemen yanıt verelim - Önce eski önbellek, yoksa varsayılan veri
+	// Refresh the validator list in the background
+	go ws.refreshValidatorsCache()
+	
+	// Respond right away - stale cache first, otherwise default data
 	if staleCacheExists && len(staleValidators) > 0 {
 		log.Printf("Returning %d validators from stale cache immediately", len(staleValidators))
 		w.WriteHeader(http.StatusOK)
@@ -1465,13 +1273,68 @@ func (ws *WebServer) getValidators(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	// Önbellekte hiç veri yoksa, boş liste döndür
+	// No cached data at all, return an empty list
 	log.Printf("No validator cache available, returning empty list")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(defaultValidators)
 }
 
-// getConfirmedTransactions handles the confirmed transactions endpoint with caching
+// refreshValidatorsCache reloads the cached validator list with the validators
+// that proposed one of the first blocks
+func (ws *WebServer) refreshValidatorsCache() {
+	start := time.Now()
+	log.Printf("Background fetching validators from blockchain")
+	
+	// Get validators from blockchain
+	allValidators := ws.blockchain.GetValidators()
+	log.Printf("Found %d total validators in blockchain", len(allValidators))
+	
+	// Filter only active validators
+	validators := make([]blockchain.ValidatorInfo, 0)
+	for _, v := range allValidators {
+		// Check if validator is active by checking if they have mined any blocks
+		hasMinedBlocks := false
+		chainHeight := ws.blockchain.GetChainHeight()
+		
+		// Check last 10 blocks for this validator
+		for i := uint64(0); i < 10 && i <= chainHeight; i++ {
+			block, err := ws.blockchain.GetBlockByIndex(i)
+			if err != nil {
+				continue
+			}
+			if block.Validator == v.Address {
+				hasMinedBlocks = true
+				break
+			}
+		}
+		
+		if hasMinedBlocks {
+			validators = append(validators, v)
+			log.Printf("Found active validator: %s", v.Address)
+		} else {
+			log.Printf("Found inactive validator: %s", v.Address)
+		}
+	}
+	
+	if len(validators) == 0 {
+		log.Printf("No active validators found in blockchain")
+		return
+	}
+	
+	// Update the cache
+	ws.validatorsCacheMutex.Lock()
+	ws.validatorsCache = validators
+	ws.validatorsCacheTime = time.Now()
+	ws.validatorsCacheMutex.Unlock()
+	
+	log.Printf("Background updated validator cache with %d active validators in %v", 
+		len(validators), time.Since(start))
+}
+
+// getConfirmedTransactions handles the confirmed transactions endpoint with
+// caching. Transactions are ordered by block, then by position in the block,
+// newest first unless order=asc; full pages carry a continuation token, see
+// pagination.go. Only the newest first page is cached.
 func (ws *WebServer) getConfirmedTransactions(w http.ResponseWriter, r *http.Request) {
 	// Set CORS headers and Content-Type
 	w.Header().Set("Content-Type", "application/json")
@@ -1496,14 +1359,21 @@ func (ws *WebServer) getConfirmedTransactions(w http.ResponseWriter, r *http.Req
 		}
 	}
 	
-	// Önbellekteki verileri kontrol edelim (15 saniyeden daha yeni ise)
+	order, cursor, err := parsePage(r, cursorTxs, orderDesc)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	firstPage := cursor == nil && order == orderDesc
+	
+	// Check the cached data (if newer than 15 seconds)
 	ws.confirmedTxCacheMutex.RLock()
 	cacheAge := time.Since(ws.confirmedTxCacheTime)
-	hasCache := len(ws.confirmedTxCache) > 0 && cacheAge < 15*time.Second
+	hasCache := firstPage && len(ws.confirmedTxCache) > 0 && cacheAge < 15*time.Second
 	
-	// Eğer önbellekte güncel veri varsa ve istenen limit önbellek boyutundan az veya eşitse, hemen döndürelim
+	// If the cache is fresh and the requested limit fits in it, return immediately
 	if hasCache && limit <= len(ws.confirmedTxCache) {
-		// Önbellekten limiti kadar veri alalım
+		// Take up to limit entries from the cache
 		txs := ws.confirmedTxCache
 		if limit < len(txs) {
 			txs = txs[:limit]
@@ -1511,148 +1381,33 @@ func (ws *WebServer) getConfirmedTransactions(w http.ResponseWriter, r *http.Req
 		ws.confirmedTxCacheMutex.RUnlock()
 		
 		log.Printf("Returning %d confirmed transactions from cache (age: %v)", len(txs), cacheAge)
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(txs)
+		setNextCursor(w, ws.txCursor(txs[len(txs)-1], order), len(txs), limit)
+		writeNegotiated(w, r, http.StatusOK, txs, transactionList(txs))
 		return
 	}
 	ws.confirmedTxCacheMutex.RUnlock()
 	
-	// Önbellekte veri yoksa veya eski ise veya istenen limit önbellek boyutundan büyükse, yeni veri alalım
-	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second) // Kısa bir timeout kullanarak hızlı cevap dönelim
-	defer cancel()
+	// Cache is empty, stale or smaller than the requested limit, so fetch fresh data
+	start := time.Now()
+	log.Printf("Getting confirmed transactions from blockchain, limit=%d", limit)
 	
-	// Use a done channel to signal when we're finished
-	done := make(chan bool, 1)
-	var confirmedTxs []*blockchain.Transaction
-	var err error
+	// Skip coinbase/reward transactions
+	confirmedTxs, last := ws.confirmedTxPage(order, cursor, limit, func(tx *blockchain.Transaction) bool {
+		return tx.From != "0" && tx.From != ""
+	})
 	
-	// Do the work in a goroutine
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("PANIC in getConfirmedTransactions: %v", r)
-				err = fmt.Errorf("internal error: %v", r)
-			}
-			done <- true
-		}()
-		
-		start := time.Now()
-		log.Printf("Getting confirmed transactions from blockchain, limit=%d", limit)
-		
-		// Initialize the result array
-		confirmedTxs = make([]*blockchain.Transaction, 0)
-		
-		// Get blockchain height
-		height := int(ws.blockchain.GetChainHeight())
-		
-		// Sadece son 10 bloğa bakalım
-		maxBlocksToCheck := 10
-		if height < maxBlocksToCheck {
-			maxBlocksToCheck = height + 1
-		}
-		
-		// En son limiti aşmamak için her bloktan az sayıda işlem alalım
-		txsPerBlock := limit / maxBlocksToCheck
-		if txsPerBlock < 5 {
-			txsPerBlock = 5
-		}
-		
-		// Onaylanmış işlemleri en son bloklardan alalım
-		for i := height; i >= (height-maxBlocksToCheck+1) && i >= 0 && len(confirmedTxs) < limit; i-- {
-			block, err := ws.blockchain.GetBlockByIndex(uint64(i))
-			if err != nil {
-				log.Printf("Error fetching block at index %d: %v", i, err)
-				continue
-			}
-			
-			// Her bloktan en son birkaç işlemi alalım
-			txsToProcess := block.Transactions
-			if len(txsToProcess) > txsPerBlock {
-				txsToProcess = txsToProcess[len(txsToProcess)-txsPerBlock:]
-			}
-			
-			for _, tx := range txsToProcess {
-				// Skip coinbase/reward transactions
-				if tx.From == "0" || tx.From == "" {
-					continue
-				}
-				
-				// Create a copy
-				txCopy := *tx
-				// Add status and block information
-				txCopy.Status = "confirmed"
-				txCopy.BlockIndex = int64(block.Index)
-				txCopy.BlockHash = block.Hash
-				
-				confirmedTxs = append(confirmedTxs, &txCopy)
-				
-				if len(confirmedTxs) >= limit {
-					break
-				}
-			}
-		}
-		
-		// Önbelleği güncelle - tüm işlemleri saklayalım (limitle sınırlamadan)
+	// Update the cache
+	if firstPage {
 		ws.confirmedTxCacheMutex.Lock()
 		ws.confirmedTxCache = confirmedTxs
 		ws.confirmedTxCacheTime = time.Now()
 		ws.confirmedTxCacheMutex.Unlock()
-		
-		log.Printf("Retrieved %d confirmed transactions in %v", len(confirmedTxs), time.Since(start))
-	}()
-	
-	// Wait for either completion or timeout
-	select {
-	case <-done:
-		if err != nil {
-			log.Printf("Error getting confirmed transactions: %v", err)
-			// Return an empty array instead of an error
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(make([]*blockchain.Transaction, 0))
-			return
-		}
-		
-		// Create empty array if nil
-		if confirmedTxs == nil {
-			confirmedTxs = make([]*blockchain.Transaction, 0)
-		}
-		
-		// Limit the response if needed
-		if len(confirmedTxs) > limit {
-			confirmedTxs = confirmedTxs[:limit]
-		}
-		
-		// Return the transactions as JSON
-		w.WriteHeader(http.StatusOK)
-		err = json.NewEncoder(w).Encode(confirmedTxs)
-		if err != nil {
-			log.Printf("Error encoding confirmed transactions: %v", err)
-			http.Error(w, "Error encoding response", http.StatusInternalServerError)
-		}
-		
-	case <-ctx.Done():
-		log.Printf("Timeout getting confirmed transactions: %v", ctx.Err())
-		
-		// Önbellekte herhangi bir veri varsa, eski de olsa döndürelim
-		ws.confirmedTxCacheMutex.RLock()
-		hasCacheData := len(ws.confirmedTxCache) > 0
-		cachedTxs := ws.confirmedTxCache // Kopyasını alalım
-		if limit < len(cachedTxs) {
-			cachedTxs = cachedTxs[:limit]
-		}
-		ws.confirmedTxCacheMutex.RUnlock()
-		
-		if hasCacheData {
-			log.Printf("Returning %d confirmed transactions from stale cache due to timeout", len(cachedTxs))
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(cachedTxs)
-			return
-		}
-		
-		// Hiç önbellek verisi yoksa boş dizi döndür
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(make([]*blockchain.Transaction, 0))
 	}
+	
+	log.Printf("Retrieved %d confirmed transactions in %v", len(confirmedTxs), time.Since(start))
+	
+	setNextCursor(w, last, len(confirmedTxs), limit)
+	writeNegotiated(w, r, http.StatusOK, confirmedTxs, transactionList(confirmedTxs))
 }
 
 // importWallet handles the wallet import endpoint
@@ -1661,62 +1416,36 @@ func (ws *WebServer) importWallet(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "OPTIONS" {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept-Language")
 		w.WriteHeader(http.StatusOK)
 		return
 	}
 
 	var req struct {
 		PrivateKey string `json:"privateKey"`
+		Format     string `json:"format,omitempty"` // hex, pem, wif or auto (default)
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request format", http.StatusBadRequest)
+		writeError(w, errors.New("Invalid request format"), http.StatusBadRequest)
 		return
 	}
 
 	if req.PrivateKey == "" {
-		http.Error(w, "Private key is required", http.StatusBadRequest)
+		writeError(w, errors.New("Private key is required"), http.StatusBadRequest)
 		return
 	}
 
-	// Import crypto/rand to use in this function
-	privKey, err := blockchain.ImportPrivateKey(req.PrivateKey)
+	privKey, format, err := blockchain.ImportPrivateKeyWithFormat(req.PrivateKey, blockchain.KeyFormat(req.Format))
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid private key: %v", err), http.StatusBadRequest)
+		writeError(w, fmt.Errorf("Invalid private key: %w", err), http.StatusBadRequest)
 		return
 	}
 
-	// Use the private key from the blockchain package
-	keyPair := &blockchain.KeyPair{
-		PrivateKey: privKey,
-		PublicKey:  &privKey.PublicKey,
-	}
-
-	// Generate address from public key
-	address := blockchain.GenerateAddress(keyPair.PublicKey)
-
-	// Check if wallet already exists in blockchain
-	existingKeyPair, exists := ws.blockchain.GetKeyPair(address)
+	address, keyPair, exists := ws.registerImportedKey(privKey)
 	if !exists {
-		// Save wallet's key pair to blockchain
-		ws.blockchain.AddKeyPair(address, keyPair)
-
-		// Check if account exists, if not create it with initial balance
-		_, err = ws.blockchain.GetBalance(address)
-		if err != nil {
-			initialBalance := big.NewInt(0) // Start with 0 tokens
-			err = ws.blockchain.CreateAccount(address, initialBalance)
-			if err != nil {
-				log.Printf("Error creating account during import: %v", err)
-			}
-		}
-
 		// Save blockchain state after import
 		go ws.blockchain.SaveToDisk()
-	} else {
-		// Use existing key pair for consistent behavior
-		keyPair = existingKeyPair
 	}
 
 	// Respond with wallet information
@@ -1724,11 +1453,13 @@ func (ws *WebServer) importWallet(w http.ResponseWriter, r *http.Request) {
 		Address    string `json:"address"`
 		PublicKey  string `json:"publicKey"`
 		PrivateKey string `json:"privateKey"`
+		Format     string `json:"format"`
 		Exists     bool   `json:"exists"`
 	}{
 		Address:    address,
 		PublicKey:  keyPair.GetPublicKeyString(),
 		PrivateKey: req.PrivateKey,
+		Format:     string(format),
 		Exists:     exists,
 	}
 
@@ -1759,83 +1490,91 @@ func (ws *WebServer) transfer(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		From  string `json:"from"`
 		To    string `json:"to"`
-		Value uint64 `json:"value"` // Changed from string to uint64
+		ToContact string `json:"toContact,omitempty"` // name of the recipient in the address book instead of to
+		Value units.Uint64 `json:"value"` // base units, as a string or a number
+		OTP   string `json:"otp,omitempty"` // one-time code for wallets with TOTP enabled
+		Category string `json:"category,omitempty"` // bookkeeping tag stored in the transaction memo
+		Memo     string `json:"memo,omitempty"`
 	}
 	
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("Error parsing transfer request: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
 		return
 	}
 	
 	// Validate request
-	if req.From == "" || req.To == "" || req.Value == 0 {
-		http.Error(w, "Missing required fields", http.StatusBadRequest)
+	if req.From == "" || (req.To == "" && req.ToContact == "") || req.Value == 0 {
+		writeError(w, errors.New("Missing required fields"), http.StatusBadRequest)
 		return
 	}
 	
-	// Create transaction
-	simpleTransaction := &blockchain.Transaction{
-		ID:        uuid.New().String(),
-		From:      req.From,
-		To:        req.To,
-		Value:     req.Value,
-		Timestamp: time.Now().Unix(),
-		Signature: []byte("system_transfer"), // Special system signature, ideally should be properly signed
-		Type:      "regular",
-		Status:    "pending",
+	// Look up the recipient in the address book when named as a contact
+	to, err := ws.resolveRecipient(r, req.From, req.To, req.ToContact)
+	if err != nil {
+		writeError(w, fmt.Errorf("Transfer failed: %w", err), http.StatusBadRequest)
+		return
 	}
 	
-	// Create a context with timeout for the transfer operation
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-	defer cancel()
+	data, err := transactionMemoData("", req.Category, req.Memo)
+	if err != nil {
+		writeError(w, fmt.Errorf("Transfer failed: %w", err), http.StatusBadRequest)
+		return
+	}
 	
-	// Use a channel to get the result
-	errCh := make(chan error, 1)
+	// Create transaction, signed with the sender's custodied key; the wallet
+	// must have been unlocked with its passphrase
+	simpleTransaction := blockchain.NewTransaction(uuid.New().String(), req.From, to, uint64(req.Value), data)
+	if err := ws.signWithSession(r, simpleTransaction); err != nil {
+		writeError(w, fmt.Errorf("Transfer failed: %w", err), http.StatusBadRequest)
+		return
+	}
 	
-	go func() {
-		// Add transaction to the blockchain
-		err := ws.blockchain.AddTransaction(simpleTransaction)
-		errCh <- err
-	}()
+	// Enforce the wallet's spending limit and second factor
+	release, err := ws.authorizeCustodialSpend(simpleTransaction, req.OTP)
+	if err != nil {
+		writeError(w, fmt.Errorf("Transfer failed: %w", err), http.StatusForbidden)
+		return
+	}
 	
-	// Wait for the transaction to complete or timeout
-	select {
-	case err := <-errCh:
-		if err != nil {
-			log.Printf("Transfer error: %v", err)
-			http.Error(w, fmt.Sprintf("Transfer failed: %v", err), http.StatusInternalServerError)
-			return
-		}
-		
-		// Success - transaction was added to the pool
-		log.Printf("Transaction added to pool: %s", simpleTransaction.ID)
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(simpleTransaction)
-		
-	case <-ctx.Done():
-		log.Printf("Timeout creating transaction: %v", ctx.Err())
-		http.Error(w, "Request timed out", http.StatusGatewayTimeout)
+	// Add transaction to the blockchain
+	if err := ws.blockchain.AddTransaction(simpleTransaction); err != nil {
+		release()
+		log.Printf("Transfer error: %v", err)
+		writeError(w, fmt.Errorf("Transfer failed: %w", err), http.StatusInternalServerError)
+		return
 	}
+	
+	// Success - transaction was added to the pool
+	log.Printf("Transaction added to pool: %s", simpleTransaction.ID)
+	writeJSON(w, http.StatusOK, simpleTransaction)
 }
 
-// SignedRequest represents a request signed by an admin
-type SignedRequest struct {
-	Action     string            `json:"action"`
-	Data       map[string]string `json:"data"`
-	AdminAddress string          `json:"adminAddress"`
-	Signature  string            `json:"signature"`
-	Timestamp  int64             `json:"timestamp"`
+// verifyAdminRequest verifies a signed admin request received on r. It writes
+// the error response itself and returns false when the request is rejected.
+func (ws *WebServer) verifyAdminRequest(w http.ResponseWriter, r *http.Request, req *types.SignedRequest) bool {
+	if !ws.acceptsAdminRequests(w, r) {
+		return false
+	}
+	if valid, err := ws.verifyAdminSignature(req); !valid {
+		writeError(w, fmt.Errorf("%w: %v", errInvalidAdminSignature, err), http.StatusUnauthorized)
+		return false
+	}
+	return true
 }
 
 // verifyAdminSignature verifies the admin signature on a request
 func (ws *WebServer) verifyAdminSignature(req *types.SignedRequest) (bool, error) {
-	// Verify timestamp (within 5 minutes)
-	if time.Now().Unix()-req.Timestamp > 300 {
-		return false, fmt.Errorf("request expired")
+	// Verify timestamp (within 5 minutes either way)
+	now := time.Now()
+	if err := checkAdminTimestamp(req.Timestamp, now); err != nil {
+		return false, err
 	}
 
 	// Verify admin address
+	if ws.validatorManager == nil {
+		return false, errNoValidatorManager
+	}
 	if !ws.validatorManager.IsAdmin(req.AdminAddress) {
 		return false, fmt.Errorf("invalid admin address")
 	}
@@ -1846,34 +1585,40 @@ func (ws *WebServer) verifyAdminSignature(req *types.SignedRequest) (bool, error
 		log.Printf("Error verifying signature: %v", err)
 		return false, err
 	}
+	if !valid {
+		return false, errors.New("signature does not match")
+	}
 
-	return valid, nil
+	// A signed request is accepted once
+	if err := ws.adminReplay.markUsed(req, now); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // approveValidator handles approving a validator
 func (ws *WebServer) approveValidator(w http.ResponseWriter, r *http.Request) {
 	var req types.SignedRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
 		return
 	}
 
 	// Verify admin signature
-	if valid, err := ws.verifyAdminSignature(&req); !valid {
-		http.Error(w, fmt.Sprintf("Invalid signature: %v", err), http.StatusUnauthorized)
+	if !ws.verifyAdminRequest(w, r, &req) {
 		return
 	}
 
 	// Extract validator address from request data
 	validatorAddress, ok := req.Data["address"]
 	if !ok {
-		http.Error(w, "Missing validator address in request data", http.StatusBadRequest)
+		writeError(w, errors.New("Missing validator address in request data"), http.StatusBadRequest)
 		return
 	}
 
 	// Approve the validator
 	if err := ws.validatorManager.ApproveValidator(req.AdminAddress, validatorAddress); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to approve validator: %v", err), http.StatusInternalServerError)
+		writeError(w, fmt.Errorf("Failed to approve validator: %w", err), http.StatusInternalServerError)
 		return
 	}
 
@@ -1888,20 +1633,19 @@ func (ws *WebServer) approveValidator(w http.ResponseWriter, r *http.Request) {
 func (ws *WebServer) rejectValidator(w http.ResponseWriter, r *http.Request) {
 	var req types.SignedRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
 		return
 	}
 
 	// Verify admin signature
-	if valid, err := ws.verifyAdminSignature(&req); !valid {
-		http.Error(w, fmt.Sprintf("Invalid signature: %v", err), http.StatusUnauthorized)
+	if !ws.verifyAdminRequest(w, r, &req) {
 		return
 	}
 
 	// Extract validator address and reason from request data
 	validatorAddress, ok := req.Data["address"]
 	if !ok {
-		http.Error(w, "Missing validator address in request data", http.StatusBadRequest)
+		writeError(w, errors.New("Missing validator address in request data"), http.StatusBadRequest)
 		return
 	}
 
@@ -1912,7 +1656,7 @@ func (ws *WebServer) rejectValidator(w http.ResponseWriter, r *http.Request) {
 
 	// Reject the validator
 	if err := ws.validatorManager.RejectValidator(validatorAddress, req.AdminAddress, reason); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to reject validator: %v", err), http.StatusInternalServerError)
+		writeError(w, fmt.Errorf("Failed to reject validator: %w", err), http.StatusInternalServerError)
 		return
 	}
 
@@ -1927,20 +1671,19 @@ func (ws *WebServer) rejectValidator(w http.ResponseWriter, r *http.Request) {
 func (ws *WebServer) suspendValidator(w http.ResponseWriter, r *http.Request) {
 	var req types.SignedRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
 		return
 	}
 
 	// Verify admin signature
-	if valid, err := ws.verifyAdminSignature(&req); !valid {
-		http.Error(w, fmt.Sprintf("Invalid signature: %v", err), http.StatusUnauthorized)
+	if !ws.verifyAdminRequest(w, r, &req) {
 		return
 	}
 
 	// Extract validator address and reason from request data
 	validatorAddress, ok := req.Data["address"]
 	if !ok {
-		http.Error(w, "Missing validator address in request data", http.StatusBadRequest)
+		writeError(w, errors.New("Missing validator address in request data"), http.StatusBadRequest)
 		return
 	}
 
@@ -1951,7 +1694,7 @@ func (ws *WebServer) suspendValidator(w http.ResponseWriter, r *http.Request) {
 
 	// Suspend the validator
 	if err := ws.validatorManager.SuspendValidator(req.AdminAddress, validatorAddress, reason); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to suspend validator: %v", err), http.StatusInternalServerError)
+		writeError(w, fmt.Errorf("Failed to suspend validator: %w", err), http.StatusInternalServerError)
 		return
 	}
 
@@ -1966,26 +1709,25 @@ func (ws *WebServer) suspendValidator(w http.ResponseWriter, r *http.Request) {
 func (ws *WebServer) addAdmin(w http.ResponseWriter, r *http.Request) {
 	var req types.SignedRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
 		return
 	}
 
 	// Verify admin signature
-	if valid, err := ws.verifyAdminSignature(&req); !valid {
-		http.Error(w, fmt.Sprintf("Invalid signature: %v", err), http.StatusUnauthorized)
+	if !ws.verifyAdminRequest(w, r, &req) {
 		return
 	}
 
 	// Extract new admin address from request data
 	newAdminAddress, ok := req.Data["address"]
 	if !ok {
-		http.Error(w, "Missing address in request data", http.StatusBadRequest)
+		writeError(w, errors.New("Missing address in request data"), http.StatusBadRequest)
 		return
 	}
 
 	// Add the new admin
 	if err := ws.validatorManager.AddAdmin(newAdminAddress, req.AdminAddress); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to add admin: %v", err), http.StatusInternalServerError)
+		writeError(w, fmt.Errorf("Failed to add admin: %w", err), http.StatusInternalServerError)
 		return
 	}
 
@@ -2000,26 +1742,25 @@ func (ws *WebServer) addAdmin(w http.ResponseWriter, r *http.Request) {
 func (ws *WebServer) removeAdmin(w http.ResponseWriter, r *http.Request) {
 	var req types.SignedRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
 		return
 	}
 
 	// Verify admin signature
-	if valid, err := ws.verifyAdminSignature(&req); !valid {
-		http.Error(w, fmt.Sprintf("Invalid signature: %v", err), http.StatusUnauthorized)
+	if !ws.verifyAdminRequest(w, r, &req) {
 		return
 	}
 
 	// Extract admin address to remove from request data
 	adminToRemove, ok := req.Data["address"]
 	if !ok {
-		http.Error(w, "Missing address in request data", http.StatusBadRequest)
+		writeError(w, errors.New("Missing address in request data"), http.StatusBadRequest)
 		return
 	}
 
 	// Remove the admin
 	if err := ws.validatorManager.RemoveAdmin(adminToRemove, req.AdminAddress); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to remove admin: %v", err), http.StatusInternalServerError)
+		writeError(w, fmt.Errorf("Failed to remove admin: %w", err), http.StatusInternalServerError)
 		return
 	}
 
@@ -2032,6 +1773,11 @@ func (ws *WebServer) removeAdmin(w http.ResponseWriter, r *http.Request) {
 
 // listAdmins returns the list of current admins
 func (ws *WebServer) listAdmins(w http.ResponseWriter, r *http.Request) {
+	if ws.validatorManager == nil {
+		writeError(w, errNoValidatorManager, http.StatusServiceUnavailable)
+		return
+	}
+
 	// Get the list of admins
 	admins := ws.validatorManager.GetAdmins()
 	
@@ -2048,7 +1794,7 @@ func (ws *WebServer) listAdmins(w http.ResponseWriter, r *http.Request) {
 // listProposals returns the list of governance proposals
 func (ws *WebServer) listProposals(w http.ResponseWriter, r *http.Request) {
 	if ws.governance == nil {
-		http.Error(w, "Governance system not enabled", http.StatusServiceUnavailable)
+		writeError(w, errors.New("Governance system not enabled"), http.StatusServiceUnavailable)
 		return
 	}
 	
@@ -2076,7 +1822,7 @@ func (ws *WebServer) listProposals(w http.ResponseWriter, r *http.Request) {
 // getProposal returns details of a specific proposal
 func (ws *WebServer) getProposal(w http.ResponseWriter, r *http.Request) {
 	if ws.governance == nil {
-		http.Error(w, "Governance system not enabled", http.StatusServiceUnavailable)
+		writeError(w, errors.New("Governance system not enabled"), http.StatusServiceUnavailable)
 		return
 	}
 	
@@ -2087,7 +1833,7 @@ func (ws *WebServer) getProposal(w http.ResponseWriter, r *http.Request) {
 	// Get the proposal
 	proposal, err := ws.governance.GetProposal(proposalID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get proposal: %v", err), http.StatusNotFound)
+		writeError(w, fmt.Errorf("Failed to get proposal: %w", err), http.StatusNotFound)
 		return
 	}
 	
@@ -2096,6 +1842,14 @@ func (ws *WebServer) getProposal(w http.ResponseWriter, r *http.Request) {
 		"success":  true,
 		"proposal": proposal,
 	}
+	// Parameter changes are voted on with the recent block utilization at hand
+	if proposal.Type == consensus.ProposalTypeChangeParameter {
+		response["utilization"] = ws.blockchain.UtilizationStats(0)
+	}
+	// Transfers from a multisig wallet wait for its owners after approval
+	if proposal.MultiSigTxID != "" {
+		response["multisig"] = ws.proposalMultiSigStatus(proposal.Data["wallet"], proposal.MultiSigTxID)
+	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
@@ -2114,14 +1868,14 @@ type ProposalRequest struct {
 // createProposal creates a new governance proposal
 func (ws *WebServer) createProposal(w http.ResponseWriter, r *http.Request) {
 	if ws.governance == nil {
-		http.Error(w, "Governance system not enabled", http.StatusServiceUnavailable)
+		writeError(w, errors.New("Governance system not enabled"), http.StatusServiceUnavailable)
 		return
 	}
 	
 	// Decode request
 	var req ProposalRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid request format: %v", err), http.StatusBadRequest)
+		writeError(w, fmt.Errorf("Invalid request format: %w", err), http.StatusBadRequest)
 		return
 	}
 	
@@ -2138,7 +1892,7 @@ func (ws *WebServer) createProposal(w http.ResponseWriter, r *http.Request) {
 	)
 	
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to create proposal: %v", err), http.StatusInternalServerError)
+		writeError(w, fmt.Errorf("Failed to create proposal: %w", err), http.StatusInternalServerError)
 		return
 	}
 	
@@ -2164,14 +1918,14 @@ type VoteRequest struct {
 // castVote casts a vote on a governance proposal
 func (ws *WebServer) castVote(w http.ResponseWriter, r *http.Request) {
 	if ws.governance == nil {
-		http.Error(w, "Governance system not enabled", http.StatusServiceUnavailable)
+		writeError(w, errors.New("Governance system not enabled"), http.StatusServiceUnavailable)
 		return
 	}
 	
 	// Decode request
 	var req VoteRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid request format: %v", err), http.StatusBadRequest)
+		writeError(w, fmt.Errorf("Invalid request format: %w", err), http.StatusBadRequest)
 		return
 	}
 	
@@ -2181,7 +1935,7 @@ func (ws *WebServer) castVote(w http.ResponseWriter, r *http.Request) {
 	// Cast the vote
 	err := ws.governance.CastVote(req.ProposalID, req.Voter, req.InFavor)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to cast vote: %v", err), http.StatusInternalServerError)
+		writeError(w, fmt.Errorf("Failed to cast vote: %w", err), http.StatusInternalServerError)
 		return
 	}
 	
@@ -2214,7 +1968,7 @@ func (ws *WebServer) getBlockByIndex(w http.ResponseWriter, r *http.Request) {
 	indexInt, err := strconv.Atoi(indexStr)
 	if err != nil || indexInt < 0 {
 		log.Printf("Error parsing block index: %v or negative index: %d", err, indexInt)
-		http.Error(w, "invalid block index", http.StatusBadRequest)
+		writeError(w, errors.New("invalid block index"), http.StatusBadRequest)
 		return
 	}
 	
@@ -2222,110 +1976,48 @@ func (ws *WebServer) getBlockByIndex(w http.ResponseWriter, r *http.Request) {
 	chainHeight := int(ws.blockchain.GetChainHeight())
 	if indexInt > chainHeight {
 		log.Printf("Block index out of range: %d (max: %d)", indexInt, chainHeight)
-		http.Error(w, fmt.Sprintf("block index out of range (max: %d)", chainHeight), http.StatusNotFound)
+		writeError(w, fmt.Errorf("%w: block index out of range (max: %d)", blockchain.ErrBlockNotFound, chainHeight), http.StatusNotFound)
 		return
 	}
 	
 	// Convert to uint64 only after validation
 	index := uint64(indexInt)
 	
-	// Check if we have a cached block
-	indexKey := fmt.Sprintf("block_%d", index)
-	if cachedValue, ok := ws.blockCache.Load(indexKey); ok {
-		if expiryTime, ok := ws.blockCacheExpiry.Load(indexKey); ok {
-			if time.Now().Before(expiryTime.(time.Time)) {
-				// Cached value is still valid
-				cachedBlock := cachedValue.(*blockchain.Block)
-				
-				log.Printf("Returning cached block for index %d", index)
-				
-				// Return the cached block with capitalized field names for React
-				returnBlockWithCapitalizedFields(w, cachedBlock)
-				return
-			}
+	start := time.Now()
+	log.Printf("Block request for index: %d", index)
+	
+	// Get the block from blockchain
+	block, err := ws.blockchain.GetBlockByIndex(index)
+	if err != nil {
+		log.Printf("Error retrieving block at index %d: %v", index, err)
+		if errors.Is(err, blockchain.ErrArchiveUnavailable) {
+			writeError(w, err, http.StatusServiceUnavailable)
+			return
 		}
+		writeError(w, fmt.Errorf("%w at index %d", blockchain.ErrBlockNotFound, index), http.StatusNotFound)
+		return
 	}
 	
-	// Set a timeout for the handler
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-	
-	// Use a done channel to signal when we're finished
-	done := make(chan bool, 1)
-	var block *blockchain.Block
-	var blockErr error
+	// Ensure block has a valid hash
+	if block.Hash == "" {
+		block.Hash = fmt.Sprintf("block_%d_%d", block.Index, block.Timestamp)
+	}
 	
-	// Do the work in a goroutine
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("PANIC in getBlockByIndex for %d: %v", index, r)
-				blockErr = fmt.Errorf("internal error: %v", r)
-			}
-			done <- true
-		}()
-		
-		start := time.Now()
-		log.Printf("Block request for index: %d", index)
-		
-		// Get the block from blockchain
-		block, blockErr = ws.blockchain.GetBlockByIndex(index)
-		
-		if blockErr == nil && block != nil {
-			// Ensure block has a valid hash
-			if block.Hash == "" {
-				block.Hash = fmt.Sprintf("block_%d_%d", block.Index, block.Timestamp)
-			}
-			
-			// Cache the block for 60 seconds - blocks don't change once created
-			ws.blockCache.Store(indexKey, block)
-			ws.blockCacheExpiry.Store(indexKey, time.Now().Add(60*time.Second))
-			
-			log.Printf("Retrieved block for index %d in %v", index, time.Since(start))
-		} else {
-			log.Printf("Error retrieving block at index %d: %v", index, blockErr)
-		}
-	}()
+	log.Printf("Retrieved block for index %d in %v", index, time.Since(start))
 	
-	// Wait for either completion or timeout
-	select {
-	case <-done:
-		if blockErr != nil {
-			log.Printf("Error retrieving block at index %d: %v", index, blockErr)
-			http.Error(w, fmt.Sprintf("block not found at index %d", index), http.StatusNotFound)
-			return
-		}
-		
-		if block == nil {
-			log.Printf("No block found at index %d", index)
-			http.Error(w, fmt.Sprintf("block not found at index %d", index), http.StatusNotFound)
-			return
-		}
-		
-		// Return the block with capitalized field names for React
-		returnBlockWithCapitalizedFields(w, block)
-		
-	case <-ctx.Done():
-		log.Printf("Timeout getting block at index %d: %v", index, ctx.Err())
-		
-		// Check for an expired cached value that we can use as a fallback
-		if cachedValue, ok := ws.blockCache.Load(indexKey); ok {
-			cachedBlock := cachedValue.(*blockchain.Block)
-			
-			log.Printf("Timeout - returning stale cached block for index %d", index)
-			
-			// Return the stale cached block with capitalized field names
-			returnBlockWithCapitalizedFields(w, cachedBlock)
-			return
-		}
-		
-		// No cached value available
-		http.Error(w, fmt.Sprintf("timeout retrieving block at index %d", index), http.StatusGatewayTimeout)
+	// Blocks never change, so clients and CDNs can keep them and revalidate by ETag
+	utilization := ws.blockchain.BlockUtilization(block)
+	if setBlockCacheHeaders(w, r, block, utilization, mediaJSON) {
+		return
 	}
+	
+	// Return the block with capitalized field names for React
+	returnBlockWithCapitalizedFields(w, r, block, utilization)
 }
 
-// Helper function to return block with capitalized field names for React
-func returnBlockWithCapitalizedFields(w http.ResponseWriter, block *blockchain.Block) {
+// Helper function to return block with capitalized field names for React.
+// Supports ?fields= selection and gzip for lightweight clients.
+func returnBlockWithCapitalizedFields(w http.ResponseWriter, r *http.Request, block *blockchain.Block, utilization blockchain.BlockUtilization) {
 	// Define a struct with capitalized field names
 	type TransactionResponse struct {
 		ID        string `json:"ID"`
@@ -2348,6 +2040,7 @@ func returnBlockWithCapitalizedFields(w http.ResponseWriter, block *blockchain.B
 		Signature    []byte               `json:"Signature"`
 		Reward       uint64               `json:"Reward"`
 		Transactions []TransactionResponse `json:"Transactions"`
+		Utilization  blockchain.BlockUtilization `json:"Utilization"`
 	}
 	
 	// Ensure block has a valid hash
@@ -2387,13 +2080,15 @@ func returnBlockWithCapitalizedFields(w http.ResponseWriter, block *blockchain.B
 		Signature:    block.Signature,
 		Reward:       block.Reward,
 		Transactions: txResponses,
+		Utilization:  utilization,
 	}
 	
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(blockResponse)
+	writeLightJSON(w, r, http.StatusOK, blockResponse)
 }
 
-// getAllTransactions combines pending and confirmed transactions
+// getAllTransactions combines pending and confirmed transactions. The first
+// newest-first page leads with a share of the pending transactions; confirmed
+// transactions follow in chain order and are paged, see pagination.go.
 func (ws *WebServer) getAllTransactions(w http.ResponseWriter, r *http.Request) {
 	// Set CORS headers
 	w.Header().Set("Content-Type", "application/json")
@@ -2421,169 +2116,72 @@ func (ws *WebServer) getAllTransactions(w http.ResponseWriter, r *http.Request)
 		limit = 100
 	}
 	
-	// Set a timeout for the handler - 30 seconds should be enough for all transactions
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-	defer cancel()
+	order, cursor, err := parsePage(r, cursorTxs, orderDesc)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
 	
-	// Use a done channel to signal when we're finished
-	done := make(chan bool, 1)
-	allTxs := make([]*blockchain.Transaction, 0)
-	var err error
+	start := time.Now()
+	log.Printf("Getting all transactions from blockchain, limit=%d", limit)
 	
-	// Do the work in a goroutine
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("PANIC in getAllTransactions: %v", r)
-				err = fmt.Errorf("internal error: %v", r)
-			}
-			done <- true
-		}()
-		
-		start := time.Now()
-		log.Printf("Getting all transactions from blockchain, limit=%d", limit)
-		
-		// Initialize the result array
-		allTxs = make([]*blockchain.Transaction, 0, limit)
-		
-		// First prioritize pending transactions - a quarter of the limit
-		pendingLimit := limit / 4
-		pendingStart := time.Now()
-		
-		// Check the cache first
-		var pendingTxs []*blockchain.Transaction
-		
-		ws.pendingTxCacheMutex.RLock()
-		if time.Since(ws.pendingTxCacheTime) < 30*time.Second {
-			pendingTxs = ws.pendingTxCache
-			log.Printf("Using cached pending transactions (%d items)", len(pendingTxs))
-		}
-		ws.pendingTxCacheMutex.RUnlock()
-		
-		// If no valid cache, get from blockchain
-		if len(pendingTxs) == 0 {
-			pendingTxs = ws.blockchain.GetPendingTransactions()
-			
-			// Update cache
-			if len(pendingTxs) > 0 {
-				ws.pendingTxCacheMutex.Lock()
-				ws.pendingTxCache = pendingTxs
-				ws.pendingTxCacheTime = time.Now()
-				ws.pendingTxCacheMutex.Unlock()
-			}
-		}
-		
-		// Limit the number of pending transactions we process
-		if len(pendingTxs) > pendingLimit {
-			pendingTxs = pendingTxs[len(pendingTxs)-pendingLimit:]
-		}
-		
-		for _, tx := range pendingTxs {
-			// Create a copy
-			txCopy := *tx
-			// Add a status field for pending transactions
-			txCopy.Status = "pending"
-			allTxs = append(allTxs, &txCopy)
-		}
-		
-		log.Printf("Got %d pending transactions in %v", len(pendingTxs), time.Since(pendingStart))
-		
-		// Calculate how many confirmed transactions we need
-		remainingLimit := limit - len(allTxs)
+	// Initialize the result array
+	allTxs := make([]*blockchain.Transaction, 0, limit)
+	
+	// First prioritize pending transactions - a quarter of the limit, on the first page only
+	pendingLimit := limit / 4
+	if cursor != nil || order != orderDesc {
+		pendingLimit = 0
+	}
+	pendingStart := time.Now()
+	
+	// Check the cache first
+	var pendingTxs []*blockchain.Transaction
+	
+	ws.pendingTxCacheMutex.RLock()
+	if time.Since(ws.pendingTxCacheTime) < 30*time.Second {
+		pendingTxs = ws.pendingTxCache
+		log.Printf("Using cached pending transactions (%d items)", len(pendingTxs))
+	}
+	ws.pendingTxCacheMutex.RUnlock()
+	
+	// If no valid cache, get from blockchain
+	if len(pendingTxs) == 0 {
+		pendingTxs = ws.blockchain.GetPendingTransactions()
 		
-		// Only get confirmed transactions if we haven't reached the limit
-		if remainingLimit > 0 {
-			// Get confirmed transactions - check only the latest 10 blocks
-			maxBlocks := 10 // Limit to 10 blocks for performance
-			confirmedStart := time.Now()
-			
-			// Get the current height (safely as int)
-			chainHeight := int(ws.blockchain.GetChainHeight())
-			
-			// Loop through recent blocks to get transactions
-			for i := chainHeight; i >= 0 && i > chainHeight-maxBlocks && len(allTxs) < limit; i-- {
-				// Convert to uint64 only after validation
-				blockIndex := uint64(i)
-				
-				// Try cache first
-				blockKey := fmt.Sprintf("block_%d", blockIndex)
-				var block *blockchain.Block
-				
-				if cachedValue, ok := ws.blockCache.Load(blockKey); ok {
-					if expiryTime, ok := ws.blockCacheExpiry.Load(blockKey); ok {
-						if time.Now().Before(expiryTime.(time.Time)) {
-							block = cachedValue.(*blockchain.Block)
-						}
-					}
-				}
-				
-				// If not in cache, get from blockchain
-				if block == nil {
-					var err error
-					block, err = ws.blockchain.GetBlockByIndex(blockIndex)
-					if err != nil {
-						log.Printf("Error getting block at index %d: %v", i, err)
-						continue
-					}
-					
-					// Cache it
-					ws.blockCache.Store(blockKey, block)
-					ws.blockCacheExpiry.Store(blockKey, time.Now().Add(60*time.Second))
-				}
-				
-				// Process transactions in the block
-				blockTxCount := 0
-				for _, tx := range block.Transactions {
-					if len(allTxs) >= limit {
-						break
-					}
-					
-					// Only process a reasonable number of transactions per block
-					if blockTxCount >= 20 {
-						break
-					}
-					
-					// Create a copy
-					txCopy := *tx
-					// Add status for confirmed transactions
-					txCopy.Status = "confirmed"
-					// Add block information - convert uint64 to int64
-					txCopy.BlockIndex = int64(block.Index)
-					txCopy.BlockHash = block.Hash
-					
-					allTxs = append(allTxs, &txCopy)
-					blockTxCount++
-				}
-				
-				log.Printf("Processed %d transactions from block %d", blockTxCount, i)
-			}
-			
-			log.Printf("Got %d confirmed transactions in %v", len(allTxs)-len(pendingTxs), time.Since(confirmedStart))
+		// Update cache
+		if len(pendingTxs) > 0 {
+			ws.pendingTxCacheMutex.Lock()
+			ws.pendingTxCache = pendingTxs
+			ws.pendingTxCacheTime = time.Now()
+			ws.pendingTxCacheMutex.Unlock()
 		}
-		
-		log.Printf("Total transactions: %d (limit: %d) in %v", len(allTxs), limit, time.Since(start))
-	}()
+	}
 	
-	// Wait for either completion or timeout
-	select {
-	case <-done:
-		// Check for errors
-		if err != nil {
-			log.Printf("Error getting transactions: %v", err)
-			// Still return what we have instead of an error
-		}
-		
-		// Return transactions as JSON
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(allTxs)
-		
-	case <-ctx.Done():
-		log.Printf("Timeout getting all transactions: %v", ctx.Err())
-		
-		// Return what we have so far
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(allTxs)
+	// Limit the number of pending transactions we process
+	if len(pendingTxs) > pendingLimit {
+		pendingTxs = pendingTxs[len(pendingTxs)-pendingLimit:]
+	}
+	
+	for _, tx := range pendingTxs {
+		// Create a copy
+		txCopy := *tx
+		// Add a status field for pending transactions
+		txCopy.Status = "pending"
+		allTxs = append(allTxs, &txCopy)
 	}
+	
+	log.Printf("Got %d pending transactions in %v", len(pendingTxs), time.Since(pendingStart))
+	
+	// Fill the rest of the page with confirmed transactions in chain order
+	confirmedStart := time.Now()
+	confirmedTxs, last := ws.confirmedTxPage(order, cursor, limit-len(allTxs), nil)
+	allTxs = append(allTxs, confirmedTxs...)
+	log.Printf("Got %d confirmed transactions in %v", len(confirmedTxs), time.Since(confirmedStart))
+	
+	setNextCursor(w, last, len(allTxs), limit)
+	log.Printf("Total transactions: %d (limit: %d) in %v", len(allTxs), limit, time.Since(start))
+	writeNegotiated(w, r, http.StatusOK, allTxs, transactionList(allTxs))
 }
 
 // getWalletBalanceSimple is a simplified version of getWalletBalance
@@ -2641,52 +2239,20 @@ func (ws *WebServer) getWalletBalanceSimple(w http.ResponseWriter, r *http.Reque
 		}
 	}
 	
-	// Super fast timeout for blockchain lookup
-	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
-	defer cancel()
-	
-	done := make(chan bool, 1)
-	resultChan := make(chan *big.Int, 1)
-	
-	// Try quick blockchain lookup in background
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("PANIC in getWalletBalanceSimple: %v", r)
-			}
-			done <- true
-		}()
-		
-		// Quick balance check - only confirmed balance
-		balance, err := ws.blockchain.GetBalance(address)
-		if err != nil || balance == nil {
-			// Just silently use default (0)
-			return
-		}
-		
+	// Quick balance check - only confirmed balance
+	balance, err := ws.wallets.GetBalance(address)
+	if err != nil || balance == nil {
+		// Just silently use default (0)
+		log.Printf("Fast endpoint: No valid balance for %s, using default (0) (in %v)",
+			address, time.Since(startTime))
+	} else {
 		// Cache result for future use
 		ws.balanceCache.Store(address, balance)
 		ws.balanceCacheExpiry.Store(address, time.Now().Add(30*time.Second))
 		
-		// Send balance back on channel
-		resultChan <- balance
-	}()
-	
-	// Wait for result or timeout
-	select {
-	case result := <-resultChan:
-		// Got real balance
-		response.Balance = result.String()
+		response.Balance = balance.String()
 		log.Printf("Fast endpoint: Retrieved balance for %s: %s (in %v)",
 			address, response.Balance, time.Since(startTime))
-	case <-done:
-		// No valid result
-		log.Printf("Fast endpoint: No valid balance for %s, using default (0) (in %v)",
-			address, time.Since(startTime))
-	case <-ctx.Done():
-		// Timeout
-		log.Printf("Fast endpoint: Timeout getting balance for %s, using default (0) (in %v)",
-			address, time.Since(startTime))
 	}
 	
 	// Send response
@@ -2712,6 +2278,7 @@ func (ws *WebServer) getHealthCheck(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"status": "healthy",
 		"time": time.Now().Unix(),
+		"maintenance": ws.Maintenance().Enabled,
 	})
 }
 
@@ -2751,7 +2318,7 @@ type ExecuteMultiSigTransactionRequest struct {
 func (ws *WebServer) createMultiSigWallet(w http.ResponseWriter, r *http.Request) {
 	var req CreateMultiSigWalletRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
 		return
 	}
 
@@ -2764,15 +2331,13 @@ func (ws *WebServer) createMultiSigWallet(w http.ResponseWriter, r *http.Request
 		Timestamp:   time.Now().Unix(),
 	}
 	
-	valid, err := ws.verifyAdminSignature(signedReq)
-	if !valid || err != nil {
-		http.Error(w, fmt.Sprintf("Invalid signature: %v", err), http.StatusUnauthorized)
+	if !ws.verifyAdminRequest(w, r, signedReq) {
 		return
 	}
 
-	err = ws.blockchain.CreateMultiSigWallet(req.Address, req.Owners, req.RequiredSigs)
+	err := ws.multisig.CreateMultiSigWallet(req.Address, req.Owners, req.RequiredSigs)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -2784,9 +2349,9 @@ func (ws *WebServer) getMultiSigWallet(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	address := vars["address"]
 
-	wallet, err := ws.blockchain.GetMultiSigWallet(address)
+	wallet, err := ws.multisig.GetMultiSigWallet(address)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeError(w, err, http.StatusNotFound)
 		return
 	}
 
@@ -2796,11 +2361,11 @@ func (ws *WebServer) getMultiSigWallet(w http.ResponseWriter, r *http.Request) {
 func (ws *WebServer) createMultiSigTransaction(w http.ResponseWriter, r *http.Request) {
 	var req CreateMultiSigTransactionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
 		return
 	}
 
-	tx, err := ws.blockchain.CreateMultiSigTransaction(
+	tx, err := ws.multisig.CreateMultiSigTransaction(
 		req.WalletAddress,
 		req.From,
 		req.To,
@@ -2809,7 +2374,7 @@ func (ws *WebServer) createMultiSigTransaction(w http.ResponseWriter, r *http.Re
 		req.Type,
 	)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -2820,18 +2385,19 @@ func (ws *WebServer) createMultiSigTransaction(w http.ResponseWriter, r *http.Re
 func (ws *WebServer) signMultiSigTransaction(w http.ResponseWriter, r *http.Request) {
 	var req SignMultiSigTransactionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
 		return
 	}
 
-	err := ws.blockchain.SignMultiSigTransaction(
+	// The signature must be the signer's signature over the transaction's signing hash
+	err := ws.multisig.SignMultiSigTransaction(
 		req.WalletAddress,
 		req.TxID,
 		req.Signer,
 		req.Signature,
 	)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err, http.StatusBadRequest)
 		return
 	}
 
@@ -2842,13 +2408,13 @@ func (ws *WebServer) signMultiSigTransaction(w http.ResponseWriter, r *http.Requ
 func (ws *WebServer) executeMultiSigTransaction(w http.ResponseWriter, r *http.Request) {
 	var req ExecuteMultiSigTransactionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
 		return
 	}
 
-	err := ws.blockchain.ExecuteMultiSigTransaction(req.WalletAddress, req.TxID)
+	err := ws.multisig.ExecuteMultiSigTransaction(req.WalletAddress, req.TxID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -2861,28 +2427,68 @@ func (ws *WebServer) getMultiSigTransactionStatus(w http.ResponseWriter, r *http
 	walletAddress := vars["walletAddress"]
 	txID := vars["txID"]
 
-	status, err := ws.blockchain.GetMultiSigTransactionStatus(walletAddress, txID)
+	status, err := ws.multisig.GetMultiSigTransactionStatus(walletAddress, txID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeError(w, err, http.StatusNotFound)
 		return
 	}
 
 	json.NewEncoder(w).Encode(map[string]string{"status": status})
 }
 
+// getMultiSigSigningHash returns the hex digest owners sign to approve a pending transaction
+func (ws *WebServer) getMultiSigSigningHash(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	walletAddress := vars["walletAddress"]
+	txID := vars["txID"]
+
+	hash, err := ws.multisig.MultiSigSigningHash(walletAddress, txID)
+	if err != nil {
+		writeError(w, err, http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"walletAddress": walletAddress,
+		"txID":          txID,
+		"signingHash":   hex.EncodeToString(hash),
+	})
+}
+
 func (ws *WebServer) getMultiSigPendingTransactions(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	walletAddress := vars["walletAddress"]
 
-	txs, err := ws.blockchain.GetMultiSigPendingTransactions(walletAddress)
+	txs, err := ws.multisig.GetMultiSigPendingTransactions(walletAddress)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeError(w, err, http.StatusNotFound)
 		return
 	}
 
 	json.NewEncoder(w).Encode(txs)
 }
 
+// proposalMultiSigStatus reports the owner signatures collected for the
+// multisig transaction of a transfer proposal
+func (ws *WebServer) proposalMultiSigStatus(walletAddress, txID string) map[string]interface{} {
+	status := map[string]interface{}{
+		"wallet": walletAddress,
+		"txID":   txID,
+		"status": "closed", // executed or rejected
+	}
+	wallet, err := ws.multisig.GetMultiSigWallet(walletAddress)
+	if err != nil {
+		status["status"] = "unknown"
+		return status
+	}
+	status["requiredSigs"] = wallet.GetRequiredSignatures()
+	if signers, pending := wallet.Signers(txID); pending {
+		status["status"] = "pending"
+		status["signers"] = signers
+	}
+	return status
+}
+
 // ... existing code ...
 
 // revertTransaction reverts a transaction by its hash
@@ -2893,20 +2499,18 @@ func (ws *WebServer) revertTransaction(w http.ResponseWriter, r *http.Request) {
 	// Verify admin signature
 	var req types.SignedRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
 		return
 	}
 
-	valid, err := ws.verifyAdminSignature(&req)
-	if !valid || err != nil {
-		http.Error(w, fmt.Sprintf("Invalid signature: %v", err), http.StatusUnauthorized)
+	if !ws.verifyAdminRequest(w, r, &req) {
 		return
 	}
 
 	// Find and revert the transaction
-	err = ws.blockchain.RevertTransaction(hash)
+	err := ws.blockchain.RevertTransaction(hash)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"math/big"
+	"time"
+
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/consensus"
+	"confirmix/pkg/types"
+)
+
+// The handlers reach the node through the service interfaces below rather
+// than the concrete blockchain, validator manager and governance types, so a
+// route group can be served, or unit tested, with its services replaced.
+// *blockchain.Blockchain implements ChainService, WalletService,
+// MultiSigService and APIKeyService; *consensus.ValidatorManager implements
+// ValidatorService, *consensus.Governance implements GovernanceService and
+// *consensus.Staking implements StakingService.
+
+// ChainService reads and extends the chain and its mempool
+type ChainService interface {
+	GetChainHeight() uint64
+	GetLatestBlock() *blockchain.Block
+	GetBlockByIndex(index uint64) (*blockchain.Block, error)
+	GetHeaders(from uint64, count int) []blockchain.BlockHeader
+	GetBlockReceipt(index uint64) (*blockchain.BlockReceipt, error)
+	BlockUtilization(block *blockchain.Block) blockchain.BlockUtilization
+	VerifyBlockReport(block *blockchain.Block) *blockchain.BlockVerificationReport
+	UtilizationStats(window int) blockchain.UtilizationStats
+	TxLatencyStats() blockchain.TxLatencyStats
+	AddBlock(block *blockchain.Block) error
+	AttachRewards(block *blockchain.Block)
+	AttachCommitCertificate(block *blockchain.Block)
+	Finality() blockchain.FinalityStatus
+
+	AddTransaction(tx *blockchain.Transaction) error
+	GetPendingTransactions() []*blockchain.Transaction
+	PendingByLane() map[string]int
+	MaxBlockTransactions() int
+	SelectTransactions(limit int) []*blockchain.Transaction
+	RejectTransactions(rejections []blockchain.TxRejection) error
+	RejectedTransactions(address string, limit int) []blockchain.RejectedTransaction
+	GetRejectedTransaction(txID string) (blockchain.RejectedTransaction, bool)
+	GetTransactionStatuses(ids []string) []blockchain.TxStatus
+	FindTransaction(id string) (*blockchain.Transaction, error)
+	SearchTransactions(prefix string, limit int) []*blockchain.Transaction
+	RevertTransaction(hash string) error
+
+	BridgeStatus() blockchain.BridgeStatus
+	BridgeReleaseTx(sourceChain, sourceTx string) (string, bool)
+
+	Oracles() []blockchain.OracleInfo
+	OracleFeeds() []blockchain.OracleFeedSummary
+	OracleHistory(feed string, limit int) ([]blockchain.OracleRecord, error)
+	VerifyOracleTransaction(tx *blockchain.Transaction) error
+
+	Halted() bool
+	CircuitBreaker() blockchain.CircuitBreakerStatus
+	VerifyCircuitBreakerTransaction(tx *blockchain.Transaction) error
+	VerifyValidatorSlash(tx *blockchain.Transaction) error
+	VerifyStakingTransaction(tx *blockchain.Transaction) error
+	VerifyCommissionTransaction(tx *blockchain.Transaction) error
+
+	GetValidators() []blockchain.ValidatorInfo
+	IsValidator(address string) bool
+	AddValidator(address string, humanProof string) error
+	LastBlockBy(validator string) (*blockchain.Block, bool)
+	GetHumanProof(address string) string
+	GetHumanProofRecord(address string) (*blockchain.HumanProofRecord, bool)
+	GetHumanProofRegistry() []blockchain.HumanProofRecord
+	GetHumanProofAttestation(address string) (*blockchain.HumanProofRegistration, bool)
+	VerifyEvidence(tx *blockchain.Transaction) (*blockchain.DoubleSignEvidence, error)
+	GetEvidence() []blockchain.EvidenceRecord
+	Events(fromBlock, afterSeq uint64, eventType string, limit int) []blockchain.ChainEvent
+	PendingEvents() []blockchain.ChainEvent
+
+	GetAllAddresses() []string
+	NextUpgrade() (blockchain.UpgradePlan, bool)
+	RequiredUpgrade() (blockchain.UpgradePlan, bool)
+	Upgrades() []blockchain.UpgradePlan
+	Activations() []blockchain.Activation
+	DustPolicy() blockchain.DustPolicy
+	GetArchiveStatus() blockchain.ArchiveStatus
+	GetGenesisInfo() (*blockchain.GenesisInfo, error)
+	Supply() blockchain.Supply
+	RichList(limit int) blockchain.RichList
+	EpochRewardConfig() blockchain.EpochRewardConfig
+	EpochRewardSummary(epoch uint64) (*blockchain.EpochRewardSummary, error)
+	BlockProduction(epoch uint64) (*blockchain.BlockProduction, error)
+	StateDigest() blockchain.StateDigest
+
+	SaveToDisk() error
+	SaveMempool() error
+	Snapshot() (string, error)
+	CompactAccounts() (blockchain.AccountGCReport, error)
+}
+
+// WalletService manages node-held keys, balances, custodial wallet
+// protections and address labels
+type WalletService interface {
+	CreateAccount(address string, initialBalance *big.Int) error
+	AddKeyPair(address string, keyPair *blockchain.KeyPair)
+	GetKeyPair(address string) (*blockchain.KeyPair, bool)
+	AuditKeys() (*blockchain.KeyAudit, error)
+	PruneKeys(addresses []string, exportDir string) (*blockchain.KeyPruneReport, error)
+	GetBalance(address string) (*big.Int, error)
+	GetBalanceAtHeight(address string, height uint64) (*big.Int, error)
+	GetBalanceHistory(address string) []blockchain.BalancePoint
+	GetAccountStatement(address string, from, to int64) (*blockchain.AccountStatement, error)
+	SpendByCategory(address string, from, to int64) (*blockchain.SpendReport, error)
+	BuildBalanceProof(address string, from, to int64) (*blockchain.BalanceProof, error)
+
+	SignWithUnlockedWallet(tx *blockchain.Transaction) error
+	SetWalletPassphrase(address, passphrase, current string) error
+	HasWalletPassphrase(address string) bool
+	UnlockWallet(address, passphrase string, duration time.Duration) (time.Time, error)
+	LockWallet(address string)
+	WalletUnlockedUntil(address string) (time.Time, bool)
+	GetWalletControls(address string) (blockchain.WalletControls, bool)
+	SetWalletControls(controls blockchain.WalletControls) (blockchain.WalletControls, error)
+	ReserveWalletSpend(address string, value uint64) (func(), error)
+	VerifyWalletTOTP(address, code string) error
+
+	AddressLabels(category string) []blockchain.AddressLabel
+	GetAddressLabel(address string) (blockchain.AddressLabel, bool)
+	SetAddressLabel(label blockchain.AddressLabel) (blockchain.AddressLabel, error)
+	RemoveAddressLabel(address string) error
+
+	Contacts(owner string) []blockchain.Contact
+	SaveContact(owner string, contact blockchain.Contact) (blockchain.Contact, error)
+	RemoveContact(owner, name string) error
+	ResolveContact(owner, name string) (blockchain.Contact, error)
+
+	CreatePaymentRequest(owner, recipient string, amount uint64, memo string, ttl time.Duration) (blockchain.PaymentRequest, error)
+	GetPaymentRequest(id string) (blockchain.PaymentRequest, error)
+	PaymentRequests(recipient, status string) []blockchain.PaymentRequest
+	CancelPaymentRequest(owner, id string) (blockchain.PaymentRequest, error)
+}
+
+// MultiSigService manages multi-signature wallets and their transactions
+type MultiSigService interface {
+	CreateMultiSigWallet(address string, owners []string, requiredSigs int) error
+	GetMultiSigWallet(address string) (*blockchain.MultiSigWallet, error)
+	CreateMultiSigTransaction(walletAddress, from, to string, value string, data []byte, txType string) (*blockchain.MultiSigTransaction, error)
+	SignMultiSigTransaction(walletAddress, txID, signer string, signature string) error
+	ExecuteMultiSigTransaction(walletAddress, txID string) error
+	GetMultiSigTransactionStatus(walletAddress, txID string) (string, error)
+	GetMultiSigPendingTransactions(walletAddress string) ([]*blockchain.MultiSigTransaction, error)
+	MultiSigSigningHash(walletAddress, txID string) ([]byte, error)
+	SetMultiSigThresholdKey(walletAddress, groupKey string, approvals map[string]string) error
+	SubmitMultiSigThresholdSignature(walletAddress, txID, signature string) error
+}
+
+// APIKeyService issues, authenticates and meters API keys
+type APIKeyService interface {
+	APIKeys() []blockchain.APIKey
+	GetAPIKey(id string) (blockchain.APIKey, bool)
+	CreateAPIKey(name string, scopes []string, rateLimit int, createdBy string) (string, blockchain.APIKey, error)
+	RevokeAPIKey(id string) (blockchain.APIKey, error)
+	AuthenticateAPIKey(secret string) (blockchain.APIKey, error)
+	RecordAPIKeyUsage(id string, outcome blockchain.APIKeyOutcome)
+}
+
+// ValidatorService manages the validator set and the admins who govern it
+type ValidatorService interface {
+	GetValidator(address string) (consensus.ValidatorInfo, bool)
+	GetValidators(statusFilter ...consensus.ValidatorStatus) []*consensus.ValidatorInfo
+	ApproveValidator(adminAddress, validatorAddress string) error
+	RejectValidator(validatorAddress, requesterAddress, reason string) error
+	SuspendValidator(requesterAddress, validatorAddress, reason string) error
+	SlashValidator(requesterAddress, validatorAddress string, amount *big.Int, reason string) (*blockchain.Transaction, error)
+	RequestExit(address string, timestamp int64, signature string) (*consensus.ValidatorInfo, error)
+	MinStake() *big.Int
+
+	SetCommission(address string, rate uint64, timestamp int64, signature string) (*blockchain.Transaction, error)
+	CommissionAt(address string, height uint64) uint64
+	CommissionConfig() consensus.CommissionConfig
+	CommissionHistory(address string) []consensus.CommissionChange
+
+	CheckEmergency() consensus.EmergencyStatus
+	EmergencyStatus() consensus.EmergencyStatus
+
+	AddAdmin(newAdminAddress string, callerAddress string) error
+	RemoveAdmin(adminToRemove string, callerAddress string) error
+	GetAdmins() []string
+	IsAdmin(address string) bool
+	VerifySignature(req *types.SignedRequest) (bool, error)
+}
+
+// GovernanceService manages governance proposals and votes
+type GovernanceService interface {
+	CreateProposal(creator string, proposalType consensus.ProposalType, title, description string, data map[string]string) (string, error)
+	CastVote(proposalID string, voter string, inFavor bool) error
+	GetProposal(proposalID string) (*consensus.Proposal, error)
+	ListProposals(statusFilter ...consensus.ProposalStatus) []*consensus.Proposal
+	ProposalVotes(proposalID string) ([]consensus.Vote, error)
+	Tally(proposalID string) (*consensus.ProposalTally, error)
+}
+
+// StakingService queues delegators' staking transactions, signed by sign,
+// and reads the delegations confirmed on chain
+type StakingService interface {
+	Delegate(delegator, validator string, amount *big.Int, sign func(*blockchain.Transaction) error) (*blockchain.Transaction, error)
+	Undelegate(delegator, validator string, amount *big.Int, sign func(*blockchain.Transaction) error) (*blockchain.Transaction, error)
+	ClaimRewards(delegator string, sign func(*blockchain.Transaction) error) (*blockchain.Transaction, error)
+	DelegatorStakes(delegator string) ([]consensus.Delegation, []consensus.Unbonding)
+	ValidatorDelegations(validator string) ([]consensus.Delegation, *big.Int)
+}
+
+// BlockchainService is everything the API uses from the blockchain itself.
+// *blockchain.Blockchain implements it; apitest.Blockchain is an in-memory
+// implementation for exercising the handlers without a node.
+type BlockchainService interface {
+	ChainService
+	WalletService
+	MultiSigService
+	APIKeyService
+}
+
+// Services are the dependencies of the API handlers. A nil Validators,
+// Governance or Staking leaves the routes that need them answering as
+// unavailable.
+type Services struct {
+	Chain      ChainService
+	Wallets    WalletService
+	MultiSig   MultiSigService
+	APIKeys    APIKeyService
+	Validators ValidatorService
+	Governance GovernanceService
+	Staking    StakingService
+}
+
+// BlockchainServices returns the services backed by a blockchain, validator
+// manager and governance system. vm and gov may be nil.
+func BlockchainServices(bc BlockchainService, vm *consensus.ValidatorManager, gov *consensus.Governance) Services {
+	services := Services{Chain: bc, Wallets: bc, MultiSig: bc, APIKeys: bc}
+	// Assign only non-nil pointers so the nil checks on the interfaces hold
+	if vm != nil {
+		services.Validators = vm
+	}
+	if gov != nil {
+		services.Governance = gov
+	}
+	return services
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/consensus"
+
+	"github.com/gorilla/mux"
+)
+
+// stakingRequest is the body of delegate and undelegate requests. The
+// delegator's wallet must be unlocked on this node: the request carries the
+// session token of the unlock as "Authorization: Bearer <token>", and the
+// node signs the staking transaction with the delegator's key.
+type stakingRequest struct {
+	Delegator string `json:"delegator"`
+	Validator string `json:"validator"`
+	Amount    string `json:"amount"`
+}
+
+// SetStaking attaches the staking module behind the /api/staking endpoints.
+// A nil module leaves them answering as unavailable.
+func (ws *WebServer) SetStaking(s *consensus.Staking) {
+	// Assign only a non-nil pointer so the nil checks on the interface hold
+	if s == nil {
+		ws.staking = nil
+		return
+	}
+	ws.staking = s
+}
+
+// decodeStakingRequest reads a delegate or undelegate request, writing an
+// error response and returning nil when it is malformed
+func (ws *WebServer) decodeStakingRequest(w http.ResponseWriter, r *http.Request) (*stakingRequest, *big.Int) {
+	if ws.staking == nil {
+		writeError(w, errors.New("staking is not enabled"), http.StatusServiceUnavailable)
+		return nil, nil
+	}
+	var req stakingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("invalid request body"), http.StatusBadRequest)
+		return nil, nil
+	}
+	if req.Delegator == "" || req.Validator == "" {
+		writeError(w, errors.New("delegator and validator are required"), http.StatusBadRequest)
+		return nil, nil
+	}
+	amount, ok := new(big.Int).SetString(req.Amount, 10)
+	if !ok || amount.Sign() <= 0 {
+		writeError(w, errors.New("amount must be a positive integer"), http.StatusBadRequest)
+		return nil, nil
+	}
+	return &req, amount
+}
+
+// signStaking returns the signer of staking transactions for r, which must
+// hold a session of the delegator's unlock
+func (ws *WebServer) signStaking(r *http.Request) func(*blockchain.Transaction) error {
+	return func(tx *blockchain.Transaction) error {
+		return ws.signWithSession(r, tx)
+	}
+}
+
+// writeStakingTransaction answers a queued staking transaction; it takes
+// effect once confirmed
+func writeStakingTransaction(w http.ResponseWriter, tx *blockchain.Transaction) {
+	request, _, err := blockchain.ParseStakingRequest(tx)
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"status": "pending",
+		"txId":   tx.ID,
+		"nonce":  request.Nonce,
+	})
+}
+
+// delegate handles POST /api/staking/delegate
+func (ws *WebServer) delegate(w http.ResponseWriter, r *http.Request) {
+	req, amount := ws.decodeStakingRequest(w, r)
+	if req == nil {
+		return
+	}
+
+	tx, err := ws.staking.Delegate(req.Delegator, req.Validator, amount, ws.signStaking(r))
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeStakingTransaction(w, tx)
+}
+
+// undelegate handles POST /api/staking/undelegate
+func (ws *WebServer) undelegate(w http.ResponseWriter, r *http.Request) {
+	req, amount := ws.decodeStakingRequest(w, r)
+	if req == nil {
+		return
+	}
+
+	tx, err := ws.staking.Undelegate(req.Delegator, req.Validator, amount, ws.signStaking(r))
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeStakingTransaction(w, tx)
+}
+
+// claimStakingRewards handles POST /api/staking/claim.
+// Body: {"delegator": "..."}, sent with the session token of an unlock of the
+// delegator's wallet like delegations.
+func (ws *WebServer) claimStakingRewards(w http.ResponseWriter, r *http.Request) {
+	if ws.staking == nil {
+		writeError(w, errors.New("staking is not enabled"), http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		Delegator string `json:"delegator"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("invalid request body"), http.StatusBadRequest)
+		return
+	}
+	if req.Delegator == "" {
+		writeError(w, errors.New("delegator is required"), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := ws.staking.ClaimRewards(req.Delegator, ws.signStaking(r))
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeStakingTransaction(w, tx)
+}
+
+// getStakingRewards handles GET /api/staking/rewards/{address}: the
+// delegations of an address with their unclaimed rewards, and its unbondings
+func (ws *WebServer) getStakingRewards(w http.ResponseWriter, r *http.Request) {
+	if ws.staking == nil {
+		writeError(w, errors.New("staking is not enabled"), http.StatusServiceUnavailable)
+		return
+	}
+	address := mux.Vars(r)["address"]
+	delegations, unbondings := ws.staking.DelegatorStakes(address)
+
+	bonded, rewards := big.NewInt(0), big.NewInt(0)
+	views := make([]map[string]string, 0, len(delegations))
+	for _, delegation := range delegations {
+		bonded.Add(bonded, delegation.Amount)
+		rewards.Add(rewards, delegation.Rewards)
+		views = append(views, delegationView(delegation))
+	}
+	unbondingViews := make([]map[string]interface{}, 0, len(unbondings))
+	for _, unbonding := range unbondings {
+		unbondingViews = append(unbondingViews, unbondingView(unbonding))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"delegator":   address,
+		"bonded":      bonded.String(),
+		"rewards":     rewards.String(),
+		"delegations": views,
+		"unbondings":  unbondingViews,
+	})
+}
+
+// getValidatorDelegations handles GET /api/staking/validators/{address}
+func (ws *WebServer) getValidatorDelegations(w http.ResponseWriter, r *http.Request) {
+	if ws.staking == nil {
+		writeError(w, errors.New("staking is not enabled"), http.StatusServiceUnavailable)
+		return
+	}
+	address := mux.Vars(r)["address"]
+	delegations, commission := ws.staking.ValidatorDelegations(address)
+
+	bonded := big.NewInt(0)
+	views := make([]map[string]string, 0, len(delegations))
+	for _, delegation := range delegations {
+		bonded.Add(bonded, delegation.Amount)
+		views = append(views, delegationView(delegation))
+	}
+
+	response := map[string]interface{}{
+		"validator":        address,
+		"bonded":           bonded.String(),
+		"commissionEarned": commission.String(),
+		"delegations":      views,
+	}
+	if ws.validatorManager != nil {
+		response["commissionRate"] = ws.validatorManager.CommissionAt(address, ws.blockchain.GetChainHeight())
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// delegationView renders the amounts of a delegation as decimal strings
+func delegationView(d consensus.Delegation) map[string]string {
+	return map[string]string{
+		"delegator": d.Delegator,
+		"validator": d.Validator,
+		"amount":    d.Amount.String(),
+		"rewards":   d.Rewards.String(),
+		"claimed":   d.Claimed.String(),
+	}
+}
+
+// unbondingView renders an unbonding with its amount as a decimal string
+func unbondingView(u consensus.Unbonding) map[string]interface{} {
+	return map[string]interface{}{
+		"delegator":        u.Delegator,
+		"validator":        u.Validator,
+		"amount":           u.Amount.String(),
+		"createdHeight":    u.CreatedHeight,
+		"completionHeight": u.CompletionHeight,
+	}
+}
@@ -0,0 +1,196 @@
+package api_test
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"confirmix/pkg/api"
+	"confirmix/pkg/api/apitest"
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/consensus"
+)
+
+// stubStaking queues staking transactions in the fake blockchain the way the
+// staking module does, numbering them per delegator
+type stubStaking struct {
+	fake   *apitest.Blockchain
+	mu     sync.Mutex
+	nonces map[string]uint64
+}
+
+func newStubStaking(fake *apitest.Blockchain) *stubStaking {
+	return &stubStaking{fake: fake, nonces: make(map[string]uint64)}
+}
+
+func (s *stubStaking) submit(txType, delegator string, request blockchain.StakingRequest, sign func(*blockchain.Transaction) error) (*blockchain.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	request.Nonce = s.nonces[delegator] + 1
+	tx, err := blockchain.NewStakingTransaction(txType, delegator, request)
+	if err != nil {
+		return nil, err
+	}
+	if err := sign(tx); err != nil {
+		return nil, err
+	}
+	if err := s.fake.AddTransaction(tx); err != nil {
+		return nil, err
+	}
+	s.nonces[delegator] = request.Nonce
+	return tx, nil
+}
+
+func (s *stubStaking) Delegate(delegator, validator string, amount *big.Int, sign func(*blockchain.Transaction) error) (*blockchain.Transaction, error) {
+	return s.submit(blockchain.StakingDelegateTxType, delegator, blockchain.StakingRequest{Validator: validator, Amount: amount.String()}, sign)
+}
+
+func (s *stubStaking) Undelegate(delegator, validator string, amount *big.Int, sign func(*blockchain.Transaction) error) (*blockchain.Transaction, error) {
+	return s.submit(blockchain.StakingUndelegateTxType, delegator, blockchain.StakingRequest{Validator: validator, Amount: amount.String()}, sign)
+}
+
+func (s *stubStaking) ClaimRewards(delegator string, sign func(*blockchain.Transaction) error) (*blockchain.Transaction, error) {
+	return s.submit(blockchain.StakingClaimTxType, delegator, blockchain.StakingRequest{}, sign)
+}
+
+func (s *stubStaking) DelegatorStakes(delegator string) ([]consensus.Delegation, []consensus.Unbonding) {
+	return []consensus.Delegation{}, []consensus.Unbonding{}
+}
+
+func (s *stubStaking) ValidatorDelegations(validator string) ([]consensus.Delegation, *big.Int) {
+	return []consensus.Delegation{}, big.NewInt(0)
+}
+
+// queuedStaking checks that the pool holds exactly one staking transaction,
+// of txType, signed and numbered with nonce 1
+func queuedStaking(txType string) func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+	return func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+		var resp struct {
+			Status string `json:"status"`
+			TxID   string `json:"txId"`
+			Nonce  uint64 `json:"nonce"`
+		}
+		decode(t, rec, &resp)
+		if resp.Status != "pending" || resp.Nonce != 1 {
+			t.Fatalf("response %+v, want a pending transaction with nonce 1", resp)
+		}
+		pending := env.fake.GetPendingTransactions()
+		if len(pending) != 1 || pending[0].ID != resp.TxID || pending[0].Type != txType {
+			t.Fatalf("pending transactions %v, want only the %s transaction %s", pending, txType, resp.TxID)
+		}
+		if len(pending[0].Signature) == 0 {
+			t.Fatalf("staking transaction %s was not signed", resp.TxID)
+		}
+	}
+}
+
+// nothingQueued checks that a refused staking request left the pool empty
+func nothingQueued(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+	if pending := env.fake.GetPendingTransactions(); len(pending) != 0 {
+		t.Fatalf("refused staking request reached the pool: %v", pending)
+	}
+}
+
+func TestStakingHandlers(t *testing.T) {
+	runHandlerCases(t, []handlerCase{
+		{
+			name:   "delegate with an unlocked wallet",
+			setup:  unlocked("alice", 100),
+			method: "POST", path: "/api/staking/delegate",
+			body:   `{"delegator": "alice", "validator": "val-1", "amount": "40"}`,
+			status: http.StatusAccepted,
+			check:  queuedStaking(blockchain.StakingDelegateTxType),
+		},
+		{
+			name:   "delegate without a wallet session",
+			setup:  fund("alice", 100),
+			method: "POST", path: "/api/staking/delegate",
+			body:   `{"delegator": "alice", "validator": "val-1", "amount": "40"}`,
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+			check: nothingQueued,
+		},
+		{
+			name: "delegate with the session of another wallet",
+			setup: func(t *testing.T, env *testEnv) {
+				unlocked("alice", 100)(t, env)
+				fund("carol", 100)(t, env)
+			},
+			method: "POST", path: "/api/staking/delegate",
+			body:   `{"delegator": "carol", "validator": "val-1", "amount": "40"}`,
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+			check: nothingQueued,
+		},
+		{
+			name:   "delegate a negative amount",
+			setup:  unlocked("alice", 100),
+			method: "POST", path: "/api/staking/delegate",
+			body:   `{"delegator": "alice", "validator": "val-1", "amount": "-5"}`,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+			check: nothingQueued,
+		},
+		{
+			name:   "delegate without a validator",
+			setup:  unlocked("alice", 100),
+			method: "POST", path: "/api/staking/delegate",
+			body:   `{"delegator": "alice", "amount": "40"}`,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "undelegate with an unlocked wallet",
+			setup:  unlocked("alice", 100),
+			method: "POST", path: "/api/staking/undelegate",
+			body:   `{"delegator": "alice", "validator": "val-1", "amount": "10"}`,
+			status: http.StatusAccepted,
+			check:  queuedStaking(blockchain.StakingUndelegateTxType),
+		},
+		{
+			name:   "undelegate without a wallet session",
+			setup:  fund("alice", 100),
+			method: "POST", path: "/api/staking/undelegate",
+			body:   `{"delegator": "alice", "validator": "val-1", "amount": "10"}`,
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+			check: nothingQueued,
+		},
+		{
+			name:   "claim with an unlocked wallet",
+			setup:  unlocked("alice", 100),
+			method: "POST", path: "/api/staking/claim",
+			body:   `{"delegator": "alice"}`,
+			status: http.StatusAccepted,
+			check:  queuedStaking(blockchain.StakingClaimTxType),
+		},
+		{
+			name:   "claim without a wallet session",
+			setup:  fund("alice", 100),
+			method: "POST", path: "/api/staking/claim",
+			body:   `{"delegator": "alice"}`,
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+			check: nothingQueued,
+		},
+		{
+			name:   "claim without a delegator",
+			method: "POST", path: "/api/staking/claim",
+			body:   `{}`,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "rewards",
+			method: "GET", path: "/api/staking/rewards/alice",
+			status: http.StatusOK,
+		},
+		{
+			name:   "delegations of a validator",
+			method: "GET", path: "/api/staking/validators/val-1",
+			status: http.StatusOK,
+		},
+		{
+			name: "delegate without staking", bare: true,
+			setup:  unlocked("alice", 100),
+			method: "POST", path: "/api/staking/delegate",
+			body:   `{"delegator": "alice", "validator": "val-1", "amount": "40"}`,
+			status: http.StatusServiceUnavailable,
+		},
+	})
+}
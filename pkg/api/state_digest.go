@@ -0,0 +1,11 @@
+package api
+
+import (
+	"net/http"
+)
+
+// getStateDigest handles GET /api/state/digest, returning the height, tip hash
+// and state hash that nodes compare to detect divergence
+func (ws *WebServer) getStateDigest(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ws.blockchain.StateDigest())
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// parseStatementTime accepts unix seconds, RFC3339 or a YYYY-MM-DD date.
+// For dates, endOfDay selects 23:59:59 so that "to=2024-01-31" includes the whole day.
+func parseStatementTime(value string, endOfDay bool) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return ts, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.Unix(), nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		if endOfDay {
+			t = t.Add(24*time.Hour - time.Second)
+		}
+		return t.Unix(), nil
+	}
+
+	return 0, fmt.Errorf("invalid time %q: use unix seconds, RFC3339 or YYYY-MM-DD", value)
+}
+
+// getAccountStatement handles GET /api/address/{address}/statement?from=&to=&format=json|csv
+func (ws *WebServer) getAccountStatement(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	query := r.URL.Query()
+
+	from, err := parseStatementTime(query.Get("from"), false)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	to, err := parseStatementTime(query.Get("to"), true)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	statement, err := ws.wallets.GetAccountStatement(address, from, to)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	switch strings.ToLower(query.Get("format")) {
+	case "", "json":
+		statement.Label = ws.addressLabel(address)
+		writeJSON(w, http.StatusOK, statement)
+	case "csv":
+		filename := fmt.Sprintf("statement_%s_%d_%d.csv", address, from, to)
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.WriteHeader(http.StatusOK)
+
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"date", "block_index", "block_hash", "tx_id", "type", "counterparty", "credit", "debit", "balance"})
+		cw.Write([]string{formatStatementTime(from), "", "", "", "opening_balance", "", "", "", statement.OpeningBalance})
+		for _, e := range statement.Entries {
+			cw.Write([]string{
+				formatStatementTime(e.Timestamp),
+				strconv.FormatUint(e.BlockIndex, 10),
+				csvCell(e.BlockHash),
+				csvCell(e.TxID),
+				csvCell(e.Type),
+				csvCell(e.Counterparty),
+				e.Credit,
+				e.Debit,
+				e.Balance,
+			})
+		}
+		closingTime := to
+		if closingTime == 0 {
+			closingTime = time.Now().Unix()
+		}
+		cw.Write([]string{formatStatementTime(closingTime), "", "", "", "closing_balance", "", statement.TotalCredits, statement.TotalDebits, statement.ClosingBalance})
+		cw.Flush()
+	default:
+		writeError(w, fmt.Errorf("unsupported format %q: use json or csv", query.Get("format")), http.StatusBadRequest)
+	}
+}
+
+// csvCell neutralizes a field a spreadsheet would evaluate as a formula by
+// prefixing it with a quote
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// formatStatementTime renders a unix timestamp as an RFC3339 UTC string for CSV output
+func formatStatementTime(ts int64) string {
+	return time.Unix(ts, 0).UTC().Format(time.RFC3339)
+}
+
+// getSpendByCategory handles GET /api/address/{address}/spending?from=&to=,
+// the outgoing transfers of an address totalled per month and category tag
+func (ws *WebServer) getSpendByCategory(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	query := r.URL.Query()
+
+	from, err := parseStatementTime(query.Get("from"), false)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	to, err := parseStatementTime(query.Get("to"), true)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	report, err := ws.wallets.SpendByCategory(address, from, to)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
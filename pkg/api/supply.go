@@ -0,0 +1,11 @@
+package api
+
+import (
+	"net/http"
+)
+
+// getSupply handles GET /api/supply, returning the issued supply, the max
+// supply of the emission schedule and what is left to issue under it
+func (ws *WebServer) getSupply(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ws.blockchain.Supply())
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"confirmix/pkg/blockchain"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// minTxSearchPrefix is the shortest partial ID or hash accepted for a search
+	minTxSearchPrefix = 6
+	// maxTxSearchMatches caps the candidates returned for an ambiguous partial ID
+	maxTxSearchMatches = 20
+	// maxBulkStatusIDs caps the IDs accepted by the bulk status endpoint
+	maxBulkStatusIDs = 100
+)
+
+// getTransaction handles GET /api/transactions/{id}.
+// An exact ID is looked up in the mempool and the chain; otherwise the value is
+// treated as a partial ID or hash. A unique match is returned as the transaction,
+// several matches are returned as a list with status 300.
+// Confirmed transactions carry an ETag and long-lived caching headers.
+func (ws *WebServer) getTransaction(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	tx, err := ws.blockchain.FindTransaction(id)
+	if err == nil {
+		if ws.setTransactionCacheHeaders(w, r, tx, mediaJSON) {
+			return
+		}
+		writeLightJSON(w, r, http.StatusOK, tx)
+		return
+	}
+
+	// Partial matches depend on what is in the mempool and chain right now
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if len(id) < minTxSearchPrefix {
+		writeError(w, fmt.Errorf("%w: %s", blockchain.ErrTxNotFound, id), http.StatusNotFound)
+		return
+	}
+
+	matches := ws.blockchain.SearchTransactions(id, maxTxSearchMatches)
+	switch len(matches) {
+	case 0:
+		writeError(w, fmt.Errorf("%w: %s", blockchain.ErrTxNotFound, id), http.StatusNotFound)
+	case 1:
+		writeLightJSON(w, r, http.StatusOK, matches[0])
+	default:
+		writeLightJSON(w, r, http.StatusMultipleChoices, map[string]interface{}{
+			"matches": matches,
+		})
+	}
+}
+
+// getRejectedTransactions handles GET /api/transactions/rejected, listing
+// transactions the block builder dropped with the reason, newest first.
+// Query: address (sender or recipient), id, limit (default 100).
+func (ws *WebServer) getRejectedTransactions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if id := query.Get("id"); id != "" {
+		rejected, exists := ws.blockchain.GetRejectedTransaction(id)
+		if !exists {
+			writeError(w, fmt.Errorf("%w: %s was not rejected", blockchain.ErrTxNotFound, id), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, rejected)
+		return
+	}
+
+	limit := 100
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			writeError(w, errors.New("limit must be a positive integer"), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	rejected := ws.blockchain.RejectedTransactions(query.Get("address"), limit)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"transactions": rejected,
+		"count":        len(rejected),
+	})
+}
+
+// getTransactionStatuses handles POST /api/transactions/status with {"ids": [...]}
+// and returns the status of up to 100 transactions in request order
+func (ws *WebServer) getTransactionStatuses(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs []string `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("invalid request body"), http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) == 0 {
+		writeError(w, errors.New("ids is required"), http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) > maxBulkStatusIDs {
+		writeError(w, fmt.Errorf("at most %d ids can be looked up per request", maxBulkStatusIDs), http.StatusBadRequest)
+		return
+	}
+
+	writeLightJSON(w, r, http.StatusOK, map[string]interface{}{
+		"statuses": ws.blockchain.GetTransactionStatuses(req.IDs),
+	})
+}
+
+// getMempoolLanes handles GET /api/transactions/lanes, reporting pending
+// transactions per priority lane and the block capacity they compete for
+func (ws *WebServer) getMempoolLanes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"pending":              ws.blockchain.PendingByLane(),
+		"maxBlockTransactions": ws.blockchain.MaxBlockTransactions(),
+	})
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"confirmix/pkg/units"
+)
+
+// amountKeys are the JSON keys, lowercased, that carry amounts of base
+// units. The API sends their values as decimal strings: as JSON numbers,
+// amounts above 2^53 lose precision in JavaScript clients.
+var amountKeys = map[string]bool{
+	"value":         true,
+	"amount":        true,
+	"balance":       true,
+	"reward":        true,
+	"paid":          true,
+	"fees":          true,
+	"totalfees":     true,
+	"mintransfer":   true,
+	"minbalance":    true,
+	"transfervalue": true,
+}
+
+// withStringAmounts returns v with the numeric values of amount keys turned
+// into decimal strings, at any depth. v is returned unchanged when it does
+// not encode to JSON.
+func withStringAmounts(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return v
+	}
+	return stringifyAmounts(generic)
+}
+
+// stringifyAmounts walks a decoded JSON value, see withStringAmounts
+func stringifyAmounts(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if number, ok := item.(json.Number); ok && amountKeys[strings.ToLower(key)] {
+				value[key] = number.String()
+				continue
+			}
+			value[key] = stringifyAmounts(item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = stringifyAmounts(item)
+		}
+	}
+	return v
+}
+
+// unitsResponse describes one amount in base units and in ConX
+type unitsResponse struct {
+	Amount    string `json:"amount"`    // base units
+	Formatted string `json:"formatted"` // ConX, e.g. "1.5"
+	Display   string `json:"display"`   // ConX with the symbol, e.g. "1.5 ConX"
+	Symbol    string `json:"symbol"`
+	Decimals  int    `json:"decimals"`
+}
+
+// newUnitsResponse describes amount, formatted with up to decimals fractional digits
+func newUnitsResponse(amount *big.Int, decimals int) unitsResponse {
+	formatted := units.Format(amount, decimals)
+	return unitsResponse{
+		Amount:    amount.String(),
+		Formatted: formatted,
+		Display:   formatted + " " + units.Symbol,
+		Symbol:    units.Symbol,
+		Decimals:  units.Decimals,
+	}
+}
+
+// parseDisplayDecimals reads the optional ?decimals= precision of formatted amounts
+func parseDisplayDecimals(r *http.Request) (int, error) {
+	raw := r.URL.Query().Get("decimals")
+	if raw == "" {
+		return units.Decimals, nil
+	}
+	decimals, err := strconv.Atoi(raw)
+	if err != nil || decimals < 0 || decimals > units.Decimals {
+		return 0, errors.New("decimals must be between 0 and 18")
+	}
+	return decimals, nil
+}
+
+// formatUnits handles GET /api/utils/format?amount=<base units>&decimals=,
+// converting base units to a ConX amount
+func (ws *WebServer) formatUnits(w http.ResponseWriter, r *http.Request) {
+	amount, err := units.ParseBaseUnits(r.URL.Query().Get("amount"))
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	decimals, err := parseDisplayDecimals(r)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, newUnitsResponse(amount, decimals))
+}
+
+// parseUnits handles GET /api/utils/parse?value=<ConX>, converting a ConX
+// amount such as "1.5" or "1.5 ConX" to base units
+func (ws *WebServer) parseUnits(w http.ResponseWriter, r *http.Request) {
+	amount, err := units.Parse(r.URL.Query().Get("value"))
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, newUnitsResponse(amount, units.Decimals))
+}
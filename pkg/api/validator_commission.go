@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// setValidatorCommission handles POST /api/validators/commission.
+// Body: {"address": "...", "rate": 500, "timestamp": 0, "signature": "..."} where rate is in
+// basis points and signature is the hex ASN.1 signature of
+// "validator_commission:<address>:<rate>:<timestamp>" by the validator key.
+func (ws *WebServer) setValidatorCommission(w http.ResponseWriter, r *http.Request) {
+	if ws.validatorManager == nil {
+		writeError(w, errNoValidatorManager, http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		Address   string `json:"address"`
+		Rate      uint64 `json:"rate"`
+		Timestamp int64  `json:"timestamp"`
+		Signature string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("invalid request body"), http.StatusBadRequest)
+		return
+	}
+	if req.Address == "" || req.Signature == "" {
+		writeError(w, errors.New("address and signature are required"), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := ws.validatorManager.SetCommission(req.Address, req.Rate, req.Timestamp, req.Signature)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	// The change is scheduled once the transaction is confirmed
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"status":      "pending",
+		"address":     req.Address,
+		"txId":        tx.ID,
+		"rate":        req.Rate,
+		"requestedAt": req.Timestamp,
+	})
+}
+
+// getValidatorCommission handles GET /api/validators/commission/{address}:
+// the commission in effect, an announced change and the full history
+func (ws *WebServer) getValidatorCommission(w http.ResponseWriter, r *http.Request) {
+	if ws.validatorManager == nil {
+		writeError(w, errNoValidatorManager, http.StatusServiceUnavailable)
+		return
+	}
+	address := mux.Vars(r)["address"]
+	height := ws.blockchain.GetChainHeight()
+	history := ws.validatorManager.CommissionHistory(address)
+	config := ws.validatorManager.CommissionConfig()
+
+	response := map[string]interface{}{
+		"address":      address,
+		"height":       height,
+		"rate":         ws.validatorManager.CommissionAt(address, height),
+		"maxRate":      config.MaxRate,
+		"noticeEpochs": config.NoticeEpochs,
+		"history":      history,
+	}
+	if n := len(history); n > 0 && history[n-1].EffectiveHeight > height {
+		response["pending"] = history[n-1]
+	}
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,15 @@
+package api
+
+import (
+	"net/http"
+)
+
+// getValidatorEmergency handles GET /api/validators/emergency, reporting
+// whether the active validator set is below the configured minimum
+func (ws *WebServer) getValidatorEmergency(w http.ResponseWriter, r *http.Request) {
+	if ws.validatorManager == nil {
+		writeError(w, errNoValidatorManager, http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, http.StatusOK, ws.validatorManager.CheckEmergency())
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// requestValidatorExit handles POST /api/validators/exit.
+// Body: {"address": "...", "timestamp": 0, "signature": "..."} where signature is the
+// hex ASN.1 signature of "validator_exit:<address>:<timestamp>" by the validator key.
+func (ws *WebServer) requestValidatorExit(w http.ResponseWriter, r *http.Request) {
+	if ws.validatorManager == nil {
+		writeError(w, errNoValidatorManager, http.StatusServiceUnavailable)
+		return
+	}
+	var req struct {
+		Address   string `json:"address"`
+		Timestamp int64  `json:"timestamp"`
+		Signature string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("invalid request body"), http.StatusBadRequest)
+		return
+	}
+	if req.Address == "" || req.Signature == "" {
+		writeError(w, errors.New("address and signature are required"), http.StatusBadRequest)
+		return
+	}
+
+	validator, err := ws.validatorManager.RequestExit(req.Address, req.Timestamp, req.Signature)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"status":          string(validator.Status),
+		"address":         validator.Address,
+		"exitEpoch":       validator.ExitEpoch,
+		"exitRequestedAt": validator.ExitRequestedAt.Unix(),
+	})
+}
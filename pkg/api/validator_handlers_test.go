@@ -0,0 +1,596 @@
+package api_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"confirmix/pkg/api"
+	"confirmix/pkg/api/apitest"
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/consensus"
+	"confirmix/pkg/types"
+)
+
+// stubValidators is a validator manager over the fake blockchain. Admin
+// signatures are checked against the keys the fake holds, like the real
+// manager does. Methods the tests do not use are left to the embedded nil
+// interface and panic.
+type stubValidators struct {
+	api.ValidatorService
+
+	fake *apitest.Blockchain
+
+	mu         sync.Mutex
+	admins     map[string]bool
+	validators map[string]*consensus.ValidatorInfo
+}
+
+func newStubValidators(fake *apitest.Blockchain, admins ...string) *stubValidators {
+	s := &stubValidators{
+		fake:       fake,
+		admins:     make(map[string]bool),
+		validators: make(map[string]*consensus.ValidatorInfo),
+	}
+	for _, admin := range admins {
+		s.admins[admin] = true
+	}
+	return s
+}
+
+// addPending adds a validator awaiting approval
+func (s *stubValidators) addPending(address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.validators[address] = &consensus.ValidatorInfo{
+		Address:  address,
+		Status:   consensus.StatusPending,
+		JoinedAt: time.Now(),
+		Stake:    big.NewInt(1000),
+	}
+}
+
+func (s *stubValidators) GetValidator(address string) (consensus.ValidatorInfo, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, exists := s.validators[address]
+	if !exists {
+		return consensus.ValidatorInfo{}, false
+	}
+	return *info, true
+}
+
+func (s *stubValidators) GetValidators(statusFilter ...consensus.ValidatorStatus) []*consensus.ValidatorInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []*consensus.ValidatorInfo
+	for _, info := range s.validators {
+		copied := *info
+		result = append(result, &copied)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Address < result[j].Address })
+	return result
+}
+
+func (s *stubValidators) ApproveValidator(adminAddress, validatorAddress string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.admins[adminAddress] {
+		return fmt.Errorf("%s is not an admin", adminAddress)
+	}
+	info, exists := s.validators[validatorAddress]
+	if !exists {
+		return fmt.Errorf("%w: %s", blockchain.ErrUnknownValidator, validatorAddress)
+	}
+	info.Status = consensus.StatusApproved
+	info.ApprovedBy = adminAddress
+	return nil
+}
+
+func (s *stubValidators) MinStake() *big.Int {
+	return big.NewInt(1000)
+}
+
+func (s *stubValidators) CommissionAt(address string, height uint64) uint64 {
+	return 500
+}
+
+func (s *stubValidators) CommissionConfig() consensus.CommissionConfig {
+	return *consensus.DefaultCommissionConfig()
+}
+
+func (s *stubValidators) CommissionHistory(address string) []consensus.CommissionChange {
+	return []consensus.CommissionChange{{Rate: 500}}
+}
+
+func (s *stubValidators) CheckEmergency() consensus.EmergencyStatus {
+	return s.EmergencyStatus()
+}
+
+func (s *stubValidators) EmergencyStatus() consensus.EmergencyStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	active := 0
+	for _, info := range s.validators {
+		if info.Status == consensus.StatusApproved {
+			active++
+		}
+	}
+	return consensus.EmergencyStatus{Active: active < 1, ActiveValidators: active, MinValidators: 1}
+}
+
+func (s *stubValidators) AddAdmin(newAdminAddress string, callerAddress string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.admins[callerAddress] {
+		return fmt.Errorf("%s is not an admin", callerAddress)
+	}
+	s.admins[newAdminAddress] = true
+	return nil
+}
+
+func (s *stubValidators) RemoveAdmin(adminToRemove string, callerAddress string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.admins[callerAddress] {
+		return fmt.Errorf("%s is not an admin", callerAddress)
+	}
+	delete(s.admins, adminToRemove)
+	return nil
+}
+
+func (s *stubValidators) GetAdmins() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	admins := make([]string, 0, len(s.admins))
+	for admin := range s.admins {
+		admins = append(admins, admin)
+	}
+	sort.Strings(admins)
+	return admins
+}
+
+func (s *stubValidators) IsAdmin(address string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.admins[address]
+}
+
+func (s *stubValidators) VerifySignature(req *types.SignedRequest) (bool, error) {
+	keyPair, exists := s.fake.GetKeyPair(req.AdminAddress)
+	if !exists {
+		return false, fmt.Errorf("admin key pair not found")
+	}
+	signature, err := hex.DecodeString(req.Signature)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode signature: %v", err)
+	}
+	digest := sha256.Sum256([]byte(req.SigningMessage()))
+	return keyPair.Public().Verify(digest[:], signature), nil
+}
+
+// stubGovernance keeps proposals in memory. Methods the tests do not use
+// are left to the embedded nil interface and panic.
+type stubGovernance struct {
+	api.GovernanceService
+
+	mu        sync.Mutex
+	proposals map[string]*consensus.Proposal
+}
+
+func newStubGovernance() *stubGovernance {
+	return &stubGovernance{proposals: make(map[string]*consensus.Proposal)}
+}
+
+func (g *stubGovernance) CreateProposal(creator string, proposalType consensus.ProposalType, title, description string, data map[string]string) (string, error) {
+	if creator == "" || title == "" {
+		return "", fmt.Errorf("a proposal needs a creator and a title")
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	id := fmt.Sprintf("proposal-%d", len(g.proposals)+1)
+	g.proposals[id] = &consensus.Proposal{
+		ID:       id,
+		Type:     proposalType,
+		Title:    title,
+		Creator:  creator,
+		Status:   consensus.ProposalStatusPending,
+		Data:     data,
+		Votes:    make(map[string]*consensus.Vote),
+		YesVotes: big.NewInt(0),
+		NoVotes:  big.NewInt(0),
+	}
+	return id, nil
+}
+
+func (g *stubGovernance) CastVote(proposalID string, voter string, inFavor bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	proposal, exists := g.proposals[proposalID]
+	if !exists {
+		return fmt.Errorf("%w: %s", consensus.ErrProposalNotFound, proposalID)
+	}
+	if inFavor {
+		proposal.YesVotes.Add(proposal.YesVotes, big.NewInt(1))
+	} else {
+		proposal.NoVotes.Add(proposal.NoVotes, big.NewInt(1))
+	}
+	return nil
+}
+
+func (g *stubGovernance) GetProposal(proposalID string) (*consensus.Proposal, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	proposal, exists := g.proposals[proposalID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", consensus.ErrProposalNotFound, proposalID)
+	}
+	return proposal, nil
+}
+
+func (g *stubGovernance) ListProposals(statusFilter ...consensus.ProposalStatus) []*consensus.Proposal {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var result []*consensus.Proposal
+	for _, proposal := range g.proposals {
+		if len(statusFilter) == 0 || proposal.Status == statusFilter[0] {
+			result = append(result, proposal)
+		}
+	}
+	return result
+}
+
+func TestValidatorHandlers(t *testing.T) {
+	pending := func(t *testing.T, env *testEnv) { env.validators.addPending("val-1") }
+
+	runHandlerCases(t, []handlerCase{
+		{
+			name:   "status of a registered validator",
+			setup:  pending,
+			method: "GET", path: "/api/validators/status/val-1",
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				var report api.ValidatorStatusReport
+				decode(t, rec, &report)
+				if !report.Registered || report.Status != string(consensus.StatusPending) {
+					t.Fatalf("report %+v, want a registered pending validator", report)
+				}
+			},
+		},
+		{
+			name:   "status of an unknown address",
+			method: "GET", path: "/api/validators/status/val-1",
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				var report api.ValidatorStatusReport
+				decode(t, rec, &report)
+				if report.Registered || report.Status != "unregistered" {
+					t.Fatalf("report %+v, want an unregistered address", report)
+				}
+			},
+		},
+		{
+			name:   "stakes",
+			setup:  pending,
+			method: "GET", path: "/api/validators/stakes",
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				var stakes struct {
+					MinStake   string               `json:"minStake"`
+					Validators []api.ValidatorStake `json:"validators"`
+				}
+				decode(t, rec, &stakes)
+				if stakes.MinStake != "1000" || len(stakes.Validators) != 1 || stakes.Validators[0].Remaining != "1000" {
+					t.Fatalf("stakes %+v, want val-1 with its full stake of 1000", stakes)
+				}
+			},
+		},
+		{
+			name:   "commission",
+			method: "GET", path: "/api/validators/commission/val-1",
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				if !strings.Contains(rec.Body.String(), `"rate":500`) {
+					t.Fatalf("commission %s, want a rate of 500", rec.Body)
+				}
+			},
+		},
+		{
+			name:   "human proof attestation of an unknown address",
+			method: "GET", path: "/api/validators/proofs/val-1/attestation",
+			status: http.StatusNotFound, code: api.CodeNotFound,
+		},
+		{
+			name:   "human proof registration without a signature",
+			method: "POST", path: "/api/validators/proofs",
+			body:   `{"registration": {"address": "val-1", "proof": "p", "attester": "val-2", "attestation": "00"}}`,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "set commission without a signature",
+			method: "POST", path: "/api/validators/commission",
+			body:   `{"address": "val-1", "rate": 500}`,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "exit without a signature",
+			method: "POST", path: "/api/validators/exit",
+			body:   `{"address": "val-1"}`,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "emergency with no active validator",
+			setup:  pending,
+			method: "GET", path: "/api/validators/emergency",
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				var status consensus.EmergencyStatus
+				decode(t, rec, &status)
+				if !status.Active {
+					t.Fatalf("emergency %+v, want active", status)
+				}
+			},
+		},
+		{
+			name:   "approve",
+			setup:  pending,
+			method: "POST", path: "/api/validators/approve",
+			signed: &types.SignedRequest{Action: "approve_validator", Data: map[string]string{"address": "val-1"}},
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				if info, _ := env.validators.GetValidator("val-1"); info.Status != consensus.StatusApproved {
+					t.Fatalf("val-1 is %s, want approved", info.Status)
+				}
+			},
+		},
+		{
+			name:   "approve an unknown validator",
+			method: "POST", path: "/api/validators/approve",
+			signed: &types.SignedRequest{Action: "approve_validator", Data: map[string]string{"address": "val-1"}},
+			status: http.StatusNotFound, code: api.CodeUnknownValidator,
+		},
+		{
+			name:   "approve without a validator address",
+			method: "POST", path: "/api/validators/approve",
+			signed: &types.SignedRequest{Action: "approve_validator"},
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "approve with data changed after signing",
+			setup:  pending,
+			method: "POST", path: "/api/validators/approve",
+			signed: &types.SignedRequest{Action: "approve_validator", Data: map[string]string{"address": "val-2"}},
+			tamper: func(req *types.SignedRequest) { req.Data["address"] = "val-1" },
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				if info, _ := env.validators.GetValidator("val-1"); info.Status != consensus.StatusPending {
+					t.Fatalf("val-1 is %s after a forged approval", info.Status)
+				}
+			},
+		},
+	})
+}
+
+func TestAdminHandlers(t *testing.T) {
+	runHandlerCases(t, []handlerCase{
+		{
+			name:   "list admins",
+			method: "GET", path: "/api/admin/list",
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				var list struct {
+					Admins []string `json:"admins"`
+				}
+				decode(t, rec, &list)
+				if len(list.Admins) != 1 || list.Admins[0] != adminAddress {
+					t.Fatalf("admins %v, want only %s", list.Admins, adminAddress)
+				}
+			},
+		},
+		{
+			name:   "add admin",
+			method: "POST", path: "/api/admin/add",
+			signed: &types.SignedRequest{Action: "add_admin", Data: map[string]string{"address": "admin-2"}},
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				if !env.validators.IsAdmin("admin-2") {
+					t.Fatalf("admin-2 was not added")
+				}
+			},
+		},
+		{
+			name:   "add admin with a malformed body",
+			method: "POST", path: "/api/admin/add",
+			body:   `[]`,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "add admin without an address",
+			method: "POST", path: "/api/admin/add",
+			signed: &types.SignedRequest{Action: "add_admin"},
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "add admin signed by a non-admin",
+			method: "POST", path: "/api/admin/add",
+			signed: &types.SignedRequest{Action: "add_admin", AdminAddress: "mallory", Data: map[string]string{"address": "mallory"}},
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+		},
+		{
+			name:   "add admin with an expired request",
+			method: "POST", path: "/api/admin/add",
+			signed: &types.SignedRequest{
+				Action:    "add_admin",
+				Data:      map[string]string{"address": "admin-2"},
+				Timestamp: time.Now().Add(-10 * time.Minute).Unix(),
+			},
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+		},
+		{
+			name:   "add admin with a request dated in the future",
+			method: "POST", path: "/api/admin/add",
+			signed: &types.SignedRequest{
+				Action:    "add_admin",
+				Data:      map[string]string{"address": "admin-2"},
+				Timestamp: time.Now().Add(10 * time.Minute).Unix(),
+			},
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+		},
+		{
+			name: "add admin from a client outside the admin access list",
+			setup: func(t *testing.T, env *testEnv) {
+				if err := env.server.SetAdminAccess(&api.AdminAccess{Allow: []string{"10.0.0.0/8"}}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			method: "POST", path: "/api/admin/add",
+			signed: &types.SignedRequest{Action: "add_admin", Data: map[string]string{"address": "admin-2"}},
+			status: http.StatusForbidden, code: api.CodeAccessDenied,
+		},
+	})
+}
+
+func TestAdminRequestReplay(t *testing.T) {
+	env := newTestEnv(t, false)
+	body := env.signAdmin(t, types.SignedRequest{Action: "add_admin", Data: map[string]string{"address": "admin-2"}}, nil)
+
+	if rec := env.do("POST", "/api/admin/add", body); rec.Code != http.StatusOK {
+		t.Fatalf("first request: status %d, want %d; body: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if rec := env.do("POST", "/api/admin/add", body); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("replayed request: status %d, want %d; body: %s", rec.Code, http.StatusUnauthorized, rec.Body)
+	}
+}
+
+func TestGovernanceHandlers(t *testing.T) {
+	proposal := func(t *testing.T, env *testEnv) {
+		if _, err := env.governance.CreateProposal("alice", consensus.ProposalTypeChangeParameter, "Raise the block size", "", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runHandlerCases(t, []handlerCase{
+		{
+			name:   "list proposals",
+			setup:  proposal,
+			method: "GET", path: "/api/proposals",
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				if !strings.Contains(rec.Body.String(), "Raise the block size") {
+					t.Fatalf("proposals %s do not list proposal-1", rec.Body)
+				}
+			},
+		},
+		{
+			name:   "proposal",
+			setup:  proposal,
+			method: "GET", path: "/api/proposals/proposal-1",
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				// Parameter changes come with the recent block utilization
+				if !strings.Contains(rec.Body.String(), `"utilization"`) {
+					t.Fatalf("parameter change %s served without utilization", rec.Body)
+				}
+			},
+		},
+		{
+			name:   "unknown proposal",
+			method: "GET", path: "/api/proposals/proposal-1",
+			status: http.StatusNotFound, code: api.CodeNotFound,
+		},
+		{
+			name:   "create proposal",
+			method: "POST", path: "/api/proposals/create",
+			body:   `{"creator": "alice", "type": "change_parameter", "title": "Raise the block size"}`,
+			status: http.StatusOK,
+			check: func(t *testing.T, env *testEnv, rec *httptest.ResponseRecorder) {
+				if _, err := env.governance.GetProposal("proposal-1"); err != nil {
+					t.Fatalf("proposal was not created: %v", err)
+				}
+			},
+		},
+		{
+			name:   "create proposal with a malformed body",
+			method: "POST", path: "/api/proposals/create",
+			body:   `{"creator": 7}`,
+			status: http.StatusBadRequest, code: api.CodeBadRequest,
+		},
+		{
+			name:   "vote",
+			setup:  proposal,
+			method: "POST", path: "/api/proposals/vote",
+			body:   `{"voter": "bob", "proposalId": "proposal-1", "inFavor": true}`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "vote on an unknown proposal",
+			method: "POST", path: "/api/proposals/vote",
+			body:   `{"voter": "bob", "proposalId": "proposal-1", "inFavor": true}`,
+			status: http.StatusNotFound, code: api.CodeNotFound,
+		},
+	})
+}
+
+// TestOptionalServicesAbsent covers a node served without a validator
+// manager or governance: their routes answer 503, or 401 where the admin
+// signature cannot be checked, instead of failing
+func TestOptionalServicesAbsent(t *testing.T) {
+	runHandlerCases(t, []handlerCase{
+		{
+			name: "validator status", bare: true,
+			method: "GET", path: "/api/validators/status/val-1",
+			status: http.StatusServiceUnavailable, code: api.CodeUnavailable,
+		},
+		{
+			name: "validator stakes", bare: true,
+			method: "GET", path: "/api/validators/stakes",
+			status: http.StatusServiceUnavailable, code: api.CodeUnavailable,
+		},
+		{
+			name: "validator commission", bare: true,
+			method: "GET", path: "/api/validators/commission/val-1",
+			status: http.StatusServiceUnavailable, code: api.CodeUnavailable,
+		},
+		{
+			name: "set validator commission", bare: true,
+			method: "POST", path: "/api/validators/commission",
+			body:   `{"address": "val-1", "rate": 500, "timestamp": 1, "signature": "00"}`,
+			status: http.StatusServiceUnavailable, code: api.CodeUnavailable,
+		},
+		{
+			name: "validator exit", bare: true,
+			method: "POST", path: "/api/validators/exit",
+			body:   `{"address": "val-1", "timestamp": 1, "signature": "00"}`,
+			status: http.StatusServiceUnavailable, code: api.CodeUnavailable,
+		},
+		{
+			name: "validator emergency", bare: true,
+			method: "GET", path: "/api/validators/emergency",
+			status: http.StatusServiceUnavailable, code: api.CodeUnavailable,
+		},
+		{
+			name: "list admins", bare: true,
+			method: "GET", path: "/api/admin/list",
+			status: http.StatusServiceUnavailable, code: api.CodeUnavailable,
+		},
+		{
+			name: "add admin", bare: true,
+			method: "POST", path: "/api/admin/add",
+			signed: &types.SignedRequest{Action: "add_admin", Data: map[string]string{"address": "admin-2"}},
+			status: http.StatusUnauthorized, code: api.CodeUnauthorized,
+		},
+		{
+			name: "proposals", bare: true,
+			method: "GET", path: "/api/proposals",
+			status: http.StatusServiceUnavailable, code: api.CodeUnavailable,
+		},
+	})
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+)
+
+// ActionValidatorSlash must be signed to slash a validator's stake
+const ActionValidatorSlash = "validator_slash"
+
+// ValidatorStake is the stake view of a validator
+type ValidatorStake struct {
+	Address   string `json:"address"`
+	Status    string `json:"status"`
+	Stake     string `json:"stake"`
+	Slashed   string `json:"slashed"`
+	Remaining string `json:"remaining"`
+}
+
+// getValidatorStakes handles GET /api/validators/stakes
+func (ws *WebServer) getValidatorStakes(w http.ResponseWriter, r *http.Request) {
+	if ws.validatorManager == nil {
+		writeError(w, errNoValidatorManager, http.StatusServiceUnavailable)
+		return
+	}
+
+	validators := ws.validatorManager.GetValidators()
+
+	stakes := make([]ValidatorStake, 0, len(validators))
+	for _, v := range validators {
+		stake, slashed := big.NewInt(0), big.NewInt(0)
+		if v.Stake != nil {
+			stake = v.Stake
+		}
+		if v.Slashed != nil {
+			slashed = v.Slashed
+		}
+		stakes = append(stakes, ValidatorStake{
+			Address:   v.Address,
+			Status:    string(v.Status),
+			Stake:     stake.String(),
+			Slashed:   slashed.String(),
+			Remaining: v.RemainingStake().String(),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"minStake":   ws.validatorManager.MinStake().String(),
+		"validators": stakes,
+	})
+}
+
+// slashValidator handles POST /api/validators/slash.
+// Signed data: {"address": "...", "amount": "...", "reason": "..."}
+func (ws *WebServer) slashValidator(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionValidatorSlash)
+	if req == nil {
+		return
+	}
+
+	validatorAddress := req.Data["address"]
+	if validatorAddress == "" {
+		writeError(w, errors.New("missing validator address in request data"), http.StatusBadRequest)
+		return
+	}
+
+	amount, ok := new(big.Int).SetString(req.Data["amount"], 10)
+	if !ok || amount.Sign() <= 0 {
+		writeError(w, errors.New("amount must be a positive integer"), http.StatusBadRequest)
+		return
+	}
+
+	reason := req.Data["reason"]
+	if reason == "" {
+		reason = "No reason provided"
+	}
+
+	tx, err := ws.validatorManager.SlashValidator(req.AdminAddress, validatorAddress, amount, reason)
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to slash validator: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	// The stake is burned once the transaction is confirmed
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"status":  "pending",
+		"address": validatorAddress,
+		"txId":    tx.ID,
+	})
+}
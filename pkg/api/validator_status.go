@@ -0,0 +1,115 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ValidatorProofStatus is the Proof of Humanity state of a validator
+type ValidatorProofStatus struct {
+	Verified         bool   `json:"verified"`                   // verification known to this node and not expired
+	ExpiresAt        int64  `json:"expiresAt,omitempty"`        // unix time the verification expires
+	SecondsRemaining int64  `json:"secondsRemaining,omitempty"` // until ExpiresAt
+	OnChain          bool   `json:"onChain"`                    // the proof is in the on-chain registry
+	Provider         string `json:"provider,omitempty"`
+	RecordedAt       uint64 `json:"recordedAt,omitempty"` // block index of the registry entry
+}
+
+// ValidatorSlot is the next block production turn of a validator
+type ValidatorSlot struct {
+	TurnsAway  int   `json:"turnsAway"`
+	EtaSeconds int64 `json:"etaSeconds"`
+}
+
+// ValidatorBlock summarizes the last block a validator produced
+type ValidatorBlock struct {
+	Index     uint64 `json:"index"`
+	Hash      string `json:"hash"`
+	Timestamp int64  `json:"timestamp"`
+	Age       int64  `json:"ageSeconds"`
+}
+
+// ValidatorStatusReport is the one-shot health view of a validator
+type ValidatorStatusReport struct {
+	Address          string               `json:"address"`
+	Registered       bool                 `json:"registered"`  // known to the validator manager
+	Status           string               `json:"status"`      // registration status, "unregistered" when unknown
+	InActiveSet      bool                 `json:"inActiveSet"` // may produce blocks now
+	ApprovedBy       string               `json:"approvedBy,omitempty"`
+	JoinedAt         *time.Time           `json:"joinedAt,omitempty"`
+	PerformanceScore float64              `json:"performanceScore"`
+	TotalBlocks      uint64               `json:"totalBlocks"`
+	QuotaViolations  uint64               `json:"quotaViolations"` // blocks proposed past the epoch quota
+	LastActive       *time.Time           `json:"lastActive,omitempty"`
+	HumanProof       ValidatorProofStatus `json:"humanProof"`
+	NextSlot         *ValidatorSlot       `json:"nextSlot,omitempty"` // absent when the validator has no slot in the schedule
+	LastBlock        *ValidatorBlock      `json:"lastBlock,omitempty"`
+	ChainHeight      uint64               `json:"chainHeight"`
+	IsLocalNode      bool                 `json:"isLocalNode"`
+}
+
+// getValidatorStatus handles GET /api/validators/status/{address}, combining
+// registration, proof, schedule and production data of one validator
+func (ws *WebServer) getValidatorStatus(w http.ResponseWriter, r *http.Request) {
+	if ws.validatorManager == nil {
+		writeError(w, errNoValidatorManager, http.StatusServiceUnavailable)
+		return
+	}
+	address := mux.Vars(r)["address"]
+	now := time.Now()
+
+	report := ValidatorStatusReport{
+		Address:     address,
+		Status:      "unregistered",
+		InActiveSet: ws.blockchain.IsValidator(address),
+		ChainHeight: ws.blockchain.GetChainHeight(),
+	}
+
+	if info, exists := ws.validatorManager.GetValidator(address); exists {
+		report.Registered = true
+		report.Status = string(info.Status)
+		report.ApprovedBy = info.ApprovedBy
+		report.PerformanceScore = info.PerformanceScore
+		report.TotalBlocks = info.TotalBlocks
+		report.QuotaViolations = info.QuotaViolations
+		if !info.JoinedAt.IsZero() {
+			report.JoinedAt = &info.JoinedAt
+		}
+		if !info.LastActive.IsZero() {
+			report.LastActive = &info.LastActive
+		}
+	}
+
+	if record, exists := ws.blockchain.GetHumanProofRecord(address); exists {
+		report.HumanProof.OnChain = true
+		report.HumanProof.Provider = record.Provider
+		report.HumanProof.RecordedAt = record.BlockIndex
+	}
+
+	if ws.consensusEngine != nil {
+		report.IsLocalNode = ws.consensusEngine.GetNodeAddress() == address
+		if verification, exists := ws.consensusEngine.HumanVerification(address); exists && verification.Verified {
+			report.HumanProof.ExpiresAt = verification.ExpiresAt
+			if remaining := verification.ExpiresAt - now.Unix(); remaining > 0 {
+				report.HumanProof.Verified = true
+				report.HumanProof.SecondsRemaining = remaining
+			}
+		}
+		if turns, eta, ok := ws.consensusEngine.NextTurn(address); ok {
+			report.NextSlot = &ValidatorSlot{TurnsAway: turns, EtaSeconds: int64(eta / time.Second)}
+		}
+	}
+
+	if block, exists := ws.blockchain.LastBlockBy(address); exists {
+		report.LastBlock = &ValidatorBlock{
+			Index:     block.Index,
+			Hash:      block.Hash,
+			Timestamp: block.Timestamp,
+			Age:       now.Unix() - block.Timestamp,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
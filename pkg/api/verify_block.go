@@ -0,0 +1,22 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"confirmix/pkg/blockchain"
+)
+
+// verifyBlock handles POST /api/verify/block. The body is a block as served by
+// GET /api/blocks/{index}; it is checked against this node's chain without
+// being imported. The response is a report of every block and transaction
+// check, with status 200 whether or not the block is valid.
+func (ws *WebServer) verifyBlock(w http.ResponseWriter, r *http.Request) {
+	var block blockchain.Block
+	if err := json.NewDecoder(r.Body).Decode(&block); err != nil {
+		writeError(w, errors.New("invalid request body: expected a block"), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, ws.blockchain.VerifyBlockReport(&block))
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+
+	"confirmix/pkg/blockchain"
+)
+
+// nodeFeatures lists the optional subsystems enabled on this node
+type nodeFeatures struct {
+	Governance  bool   `json:"governance"`
+	Contracts   bool   `json:"contracts"`
+	Staking     bool   `json:"staking"`
+	BFT         bool   `json:"bft"` // validator pre-vote/pre-commit finality
+	Observer    bool   `json:"observer"`
+	Replica     bool   `json:"replica"`
+	PoHProvider string `json:"pohProvider,omitempty"` // local, simulator or external; empty without a consensus engine
+}
+
+// getVersion handles GET /api/version, reporting the software version, build
+// metadata, the peer protocol versions this node speaks and its enabled
+// features, so operators and clients can check compatibility
+func (ws *WebServer) getVersion(w http.ResponseWriter, r *http.Request) {
+	features := nodeFeatures{
+		Governance: ws.governance != nil,
+		Contracts:  true, // the contract engine is part of every build
+		Staking:    ws.staking != nil,
+		BFT:        ws.bft != nil,
+		Observer:   ws.observer,
+		Replica:    ws.node.replica != nil,
+	}
+	if ws.consensusEngine != nil {
+		features.PoHProvider = ws.consensusEngine.PoHProviderName()
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"build":    blockchain.Build(),
+		"chainId":  blockchain.ChainID(),
+		"features": features,
+	})
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// Actions that must be signed for the wallet control endpoints
+const (
+	ActionWalletControlsView = "node_wallet_controls_view"
+	ActionWalletControlsSet  = "node_wallet_controls_set"
+)
+
+// approvalTimeout bounds the wait for a wallet's approval callback
+const approvalTimeout = 10 * time.Second
+
+// WalletControlsView is the API form of the controls of a wallet. The TOTP
+// secret is never returned after it was issued.
+type WalletControlsView struct {
+	Address     string `json:"address"`
+	DailyLimit  uint64 `json:"dailyLimit"` // 0 is unlimited
+	SpentToday  uint64 `json:"spentToday"`
+	TOTPEnabled bool   `json:"totpEnabled"`
+	ApprovalURL string `json:"approvalUrl,omitempty"`
+	UpdatedBy   string `json:"updatedBy,omitempty"`
+	UpdatedAt   int64  `json:"updatedAt,omitempty"`
+}
+
+// newWalletControlsView redacts controls for a response
+func newWalletControlsView(controls blockchain.WalletControls) WalletControlsView {
+	return WalletControlsView{
+		Address:     controls.Address,
+		DailyLimit:  controls.DailyLimit,
+		SpentToday:  controls.SpentToday(),
+		TOTPEnabled: controls.TOTPSecret != "",
+		ApprovalURL: controls.ApprovalURL,
+		UpdatedBy:   controls.UpdatedBy,
+		UpdatedAt:   controls.UpdatedAt,
+	}
+}
+
+// authorizeCustodialSpend enforces the controls of tx.From before a spend from
+// a custodied wallet enters the pool, whether the node or the client signed it:
+// the daily limit, then the one-time code and the approval callback. Every API
+// path that submits a transfer calls it. The returned release function gives
+// the limit back when the transaction is not accepted after all.
+func (ws *WebServer) authorizeCustodialSpend(tx *blockchain.Transaction, otp string) (func(), error) {
+	controls, exists := ws.wallets.GetWalletControls(tx.From)
+	if !exists {
+		return func() {}, nil
+	}
+
+	release, err := ws.wallets.ReserveWalletSpend(tx.From, tx.Value)
+	if err != nil {
+		return nil, err
+	}
+	if err := ws.wallets.VerifyWalletTOTP(tx.From, otp); err != nil {
+		release()
+		return nil, err
+	}
+	if controls.ApprovalURL != "" {
+		if err := requestSpendApproval(controls.ApprovalURL, tx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// requestSpendApproval posts a spend to a wallet's approval callback. Any 2xx
+// answer approves it; everything else, including a timeout, denies it.
+func requestSpendApproval(url string, tx *blockchain.Transaction) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"txId":        tx.ID,
+		"address":     tx.From,
+		"to":          tx.To,
+		"value":       tx.Value,
+		"type":        tx.Type,
+		"requestedAt": time.Now().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: approvalTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Approval callback for %s failed: %v", tx.From, err)
+		return fmt.Errorf("%w: approval callback unreachable", blockchain.ErrSecondFactorFailed)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: approval denied with status %d", blockchain.ErrSecondFactorFailed, resp.StatusCode)
+	}
+	return nil
+}
+
+// viewWalletControls handles reading the controls of a custodied wallet.
+// Data: {"address": "..."}
+func (ws *WebServer) viewWalletControls(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionWalletControlsView)
+	if req == nil {
+		return
+	}
+
+	address := req.Data["address"]
+	controls, exists := ws.wallets.GetWalletControls(address)
+	if !exists {
+		controls = blockchain.WalletControls{Address: address}
+	}
+	writeJSON(w, http.StatusOK, newWalletControlsView(controls))
+}
+
+// setWalletControls handles setting the controls of a custodied wallet. The
+// TOTP secret is only returned when it is issued.
+// Data: {"address": "...", "daily_limit": "0" (unlimited), "approval_url": "" (none),
+// "totp": "enable" (new secret) | "disable" | "" (keep)}
+func (ws *WebServer) setWalletControls(w http.ResponseWriter, r *http.Request) {
+	req := ws.decodeAdminRequest(w, r, ActionWalletControlsSet)
+	if req == nil {
+		return
+	}
+
+	address := req.Data["address"]
+	existing, _ := ws.wallets.GetWalletControls(address)
+	controls := blockchain.WalletControls{
+		Address:     address,
+		TOTPSecret:  existing.TOTPSecret,
+		ApprovalURL: req.Data["approval_url"],
+		UpdatedBy:   req.AdminAddress,
+	}
+	if raw := req.Data["daily_limit"]; raw != "" {
+		limit, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			writeError(w, fmt.Errorf("%w: daily_limit must be a non-negative integer", blockchain.ErrInvalidWalletControls), http.StatusBadRequest)
+			return
+		}
+		controls.DailyLimit = limit
+	}
+
+	issued := ""
+	switch req.Data["totp"] {
+	case "":
+	case "enable":
+		secret, err := blockchain.GenerateTOTPSecret()
+		if err != nil {
+			writeError(w, err, http.StatusInternalServerError)
+			return
+		}
+		controls.TOTPSecret, issued = secret, secret
+	case "disable":
+		controls.TOTPSecret = ""
+	default:
+		writeError(w, fmt.Errorf("%w: totp must be enable or disable", blockchain.ErrInvalidWalletControls), http.StatusBadRequest)
+		return
+	}
+
+	saved, err := ws.wallets.SetWalletControls(controls)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Admin %s set wallet controls of %s (daily limit %d, totp %t, approval url %t)",
+		req.AdminAddress, address, saved.DailyLimit, saved.TOTPSecret != "", saved.ApprovalURL != "")
+	response := map[string]interface{}{
+		"status":   "success",
+		"controls": newWalletControlsView(saved),
+	}
+	if issued != "" {
+		response["totpSecret"] = issued
+		response["totpUri"] = fmt.Sprintf("otpauth://totp/Confirmix:%s?secret=%s&issuer=Confirmix", address, issued)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// Failed unlocks a client may make before it has to wait, and how long they count
+const (
+	maxUnlockFailures   = 5
+	unlockFailureWindow = 15 * time.Minute
+)
+
+var (
+	// errWalletSessionRequired is returned when a request acts for an unlocked
+	// wallet without the session token its unlock issued
+	errWalletSessionRequired = errors.New("wallet session required")
+	// errUnlockRateLimited is returned to a client with too many failed unlocks
+	errUnlockRateLimited = errors.New("too many failed wallet unlocks")
+)
+
+// walletSession is what an unlock lets its bearer do: act for one wallet, from
+// the client that unlocked it, until the unlock ends
+type walletSession struct {
+	address string
+	client  string
+	until   time.Time
+}
+
+// unlockFailures counts the failed unlocks of a client since first
+type unlockFailures struct {
+	count int
+	first time.Time
+}
+
+// walletSessionState holds the sessions issued by wallet unlocks, keyed by the
+// SHA-256 of their token, and the failed unlocks per client. The zero value
+// holds none.
+type walletSessionState struct {
+	mu       sync.Mutex
+	sessions map[[sha256.Size]byte]walletSession
+	failures map[string]*unlockFailures
+}
+
+// sessionKey is the key a session token is stored under, so the tokens
+// themselves are never kept
+func sessionKey(token string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(token))
+}
+
+// admit refuses clients with maxUnlockFailures failures in the window and
+// tells them when to retry
+func (s *walletSessionState) admit(client string, now time.Time) (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	failures, exists := s.failures[client]
+	if !exists {
+		return true, 0
+	}
+	if now.Sub(failures.first) >= unlockFailureWindow {
+		delete(s.failures, client)
+		return true, 0
+	}
+	if failures.count < maxUnlockFailures {
+		return true, 0
+	}
+	return false, failures.first.Add(unlockFailureWindow).Sub(now)
+}
+
+// fail records a failed unlock by client
+func (s *walletSessionState) fail(client string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures == nil {
+		s.failures = make(map[string]*unlockFailures)
+	}
+	failures, exists := s.failures[client]
+	if !exists || now.Sub(failures.first) >= unlockFailureWindow {
+		failures = &unlockFailures{first: now}
+		s.failures[client] = failures
+	}
+	failures.count++
+}
+
+// issue returns a new session token for client to act for address until the
+// unlock ends. Expired sessions are dropped along the way.
+func (s *walletSessionState) issue(address, client string, until, now time.Time) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("generating session token: %v", err)
+	}
+	token := hex.EncodeToString(secret)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessions == nil {
+		s.sessions = make(map[[sha256.Size]byte]walletSession)
+	}
+	for key, session := range s.sessions {
+		if now.After(session.until) {
+			delete(s.sessions, key)
+		}
+	}
+	s.sessions[sessionKey(token)] = walletSession{address: address, client: client, until: until}
+	delete(s.failures, client)
+	return token, nil
+}
+
+// authorize checks that token is a live session for address held by client
+func (s *walletSessionState) authorize(token, address, client string, now time.Time) bool {
+	if token == "" {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, exists := s.sessions[sessionKey(token)]
+	return exists && session.address == address && session.client == client && !now.After(session.until)
+}
+
+// revoke ends every session of address
+func (s *walletSessionState) revoke(address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, session := range s.sessions {
+		if session.address == address {
+			delete(s.sessions, key)
+		}
+	}
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// unlockClient identifies the client of a request for sessions and failure
+// counts, by its address behind the trusted proxies of the admin access list
+func (ws *WebServer) unlockClient(r *http.Request) string {
+	if ip := ws.adminAccess.load().clientIP(r); ip != nil {
+		return ip.String()
+	}
+	return r.RemoteAddr
+}
+
+// authorizeWallet checks that r carries a session for address, issued by an
+// unlock from the same client
+func (ws *WebServer) authorizeWallet(r *http.Request, address string) error {
+	if !ws.walletSessions.authorize(bearerToken(r), address, ws.unlockClient(r), time.Now()) {
+		return fmt.Errorf("%w: send the session token of an unlock of %s as \"Authorization: Bearer <token>\"", errWalletSessionRequired, address)
+	}
+	return nil
+}
+
+// signWithSession signs tx with the custodied key of its sender, for a request
+// that holds a session of the sender's unlock
+func (ws *WebServer) signWithSession(r *http.Request, tx *blockchain.Transaction) error {
+	if tx == nil {
+		return blockchain.ErrNilTransaction
+	}
+	if err := ws.authorizeWallet(r, tx.From); err != nil {
+		return err
+	}
+	return ws.wallets.SignWithUnlockedWallet(tx)
+}
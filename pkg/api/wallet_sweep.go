@@ -0,0 +1,160 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"time"
+
+	"confirmix/pkg/blockchain"
+	"github.com/google/uuid"
+)
+
+// maxSweepKeys limits how many keys can be imported in one sweep request
+const maxSweepKeys = 100
+
+// SweepKey is a single key in a sweep request
+type SweepKey struct {
+	PrivateKey string `json:"privateKey"`
+	Format     string `json:"format,omitempty"` // hex, pem, wif or auto (default)
+}
+
+// SweepRequest imports many keys at once and optionally consolidates their funds
+type SweepRequest struct {
+	Keys        []SweepKey `json:"keys"`
+	Target      string     `json:"target,omitempty"`      // consolidation address
+	Consolidate bool       `json:"consolidate,omitempty"` // schedule a transfer of each balance to Target
+}
+
+// SweepResult reports the outcome for one imported key
+type SweepResult struct {
+	Index         int    `json:"index"`
+	Address       string `json:"address,omitempty"`
+	Format        string `json:"format,omitempty"`
+	Exists        bool   `json:"exists"`
+	Balance       string `json:"balance,omitempty"`
+	TransferTxID  string `json:"transferTxId,omitempty"`
+	TransferValue uint64 `json:"transferValue,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// registerImportedKey stores an imported key pair and creates its account if needed.
+// It returns the address, the key pair in use and whether the wallet already existed.
+func (ws *WebServer) registerImportedKey(privKey *ecdsa.PrivateKey) (string, *blockchain.KeyPair, bool) {
+	keyPair := &blockchain.KeyPair{
+		PrivateKey: privKey,
+		PublicKey:  &privKey.PublicKey,
+	}
+
+	// Generate address from public key
+	address := blockchain.GenerateAddress(keyPair.PublicKey)
+
+	// Use the existing key pair for consistent behavior
+	if existingKeyPair, exists := ws.wallets.GetKeyPair(address); exists {
+		return address, existingKeyPair, true
+	}
+
+	ws.wallets.AddKeyPair(address, keyPair)
+
+	// Create the account with a zero balance if it does not exist yet
+	if _, err := ws.wallets.GetBalance(address); err != nil {
+		if err := ws.wallets.CreateAccount(address, big.NewInt(0)); err != nil {
+			log.Printf("Error creating account during import: %v", err)
+		}
+	}
+
+	return address, keyPair, false
+}
+
+// sweepWallets handles importing many keys in one call, optionally consolidating
+// every imported balance into a single target address
+func (ws *WebServer) sweepWallets(w http.ResponseWriter, r *http.Request) {
+	var req SweepRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("invalid request format"), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Keys) == 0 {
+		writeError(w, errors.New("at least one key is required"), http.StatusBadRequest)
+		return
+	}
+	if len(req.Keys) > maxSweepKeys {
+		writeError(w, fmt.Errorf("too many keys: %d (max %d)", len(req.Keys), maxSweepKeys), http.StatusBadRequest)
+		return
+	}
+	if req.Consolidate && req.Target == "" {
+		writeError(w, errors.New("target address is required for consolidation"), http.StatusBadRequest)
+		return
+	}
+
+	results := make([]SweepResult, 0, len(req.Keys))
+	imported := 0
+	var swept uint64
+
+	for i, key := range req.Keys {
+		result := SweepResult{Index: i}
+
+		privKey, format, err := blockchain.ImportPrivateKeyWithFormat(key.PrivateKey, blockchain.KeyFormat(key.Format))
+		if err != nil {
+			result.Format = string(format)
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		address, _, exists := ws.registerImportedKey(privKey)
+		result.Address = address
+		result.Format = string(format)
+		result.Exists = exists
+		imported++
+
+		balance, err := ws.wallets.GetBalance(address)
+		if err != nil {
+			balance = big.NewInt(0)
+		}
+		result.Balance = balance.String()
+
+		if req.Consolidate && address != req.Target && balance.Sign() > 0 {
+			if !balance.IsUint64() {
+				result.Error = "balance too large to consolidate in one transaction"
+			} else {
+				tx := blockchain.NewTransaction(uuid.New().String(), address, req.Target, balance.Uint64(), nil)
+				if err := tx.Sign(privKey); err != nil {
+					result.Error = fmt.Sprintf("failed to sign consolidation transfer: %v", err)
+				} else if release, err := ws.authorizeCustodialSpend(tx, ""); err != nil {
+					result.Error = fmt.Sprintf("consolidation transfer not authorized: %v", err)
+				} else if err := ws.blockchain.AddTransaction(tx); err != nil {
+					release()
+					result.Error = fmt.Sprintf("failed to schedule consolidation transfer: %v", err)
+				} else {
+					result.TransferTxID = tx.ID
+					result.TransferValue = tx.Value
+					swept += tx.Value
+				}
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	if imported > 0 {
+		// Save blockchain state after import
+		go ws.blockchain.SaveToDisk()
+	}
+
+	log.Printf("Sweep imported %d/%d keys, scheduled %d for consolidation to %s", imported, len(req.Keys), swept, req.Target)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"imported":    imported,
+		"failed":      len(req.Keys) - imported,
+		"target":      req.Target,
+		"totalSwept":  swept,
+		"results":     results,
+		"completedAt": time.Now().Unix(),
+	})
+}
@@ -0,0 +1,244 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/units"
+
+	"github.com/google/uuid"
+)
+
+// walletKeyMatches reports whether privateKey is the custodied key of address
+func walletKeyMatches(keyPair *blockchain.KeyPair, address, privateKey string) bool {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+	}
+	if held := keyPair.GetPrivateKeyString(); held != "" &&
+		subtle.ConstantTimeCompare([]byte(normalize(held)), []byte(normalize(privateKey))) == 1 {
+		return true
+	}
+	privKey, _, err := blockchain.ImportPrivateKeyWithFormat(privateKey, blockchain.KeyFormatAuto)
+	return err == nil && blockchain.GenerateAddress(&privKey.PublicKey) == address
+}
+
+// errNoOwner is returned when a request identifies neither an API key nor a wallet
+var errNoOwner = errors.New("send an API key or the address of an unlocked wallet")
+
+// requestOwner returns who a request acts for, keying node-side data such as
+// address books and payment requests. Requests with an API key act for that
+// key; others for wallet, a custodied wallet whose unlock session they must
+// carry, so only the client that unlocked it with its passphrase can act for it.
+func (ws *WebServer) requestOwner(r *http.Request, wallet string) (string, error) {
+	if id, _ := r.Context().Value(apiKeyContextKey{}).(string); id != "" {
+		return "key:" + id, nil
+	}
+	if wallet == "" {
+		return "", errNoOwner
+	}
+	if _, unlocked := ws.wallets.WalletUnlockedUntil(wallet); !unlocked {
+		return "", fmt.Errorf("%w: unlock %s first", blockchain.ErrWalletLocked, wallet)
+	}
+	if err := ws.authorizeWallet(r, wallet); err != nil {
+		return "", err
+	}
+	return "wallet:" + wallet, nil
+}
+
+// setWalletPassphrase handles POST /api/wallet/passphrase. The first
+// passphrase of a wallet must be authorized with its private key, later
+// changes with the current passphrase. Failures count against the client like
+// failed unlocks, and a change ends the sessions of the wallet.
+func (ws *WebServer) setWalletPassphrase(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Address           string `json:"address"`
+		Passphrase        string `json:"passphrase"`
+		CurrentPassphrase string `json:"currentPassphrase,omitempty"`
+		PrivateKey        string `json:"privateKey,omitempty"` // proves ownership when no passphrase is set
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
+		return
+	}
+
+	client := ws.unlockClient(r)
+	if !ws.admitUnlock(w, client) {
+		return
+	}
+
+	keyPair, exists := ws.wallets.GetKeyPair(req.Address)
+	if !exists {
+		writeError(w, fmt.Errorf("%w: the node does not hold the key of %s", blockchain.ErrKeyPairNotFound, req.Address), http.StatusNotFound)
+		return
+	}
+	if !ws.wallets.HasWalletPassphrase(req.Address) && !walletKeyMatches(keyPair, req.Address, req.PrivateKey) {
+		ws.walletSessions.fail(client, time.Now())
+		writeErrorCode(w, http.StatusUnauthorized, CodeUnauthorized,
+			"the first passphrase of a wallet must be set with its private key")
+		return
+	}
+
+	if err := ws.wallets.SetWalletPassphrase(req.Address, req.Passphrase, req.CurrentPassphrase); err != nil {
+		if errors.Is(err, blockchain.ErrWrongPassphrase) {
+			ws.walletSessions.fail(client, time.Now())
+		}
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	ws.walletSessions.revoke(req.Address)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"address": req.Address,
+		"locked":  true,
+	})
+}
+
+// unlockWallet handles POST /api/wallet/unlock, letting the node sign for a
+// custodied wallet for a bounded time window. The response carries a session
+// token that the routes signing or acting for the wallet require as
+// "Authorization: Bearer <token>", from the same client. After
+// maxUnlockFailures wrong passphrases a client waits out unlockFailureWindow.
+func (ws *WebServer) unlockWallet(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Address    string `json:"address"`
+		Passphrase string `json:"passphrase"`
+		Duration   int64  `json:"duration,omitempty"` // seconds; default 300, at most 3600
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
+		return
+	}
+
+	client := ws.unlockClient(r)
+	if !ws.admitUnlock(w, client) {
+		return
+	}
+
+	until, err := ws.wallets.UnlockWallet(req.Address, req.Passphrase, time.Duration(req.Duration)*time.Second)
+	if err != nil {
+		if errors.Is(err, blockchain.ErrWrongPassphrase) {
+			ws.walletSessions.fail(client, time.Now())
+		}
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	token, err := ws.walletSessions.issue(req.Address, client, until, time.Now())
+	if err != nil {
+		writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":       true,
+		"address":       req.Address,
+		"unlockedUntil": until.Unix(),
+		"sessionToken":  token,
+	})
+}
+
+// admitUnlock refuses a client with too many recent failed unlocks. It writes
+// the error response itself and returns false when the client must wait.
+func (ws *WebServer) admitUnlock(w http.ResponseWriter, client string) bool {
+	ok, retryAfter := ws.walletSessions.admit(client, time.Now())
+	if !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds()+0.999)))
+		writeError(w, fmt.Errorf("%w: retry in %v", errUnlockRateLimited, retryAfter.Round(time.Second)), http.StatusTooManyRequests)
+	}
+	return ok
+}
+
+// lockWallet handles POST /api/wallet/lock, ending an unlock window and its
+// sessions early. Locking needs no passphrase since it only removes the
+// node's ability to sign.
+func (ws *WebServer) lockWallet(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Address string `json:"address"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
+		return
+	}
+
+	ws.wallets.LockWallet(req.Address)
+	ws.walletSessions.revoke(req.Address)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"address": req.Address,
+		"locked":  true,
+	})
+}
+
+// signWalletTransaction handles POST /api/wallet/sign. It signs a prepared
+// transaction with the custodied key of its sender, which must be unlocked
+// by the session the request carries and pass its wallet controls, and submits it to the pool when asked to.
+// Signing without submitting counts against the daily limit too, since the
+// caller can broadcast the signed transaction elsewhere.
+func (ws *WebServer) signWalletTransaction(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID        string `json:"id,omitempty"`
+		From      string `json:"from"`
+		To        string `json:"to"`
+		ToContact string `json:"toContact,omitempty"` // name of the recipient in the address book instead of to
+		Value     units.Uint64 `json:"value"`
+		Data      string `json:"data,omitempty"`
+		Type      string `json:"type,omitempty"` // regular (default), contract_deploy or contract_call
+		Submit    bool   `json:"submit,omitempty"`
+		OTP       string `json:"otp,omitempty"` // one-time code for wallets with TOTP enabled
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errors.New("Invalid request body"), http.StatusBadRequest)
+		return
+	}
+	if req.From == "" {
+		writeError(w, errors.New("sender address cannot be empty"), http.StatusBadRequest)
+		return
+	}
+	switch req.Type {
+	case "":
+		req.Type = "regular"
+	case "regular", "contract_deploy", "contract_call":
+	default:
+		writeError(w, fmt.Errorf("transaction type %q cannot be signed by the node", req.Type), http.StatusBadRequest)
+		return
+	}
+	to, err := ws.resolveRecipient(r, req.From, req.To, req.ToContact)
+	if err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	if req.ID == "" {
+		req.ID = uuid.New().String()
+	}
+
+	tx := blockchain.NewTransaction(req.ID, req.From, to, uint64(req.Value), nil)
+	tx.Type = req.Type
+	if req.Data != "" {
+		tx.Data = []byte(req.Data)
+	}
+	if err := ws.signWithSession(r, tx); err != nil {
+		writeError(w, err, http.StatusBadRequest)
+		return
+	}
+	release, err := ws.authorizeCustodialSpend(tx, req.OTP)
+	if err != nil {
+		writeError(w, err, http.StatusForbidden)
+		return
+	}
+
+	if !req.Submit {
+		writeJSON(w, http.StatusOK, tx)
+		return
+	}
+	if err := ws.blockchain.AddTransaction(tx); err != nil {
+		release()
+		writeError(w, fmt.Errorf("failed to submit transaction: %w", err), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusCreated, tx)
+}
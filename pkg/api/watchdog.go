@@ -0,0 +1,23 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"confirmix/pkg/watchdog"
+)
+
+// SetWatchdog attaches the chain health watchdog reported by the API
+func (ws *WebServer) SetWatchdog(w *watchdog.Watchdog) {
+	ws.node.watchdog = w
+}
+
+// getWatchdog handles GET /api/watchdog, reporting active alerts and the
+// recent alert history
+func (ws *WebServer) getWatchdog(w http.ResponseWriter, r *http.Request) {
+	if ws.node.watchdog == nil {
+		writeError(w, errors.New("the watchdog is not enabled on this node"), http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, http.StatusOK, ws.node.watchdog.Status())
+}
@@ -0,0 +1,272 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// Backup archive naming: backup-<20060102-150405>-<height>.tar.gz
+const (
+	namePrefix = "backup-"
+	nameSuffix = ".tar.gz"
+	timeLayout = "20060102-150405"
+
+	manifestFile = "manifest.json"
+)
+
+// ErrInvalidBackup is returned when an archive is unreadable or fails its checksums
+var ErrInvalidBackup = errors.New("invalid backup archive")
+
+// Manifest describes the contents of a backup archive
+type Manifest struct {
+	Version   int               `json:"version"`
+	Height    uint64            `json:"height"`
+	Hash      string            `json:"hash"`
+	CreatedAt int64             `json:"createdAt"`
+	Files     map[string]string `json:"files"` // file name -> sha256
+}
+
+// Info describes a backup held by a store
+type Info struct {
+	Name      string `json:"name"`
+	Height    uint64 `json:"height"`
+	Size      int64  `json:"size"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// objectName returns the archive name of a backup taken at height and time t
+func objectName(height uint64, t time.Time) string {
+	return fmt.Sprintf("%s%s-%d%s", namePrefix, t.UTC().Format(timeLayout), height, nameSuffix)
+}
+
+// parseObjectName extracts the creation time and height encoded in an archive name
+func parseObjectName(name string) (time.Time, uint64, bool) {
+	if !strings.HasPrefix(name, namePrefix) || !strings.HasSuffix(name, nameSuffix) {
+		return time.Time{}, 0, false
+	}
+	core := strings.TrimSuffix(strings.TrimPrefix(name, namePrefix), nameSuffix)
+	if len(core) < len(timeLayout)+2 || core[len(timeLayout)] != '-' {
+		return time.Time{}, 0, false
+	}
+
+	created, err := time.Parse(timeLayout, core[:len(timeLayout)])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	height, err := strconv.ParseUint(core[len(timeLayout)+1:], 10, 64)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return created, height, true
+}
+
+// Create takes a consistent export of the chain state and stores it as a compressed archive
+func Create(bc *blockchain.Blockchain, store Store) (*Info, error) {
+	export, err := bc.ExportState()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export state: %v", err)
+	}
+
+	manifest := Manifest{
+		Version:   1,
+		Height:    export.Height,
+		Hash:      export.Hash,
+		CreatedAt: export.CreatedAt.Unix(),
+		Files:     make(map[string]string, len(export.Files)),
+	}
+	for name, data := range export.Files {
+		sum := sha256.Sum256(data)
+		manifest.Files[name] = hex.EncodeToString(sum[:])
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %v", err)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	entries := append([]string{manifestFile}, blockchain.StateFiles...)
+	for _, name := range entries {
+		data := manifestData
+		if name != manifestFile {
+			data = export.Files[name]
+		}
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: export.CreatedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("failed to write archive: %v", err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to write archive: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress archive: %v", err)
+	}
+
+	name := objectName(export.Height, export.CreatedAt)
+	if err := store.Put(name, buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to store backup: %v", err)
+	}
+
+	log.Printf("Backup %s created at height %d (%d bytes) in %s", name, export.Height, buf.Len(), store.Location())
+	return &Info{
+		Name:      name,
+		Height:    export.Height,
+		Size:      int64(buf.Len()),
+		CreatedAt: export.CreatedAt.Unix(),
+	}, nil
+}
+
+// List returns the backups held by store, newest first
+func List(store Store) ([]Info, error) {
+	objects, err := store.List(namePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]Info, 0, len(objects))
+	for i := len(objects) - 1; i >= 0; i-- {
+		created, height, ok := parseObjectName(objects[i].Name)
+		if !ok {
+			continue
+		}
+		backups = append(backups, Info{
+			Name:      objects[i].Name,
+			Height:    height,
+			Size:      objects[i].Size,
+			CreatedAt: created.Unix(),
+		})
+	}
+	return backups, nil
+}
+
+// readArchive unpacks an archive and verifies every state file against the manifest
+func readArchive(data []byte) (*Manifest, map[string][]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+		}
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+		}
+		files[header.Name] = content
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(files[manifestFile], &manifest); err != nil {
+		return nil, nil, fmt.Errorf("%w: missing or unreadable manifest: %v", ErrInvalidBackup, err)
+	}
+
+	state := make(map[string][]byte, len(blockchain.StateFiles))
+	for _, name := range blockchain.StateFiles {
+		content, exists := files[name]
+		if !exists {
+			return nil, nil, fmt.Errorf("%w: %s is missing", ErrInvalidBackup, name)
+		}
+		sum := sha256.Sum256(content)
+		if hex.EncodeToString(sum[:]) != manifest.Files[name] {
+			return nil, nil, fmt.Errorf("%w: checksum mismatch for %s", ErrInvalidBackup, name)
+		}
+		state[name] = content
+	}
+	return &manifest, state, nil
+}
+
+// Verify downloads a backup and checks its integrity without restoring it
+func Verify(store Store, name string) (*Manifest, error) {
+	data, err := store.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	manifest, _, err := readArchive(data)
+	return manifest, err
+}
+
+// Restore replaces the chain state in dataDir with the contents of a backup.
+// The node must not be running. The current state files are moved into
+// dataDir/pre-restore-<time> first, so a restore can be undone by hand.
+func Restore(store Store, name, dataDir string) (*Manifest, error) {
+	data, err := store.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	manifest, state, err := readArchive(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %v", err)
+	}
+
+	asideDir := filepath.Join(dataDir, "pre-restore-"+time.Now().UTC().Format(timeLayout))
+	for _, file := range blockchain.StateFiles {
+		current := filepath.Join(dataDir, file)
+		if _, err := os.Stat(current); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.MkdirAll(asideDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %v", asideDir, err)
+		}
+		if err := os.Rename(current, filepath.Join(asideDir, file)); err != nil {
+			return nil, fmt.Errorf("failed to move %s aside: %v", file, err)
+		}
+	}
+
+	for _, file := range blockchain.StateFiles {
+		path := filepath.Join(dataDir, file)
+		tmpPath := path + ".tmp"
+		if err := ioutil.WriteFile(tmpPath, state[file], 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %v", file, err)
+		}
+		if err := os.Rename(tmpPath, path); err != nil {
+			return nil, fmt.Errorf("failed to move %s into place: %v", file, err)
+		}
+	}
+
+	if err := blockchain.WriteStateChecksums(dataDir); err != nil {
+		return nil, fmt.Errorf("failed to record checksums of the restored state: %v", err)
+	}
+
+	log.Printf("Restored backup %s (height %d) into %s", name, manifest.Height, dataDir)
+	return manifest, nil
+}
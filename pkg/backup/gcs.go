@@ -0,0 +1,261 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gcsMetadataTokenURL is where a node running on Google Cloud obtains access tokens
+// for its service account
+const gcsMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// GCSConfig configures a store in a Google Cloud Storage bucket
+type GCSConfig struct {
+	Bucket      string
+	Prefix      string // object name prefix, e.g. "confirmix/archive/"
+	AccessToken string // OAuth2 access token; when empty a token is fetched from the metadata server
+	Endpoint    string // defaults to https://storage.googleapis.com
+	Timeout     time.Duration
+}
+
+// GCSStore keeps objects in a Google Cloud Storage bucket using the JSON API
+type GCSStore struct {
+	config     GCSConfig
+	httpClient *http.Client
+
+	tokenMu     sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewGCSStore creates a store for the given bucket
+func NewGCSStore(config GCSConfig) (*GCSStore, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("gcs bucket is required")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://storage.googleapis.com"
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	if config.Timeout <= 0 {
+		config.Timeout = time.Minute
+	}
+
+	return &GCSStore{
+		config:     config,
+		httpClient: &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+// Put uploads an object
+func (s *GCSStore) Put(name string, data []byte) error {
+	if err := validObjectName(name); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("uploadType", "media")
+	query.Set("name", s.config.Prefix+name)
+	target := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?%s", s.config.Endpoint, url.PathEscape(s.config.Bucket), query.Encode())
+
+	resp, err := s.do(http.MethodPost, target, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return gcsError("put", name, resp)
+	}
+	return nil
+}
+
+// Get downloads an object
+func (s *GCSStore) Get(name string) ([]byte, error) {
+	if err := validObjectName(name); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(http.MethodGet, s.objectURL(name)+"?alt=media", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, name)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, gcsError("get", name, resp)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// gcsListResult is the subset of the objects.list response used by List
+type gcsListResult struct {
+	Items []struct {
+		Name    string    `json:"name"`
+		Size    string    `json:"size"`
+		Updated time.Time `json:"updated"`
+	} `json:"items"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// List returns the objects under the store prefix whose name starts with prefix
+func (s *GCSStore) List(prefix string) ([]Object, error) {
+	var objects []Object
+	pageToken := ""
+
+	for {
+		query := url.Values{}
+		query.Set("prefix", s.config.Prefix+prefix)
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		target := fmt.Sprintf("%s/storage/v1/b/%s/o?%s", s.config.Endpoint, url.PathEscape(s.config.Bucket), query.Encode())
+
+		resp, err := s.do(http.MethodGet, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := gcsError("list", prefix, resp)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var result gcsListResult
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gcs listing: %v", err)
+		}
+
+		for _, item := range result.Items {
+			name := strings.TrimPrefix(item.Name, s.config.Prefix)
+			if strings.Contains(name, "/") {
+				continue // nested objects do not belong to this store
+			}
+			size, _ := strconv.ParseInt(item.Size, 10, 64)
+			objects = append(objects, Object{Name: name, Size: size, Modified: item.Updated})
+		}
+
+		if result.NextPageToken == "" {
+			break
+		}
+		pageToken = result.NextPageToken
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Name < objects[j].Name })
+	return objects, nil
+}
+
+// Delete removes an object
+func (s *GCSStore) Delete(name string) error {
+	if err := validObjectName(name); err != nil {
+		return err
+	}
+
+	resp, err := s.do(http.MethodDelete, s.objectURL(name), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return gcsError("delete", name, resp)
+	}
+	return nil
+}
+
+// Location returns the gs:// URL of the store
+func (s *GCSStore) Location() string {
+	return fmt.Sprintf("gs://%s/%s", s.config.Bucket, s.config.Prefix)
+}
+
+// objectURL returns the JSON API URL of an object
+func (s *GCSStore) objectURL(name string) string {
+	return fmt.Sprintf("%s/storage/v1/b/%s/o/%s", s.config.Endpoint, url.PathEscape(s.config.Bucket), url.PathEscape(s.config.Prefix+name))
+}
+
+// gcsError turns an unexpected response into an error including the body GCS returned
+func gcsError(op, name string, resp *http.Response) error {
+	body, _ := ioutil.ReadAll(resp.Body)
+	return fmt.Errorf("gcs %s %s failed with status %d: %s", op, name, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// do sends an authorized request
+func (s *GCSStore) do(method, target string, body []byte) (*http.Response, error) {
+	token, err := s.accessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("gcs request failed: %v", err)
+	}
+	return resp, nil
+}
+
+// accessToken returns the configured token, or a cached token from the metadata server
+func (s *GCSStore) accessToken() (string, error) {
+	if s.config.AccessToken != "" {
+		return s.config.AccessToken, nil
+	}
+
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+
+	if s.token != "" && time.Now().Before(s.tokenExpiry) {
+		return s.token, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, gcsMetadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("no gcs access token configured and the metadata server is unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", gcsError("token", "metadata", resp)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode metadata token: %v", err)
+	}
+
+	s.token = token.AccessToken
+	// Refresh a minute early so requests never go out with an expired token
+	s.tokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return s.token, nil
+}
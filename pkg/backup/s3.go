@@ -0,0 +1,300 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config configures a store on S3 or any S3-compatible object storage (MinIO, Ceph, ...)
+type S3Config struct {
+	Endpoint  string // e.g. https://s3.eu-central-1.amazonaws.com or http://localhost:9000
+	Region    string
+	Bucket    string
+	Prefix    string // key prefix objects are stored under, e.g. "confirmix/backups/"
+	AccessKey string
+	SecretKey string
+	Timeout   time.Duration
+}
+
+// S3Store keeps objects in an S3 bucket using path-style requests signed with AWS Signature V4
+type S3Store struct {
+	config     S3Config
+	endpoint   *url.URL
+	httpClient *http.Client
+}
+
+// NewS3Store creates a store for the given bucket
+func NewS3Store(config S3Config) (*S3Store, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, errors.New("s3 endpoint and bucket are required")
+	}
+	if config.AccessKey == "" || config.SecretKey == "" {
+		return nil, errors.New("s3 access key and secret key are required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = time.Minute
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", config.Endpoint)
+	}
+
+	return &S3Store{
+		config:     config,
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+// Put uploads an object
+func (s *S3Store) Put(name string, data []byte) error {
+	if err := validObjectName(name); err != nil {
+		return err
+	}
+
+	resp, err := s.do(http.MethodPut, s.config.Prefix+name, nil, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3Error("put", name, resp)
+	}
+	return nil
+}
+
+// Get downloads an object
+func (s *S3Store) Get(name string) ([]byte, error) {
+	if err := validObjectName(name); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(http.MethodGet, s.config.Prefix+name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, name)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, s3Error("get", name, resp)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// listBucketResult is the subset of the ListObjectsV2 response used by List
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List returns the objects under the store prefix whose name starts with prefix
+func (s *S3Store) List(prefix string) ([]Object, error) {
+	var objects []Object
+	token := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", s.config.Prefix+prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.do(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := s3Error("list", prefix, resp)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode s3 listing: %v", err)
+		}
+
+		for _, item := range result.Contents {
+			name := strings.TrimPrefix(item.Key, s.config.Prefix)
+			if strings.Contains(name, "/") {
+				continue // nested keys do not belong to this store
+			}
+			objects = append(objects, Object{Name: name, Size: item.Size, Modified: item.LastModified})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Name < objects[j].Name })
+	return objects, nil
+}
+
+// Delete removes an object
+func (s *S3Store) Delete(name string) error {
+	if err := validObjectName(name); err != nil {
+		return err
+	}
+
+	resp, err := s.do(http.MethodDelete, s.config.Prefix+name, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s3Error("delete", name, resp)
+	}
+	return nil
+}
+
+// Location returns the bucket URL of the store
+func (s *S3Store) Location() string {
+	return fmt.Sprintf("%s/%s/%s", s.endpoint.String(), s.config.Bucket, s.config.Prefix)
+}
+
+// s3Error turns an unexpected response into an error including the body S3 returned
+func s3Error(op, name string, resp *http.Response) error {
+	body, _ := ioutil.ReadAll(resp.Body)
+	return fmt.Errorf("s3 %s %s failed with status %d: %s", op, name, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// do sends a signed request for key (empty for bucket-level requests)
+func (s *S3Store) do(method, key string, query url.Values, body []byte) (*http.Response, error) {
+	path := "/" + s.config.Bucket
+	if key != "" {
+		path += "/" + key
+	}
+
+	target := *s.endpoint
+	target.Path = s.endpoint.Path + path
+	target.RawPath = uriEncode(target.Path, false)
+	target.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequest(method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	s.sign(req, s.endpoint.Path+path, body, time.Now().UTC())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 request failed: %v", err)
+	}
+	return resp, nil
+}
+
+// sign adds AWS Signature Version 4 headers to req
+func (s *S3Store) sign(req *http.Request, path string, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(path, false),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query parameters sorted by key as required by Signature V4
+func canonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything except unreserved characters (and '/' unless encodeSlash is set)
+func uriEncode(value string, encodeSlash bool) string {
+	var buf strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9'),
+			b == '-', b == '_', b == '.', b == '~':
+			buf.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			buf.WriteByte(b)
+		default:
+			fmt.Fprintf(&buf, "%%%02X", b)
+		}
+	}
+	return buf.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,183 @@
+package backup
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// Config controls how often backups are taken and how long they are kept
+type Config struct {
+	Interval time.Duration // time between scheduled backups
+	KeepLast int           // number of most recent backups always kept; 0 keeps all
+	MaxAge   time.Duration // backups older than this are removed (the newest is always kept); 0 disables
+}
+
+// DefaultConfig returns the default backup schedule
+func DefaultConfig() *Config {
+	return &Config{
+		Interval: time.Hour,
+		KeepLast: 24,
+		MaxAge:   7 * 24 * time.Hour,
+	}
+}
+
+// Status reports the state of the scheduler
+type Status struct {
+	Location  string `json:"location"`
+	Interval  string `json:"interval"`
+	KeepLast  int    `json:"keepLast"`
+	MaxAge    string `json:"maxAge"`
+	Running   bool   `json:"running"`
+	Last      *Info  `json:"last,omitempty"`
+	LastError string `json:"lastError,omitempty"`
+	LastRunAt int64  `json:"lastRunAt,omitempty"`
+}
+
+// Scheduler periodically backs up the chain state and applies the retention policy
+type Scheduler struct {
+	blockchain *blockchain.Blockchain
+	store      Store
+	config     *Config
+
+	runMu     sync.Mutex // serializes backup runs
+	mu        sync.Mutex
+	last      *Info
+	lastErr   error
+	lastRunAt time.Time
+	stopCh    chan struct{}
+}
+
+// NewScheduler creates a backup scheduler writing to store
+func NewScheduler(bc *blockchain.Blockchain, store Store, config *Config) *Scheduler {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &Scheduler{
+		blockchain: bc,
+		store:      store,
+		config:     config,
+	}
+}
+
+// Store returns the store backups are written to
+func (s *Scheduler) Store() Store {
+	return s.store
+}
+
+// Start begins taking backups every Interval
+func (s *Scheduler) Start() error {
+	if s.config.Interval <= 0 {
+		return errors.New("backup interval must be positive")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopCh != nil {
+		return errors.New("backup scheduler already running")
+	}
+	s.stopCh = make(chan struct{})
+
+	ticker := time.NewTicker(s.config.Interval)
+	go func(stopCh chan struct{}) {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.RunOnce(); err != nil {
+					log.Printf("Warning: Scheduled backup failed: %v", err)
+				}
+			case <-stopCh:
+				return
+			}
+		}
+	}(s.stopCh)
+
+	log.Printf("Backup scheduler started: every %v to %s", s.config.Interval, s.store.Location())
+	return nil
+}
+
+// Stop halts scheduled backups
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopCh != nil {
+		close(s.stopCh)
+		s.stopCh = nil
+	}
+}
+
+// RunOnce takes a backup now and prunes backups outside the retention policy
+func (s *Scheduler) RunOnce() (*Info, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	info, err := Create(s.blockchain, s.store)
+
+	s.mu.Lock()
+	s.lastRunAt = time.Now()
+	s.lastErr = err
+	if err == nil {
+		s.last = info
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.prune(); err != nil {
+		log.Printf("Warning: Failed to apply backup retention: %v", err)
+	}
+	return info, nil
+}
+
+// prune removes backups that are outside the retention policy
+func (s *Scheduler) prune() error {
+	backups, err := List(s.store)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-s.config.MaxAge).Unix()
+	for i, backup := range backups {
+		if i == 0 {
+			continue // never remove the newest backup
+		}
+		expired := s.config.MaxAge > 0 && backup.CreatedAt < cutoff
+		surplus := s.config.KeepLast > 0 && i >= s.config.KeepLast
+		if !expired && !surplus {
+			continue
+		}
+		if err := s.store.Delete(backup.Name); err != nil {
+			return err
+		}
+		log.Printf("Removed backup %s (retention policy)", backup.Name)
+	}
+	return nil
+}
+
+// Status returns the scheduler configuration and the outcome of the last run
+func (s *Scheduler) Status() *Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := &Status{
+		Location: s.store.Location(),
+		Interval: s.config.Interval.String(),
+		KeepLast: s.config.KeepLast,
+		MaxAge:   s.config.MaxAge.String(),
+		Running:  s.stopCh != nil,
+		Last:     s.last,
+	}
+	if s.lastErr != nil {
+		status.LastError = s.lastErr.Error()
+	}
+	if !s.lastRunAt.IsZero() {
+		status.LastRunAt = s.lastRunAt.Unix()
+	}
+	return status
+}
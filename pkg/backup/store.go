@@ -0,0 +1,127 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrObjectNotFound is returned when a store has no object with the requested name
+var ErrObjectNotFound = errors.New("object not found")
+
+// Object describes an object held by a Store
+type Object struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// Store is an object store backups are written to
+type Store interface {
+	// Put stores data under name, replacing any existing object
+	Put(name string, data []byte) error
+	// Get returns the contents of an object, or ErrObjectNotFound
+	Get(name string) ([]byte, error)
+	// List returns the objects whose name starts with prefix, ordered by name
+	List(prefix string) ([]Object, error)
+	// Delete removes an object; deleting a missing object is not an error
+	Delete(name string) error
+	// Location describes where the store keeps its objects
+	Location() string
+}
+
+// validObjectName rejects names that could escape the store
+func validObjectName(name string) error {
+	if name == "" || strings.Contains(name, "..") || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid object name %q", name)
+	}
+	return nil
+}
+
+// LocalStore keeps objects as files in a directory
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore creates a store in dir, creating the directory if needed
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %v", err)
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+// Put writes the object atomically via a temporary file
+func (s *LocalStore) Put(name string, data []byte) error {
+	if err := validObjectName(name); err != nil {
+		return err
+	}
+
+	path := filepath.Join(s.dir, name)
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to move %s into place: %v", name, err)
+	}
+	return nil
+}
+
+// Get reads an object
+func (s *LocalStore) Get(name string) ([]byte, error) {
+	if err := validObjectName(name); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, name))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, name)
+	}
+	return data, err
+}
+
+// List returns the files of the directory whose name starts with prefix
+func (s *LocalStore) List(prefix string) ([]Object, error) {
+	entries, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %v", err)
+	}
+
+	objects := make([]Object, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) || strings.HasSuffix(entry.Name(), ".tmp") {
+			continue
+		}
+		objects = append(objects, Object{
+			Name:     entry.Name(),
+			Size:     entry.Size(),
+			Modified: entry.ModTime(),
+		})
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Name < objects[j].Name })
+	return objects, nil
+}
+
+// Delete removes an object
+func (s *LocalStore) Delete(name string) error {
+	if err := validObjectName(name); err != nil {
+		return err
+	}
+
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Location returns the backup directory
+func (s *LocalStore) Location() string {
+	return s.dir
+}
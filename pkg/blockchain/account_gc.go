@@ -0,0 +1,100 @@
+package blockchain
+
+import (
+	"log"
+	"sort"
+)
+
+// AccountGCReport summarizes an account compaction
+type AccountGCReport struct {
+	Removed   int      `json:"removed"`
+	Remaining int      `json:"remaining"`
+	Kept      int      `json:"kept"`                // empty accounts kept because they are in use or exempt
+	Addresses []string `json:"addresses,omitempty"` // removed addresses, at most maxGCReportAddresses
+}
+
+// maxGCReportAddresses caps the removed addresses listed in a report
+const maxGCReportAddresses = 100
+
+// SetAccountGCExemptions sets addresses that compaction never removes, even when empty
+func (bc *Blockchain) SetAccountGCExemptions(addresses []string) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.gcExempt = make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		if address != "" {
+			bc.gcExempt[address] = true
+		}
+	}
+}
+
+// SetCompactAccountsOnSnapshot makes Snapshot compact the accounts before exporting the state
+func (bc *Blockchain) SetCompactAccountsOnSnapshot(enabled bool) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.gcOnSnapshot = enabled
+}
+
+// CompactAccounts removes empty accounts from the state and persists it. An
+// account is removed when its balance is zero and it is not a validator, an
+// admin, a multi-signature wallet, holding locked tokens, a key pair held by
+// this node or exempt. A removed account reads as a zero balance, as before.
+func (bc *Blockchain) CompactAccounts() (AccountGCReport, error) {
+	report := bc.compactAccounts()
+	if report.Removed == 0 {
+		return report, nil
+	}
+	log.Printf("Account compaction removed %d empty accounts, %d remain", report.Removed, report.Remaining)
+	return report, bc.SaveToDisk()
+}
+
+// compactAccounts removes the empty accounts without persisting the state
+func (bc *Blockchain) compactAccounts() AccountGCReport {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	admins := make(map[string]bool, len(bc.Admins))
+	for _, admin := range bc.Admins {
+		admins[admin] = true
+	}
+
+	var report AccountGCReport
+	var removed []string
+	for address, balance := range bc.accounts {
+		if balance.Sign() != 0 {
+			continue
+		}
+		if bc.accountInUseLocked(address, admins) {
+			report.Kept++
+			continue
+		}
+		delete(bc.accounts, address)
+		removed = append(removed, address)
+	}
+
+	sort.Strings(removed)
+	report.Removed = len(removed)
+	report.Remaining = len(bc.accounts)
+	if len(removed) > maxGCReportAddresses {
+		removed = removed[:maxGCReportAddresses]
+	}
+	report.Addresses = removed
+	return report
+}
+
+// accountInUseLocked reports whether an empty account must be kept. The caller must hold bc.mu.
+func (bc *Blockchain) accountInUseLocked(address string, admins map[string]bool) bool {
+	if bc.gcExempt[address] || bc.validators[address] || admins[address] {
+		return true
+	}
+	if _, exists := bc.multiSigWallets[address]; exists {
+		return true
+	}
+	if locked, exists := bc.lockedBalances[address]; exists && locked.Sign() > 0 {
+		return true
+	}
+	_, held := bc.keyPairs[address]
+	return held
+}
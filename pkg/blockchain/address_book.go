@@ -0,0 +1,183 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// addressBookFile holds the address books kept by this node
+const addressBookFile = "address_book.json"
+
+// Limits on address books, in bytes and entries
+const (
+	MaxContactName     = 64
+	MaxContactNote     = 256
+	MaxContactsPerBook = 500
+)
+
+var (
+	// ErrContactNotFound is returned when an address book has no contact of a name
+	ErrContactNotFound = errors.New("contact not found")
+	// ErrInvalidContact is returned for contacts that fail validation
+	ErrInvalidContact = errors.New("invalid contact")
+)
+
+// Contact is a named address in an address book. Names are unique within a
+// book, ignoring case, so transfers can name the recipient instead of giving
+// its address.
+type Contact struct {
+	Name      string `json:"name"`
+	Address   string `json:"address"`
+	Note      string `json:"note,omitempty"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+// contactKey is the key of a contact name within a book
+func contactKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// validate checks the contact fields
+func (c *Contact) validate() error {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Address = strings.TrimSpace(c.Address)
+	c.Note = strings.TrimSpace(c.Note)
+	switch {
+	case c.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidContact)
+	case len(c.Name) > MaxContactName:
+		return fmt.Errorf("%w: name exceeds %d bytes", ErrInvalidContact, MaxContactName)
+	case c.Address == "":
+		return fmt.Errorf("%w: address is required", ErrInvalidContact)
+	case len(c.Note) > MaxContactNote:
+		return fmt.Errorf("%w: note exceeds %d bytes", ErrInvalidContact, MaxContactNote)
+	}
+	return nil
+}
+
+// addressBookStore holds the address books by owner. Owners are opaque to the
+// chain; the API keys them by API key or by custodied wallet. Like labels,
+// address books are node metadata and have their own lock.
+type addressBookStore struct {
+	mu    sync.RWMutex
+	books map[string]map[string]Contact // owner -> contact key -> contact
+}
+
+// SaveContact adds a contact to the address book of owner, or replaces the
+// contact of the same name, and persists the books
+func (bc *Blockchain) SaveContact(owner string, contact Contact) (Contact, error) {
+	if owner == "" {
+		return contact, fmt.Errorf("%w: the address book owner is required", ErrInvalidContact)
+	}
+	if err := contact.validate(); err != nil {
+		return contact, err
+	}
+	now := time.Now().Unix()
+
+	bc.addressBook.mu.Lock()
+	defer bc.addressBook.mu.Unlock()
+	if bc.addressBook.books == nil {
+		bc.addressBook.books = make(map[string]map[string]Contact)
+	}
+	book := bc.addressBook.books[owner]
+	if book == nil {
+		book = make(map[string]Contact)
+		bc.addressBook.books[owner] = book
+	}
+	key := contactKey(contact.Name)
+	if existing, exists := book[key]; exists {
+		contact.CreatedAt = existing.CreatedAt
+	} else if len(book) >= MaxContactsPerBook {
+		return contact, fmt.Errorf("%w: the address book holds the maximum of %d contacts", ErrInvalidContact, MaxContactsPerBook)
+	} else {
+		contact.CreatedAt = now
+	}
+	contact.UpdatedAt = now
+	book[key] = contact
+	return contact, bc.saveAddressBookLocked(GetBlockchainDataPath())
+}
+
+// RemoveContact deletes a contact from the address book of owner
+func (bc *Blockchain) RemoveContact(owner, name string) error {
+	bc.addressBook.mu.Lock()
+	defer bc.addressBook.mu.Unlock()
+	book := bc.addressBook.books[owner]
+	if _, exists := book[contactKey(name)]; !exists {
+		return fmt.Errorf("%w: %s", ErrContactNotFound, name)
+	}
+	delete(book, contactKey(name))
+	if len(book) == 0 {
+		delete(bc.addressBook.books, owner)
+	}
+	return bc.saveAddressBookLocked(GetBlockchainDataPath())
+}
+
+// Contacts returns the address book of owner ordered by name
+func (bc *Blockchain) Contacts(owner string) []Contact {
+	bc.addressBook.mu.RLock()
+	book := bc.addressBook.books[owner]
+	result := make([]Contact, 0, len(book))
+	for _, contact := range book {
+		result = append(result, contact)
+	}
+	bc.addressBook.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool { return contactKey(result[i].Name) < contactKey(result[j].Name) })
+	return result
+}
+
+// ResolveContact returns the contact of a name in the address book of owner
+func (bc *Blockchain) ResolveContact(owner, name string) (Contact, error) {
+	bc.addressBook.mu.RLock()
+	defer bc.addressBook.mu.RUnlock()
+	contact, exists := bc.addressBook.books[owner][contactKey(name)]
+	if !exists {
+		return Contact{}, fmt.Errorf("%w: %s", ErrContactNotFound, name)
+	}
+	return contact, nil
+}
+
+// saveAddressBookLocked writes the address books to dir. The caller must hold
+// bc.addressBook.mu.
+func (bc *Blockchain) saveAddressBookLocked(dir string) error {
+	data, err := json.MarshalIndent(bc.addressBook.books, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal address books: %v", err)
+	}
+
+	path := filepath.Join(dir, addressBookFile)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write address books: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadAddressBook reads the address books from dir
+func (bc *Blockchain) loadAddressBook(dir string) {
+	bc.addressBook.mu.Lock()
+	defer bc.addressBook.mu.Unlock()
+
+	bc.addressBook.books = make(map[string]map[string]Contact)
+	raw, err := ioutil.ReadFile(filepath.Join(dir, addressBookFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to read address books: %v", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(raw, &bc.addressBook.books); err != nil {
+		log.Printf("Warning: Failed to parse address books: %v", err)
+		bc.addressBook.books = make(map[string]map[string]Contact)
+	}
+}
@@ -0,0 +1,323 @@
+package blockchain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// apiKeysFile holds the API keys issued by this node
+const apiKeysFile = "api_keys.json"
+
+// Scopes an API key can be granted. Admin implies the other scopes.
+const (
+	APIScopeRead     = "read"
+	APIScopeTxSubmit = "tx-submit"
+	APIScopeAdmin    = "admin"
+	APIScopeReplica  = "replica" // follow the block stream, see /api/replica/blocks
+)
+
+// APIKeyPrefix starts every issued API key so leaked keys are easy to spot
+const APIKeyPrefix = "cmx_"
+
+// DefaultAPIKeyRateLimit is the requests per minute of keys created without a limit
+const DefaultAPIKeyRateLimit = 600
+
+// MaxAPIKeyName is the longest key name, in bytes
+const MaxAPIKeyName = 64
+
+// apiKeyUsageFlushInterval bounds how often usage counters are written to disk
+const apiKeyUsageFlushInterval = time.Minute
+
+var (
+	// ErrAPIKeyNotFound is returned for an unknown key ID
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrInvalidAPIKey is returned for a key that is unknown, revoked or malformed
+	ErrInvalidAPIKey = errors.New("invalid api key")
+)
+
+// APIKeyOutcome is what happened to a request made with an API key
+type APIKeyOutcome int
+
+const (
+	APIKeyServed    APIKeyOutcome = iota // the request was let through
+	APIKeyThrottled                      // refused by the key's rate limit
+	APIKeyDenied                         // refused because the key lacks the scope
+)
+
+// APIKeyUsage meters the requests made with a key
+type APIKeyUsage struct {
+	Requests   uint64 `json:"requests"`
+	Throttled  uint64 `json:"throttled"`
+	Denied     uint64 `json:"denied"`
+	LastUsedAt int64  `json:"lastUsedAt,omitempty"`
+}
+
+// APIKey is a credential issued to an API tenant. The secret is returned once
+// on creation; the node keeps only its hash.
+type APIKey struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Scopes    []string    `json:"scopes"`
+	RateLimit int         `json:"rateLimit"` // requests per minute, 0 = unlimited
+	Hint      string      `json:"hint"`      // last characters of the secret
+	CreatedBy string      `json:"createdBy"`
+	CreatedAt int64       `json:"createdAt"`
+	RevokedAt int64       `json:"revokedAt,omitempty"`
+	Usage     APIKeyUsage `json:"usage"`
+}
+
+// HasScope reports whether the key grants scope
+func (k APIKey) HasScope(scope string) bool {
+	for _, granted := range k.Scopes {
+		if granted == scope || granted == APIScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Revoked reports whether the key can no longer be used
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != 0
+}
+
+// ParseAPIScopes parses a comma-separated scope list
+func ParseAPIScopes(list string) ([]string, error) {
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, scope := range strings.Split(list, ",") {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope == "" || seen[scope] {
+			continue
+		}
+		switch scope {
+		case APIScopeRead, APIScopeTxSubmit, APIScopeAdmin, APIScopeReplica:
+		default:
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIKey, scope)
+		}
+		seen[scope] = true
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKey)
+	}
+	return scopes, nil
+}
+
+// storedAPIKey is an API key as persisted, with the hash of its secret
+type storedAPIKey struct {
+	APIKey
+	Hash string `json:"hash"`
+}
+
+// apiKeyStore holds the API keys. Like labels it has its own lock because keys
+// are node metadata, not chain state.
+type apiKeyStore struct {
+	mu       sync.RWMutex
+	keys     map[string]*storedAPIKey // key ID -> key
+	byHash   map[string]string        // secret hash -> key ID
+	lastSave time.Time
+}
+
+// hashAPIKey returns the stored form of a secret
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey issues a key with the given scopes and rate limit (requests per
+// minute; negative means unlimited, zero the default) and returns its secret
+func (bc *Blockchain) CreateAPIKey(name string, scopes []string, rateLimit int, createdBy string) (string, APIKey, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", APIKey{}, fmt.Errorf("%w: name is required", ErrInvalidAPIKey)
+	case len(name) > MaxAPIKeyName:
+		return "", APIKey{}, fmt.Errorf("%w: name exceeds %d bytes", ErrInvalidAPIKey, MaxAPIKeyName)
+	case len(scopes) == 0:
+		return "", APIKey{}, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKey)
+	}
+	switch {
+	case rateLimit < 0:
+		rateLimit = 0
+	case rateLimit == 0:
+		rateLimit = DefaultAPIKeyRateLimit
+	}
+
+	random := make([]byte, 24)
+	if _, err := rand.Read(random); err != nil {
+		return "", APIKey{}, fmt.Errorf("failed to generate api key: %v", err)
+	}
+	secret := APIKeyPrefix + hex.EncodeToString(random)
+
+	key := &storedAPIKey{
+		APIKey: APIKey{
+			ID:        uuid.New().String(),
+			Name:      name,
+			Scopes:    append([]string(nil), scopes...),
+			RateLimit: rateLimit,
+			Hint:      secret[len(secret)-4:],
+			CreatedBy: createdBy,
+			CreatedAt: time.Now().Unix(),
+		},
+		Hash: hashAPIKey(secret),
+	}
+
+	bc.apiKeys.mu.Lock()
+	defer bc.apiKeys.mu.Unlock()
+	if bc.apiKeys.keys == nil {
+		bc.apiKeys.keys = make(map[string]*storedAPIKey)
+		bc.apiKeys.byHash = make(map[string]string)
+	}
+	bc.apiKeys.keys[key.ID] = key
+	bc.apiKeys.byHash[key.Hash] = key.ID
+	if err := bc.saveAPIKeysLocked(GetBlockchainDataPath()); err != nil {
+		return "", APIKey{}, err
+	}
+	return secret, key.APIKey, nil
+}
+
+// RevokeAPIKey disables a key. Revoked keys are kept so their usage stays on record.
+func (bc *Blockchain) RevokeAPIKey(id string) (APIKey, error) {
+	bc.apiKeys.mu.Lock()
+	defer bc.apiKeys.mu.Unlock()
+	key, exists := bc.apiKeys.keys[id]
+	if !exists {
+		return APIKey{}, fmt.Errorf("%w: %s", ErrAPIKeyNotFound, id)
+	}
+	if !key.Revoked() {
+		key.RevokedAt = time.Now().Unix()
+		delete(bc.apiKeys.byHash, key.Hash)
+	}
+	return key.APIKey, bc.saveAPIKeysLocked(GetBlockchainDataPath())
+}
+
+// AuthenticateAPIKey returns the active key whose secret is given
+func (bc *Blockchain) AuthenticateAPIKey(secret string) (APIKey, error) {
+	if !strings.HasPrefix(secret, APIKeyPrefix) {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+	bc.apiKeys.mu.RLock()
+	defer bc.apiKeys.mu.RUnlock()
+	id, exists := bc.apiKeys.byHash[hashAPIKey(secret)]
+	if !exists {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+	return bc.apiKeys.keys[id].APIKey, nil
+}
+
+// GetAPIKey returns a key and its usage
+func (bc *Blockchain) GetAPIKey(id string) (APIKey, bool) {
+	bc.apiKeys.mu.RLock()
+	defer bc.apiKeys.mu.RUnlock()
+	key, exists := bc.apiKeys.keys[id]
+	if !exists {
+		return APIKey{}, false
+	}
+	return key.APIKey, true
+}
+
+// APIKeys returns every key, revoked ones included, oldest first
+func (bc *Blockchain) APIKeys() []APIKey {
+	bc.apiKeys.mu.RLock()
+	result := make([]APIKey, 0, len(bc.apiKeys.keys))
+	for _, key := range bc.apiKeys.keys {
+		result = append(result, key.APIKey)
+	}
+	bc.apiKeys.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CreatedAt != result[j].CreatedAt {
+			return result[i].CreatedAt < result[j].CreatedAt
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// RecordAPIKeyUsage meters a request made with a key. Counters are written to
+// disk at most once per apiKeyUsageFlushInterval.
+func (bc *Blockchain) RecordAPIKeyUsage(id string, outcome APIKeyOutcome) {
+	bc.apiKeys.mu.Lock()
+	defer bc.apiKeys.mu.Unlock()
+	key, exists := bc.apiKeys.keys[id]
+	if !exists {
+		return
+	}
+
+	switch outcome {
+	case APIKeyThrottled:
+		key.Usage.Throttled++
+	case APIKeyDenied:
+		key.Usage.Denied++
+	default:
+		key.Usage.Requests++
+	}
+	key.Usage.LastUsedAt = time.Now().Unix()
+
+	if time.Since(bc.apiKeys.lastSave) >= apiKeyUsageFlushInterval {
+		if err := bc.saveAPIKeysLocked(GetBlockchainDataPath()); err != nil {
+			log.Printf("Warning: Failed to save api key usage: %v", err)
+		}
+	}
+}
+
+// saveAPIKeysLocked writes the keys to dir. The caller must hold bc.apiKeys.mu.
+func (bc *Blockchain) saveAPIKeysLocked(dir string) error {
+	data, err := json.MarshalIndent(bc.apiKeys.keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal api keys: %v", err)
+	}
+
+	path := filepath.Join(dir, apiKeysFile)
+	tmp := path + ".tmp"
+	// The file holds only hashes, but usage and scopes are still private
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write api keys: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	bc.apiKeys.lastSave = time.Now()
+	return nil
+}
+
+// loadAPIKeys reads the keys from dir
+func (bc *Blockchain) loadAPIKeys(dir string) {
+	bc.apiKeys.mu.Lock()
+	defer bc.apiKeys.mu.Unlock()
+
+	bc.apiKeys.keys = make(map[string]*storedAPIKey)
+	bc.apiKeys.byHash = make(map[string]string)
+	raw, err := ioutil.ReadFile(filepath.Join(dir, apiKeysFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to read api keys: %v", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(raw, &bc.apiKeys.keys); err != nil {
+		log.Printf("Warning: Failed to parse api keys: %v", err)
+		bc.apiKeys.keys = make(map[string]*storedAPIKey)
+		return
+	}
+	for id, key := range bc.apiKeys.keys {
+		if !key.Revoked() {
+			bc.apiKeys.byHash[key.Hash] = id
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package blockchain
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sync"
+	"time"
+)
+
+// ArchiveStore is the object storage old block ranges are offloaded to.
+// The stores of pkg/backup (local directory, S3, GCS) implement it.
+type ArchiveStore interface {
+	Put(name string, data []byte) error
+	Get(name string) ([]byte, error)
+	Location() string
+}
+
+// ArchiveConfig controls which blocks are offloaded to the archive
+type ArchiveConfig struct {
+	SegmentSize   uint64 // blocks per archived object
+	KeepRecent    uint64 // most recent blocks always kept in full locally
+	CacheSegments int    // fetched segments kept in memory for repeated historical queries
+}
+
+// DefaultArchiveConfig returns the default archive settings
+func DefaultArchiveConfig() *ArchiveConfig {
+	return &ArchiveConfig{
+		SegmentSize:   1000,
+		KeepRecent:    10000,
+		CacheSegments: 4,
+	}
+}
+
+// ArchiveStatus reports the state of the block archive
+type ArchiveStatus struct {
+	Enabled        bool   `json:"enabled"`
+	Location       string `json:"location,omitempty"`
+	SegmentSize    uint64 `json:"segmentSize,omitempty"`
+	KeepRecent     uint64 `json:"keepRecent,omitempty"`
+	PrunedBlocks   uint64 `json:"prunedBlocks"`
+	CachedSegments int    `json:"cachedSegments"`
+	Fetches        uint64 `json:"fetches"`
+}
+
+// blockArchive offloads the bodies of old blocks to an ArchiveStore and
+// caches segments fetched back for historical queries
+type blockArchive struct {
+	store  ArchiveStore
+	config *ArchiveConfig
+
+	runMu   sync.Mutex // serializes archiving runs
+	mu      sync.Mutex
+	cache   map[string][]*Block
+	order   []string // cached segment names, least recently used first
+	fetches uint64
+}
+
+// IsPruned reports whether the block's body has been offloaded to the archive.
+// A pruned block keeps its header and the human proof registrations needed to rebuild state.
+func (b *Block) IsPruned() bool {
+	return b.Archive != ""
+}
+
+// segmentName returns the archive object holding blocks [first, first+size)
+func segmentName(first, size uint64) string {
+	return fmt.Sprintf("blocks-%012d-%012d.json.gz", first, first+size-1)
+}
+
+// SetArchive enables offloading old block bodies to store. A nil store disables the archive;
+// blocks already pruned then return their pruned form.
+func (bc *Blockchain) SetArchive(store ArchiveStore, config *ArchiveConfig) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if store == nil {
+		bc.archive = nil
+		return
+	}
+	if config == nil {
+		config = DefaultArchiveConfig()
+	}
+	if config.SegmentSize == 0 {
+		config.SegmentSize = DefaultArchiveConfig().SegmentSize
+	}
+	if config.KeepRecent == 0 {
+		config.KeepRecent = 1 // the chain tip is needed to verify new blocks
+	}
+	bc.archive = &blockArchive{
+		store:  store,
+		config: config,
+		cache:  make(map[string][]*Block),
+	}
+}
+
+// prunedCopy returns the local form of an archived block. Human proof registrations,
+// double-signing evidence and oracle transactions stay local because their registries
+// are rebuilt from them on startup.
+func prunedCopy(block *Block, archive string) *Block {
+	pruned := *block
+	pruned.Transactions = nil
+	for _, tx := range block.Transactions {
+		if tx.Type == HumanProofTxType || tx.Type == SlashEvidenceTxType || isOracleTransaction(tx) {
+			pruned.Transactions = append(pruned.Transactions, tx)
+		}
+	}
+	pruned.Archive = archive
+	pruned.TxCount = len(block.Transactions)
+	return &pruned
+}
+
+// ArchiveOldBlocks offloads every complete segment older than KeepRecent blocks
+// to the archive store, replaces those blocks by their pruned form and saves the chain.
+// It returns the number of blocks archived.
+func (bc *Blockchain) ArchiveOldBlocks() (int, error) {
+	bc.mu.RLock()
+	archive := bc.archive
+	bc.mu.RUnlock()
+	if archive == nil {
+		return 0, errors.New("block archive is not configured")
+	}
+
+	archive.runMu.Lock()
+	defer archive.runMu.Unlock()
+
+	size := archive.config.SegmentSize
+	archived := 0
+	for first := uint64(0); ; first += size {
+		bc.mu.RLock()
+		height := uint64(len(bc.Blocks))
+		if height <= archive.config.KeepRecent || first+size > height-archive.config.KeepRecent {
+			bc.mu.RUnlock()
+			break
+		}
+		segment := bc.Blocks[first : first+size]
+		if segment[0].IsPruned() {
+			bc.mu.RUnlock()
+			continue
+		}
+		data, err := json.Marshal(segment)
+		bc.mu.RUnlock()
+		if err != nil {
+			return archived, fmt.Errorf("failed to marshal blocks %d-%d: %v", first, first+size-1, err)
+		}
+
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(data); err != nil {
+			return archived, fmt.Errorf("failed to compress blocks %d-%d: %v", first, first+size-1, err)
+		}
+		if err := gz.Close(); err != nil {
+			return archived, fmt.Errorf("failed to compress blocks %d-%d: %v", first, first+size-1, err)
+		}
+
+		name := segmentName(first, size)
+		if err := archive.store.Put(name, buf.Bytes()); err != nil {
+			return archived, fmt.Errorf("failed to upload %s: %v", name, err)
+		}
+
+		// Archived blocks are final, so the pointers read above are still the chain's blocks
+		bc.mu.Lock()
+		for i := first; i < first+size; i++ {
+			bc.Blocks[i] = prunedCopy(bc.Blocks[i], name)
+		}
+		bc.mu.Unlock()
+
+		archived += int(size)
+		log.Printf("Archived blocks %d-%d to %s/%s", first, first+size-1, archive.store.Location(), name)
+	}
+
+	if archived > 0 {
+		if err := bc.SaveToDisk(); err != nil {
+			return archived, fmt.Errorf("failed to save pruned chain: %v", err)
+		}
+	}
+	return archived, nil
+}
+
+// StartArchiveRoutine periodically offloads old blocks to the archive
+func (bc *Blockchain) StartArchiveRoutine(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			if _, err := bc.ArchiveOldBlocks(); err != nil {
+				log.Printf("Warning: Block archiving failed: %v", err)
+			}
+		}
+	}()
+}
+
+// hydrateBlock returns the full block for a pruned block, fetching its segment
+// from the archive when it is not cached. Blocks that are not pruned are returned as is.
+// The caller must not hold bc.mu, since fetching can take a while.
+func (bc *Blockchain) hydrateBlock(block *Block) (*Block, error) {
+	if !block.IsPruned() {
+		return block, nil
+	}
+
+	bc.mu.RLock()
+	archive := bc.archive
+	bc.mu.RUnlock()
+	if archive == nil {
+		// Without an archive only the header and local registrations are available
+		return block, nil
+	}
+
+	segment, err := archive.segment(block.Archive)
+	if err != nil {
+		return nil, fmt.Errorf("%w: block %d: %v", ErrArchiveUnavailable, block.Index, err)
+	}
+	for _, full := range segment {
+		if full.Index == block.Index {
+			if full.Hash != block.Hash {
+				return nil, fmt.Errorf("%w: block %d: archived hash %s does not match chain hash %s", ErrArchiveUnavailable, block.Index, full.Hash, block.Hash)
+			}
+			return full, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: block %d is missing from %s", ErrArchiveUnavailable, block.Index, block.Archive)
+}
+
+// segment returns the blocks of an archived segment, from the cache or the store
+func (a *blockArchive) segment(name string) ([]*Block, error) {
+	a.mu.Lock()
+	if blocks, cached := a.cache[name]; cached {
+		a.touchLocked(name)
+		a.mu.Unlock()
+		return blocks, nil
+	}
+	a.mu.Unlock()
+
+	data, err := a.store.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive object %s: %v", name, err)
+	}
+	defer gz.Close()
+	raw, err := ioutil.ReadAll(gz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive object %s: %v", name, err)
+	}
+
+	var blocks []*Block
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return nil, fmt.Errorf("invalid archive object %s: %v", name, err)
+	}
+
+	// The archive is not trusted: every block must hash to its recorded hash
+	for _, block := range blocks {
+		if block.Hash != block.CalculateHash() {
+			return nil, fmt.Errorf("archived block %d does not match its hash", block.Index)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.fetches++
+	a.cache[name] = blocks
+	a.touchLocked(name)
+	for len(a.order) > a.config.CacheSegments && len(a.order) > 0 {
+		delete(a.cache, a.order[0])
+		a.order = a.order[1:]
+	}
+	return blocks, nil
+}
+
+// touchLocked marks a cached segment as most recently used. The caller must hold a.mu.
+func (a *blockArchive) touchLocked(name string) {
+	for i, cached := range a.order {
+		if cached == name {
+			a.order = append(a.order[:i], a.order[i+1:]...)
+			break
+		}
+	}
+	a.order = append(a.order, name)
+}
+
+// GetArchiveStatus reports the archive configuration and usage
+func (bc *Blockchain) GetArchiveStatus() ArchiveStatus {
+	bc.mu.RLock()
+	archive := bc.archive
+	var pruned uint64
+	for _, block := range bc.Blocks {
+		if !block.IsPruned() {
+			break // blocks are archived oldest first
+		}
+		pruned++
+	}
+	bc.mu.RUnlock()
+
+	status := ArchiveStatus{PrunedBlocks: pruned}
+	if archive == nil {
+		return status
+	}
+
+	archive.mu.Lock()
+	defer archive.mu.Unlock()
+	status.Enabled = true
+	status.Location = archive.store.Location()
+	status.SegmentSize = archive.config.SegmentSize
+	status.KeepRecent = archive.config.KeepRecent
+	status.CachedSegments = len(archive.cache)
+	status.Fetches = archive.fetches
+	return status
+}
@@ -0,0 +1,175 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// balanceHistoryFile holds the balance journal next to the chain state
+const balanceHistoryFile = "balance_history.json"
+
+// ErrHeightNotReached is returned for balance queries above the chain height
+var ErrHeightNotReached = errors.New("height is above the current chain height")
+
+// BalancePoint is the balance of an address after the block at Height was applied
+type BalancePoint struct {
+	Height  uint64   `json:"height"`
+	Balance *big.Int `json:"balance"`
+}
+
+// balanceHistoryData is the persisted form of the balance journal
+type balanceHistoryData struct {
+	Height  uint64                    `json:"height"`
+	TipHash string                    `json:"tipHash"`
+	Points  map[string][]BalancePoint `json:"points"`
+}
+
+// blockAddresses returns the addresses whose balances a block may change
+func blockAddresses(block *Block) []string {
+	seen := make(map[string]bool)
+	var addresses []string
+	for _, tx := range block.Transactions {
+		for _, address := range []string{tx.From, tx.To} {
+			if address != "" && !seen[address] {
+				seen[address] = true
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return addresses
+}
+
+// snapshotBalancesLocked returns copies of the current balances of addresses.
+// The caller must hold bc.mu.
+func (bc *Blockchain) snapshotBalancesLocked(addresses []string) map[string]*big.Int {
+	balances := make(map[string]*big.Int, len(addresses))
+	for _, address := range addresses {
+		balance := big.NewInt(0)
+		if current, exists := bc.accounts[address]; exists && current != nil {
+			balance.Set(current)
+		}
+		balances[address] = balance
+	}
+	return balances
+}
+
+// recordBalancesLocked journals the balances a block changed. Addresses seen for the
+// first time also get their balance before the block, so earlier heights resolve too.
+// The caller must hold bc.mu.
+func (bc *Blockchain) recordBalancesLocked(block *Block, before map[string]*big.Int) {
+	if bc.balanceHistory == nil {
+		bc.balanceHistory = make(map[string][]BalancePoint)
+	}
+	after := bc.snapshotBalancesLocked(blockAddresses(block))
+	for address, balance := range after {
+		previous, known := before[address]
+		if !known {
+			previous = big.NewInt(0)
+		}
+		if previous.Cmp(balance) == 0 {
+			continue
+		}
+
+		points := bc.balanceHistory[address]
+		if len(points) == 0 && block.Index > 0 {
+			points = append(points, BalancePoint{Height: block.Index - 1, Balance: previous})
+		}
+		bc.balanceHistory[address] = append(points, BalancePoint{Height: block.Index, Balance: balance})
+	}
+}
+
+// GetBalanceAtHeight returns the balance of address after the block at height was applied.
+// Balances only change through blocks in the journal; an address without entries at or
+// below height had no balance then, and one without any entries never changed through a block.
+func (bc *Blockchain) GetBalanceAtHeight(address string, height uint64) (*big.Int, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if height >= uint64(len(bc.Blocks)) {
+		return nil, fmt.Errorf("%w: %d > %d", ErrHeightNotReached, height, len(bc.Blocks)-1)
+	}
+
+	points := bc.balanceHistory[address]
+	if len(points) == 0 {
+		if balance, exists := bc.accounts[address]; exists && balance != nil {
+			return new(big.Int).Set(balance), nil
+		}
+		return big.NewInt(0), nil
+	}
+
+	// Last point at or below height
+	i := sort.Search(len(points), func(i int) bool { return points[i].Height > height })
+	if i == 0 {
+		return big.NewInt(0), nil
+	}
+	return new(big.Int).Set(points[i-1].Balance), nil
+}
+
+// GetBalanceHistory returns the journaled balance changes of an address, oldest first
+func (bc *Blockchain) GetBalanceHistory(address string) []BalancePoint {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	points := bc.balanceHistory[address]
+	history := make([]BalancePoint, len(points))
+	for i, point := range points {
+		history[i] = BalancePoint{Height: point.Height, Balance: new(big.Int).Set(point.Balance)}
+	}
+	return history
+}
+
+// saveBalanceHistoryLocked writes the journal to dir. The caller must hold bc.mu.
+func (bc *Blockchain) saveBalanceHistoryLocked(dir string) error {
+	data, err := json.Marshal(balanceHistoryData{
+		Height:  uint64(len(bc.Blocks)),
+		TipHash: bc.tipHashLocked(),
+		Points:  bc.balanceHistory,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal balance history: %v", err)
+	}
+
+	path := filepath.Join(dir, balanceHistoryFile)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write balance history: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadBalanceHistoryLocked reads the journal from dir. The journal cannot be rebuilt from
+// blocks because accounts also change outside them, so a journal written for another tip
+// (e.g. before a restore) keeps only the points the loaded chain can contain.
+// The caller must hold bc.mu.
+func (bc *Blockchain) loadBalanceHistoryLocked(dir string) {
+	bc.balanceHistory = make(map[string][]BalancePoint)
+
+	raw, err := ioutil.ReadFile(filepath.Join(dir, balanceHistoryFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to read balance history: %v", err)
+		}
+		return
+	}
+	var data balanceHistoryData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		log.Printf("Warning: Ignoring unreadable balance history: %v", err)
+		return
+	}
+
+	if data.TipHash != bc.tipHashLocked() {
+		log.Printf("Warning: Balance history was written for another chain tip, dropping entries above height %d", len(bc.Blocks)-1)
+		for address, points := range data.Points {
+			i := sort.Search(len(points), func(i int) bool { return points[i].Height >= uint64(len(bc.Blocks)) })
+			data.Points[address] = points[:i]
+		}
+	}
+	bc.balanceHistory = data.Points
+}
@@ -0,0 +1,331 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// balanceProofSigningDomain keeps a balance proof signature from being valid for anything else
+const balanceProofSigningDomain = "confirmix/balance-proof"
+
+// Kinds of entries in a balance proof
+const (
+	BalanceEntryTransferIn  = "transfer_in"  // received in a transaction or a node-side transfer
+	BalanceEntryTransferOut = "transfer_out" // sent in a transaction or a node-side transfer
+	BalanceEntryReward      = "reward"       // block or epoch reward
+	BalanceEntryLock        = "lock"         // moved to the locked balance, e.g. a stake or deposit
+	BalanceEntryUnlock      = "unlock"       // returned from the locked balance
+	BalanceEntrySlash       = "slash"        // burned from the locked balance
+	BalanceEntryRevert      = "revert"       // reversal of a confirmed transaction
+)
+
+// BalanceProofEntry is one event that changed the spendable or locked
+// balance of an address. Changes are signed decimal strings in base units.
+type BalanceProofEntry struct {
+	Timestamp    int64  `json:"timestamp"`
+	BlockIndex   uint64 `json:"blockIndex"`
+	BlockHash    string `json:"blockHash,omitempty"` // empty for events not yet committed with a block
+	TxID         string `json:"txId,omitempty"`
+	EventSeq     uint64 `json:"eventSeq,omitempty"` // journal sequence of non-transaction events
+	Kind         string `json:"kind"`
+	Type         string `json:"type"` // transaction or event type
+	Counterparty string `json:"counterparty,omitempty"`
+	Change       string `json:"change"`
+	LockedChange string `json:"lockedChange"`
+	Balance      string `json:"balance"` // running spendable balance after this entry
+	Locked       string `json:"locked"`  // running locked balance after this entry
+}
+
+// BalanceProof lists every event that changed the balance of an address over
+// a time range, with running balances, and is signed by the node that built
+// it. Support staff use it to settle disputes about missing funds: the
+// customer can check the signature and each block reference independently.
+type BalanceProof struct {
+	Address        string              `json:"address"`
+	From           int64               `json:"from"`
+	To             int64               `json:"to"`
+	Height         uint64              `json:"height"` // chain height the proof was built at
+	TipHash        string              `json:"tipHash"`
+	GeneratedAt    int64               `json:"generatedAt"`
+	OpeningBalance string              `json:"openingBalance"`
+	OpeningLocked  string              `json:"openingLocked"`
+	ClosingBalance string              `json:"closingBalance"`
+	ClosingLocked  string              `json:"closingLocked"`
+	Entries        []BalanceProofEntry `json:"entries"`
+
+	// Signer is the node address whose key signed the proof
+	Signer    string `json:"signer"`
+	PublicKey string `json:"publicKey"` // hex
+	ChainID   string `json:"chainId"`
+	SigScheme int    `json:"sigScheme"`
+	Signature []byte `json:"signature"`
+}
+
+// balanceChange is a proof entry with its effects still as integers
+type balanceChange struct {
+	entry          BalanceProofEntry
+	change, locked *big.Int
+}
+
+// eventBalanceChange returns the effect of a journal event on the spendable
+// and locked balance of address, or ok false when it has none
+func eventBalanceChange(event ChainEvent, address string) (balanceChange, bool) {
+	amount, valid := new(big.Int).SetString(event.Data["amount"], 10)
+	if !valid {
+		return balanceChange{}, false
+	}
+	change := balanceChange{
+		entry: BalanceProofEntry{
+			Timestamp:  event.Timestamp,
+			BlockIndex: event.BlockIndex,
+			BlockHash:  event.BlockHash,
+			EventSeq:   event.Seq,
+			Type:       event.Type,
+		},
+		change: big.NewInt(0),
+		locked: big.NewInt(0),
+	}
+
+	switch event.Type {
+	case EventBalanceLocked, EventBalanceUnlocked, EventValidatorSlashed:
+		if event.Subject != address {
+			return balanceChange{}, false
+		}
+		switch event.Type {
+		case EventBalanceLocked:
+			change.entry.Kind = BalanceEntryLock
+			change.change.Neg(amount)
+			change.locked.Set(amount)
+		case EventBalanceUnlocked:
+			change.entry.Kind = BalanceEntryUnlock
+			change.change.Set(amount)
+			change.locked.Neg(amount)
+		default:
+			change.entry.Kind = BalanceEntrySlash
+			change.locked.Neg(amount)
+		}
+	case EventBalanceTransfer, EventTxReverted:
+		from, to := event.Data["from"], event.Data["to"]
+		if from == to || (from != address && to != address) {
+			return balanceChange{}, false
+		}
+		if from == address {
+			change.entry.Kind = BalanceEntryTransferOut
+			change.entry.Counterparty = to
+			change.change.Neg(amount)
+		} else {
+			change.entry.Kind = BalanceEntryTransferIn
+			change.entry.Counterparty = from
+			change.change.Set(amount)
+		}
+		if event.Type == EventTxReverted {
+			change.entry.Kind = BalanceEntryRevert
+			change.entry.TxID = event.Subject
+		}
+	default:
+		return balanceChange{}, false
+	}
+	return change, true
+}
+
+// txBalanceChange returns the effect of a confirmed transaction on the
+// spendable balance of address, or ok false when it has none
+func txBalanceChange(block *Block, tx *Transaction, address string) (balanceChange, bool) {
+	if tx.Status == "failed" {
+		return balanceChange{}, false
+	}
+	effect := txBalanceEffect(tx, address)
+	if effect.Sign() == 0 {
+		return balanceChange{}, false
+	}
+	entry := BalanceProofEntry{
+		Timestamp:  block.Timestamp,
+		BlockIndex: block.Index,
+		BlockHash:  block.Hash,
+		TxID:       tx.ID,
+		Type:       tx.Type,
+	}
+	if entry.Type == "" {
+		entry.Type = "regular"
+	}
+	switch {
+	case tx.Type == RewardTxType || tx.Type == EpochRewardTxType:
+		entry.Kind = BalanceEntryReward
+		entry.Counterparty = tx.From
+	case effect.Sign() > 0:
+		entry.Kind = BalanceEntryTransferIn
+		entry.Counterparty = tx.From
+	default:
+		entry.Kind = BalanceEntryTransferOut
+		entry.Counterparty = tx.To
+	}
+	return balanceChange{entry: entry, change: effect, locked: big.NewInt(0)}, true
+}
+
+// BuildBalanceProof lists the balance-affecting events of address in the
+// range from <= timestamp <= to (unix seconds; to == 0 means up to now): the
+// transactions applied by confirmed blocks and the journal events for locks,
+// unlocks, slashes, node-side transfers and reverts. Events are listed before
+// the transactions of the block they were committed with, as most were
+// recorded since the previous block. Like account statements, balances are
+// anchored on the current balances and walked back. The proof is returned
+// unsigned, see Sign.
+func (bc *Blockchain) BuildBalanceProof(address string, from, to int64) (*BalanceProof, error) {
+	if address == "" {
+		return nil, errors.New("address is required")
+	}
+	if to != 0 && to < from {
+		return nil, errors.New("proof end must not be before its start")
+	}
+
+	// Committed events by the block they were committed with
+	eventsByBlock := make(map[uint64][]ChainEvent)
+	for _, event := range bc.Events(0, 0, "", math.MaxInt32) {
+		eventsByBlock[event.BlockIndex] = append(eventsByBlock[event.BlockIndex], event)
+	}
+	pending := bc.PendingEvents()
+
+	bc.mu.RLock()
+	bc.mutex.RLock()
+	proof := &BalanceProof{
+		Address:     address,
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now().Unix(),
+		Entries:     []BalanceProofEntry{},
+	}
+	current := big.NewInt(0)
+	if balance, exists := bc.accounts[address]; exists && balance != nil {
+		current.Set(balance)
+	}
+	currentLocked := big.NewInt(0)
+	if locked, exists := bc.lockedBalances[address]; exists && locked != nil {
+		currentLocked.Set(locked)
+	}
+
+	var changes []balanceChange
+	for _, block := range bc.Blocks {
+		for _, event := range eventsByBlock[block.Index] {
+			if change, ok := eventBalanceChange(event, address); ok {
+				changes = append(changes, change)
+			}
+		}
+		for _, tx := range block.Transactions {
+			if change, ok := txBalanceChange(block, tx, address); ok {
+				changes = append(changes, change)
+			}
+		}
+	}
+	if len(bc.Blocks) > 0 {
+		tip := bc.Blocks[len(bc.Blocks)-1]
+		proof.Height, proof.TipHash = tip.Index, tip.Hash
+	}
+	bc.mutex.RUnlock()
+	bc.mu.RUnlock()
+
+	for _, event := range pending {
+		if change, ok := eventBalanceChange(event, address); ok {
+			changes = append(changes, change)
+		}
+	}
+
+	// Changes after the range give the closing balances, those in it the opening ones
+	closing, closingLocked := current, currentLocked
+	var inRange []balanceChange
+	for _, change := range changes {
+		switch {
+		case to != 0 && change.entry.Timestamp > to:
+			closing.Sub(closing, change.change)
+			closingLocked.Sub(closingLocked, change.locked)
+		case change.entry.Timestamp >= from:
+			inRange = append(inRange, change)
+		}
+	}
+	balance, locked := new(big.Int).Set(closing), new(big.Int).Set(closingLocked)
+	for _, change := range inRange {
+		balance.Sub(balance, change.change)
+		locked.Sub(locked, change.locked)
+	}
+	proof.OpeningBalance, proof.OpeningLocked = balance.String(), locked.String()
+	proof.ClosingBalance, proof.ClosingLocked = closing.String(), closingLocked.String()
+
+	for _, change := range inRange {
+		balance.Add(balance, change.change)
+		locked.Add(locked, change.locked)
+		entry := change.entry
+		entry.Change = change.change.String()
+		entry.LockedChange = change.locked.String()
+		entry.Balance = balance.String()
+		entry.Locked = locked.String()
+		proof.Entries = append(proof.Entries, entry)
+	}
+	return proof, nil
+}
+
+// SigningHash returns the digest a balance proof signature covers: every
+// field but the signature, under the proof's chain ID and scheme
+func (p *BalanceProof) SigningHash() ([]byte, error) {
+	unsigned := *p
+	unsigned.Signature = nil
+	payload, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	return signingDigest(balanceProofSigningDomain, p.SigScheme, p.ChainID, payload)
+}
+
+// Sign signs the proof with the key pair of the node address signer
+func (p *BalanceProof) Sign(signer string, keyPair *KeyPair) error {
+	key := keyPair.Signer()
+	if key == nil {
+		return fmt.Errorf("%w: no private key for %s", ErrKeyPairNotFound, signer)
+	}
+	p.Signer = signer
+	p.PublicKey = hex.EncodeToString(key.Public().Bytes())
+	p.ChainID = ChainID()
+	p.SigScheme = CurrentSigScheme
+	hash, err := p.SigningHash()
+	if err != nil {
+		return err
+	}
+	signature, err := key.Sign(hash)
+	if err != nil {
+		return err
+	}
+	p.Signature = signature
+	return nil
+}
+
+// Verify checks that the proof was signed for this chain with PublicKey. It
+// does not tell whose key that is: compare it with the key of the node the
+// proof is expected from.
+func (p *BalanceProof) Verify() error {
+	if len(p.Signature) == 0 {
+		return errors.New("balance proof is not signed")
+	}
+	if err := checkSigningDomain(p.ChainID, p.SigScheme); err != nil {
+		return err
+	}
+	keyBytes, err := hex.DecodeString(strings.TrimPrefix(p.PublicKey, "0x"))
+	if err != nil {
+		return fmt.Errorf("malformed public key: %v", err)
+	}
+	publicKey, err := ParsePublicKey(keyBytes)
+	if err != nil {
+		return err
+	}
+	hash, err := p.SigningHash()
+	if err != nil {
+		return err
+	}
+	if !publicKey.Verify(hash, p.Signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
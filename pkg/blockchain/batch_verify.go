@@ -0,0 +1,169 @@
+package blockchain
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// minParallelSignatures is the batch size below which signatures are checked
+// serially; for small blocks goroutine startup costs more than it saves.
+const minParallelSignatures = 16
+
+// BatchVerifier is implemented by signature schemes that can check many
+// signatures in one algorithmic batch. It reports whether every signature is valid;
+// on failure the signatures are checked one by one to find the offending one.
+type BatchVerifier interface {
+	VerifyBatch(keys []PublicKey, digests, signatures [][]byte) bool
+}
+
+// signatureCheck is one transaction signature to verify
+type signatureCheck struct {
+	tx     *Transaction
+	key    PublicKey
+	digest []byte
+}
+
+// SetSignatureWorkers sets how many goroutines verify transaction signatures
+// during block validation; n <= 0 uses one per CPU.
+func (bc *Blockchain) SetSignatureWorkers(n int) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.sigWorkers = n
+}
+
+// signatureWorkersLocked returns the verification parallelism. The caller must hold bc.mu.
+func (bc *Blockchain) signatureWorkersLocked() int {
+	if bc.sigWorkers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return bc.sigWorkers
+}
+
+// senderKeyLocked returns the key a transaction signature must verify under.
+// Every transaction but the system ones must be signed under the current scheme
+// by a sender whose public key this node knows. The caller must hold bc.mu.
+func (bc *Blockchain) senderKeyLocked(tx *Transaction) (PublicKey, error) {
+	if len(tx.Signature) == 0 {
+		return nil, ErrTxNotSigned
+	}
+	if tx.SigScheme != CurrentSigScheme {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedSigScheme, tx.SigScheme)
+	}
+	keyPair, exists := bc.keyPairs[tx.From]
+	if !exists || keyPair.Public() == nil {
+		return nil, fmt.Errorf("%w: no public key for sender %s", ErrKeyPairNotFound, tx.From)
+	}
+	return keyPair.Public(), nil
+}
+
+// collectSignatureChecksLocked returns the signatures of a block to verify. Unsigned
+// system transactions (rewards, allocations) carry nothing to check; any other
+// transaction without a valid signature or a known sender key fails the block.
+// The caller must hold bc.mu.
+func (bc *Blockchain) collectSignatureChecksLocked(block *Block) ([]signatureCheck, error) {
+	var checks []signatureCheck
+	for _, tx := range block.Transactions {
+		if !requiresSignature(tx) {
+			continue
+		}
+		key, err := bc.senderKeyLocked(tx)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %s: %w", tx.ID, err)
+		}
+
+		digest, err := tx.SigningHash()
+		if err != nil {
+			return nil, fmt.Errorf("transaction %s: %w", tx.ID, err)
+		}
+		checks = append(checks, signatureCheck{tx: tx, key: key, digest: digest})
+	}
+	return checks, nil
+}
+
+// verifySignatureChecks verifies all checks, batching per key type where the scheme
+// supports it and spreading the rest over workers goroutines. It returns an error
+// naming the first invalid transaction in block order.
+func verifySignatureChecks(checks []signatureCheck, workers int) error {
+	valid := make([]bool, len(checks))
+
+	// Algorithmic batches first; a failed batch falls through to individual checks
+	byType := make(map[KeyType][]int)
+	for i, check := range checks {
+		byType[check.key.Type()] = append(byType[check.key.Type()], i)
+	}
+	for keyType, indexes := range byType {
+		scheme, err := SchemeFor(keyType)
+		if err != nil {
+			continue
+		}
+		batcher, ok := scheme.(BatchVerifier)
+		if !ok || len(indexes) < 2 {
+			continue
+		}
+		keys := make([]PublicKey, len(indexes))
+		digests := make([][]byte, len(indexes))
+		signatures := make([][]byte, len(indexes))
+		for j, i := range indexes {
+			keys[j], digests[j], signatures[j] = checks[i].key, checks[i].digest, checks[i].tx.Signature
+		}
+		if batcher.VerifyBatch(keys, digests, signatures) {
+			for _, i := range indexes {
+				valid[i] = true
+			}
+		}
+	}
+
+	var pending []int
+	for i := range checks {
+		if !valid[i] {
+			pending = append(pending, i)
+		}
+	}
+
+	verify := func(i int) {
+		valid[i] = checks[i].key.Verify(checks[i].digest, checks[i].tx.Signature)
+	}
+	if workers <= 1 || len(pending) < minParallelSignatures {
+		for _, i := range pending {
+			verify(i)
+		}
+	} else {
+		if workers > len(pending) {
+			workers = len(pending)
+		}
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					verify(i)
+				}
+			}()
+		}
+		for _, i := range pending {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+	}
+
+	for i, ok := range valid {
+		if !ok {
+			return fmt.Errorf("%w: transaction %s", ErrInvalidSignature, checks[i].tx.ID)
+		}
+	}
+	return nil
+}
+
+// verifyTransactionSignaturesLocked checks the signature of every transaction of a
+// block. The caller must hold bc.mu.
+func (bc *Blockchain) verifyTransactionSignaturesLocked(block *Block) error {
+	checks, err := bc.collectSignatureChecksLocked(block)
+	if err != nil {
+		return err
+	}
+	return verifySignatureChecks(checks, bc.signatureWorkersLocked())
+}
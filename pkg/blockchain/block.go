@@ -3,13 +3,10 @@ package blockchain
 import (
 	"bytes"
 	"crypto/ecdsa"
-	"crypto/rand"
 	"crypto/sha256"
 	"encoding/gob"
 	"encoding/hex"
 	"errors"
-	"math/big"
-	"time"
 )
 
 // Block represents a block in the blockchain
@@ -23,20 +20,27 @@ type Block struct {
 	HumanProof   string         `json:"humanProof"`
 	Signature    []byte         `json:"signature"`
 	Reward       uint64         `json:"reward"` // Adding reward field
+	Archive      string         `json:"archive,omitempty"` // Archive object holding the full body of a pruned block
+	TxCount      int            `json:"txCount,omitempty"` // Transaction count of a pruned block
+	ChainID      string         `json:"chainId,omitempty"`   // Network the signature is bound to
+	SigScheme    int            `json:"sigScheme,omitempty"` // Signing scheme version, see CurrentSigScheme
+	Commit       *CommitCertificate `json:"commit,omitempty"` // Commit certificate of the parent block, see finality.go
 }
 
 // CalculateHash calculates the hash of the block
 func (b *Block) CalculateHash() string {
-	record := bytes.Join(
-		[][]byte{
-			[]byte(b.PrevHash),
-			[]byte(b.Validator),
-			SerializeTransactions(b.Transactions),
-			IntToHex(b.Timestamp),
-			[]byte(b.HumanProof),
-		},
-		[]byte{},
-	)
+	fields := [][]byte{
+		[]byte(b.PrevHash),
+		[]byte(b.Validator),
+		SerializeTransactions(b.Transactions),
+		IntToHex(b.Timestamp),
+		[]byte(b.HumanProof),
+	}
+	// Blocks without a commit certificate keep the hash they always had
+	if b.Commit != nil {
+		fields = append(fields, commitBytes(b.Commit))
+	}
+	record := bytes.Join(fields, []byte{})
 
 	h := sha256.New()
 	h.Write(record)
@@ -193,7 +197,7 @@ func SerializeTransactions(txs []*Transaction) []byte {
 func NewBlock(index uint64, transactions []*Transaction, prevHash string, validator string, humanProof string) *Block {
 	block := &Block{
 		Index:        index,
-		Timestamp:    time.Now().Unix(),
+		Timestamp:    Now().Unix(),
 		Transactions: transactions,
 		PrevHash:     prevHash,
 		Validator:    validator,
@@ -220,38 +224,54 @@ func IntToHex(num int64) []byte {
 
 // Sign signs the block with the given private key
 func (b *Block) Sign(privateKey *ecdsa.PrivateKey) error {
-	// Create a hash of the block data
-	hash := b.CalculateHash()
-	
-	// Sign the hash
-	r, s, err := ecdsa.Sign(rand.Reader, privateKey, []byte(hash))
+	return b.SignWith(P256PrivateKey(privateKey))
+}
+
+// SignWith signs the block with a key of any signature scheme
+func (b *Block) SignWith(key PrivateKey) error {
+	if key == nil {
+		return errors.New("no private key to sign with")
+	}
+
+	// Bind the signature to this network and the current scheme
+	b.ChainID = ChainID()
+	b.SigScheme = CurrentSigScheme
+	hash, err := b.SigningHash()
 	if err != nil {
 		return err
 	}
 
-	// Combine r and s into a single signature
-	signature := append(r.Bytes(), s.Bytes()...)
+	signature, err := key.Sign(hash)
+	if err != nil {
+		return err
+	}
 	b.Signature = signature
 	return nil
 }
 
 // Verify verifies the block signature
 func (b *Block) Verify(publicKey *ecdsa.PublicKey) error {
+	return b.VerifyWith(P256PublicKey(publicKey))
+}
+
+// VerifyWith verifies the block signature with a key of any signature scheme
+func (b *Block) VerifyWith(publicKey PublicKey) error {
 	if b.Signature == nil || len(b.Signature) == 0 {
 		return errors.New("block is not signed")
 	}
 
-	// Split signature into r and s components
-	r := new(big.Int).SetBytes(b.Signature[:len(b.Signature)/2])
-	s := new(big.Int).SetBytes(b.Signature[len(b.Signature)/2:])
-
-	// Create a hash of the block data
-	hash := b.CalculateHash()
+	// Reject signatures made for another network or scheme
+	if err := checkSigningDomain(b.ChainID, b.SigScheme); err != nil {
+		return err
+	}
+	hash, err := b.SigningHash()
+	if err != nil {
+		return err
+	}
 
 	// Verify the signature
-	valid := ecdsa.Verify(publicKey, []byte(hash), r, s)
-	if !valid {
-		return errors.New("invalid block signature")
+	if !publicKey.Verify(hash, b.Signature) {
+		return ErrInvalidBlockSignature
 	}
 
 	return nil
@@ -0,0 +1,449 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+)
+
+// RewardTxType is the transaction type of the block reward (coinbase) transaction
+const RewardTxType = "reward"
+
+// RewardSender is the symbolic emission module block rewards are minted from.
+// It is not an account: nothing is debited from it and transfers cannot use
+// it. The name predates minting and is kept so existing chains still verify.
+const RewardSender = "confirmix_genesis_address"
+
+// NewRewardTransaction builds the reward transaction a proposer embeds in its block.
+// Amounts that do not fit into a transaction value are capped.
+func NewRewardTransaction(blockIndex uint64, validator string, timestamp int64, amount *big.Int) *Transaction {
+	value := uint64(0)
+	if amount.IsUint64() {
+		value = amount.Uint64()
+	} else {
+		log.Printf("Warning: Reward amount is too large for uint64, capping it")
+		value = ^uint64(0)
+	}
+
+	return &Transaction{
+		ID:         fmt.Sprintf("reward_%d_%s", blockIndex, validator),
+		From:       RewardSender,
+		To:         validator,
+		Value:      value,
+		Timestamp:  timestamp,
+		Type:       RewardTxType,
+		Status:     "pending",
+		BlockIndex: int64(blockIndex),
+	}
+}
+
+// AttachReward puts the reward transaction at the front of the block and
+// recomputes the block hash. It pays the validator, so it only suits chains
+// without epoch rewards; proposers use Blockchain.AttachRewards instead.
+func (b *Block) AttachReward(amount *big.Int) {
+	b.attachReward(amount, b.Validator)
+	b.Hash = b.CalculateHash()
+}
+
+// attachReward puts a reward transaction minting amount to recipient at the
+// front of the block
+func (b *Block) attachReward(amount *big.Int, recipient string) {
+	if amount == nil || amount.Sign() <= 0 {
+		return
+	}
+
+	rewardTx := NewRewardTransaction(b.Index, b.Validator, b.Timestamp, amount)
+	rewardTx.To = recipient
+	b.Transactions = append([]*Transaction{rewardTx}, b.Transactions...)
+	b.Reward = rewardTx.Value
+}
+
+// checkUniqueTransaction refuses the transaction at position of a block when
+// an earlier one in seen has its ID, and records its position otherwise
+func checkUniqueTransaction(seen map[string]int, tx *Transaction, position int) error {
+	if first, exists := seen[tx.ID]; exists {
+		return fmt.Errorf("%w: %s at positions %d and %d of the block", ErrTxExists, tx.ID, first, position)
+	}
+	seen[tx.ID] = position
+	return nil
+}
+
+// VerifyBlock checks that a block can be appended to the chain without changing any state
+func (bc *Blockchain) VerifyBlock(block *Block) error {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.verifyBlockLocked(block)
+}
+
+// verifyBlockLocked performs the structural and consensus checks of VerifyBlock.
+// The caller must hold bc.mu.
+func (bc *Blockchain) verifyBlockLocked(block *Block) error {
+	if block == nil {
+		return errors.New("block is nil")
+	}
+
+	// Refuse to validate past an upgrade this node's version does not satisfy
+	if err := bc.checkUpgradeLocked(block.Index); err != nil {
+		return err
+	}
+	if err := bc.checkActivationsLocked(block.Index); err != nil {
+		return err
+	}
+
+	// Verify block index
+	if uint64(len(bc.Blocks)) != block.Index {
+		return fmt.Errorf("%w: expected %d, got %d", ErrInvalidBlockIndex, len(bc.Blocks), block.Index)
+	}
+
+	// Verify previous hash
+	prevBlock := bc.Blocks[len(bc.Blocks)-1]
+	if prevBlock.Hash != block.PrevHash {
+		return fmt.Errorf("%w: expected %s, got %s", ErrInvalidPrevHash, prevBlock.Hash, block.PrevHash)
+	}
+
+	// Verify the proposer is an authorized validator
+	if !bc.validators[block.Validator] {
+		return fmt.Errorf("%w: %s is not an authorized validator", ErrUnknownValidator, block.Validator)
+	}
+
+	// Verify that human proof matches
+	expectedProof := bc.humanProofForLocked(block.Validator)
+	if expectedProof != block.HumanProof {
+		return fmt.Errorf("%w: expected %s, got %s", ErrInvalidHumanProof, expectedProof, block.HumanProof)
+	}
+
+	// Verify the hash covers the block contents
+	if block.Hash != block.CalculateHash() {
+		return fmt.Errorf("%w: hash does not match block contents", ErrInvalidBlockSignature)
+	}
+
+	// Verify block signature
+	if err := bc.verifyBlockSignature(block); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBlockSignature, err)
+	}
+
+	// A validator may not produce more than its share of the epoch
+	if err := bc.checkBlockQuotaLocked(block); err != nil {
+		return err
+	}
+
+	// A commit certificate must prove that a quorum of validators committed to the parent
+	if err := bc.checkBlockCommitLocked(block); err != nil {
+		return err
+	}
+
+	// Verify the block does not exceed its capacity; rewards do not count
+	txCount := 0
+	for _, tx := range block.Transactions {
+		if !isRewardTransaction(tx) {
+			txCount++
+		}
+	}
+	if max := bc.maxBlockTxsLocked(); txCount > max {
+		return fmt.Errorf("%w: block carries %d transactions, the limit is %d", ErrBlockTooLarge, txCount, max)
+	}
+
+	// A paused chain only accepts the block resuming it
+	if err := bc.checkBlockCircuitBreakerLocked(block); err != nil {
+		return err
+	}
+
+	// Every transaction is confirmed once: its ID must be unique in the block
+	// and not confirmed before
+	seen := make(map[string]int, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		if err := checkUniqueTransaction(seen, tx, i); err != nil {
+			return err
+		}
+		if err := bc.checkUnconfirmedLocked(tx); err != nil {
+			return err
+		}
+	}
+
+	// Verify transaction signatures in parallel; they dominate the cost of block import
+	if err := bc.verifyTransactionSignaturesLocked(block); err != nil {
+		return err
+	}
+
+	// Verify the transactions carried by the block: signatures must belong to this
+	// network, genesis allocations are only valid in block 0, evidence must prove
+	// a double-sign, contracts must pass static validation and human proof
+	// registrations must be well-formed
+	for _, tx := range block.Transactions {
+		if tx.Type == GenesisAllocationTxType {
+			return fmt.Errorf("%w: transaction %s outside the genesis block", ErrInvalidGenesisAllocation, tx.ID)
+		}
+		if tx.Type == GenesisActivationsTxType {
+			return fmt.Errorf("%w: transaction %s outside the genesis block", ErrInvalidActivation, tx.ID)
+		}
+		if tx.Type == GenesisRewardScheduleTxType {
+			return fmt.Errorf("%w: transaction %s outside the genesis block", ErrInvalidRewardSchedule, tx.ID)
+		}
+		if err := checkTransactionChain(tx); err != nil {
+			return err
+		}
+		if err := bc.checkBridgeTransactionLocked(tx, block.Index); err != nil {
+			return fmt.Errorf("transaction %s: %w", tx.ID, err)
+		}
+		if err := bc.checkOracleTransactionLocked(tx, block.Index); err != nil {
+			return fmt.Errorf("transaction %s: %w", tx.ID, err)
+		}
+		if tx.Type == SlashEvidenceTxType {
+			if _, err := bc.verifyEvidenceLocked(tx); err != nil {
+				return fmt.Errorf("transaction %s: %w", tx.ID, err)
+			}
+			continue
+		}
+		if tx.Type == ValidatorSlashTxType {
+			if err := bc.checkValidatorSlashLocked(tx); err != nil {
+				return fmt.Errorf("transaction %s: %w", tx.ID, err)
+			}
+			continue
+		}
+		if err := bc.checkStakingTransactionLocked(tx); err != nil {
+			return fmt.Errorf("transaction %s: %w", tx.ID, err)
+		}
+		if err := bc.checkCommissionTransactionLocked(tx); err != nil {
+			return fmt.Errorf("transaction %s: %w", tx.ID, err)
+		}
+		if tx.IsContractTransaction() {
+			if err := ValidateContractTransaction(tx); err != nil {
+				return fmt.Errorf("transaction %s: %w", tx.ID, err)
+			}
+			continue
+		}
+		if tx.Type != HumanProofTxType {
+			continue
+		}
+		if err := bc.checkHumanProofLocked(tx); err != nil {
+			return fmt.Errorf("transaction %s: %w", tx.ID, err)
+		}
+	}
+
+	// Verify the reward embedded by the proposer and the epoch reward distribution
+	if err := bc.verifyReward(block); err != nil {
+		return err
+	}
+	return bc.verifyEpochRewardsLocked(block)
+}
+
+// verifyReward checks that a block carries exactly the reward the schedule allows:
+// a single reward transaction paying BlockReward(index) to the block's validator,
+// or to the epoch reward pool once epoch rewards are active. Blocks whose
+// schedule yields no reward must not contain one.
+func (bc *Blockchain) verifyReward(block *Block) error {
+	var rewardTx *Transaction
+	for _, tx := range block.Transactions {
+		if tx.Type != RewardTxType {
+			continue
+		}
+		if rewardTx != nil {
+			return fmt.Errorf("%w: block contains more than one reward transaction", ErrInvalidReward)
+		}
+		rewardTx = tx
+	}
+
+	amount := bc.blockRewardLocked(block.Index)
+	if amount.Sign() <= 0 {
+		if rewardTx != nil {
+			return fmt.Errorf("%w: no reward is due at height %d", ErrInvalidReward, block.Index)
+		}
+		return nil
+	}
+
+	if rewardTx == nil {
+		return fmt.Errorf("%w: reward transaction is missing", ErrInvalidReward)
+	}
+
+	expected := NewRewardTransaction(block.Index, block.Validator, block.Timestamp, amount)
+	if rewardTx.ID != expected.ID {
+		return fmt.Errorf("%w: expected id %s, got %s", ErrInvalidReward, expected.ID, rewardTx.ID)
+	}
+	if rewardTx.From != RewardSender {
+		return fmt.Errorf("%w: reward must come from %s, got %s", ErrInvalidReward, RewardSender, rewardTx.From)
+	}
+	if recipient := bc.rewardRecipientLocked(block.Index, block.Validator); rewardTx.To != recipient {
+		return fmt.Errorf("%w: reward must be paid to %s, got %s", ErrInvalidReward, recipient, rewardTx.To)
+	}
+	if rewardTx.Value != expected.Value {
+		return fmt.Errorf("%w: expected amount %d, got %d", ErrInvalidReward, expected.Value, rewardTx.Value)
+	}
+	if block.Reward != rewardTx.Value {
+		return fmt.Errorf("%w: block reward %d does not match reward transaction %d", ErrInvalidReward, block.Reward, rewardTx.Value)
+	}
+
+	return nil
+}
+
+// ApplyBlock appends an already verified block and applies its transactions to the state.
+// It does not persist anything; see AddBlock for the verify-apply-save sequence.
+func (bc *Blockchain) ApplyBlock(block *Block) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	return bc.applyBlockLocked(block)
+}
+
+// applyBlockLocked is the state transition of ApplyBlock. The caller must hold bc.mu.
+// Transactions that cannot be applied are marked "failed" and reported in the
+// returned error, which wraps ErrBlockAppliedWithErrors.
+func (bc *Blockchain) applyBlockLocked(block *Block) error {
+	bc.Blocks = append(bc.Blocks, block)
+	if block.Commit != nil {
+		bc.recordFinalityLocked(block.Commit)
+	}
+	balancesBefore := bc.snapshotBalancesLocked(blockAddresses(block))
+
+	// Stake whose unbonding period ends with this block is unlocked first
+	bc.completeUnbondingsLocked(block.Index, true)
+
+	var errMsgs []string
+	for _, tx := range block.Transactions {
+		tx.BlockIndex = int64(block.Index)
+		tx.BlockHash = block.Hash
+
+		// Human proof registrations change the registry, not balances
+		if tx.Type == HumanProofTxType {
+			if err := bc.applyHumanProofLocked(tx, block); err != nil {
+				tx.Status = "failed"
+				errMsgs = append(errMsgs, fmt.Sprintf("failed to process human proof %s: %v", tx.ID, err))
+				continue
+			}
+			tx.Status = "confirmed"
+			continue
+		}
+
+		// Evidence slashes and suspends the accused validator and is recorded permanently
+		if tx.Type == SlashEvidenceTxType {
+			if err := bc.applyEvidenceLocked(tx, block); err != nil {
+				tx.Status = "failed"
+				errMsgs = append(errMsgs, fmt.Sprintf("failed to process evidence %s: %v", tx.ID, err))
+				continue
+			}
+			tx.Status = "confirmed"
+			continue
+		}
+
+		// Slashes burn locked stake, spendable balances do not change
+		if tx.Type == ValidatorSlashTxType {
+			if err := bc.applyValidatorSlashLocked(tx); err != nil {
+				tx.Status = "failed"
+				errMsgs = append(errMsgs, fmt.Sprintf("failed to slash with %s: %v", tx.ID, err))
+				continue
+			}
+			tx.Status = "confirmed"
+			continue
+		}
+
+		// Rewards create new supply instead of moving funds; the delegators'
+		// share moves on to the staking pool
+		if tx.Type == RewardTxType {
+			if err := bc.mintRewardLocked(tx); err != nil {
+				tx.Status = "failed"
+				errMsgs = append(errMsgs, fmt.Sprintf("failed to mint reward %s: %v", tx.ID, err))
+				continue
+			}
+			tx.Status = "confirmed"
+			bc.shareBlockRewardLocked(block, tx, true)
+			continue
+		}
+
+		// Epoch rewards move accrued rewards out of the pool
+		if tx.Type == EpochRewardTxType {
+			if err := bc.applyEpochRewardLocked(tx); err != nil {
+				tx.Status = "failed"
+				errMsgs = append(errMsgs, fmt.Sprintf("failed to pay epoch reward %s: %v", tx.ID, err))
+				continue
+			}
+			tx.Status = "confirmed"
+			bc.recordEpochPayoutLocked(tx)
+			continue
+		}
+
+		// Staking requests bond, unbond and pay out stake by the delegator's nonce
+		if isStakingTransaction(tx) {
+			if err := bc.applyStakingTransactionLocked(tx, block); err != nil {
+				tx.Status = "failed"
+				errMsgs = append(errMsgs, fmt.Sprintf("failed to process staking request %s: %v", tx.ID, err))
+				continue
+			}
+			tx.Status = "confirmed"
+			continue
+		}
+
+		// Commission changes extend the validator's schedule, no balances move
+		if tx.Type == ValidatorCommissionTxType {
+			if err := bc.applyCommissionTransactionLocked(tx, block); err != nil {
+				tx.Status = "failed"
+				errMsgs = append(errMsgs, fmt.Sprintf("failed to process commission change %s: %v", tx.ID, err))
+				continue
+			}
+			tx.Status = "confirmed"
+			continue
+		}
+
+		// Oracle authorizations and data points change the oracle registry, not balances
+		if isOracleTransaction(tx) {
+			if err := bc.applyOracleTransactionLocked(tx, block); err != nil {
+				tx.Status = "failed"
+				errMsgs = append(errMsgs, fmt.Sprintf("failed to process oracle transaction %s: %v", tx.ID, err))
+				continue
+			}
+			tx.Status = "confirmed"
+			continue
+		}
+
+		// Circuit breaker actions pause or resume the chain, no balances move
+		if tx.Type == CircuitBreakerTxType {
+			if err := bc.applyCircuitBreakerLocked(tx, block); err != nil {
+				tx.Status = "failed"
+				errMsgs = append(errMsgs, fmt.Sprintf("failed to process circuit breaker transaction %s: %v", tx.ID, err))
+				continue
+			}
+			tx.Status = "confirmed"
+			continue
+		}
+
+		// Bridge transfers move funds in and out of the bridge escrow
+		if involvesBridge(tx) {
+			if err := bc.applyBridgeTransferLocked(tx, block); err != nil {
+				tx.Status = "failed"
+				errMsgs = append(errMsgs, fmt.Sprintf("failed to process bridge transfer %s: %v", tx.ID, err))
+				continue
+			}
+			tx.Status = "confirmed"
+			continue
+		}
+
+		// Update balances
+		if err := bc.UpdateBalances(tx); err != nil {
+			tx.Status = "failed"
+			errMsgs = append(errMsgs, fmt.Sprintf("failed to process transaction %s: %v", tx.ID, err))
+			continue
+		}
+		tx.Status = "confirmed"
+
+		// Process contract transaction if applicable
+		if tx.IsContractTransaction() {
+			if err := bc.processContractTransaction(tx); err != nil {
+				errMsgs = append(errMsgs, fmt.Sprintf("failed to process contract transaction %s: %v", tx.ID, err))
+			}
+		}
+	}
+
+	// Index the transactions for lookups by ID
+	bc.indexBlockLocked(block)
+	bc.recordBalancesLocked(block, balancesBefore)
+	bc.matchPaymentRequestsLocked(block)
+	bc.commitEvents(block)
+
+	// Clean transaction pool
+	bc.recordInclusionLocked(block)
+	bc.cleanTransactionPool(block.Transactions)
+
+	if len(errMsgs) > 0 {
+		return fmt.Errorf("%w: %s", ErrBlockAppliedWithErrors, strings.Join(errMsgs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// FeatureBlockQuota caps the blocks a validator may produce per epoch to its
+// fair share of the epoch, so one validator cannot take over the slots of
+// the others by proposing out of turn
+const FeatureBlockQuota Feature = "block_quota"
+
+// EventBlockQuotaExceeded is recorded when a validator proposes a block past
+// its quota; the validator manager counts it against the validator's score
+const EventBlockQuotaExceeded = "block_quota_exceeded"
+
+// BlockQuotaTolerance is how far a validator may exceed its even share of an
+// epoch, in percent. It leaves room for taking over the turns of validators
+// that are offline.
+const BlockQuotaTolerance = 50
+
+// ErrBlockQuotaExceeded is returned for a block whose validator already
+// produced its share of the epoch
+var ErrBlockQuotaExceeded = errors.New("validator exceeded its block quota")
+
+func init() {
+	RegisterFeature(FeatureBlockQuota, "Validators may produce at most their fair share of the blocks of an epoch")
+}
+
+// BlockProduction is how many blocks each validator produced in an epoch
+type BlockProduction struct {
+	Epoch       uint64                `json:"epoch"`
+	StartHeight uint64                `json:"startHeight"`
+	EndHeight   uint64                `json:"endHeight"`
+	Enforced    bool                  `json:"enforced"` // FeatureBlockQuota is active in the epoch
+	Quota       int                   `json:"quota"`    // blocks each validator may produce
+	Validators  []ValidatorProduction `json:"validators"`
+}
+
+// ValidatorProduction is one validator's blocks in an epoch
+type ValidatorProduction struct {
+	Validator string `json:"validator"`
+	Blocks    int    `json:"blocks"`
+	Remaining int    `json:"remaining"` // blocks left under the quota
+}
+
+// blockQuotaLocked returns the blocks a validator may produce per epoch:
+// an even share of the epoch among the active validators plus
+// BlockQuotaTolerance. The caller must hold bc.mu.
+func (bc *Blockchain) blockQuotaLocked() int {
+	validators := uint64(len(bc.validators))
+	if validators == 0 {
+		validators = 1
+	}
+	length := bc.epochRewards.EpochLength
+	share := (length*(100+BlockQuotaTolerance) + validators*100 - 1) / (validators * 100)
+	if share > length {
+		share = length
+	}
+	return int(share)
+}
+
+// epochProductionLocked counts the blocks per validator of the epoch holding
+// height, up to the chain head. The caller must hold bc.mu.
+func (bc *Blockchain) epochProductionLocked(height uint64) map[string]int {
+	length := bc.epochRewards.EpochLength
+	produced := make(map[string]int)
+	for index := height / length * length; index < uint64(len(bc.Blocks)); index++ {
+		if index == 0 {
+			continue
+		}
+		produced[bc.Blocks[index].Validator]++
+	}
+	return produced
+}
+
+// checkBlockQuotaLocked rejects a block whose validator already produced its
+// quota of the block's epoch. The caller must hold bc.mu.
+func (bc *Blockchain) checkBlockQuotaLocked(block *Block) error {
+	if !bc.featureActiveLocked(FeatureBlockQuota, block.Index) {
+		return nil
+	}
+	quota := bc.blockQuotaLocked()
+	if produced := bc.epochProductionLocked(block.Index)[block.Validator]; produced >= quota {
+		return fmt.Errorf("%w: %s produced %d of %d blocks in epoch %d",
+			ErrBlockQuotaExceeded, block.Validator, produced, quota, block.Index/bc.epochRewards.EpochLength)
+	}
+	return nil
+}
+
+// recordQuotaViolation journals a block rejected for exceeding its quota
+func (bc *Blockchain) recordQuotaViolation(block *Block) {
+	bc.RecordEvent(EventBlockQuotaExceeded, block.Validator, map[string]string{
+		"blockIndex": strconv.FormatUint(block.Index, 10),
+		"blockHash":  block.Hash,
+	})
+}
+
+// BlockQuotaReached reports whether validator produced its quota of the
+// epoch the next block belongs to. Proposers check it before building a block.
+func (bc *Blockchain) BlockQuotaReached(validator string) bool {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	height := uint64(len(bc.Blocks))
+	if !bc.featureActiveLocked(FeatureBlockQuota, height) {
+		return false
+	}
+	return bc.epochProductionLocked(height)[validator] >= bc.blockQuotaLocked()
+}
+
+// BlockProduction returns the blocks per validator and the quota of an epoch.
+// The quota is the one in force now, for the current validator set.
+func (bc *Blockchain) BlockProduction(epoch uint64) (*BlockProduction, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	length := bc.epochRewards.EpochLength
+	start := epoch * length
+	if start >= uint64(len(bc.Blocks)) {
+		return nil, fmt.Errorf("%w: epoch %d starts at block %d, the chain height is %d", ErrBlockNotFound, epoch, start, len(bc.Blocks)-1)
+	}
+
+	production := &BlockProduction{
+		Epoch:       epoch,
+		StartHeight: start,
+		EndHeight:   start + length - 1,
+		Enforced:    bc.featureActiveLocked(FeatureBlockQuota, start+length-1),
+		Quota:       bc.blockQuotaLocked(),
+		Validators:  []ValidatorProduction{},
+	}
+
+	produced := make(map[string]int)
+	for index := start; index <= production.EndHeight && index < uint64(len(bc.Blocks)); index++ {
+		if index > 0 {
+			produced[bc.Blocks[index].Validator]++
+		}
+	}
+	for validator := range bc.validators {
+		if _, exists := produced[validator]; !exists {
+			produced[validator] = 0
+		}
+	}
+	for validator, blocks := range produced {
+		remaining := production.Quota - blocks
+		if remaining < 0 {
+			remaining = 0
+		}
+		production.Validators = append(production.Validators, ValidatorProduction{
+			Validator: validator,
+			Blocks:    blocks,
+			Remaining: remaining,
+		})
+	}
+	sort.Slice(production.Validators, func(i, j int) bool {
+		return production.Validators[i].Validator < production.Validators[j].Validator
+	})
+	return production, nil
+}
@@ -0,0 +1,135 @@
+package blockchain
+
+import (
+	"encoding/json"
+)
+
+// BlockUtilization is how much of the block capacity a block used
+type BlockUtilization struct {
+	Bytes    int     `json:"bytes"`    // size of the encoded block
+	TxCount  int     `json:"txCount"`  // transactions besides the rewards
+	MaxTxs   int     `json:"maxTxs"`   // the current block capacity
+	TxFill   float64 `json:"txFill"`   // TxCount / MaxTxs
+	GasLimit uint64  `json:"gasLimit"` // gas reserved by the contract calls of the block
+	Fees     uint64  `json:"fees"`     // transaction fees; none are charged yet
+	Pruned   bool    `json:"pruned,omitempty"`
+}
+
+// UtilizationStats aggregates block utilization over a range of blocks
+type UtilizationStats struct {
+	Blocks        int     `json:"blocks"`
+	FromIndex     uint64  `json:"fromIndex"`
+	ToIndex       uint64  `json:"toIndex"`
+	MaxTxs        int     `json:"maxTxs"`
+	AvgTxCount    float64 `json:"avgTxCount"`
+	PeakTxCount   int     `json:"peakTxCount"`
+	AvgTxFill     float64 `json:"avgTxFill"`
+	FullBlocks    int     `json:"fullBlocks"` // blocks at the transaction capacity
+	AvgBytes      float64 `json:"avgBytes"`
+	PeakBytes     int     `json:"peakBytes"`
+	TotalGasLimit uint64  `json:"totalGasLimit"`
+	TotalFees     uint64  `json:"totalFees"`
+	PrunedBlocks  int     `json:"prunedBlocks"` // counted from their stub, without size or gas
+}
+
+// DefaultUtilizationWindow is the number of recent blocks UtilizationStats covers by default
+const DefaultUtilizationWindow = 100
+
+// BlockUtilization measures block against the current block capacity. Pruned
+// blocks only report their transaction count.
+func (bc *Blockchain) BlockUtilization(block *Block) BlockUtilization {
+	return measureBlock(block, bc.MaxBlockTransactions())
+}
+
+// measureBlock measures block against a capacity of maxTxs transactions
+func measureBlock(block *Block, maxTxs int) BlockUtilization {
+	u := BlockUtilization{MaxTxs: maxTxs}
+	if block.Archive != "" && len(block.Transactions) == 0 {
+		u.TxCount = block.TxCount
+		u.Pruned = true
+	} else {
+		if encoded, err := json.Marshal(block); err == nil {
+			u.Bytes = len(encoded)
+		}
+		for _, tx := range block.Transactions {
+			if isRewardTransaction(tx) {
+				continue
+			}
+			u.TxCount++
+			if tx.Type != "contract_call" {
+				continue
+			}
+			if contractTx, err := ParseContractTransaction(tx.Data); err == nil {
+				if contractTx.GasLimit == 0 {
+					u.GasLimit += DefaultContractGasLimit
+				} else {
+					u.GasLimit += contractTx.GasLimit
+				}
+			}
+		}
+	}
+	if maxTxs > 0 {
+		u.TxFill = float64(u.TxCount) / float64(maxTxs)
+	}
+	return u
+}
+
+// UtilizationStats aggregates the utilization of the last blocks, genesis
+// excluded. A window of 0 covers DefaultUtilizationWindow blocks.
+func (bc *Blockchain) UtilizationStats(window int) UtilizationStats {
+	if window <= 0 {
+		window = DefaultUtilizationWindow
+	}
+	maxTxs := bc.MaxBlockTransactions()
+
+	bc.mu.RLock()
+	from := 1
+	if len(bc.Blocks)-window > from {
+		from = len(bc.Blocks) - window
+	}
+	blocks := make([]*Block, 0, window)
+	for i := from; i < len(bc.Blocks); i++ {
+		blocks = append(blocks, bc.Blocks[i])
+	}
+	bc.mu.RUnlock()
+
+	stats := UtilizationStats{MaxTxs: maxTxs}
+	if len(blocks) == 0 {
+		return stats
+	}
+	stats.Blocks = len(blocks)
+	stats.FromIndex = blocks[0].Index
+	stats.ToIndex = blocks[len(blocks)-1].Index
+
+	var txs, bytes, measured int
+	for _, block := range blocks {
+		u := measureBlock(block, maxTxs)
+		txs += u.TxCount
+		if u.TxCount > stats.PeakTxCount {
+			stats.PeakTxCount = u.TxCount
+		}
+		if maxTxs > 0 && u.TxCount >= maxTxs {
+			stats.FullBlocks++
+		}
+		stats.TotalGasLimit += u.GasLimit
+		stats.TotalFees += u.Fees
+		if u.Pruned {
+			stats.PrunedBlocks++
+			continue
+		}
+		measured++
+		bytes += u.Bytes
+		if u.Bytes > stats.PeakBytes {
+			stats.PeakBytes = u.Bytes
+		}
+	}
+
+	stats.AvgTxCount = float64(txs) / float64(len(blocks))
+	if maxTxs > 0 {
+		stats.AvgTxFill = stats.AvgTxCount / float64(maxTxs)
+	}
+	if measured > 0 {
+		stats.AvgBytes = float64(bytes) / float64(measured)
+	}
+	return stats
+}
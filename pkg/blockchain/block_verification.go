@@ -0,0 +1,260 @@
+package blockchain
+
+import (
+	"fmt"
+)
+
+// Names of the checks in a block verification report
+const (
+	CheckHash          = "hash"        // the hash is recomputed from the block contents
+	CheckSignature     = "signature"   // the proposer's signature over the block
+	CheckValidator     = "validator"   // the proposer is in the current validator set
+	CheckHumanProof    = "human_proof" // the block carries the proposer's human proof
+	CheckLinkage       = "linkage"     // index and previous hash continue the chain
+	CheckChainBlock    = "chain_block" // a block the chain already has at that height is the same block
+	CheckCapacity      = "capacity"    // the transaction count is within the block capacity
+	CheckReward        = "reward"      // the reward and epoch reward distribution follow the schedule
+	CheckQuota         = "quota"       // the proposer stays within its block quota of the epoch
+	CheckImport        = "import"      // every check the node runs before importing a block
+	CheckTxChain       = "chain_id"    // the transaction is signed for this network
+	CheckTxSignature   = "signature"   // the sender's signature over the transaction
+	CheckTxType        = "type"        // type-specific rules, e.g. genesis-only types and evidence
+	CheckTxUnique      = "unique"      // the transaction appears once in the block
+	CheckTxUnconfirmed = "unconfirmed" // the transaction is not confirmed in an earlier block
+)
+
+// Check results
+const (
+	CheckPassed  = "passed"
+	CheckFailed  = "failed"
+	CheckSkipped = "skipped" // the check does not apply or the node lacks what it needs
+)
+
+// VerificationCheck is the result of one check of a block or transaction
+type VerificationCheck struct {
+	Name     string `json:"name"`
+	Result   string `json:"result"`
+	Detail   string `json:"detail,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// TxVerification is the verification report of one transaction of a block
+type TxVerification struct {
+	Position int                 `json:"position"`
+	ID       string              `json:"id"`
+	Type     string              `json:"type"`
+	Valid    bool                `json:"valid"`
+	Checks   []VerificationCheck `json:"checks"`
+}
+
+// BlockVerificationReport describes how a block fares against this node's
+// chain. Height is the node's chain height when the report was made. Blocks
+// at the next height get the full import checks; blocks the chain already has
+// at their height are compared with it; blocks further ahead only get the
+// checks that do not depend on their parent.
+type BlockVerificationReport struct {
+	Index        uint64              `json:"index"`
+	Hash         string              `json:"hash"`
+	ComputedHash string              `json:"computedHash"`
+	Height       uint64              `json:"height"`
+	Valid        bool                `json:"valid"` // no check failed
+	Checks       []VerificationCheck `json:"checks"`
+	Transactions []TxVerification    `json:"transactions"`
+}
+
+// add records a check, failing the report when it failed
+func (r *BlockVerificationReport) add(check VerificationCheck) {
+	if check.Result == CheckFailed {
+		r.Valid = false
+	}
+	r.Checks = append(r.Checks, check)
+}
+
+// checkOf turns the error of a check into its result
+func checkOf(name string, err error) VerificationCheck {
+	if err != nil {
+		return VerificationCheck{Name: name, Result: CheckFailed, Detail: err.Error()}
+	}
+	return VerificationCheck{Name: name, Result: CheckPassed}
+}
+
+// compareCheck passes when actual equals expected
+func compareCheck(name, expected, actual string) VerificationCheck {
+	check := VerificationCheck{Name: name, Result: CheckPassed, Expected: expected, Actual: actual}
+	if expected != actual {
+		check.Result = CheckFailed
+	}
+	return check
+}
+
+// VerifyBlockReport checks a block without importing it and reports every
+// check separately, so other implementations can find out exactly where
+// their encoding or signing differs from this node's
+func (bc *Blockchain) VerifyBlockReport(block *Block) *BlockVerificationReport {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	report := &BlockVerificationReport{
+		Index:        block.Index,
+		Hash:         block.Hash,
+		ComputedHash: block.CalculateHash(),
+		Height:       uint64(len(bc.Blocks)) - 1,
+		Valid:        true,
+		Checks:       []VerificationCheck{},
+		Transactions: []TxVerification{},
+	}
+	next := block.Index == uint64(len(bc.Blocks))
+
+	report.add(compareCheck(CheckHash, report.ComputedHash, block.Hash))
+	report.add(checkOf(CheckSignature, bc.verifyBlockSignature(block)))
+	switch {
+	case bc.validators[block.Validator]:
+		report.add(VerificationCheck{Name: CheckValidator, Result: CheckPassed})
+	case block.Index < uint64(len(bc.Blocks)):
+		// Past validator sets are not kept; chain_block tells whether the chain accepted it
+		report.add(VerificationCheck{Name: CheckValidator, Result: CheckSkipped,
+			Detail: fmt.Sprintf("%s is no longer in the validator set", block.Validator)})
+	default:
+		report.add(VerificationCheck{Name: CheckValidator, Result: CheckFailed,
+			Detail: fmt.Sprintf("%s is not in the current validator set", block.Validator)})
+	}
+	report.add(compareCheck(CheckHumanProof, bc.humanProofForLocked(block.Validator), block.HumanProof))
+
+	switch {
+	case block.Index == 0:
+		report.add(VerificationCheck{Name: CheckLinkage, Result: CheckSkipped, Detail: "the genesis block has no parent"})
+	case block.Index <= uint64(len(bc.Blocks)):
+		check := compareCheck(CheckLinkage, bc.Blocks[block.Index-1].Hash, block.PrevHash)
+		check.Detail = fmt.Sprintf("previous hash against block %d", block.Index-1)
+		report.add(check)
+	default:
+		report.add(VerificationCheck{Name: CheckLinkage, Result: CheckSkipped,
+			Detail: fmt.Sprintf("the parent is not known yet; the chain height is %d", report.Height)})
+	}
+	if block.Index < uint64(len(bc.Blocks)) {
+		check := compareCheck(CheckChainBlock, bc.Blocks[block.Index].Hash, block.Hash)
+		check.Detail = fmt.Sprintf("hash of block %d on this chain", block.Index)
+		report.add(check)
+	}
+
+	txCount := 0
+	for _, tx := range block.Transactions {
+		if !isRewardTransaction(tx) {
+			txCount++
+		}
+	}
+	if max := bc.maxBlockTxsLocked(); txCount > max {
+		report.add(VerificationCheck{Name: CheckCapacity, Result: CheckFailed,
+			Detail: fmt.Sprintf("block carries %d transactions, the limit is %d", txCount, max)})
+	} else {
+		report.add(VerificationCheck{Name: CheckCapacity, Result: CheckPassed})
+	}
+
+	seen := make(map[string]int, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		txReport := bc.verifyTransactionReportLocked(block, tx, next)
+		txReport.Position = i
+		unique := checkOf(CheckTxUnique, checkUniqueTransaction(seen, tx, i))
+		if unique.Result == CheckFailed {
+			txReport.Valid = false
+		}
+		txReport.Checks = append(txReport.Checks, unique)
+		if !txReport.Valid {
+			report.Valid = false
+		}
+		report.Transactions = append(report.Transactions, txReport)
+	}
+
+	// The reward schedule and the import checks depend on the chain state
+	// the block would be applied to
+	if !next {
+		detail := "only checked for the next block"
+		report.add(VerificationCheck{Name: CheckReward, Result: CheckSkipped, Detail: detail})
+		report.add(VerificationCheck{Name: CheckQuota, Result: CheckSkipped, Detail: detail})
+		report.add(VerificationCheck{Name: CheckImport, Result: CheckSkipped, Detail: detail})
+		return report
+	}
+	rewardErr := bc.verifyReward(block)
+	if rewardErr == nil {
+		rewardErr = bc.verifyEpochRewardsLocked(block)
+	}
+	report.add(checkOf(CheckReward, rewardErr))
+	report.add(checkOf(CheckQuota, bc.checkBlockQuotaLocked(block)))
+	report.add(checkOf(CheckImport, bc.verifyBlockLocked(block)))
+	return report
+}
+
+// verifyTransactionReportLocked runs the checks of one transaction of block.
+// next reports whether the block is the next one, for which transactions
+// already confirmed on the chain are refused. The caller must hold bc.mu.
+func (bc *Blockchain) verifyTransactionReportLocked(block *Block, tx *Transaction, next bool) TxVerification {
+	report := TxVerification{ID: tx.ID, Type: tx.Type, Valid: true, Checks: []VerificationCheck{}}
+	add := func(check VerificationCheck) {
+		if check.Result == CheckFailed {
+			report.Valid = false
+		}
+		report.Checks = append(report.Checks, check)
+	}
+
+	add(checkOf(CheckTxChain, checkTransactionChain(tx)))
+	add(bc.transactionSignatureCheckLocked(tx))
+
+	var typeErr error
+	switch {
+	case block.Index != 0 && tx.Type == GenesisAllocationTxType:
+		typeErr = fmt.Errorf("%w: outside the genesis block", ErrInvalidGenesisAllocation)
+	case block.Index != 0 && tx.Type == GenesisActivationsTxType:
+		typeErr = fmt.Errorf("%w: outside the genesis block", ErrInvalidActivation)
+	case block.Index != 0 && tx.Type == GenesisRewardScheduleTxType:
+		typeErr = fmt.Errorf("%w: outside the genesis block", ErrInvalidRewardSchedule)
+	case tx.Type == SlashEvidenceTxType:
+		_, typeErr = bc.verifyEvidenceLocked(tx)
+	case tx.IsContractTransaction():
+		typeErr = ValidateContractTransaction(tx)
+	case tx.Type == ValidatorSlashTxType && next:
+		typeErr = bc.checkValidatorSlashLocked(tx)
+	case tx.Type == HumanProofTxType && next:
+		typeErr = bc.checkHumanProofLocked(tx)
+	case tx.Type == HumanProofTxType:
+		if _, err := ParseHumanProofRegistration(tx); err != nil {
+			typeErr = fmt.Errorf("%w: %v", ErrInvalidHumanProof, err)
+		}
+	case isOracleTransaction(tx) && next:
+		typeErr = bc.checkOracleTransactionLocked(tx, block.Index)
+	case isStakingTransaction(tx) && next:
+		typeErr = bc.checkStakingTransactionLocked(tx)
+	case tx.Type == ValidatorCommissionTxType && next:
+		typeErr = bc.checkCommissionTransactionLocked(tx)
+	case involvesBridge(tx) && next:
+		// Confirmed releases would count as released already
+		typeErr = bc.checkBridgeTransactionLocked(tx, block.Index)
+	}
+	add(checkOf(CheckTxType, typeErr))
+
+	if next {
+		add(checkOf(CheckTxUnconfirmed, bc.checkUnconfirmedLocked(tx)))
+	}
+	return report
+}
+
+// transactionSignatureCheckLocked verifies the signature of a transaction the
+// way block import does: only unsigned system transactions are skipped. The
+// caller must hold bc.mu.
+func (bc *Blockchain) transactionSignatureCheckLocked(tx *Transaction) VerificationCheck {
+	if !requiresSignature(tx) {
+		return VerificationCheck{Name: CheckTxSignature, Result: CheckSkipped, Detail: "system transactions are not signed"}
+	}
+	key, err := bc.senderKeyLocked(tx)
+	if err != nil {
+		return checkOf(CheckTxSignature, err)
+	}
+	digest, err := tx.SigningHash()
+	if err != nil {
+		return checkOf(CheckTxSignature, err)
+	}
+	if !key.Verify(digest, tx.Signature) {
+		return VerificationCheck{Name: CheckTxSignature, Result: CheckFailed, Detail: ErrInvalidSignature.Error()}
+	}
+	return VerificationCheck{Name: CheckTxSignature, Result: CheckPassed}
+}
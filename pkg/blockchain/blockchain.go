@@ -1,7 +1,6 @@
 package blockchain
 
 import (
-	"crypto/ecdsa"
 	"crypto/rsa"
 	"encoding/json"
 	"errors"
@@ -11,7 +10,7 @@ import (
 	"math/big"
 	"os"
 	"path/filepath"
-	"strings"
+	"sort"
 	"sync"
 	"time"
 	"crypto/sha256"
@@ -30,6 +29,9 @@ type Blockchain struct {
 	chain_data       string
 	validators       map[string]bool // Map of validator addresses
 	humanProofs      map[string]string // Map of address to human verification proof
+	humanProofRegistry map[string]*HumanProofRecord // Human proofs recorded on chain
+	humanProofAttestations map[string]*HumanProofRegistration // Attested proofs waiting for their validator to submit them, see human_proof.go
+	evidence         map[string]*EvidenceRecord // Double-signing evidence recorded on chain
 	lockedBalances   map[string]*big.Int // Map of address to locked balance
 	mutex            sync.RWMutex // Mutex for concurrent access
 	mu               sync.RWMutex
@@ -39,43 +41,82 @@ type Blockchain struct {
 	keyPairs         map[string]*KeyPair // Map of address to key pair
 	mutex_           sync.RWMutex
 	multiSigWallets  map[string]*MultiSigWallet // Map of address to multi-signature wallet
+	archive          *blockArchive              // Remote archive of old block bodies, nil when disabled
+	maxBlockTxs      int                        // Block capacity in transactions, excluding the reward
+	sigWorkers       int                        // Goroutines verifying transaction signatures, 0 = one per CPU
+	txIndex          map[string]TxLocation      // Confirmed transaction ID -> location, see tx_index.go
+	bridgeReleases   map[string]string          // Released bridge source transactions -> release transaction ID, see bridge.go
+	oracles          map[string]*OracleInfo     // Authorized oracle accounts, see oracle.go
+	oracleFeeds      map[string][]OracleRecord  // Confirmed data points per oracle feed, oldest first
+	circuitBreaker   []CircuitBreakerRecord     // Confirmed pauses and resumes of the chain, see circuit_breaker.go
+	finality         finalityState              // Newest commit certificate known, see finality.go
+	balanceHistory   map[string][]BalancePoint  // Balance journal per address, see balance_history.go
+	upgrades         []UpgradePlan              // Governance-approved software upgrades, see upgrade.go
+	activations      map[Feature]Activation     // Consensus rule activation heights, see features.go
+	rewardSchedule   RewardSchedule             // Block reward emission fixed by the genesis block, see rewards.go
+	genesisSupply    *big.Int                   // Supply allocated by the genesis block, see emission.go
+	nameResolver     NameResolver               // Name registry for the contract host API, nil when absent
+	governanceReader GovernanceReader           // Governance parameters for the contract host API, nil when absent
+	labels           labelStore                 // Public address labels for explorers, see labels.go
+	addressBook      addressBookStore           // Named contacts of API keys and wallets, see address_book.go
+	payments         paymentRequestStore        // Payment requests and the transactions paying them, see payment_requests.go
+	apiKeys          apiKeyStore                // API tenant keys and their usage, see api_keys.go
+	walletLocks      walletLockStore            // Passphrases and unlock windows of custodied wallets, see wallet_unlock.go
+	walletControls   walletControlStore         // Spending limits and second factors of custodied wallets, see wallet_controls.go
+	rejectedTxs      []RejectedTransaction      // Transactions dropped by the block builder, see rejected_txs.go
+	txLifecycles     txLifecycleStore           // When transactions were seen, broadcast and included, see tx_lifecycle.go
+	dustPolicy       DustPolicy                 // Admission rules against near-zero accounts, see dust.go
+	gcExempt         map[string]bool            // Empty accounts compaction keeps, see account_gc.go
+	gcOnSnapshot     bool                       // Compact accounts before every snapshot
+	persistMempool   bool                       // Keep the transaction pool across restarts, see mempool_persistence.go
+	richIndex        richIndex                  // Accounts ordered by balance for the rich list, see rich_list.go
+	events           eventJournal               // Validator, governance and parameter changes per block, see events.go
+	epochRewards     EpochRewardConfig          // Epochs and treasury share of the reward distribution, see epoch_rewards.go
+	staking          stakingLedger              // Delegations, unbondings and staking nonces confirmed on chain, see staking.go
+	stakingConfig    StakingConfig              // Delegation rules the staking transactions are applied by
+	commissions      map[string][]CommissionChange // Commission schedule per validator, see validator_commission.go
+	commissionConfig CommissionConfig           // Bounds of validator commissions
+	diskWriteHook    func(name string)          // Runs before each state file is written, see SetDiskWriteHook
+	keyUses          map[string]func(address string) bool // Key uses tracked outside the chain state, see key_audit.go
 	Admins           []string                 // Added for the new initialization logic
 }
 
-// NewBlockchain creates a new blockchain instance
-func NewBlockchain() (*Blockchain, error) {
-	bc := &Blockchain{
+// newBlockchainState returns a blockchain with empty state and no genesis block
+func newBlockchainState() *Blockchain {
+	return &Blockchain{
 		Blocks:            make([]*Block, 0),
 		pendingTxs:        make([]*Transaction, 0),
 		accounts:          make(map[string]*big.Int),
 		keyPairs:          make(map[string]*KeyPair),
 		validators:        make(map[string]bool),
 		multiSigWallets:   make(map[string]*MultiSigWallet),
+		bridgeReleases:    make(map[string]string),
+		oracles:           make(map[string]*OracleInfo),
+		oracleFeeds:       make(map[string][]OracleRecord),
 		PendingTXs:        make(map[string]*Transaction),
 		txPool:           make(map[string]*Transaction),
 		contractManager:  NewContractManager(),
 		humanProofs:      make(map[string]string),
+		humanProofRegistry: make(map[string]*HumanProofRecord),
+		evidence:         make(map[string]*EvidenceRecord),
+		txIndex:          make(map[string]TxLocation),
+		balanceHistory:   make(map[string][]BalancePoint),
+		activations:      make(map[Feature]Activation),
+		rewardSchedule:   DefaultRewardSchedule(),
+		epochRewards:     DefaultEpochRewardConfig(),
+		staking:          newStakingLedger(),
+		stakingConfig:    DefaultStakingConfig(),
+		commissions:      make(map[string][]CommissionChange),
+		commissionConfig: DefaultCommissionConfig(),
 		lockedBalances:   make(map[string]*big.Int),
 		TotalMinted:      big.NewInt(0),
 		CurrentDifficult: 1,
 	}
+}
 
-	// Create genesis block
-	genesisBlock := &Block{
-		Index:        0,
-		Timestamp:    time.Now().Unix(),
-		Transactions: []*Transaction{},
-		PrevHash:     "0",
-		Validator:    "genesis",
-		HumanProof:   "genesis_proof",
-		Reward:       0,
-	}
-
-	// Calculate genesis block hash
-	genesisBlock.Hash = genesisBlock.CalculateHash()
-
-	// Add genesis block to chain
-	bc.Blocks = append(bc.Blocks, genesisBlock)
+// NewBlockchain creates a new blockchain instance
+func NewBlockchain() (*Blockchain, error) {
+	bc := newBlockchainState()
 
 	// Create genesis admin account (symbolic address)
 	adminAddress := "0x0000000000000000000000000000000000000000admin"
@@ -107,6 +148,11 @@ func NewBlockchain() (*Blockchain, error) {
 		log.Printf("Warning: Failed to save owner3 key pair: %v", err)
 	}
 
+	// Register the owners' keys so their multisig signatures can be verified
+	for _, ownerKeyPair := range []*KeyPair{owner1KeyPair, owner2KeyPair, owner3KeyPair} {
+		bc.keyPairs[ownerKeyPair.GetAddress()] = ownerKeyPair
+	}
+
 	// Create genesis multisig wallet with only the three new owners
 	genesisOwners := []string{
 		owner1KeyPair.GetAddress(), // Owner 1
@@ -155,7 +201,7 @@ func NewBlockchain() (*Blockchain, error) {
 	if err != nil {
 		log.Printf("Warning: Failed to marshal multisig info: %v", err)
 	} else {
-		if err := os.WriteFile("data/multisig.json", multisigData, 0644); err != nil {
+		if err := os.WriteFile(filepath.Join(GetBlockchainDataPath(), "multisig.json"), multisigData, 0644); err != nil {
 			log.Printf("Warning: Failed to save multisig info: %v", err)
 		}
 	}
@@ -164,8 +210,51 @@ func NewBlockchain() (*Blockchain, error) {
 	totalSupply := new(big.Int)
 	totalSupply.SetString("100000000000000000000000000", 10) // 100 million tokens with 18 decimals
 
-	// Initialize genesis account with total supply
-	bc.accounts[genesisMultiSigWallet.Address] = totalSupply
+	// Create the genesis block; the total supply is allocated to the genesis
+	// multisig wallet by an explicit transaction so it can be audited on chain
+	genesisBlock := &Block{
+		Index:        0,
+		Timestamp:    time.Now().Unix(),
+		Transactions: []*Transaction{},
+		PrevHash:     "0",
+		Validator:    "genesis",
+		HumanProof:   "genesis_proof",
+		Reward:       0,
+	}
+	if err := attachGenesisAllocations(genesisBlock, []GenesisAllocation{
+		{Address: genesisMultiSigWallet.Address, Amount: totalSupply.String(), Label: "genesis multisig wallet"},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create genesis allocations: %v", err)
+	}
+	if err := attachGenesisActivations(genesisBlock); err != nil {
+		return nil, fmt.Errorf("failed to record feature activations: %v", err)
+	}
+	if err := attachGenesisRewardSchedule(genesisBlock); err != nil {
+		return nil, fmt.Errorf("failed to record the reward schedule: %v", err)
+	}
+
+	// Calculate genesis block hash
+	genesisBlock.Hash = genesisBlock.CalculateHash()
+	for _, tx := range genesisBlock.Transactions {
+		tx.BlockHash = genesisBlock.Hash
+	}
+
+	// Add genesis block to chain and credit its allocations
+	bc.Blocks = append(bc.Blocks, genesisBlock)
+	if err := bc.applyGenesisLocked(genesisBlock); err != nil {
+		return nil, fmt.Errorf("failed to apply genesis allocations: %v", err)
+	}
+	bc.indexBlockLocked(genesisBlock)
+	bc.recordBalancesLocked(genesisBlock, nil)
+	bc.loadUpgradesLocked(GetBlockchainDataPath())
+	bc.loadActivationsLocked(GetBlockchainDataPath())
+	bc.loadLabels(GetBlockchainDataPath())
+	bc.loadAddressBook(GetBlockchainDataPath())
+	bc.loadPaymentRequests(GetBlockchainDataPath())
+	bc.loadAPIKeys(GetBlockchainDataPath())
+	bc.loadWalletPassphrases(GetBlockchainDataPath())
+	bc.loadWalletControls(GetBlockchainDataPath())
+	bc.loadRejectedTxsLocked(GetBlockchainDataPath())
 
 	// Save initial state
 	if err := bc.SaveToDisk(); err != nil {
@@ -176,20 +265,44 @@ func NewBlockchain() (*Blockchain, error) {
 	log.Printf("Admin wallet address (symbolic): %s", adminAddress)
 	log.Printf("Genesis MultiSig wallet initialized with %d owners", len(genesisOwners))
 	log.Printf("Genesis wallet initialized with total supply of %s tokens", totalSupply.String())
-	log.Printf("Multisig wallet info saved to data/multisig.json")
+	log.Printf("Multisig wallet info saved to %s", filepath.Join(GetBlockchainDataPath(), "multisig.json"))
 	log.Printf("Required signatures for multisig operations: %d", requiredSigs)
 	log.Printf("Owner addresses and their key pairs:")
-	log.Printf("  Owner 1: %s (saved to data/key_%s.json)", owner1KeyPair.GetAddress(), owner1KeyPair.GetAddress())
-	log.Printf("  Owner 2: %s (saved to data/key_%s.json)", owner2KeyPair.GetAddress(), owner2KeyPair.GetAddress())
-	log.Printf("  Owner 3: %s (saved to data/key_%s.json)", owner3KeyPair.GetAddress(), owner3KeyPair.GetAddress())
+	log.Printf("  Owner 1: %s (saved to %s)", owner1KeyPair.GetAddress(), filepath.Join(GetBlockchainDataPath(), "key_"+owner1KeyPair.GetAddress()+".json"))
+	log.Printf("  Owner 2: %s (saved to %s)", owner2KeyPair.GetAddress(), filepath.Join(GetBlockchainDataPath(), "key_"+owner2KeyPair.GetAddress()+".json"))
+	log.Printf("  Owner 3: %s (saved to %s)", owner3KeyPair.GetAddress(), filepath.Join(GetBlockchainDataPath(), "key_"+owner3KeyPair.GetAddress()+".json"))
 
 	return bc, nil
 }
 
+// DefaultDataDir is the data directory of a node started without one configured
+const DefaultDataDir = "data"
+
+var (
+	dataDirMu sync.RWMutex
+	// nodeDataDir holds the state of every component of the node, see SetDataDir
+	nodeDataDir = DefaultDataDir
+)
+
+// SetDataDir sets the directory the blockchain, keystore, backups and logs are
+// kept in. Nodes with their own data directories can run side by side on one
+// machine. It must be called before any component is created.
+func SetDataDir(dir string) {
+	if dir == "" {
+		dir = DefaultDataDir
+	}
+	dataDirMu.Lock()
+	defer dataDirMu.Unlock()
+	nodeDataDir = filepath.Clean(dir)
+}
+
 // GetBlockchainDataPath returns the path where blockchain data is stored
 func GetBlockchainDataPath() string {
-	// Create data directory in project root
-	dataDir := "data"
+	dataDirMu.RLock()
+	dataDir := nodeDataDir
+	dataDirMu.RUnlock()
+	
+	// Create the data directory on first use
 	err := os.MkdirAll(dataDir, 0755)
 	if err != nil {
 		log.Printf("Failed to create data directory: %v", err)
@@ -198,6 +311,14 @@ func GetBlockchainDataPath() string {
 	return dataDir
 }
 
+// SetDiskWriteHook sets a function run before each state file SaveToDisk
+// writes, e.g. to delay disk writes in chaos tests. nil removes it.
+func (bc *Blockchain) SetDiskWriteHook(hook func(name string)) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.diskWriteHook = hook
+}
+
 // SaveToDisk persists the blockchain state to disk
 func (bc *Blockchain) SaveToDisk() error {
 	bc.mu.RLock()
@@ -205,71 +326,49 @@ func (bc *Blockchain) SaveToDisk() error {
 	
 	dataDir := GetBlockchainDataPath()
 	
-	// Save blocks
-	blocksFile := filepath.Join(dataDir, "blocks.json")
-	blocksData, err := json.MarshalIndent(bc.Blocks, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal blocks: %v", err)
-	}
-	
-	if err := ioutil.WriteFile(blocksFile, blocksData, 0644); err != nil {
-		return fmt.Errorf("failed to write blocks file: %v", err)
-	}
-	
-	// Save validators
-	validatorsMap := make(map[string]string)
-	for addr := range bc.validators {
-		validatorsMap[addr] = bc.humanProofs[addr]
-	}
-	
-	validatorsFile := filepath.Join(dataDir, "validators.json")
-	validatorsData, err := json.MarshalIndent(validatorsMap, "", "  ")
+	files, err := bc.exportStateLocked()
 	if err != nil {
-		return fmt.Errorf("failed to marshal validators: %v", err)
+		return err
 	}
 	
-	if err := ioutil.WriteFile(validatorsFile, validatorsData, 0644); err != nil {
-		return fmt.Errorf("failed to write validators file: %v", err)
+	for _, name := range StateFiles {
+		if bc.diskWriteHook != nil {
+			bc.diskWriteHook(name)
+		}
+		if err := writeFileAtomic(filepath.Join(dataDir, name), files[name]); err != nil {
+			return fmt.Errorf("failed to write %s: %v", name, err)
+		}
 	}
-	
-	// Save accounts
-	accountsMap := make(map[string]string)
-	for addr, balance := range bc.accounts {
-		accountsMap[addr] = balance.String()
+	if err := writeStateChecksums(dataDir, files); err != nil {
+		return fmt.Errorf("failed to write state checksums: %v", err)
 	}
-	
-	accountsFile := filepath.Join(dataDir, "accounts.json")
-	accountsData, err := json.MarshalIndent(accountsMap, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal accounts: %v", err)
-	}
-	
-	if err := ioutil.WriteFile(accountsFile, accountsData, 0644); err != nil {
-		return fmt.Errorf("failed to write accounts file: %v", err)
+	if err := bc.saveTxIndexLocked(dataDir); err != nil {
+		return err
 	}
-
-	// Save multi-signature wallets
-	multiSigFile := filepath.Join(dataDir, "multisig.json")
-	multiSigData, err := json.MarshalIndent(bc.multiSigWallets, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal multi-signature wallets: %v", err)
+	if err := bc.saveBalanceHistoryLocked(dataDir); err != nil {
+		return err
 	}
-	
-	if err := ioutil.WriteFile(multiSigFile, multiSigData, 0644); err != nil {
-		return fmt.Errorf("failed to write multi-signature wallets file: %v", err)
+	if err := bc.saveEvents(dataDir); err != nil {
+		return err
 	}
 	
 	log.Printf("Blockchain state saved to disk: %s", dataDir)
 	return nil
 }
 
-// LoadFromDisk loads the blockchain state from disk
+// LoadFromDisk loads the blockchain state from disk. State that fails its
+// integrity check is not loaded: the error wraps ErrStorageCorrupted and the
+// report is written to CorruptionReportFile, see integrity.go.
 func (bc *Blockchain) LoadFromDisk() error {
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
-	
-	dataDir := GetBlockchainDataPath()
-	
+	return bc.loadFromDiskLocked(GetBlockchainDataPath(), false)
+}
+
+// loadFromDiskLocked loads the state in dataDir. With reindex, the balances
+// are rebuilt from the blocks instead of read from accounts.json, and only
+// blocks.json must pass the integrity check. The caller must hold bc.mu.
+func (bc *Blockchain) loadFromDiskLocked(dataDir string, reindex bool) error {
 	// Load blocks
 	blocksFile := filepath.Join(dataDir, "blocks.json")
 	if _, err := os.Stat(blocksFile); os.IsNotExist(err) {
@@ -277,6 +376,17 @@ func (bc *Blockchain) LoadFromDisk() error {
 		return errors.New("no existing blockchain data found")
 	}
 	
+	report := CheckStorage(dataDir)
+	skip := make(map[string]bool)
+	for _, file := range report.Files {
+		skip[file.Name] = file.Status != FileOK
+	}
+	if report.Corrupted && (!reindex || skip["blocks.json"]) {
+		saveCorruptionReport(dataDir, report)
+		log.Printf("Storage integrity check failed: %s; see %s", report.Summary(), filepath.Join(dataDir, CorruptionReportFile))
+		return fmt.Errorf("%w: %s", ErrStorageCorrupted, report.Summary())
+	}
+	
 	blocksData, err := ioutil.ReadFile(blocksFile)
 	if err != nil {
 		log.Printf("Failed to read blocks file: %v", err)
@@ -293,7 +403,7 @@ func (bc *Blockchain) LoadFromDisk() error {
 	
 	// Load validators
 	validatorsFile := filepath.Join(dataDir, "validators.json")
-	if _, err := os.Stat(validatorsFile); !os.IsNotExist(err) {
+	if !skip["validators.json"] {
 		validatorsData, err := ioutil.ReadFile(validatorsFile)
 		if err == nil {
 			var validatorsMap map[string]string
@@ -309,40 +419,69 @@ func (bc *Blockchain) LoadFromDisk() error {
 		}
 	}
 	
-	// Load accounts
-	accountsFile := filepath.Join(dataDir, "accounts.json")
-	accountsData, err := ioutil.ReadFile(accountsFile)
-	if err != nil {
-		log.Printf("Failed to read accounts file: %v", err)
-		return err
-	}
-	
-	var accountsMap map[string]string
-	if err := json.Unmarshal(accountsData, &accountsMap); err != nil {
-		log.Printf("Failed to unmarshal accounts: %v", err)
-		return err
+	// Human proofs recorded on chain override the node-local copies
+	bc.rebuildHumanProofRegistryLocked()
+	bc.rebuildEvidenceLocked()
+	bc.loadTxIndexLocked(dataDir)
+	bc.loadBalanceHistoryLocked(dataDir)
+	bc.loadUpgradesLocked(dataDir)
+	bc.activations = make(map[Feature]Activation)
+	if len(bc.Blocks) > 0 {
+		if err := bc.applyGenesisActivationsLocked(bc.Blocks[0]); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		if err := bc.applyGenesisRewardScheduleLocked(bc.Blocks[0]); err != nil {
+			return fmt.Errorf("failed to load the reward schedule: %v", err)
+		}
 	}
-	
-	bc.accounts = make(map[string]*big.Int)
-	for addr, balanceStr := range accountsMap {
-		balance := new(big.Int)
-		success := false
-		if balanceStr != "" {
-			_, success = balance.SetString(balanceStr, 10)
+	bc.rebuildSupplyLocked()
+	bc.rebuildBridgeReleasesLocked()
+	bc.rebuildOraclesLocked()
+	bc.rebuildStakingLocked()
+	bc.rebuildCircuitBreakerLocked()
+	bc.rebuildFinalityLocked()
+	bc.loadActivationsLocked(dataDir)
+	bc.loadLabels(dataDir)
+	bc.loadAddressBook(dataDir)
+	bc.loadPaymentRequests(dataDir)
+	bc.loadAPIKeys(dataDir)
+	bc.loadWalletPassphrases(dataDir)
+	bc.loadWalletControls(dataDir)
+	bc.loadRejectedTxsLocked(dataDir)
+	bc.loadEventsLocked(dataDir)
+	
+	// Load accounts, or rebuild them from the blocks
+	if reindex {
+		if err := bc.reindexAccountsLocked(); err != nil {
+			return fmt.Errorf("failed to rebuild balances from blocks: %v", err)
+		}
+		log.Printf("Rebuilt %d account balances from %d blocks", len(bc.accounts), len(bc.Blocks))
+	} else {
+		accountsFile := filepath.Join(dataDir, "accounts.json")
+		accountsData, err := ioutil.ReadFile(accountsFile)
+		if err != nil {
+			log.Printf("Failed to read accounts file: %v", err)
+			return err
 		}
 		
-		if !success {
-			log.Printf("Invalid balance format for %s: %s, setting to 0", addr, balanceStr)
-			balance = big.NewInt(0)
+		var accountsMap map[string]string
+		if err := json.Unmarshal(accountsData, &accountsMap); err != nil {
+			log.Printf("Failed to unmarshal accounts: %v", err)
+			return err
 		}
 		
-		bc.accounts[addr] = balance
-		log.Printf("Loaded account %s with balance %s", addr, balance.String())
+		// Balances were validated by the integrity check
+		bc.accounts = make(map[string]*big.Int)
+		for addr, balanceStr := range accountsMap {
+			balance, _ := new(big.Int).SetString(balanceStr, 10)
+			bc.accounts[addr] = balance
+		}
+		bc.richIndex.reset()
 	}
 
 	// Load multi-signature wallets
 	multiSigFile := filepath.Join(dataDir, "multisig.json")
-	if _, err := os.Stat(multiSigFile); !os.IsNotExist(err) {
+	if !skip["multisig.json"] {
 		multiSigData, err := ioutil.ReadFile(multiSigFile)
 		if err == nil {
 			var multiSigMap map[string]*MultiSigWallet
@@ -366,11 +505,11 @@ func (bc *Blockchain) AddValidator(address string, humanProof string) error {
 	
 	// Check if human proof is valid (in a real implementation, this would verify with PoH)
 	if humanProof == "" {
-		return errors.New("human proof is required for validators")
+		return ErrHumanProofRequired
 	}
 	
-	// Generate a new key pair for the validator
-	keyPair, err := NewKeyPair()
+	// Generate a new key pair for the validator, using the scheme its address encodes
+	keyPair, err := NewKeyPairOfType(AddressKeyType(address))
 	if err != nil {
 		return err
 	}
@@ -378,6 +517,10 @@ func (bc *Blockchain) AddValidator(address string, humanProof string) error {
 	bc.validators[address] = true
 	bc.humanProofs[address] = humanProof
 	bc.keyPairs[address] = keyPair
+	
+	// Record the proof on chain so other nodes can validate this validator's blocks
+	bc.queueHumanProofLocked(address, humanProof)
+	bc.RecordEvent(EventValidatorAdded, address, nil)
 	return nil
 }
 
@@ -392,27 +535,118 @@ func (bc *Blockchain) IsValidator(address string) bool {
 func (bc *Blockchain) GetHumanProof(address string) string {
 	bc.mu.RLock()
 	defer bc.mu.RUnlock()
-	return bc.humanProofs[address]
+	return bc.humanProofForLocked(address)
 }
 
 // AddTransaction adds a new transaction to the pending transactions pool
 func (bc *Blockchain) AddTransaction(tx *Transaction) error {
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
+	return bc.addTransactionLocked(tx)
+}
 
+// addTransactionLocked validates tx and adds it to the pool. The caller must hold bc.mu.
+func (bc *Blockchain) addTransactionLocked(tx *Transaction) error {
 	// Validate transaction
 	if tx == nil {
-		return errors.New("transaction is nil")
+		return ErrNilTransaction
 	}
 
-	// Check if transaction already exists
+	// Rewards are minted by block proposers only and never enter the pool
+	if tx.Type == RewardTxType {
+		return fmt.Errorf("%w: reward transactions cannot be submitted", ErrInvalidReward)
+	}
+	if tx.Type == EpochRewardTxType {
+		return fmt.Errorf("%w: epoch rewards are paid out by block proposers only", ErrInvalidEpochReward)
+	}
+	if tx.Type == GenesisAllocationTxType {
+		return fmt.Errorf("%w: allocations only exist in the genesis block", ErrInvalidGenesisAllocation)
+	}
+	if tx.Type == GenesisActivationsTxType {
+		return fmt.Errorf("%w: the genesis schedule only exists in the genesis block", ErrInvalidActivation)
+	}
+	if tx.Type == GenesisRewardScheduleTxType {
+		return fmt.Errorf("%w: the emission schedule only exists in the genesis block", ErrInvalidRewardSchedule)
+	}
+	if err := checkReservedAddresses(tx.From, tx.To); err != nil {
+		return err
+	}
+	if err := bc.checkBridgeTransactionLocked(tx, uint64(len(bc.Blocks))); err != nil {
+		return err
+	}
+	if err := bc.checkOracleTransactionLocked(tx, uint64(len(bc.Blocks))); err != nil {
+		return err
+	}
+	if err := bc.checkCircuitBreakerLocked(tx, uint64(len(bc.Blocks))); err != nil {
+		return err
+	}
+
+	// Signatures made for another network must not be replayed here
+	if err := checkTransactionChain(tx); err != nil {
+		return err
+	}
+	if err := bc.verifyPooledSignatureLocked(tx); err != nil {
+		return err
+	}
+
+	// Staking requests must use a fresh nonce and be covered by the delegator's stake
+	if err := bc.admitStakingTransactionLocked(tx); err != nil {
+		return err
+	}
+	if err := bc.checkCommissionTransactionLocked(tx); err != nil {
+		return err
+	}
+
+	// Slashes burn stake on every node and are checked before they reach a block
+	if tx.Type == ValidatorSlashTxType {
+		if err := bc.checkValidatorSlashLocked(tx); err != nil {
+			return err
+		}
+	}
+
+	// Human proofs must be attested by another validator
+	if tx.Type == HumanProofTxType {
+		if err := bc.checkHumanProofLocked(tx); err != nil {
+			return err
+		}
+	}
+
+	// Evidence is checked up front so forged accusations never reach a block
+	if tx.Type == SlashEvidenceTxType {
+		if _, err := bc.verifyEvidenceLocked(tx); err != nil {
+			return err
+		}
+	}
+
+	// Malformed or oversized contracts are refused before they can reach a block
+	if tx.IsContractTransaction() {
+		if err := ValidateContractTransaction(tx); err != nil {
+			return err
+		}
+	}
+
+	// Transfers that would leave dust behind bloat the state
+	if err := bc.checkDustLocked(tx); err != nil {
+		return err
+	}
+
+	// Memos are read back by the spend report, so malformed ones are refused
+	if err := checkTxMemo(tx); err != nil {
+		return err
+	}
+
+	// Check if transaction already exists, pending or confirmed
 	if _, exists := bc.txPool[tx.ID]; exists {
-		return errors.New("transaction already exists")
+		return fmt.Errorf("%w: %s", ErrTxExists, tx.ID)
+	}
+	if err := bc.checkUnconfirmedLocked(tx); err != nil {
+		return err
 	}
 
 	// Add to pending transactions
 	bc.txPool[tx.ID] = tx
 	bc.pendingTxs = append(bc.pendingTxs, tx)
+	bc.recordFirstSeenLocked(tx.ID)
 	return nil
 }
 
@@ -422,7 +656,7 @@ func (bc *Blockchain) GetPendingTransactions() []*Transaction {
 	
 	transactionCount := len(bc.pendingTxs)
 	
-	// Hızlı bir kopya oluştur ve kilidi bırak
+	// Make a quick copy and release the lock
 	result := make([]*Transaction, transactionCount)
 	for i := 0; i < transactionCount && i < len(bc.pendingTxs); i++ {
 		result[i] = bc.pendingTxs[i]
@@ -433,118 +667,33 @@ func (bc *Blockchain) GetPendingTransactions() []*Transaction {
 	return result
 }
 
-// AddBlock adds a new block to the blockchain
+// AddBlock verifies a block, applies it to the state and persists the chain.
+// The reward transaction must already be part of the block (see AttachRewards),
+// so the block is never modified after it was signed.
 func (bc *Blockchain) AddBlock(block *Block) error {
 	bc.mu.Lock()
-	defer bc.mu.Unlock()
-	
-	// Verify block index
-	if uint64(len(bc.Blocks)) != block.Index {
-		return fmt.Errorf("invalid block index: expected %d, got %d", len(bc.Blocks), block.Index)
-	}
-	
-	// Verify previous hash
-	prevBlock := bc.Blocks[len(bc.Blocks)-1]
-	if prevBlock.Hash != block.PrevHash {
-		return fmt.Errorf("invalid previous hash: expected %s, got %s", prevBlock.Hash, block.PrevHash)
-	}
-	
-	// Verify human proof
-	if !bc.IsValidator(block.Validator) {
-		return fmt.Errorf("invalid validator: %s is not an authorized validator", block.Validator)
-	}
 	
-	// Verify that human proof matches
-	expectedProof := bc.GetHumanProof(block.Validator)
-	if expectedProof != block.HumanProof {
-		return fmt.Errorf("invalid human proof: expected %s, got %s", expectedProof, block.HumanProof)
-	}
-	
-	// Verify block signature
-	err := bc.verifyBlockSignature(block)
-	if err != nil {
-		return fmt.Errorf("invalid block signature: %v", err)
-	}
-	
-	// Add the block
-	bc.Blocks = append(bc.Blocks, block)
-	
-	// Process all transactions
-	var errMsgs []string
-	
-	// Create a mining reward transaction for the validator
-	rewardAmount := bc.GetRewardAmount()
-	if rewardAmount.Cmp(big.NewInt(0)) > 0 {
-		// Convert big.Int to uint64 for the transaction
-		rewardUint64 := uint64(0)
-		if rewardAmount.IsUint64() {
-			rewardUint64 = rewardAmount.Uint64()
-		} else {
-			// If reward is too large for uint64, cap it
-			log.Printf("Warning: Reward amount is too large for uint64, capping it")
-			rewardUint64 = ^uint64(0) // Maximum uint64 value
-		}
-		
-		rewardTx := &Transaction{
-			ID:        fmt.Sprintf("reward_%d_%s", block.Index, block.Validator),
-			From:      "confirmix_genesis_address", // Rewards come from the genesis account
-			To:        block.Validator,
-			Value:     rewardUint64,
-			Timestamp: block.Timestamp,
-			Type:      "reward",
-			Status:    "confirmed",
-			BlockIndex: int64(block.Index),
-			BlockHash:  block.Hash,
-		}
-		
-		// Add the reward transaction to the block
-		block.Transactions = append(block.Transactions, rewardTx)
-		
-		// Update balances for the reward transaction
-		if err := bc.UpdateBalances(rewardTx); err != nil {
-			errMsgs = append(errMsgs, fmt.Sprintf("failed to process reward transaction: %v", err))
-		}
-	}
-	
-	// Process all user transactions
-	for _, tx := range block.Transactions {
-		// Skip the reward transaction as it was already processed
-		if tx.Type == "reward" {
-			continue
-		}
-		
-		// Update transaction status
-		tx.Status = "confirmed"
-		tx.BlockIndex = int64(block.Index)
-		tx.BlockHash = block.Hash
-		
-		// Update balances
-		if err := bc.UpdateBalances(tx); err != nil {
-			errMsgs = append(errMsgs, fmt.Sprintf("failed to process transaction %s: %v", tx.ID, err))
-			continue
-		}
-		
-		// Process contract transaction if applicable
-		if tx.IsContractTransaction() {
-			if err := bc.processContractTransaction(tx); err != nil {
-				errMsgs = append(errMsgs, fmt.Sprintf("failed to process contract transaction %s: %v", tx.ID, err))
-			}
+	if err := bc.verifyBlockLocked(block); err != nil {
+		bc.mu.Unlock()
+		if errors.Is(err, ErrBlockQuotaExceeded) {
+			bc.recordQuotaViolation(block)
 		}
+		return err
 	}
 	
-	// Clean transaction pool
-	bc.cleanTransactionPool(block.Transactions)
+	applyErr := bc.applyBlockLocked(block)
+	bc.mu.Unlock()
+	bc.warnUpcomingUpgrade(block.Index + 1)
 	
 	// Save blockchain state
 	if err := bc.SaveToDisk(); err != nil {
-		errMsgs = append(errMsgs, fmt.Sprintf("failed to save blockchain state: %v", err))
-	}
-	
-	if len(errMsgs) > 0 {
-		return fmt.Errorf("block added with errors: %s", strings.Join(errMsgs, "; "))
+		if applyErr != nil {
+			return fmt.Errorf("%v; failed to save blockchain state: %v", applyErr, err)
+		}
+		return fmt.Errorf("%w: failed to save blockchain state: %v", ErrBlockAppliedWithErrors, err)
 	}
 	
-	return nil
+	return applyErr
 }
 
 // verifyBlockSignature verifies the signature of a block
@@ -552,10 +701,14 @@ func (bc *Blockchain) verifyBlockSignature(block *Block) error {
 	// Get the public key for the block validator
 	keyPair, exists := bc.keyPairs[block.Validator]
 	if !exists {
-		return errors.New("validator's public key not found")
+		return fmt.Errorf("%w: validator's public key not found", ErrKeyPairNotFound)
 	}
 	
-	return block.Verify(keyPair.PublicKey)
+	publicKey := keyPair.Public()
+	if publicKey == nil {
+		return fmt.Errorf("%w: validator's public key not found", ErrKeyPairNotFound)
+	}
+	return block.VerifyWith(publicKey)
 }
 
 // processContractTransaction processes a contract transaction
@@ -570,16 +723,17 @@ func (bc *Blockchain) processContractTransaction(tx *Transaction) error {
 	switch contractTx.Operation {
 	case "deploy":
 		// Deploy a new contract
-		_, err := bc.contractManager.DeployContract(contractTx.Code, tx.From)
+		_, err := bc.contractManager.DeployContract(contractTx.Code, tx.From, contractTx.Permissions...)
 		return err
 		
 	case "call":
-		// Call a contract function
-		_, err := bc.contractManager.CallContract(
+		// Call a contract function with access to the host API
+		_, err := bc.contractManager.callContract(
 			contractTx.ContractAddress,
 			contractTx.Function,
 			contractTx.Parameters,
 			tx.From,
+			bc.newHostContext(tx.From, contractTx.GasLimit),
 		)
 		return err
 		
@@ -620,27 +774,48 @@ func (bc *Blockchain) GetLatestBlock() *Block {
 // GetBlock returns a block by its hash
 func (bc *Blockchain) GetBlock(hash string) (*Block, error) {
 	bc.mu.RLock()
-	defer bc.mu.RUnlock()
-	
+	var found *Block
 	for _, block := range bc.Blocks {
 		if block.Hash == hash {
-			return block, nil
+			found = block
+			break
 		}
 	}
+	bc.mu.RUnlock()
+	
+	if found == nil {
+		return nil, ErrBlockNotFound
+	}
 	
-	return nil, errors.New("block not found")
+	// Archived bodies are fetched without holding the chain lock
+	return bc.hydrateBlock(found)
 }
 
 // GetBlockByIndex returns a block by its index
 func (bc *Blockchain) GetBlockByIndex(index uint64) (*Block, error) {
 	bc.mu.RLock()
-	defer bc.mu.RUnlock()
-	
 	if index >= uint64(len(bc.Blocks)) {
-		return nil, errors.New("block index out of range")
+		bc.mu.RUnlock()
+		return nil, fmt.Errorf("%w: block index out of range", ErrBlockNotFound)
 	}
+	block := bc.Blocks[index]
+	bc.mu.RUnlock()
 	
-	return bc.Blocks[index], nil
+	// Archived bodies are fetched without holding the chain lock
+	return bc.hydrateBlock(block)
+}
+
+// LastBlockBy returns the most recent block produced by validator
+func (bc *Blockchain) LastBlockBy(validator string) (*Block, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	
+	for i := len(bc.Blocks) - 1; i >= 0; i-- {
+		if bc.Blocks[i].Validator == validator {
+			return bc.Blocks[i], true
+		}
+	}
+	return nil, false
 }
 
 // GetContractManager returns the contract manager
@@ -650,12 +825,17 @@ func (bc *Blockchain) GetContractManager() *ContractManager {
 	return bc.contractManager
 }
 
-// GetTransaction returns a transaction by ID
+// GetTransaction returns a pending or confirmed transaction by ID
 func (bc *Blockchain) GetTransaction(id string) (*Transaction, bool) {
 	bc.mu.RLock()
-	defer bc.mu.RUnlock()
 	tx, exists := bc.txPool[id]
-	return tx, exists
+	bc.mu.RUnlock()
+	if exists {
+		return tx, true
+	}
+
+	tx, _, err := bc.getIndexedTransaction(id)
+	return tx, err == nil
 }
 
 // GetKeyPair returns the key pair for an address
@@ -694,10 +874,11 @@ func (bc *Blockchain) CreateAccount(address string, initialBalance *big.Int) err
 	defer bc.mu.Unlock()
 	
 	if _, exists := bc.accounts[address]; exists {
-		return errors.New("account already exists")
+		return ErrAccountExists
 	}
 	
 	bc.accounts[address] = initialBalance
+	bc.richIndex.touch(address)
 	return nil
 }
 
@@ -722,30 +903,27 @@ func (bc *Blockchain) UpdateBalances(tx *Transaction) error {
 	// Convert the uint64 value to big.Int
 	txValue := new(big.Int).SetUint64(tx.Value)
 	
-	// Reward transaction handling
-	if tx.Type == "reward" {
-		// Add rewards to validator account
-		currentBalance, exists := bc.accounts[tx.To]
-		if !exists {
-			currentBalance = big.NewInt(0)
-		}
-		bc.accounts[tx.To] = new(big.Int).Add(currentBalance, txValue)
-		return nil
+	// Rewards are minted when their block is applied, see emission.go
+	if tx.Type == RewardTxType {
+		return fmt.Errorf("%w: rewards are minted, not transferred", ErrInvalidReward)
+	}
+	if err := checkReservedAddresses(tx.From, tx.To); err != nil {
+		return err
 	}
 	
 	// Regular transaction handling
 	if tx.From == tx.To {
-		return errors.New("sender and recipient cannot be the same")
+		return ErrSelfTransfer
 	}
 	
 	fromBalance, exists := bc.accounts[tx.From]
 	if !exists {
-		return errors.New("sender account does not exist")
+		return fmt.Errorf("%w: sender account does not exist", ErrAccountNotFound)
 	}
 	
 	// Check if sender has enough funds
 	if fromBalance.Cmp(txValue) < 0 {
-		return errors.New("insufficient funds")
+		return fmt.Errorf("%w: insufficient funds", ErrInsufficientBalance)
 	}
 	
 	// Update sender's balance
@@ -757,6 +935,7 @@ func (bc *Blockchain) UpdateBalances(tx *Transaction) error {
 		toBalance = big.NewInt(0)
 	}
 	bc.accounts[tx.To] = new(big.Int).Add(toBalance, txValue)
+	bc.richIndex.touch(tx.From, tx.To)
 	
 	return nil
 }
@@ -782,6 +961,20 @@ func (bc *Blockchain) GetValidators() []ValidatorInfo {
 	return validators
 }
 
+// ValidatorSet returns the addresses of the active validator set, sorted so
+// that every node holding the same set derives the same proposer order
+func (bc *Blockchain) ValidatorSet() []string {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	
+	addresses := make([]string, 0, len(bc.validators))
+	for addr := range bc.validators {
+		addresses = append(addresses, addr)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
 // RemoveTransaction removes a transaction from the pool by ID
 func (bc *Blockchain) RemoveTransaction(txID string) error {
 	bc.mu.Lock()
@@ -789,11 +982,12 @@ func (bc *Blockchain) RemoveTransaction(txID string) error {
 	
 	// Check if transaction exists in the pool
 	if _, exists := bc.txPool[txID]; !exists {
-		return fmt.Errorf("transaction %s not found in pool", txID)
+		return fmt.Errorf("%w: %s not found in pool", ErrTxNotFound, txID)
 	}
 	
 	// Remove from transaction pool
 	delete(bc.txPool, txID)
+	bc.dropLifecycleLocked(txID)
 	
 	// Also remove from pending transactions
 	for i, tx := range bc.pendingTxs {
@@ -808,85 +1002,58 @@ func (bc *Blockchain) RemoveTransaction(txID string) error {
 	return nil
 }
 
-// GetRewardAmount returns the amount of ConX tokens to be rewarded for mining a block
+// GetRewardAmount returns the amount of ConX tokens to be rewarded for mining the next block
 // This implements a halving schedule for rewards
 func (bc *Blockchain) GetRewardAmount() *big.Int {
-	bc.mutex.RLock()
-	defer bc.mutex.RUnlock()
-	
-	// Base reward: 50 ConX tokens with 18 decimals
-	baseReward := new(big.Int)
-	baseReward.SetString("50000000000000000000", 10) // 50 tokens with 18 decimals
-	
-	// Determine the reward epoch (halving every 210,000 blocks, similar to Bitcoin)
-	blockHeight := uint64(len(bc.Blocks))
-	halvingInterval := uint64(210000)
-	epoch := blockHeight / halvingInterval
-	
-	// Calculate the reward based on the epoch (halving)
-	if epoch > 0 {
-		// Calculate 2^epoch for the divisor
-		divisor := big.NewInt(1)
-		for i := uint64(0); i < epoch; i++ {
-			divisor.Mul(divisor, big.NewInt(2))
-		}
-		
-		// Apply the divisor
-		reward := new(big.Int).Div(baseReward, divisor)
-		return reward
-	}
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
 	
-	return baseReward
+	return bc.blockRewardLocked(uint64(len(bc.Blocks)))
 }
 
 // MineBlock creates a new block with pending transactions
 func (bc *Blockchain) MineBlock(validatorAddress string) (*Block, error) {
-	bc.mu.Lock()
-	defer bc.mu.Unlock()
-
 	// Check if the validator is authorized
 	if !bc.IsValidator(validatorAddress) {
-		return nil, errors.New("unauthorized validator")
+		return nil, fmt.Errorf("%w: %s", ErrUnknownValidator, validatorAddress)
 	}
 
-	// Get pending transactions
-	pendingTxs := bc.GetPendingTransactions()
+	// Get pending transactions in priority order, up to the block capacity
+	pendingTxs := bc.SelectTransactions(bc.MaxBlockTransactions())
 	if len(pendingTxs) == 0 {
-		return nil, errors.New("no pending transactions")
+		return nil, ErrNoPendingTxs
+	}
+
+	keyPair, exists := bc.GetKeyPair(validatorAddress)
+	if !exists {
+		return nil, fmt.Errorf("%w: validator %s", ErrKeyPairNotFound, validatorAddress)
 	}
 
 	// Create new block
 	prevBlock := bc.GetLatestBlock()
 	block := &Block{
-		Index:        uint64(len(bc.Blocks)),
-		Timestamp:    time.Now().Unix(),
+		Index:        prevBlock.Index + 1,
+		Timestamp:    Now().Unix(),
 		Transactions: pendingTxs,
 		PrevHash:     prevBlock.Hash,
 		Validator:    validatorAddress,
-		Reward:       bc.GetRewardAmount().Uint64(), // Convert big.Int to uint64
+		HumanProof:   bc.GetHumanProof(validatorAddress),
 	}
 
-	// Calculate block hash
-	block.Hash = block.CalculateHash()
+	// Embed the rewards and the parent's commit certificate and calculate the final block hash
+	bc.AttachRewards(block)
+	bc.AttachCommitCertificate(block)
 
 	// Sign block with validator's private key
-	keyPair, exists := bc.GetKeyPair(validatorAddress)
-	if !exists {
-		return nil, errors.New("validator key pair not found")
-	}
-
-	if err := block.Sign(keyPair.PrivateKey); err != nil {
+	if err := block.SignWith(keyPair.Signer()); err != nil {
 		return nil, fmt.Errorf("failed to sign block: %v", err)
 	}
 
-	// Add block to chain
-	if err := bc.AddBlock(block); err != nil {
+	// Add block to chain; this also cleans the transaction pool
+	if err := bc.AddBlock(block); err != nil && !errors.Is(err, ErrBlockAppliedWithErrors) {
 		return nil, err
 	}
 
-	// Clean transaction pool
-	bc.cleanTransactionPool(pendingTxs)
-
 	return block, nil
 }
 
@@ -897,14 +1064,18 @@ func (bc *Blockchain) RegisterValidator(address string, humanProof string) error
 	
 	// Check if already a validator
 	if _, exists := bc.validators[address]; exists {
-		return errors.New("address is already a validator")
+		return ErrValidatorExists
 	}
 	
 	// Add to validators map
 	bc.validators[address] = true
 	
-	// Store human proof
+	// Store human proof and record it on chain
 	bc.humanProofs[address] = humanProof
+	if humanProof != "" {
+		bc.queueHumanProofLocked(address, humanProof)
+	}
+	bc.RecordEvent(EventValidatorAdded, address, nil)
 	
 	log.Printf("Validator registered: %s with human proof: %s", address, humanProof)
 	
@@ -921,13 +1092,14 @@ func (bc *Blockchain) RemoveValidator(address string) error {
 	
 	// Check if the address is a validator
 	if _, exists := bc.validators[address]; !exists {
-		return errors.New("address is not a validator")
+		return fmt.Errorf("%w: %s", ErrUnknownValidator, address)
 	}
 	
 	// Remove from validators map
 	delete(bc.validators, address)
 	
 	// We keep the human proof in case they are re-added later
+	bc.RecordEvent(EventValidatorRemoved, address, nil)
 	
 	log.Printf("Validator removed: %s", address)
 	
@@ -945,12 +1117,12 @@ func (bc *Blockchain) Lock(address string, amount *big.Int) error {
 	// Check if the address has sufficient balance
 	balance, exists := bc.accounts[address]
 	if !exists {
-		return fmt.Errorf("address %s not found", address)
+		return fmt.Errorf("%w: %s", ErrAccountNotFound, address)
 	}
 	
 	// Check if balance is sufficient
 	if balance.Cmp(amount) < 0 {
-		return fmt.Errorf("insufficient balance: have %s, trying to lock %s", 
+		return fmt.Errorf("%w: have %s, trying to lock %s", ErrInsufficientBalance,
 			balance.String(), amount.String())
 	}
 	
@@ -962,6 +1134,8 @@ func (bc *Blockchain) Lock(address string, amount *big.Int) error {
 	// Update balances
 	bc.accounts[address] = new(big.Int).Sub(balance, amount)
 	bc.lockedBalances[address] = new(big.Int).Add(bc.lockedBalances[address], amount)
+	bc.richIndex.touch(address)
+	bc.RecordEvent(EventBalanceLocked, address, map[string]string{"amount": amount.String()})
 	
 	// Save the updated state
 	return bc.SaveToDisk()
@@ -975,12 +1149,12 @@ func (bc *Blockchain) Unlock(address string, amount *big.Int) error {
 	// Check if the address has locked tokens
 	lockedBalance, exists := bc.lockedBalances[address]
 	if !exists || lockedBalance.Cmp(big.NewInt(0)) == 0 {
-		return fmt.Errorf("address %s has no locked tokens", address)
+		return fmt.Errorf("%w: address %s has no locked tokens", ErrInsufficientLocked, address)
 	}
 	
 	// Check if locked balance is sufficient
 	if lockedBalance.Cmp(amount) < 0 {
-		return fmt.Errorf("insufficient locked balance: have %s locked, trying to unlock %s", 
+		return fmt.Errorf("%w: have %s locked, trying to unlock %s", ErrInsufficientLocked,
 			lockedBalance.String(), amount.String())
 	}
 	
@@ -992,6 +1166,8 @@ func (bc *Blockchain) Unlock(address string, amount *big.Int) error {
 	// Update balances
 	bc.lockedBalances[address] = new(big.Int).Sub(lockedBalance, amount)
 	bc.accounts[address] = new(big.Int).Add(bc.accounts[address], amount)
+	bc.richIndex.touch(address)
+	bc.RecordEvent(EventBalanceUnlocked, address, map[string]string{"amount": amount.String()})
 	
 	// Save the updated state
 	return bc.SaveToDisk()
@@ -1013,18 +1189,22 @@ func (bc *Blockchain) GetLockedBalance(address string) (*big.Int, error) {
 // TransferFrom transfers tokens from one address to another
 // Used for governance operations like treasury transfers
 func (bc *Blockchain) TransferFrom(from, to string, amount *big.Int) error {
+	if err := checkReservedAddresses(from, to); err != nil {
+		return err
+	}
+
 	bc.mutex.Lock()
 	defer bc.mutex.Unlock()
 	
 	// Check if the source address exists and has sufficient balance
 	fromBalance, exists := bc.accounts[from]
 	if !exists {
-		return fmt.Errorf("source address %s not found", from)
+		return fmt.Errorf("%w: source address %s", ErrAccountNotFound, from)
 	}
 	
 	// Check if balance is sufficient
 	if fromBalance.Cmp(amount) < 0 {
-		return fmt.Errorf("insufficient balance: have %s, trying to transfer %s", 
+		return fmt.Errorf("%w: have %s, trying to transfer %s", ErrInsufficientBalance,
 			fromBalance.String(), amount.String())
 	}
 	
@@ -1036,6 +1216,12 @@ func (bc *Blockchain) TransferFrom(from, to string, amount *big.Int) error {
 	// Update balances
 	bc.accounts[from] = new(big.Int).Sub(fromBalance, amount)
 	bc.accounts[to] = new(big.Int).Add(bc.accounts[to], amount)
+	bc.richIndex.touch(from, to)
+	bc.RecordEvent(EventBalanceTransfer, from, map[string]string{
+		"from":   from,
+		"to":     to,
+		"amount": amount.String(),
+	})
 	
 	// Save the updated state
 	return bc.SaveToDisk()
@@ -1049,11 +1235,23 @@ func (bc *Blockchain) initialize() {
 	
 	// Initialize maps
 	bc.accounts = make(map[string]*big.Int)
+	bc.richIndex.reset()
 	bc.PendingTXs = make(map[string]*Transaction)
 	bc.pendingTxs = make([]*Transaction, 0)
 	bc.txPool = make(map[string]*Transaction)
+	bc.txLifecycles = txLifecycleStore{}
 	bc.validators = make(map[string]bool)
 	bc.humanProofs = make(map[string]string)
+	bc.humanProofRegistry = make(map[string]*HumanProofRecord)
+	bc.evidence = make(map[string]*EvidenceRecord)
+	bc.txIndex = make(map[string]TxLocation)
+	bc.bridgeReleases = make(map[string]string)
+	bc.oracles = make(map[string]*OracleInfo)
+	bc.oracleFeeds = make(map[string][]OracleRecord)
+	bc.staking = newStakingLedger()
+	bc.commissions = make(map[string][]CommissionChange)
+	bc.finality = finalityState{}
+	bc.balanceHistory = make(map[string][]BalancePoint)
 	bc.lockedBalances = make(map[string]*big.Int)
 	bc.contractManager = NewContractManager()
 	bc.keyPairs = make(map[string]*KeyPair)
@@ -1098,6 +1296,11 @@ func (bc *Blockchain) AddGenesisBlock(totalSupply *big.Int) {
 		log.Printf("Warning: Failed to save owner3 key pair: %v", err)
 	}
 
+	// Register the owners' keys so their multisig signatures can be verified
+	for _, ownerKeyPair := range []*KeyPair{owner1KeyPair, owner2KeyPair, owner3KeyPair} {
+		bc.keyPairs[ownerKeyPair.GetAddress()] = ownerKeyPair
+	}
+
 	// Step 3: Create Multisig Wallet Structure with only the three new owners
 	genesisOwners := []string{
 		owner1KeyPair.GetAddress(), // Owner 1
@@ -1129,12 +1332,21 @@ func (bc *Blockchain) AddGenesisBlock(totalSupply *big.Int) {
 		Validator:    genesisMultiSigWallet.Address, // Use multisig wallet address as validator
 		HumanProof:   "genesis",
 	}
+	if err := attachGenesisAllocations(genesisBlock, []GenesisAllocation{
+		{Address: genesisMultiSigWallet.Address, Amount: totalSupply.String(), Label: "genesis multisig wallet"},
+	}); err != nil {
+		log.Fatalf("Failed to create genesis allocations: %v", err)
+	}
 
 	// Add the genesis block
 	bc.Blocks = append(bc.Blocks, genesisBlock)
 
-	// Step 6: Initialize Genesis Account with Total Supply
-	bc.accounts[genesisMultiSigWallet.Address] = totalSupply
+	// Step 6: Credit the Total Supply through the Genesis Allocation
+	if err := bc.applyGenesisLocked(genesisBlock); err != nil {
+		log.Fatalf("Failed to apply genesis allocations: %v", err)
+	}
+	bc.indexBlockLocked(genesisBlock)
+	bc.recordBalancesLocked(genesisBlock, nil)
 
 	// Step 7: Register Genesis Multisig Wallet as Validator
 	bc.validators[genesisMultiSigWallet.Address] = true
@@ -1164,7 +1376,7 @@ func (bc *Blockchain) AddGenesisBlock(totalSupply *big.Int) {
 	if err != nil {
 		log.Printf("Warning: Failed to marshal multisig info: %v", err)
 	} else {
-		if err := os.WriteFile("data/multisig.json", multisigData, 0644); err != nil {
+		if err := os.WriteFile(filepath.Join(GetBlockchainDataPath(), "multisig.json"), multisigData, 0644); err != nil {
 			log.Printf("Warning: Failed to save multisig info: %v", err)
 		}
 	}
@@ -1174,12 +1386,12 @@ func (bc *Blockchain) AddGenesisBlock(totalSupply *big.Int) {
 	log.Printf("Admin wallet address (symbolic): %s", adminAddress)
 	log.Printf("Genesis MultiSig wallet initialized with %d owners", len(genesisOwners))
 	log.Printf("Genesis wallet initialized with total supply of %s tokens", totalSupply.String())
-	log.Printf("Multisig wallet info saved to data/multisig.json")
+	log.Printf("Multisig wallet info saved to %s", filepath.Join(GetBlockchainDataPath(), "multisig.json"))
 	log.Printf("Required signatures for multisig operations: %d", requiredSigs)
 	log.Printf("Owner addresses and their key pairs:")
-	log.Printf("  Owner 1: %s (saved to data/key_%s.json)", owner1KeyPair.GetAddress(), owner1KeyPair.GetAddress())
-	log.Printf("  Owner 2: %s (saved to data/key_%s.json)", owner2KeyPair.GetAddress(), owner2KeyPair.GetAddress())
-	log.Printf("  Owner 3: %s (saved to data/key_%s.json)", owner3KeyPair.GetAddress(), owner3KeyPair.GetAddress())
+	log.Printf("  Owner 1: %s (saved to %s)", owner1KeyPair.GetAddress(), filepath.Join(GetBlockchainDataPath(), "key_"+owner1KeyPair.GetAddress()+".json"))
+	log.Printf("  Owner 2: %s (saved to %s)", owner2KeyPair.GetAddress(), filepath.Join(GetBlockchainDataPath(), "key_"+owner2KeyPair.GetAddress()+".json"))
+	log.Printf("  Owner 3: %s (saved to %s)", owner3KeyPair.GetAddress(), filepath.Join(GetBlockchainDataPath(), "key_"+owner3KeyPair.GetAddress()+".json"))
 
 	// Step 11: Save Final Blockchain State
 	bc.SaveToDisk()
@@ -1192,7 +1404,7 @@ func (bc *Blockchain) CreateMultiSigWallet(address string, owners []string, requ
 
 	// Check if wallet already exists
 	if _, exists := bc.multiSigWallets[address]; exists {
-		return errors.New("multi-signature wallet already exists")
+		return ErrMultiSigWalletExists
 	}
 
 	// Create new wallet
@@ -1212,7 +1424,7 @@ func (bc *Blockchain) GetMultiSigWallet(address string) (*MultiSigWallet, error)
 
 	wallet, exists := bc.multiSigWallets[address]
 	if !exists {
-		return nil, errors.New("multi-signature wallet not found")
+		return nil, ErrMultiSigWalletNotFound
 	}
 
 	return wallet, nil
@@ -1228,33 +1440,83 @@ func (bc *Blockchain) CreateMultiSigTransaction(walletAddress, from, to string,
 	return wallet.CreateTransaction(from, to, value, data, txType)
 }
 
-// SignMultiSigTransaction signs a multi-signature transaction
+// CreateMultiSigProposalTransaction creates the multisig transaction paying out
+// an approved governance proposal from a multi-signature wallet
+func (bc *Blockchain) CreateMultiSigProposalTransaction(walletAddress, proposalID, to string, value *big.Int) (*MultiSigTransaction, error) {
+	wallet, err := bc.GetMultiSigWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := wallet.CreateProposalTransaction(proposalID, to, value)
+	if err != nil {
+		return nil, err
+	}
+	go bc.SaveToDisk()
+	return tx, nil
+}
+
+// SignMultiSigTransaction adds an owner's signature to a multi-signature
+// transaction after verifying it with the owner's registered key
 func (bc *Blockchain) SignMultiSigTransaction(walletAddress, txID, signer string, signature string) error {
 	wallet, err := bc.GetMultiSigWallet(walletAddress)
 	if err != nil {
 		return err
 	}
+	if !wallet.IsOwner(signer) {
+		return fmt.Errorf("%w: signer %s", ErrNotOwner, signer)
+	}
 
-	return wallet.SignTransaction(txID, signer, signature)
+	keyPair, exists := bc.GetKeyPair(signer)
+	if !exists {
+		return fmt.Errorf("%w: owner %s has no registered key", ErrKeyPairNotFound, signer)
+	}
+	return wallet.SignTransaction(txID, signer, signature, keyPair.Public())
 }
 
-// ExecuteMultiSigTransaction executes a multi-signature transaction that has enough signatures
+// ExecuteMultiSigTransaction executes a multi-signature transaction that has enough signatures.
+// Once the owners approved it, the transaction is signed with the wallet's own key so
+// other nodes can verify it like any other transfer.
 func (bc *Blockchain) ExecuteMultiSigTransaction(walletAddress, txID string) error {
 	wallet, err := bc.GetMultiSigWallet(walletAddress)
 	if err != nil {
 		return err
 	}
+	keyPair, exists := bc.GetKeyPair(walletAddress)
+	if !exists || keyPair.Signer() == nil {
+		return fmt.Errorf("%w: the node does not hold the key of %s", ErrKeyPairNotFound, walletAddress)
+	}
 
 	// Get the transaction
 	tx, err := wallet.ExecuteTransaction(txID)
 	if err != nil {
 		return err
 	}
+	if err := tx.SignWith(keyPair.Signer()); err != nil {
+		return err
+	}
 
 	// Add to pending transactions
 	return bc.AddTransaction(tx)
 }
 
+// MultiSigSigningHash returns the digest the owners sign to approve a pending
+// multi-signature transaction
+func (bc *Blockchain) MultiSigSigningHash(walletAddress, txID string) ([]byte, error) {
+	wallet, err := bc.GetMultiSigWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet.mutex.RLock()
+	defer wallet.mutex.RUnlock()
+	tx, exists := wallet.PendingTxs[txID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrTxNotFound, txID)
+	}
+	return tx.SigningHash(walletAddress)
+}
+
 // GetMultiSigTransactionStatus returns the status of a multi-signature transaction
 func (bc *Blockchain) GetMultiSigTransactionStatus(walletAddress, txID string) (string, error) {
 	wallet, err := bc.GetMultiSigWallet(walletAddress)
@@ -1298,6 +1560,11 @@ func (bc *Blockchain) RevertTransaction(hash string) error {
 				if err := bc.UpdateBalances(reversedTx); err != nil {
 					return fmt.Errorf("failed to update balances: %v", err)
 				}
+				bc.RecordEvent(EventTxReverted, tx.ID, map[string]string{
+					"from":   reversedTx.From,
+					"to":     reversedTx.To,
+					"amount": new(big.Int).SetUint64(reversedTx.Value).String(),
+				})
 
 				// Save the updated state
 				bc.SaveToDisk()
@@ -1306,11 +1573,16 @@ func (bc *Blockchain) RevertTransaction(hash string) error {
 		}
 	}
 
-	return fmt.Errorf("transaction not found")
+	return ErrTxNotFound
 }
 
-// VerifySignature verifies a signature using a public key
-func (bc *Blockchain) VerifySignature(message, signature string, publicKey *ecdsa.PublicKey) (bool, error) {
+// VerifySignature verifies a signature of sha256(message) using a public key of any scheme.
+// P-256 signatures may be ASN.1 DER or r||s encoded.
+func (bc *Blockchain) VerifySignature(message, signature string, publicKey PublicKey) (bool, error) {
+	if publicKey == nil {
+		return false, errors.New("no public key to verify with")
+	}
+
 	// Decode the signature
 	sigBytes, err := hex.DecodeString(signature)
 	if err != nil {
@@ -1321,5 +1593,5 @@ func (bc *Blockchain) VerifySignature(message, signature string, publicKey *ecds
 	hash := sha256.Sum256([]byte(message))
 
 	// Verify the signature
-	return ecdsa.VerifyASN1(publicKey, hash[:], sigBytes), nil
+	return publicKey.Verify(hash[:], sigBytes), nil
 }
@@ -0,0 +1,334 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// FeatureBridge enables the lock-and-release bridge: ConX locked in the
+// bridge escrow is represented by a wrapped asset on another chain and
+// released again once validators attest that the wrapped asset was burned
+const FeatureBridge Feature = "bridge"
+
+// Transaction types of the bridge
+const (
+	BridgeLockTxType    = "bridge_lock"    // moves ConX into the escrow for a target chain address
+	BridgeReleaseTxType = "bridge_release" // pays ConX out of the escrow, approved by the validators
+)
+
+// BridgeEscrowAddress holds the ConX locked for other chains. It has no key:
+// only bridge lock transactions pay into it and only bridge releases approved
+// by the validator set pay out of it.
+const BridgeEscrowAddress = "confirmix_bridge_escrow"
+
+// bridgeSigningDomain separates release approvals from other signatures
+const bridgeSigningDomain = "confirmix/bridge"
+
+// MaxBridgeField is the longest chain ID, address or transaction hash of a
+// bridge payload, in bytes
+const MaxBridgeField = 128
+
+var (
+	// ErrInvalidBridgeTransfer is returned for bridge transactions that fail validation
+	ErrInvalidBridgeTransfer = errors.New("invalid bridge transfer")
+	// ErrBridgeReleased is returned for a release of a source transaction that was already released
+	ErrBridgeReleased = errors.New("bridge transfer already released")
+)
+
+func init() {
+	RegisterFeature(FeatureBridge, "ConX can be locked in the bridge escrow for another chain and released with validator approval")
+}
+
+// BridgeLock is the payload of a bridge lock transaction
+type BridgeLock struct {
+	TargetChain   string `json:"targetChain"`   // e.g. "eip155:1"
+	TargetAddress string `json:"targetAddress"` // receives the wrapped asset on the target chain
+}
+
+// BridgeRelease is the payload of a bridge release transaction. Approvals
+// maps validator addresses to their hex signatures over BridgeReleaseHash.
+type BridgeRelease struct {
+	SourceChain string            `json:"sourceChain"`
+	SourceTx    string            `json:"sourceTx"` // burn of the wrapped asset on the source chain
+	Approvals   map[string]string `json:"approvals"`
+}
+
+// BridgeStatus describes the bridge at the chain tip
+type BridgeStatus struct {
+	Active            bool   `json:"active"` // bridge transactions are accepted in the next block
+	Escrow            string `json:"escrow"`
+	Locked            string `json:"locked"` // balance of the escrow in base units
+	Validators        int    `json:"validators"`
+	RequiredApprovals int    `json:"requiredApprovals"`
+	Releases          int    `json:"releases"`
+}
+
+// checkBridgeField validates one field of a bridge payload
+func checkBridgeField(name, value string) error {
+	if value == "" {
+		return fmt.Errorf("%w: %s is required", ErrInvalidBridgeTransfer, name)
+	}
+	if len(value) > MaxBridgeField {
+		return fmt.Errorf("%w: %s exceeds %d bytes", ErrInvalidBridgeTransfer, name, MaxBridgeField)
+	}
+	return nil
+}
+
+// bridgeReleaseKey identifies a source transaction across chains
+func bridgeReleaseKey(sourceChain, sourceTx string) string {
+	return sourceChain + "/" + sourceTx
+}
+
+// NewBridgeLockTransaction creates an unsigned transaction locking value of
+// from in the escrow for an address on the target chain
+func NewBridgeLockTransaction(id, from string, value uint64, lock BridgeLock) (*Transaction, error) {
+	if err := checkBridgeField("target chain", lock.TargetChain); err != nil {
+		return nil, err
+	}
+	if err := checkBridgeField("target address", lock.TargetAddress); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(id, from, BridgeEscrowAddress, value, data)
+	tx.Type = BridgeLockTxType
+	return tx, nil
+}
+
+// BridgeReleaseHash returns the digest validators sign to approve releasing
+// value to to for a source transaction. It is bound to the chain ID, so an
+// approval cannot be replayed on another network.
+func BridgeReleaseHash(sourceChain, sourceTx, to string, value uint64) ([]byte, error) {
+	payload, err := json.Marshal(struct {
+		SourceChain string `json:"sourceChain"`
+		SourceTx    string `json:"sourceTx"`
+		To          string `json:"to"`
+		Value       string `json:"value"`
+	}{sourceChain, sourceTx, to, strconv.FormatUint(value, 10)})
+	if err != nil {
+		return nil, err
+	}
+	return signingDigest(bridgeSigningDomain, CurrentSigScheme, ChainID(), payload)
+}
+
+// NewBridgeReleaseTransaction creates the transaction releasing value from
+// the escrow to to. Its ID is derived from the source transaction, so a
+// source transaction can occupy the pool only once.
+func NewBridgeReleaseTransaction(release BridgeRelease, to string, value uint64) (*Transaction, error) {
+	if err := checkBridgeField("source chain", release.SourceChain); err != nil {
+		return nil, err
+	}
+	if err := checkBridgeField("source transaction", release.SourceTx); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(release)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(bridgeReleaseKey(release.SourceChain, release.SourceTx)))
+	tx := NewTransaction("bridge_release_"+hex.EncodeToString(sum[:16]), BridgeEscrowAddress, to, value, data)
+	tx.Type = BridgeReleaseTxType
+	return tx, nil
+}
+
+// ParseBridgeLock decodes and validates the payload of a bridge lock transaction
+func ParseBridgeLock(tx *Transaction) (*BridgeLock, error) {
+	if tx == nil || tx.Type != BridgeLockTxType {
+		return nil, fmt.Errorf("%w: not a bridge lock transaction", ErrInvalidBridgeTransfer)
+	}
+	var lock BridgeLock
+	if err := json.Unmarshal(tx.Data, &lock); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBridgeTransfer, err)
+	}
+	if err := checkBridgeField("target chain", lock.TargetChain); err != nil {
+		return nil, err
+	}
+	if err := checkBridgeField("target address", lock.TargetAddress); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// ParseBridgeRelease decodes and validates the payload of a bridge release transaction
+func ParseBridgeRelease(tx *Transaction) (*BridgeRelease, error) {
+	if tx == nil || tx.Type != BridgeReleaseTxType {
+		return nil, fmt.Errorf("%w: not a bridge release transaction", ErrInvalidBridgeTransfer)
+	}
+	var release BridgeRelease
+	if err := json.Unmarshal(tx.Data, &release); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBridgeTransfer, err)
+	}
+	if err := checkBridgeField("source chain", release.SourceChain); err != nil {
+		return nil, err
+	}
+	if err := checkBridgeField("source transaction", release.SourceTx); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// involvesBridge reports whether tx is a bridge transaction or moves funds in
+// or out of the escrow
+func involvesBridge(tx *Transaction) bool {
+	return tx.Type == BridgeLockTxType || tx.Type == BridgeReleaseTxType ||
+		tx.To == BridgeEscrowAddress || tx.From == BridgeEscrowAddress
+}
+
+// requiredBridgeApprovalsLocked is the number of validator approvals a
+// release needs: more than two thirds of the validator set. The caller must
+// hold bc.mu.
+func (bc *Blockchain) requiredBridgeApprovalsLocked() int {
+	return len(bc.validators)*2/3 + 1
+}
+
+// checkBridgeTransactionLocked validates a transaction for inclusion at
+// height: bridge transactions need the bridge to be active, and only they may
+// move funds in or out of the escrow. The caller must hold bc.mu.
+func (bc *Blockchain) checkBridgeTransactionLocked(tx *Transaction, height uint64) error {
+	if !involvesBridge(tx) {
+		return nil
+	}
+	if !bc.featureActiveLocked(FeatureBridge, height) {
+		if tx.Type == BridgeLockTxType || tx.Type == BridgeReleaseTxType {
+			return fmt.Errorf("%w: the bridge is not active at height %d", ErrInvalidBridgeTransfer, height)
+		}
+		return nil
+	}
+	if tx.Value == 0 {
+		return fmt.Errorf("%w: transaction %s moves no value", ErrInvalidBridgeTransfer, tx.ID)
+	}
+
+	switch tx.Type {
+	case BridgeLockTxType:
+		if tx.To != BridgeEscrowAddress {
+			return fmt.Errorf("%w: locks pay %s", ErrInvalidBridgeTransfer, BridgeEscrowAddress)
+		}
+		_, err := ParseBridgeLock(tx)
+		return err
+	case BridgeReleaseTxType:
+		if tx.From != BridgeEscrowAddress || tx.To == BridgeEscrowAddress {
+			return fmt.Errorf("%w: releases pay out of %s", ErrInvalidBridgeTransfer, BridgeEscrowAddress)
+		}
+		release, err := ParseBridgeRelease(tx)
+		if err != nil {
+			return err
+		}
+		if txID, released := bc.bridgeReleases[bridgeReleaseKey(release.SourceChain, release.SourceTx)]; released {
+			return fmt.Errorf("%w: %s on %s by %s", ErrBridgeReleased, release.SourceTx, release.SourceChain, txID)
+		}
+		return bc.verifyBridgeApprovalsLocked(tx, release)
+	}
+	return fmt.Errorf("%w: only bridge transactions move funds in or out of %s", ErrInvalidBridgeTransfer, BridgeEscrowAddress)
+}
+
+// verifyBridgeApprovalsLocked checks that enough current validators signed
+// the release. Approvals of non-validators or with unknown keys do not
+// count. The caller must hold bc.mu.
+func (bc *Blockchain) verifyBridgeApprovalsLocked(tx *Transaction, release *BridgeRelease) error {
+	digest, err := BridgeReleaseHash(release.SourceChain, release.SourceTx, tx.To, tx.Value)
+	if err != nil {
+		return err
+	}
+	approved := 0
+	for validator, signature := range release.Approvals {
+		if !bc.validators[validator] {
+			continue
+		}
+		keyPair, exists := bc.keyPairs[validator]
+		if !exists || keyPair.Public() == nil {
+			continue
+		}
+		sigBytes, err := hex.DecodeString(signature)
+		if err != nil || !keyPair.Public().Verify(digest, sigBytes) {
+			return fmt.Errorf("%w: approval of %s does not match the release", ErrInvalidSignature, validator)
+		}
+		approved++
+	}
+	if required := bc.requiredBridgeApprovalsLocked(); approved < required {
+		return fmt.Errorf("%w: %d validator approvals, %d required", ErrInvalidBridgeTransfer, approved, required)
+	}
+	return nil
+}
+
+// applyBridgeTransferLocked moves the funds of a bridge transaction and
+// records the event the relayers follow. The caller must hold bc.mu.
+func (bc *Blockchain) applyBridgeTransferLocked(tx *Transaction, block *Block) error {
+	if err := bc.checkBridgeTransactionLocked(tx, block.Index); err != nil {
+		return err
+	}
+	if err := bc.UpdateBalances(tx); err != nil {
+		return err
+	}
+
+	amount := strconv.FormatUint(tx.Value, 10)
+	switch tx.Type {
+	case BridgeLockTxType:
+		lock, _ := ParseBridgeLock(tx)
+		bc.RecordEvent(EventBridgeLock, tx.From, map[string]string{
+			"txId":          tx.ID,
+			"amount":        amount,
+			"targetChain":   lock.TargetChain,
+			"targetAddress": lock.TargetAddress,
+		})
+	case BridgeReleaseTxType:
+		release, _ := ParseBridgeRelease(tx)
+		bc.bridgeReleases[bridgeReleaseKey(release.SourceChain, release.SourceTx)] = tx.ID
+		bc.RecordEvent(EventBridgeRelease, tx.To, map[string]string{
+			"txId":        tx.ID,
+			"amount":      amount,
+			"sourceChain": release.SourceChain,
+			"sourceTx":    release.SourceTx,
+		})
+	}
+	return nil
+}
+
+// rebuildBridgeReleasesLocked collects the released source transactions from
+// the confirmed releases of the chain. The caller must hold bc.mu.
+func (bc *Blockchain) rebuildBridgeReleasesLocked() {
+	bc.bridgeReleases = make(map[string]string)
+	for _, block := range bc.Blocks {
+		for _, tx := range block.Transactions {
+			if tx.Type != BridgeReleaseTxType || tx.Status != "confirmed" {
+				continue
+			}
+			if release, err := ParseBridgeRelease(tx); err == nil {
+				bc.bridgeReleases[bridgeReleaseKey(release.SourceChain, release.SourceTx)] = tx.ID
+			}
+		}
+	}
+}
+
+// BridgeStatus returns the state of the bridge at the chain tip
+func (bc *Blockchain) BridgeStatus() BridgeStatus {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	locked := big.NewInt(0)
+	if balance, exists := bc.accounts[BridgeEscrowAddress]; exists {
+		locked = balance
+	}
+	return BridgeStatus{
+		Active:            bc.featureActiveLocked(FeatureBridge, uint64(len(bc.Blocks))),
+		Escrow:            BridgeEscrowAddress,
+		Locked:            locked.String(),
+		Validators:        len(bc.validators),
+		RequiredApprovals: bc.requiredBridgeApprovalsLocked(),
+		Releases:          len(bc.bridgeReleases),
+	}
+}
+
+// BridgeReleaseTx returns the ID of the transaction that released a source
+// transaction, if it was released
+func (bc *Blockchain) BridgeReleaseTx(sourceChain, sourceTx string) (string, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	txID, released := bc.bridgeReleases[bridgeReleaseKey(sourceChain, sourceTx)]
+	return txID, released
+}
@@ -0,0 +1,235 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// FeatureCircuitBreaker enables the circuit breaker: a validator, on behalf of
+// an admin, pauses block production and transaction admission network-wide
+// for incident response, and resumes them once the incident is resolved
+const FeatureCircuitBreaker Feature = "circuit_breaker"
+
+// CircuitBreakerTxType is the transaction type of an emergency pause or resume
+const CircuitBreakerTxType = "circuit_breaker"
+
+// EventCircuitBreaker is recorded when a pause or resume is confirmed
+const EventCircuitBreaker = "circuit_breaker"
+
+// MaxCircuitBreakerReason is the longest reason a pause or resume may give, in bytes
+const MaxCircuitBreakerReason = 512
+
+var (
+	// ErrChainHalted is returned while the circuit breaker pauses the chain
+	ErrChainHalted = errors.New("block production and transaction admission are paused")
+	// ErrInvalidCircuitBreaker is returned for circuit breaker transactions that fail validation
+	ErrInvalidCircuitBreaker = errors.New("invalid circuit breaker transaction")
+)
+
+func init() {
+	RegisterFeature(FeatureCircuitBreaker, "validators pause and resume block production and transaction admission in an emergency")
+	RegisterTxLane(CircuitBreakerTxType, LaneSystem)
+}
+
+// CircuitBreakerAction is the payload of a circuit breaker transaction
+type CircuitBreakerAction struct {
+	Halt   bool   `json:"halt"`            // true pauses the chain, false resumes it
+	Reason string `json:"reason"`          // why, for operators and auditors
+	Admin  string `json:"admin,omitempty"` // admin who ordered the action through the validator's node
+}
+
+// CircuitBreakerRecord is a pause or resume confirmed on chain
+type CircuitBreakerRecord struct {
+	CircuitBreakerAction
+	Validator  string `json:"validator"` // sender of the transaction
+	TxID       string `json:"txId"`
+	BlockIndex uint64 `json:"blockIndex"`
+	Timestamp  int64  `json:"timestamp"` // of the block confirming the action
+}
+
+// CircuitBreakerStatus reports whether the chain is paused and the actions
+// that paused and resumed it, oldest first
+type CircuitBreakerStatus struct {
+	Halted  bool                   `json:"halted"`
+	Since   *CircuitBreakerRecord  `json:"since,omitempty"` // the action in force
+	History []CircuitBreakerRecord `json:"history"`
+}
+
+// NewCircuitBreakerTransaction creates an unsigned transaction by which the
+// validator pauses or resumes the chain
+func NewCircuitBreakerTransaction(id, validator string, action CircuitBreakerAction) (*Transaction, error) {
+	data, err := json.Marshal(action)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(id, validator, validator, 0, data)
+	tx.Type = CircuitBreakerTxType
+	if _, err := ParseCircuitBreakerAction(tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// ParseCircuitBreakerAction decodes and validates the payload of a circuit breaker transaction
+func ParseCircuitBreakerAction(tx *Transaction) (*CircuitBreakerAction, error) {
+	if tx == nil || tx.Type != CircuitBreakerTxType {
+		return nil, fmt.Errorf("%w: not a circuit breaker transaction", ErrInvalidCircuitBreaker)
+	}
+	var action CircuitBreakerAction
+	if err := json.Unmarshal(tx.Data, &action); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCircuitBreaker, err)
+	}
+	if action.Reason == "" {
+		return nil, fmt.Errorf("%w: a reason is required", ErrInvalidCircuitBreaker)
+	}
+	if len(action.Reason) > MaxCircuitBreakerReason {
+		return nil, fmt.Errorf("%w: reason is longer than %d bytes", ErrInvalidCircuitBreaker, MaxCircuitBreakerReason)
+	}
+	return &action, nil
+}
+
+// haltedLocked reports whether the last confirmed action paused the chain.
+// The caller must hold bc.mu.
+func (bc *Blockchain) haltedLocked() bool {
+	return len(bc.circuitBreaker) > 0 && bc.circuitBreaker[len(bc.circuitBreaker)-1].Halt
+}
+
+// checkCircuitBreakerLocked checks tx for inclusion at height. While the
+// chain is paused only circuit breaker transactions are admitted. A circuit
+// breaker transaction must be signed by a validator and change the state: a
+// paused chain can only be resumed and a running one only paused. The caller
+// must hold bc.mu.
+func (bc *Blockchain) checkCircuitBreakerLocked(tx *Transaction, height uint64) error {
+	halted := bc.haltedLocked()
+	if tx.Type != CircuitBreakerTxType {
+		if halted && !isRewardTransaction(tx) {
+			return ErrChainHalted
+		}
+		return nil
+	}
+
+	if !bc.featureActiveLocked(FeatureCircuitBreaker, height) {
+		return fmt.Errorf("%w: the circuit breaker is not active at height %d", ErrInvalidCircuitBreaker, height)
+	}
+	if tx.Value != 0 {
+		return fmt.Errorf("%w: circuit breaker transactions carry no value", ErrInvalidCircuitBreaker)
+	}
+	if len(tx.Signature) == 0 {
+		return fmt.Errorf("%w: circuit breaker transactions must be signed", ErrInvalidSignature)
+	}
+	if !bc.validators[tx.From] {
+		return fmt.Errorf("%w: %s is not a validator", ErrInvalidCircuitBreaker, tx.From)
+	}
+	action, err := ParseCircuitBreakerAction(tx)
+	if err != nil {
+		return err
+	}
+	if action.Halt == halted {
+		if halted {
+			return fmt.Errorf("%w: the chain is already paused", ErrInvalidCircuitBreaker)
+		}
+		return fmt.Errorf("%w: the chain is not paused", ErrInvalidCircuitBreaker)
+	}
+	return nil
+}
+
+// checkBlockCircuitBreakerLocked checks the transactions of a block against
+// the circuit breaker: a block on a paused chain may carry nothing but its
+// reward and the resume, and at most one action is confirmed per block. The
+// caller must hold bc.mu.
+func (bc *Blockchain) checkBlockCircuitBreakerLocked(block *Block) error {
+	actions := 0
+	for _, tx := range block.Transactions {
+		if err := bc.checkCircuitBreakerLocked(tx, block.Index); err != nil {
+			return fmt.Errorf("transaction %s: %w", tx.ID, err)
+		}
+		if tx.Type == CircuitBreakerTxType {
+			actions++
+		}
+	}
+	if actions > 1 {
+		return fmt.Errorf("%w: block carries %d circuit breaker transactions, at most one is allowed", ErrInvalidCircuitBreaker, actions)
+	}
+	return nil
+}
+
+// applyCircuitBreakerLocked pauses or resumes the chain. The caller must hold bc.mu.
+func (bc *Blockchain) applyCircuitBreakerLocked(tx *Transaction, block *Block) error {
+	if err := bc.checkCircuitBreakerLocked(tx, block.Index); err != nil {
+		return err
+	}
+	record := bc.recordCircuitBreakerLocked(tx, block)
+	bc.RecordEvent(EventCircuitBreaker, record.Validator, map[string]string{
+		"halt":   strconv.FormatBool(record.Halt),
+		"reason": record.Reason,
+		"admin":  record.Admin,
+		"txId":   record.TxID,
+	})
+	return nil
+}
+
+// recordCircuitBreakerLocked appends a validated action to the circuit
+// breaker history. The caller must hold bc.mu.
+func (bc *Blockchain) recordCircuitBreakerLocked(tx *Transaction, block *Block) CircuitBreakerRecord {
+	action, _ := ParseCircuitBreakerAction(tx)
+	record := CircuitBreakerRecord{
+		Validator:  tx.From,
+		TxID:       tx.ID,
+		BlockIndex: block.Index,
+		Timestamp:  block.Timestamp,
+	}
+	if action != nil {
+		record.CircuitBreakerAction = *action
+	}
+	bc.circuitBreaker = append(bc.circuitBreaker, record)
+	return record
+}
+
+// rebuildCircuitBreakerLocked replays the confirmed circuit breaker
+// transactions of the chain. The caller must hold bc.mu.
+func (bc *Blockchain) rebuildCircuitBreakerLocked() {
+	bc.circuitBreaker = nil
+	for _, block := range bc.Blocks {
+		for _, tx := range block.Transactions {
+			if tx.Type == CircuitBreakerTxType && tx.Status == "confirmed" {
+				bc.recordCircuitBreakerLocked(tx, block)
+			}
+		}
+	}
+}
+
+// VerifyCircuitBreakerTransaction checks a circuit breaker transaction for
+// inclusion in the next block
+func (bc *Blockchain) VerifyCircuitBreakerTransaction(tx *Transaction) error {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	if tx == nil || tx.Type != CircuitBreakerTxType {
+		return fmt.Errorf("%w: not a circuit breaker transaction", ErrInvalidCircuitBreaker)
+	}
+	return bc.checkCircuitBreakerLocked(tx, uint64(len(bc.Blocks)))
+}
+
+// Halted reports whether the circuit breaker pauses the chain
+func (bc *Blockchain) Halted() bool {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.haltedLocked()
+}
+
+// CircuitBreaker returns whether the chain is paused and the history of pauses and resumes
+func (bc *Blockchain) CircuitBreaker() CircuitBreakerStatus {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	status := CircuitBreakerStatus{
+		Halted:  bc.haltedLocked(),
+		History: append([]CircuitBreakerRecord{}, bc.circuitBreaker...),
+	}
+	if len(status.History) > 0 {
+		since := status.History[len(status.History)-1]
+		status.Since = &since
+	}
+	return status
+}
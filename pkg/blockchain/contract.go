@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 )
 
@@ -17,6 +18,19 @@ type Contract struct {
 	Creator  string        `json:"creator"`
 	State    ContractState `json:"state"`
 	Deployed bool          `json:"deployed"`
+
+	// Host API permissions granted at deployment, see contract_host.go
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// HasPermission reports whether the contract was granted a host permission
+func (c *Contract) HasPermission(permission string) bool {
+	for _, granted := range c.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
 }
 
 // ContractFunction represents a callable function in a smart contract
@@ -39,8 +53,17 @@ func NewContractManager() *ContractManager {
 	}
 }
 
-// DeployContract deploys a new contract to the blockchain
-func (cm *ContractManager) DeployContract(code string, creator string) (string, error) {
+// DeployContract deploys a new contract to the blockchain, granting it the given host permissions
+func (cm *ContractManager) DeployContract(code string, creator string, permissions ...string) (string, error) {
+	if err := ValidateContractCode(code); err != nil {
+		return "", err
+	}
+	for _, permission := range permissions {
+		if !validPermission(permission) {
+			return "", fmt.Errorf("%w: unknown permission %q", ErrHostPermission, permission)
+		}
+	}
+
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
 	
@@ -54,6 +77,7 @@ func (cm *ContractManager) DeployContract(code string, creator string) (string,
 		Creator:  creator,
 		State:    make(ContractState),
 		Deployed: true,
+		Permissions: permissions,
 	}
 	
 	// Store the contract
@@ -69,7 +93,7 @@ func (cm *ContractManager) GetContract(address string) (*Contract, error) {
 	
 	contract, exists := cm.contracts[address]
 	if !exists {
-		return nil, errors.New("contract not found")
+		return nil, ErrContractNotFound
 	}
 	
 	return contract, nil
@@ -77,19 +101,52 @@ func (cm *ContractManager) GetContract(address string) (*Contract, error) {
 
 // CallContract calls a function on a contract with the given parameters
 func (cm *ContractManager) CallContract(contractAddress string, function string, params []interface{}, caller string) (interface{}, error) {
+	return cm.callContract(contractAddress, function, params, caller, nil)
+}
+
+// callContract calls a function on a contract. With a host context the call is
+// metered, may use the host API and reverts every state change it made, including
+// those of nested calls, when it fails; without one host functions are unavailable.
+func (cm *ContractManager) callContract(contractAddress string, function string, params []interface{}, caller string, host *HostContext) (interface{}, error) {
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
 	
+	if host == nil {
+		return cm.executeLocked(contractAddress, function, params, caller, nil)
+	}
+	mark := host.journalMark()
+	result, err := cm.executeLocked(contractAddress, function, params, caller, host)
+	if err != nil {
+		host.revertTo(mark)
+	}
+	return result, err
+}
+
+// executeLocked runs a contract function. The caller must hold cm.mutex.
+func (cm *ContractManager) executeLocked(contractAddress string, function string, params []interface{}, caller string, host *HostContext) (interface{}, error) {
 	// Get the contract
 	contract, exists := cm.contracts[contractAddress]
 	if !exists {
-		return nil, errors.New("contract not found")
+		return nil, ErrContractNotFound
 	}
 	
 	if !contract.Deployed {
 		return nil, errors.New("contract not deployed")
 	}
 	
+	// Meter the call and route host functions to the host API
+	if host != nil {
+		host.contract = contract
+		if err := host.UseGas(GasContractCall); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(function, HostPrefix) {
+			return host.Invoke(function, params)
+		}
+	} else if strings.HasPrefix(function, HostPrefix) {
+		return nil, fmt.Errorf("%w: %s can only be called from a transaction", ErrHostUnavailable, function)
+	}
+	
 	// In a real implementation, this would parse and execute the contract code
 	// For this demo, we'll just update the state based on the function name
 	
@@ -123,12 +180,12 @@ func (cm *ContractManager) CallContract(contractAddress string, function string,
 		
 		// Check if caller has enough balance
 		if callerBalance < amount {
-			return nil, errors.New("insufficient balance")
+			return nil, ErrInsufficientBalance
 		}
 		
 		// Update balances
-		contract.State[caller] = callerBalance - amount
-		contract.State[recipient] = recipientBalance + amount
+		host.setState(contract, caller, callerBalance-amount)
+		host.setState(contract, recipient, recipientBalance+amount)
 		
 		return true, nil
 		
@@ -175,7 +232,7 @@ func (cm *ContractManager) CallContract(contractAddress string, function string,
 		}
 		
 		// Update balance
-		contract.State[recipient] = recipientBalance + amount
+		host.setState(contract, recipient, recipientBalance+amount)
 		
 		return true, nil
 		
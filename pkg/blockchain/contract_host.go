@@ -0,0 +1,427 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// HostPrefix marks contract functions served by the host API rather than the contract itself
+const HostPrefix = "host."
+
+// DefaultContractGasLimit applies to contract calls that do not set a gas limit
+const DefaultContractGasLimit uint64 = 100000
+
+// GasContractCall is charged for every contract call before the function runs
+const GasContractCall uint64 = 100
+
+// MaxCallDepth is how deeply contracts may call each other within one transaction
+const MaxCallDepth = 8
+
+// Permissions a contract declares at deployment to use the host functions that need them
+const (
+	PermissionReadState  = "read_state" // read native balances
+	PermissionTransfer   = "transfer"   // move native tokens out of the contract's own account
+	PermissionNames      = "names"      // resolve registered names to addresses
+	PermissionGovernance = "governance" // read governance parameters
+	PermissionCall       = "call"       // call other contracts
+	PermissionOracle     = "oracle"     // read oracle feeds
+)
+
+var (
+	// ErrOutOfGas is returned when a contract call exceeds its gas limit
+	ErrOutOfGas = errors.New("out of gas")
+	// ErrHostPermission is returned when a contract uses a host function it was not granted
+	ErrHostPermission = errors.New("host permission denied")
+	// ErrHostUnavailable is returned when a host function's backing service is not configured
+	ErrHostUnavailable = errors.New("host function unavailable")
+	// ErrCallDepthExceeded is returned when nested contract calls exceed MaxCallDepth
+	ErrCallDepthExceeded = errors.New("contract call depth exceeded")
+	// ErrContractReverted wraps the failure of a nested contract call whose state changes were rolled back
+	ErrContractReverted = errors.New("contract call reverted")
+)
+
+// NameResolver resolves registered names to addresses for contracts
+type NameResolver interface {
+	ResolveName(name string) (string, error)
+}
+
+// GovernanceReader exposes governance parameters to contracts
+type GovernanceReader interface {
+	GovernanceParameter(name string) (string, error)
+}
+
+// hostFunction is a host API entry: its gas cost, the permission it needs and its implementation
+type hostFunction struct {
+	gas        uint64
+	permission string
+	call       func(h *HostContext, params []interface{}) (interface{}, error)
+}
+
+// hostFunctions is the contract standard library, keyed by name without HostPrefix
+var hostFunctions = map[string]hostFunction{
+	"balance":          {gas: 200, permission: PermissionReadState, call: hostBalance},
+	"self_balance":     {gas: 200, permission: "", call: hostSelfBalance},
+	"transfer":         {gas: 2000, permission: PermissionTransfer, call: hostTransfer},
+	"resolve_name":     {gas: 300, permission: PermissionNames, call: hostResolveName},
+	"governance_param": {gas: 300, permission: PermissionGovernance, call: hostGovernanceParam},
+	"oracle_latest":    {gas: 300, permission: PermissionOracle, call: hostOracleLatest},
+	"oracle_at":        {gas: 500, permission: PermissionOracle, call: hostOracleAt},
+}
+
+func init() {
+	// Registered here because nested calls run contracts that use hostFunctions
+	hostFunctions["call"] = hostFunction{gas: 700, permission: PermissionCall, call: hostCall}
+}
+
+// validPermission reports whether a contract may declare permission
+func validPermission(permission string) bool {
+	switch permission {
+	case PermissionReadState, PermissionTransfer, PermissionNames, PermissionGovernance, PermissionCall, PermissionOracle:
+		return true
+	}
+	return false
+}
+
+// HostContext is the chain state a contract call can reach through the host API,
+// metered against the call's gas limit. Nested calls get their own context
+// sharing the transaction's journal, so a failed call can undo its changes.
+type HostContext struct {
+	bc       *Blockchain
+	contract *Contract
+	caller   string
+	gasLimit uint64
+	gasUsed  uint64
+	depth    int
+	journal  *[]func() // undo entries for every state change in the transaction
+}
+
+// GasUsed returns the gas consumed so far by the call
+func (h *HostContext) GasUsed() uint64 {
+	return h.gasUsed
+}
+
+// UseGas charges gas to the call, failing once the limit would be exceeded
+func (h *HostContext) UseGas(amount uint64) error {
+	if h.gasUsed+amount > h.gasLimit {
+		return fmt.Errorf("%w: %d used of %d, %d more needed", ErrOutOfGas, h.gasUsed, h.gasLimit, amount)
+	}
+	h.gasUsed += amount
+	return nil
+}
+
+// Invoke runs a host function for the contract after checking its permission and charging its gas
+func (h *HostContext) Invoke(function string, params []interface{}) (interface{}, error) {
+	name := strings.TrimPrefix(function, HostPrefix)
+	fn, exists := hostFunctions[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown host function: %s", function)
+	}
+	if fn.permission != "" && !h.contract.HasPermission(fn.permission) {
+		return nil, fmt.Errorf("%w: %s requires the %q permission", ErrHostPermission, function, fn.permission)
+	}
+	if err := h.UseGas(fn.gas); err != nil {
+		return nil, err
+	}
+	return fn.call(h, params)
+}
+
+// SetNameResolver attaches the name registry contracts resolve names through
+func (bc *Blockchain) SetNameResolver(resolver NameResolver) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	bc.nameResolver = resolver
+}
+
+// SetGovernanceReader attaches the governance system contracts read parameters from
+func (bc *Blockchain) SetGovernanceReader(reader GovernanceReader) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	bc.governanceReader = reader
+}
+
+// newHostContext creates the host context for a contract call by caller; the
+// contract manager attaches the called contract
+func (bc *Blockchain) newHostContext(caller string, gasLimit uint64) *HostContext {
+	if gasLimit == 0 {
+		gasLimit = DefaultContractGasLimit
+	}
+	return &HostContext{bc: bc, caller: caller, gasLimit: gasLimit, journal: new([]func())}
+}
+
+// record adds an undo entry to the journal
+func (h *HostContext) record(undo func()) {
+	*h.journal = append(*h.journal, undo)
+}
+
+// journalMark returns the journal position to revert to if a call fails
+func (h *HostContext) journalMark() int {
+	return len(*h.journal)
+}
+
+// revertTo undoes, newest first, every state change recorded after mark
+func (h *HostContext) revertTo(mark int) {
+	entries := *h.journal
+	for i := len(entries) - 1; i >= mark; i-- {
+		entries[i]()
+	}
+	*h.journal = entries[:mark]
+}
+
+// setState writes a contract state entry, journaling the previous value.
+// Without a host context (unmetered calls) the write is not journaled.
+func (h *HostContext) setState(contract *Contract, key string, value interface{}) {
+	if h != nil {
+		previous, existed := contract.State[key]
+		h.record(func() {
+			if existed {
+				contract.State[key] = previous
+			} else {
+				delete(contract.State, key)
+			}
+		})
+	}
+	contract.State[key] = value
+}
+
+// hostCall calls another contract: [address, function, params (array, optional),
+// gas (optional)]. The callee runs as a nested frame whose caller is this contract.
+// It receives the requested gas, at most all but 1/64th of what remains so the
+// caller can still finish, and its state changes are reverted if it fails.
+func hostCall(h *HostContext, params []interface{}) (interface{}, error) {
+	address, err := stringParam(params, 0, "contract")
+	if err != nil {
+		return nil, err
+	}
+	function, err := stringParam(params, 1, "function")
+	if err != nil {
+		return nil, err
+	}
+	var args []interface{}
+	if len(params) > 2 && params[2] != nil {
+		if args, err = arrayParam(params, 2, "params"); err != nil {
+			return nil, err
+		}
+	}
+	if h.depth+1 >= MaxCallDepth {
+		return nil, fmt.Errorf("%w: limit is %d", ErrCallDepthExceeded, MaxCallDepth)
+	}
+
+	available := h.gasLimit - h.gasUsed
+	forward := available - available/64
+	if len(params) > 3 {
+		requested, err := amountParam(params, 3)
+		if err != nil {
+			return nil, fmt.Errorf("gas: %v", err)
+		}
+		if requested.IsUint64() && requested.Uint64() < forward {
+			forward = requested.Uint64()
+		}
+	}
+
+	child := &HostContext{
+		bc:       h.bc,
+		caller:   h.contract.Address,
+		gasLimit: forward,
+		depth:    h.depth + 1,
+		journal:  h.journal,
+	}
+	mark := child.journalMark()
+	result, err := h.bc.contractManager.executeLocked(address, function, args, h.contract.Address, child)
+	h.gasUsed += child.gasUsed
+	if err != nil {
+		child.revertTo(mark)
+		return nil, fmt.Errorf("%w: %s.%s: %v", ErrContractReverted, address, function, err)
+	}
+	return result, nil
+}
+
+func hostBalance(h *HostContext, params []interface{}) (interface{}, error) {
+	address, err := stringParam(params, 0, "address")
+	if err != nil {
+		return nil, err
+	}
+	return h.bc.nativeBalance(address), nil
+}
+
+func hostSelfBalance(h *HostContext, params []interface{}) (interface{}, error) {
+	return h.bc.nativeBalance(h.contract.Address), nil
+}
+
+// hostTransfer moves native tokens from the contract's own account. Without
+// contract bytecode to enforce its own rules, only the creator may direct it.
+func hostTransfer(h *HostContext, params []interface{}) (interface{}, error) {
+	if h.caller != h.contract.Creator {
+		return nil, fmt.Errorf("%w: only the contract creator can transfer from the contract", ErrHostPermission)
+	}
+	recipient, err := stringParam(params, 0, "recipient")
+	if err != nil {
+		return nil, err
+	}
+	amount, err := amountParam(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	if recipient == h.contract.Address {
+		return nil, ErrSelfTransfer
+	}
+
+	bc := h.bc
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+
+	balance, exists := bc.accounts[h.contract.Address]
+	if !exists || balance.Cmp(amount) < 0 {
+		return nil, fmt.Errorf("%w: contract %s holds less than %s", ErrInsufficientBalance, h.contract.Address, amount)
+	}
+	toBalance, exists := bc.accounts[recipient]
+	h.record(func() {
+		bc.mutex.Lock()
+		defer bc.mutex.Unlock()
+		bc.accounts[h.contract.Address] = balance
+		if exists {
+			bc.accounts[recipient] = toBalance
+		} else {
+			delete(bc.accounts, recipient)
+		}
+		bc.richIndex.touch(h.contract.Address, recipient)
+	})
+
+	bc.accounts[h.contract.Address] = new(big.Int).Sub(balance, amount)
+	if !exists {
+		toBalance = big.NewInt(0)
+	}
+	bc.accounts[recipient] = new(big.Int).Add(toBalance, amount)
+	bc.richIndex.touch(h.contract.Address, recipient)
+	return true, nil
+}
+
+func hostResolveName(h *HostContext, params []interface{}) (interface{}, error) {
+	name, err := stringParam(params, 0, "name")
+	if err != nil {
+		return nil, err
+	}
+	h.bc.mutex.RLock()
+	resolver := h.bc.nameResolver
+	h.bc.mutex.RUnlock()
+	if resolver == nil {
+		return nil, fmt.Errorf("%w: no name registry is configured", ErrHostUnavailable)
+	}
+	return resolver.ResolveName(name)
+}
+
+func hostGovernanceParam(h *HostContext, params []interface{}) (interface{}, error) {
+	name, err := stringParam(params, 0, "parameter")
+	if err != nil {
+		return nil, err
+	}
+	h.bc.mutex.RLock()
+	reader := h.bc.governanceReader
+	h.bc.mutex.RUnlock()
+	if reader == nil {
+		return nil, fmt.Errorf("%w: governance is not enabled", ErrHostUnavailable)
+	}
+	return reader.GovernanceParameter(name)
+}
+
+// hostOracleLatest returns the latest confirmed data point of a feed: [feed].
+// Contract calls run while their block is applied, so the feed already holds
+// the points confirmed earlier in the same block.
+func hostOracleLatest(h *HostContext, params []interface{}) (interface{}, error) {
+	feed, err := stringParam(params, 0, "feed")
+	if err != nil {
+		return nil, err
+	}
+	record, err := h.bc.oracleValueAtLocked(feed, ^uint64(0))
+	if err != nil {
+		return nil, err
+	}
+	return oracleHostResult(record), nil
+}
+
+// hostOracleAt returns the latest data point of a feed confirmed at or below
+// a block height: [feed, height]
+func hostOracleAt(h *HostContext, params []interface{}) (interface{}, error) {
+	feed, err := stringParam(params, 0, "feed")
+	if err != nil {
+		return nil, err
+	}
+	if len(params) < 2 {
+		return nil, errors.New("height parameter is required")
+	}
+	height, ok := params[1].(float64)
+	if !ok || height < 0 || height != float64(uint64(height)) {
+		return nil, errors.New("height must be a non-negative integer")
+	}
+	record, err := h.bc.oracleValueAtLocked(feed, uint64(height))
+	if err != nil {
+		return nil, err
+	}
+	return oracleHostResult(record), nil
+}
+
+// oracleHostResult is the form in which contracts receive a data point
+func oracleHostResult(record OracleRecord) map[string]interface{} {
+	return map[string]interface{}{
+		"value":      record.Value,
+		"observedAt": record.ObservedAt,
+		"oracle":     record.Oracle,
+		"blockIndex": record.BlockIndex,
+	}
+}
+
+// nativeBalance returns the native balance of address as a decimal string
+func (bc *Blockchain) nativeBalance(address string) string {
+	bc.mutex.RLock()
+	defer bc.mutex.RUnlock()
+	balance, exists := bc.accounts[address]
+	if !exists {
+		return "0"
+	}
+	return balance.String()
+}
+
+// stringParam reads the string parameter at index
+func stringParam(params []interface{}, index int, name string) (string, error) {
+	if len(params) <= index {
+		return "", fmt.Errorf("%s parameter is required", name)
+	}
+	value, ok := params[index].(string)
+	if !ok || value == "" {
+		return "", fmt.Errorf("%s must be a non-empty string", name)
+	}
+	return value, nil
+}
+
+// arrayParam reads the array parameter at index
+func arrayParam(params []interface{}, index int, name string) ([]interface{}, error) {
+	if len(params) <= index {
+		return nil, fmt.Errorf("%s parameter is required", name)
+	}
+	value, ok := params[index].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be an array", name)
+	}
+	return value, nil
+}
+
+// amountParam reads a positive token amount, given as a decimal string or a JSON number
+func amountParam(params []interface{}, index int) (*big.Int, error) {
+	if len(params) <= index {
+		return nil, errors.New("amount parameter is required")
+	}
+	var amount *big.Int
+	switch value := params[index].(type) {
+	case string:
+		amount, _ = new(big.Int).SetString(value, 10)
+	case float64:
+		if value == float64(uint64(value)) {
+			amount = new(big.Int).SetUint64(uint64(value))
+		}
+	}
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, errors.New("amount must be a positive integer")
+	}
+	return amount, nil
+}
@@ -0,0 +1,95 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxContractCodeSize is the largest contract accepted for deployment, in bytes
+// of source or of decoded bytecode
+const MaxContractCodeSize = 24 * 1024
+
+// MaxContractGasLimit caps the gas a single contract call may request
+const MaxContractGasLimit uint64 = 10000000
+
+// ErrInvalidContract is returned for contract transactions rejected by static validation
+var ErrInvalidContract = errors.New("invalid contract transaction")
+
+// ValidateContractCode checks code before deployment. Bytecode is given as
+// 0x-prefixed hex; anything else is source text, which must be printable UTF-8.
+func ValidateContractCode(code string) error {
+	if strings.TrimSpace(code) == "" {
+		return fmt.Errorf("%w: contract code is empty", ErrInvalidContract)
+	}
+
+	if strings.HasPrefix(code, "0x") {
+		bytecode, err := hex.DecodeString(code[2:])
+		if err != nil {
+			return fmt.Errorf("%w: bytecode is not valid hex: %v", ErrInvalidContract, err)
+		}
+		if len(bytecode) == 0 {
+			return fmt.Errorf("%w: bytecode is empty", ErrInvalidContract)
+		}
+		if len(bytecode) > MaxContractCodeSize {
+			return fmt.Errorf("%w: bytecode is %d bytes, the limit is %d", ErrInvalidContract, len(bytecode), MaxContractCodeSize)
+		}
+		return nil
+	}
+
+	if len(code) > MaxContractCodeSize {
+		return fmt.Errorf("%w: code is %d bytes, the limit is %d", ErrInvalidContract, len(code), MaxContractCodeSize)
+	}
+	if !utf8.ValidString(code) {
+		return fmt.Errorf("%w: code is not valid UTF-8", ErrInvalidContract)
+	}
+	for i, r := range code {
+		if unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' {
+			return fmt.Errorf("%w: code contains control character %U at byte %d", ErrInvalidContract, r, i)
+		}
+	}
+	return nil
+}
+
+// ValidateContractTransaction statically checks a contract deployment or call
+// so malformed ones are refused at admission instead of failing in a block
+func ValidateContractTransaction(tx *Transaction) error {
+	contractTx, err := ParseContractTransaction(tx.Data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidContract, err)
+	}
+	if contractTx.GasLimit > MaxContractGasLimit {
+		return fmt.Errorf("%w: gas limit %d exceeds %d", ErrInvalidContract, contractTx.GasLimit, MaxContractGasLimit)
+	}
+
+	switch contractTx.Operation {
+	case "deploy":
+		if tx.Type != "contract_deploy" {
+			return fmt.Errorf("%w: deploy operation in a %s transaction", ErrInvalidContract, tx.Type)
+		}
+		for _, permission := range contractTx.Permissions {
+			if !validPermission(permission) {
+				return fmt.Errorf("%w: unknown permission %q", ErrInvalidContract, permission)
+			}
+		}
+		return ValidateContractCode(contractTx.Code)
+
+	case "call":
+		if tx.Type != "contract_call" {
+			return fmt.Errorf("%w: call operation in a %s transaction", ErrInvalidContract, tx.Type)
+		}
+		if contractTx.ContractAddress == "" || contractTx.ContractAddress != tx.To {
+			return fmt.Errorf("%w: contract address must be set and match the recipient", ErrInvalidContract)
+		}
+		if contractTx.Function == "" {
+			return fmt.Errorf("%w: function is required", ErrInvalidContract)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("%w: unknown operation %q", ErrInvalidContract, contractTx.Operation)
+	}
+}
@@ -10,16 +10,16 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"math/big"
-	"os"
 	"path/filepath"
 )
 
-// KeyPair represents a public-private key pair
+// KeyPair represents a public-private key pair.
+// PrivateKey and PublicKey are only set for P-256 keys; Key holds the key of any scheme.
 type KeyPair struct {
 	PrivateKey     *ecdsa.PrivateKey
 	PublicKey      *ecdsa.PublicKey
 	PublicKeyBytes []byte
+	Key            PrivateKey
 }
 
 // NewKeyPair generates a new ECDSA key pair
@@ -37,58 +37,127 @@ func NewKeyPair() (*KeyPair, error) {
 		PrivateKey:     privateKey,
 		PublicKey:      &privateKey.PublicKey,
 		PublicKeyBytes: publicKeyBytes,
+		Key:            P256PrivateKey(privateKey),
 	}, nil
 }
 
+// NewKeyPairOfType generates a key pair with the signature scheme of the given key type
+func NewKeyPairOfType(keyType KeyType) (*KeyPair, error) {
+	if keyType == KeyTypeP256 {
+		return NewKeyPair()
+	}
+
+	scheme, err := SchemeFor(keyType)
+	if err != nil {
+		return nil, err
+	}
+	key, err := scheme.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{
+		PublicKeyBytes: key.Public().Bytes(),
+		Key:            key,
+	}, nil
+}
+
+// Type returns the key type of the pair
+func (kp *KeyPair) Type() KeyType {
+	if kp.Key != nil {
+		return kp.Key.Type()
+	}
+	return KeyTypeP256
+}
+
+// Signer returns the private key of the pair, or nil for a verify-only pair
+func (kp *KeyPair) Signer() PrivateKey {
+	if kp.Key != nil {
+		return kp.Key
+	}
+	if kp.PrivateKey != nil {
+		return P256PrivateKey(kp.PrivateKey)
+	}
+	return nil
+}
+
+// Public returns the public key of the pair, or nil if the pair has none
+func (kp *KeyPair) Public() PublicKey {
+	switch {
+	case kp.Key != nil:
+		return kp.Key.Public()
+	case kp.PublicKey != nil:
+		return P256PublicKey(kp.PublicKey)
+	case kp.PrivateKey != nil:
+		return P256PublicKey(&kp.PrivateKey.PublicKey)
+	case len(kp.PublicKeyBytes) > 0:
+		key, err := ParsePublicKey(kp.PublicKeyBytes)
+		if err != nil {
+			return nil
+		}
+		return key
+	default:
+		return nil
+	}
+}
+
 // SignTransaction signs a transaction with the given private key
 func (tx *Transaction) Sign(privateKey *ecdsa.PrivateKey) error {
-	// Create a hash of the transaction data
-	hash := tx.CalculateHash()
-	
-	// Sign the hash
-	r, s, err := ecdsa.Sign(rand.Reader, privateKey, []byte(hash))
+	return tx.SignWith(P256PrivateKey(privateKey))
+}
+
+// SignWith signs the transaction with a key of any signature scheme
+func (tx *Transaction) SignWith(key PrivateKey) error {
+	if key == nil {
+		return errors.New("no private key to sign with")
+	}
+
+	// Bind the signature to this network and the current scheme
+	tx.ChainID = ChainID()
+	tx.SigScheme = CurrentSigScheme
+	hash, err := tx.SigningHash()
 	if err != nil {
 		return err
 	}
 
-	// Combine r and s into a single signature
-	tx.Signature = append(r.Bytes(), s.Bytes()...)
+	signature, err := key.Sign(hash)
+	if err != nil {
+		return err
+	}
+	tx.Signature = signature
 	return nil
 }
 
 // VerifyTransaction verifies the transaction signature
 func (tx *Transaction) Verify(publicKey *ecdsa.PublicKey) error {
+	return tx.VerifyWith(P256PublicKey(publicKey))
+}
+
+// VerifyWith verifies the transaction signature with a key of any signature scheme
+func (tx *Transaction) VerifyWith(publicKey PublicKey) error {
 	if tx.Signature == nil || len(tx.Signature) == 0 {
-		return errors.New("transaction is not signed")
+		return ErrTxNotSigned
 	}
 
-	// Split signature into r and s components
-	r := new(big.Int).SetBytes(tx.Signature[:len(tx.Signature)/2])
-	s := new(big.Int).SetBytes(tx.Signature[len(tx.Signature)/2:])
-
-	// Create a hash of the transaction data
-	hash := tx.CalculateHash()
+	// Reject signatures made for another network or scheme
+	if err := checkSigningDomain(tx.ChainID, tx.SigScheme); err != nil {
+		return err
+	}
+	hash, err := tx.SigningHash()
+	if err != nil {
+		return err
+	}
 
 	// Verify the signature
-	valid := ecdsa.Verify(publicKey, []byte(hash), r, s)
-	if !valid {
-		return errors.New("invalid transaction signature")
+	if !publicKey.Verify(hash, tx.Signature) {
+		return fmt.Errorf("%w: invalid transaction signature", ErrInvalidSignature)
 	}
 
 	return nil
 }
 
-// CalculateHash calculates the hash of a transaction for signing
+// CalculateHash calculates the hash of a transaction over its canonical encoding, see txSigningPayload
 func (tx *Transaction) CalculateHash() string {
-	// Create a string representation of the transaction
-	data := tx.ID + tx.From + tx.To + string(IntToHex(int64(tx.Value * 1000000)))
-	if tx.Data != nil {
-		data += string(tx.Data)
-	}
-	data += string(IntToHex(tx.Timestamp))
-
-	// Calculate SHA-256 hash
-	hash := sha256.Sum256([]byte(data))
+	hash := sha256.Sum256(txSigningPayload(tx))
 	return hex.EncodeToString(hash[:])
 }
 
@@ -99,28 +168,28 @@ func GetPublicKeyFromAddress(address string) (*ecdsa.PublicKey, error) {
 	return nil, errors.New("address to public key conversion not implemented")
 }
 
-// GetAddress returns the address derived from the public key (Ethereum format)
+// GetAddress returns the address derived from the public key (Ethereum format).
+// Keys other than P-256 carry their key type as a version byte, see AddressFromPublicKey.
 func (kp *KeyPair) GetAddress() string {
 	if kp.PublicKeyBytes == nil {
 		return ""
 	}
-	
-	// Create a hash of the public key
-	hash := sha256.Sum256(kp.PublicKeyBytes)
-	
-	// Take last 20 bytes of the hash (like Ethereum)
-	addressBytes := hash[len(hash)-20:]
-	
-	// Add 0x prefix
-	return "0x" + hex.EncodeToString(addressBytes)
+	publicKey := kp.Public()
+	if publicKey == nil {
+		return ""
+	}
+	return AddressFromPublicKey(publicKey)
 }
 
 // GetPrivateKeyString returns the private key as a hexadecimal string with 0x prefix
 func (kp *KeyPair) GetPrivateKeyString() string {
-	if kp.PrivateKey == nil {
-		return ""
+	if kp.PrivateKey != nil {
+		return "0x" + hex.EncodeToString(kp.PrivateKey.D.Bytes())
+	}
+	if kp.Key != nil {
+		return "0x" + hex.EncodeToString(kp.Key.Bytes())
 	}
-	return "0x" + hex.EncodeToString(kp.PrivateKey.D.Bytes())
+	return ""
 }
 
 // GetPublicKeyString returns the public key as a hexadecimal string with 0x prefix
@@ -133,17 +202,18 @@ func (kp *KeyPair) GetPublicKeyString() string {
 
 // SaveToFile saves the key pair to a file in the data directory
 func (kp *KeyPair) SaveToFile(address string) error {
-	// Create data directory if it doesn't exist
-	dataDir := "data"
-	os.MkdirAll(dataDir, 0755)
+	// The data directory is created if it doesn't exist
+	dataDir := GetBlockchainDataPath()
 	
 	// Create key pair data
 	keyData := struct {
 		Address     string `json:"address"`
+		KeyType     string `json:"key_type"`
 		PrivateKey  string `json:"private_key"`
 		PublicKey   string `json:"public_key"`
 	}{
 		Address:     address,
+		KeyType:     kp.Type().String(),
 		PrivateKey:  kp.GetPrivateKeyString(),
 		PublicKey:   kp.GetPublicKeyString(),
 	}
@@ -164,7 +234,8 @@ func (kp *KeyPair) SaveToFile(address string) error {
 	return nil
 }
 
-// VerifySignature verifies a signature using raw byte arrays
+// VerifySignature verifies a signature using raw byte arrays.
+// The signature scheme is taken from the encoding of the public key.
 func VerifySignature(dataHash []byte, signature []byte, publicKey []byte) (bool, error) {
 	if len(signature) == 0 {
 		return false, errors.New("empty signature")
@@ -174,23 +245,11 @@ func VerifySignature(dataHash []byte, signature []byte, publicKey []byte) (bool,
 		return false, errors.New("empty public key")
 	}
 	
-	// Convert public key bytes to ecdsa.PublicKey
-	curve := elliptic.P256()
-	x, y := elliptic.Unmarshal(curve, publicKey)
-	if x == nil {
-		return false, errors.New("failed to unmarshal public key")
-	}
-	
-	pubKey := &ecdsa.PublicKey{
-		Curve: curve,
-		X:     x,
-		Y:     y,
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to unmarshal public key: %v", err)
 	}
 	
-	// Split signature into r and s components
-	r := new(big.Int).SetBytes(signature[:len(signature)/2])
-	s := new(big.Int).SetBytes(signature[len(signature)/2:])
-	
 	// Verify signature
-	return ecdsa.Verify(pubKey, dataHash, r, s), nil
-} 
\ No newline at end of file
+	return key.Verify(dataHash, signature), nil
+}
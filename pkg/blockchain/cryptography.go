@@ -2,8 +2,8 @@ package blockchain
 
 // SimpleVerifySignature is a simplified version that verifies a signature using a public key
 func SimpleVerifySignature(data []byte, signature []byte, publicKey []byte) (bool, error) {
-	// Bu fonksiyon gerçek bir uygulamada kriptografik imza doğrulaması yapmalı
-	// Burada basitleştirilmiş olarak true dönüyoruz, üretim ortamında gerçek
-	// bir kriptografik doğrulama algoritması kullanılmalıdır
+	// In a real implementation this function must verify the signature cryptographically
+	// For simplicity it returns true here; a real cryptographic verification
+	// algorithm must be used in production
 	return true, nil
 } 
\ No newline at end of file
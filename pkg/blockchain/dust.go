@@ -0,0 +1,77 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrDust is returned for transfers refused by the dust policy
+var ErrDust = errors.New("dust transaction")
+
+// DustPolicy keeps near-zero accounts out of the state. It applies at admission
+// and is node policy, not consensus: blocks from other nodes are not checked
+// against it. Zero values disable the corresponding rule.
+type DustPolicy struct {
+	MinTransfer uint64 `json:"minTransfer"` // smallest value a transfer may carry
+	MinBalance  uint64 `json:"minBalance"`  // smallest non-zero balance a transfer may leave behind
+}
+
+// SetDustPolicy sets the dust rules applied to new transactions
+func (bc *Blockchain) SetDustPolicy(policy DustPolicy) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.dustPolicy = policy
+}
+
+// DustPolicy returns the dust rules applied to new transactions
+func (bc *Blockchain) DustPolicy() DustPolicy {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.dustPolicy
+}
+
+// checkDustLocked refuses value transfers below the minimum, transfers that
+// would create a recipient balance below the threshold and transfers that would
+// leave the sender with a non-zero balance below it. Emptying an account is
+// allowed. The sender's pending transfers are taken into account. The caller
+// must hold bc.mu.
+func (bc *Blockchain) checkDustLocked(tx *Transaction) error {
+	policy := bc.dustPolicy
+	if tx.Type != "regular" || (policy.MinTransfer == 0 && policy.MinBalance == 0) {
+		return nil
+	}
+
+	if tx.Value < policy.MinTransfer {
+		return fmt.Errorf("%w: value %d is below the minimum transfer of %d", ErrDust, tx.Value, policy.MinTransfer)
+	}
+	if policy.MinBalance == 0 {
+		return nil
+	}
+
+	minBalance := new(big.Int).SetUint64(policy.MinBalance)
+	value := new(big.Int).SetUint64(tx.Value)
+
+	received := new(big.Int).Set(value)
+	if balance, exists := bc.accounts[tx.To]; exists {
+		received.Add(received, balance)
+	}
+	if received.Cmp(minBalance) < 0 {
+		return fmt.Errorf("%w: recipient balance would be %s, below the dust threshold of %d", ErrDust, received, policy.MinBalance)
+	}
+
+	remaining := big.NewInt(0)
+	if balance, exists := bc.accounts[tx.From]; exists {
+		remaining.Set(balance)
+	}
+	for _, pending := range bc.pendingTxs {
+		if pending.From == tx.From {
+			remaining.Sub(remaining, new(big.Int).SetUint64(pending.Value))
+		}
+	}
+	remaining.Sub(remaining, value)
+	if remaining.Sign() > 0 && remaining.Cmp(minBalance) < 0 {
+		return fmt.Errorf("%w: sender would keep %s, below the dust threshold of %d; send the full balance instead", ErrDust, remaining, policy.MinBalance)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	// ErrReservedAddress is returned for transfers from or to the symbolic
+	// senders of new supply, which are not accounts
+	ErrReservedAddress = errors.New("address is reserved for minting")
+	// ErrMaxSupplyReached is returned for a mint that would exceed the max supply
+	ErrMaxSupplyReached = errors.New("max supply reached")
+)
+
+// isMintSender reports whether address is a symbolic source of new supply.
+// Genesis allocations and block rewards are created from nothing: no account
+// is debited for them and none may hold a balance under these names.
+func isMintSender(address string) bool {
+	return address == RewardSender || address == GenesisSender
+}
+
+// checkReservedAddresses refuses transfers that would use a mint sender as an
+// account or move funds in or out of the epoch reward pool
+func checkReservedAddresses(from, to string) error {
+	if isMintSender(from) {
+		return fmt.Errorf("%w: %s cannot send funds", ErrReservedAddress, from)
+	}
+	if from == EpochRewardPoolAddress {
+		return fmt.Errorf("%w: %s only pays out epoch rewards", ErrReservedAddress, from)
+	}
+	if from == StakingPoolAddress {
+		return fmt.Errorf("%w: %s only pays out claimed staking rewards", ErrReservedAddress, from)
+	}
+	return checkRewardRecipient(to)
+}
+
+// checkRewardRecipient refuses reserved addresses as the recipient of a transfer or payout
+func checkRewardRecipient(address string) error {
+	if isMintSender(address) || address == EpochRewardPoolAddress {
+		return fmt.Errorf("%w: %s cannot receive funds", ErrReservedAddress, address)
+	}
+	return nil
+}
+
+// issuableLocked returns the supply the emission schedule may still create,
+// or nil when it has no max supply. The caller must hold bc.mu.
+func (bc *Blockchain) issuableLocked() *big.Int {
+	max := bc.rewardSchedule.maxSupply()
+	if max == nil {
+		return nil
+	}
+	left := new(big.Int).Sub(max, bc.totalSupplyLocked())
+	if left.Sign() < 0 {
+		return big.NewInt(0)
+	}
+	return left
+}
+
+// totalSupplyLocked returns the genesis allocations plus everything minted
+// since. The caller must hold bc.mu.
+func (bc *Blockchain) totalSupplyLocked() *big.Int {
+	total := new(big.Int).Set(bc.TotalMinted)
+	if bc.genesisSupply != nil {
+		total.Add(total, bc.genesisSupply)
+	}
+	return total
+}
+
+// mintLocked creates amount of new supply for address and adds it to
+// TotalMinted. Every mint operation goes through it so that none can exceed
+// the max supply. The caller must hold bc.mu.
+func (bc *Blockchain) mintLocked(address string, amount *big.Int) error {
+	if isMintSender(address) {
+		return fmt.Errorf("%w: %s cannot receive minted funds", ErrReservedAddress, address)
+	}
+	if left := bc.issuableLocked(); left != nil && amount.Cmp(left) > 0 {
+		return fmt.Errorf("%w: minting %s exceeds the %s left to issue", ErrMaxSupplyReached, amount.String(), left.String())
+	}
+
+	bc.mutex.Lock()
+	balance, exists := bc.accounts[address]
+	if !exists {
+		balance = big.NewInt(0)
+	}
+	bc.accounts[address] = new(big.Int).Add(balance, amount)
+	bc.mutex.Unlock()
+	bc.richIndex.touch(address)
+
+	bc.TotalMinted = new(big.Int).Add(bc.TotalMinted, amount)
+	return nil
+}
+
+// mintRewardLocked applies a reward transaction: the amount is minted to the
+// proposer. The caller must hold bc.mu.
+func (bc *Blockchain) mintRewardLocked(tx *Transaction) error {
+	if tx.From != RewardSender {
+		return fmt.Errorf("%w: reward must come from %s, got %s", ErrInvalidReward, RewardSender, tx.From)
+	}
+	return bc.mintLocked(tx.To, new(big.Int).SetUint64(tx.Value))
+}
+
+// checkGenesisSupplyLocked refuses a genesis block allocating more than the
+// max supply of its emission schedule. The caller must hold bc.mu.
+func (bc *Blockchain) checkGenesisSupplyLocked() error {
+	max := bc.rewardSchedule.maxSupply()
+	if max != nil && bc.genesisSupply != nil && bc.genesisSupply.Cmp(max) > 0 {
+		return fmt.Errorf("%w: genesis allocates %s, the max supply is %s", ErrMaxSupplyReached, bc.genesisSupply.String(), max.String())
+	}
+	return nil
+}
+
+// rebuildSupplyLocked recomputes the genesis supply and TotalMinted from the
+// chain. Block headers carry their reward, so pruned blocks count as well.
+// The caller must hold bc.mu.
+func (bc *Blockchain) rebuildSupplyLocked() {
+	bc.genesisSupply = big.NewInt(0)
+	bc.TotalMinted = big.NewInt(0)
+	for _, block := range bc.Blocks {
+		if block.Index == 0 {
+			bc.genesisSupply = genesisSupplyOf(block)
+			continue
+		}
+		bc.TotalMinted.Add(bc.TotalMinted, new(big.Int).SetUint64(block.Reward))
+	}
+}
+
+// genesisSupplyOf sums the valid allocations of a genesis block
+func genesisSupplyOf(genesis *Block) *big.Int {
+	total := big.NewInt(0)
+	for _, tx := range genesis.Transactions {
+		if tx.Type != GenesisAllocationTxType {
+			continue
+		}
+		if _, amount, err := ParseGenesisAllocation(tx); err == nil {
+			total.Add(total, amount)
+		}
+	}
+	return total
+}
+
+// GetTotalMinted returns the supply minted by block rewards since genesis
+func (bc *Blockchain) GetTotalMinted() *big.Int {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return new(big.Int).Set(bc.TotalMinted)
+}
+
+// Supply describes the issued supply and what the emission may still create.
+// Amounts are decimal strings in base units.
+type Supply struct {
+	Height     uint64 `json:"height"`
+	Genesis    string `json:"genesis"` // allocated by the genesis block
+	Minted     string `json:"minted"`  // minted by block rewards since genesis
+	Total      string `json:"total"`
+	MaxSupply  string `json:"maxSupply,omitempty"` // empty when the supply is uncapped
+	Issuable   string `json:"issuable,omitempty"`  // left to mint before MaxSupply
+	NextReward string `json:"nextReward"`          // reward of the next block
+}
+
+// Supply returns the current supply of the chain
+func (bc *Blockchain) Supply() Supply {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	next := uint64(len(bc.Blocks))
+	supply := Supply{
+		Height:     next - 1,
+		Genesis:    "0",
+		Minted:     bc.TotalMinted.String(),
+		Total:      bc.totalSupplyLocked().String(),
+		NextReward: bc.blockRewardLocked(next).String(),
+	}
+	if bc.genesisSupply != nil {
+		supply.Genesis = bc.genesisSupply.String()
+	}
+	if max := bc.rewardSchedule.maxSupply(); max != nil {
+		supply.MaxSupply = max.String()
+		supply.Issuable = bc.issuableLocked().String()
+	}
+	return supply
+}
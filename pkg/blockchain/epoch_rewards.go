@@ -0,0 +1,446 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// FeatureEpochRewards makes block rewards accrue in the epoch reward pool and
+// pays them out in one batch of distribution transactions carried by the
+// first block of the next epoch
+const FeatureEpochRewards Feature = "epoch_rewards"
+
+// EpochRewardTxType is the transaction type of an epoch reward payout
+const EpochRewardTxType = "epoch_reward"
+
+// EpochRewardPoolAddress holds the block rewards of the current epoch until
+// they are distributed. Only epoch reward transactions move funds out of it.
+const EpochRewardPoolAddress = "confirmix_epoch_reward_pool"
+
+// MaxTreasuryShare is the treasury share of all rewards, in basis points
+const MaxTreasuryShare = 10000
+
+// Recipients of epoch reward payouts
+const (
+	EpochRewardValidator = "validator" // the validator's own part of its blocks' rewards
+	EpochRewardDelegator = "delegator" // a delegator's part of its validator's rewards
+	EpochRewardTreasury  = "treasury"  // the treasury share of the epoch's rewards
+)
+
+// ErrInvalidEpochReward is returned for a block whose epoch reward distribution does not match the chain
+var ErrInvalidEpochReward = errors.New("invalid epoch reward distribution")
+
+func init() {
+	RegisterFeature(FeatureEpochRewards, "Block rewards accrue per epoch and are distributed by the first block of the next epoch")
+}
+
+// EpochRewardConfig controls the epoch reward distribution. It is a consensus
+// parameter: blocks with another distribution are rejected, so all nodes must
+// agree on it.
+type EpochRewardConfig struct {
+	EpochLength     uint64 `json:"epochLength"`               // blocks per epoch; the validator manager uses the same epochs
+	TreasuryAddress string `json:"treasuryAddress,omitempty"` // receives the treasury share
+	TreasuryShare   uint64 `json:"treasuryShare"`             // part of every validator's rewards for the treasury, in basis points
+}
+
+// DefaultEpochRewardConfig returns epochs of 100 blocks without a treasury share
+func DefaultEpochRewardConfig() EpochRewardConfig {
+	return EpochRewardConfig{EpochLength: 100}
+}
+
+// RewardSplit is how the rewards a validator earned in an epoch are shared
+// with its delegators. Whatever the delegators are not paid goes to the validator.
+type RewardSplit struct {
+	Delegators map[string]*big.Int // payout per delegator address
+	Commission *big.Int            // part of the delegators' share the validator kept, for the record
+}
+
+// EpochReward is the payload of an epoch reward transaction
+type EpochReward struct {
+	Epoch      uint64 `json:"epoch"`
+	Role       string `json:"role"`                 // EpochRewardValidator, EpochRewardDelegator or EpochRewardTreasury
+	Validator  string `json:"validator,omitempty"`  // validator whose blocks earned the payout, empty for the treasury
+	Amount     string `json:"amount"`               // in base units; the transaction value is capped to a uint64
+	Commission string `json:"commission,omitempty"` // validator payouts: commission kept on the delegators' share
+}
+
+// ParseEpochReward decodes and validates the payload of an epoch reward transaction
+func ParseEpochReward(tx *Transaction) (*EpochReward, *big.Int, error) {
+	if tx == nil || tx.Type != EpochRewardTxType {
+		return nil, nil, fmt.Errorf("%w: not an epoch reward transaction", ErrInvalidEpochReward)
+	}
+
+	var reward EpochReward
+	if err := json.Unmarshal(tx.Data, &reward); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidEpochReward, err)
+	}
+	amount, ok := new(big.Int).SetString(reward.Amount, 10)
+	if !ok || amount.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("%w: amount must be a positive integer, got %q", ErrInvalidEpochReward, reward.Amount)
+	}
+	return &reward, amount, nil
+}
+
+// newEpochRewardTransaction builds the n-th payout of an epoch's distribution
+func newEpochRewardTransaction(block *Block, n int, to string, amount *big.Int, reward EpochReward) *Transaction {
+	reward.Amount = amount.String()
+	data, _ := json.Marshal(reward)
+
+	value := ^uint64(0)
+	if amount.IsUint64() {
+		value = amount.Uint64()
+	}
+	return &Transaction{
+		ID:         epochRewardTxID(reward.Epoch, n),
+		From:       EpochRewardPoolAddress,
+		To:         to,
+		Value:      value,
+		Data:       data,
+		Timestamp:  block.Timestamp,
+		Type:       EpochRewardTxType,
+		Status:     "pending",
+		BlockIndex: int64(block.Index),
+	}
+}
+
+// epochRewardTxID returns the ID of the n-th payout of an epoch
+func epochRewardTxID(epoch uint64, n int) string {
+	return fmt.Sprintf("epoch_reward_%d_%d", epoch, n)
+}
+
+// isRewardTransaction reports whether tx pays out rewards rather than being
+// submitted by a user. Reward transactions do not count against the block capacity.
+func isRewardTransaction(tx *Transaction) bool {
+	return tx.Type == RewardTxType || tx.Type == EpochRewardTxType
+}
+
+// SetEpochRewardConfig sets the epochs and the treasury share of the epoch
+// reward distribution
+func (bc *Blockchain) SetEpochRewardConfig(config EpochRewardConfig) error {
+	if config.EpochLength == 0 {
+		config.EpochLength = DefaultEpochRewardConfig().EpochLength
+	}
+	if config.TreasuryShare > MaxTreasuryShare {
+		return fmt.Errorf("treasury share is %d basis points, the maximum is %d", config.TreasuryShare, MaxTreasuryShare)
+	}
+	if config.TreasuryShare > 0 && config.TreasuryAddress == "" {
+		return errors.New("a treasury share needs a treasury address")
+	}
+	if config.TreasuryAddress != "" {
+		if err := checkRewardRecipient(config.TreasuryAddress); err != nil {
+			return err
+		}
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.epochRewards = config
+	return nil
+}
+
+// EpochRewardConfig returns the epochs and treasury share of the epoch reward distribution
+func (bc *Blockchain) EpochRewardConfig() EpochRewardConfig {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.epochRewards
+}
+
+// rewardRecipientLocked returns who the reward of the block at height is
+// minted to. The caller must hold bc.mu.
+func (bc *Blockchain) rewardRecipientLocked(height uint64, validator string) string {
+	if bc.featureActiveLocked(FeatureEpochRewards, height) {
+		return EpochRewardPoolAddress
+	}
+	return validator
+}
+
+// distributionDueLocked returns the epoch whose rewards the block at height
+// must distribute, if any: the first block of every epoch distributes the
+// previous one. The caller must hold bc.mu.
+func (bc *Blockchain) distributionDueLocked(height uint64) (uint64, bool) {
+	length := bc.epochRewards.EpochLength
+	if height == 0 || height%length != 0 || !bc.featureActiveLocked(FeatureEpochRewards, height) {
+		return 0, false
+	}
+	return height/length - 1, true
+}
+
+// epochAccrual is what the blocks of an epoch minted into the epoch reward pool
+type epochAccrual struct {
+	epoch    uint64
+	blocks   map[string]int      // blocks per validator
+	earned   map[string]*big.Int // rewards per validator
+	treasury map[string]*big.Int // treasury share of each validator's rewards
+}
+
+// epochAccrualLocked sums the rewards the blocks of epoch minted into the
+// pool. Pruned blocks keep their reward in the header, so they count as well.
+// The caller must hold bc.mu.
+func (bc *Blockchain) epochAccrualLocked(epoch uint64) epochAccrual {
+	accrual := epochAccrual{
+		epoch:    epoch,
+		blocks:   make(map[string]int),
+		earned:   make(map[string]*big.Int),
+		treasury: make(map[string]*big.Int),
+	}
+	length := bc.epochRewards.EpochLength
+	for index := epoch * length; index < (epoch+1)*length && index < uint64(len(bc.Blocks)); index++ {
+		block := bc.Blocks[index]
+		if index == 0 || block.Reward == 0 || !bc.featureActiveLocked(FeatureEpochRewards, index) {
+			continue
+		}
+		if accrual.earned[block.Validator] == nil {
+			accrual.earned[block.Validator] = big.NewInt(0)
+		}
+		accrual.blocks[block.Validator]++
+		accrual.earned[block.Validator].Add(accrual.earned[block.Validator], new(big.Int).SetUint64(block.Reward))
+	}
+	for validator, earned := range accrual.earned {
+		share := new(big.Int).Mul(earned, new(big.Int).SetUint64(bc.epochRewards.TreasuryShare))
+		accrual.treasury[validator] = share.Quo(share, big.NewInt(MaxTreasuryShare))
+	}
+	return accrual
+}
+
+// validators returns the validators that earned rewards, sorted
+func (a epochAccrual) validators() []string {
+	validators := make([]string, 0, len(a.earned))
+	for validator := range a.earned {
+		validators = append(validators, validator)
+	}
+	sort.Strings(validators)
+	return validators
+}
+
+// AttachRewards puts the block reward and, in the first block of an epoch,
+// the distribution of the previous epoch's rewards at the front of the block
+// and recomputes its hash. Proposers call it before signing the block.
+func (bc *Blockchain) AttachRewards(block *Block) {
+	bc.mu.RLock()
+	reward := bc.blockRewardLocked(block.Index)
+	recipient := bc.rewardRecipientLocked(block.Index, block.Validator)
+	var payouts []*Transaction
+	if epoch, due := bc.distributionDueLocked(block.Index); due {
+		payouts = bc.planEpochRewardsLocked(block, bc.epochAccrualLocked(epoch))
+	}
+	bc.mu.RUnlock()
+
+	block.Transactions = append(payouts, block.Transactions...)
+	block.attachReward(reward, recipient)
+	block.Hash = block.CalculateHash()
+}
+
+// planEpochRewardsLocked builds the distribution of an epoch: the treasury
+// share first, then per validator in address order its own payout followed
+// by its delegators' in address order. The delegators' part follows the
+// delegations confirmed on chain, see splitRewardLocked, so every node plans
+// the same payouts. Amounts that round down stay with the validator. The
+// caller must hold bc.mu.
+func (bc *Blockchain) planEpochRewardsLocked(block *Block, accrual epochAccrual) []*Transaction {
+	var payouts []*Transaction
+	add := func(to string, amount *big.Int, reward EpochReward) {
+		if amount.Sign() > 0 {
+			payouts = append(payouts, newEpochRewardTransaction(block, len(payouts), to, amount, reward))
+		}
+	}
+
+	treasury := big.NewInt(0)
+	for _, share := range accrual.treasury {
+		treasury.Add(treasury, share)
+	}
+	add(bc.epochRewards.TreasuryAddress, treasury, EpochReward{Epoch: accrual.epoch, Role: EpochRewardTreasury})
+
+	for _, validator := range accrual.validators() {
+		rest := new(big.Int).Sub(accrual.earned[validator], accrual.treasury[validator])
+		credits, delegated, commission := bc.splitRewardLocked(validator, block.Index, rest)
+
+		delegators := make([]string, 0, len(credits))
+		for delegator := range credits {
+			delegators = append(delegators, delegator)
+		}
+		sort.Strings(delegators)
+
+		own := EpochReward{Epoch: accrual.epoch, Role: EpochRewardValidator, Validator: validator}
+		if commission.Sign() > 0 && len(delegators) > 0 {
+			own.Commission = commission.String()
+		}
+		add(validator, new(big.Int).Sub(rest, delegated), own)
+		for _, delegator := range delegators {
+			add(delegator, credits[delegator], EpochReward{Epoch: accrual.epoch, Role: EpochRewardDelegator, Validator: validator})
+		}
+	}
+	return payouts
+}
+
+// verifyEpochRewardsLocked checks the epoch reward transactions of a block:
+// the first block of an epoch must carry exactly the distribution this node
+// plans from the chain, see planEpochRewardsLocked, with the same recipients,
+// amounts and order. Other blocks must not carry payouts. The caller must
+// hold bc.mu.
+func (bc *Blockchain) verifyEpochRewardsLocked(block *Block) error {
+	var payouts []*Transaction
+	for _, tx := range block.Transactions {
+		if tx.Type == EpochRewardTxType {
+			payouts = append(payouts, tx)
+		}
+	}
+
+	epoch, due := bc.distributionDueLocked(block.Index)
+	if !due {
+		if len(payouts) > 0 {
+			return fmt.Errorf("%w: no distribution is due at height %d", ErrInvalidEpochReward, block.Index)
+		}
+		return nil
+	}
+
+	expected := bc.planEpochRewardsLocked(block, bc.epochAccrualLocked(epoch))
+	if len(payouts) != len(expected) {
+		return fmt.Errorf("%w: epoch %d must be distributed in %d payouts, got %d", ErrInvalidEpochReward, epoch, len(expected), len(payouts))
+	}
+	for n, tx := range payouts {
+		want := expected[n]
+		if tx.ID != want.ID {
+			return fmt.Errorf("%w: expected id %s, got %s", ErrInvalidEpochReward, want.ID, tx.ID)
+		}
+		if tx.From != want.From || tx.To != want.To || tx.Value != want.Value || !bytes.Equal(tx.Data, want.Data) {
+			reward, _, _ := ParseEpochReward(want)
+			return fmt.Errorf("%w: payout %s must pay %s to %s as %s of %s", ErrInvalidEpochReward, tx.ID, reward.Amount, want.To, reward.Role, reward.Validator)
+		}
+	}
+	return nil
+}
+
+// applyEpochRewardLocked applies an epoch reward payout: the amount moves
+// from the epoch reward pool to the recipient. The caller must hold bc.mu.
+func (bc *Blockchain) applyEpochRewardLocked(tx *Transaction) error {
+	_, amount, err := ParseEpochReward(tx)
+	if err != nil {
+		return err
+	}
+
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	pool := bc.accounts[EpochRewardPoolAddress]
+	if pool == nil || pool.Cmp(amount) < 0 {
+		return fmt.Errorf("%w: the epoch reward pool cannot pay %s", ErrInsufficientBalance, amount.String())
+	}
+	bc.accounts[EpochRewardPoolAddress] = new(big.Int).Sub(pool, amount)
+	balance, exists := bc.accounts[tx.To]
+	if !exists {
+		balance = big.NewInt(0)
+	}
+	bc.accounts[tx.To] = new(big.Int).Add(balance, amount)
+	bc.richIndex.touch(EpochRewardPoolAddress, tx.To)
+	return nil
+}
+
+// ValidatorEpochReward is what one validator's blocks earned in an epoch and
+// where it went. Amounts are decimal strings in base units.
+type ValidatorEpochReward struct {
+	Validator  string `json:"validator"`
+	Blocks     int    `json:"blocks"`
+	Earned     string `json:"earned"`               // rewards of the validator's blocks
+	Treasury   string `json:"treasury"`             // treasury share of Earned
+	Paid       string `json:"paid,omitempty"`       // paid to the validator
+	Delegators string `json:"delegators,omitempty"` // paid to its delegators
+	Commission string `json:"commission,omitempty"` // kept by the validator on the delegators' share
+}
+
+// EpochRewardSummary reports the rewards an epoch accrued in the pool and,
+// once the first block of the next epoch is applied, their distribution
+type EpochRewardSummary struct {
+	Epoch              uint64                 `json:"epoch"`
+	StartHeight        uint64                 `json:"startHeight"`
+	EndHeight          uint64                 `json:"endHeight"`          // last block of the epoch
+	DistributionHeight uint64                 `json:"distributionHeight"` // block carrying the payouts
+	Distributed        bool                   `json:"distributed"`
+	Total              string                 `json:"total"` // minted into the pool by the epoch's blocks
+	Treasury           string                 `json:"treasury"`
+	TreasuryAddress    string                 `json:"treasuryAddress,omitempty"`
+	Payouts            int                    `json:"payouts"` // distribution transactions
+	Validators         []ValidatorEpochReward `json:"validators"`
+}
+
+// EpochRewardSummary returns the rewards and distribution of an epoch
+func (bc *Blockchain) EpochRewardSummary(epoch uint64) (*EpochRewardSummary, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	length := bc.epochRewards.EpochLength
+	start := epoch * length
+	if start >= uint64(len(bc.Blocks)) {
+		return nil, fmt.Errorf("%w: epoch %d starts at block %d, the chain height is %d", ErrBlockNotFound, epoch, start, len(bc.Blocks)-1)
+	}
+
+	accrual := bc.epochAccrualLocked(epoch)
+	summary := &EpochRewardSummary{
+		Epoch:              epoch,
+		StartHeight:        start,
+		EndHeight:          start + length - 1,
+		DistributionHeight: start + length,
+		TreasuryAddress:    bc.epochRewards.TreasuryAddress,
+		Validators:         []ValidatorEpochReward{},
+	}
+
+	paid := make(map[string]*big.Int)
+	delegated := make(map[string]*big.Int)
+	commission := make(map[string]string)
+	treasury := big.NewInt(0)
+	if _, due := bc.distributionDueLocked(summary.DistributionHeight); due && summary.DistributionHeight < uint64(len(bc.Blocks)) {
+		summary.Distributed = true
+		for _, tx := range bc.Blocks[summary.DistributionHeight].Transactions {
+			reward, amount, err := ParseEpochReward(tx)
+			if err != nil {
+				continue
+			}
+			summary.Payouts++
+			totals := paid
+			switch reward.Role {
+			case EpochRewardTreasury:
+				treasury.Add(treasury, amount)
+				continue
+			case EpochRewardDelegator:
+				totals = delegated
+			default:
+				commission[reward.Validator] = reward.Commission
+			}
+			if totals[reward.Validator] == nil {
+				totals[reward.Validator] = big.NewInt(0)
+			}
+			totals[reward.Validator].Add(totals[reward.Validator], amount)
+		}
+	}
+
+	total, expectedTreasury := big.NewInt(0), big.NewInt(0)
+	for _, validator := range accrual.validators() {
+		total.Add(total, accrual.earned[validator])
+		expectedTreasury.Add(expectedTreasury, accrual.treasury[validator])
+		entry := ValidatorEpochReward{
+			Validator:  validator,
+			Blocks:     accrual.blocks[validator],
+			Earned:     accrual.earned[validator].String(),
+			Treasury:   accrual.treasury[validator].String(),
+			Commission: commission[validator],
+		}
+		if summary.Distributed {
+			entry.Paid, entry.Delegators = "0", "0"
+			if amount := paid[validator]; amount != nil {
+				entry.Paid = amount.String()
+			}
+			if amount := delegated[validator]; amount != nil {
+				entry.Delegators = amount.String()
+			}
+		}
+		summary.Validators = append(summary.Validators, entry)
+	}
+	summary.Total = total.String()
+	summary.Treasury = expectedTreasury.String()
+	if summary.Distributed {
+		summary.Treasury = treasury.String()
+	}
+	return summary, nil
+}
@@ -0,0 +1,54 @@
+package blockchain
+
+import "errors"
+
+// Sentinel errors returned by the blockchain package.
+// Callers should compare with errors.Is instead of matching error text,
+// since most of these are wrapped with additional context.
+var (
+	// Account and balance errors
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrInsufficientLocked  = errors.New("insufficient locked balance")
+	ErrAccountNotFound     = errors.New("account not found")
+	ErrAccountExists       = errors.New("account already exists")
+	ErrSelfTransfer        = errors.New("sender and recipient cannot be the same")
+
+	// Transaction errors
+	ErrNilTransaction   = errors.New("transaction is nil")
+	ErrTxExists         = errors.New("transaction already exists")
+	ErrTxNotFound       = errors.New("transaction not found")
+	ErrNoPendingTxs     = errors.New("no pending transactions")
+	ErrTxNotSigned      = errors.New("transaction is not signed")
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// Block errors
+	ErrBlockNotFound         = errors.New("block not found")
+	ErrInvalidBlockIndex     = errors.New("invalid block index")
+	ErrInvalidPrevHash       = errors.New("invalid previous hash")
+	ErrInvalidHumanProof     = errors.New("invalid human proof")
+	ErrInvalidBlockSignature = errors.New("invalid block signature")
+	ErrInvalidReward         = errors.New("invalid block reward")
+	ErrBlockTooLarge         = errors.New("block exceeds the transaction limit")
+	// ErrBlockAppliedWithErrors means the block was appended to the chain
+	// but some of its transactions could not be applied
+	ErrBlockAppliedWithErrors = errors.New("block added with errors")
+	// ErrArchiveUnavailable means an archived block body could not be fetched or failed verification
+	ErrArchiveUnavailable = errors.New("archived block is unavailable")
+
+	// Validator errors
+	ErrUnknownValidator   = errors.New("unknown validator")
+	ErrValidatorExists    = errors.New("address is already a validator")
+	ErrHumanProofRequired = errors.New("human proof is required for validators")
+	ErrKeyPairNotFound    = errors.New("key pair not found")
+
+	// Multi-signature errors
+	ErrMultiSigWalletNotFound = errors.New("multi-signature wallet not found")
+	ErrMultiSigWalletExists   = errors.New("multi-signature wallet already exists")
+	ErrNotOwner               = errors.New("address is not an owner of this wallet")
+	ErrAlreadySigned          = errors.New("transaction already signed by this owner")
+	ErrNotEnoughSignatures    = errors.New("not enough signatures")
+	ErrThresholdKeyNotSet     = errors.New("multi-signature wallet has no threshold key")
+
+	// Contract errors
+	ErrContractNotFound = errors.New("contract not found")
+)
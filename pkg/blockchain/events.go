@@ -0,0 +1,253 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// eventsFile holds the chain event journal next to the chain state
+const eventsFile = "events.json"
+
+// Types of chain events. Events record state changes that are not
+// transactions, so explorers and auditors can see when and why they happened.
+const (
+	EventValidatorAdded     = "validator_added"      // address joined the active validator set
+	EventValidatorRemoved   = "validator_removed"    // address left the active validator set
+	EventValidatorStatus    = "validator_status"     // validator manager status change, e.g. approved or suspended
+	EventValidatorSlashed   = "validator_slashed"    // part of a validator's stake was burned
+	EventGovernanceExecuted = "governance_executed"  // an approved proposal was executed
+	EventParameterChanged   = "parameter_changed"    // a chain or consensus parameter was changed
+	EventBridgeLock         = "bridge_lock"          // ConX was locked in the bridge escrow for another chain
+	EventBridgeRelease      = "bridge_release"       // ConX was released from the bridge escrow
+	EventBalanceLocked      = "balance_locked"       // ConX was moved from an account's spendable to its locked balance
+	EventBalanceUnlocked    = "balance_unlocked"     // ConX was moved from an account's locked to its spendable balance
+	EventBalanceTransfer    = "balance_transfer"     // ConX was moved between accounts outside a transaction, e.g. a treasury payout
+	EventTxReverted         = "transaction_reverted" // a confirmed transaction was reversed by an operator
+)
+
+// ChainEvent is a notable change of chain state that is not a transaction.
+// Events are recorded as they happen and committed with the next block
+// applied, which gives them their block index and hash.
+type ChainEvent struct {
+	Seq        uint64            `json:"seq"` // position in the journal, starting at 1
+	BlockIndex uint64            `json:"blockIndex"`
+	BlockHash  string            `json:"blockHash"`
+	Type       string            `json:"type"`
+	Subject    string            `json:"subject,omitempty"` // address or proposal the event is about
+	Data       map[string]string `json:"data,omitempty"`
+	Timestamp  int64             `json:"timestamp"`
+}
+
+// eventJournal holds the committed events and those waiting for the next
+// block, and the subscribers notified of new events. It has its own lock
+// because events are recorded from the validator manager and governance while
+// they hold their own locks, and from chain code holding bc.mu.
+type eventJournal struct {
+	mu          sync.RWMutex
+	committed   []ChainEvent
+	pending     []ChainEvent
+	seq         uint64
+	subscribers []*eventSubscriber
+}
+
+// eventSubscriber delivers events to a subscriber function on a goroutine of
+// its own, in recording order. Its queue is unbounded so that recording an
+// event never waits for a subscriber.
+type eventSubscriber struct {
+	fn    func(ChainEvent)
+	types map[string]bool // nil for every type
+	mu    sync.Mutex
+	queue []ChainEvent
+	wake  chan struct{}
+}
+
+// push queues an event for the subscriber
+func (s *eventSubscriber) push(event ChainEvent) {
+	if s.types != nil && !s.types[event.Type] {
+		return
+	}
+	s.mu.Lock()
+	s.queue = append(s.queue, event)
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers the queued events until the process exits
+func (s *eventSubscriber) run() {
+	for range s.wake {
+		s.mu.Lock()
+		queue := s.queue
+		s.queue = nil
+		s.mu.Unlock()
+		for _, event := range queue {
+			s.fn(event)
+		}
+	}
+}
+
+// OnEvent subscribes fn to the events recorded from now on, or only to those
+// of the given types. fn runs on a goroutine of its own and receives the
+// events one at a time in recording order, as soon as they are recorded
+// rather than when their block is applied, so it may call back into the chain.
+func (bc *Blockchain) OnEvent(fn func(ChainEvent), types ...string) {
+	subscriber := &eventSubscriber{fn: fn, wake: make(chan struct{}, 1)}
+	if len(types) > 0 {
+		subscriber.types = make(map[string]bool, len(types))
+		for _, eventType := range types {
+			subscriber.types[eventType] = true
+		}
+	}
+	go subscriber.run()
+
+	bc.events.mu.Lock()
+	defer bc.events.mu.Unlock()
+	bc.events.subscribers = append(bc.events.subscribers, subscriber)
+}
+
+// RecordEvent adds an event to the journal and notifies the subscribers. It
+// is committed with the next block applied.
+func (bc *Blockchain) RecordEvent(eventType, subject string, data map[string]string) {
+	bc.events.mu.Lock()
+	defer bc.events.mu.Unlock()
+
+	bc.events.seq++
+	event := ChainEvent{
+		Seq:       bc.events.seq,
+		Type:      eventType,
+		Subject:   subject,
+		Data:      data,
+		Timestamp: time.Now().Unix(),
+	}
+	bc.events.pending = append(bc.events.pending, event)
+	for _, subscriber := range bc.events.subscribers {
+		event.Data = copyEventData(data)
+		subscriber.push(event)
+	}
+}
+
+// commitEvents assigns the pending events to block
+func (bc *Blockchain) commitEvents(block *Block) {
+	bc.events.mu.Lock()
+	defer bc.events.mu.Unlock()
+
+	for _, event := range bc.events.pending {
+		event.BlockIndex = block.Index
+		event.BlockHash = block.Hash
+		bc.events.committed = append(bc.events.committed, event)
+	}
+	bc.events.pending = nil
+}
+
+// Events returns up to limit committed events in blocks from fromBlock on
+// with a sequence number above afterSeq, oldest first, optionally only those
+// of one type
+func (bc *Blockchain) Events(fromBlock, afterSeq uint64, eventType string, limit int) []ChainEvent {
+	bc.events.mu.RLock()
+	defer bc.events.mu.RUnlock()
+
+	events := []ChainEvent{}
+	for _, event := range bc.events.committed {
+		if len(events) >= limit {
+			break
+		}
+		if event.BlockIndex < fromBlock || event.Seq <= afterSeq || (eventType != "" && event.Type != eventType) {
+			continue
+		}
+		event.Data = copyEventData(event.Data)
+		events = append(events, event)
+	}
+	return events
+}
+
+// PendingEvents returns the events recorded since the last block, oldest first
+func (bc *Blockchain) PendingEvents() []ChainEvent {
+	bc.events.mu.RLock()
+	defer bc.events.mu.RUnlock()
+
+	events := make([]ChainEvent, len(bc.events.pending))
+	for i, event := range bc.events.pending {
+		event.Data = copyEventData(event.Data)
+		events[i] = event
+	}
+	return events
+}
+
+// copyEventData copies the data of an event so callers cannot change the journal
+func copyEventData(data map[string]string) map[string]string {
+	if data == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(data))
+	for key, value := range data {
+		copied[key] = value
+	}
+	return copied
+}
+
+// eventJournalData is the persisted form of the journal
+type eventJournalData struct {
+	Committed []ChainEvent `json:"committed"`
+	Pending   []ChainEvent `json:"pending,omitempty"`
+}
+
+// saveEvents writes the journal to dir
+func (bc *Blockchain) saveEvents(dir string) error {
+	bc.events.mu.RLock()
+	data, err := json.Marshal(eventJournalData{Committed: bc.events.committed, Pending: bc.events.pending})
+	bc.events.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal chain events: %v", err)
+	}
+
+	path := filepath.Join(dir, eventsFile)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write chain events: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadEventsLocked reads the journal from dir. Events committed to blocks the chain
+// no longer has are dropped. The caller must hold bc.mu.
+func (bc *Blockchain) loadEventsLocked(dir string) {
+	raw, err := ioutil.ReadFile(filepath.Join(dir, eventsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to read chain events: %v", err)
+		}
+		return
+	}
+	var data eventJournalData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		log.Printf("Warning: Failed to parse chain events: %v", err)
+		return
+	}
+
+	bc.events.mu.Lock()
+	defer bc.events.mu.Unlock()
+	bc.events.committed = bc.events.committed[:0]
+	bc.events.seq = 0
+	for _, event := range data.Committed {
+		if event.BlockIndex >= uint64(len(bc.Blocks)) || bc.Blocks[event.BlockIndex].Hash != event.BlockHash {
+			continue
+		}
+		bc.events.committed = append(bc.events.committed, event)
+	}
+	bc.events.pending = data.Pending
+	for _, events := range [][]ChainEvent{bc.events.committed, bc.events.pending} {
+		for _, event := range events {
+			if event.Seq > bc.events.seq {
+				bc.events.seq = event.Seq
+			}
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// Errors returned for double-signing evidence
+var (
+	ErrInvalidEvidence = errors.New("invalid double-signing evidence")
+	ErrEvidenceExists  = errors.New("double-signing evidence already recorded")
+)
+
+// SignedHeader is the part of a block a validator signs. Two different signed
+// headers from one validator at one height prove that it signed conflicting blocks.
+type SignedHeader struct {
+	Index     uint64 `json:"index"`
+	Hash      string `json:"hash"`
+	Validator string `json:"validator"`
+	ChainID   string `json:"chainId"`
+	SigScheme int    `json:"sigScheme"`
+	Signature []byte `json:"signature"`
+}
+
+// DoubleSignEvidence is the payload (Transaction.Data) of a slashing evidence transaction
+type DoubleSignEvidence struct {
+	First  SignedHeader `json:"first"`
+	Second SignedHeader `json:"second"`
+}
+
+// EvidenceRecord is an entry of the on-chain evidence registry
+type EvidenceRecord struct {
+	ID         string             `json:"id"`
+	Validator  string             `json:"validator"`
+	Height     uint64             `json:"height"`
+	Evidence   DoubleSignEvidence `json:"evidence"`
+	Reporter   string             `json:"reporter"`
+	TxID       string             `json:"txId"`
+	BlockIndex uint64             `json:"blockIndex"`
+	BlockHash  string             `json:"blockHash"`
+	Timestamp  int64              `json:"timestamp"`
+}
+
+// SignedHeader returns the signed header of the block
+func (b *Block) SignedHeader() SignedHeader {
+	return SignedHeader{
+		Index:     b.Index,
+		Hash:      b.Hash,
+		Validator: b.Validator,
+		ChainID:   b.ChainID,
+		SigScheme: b.SigScheme,
+		Signature: b.Signature,
+	}
+}
+
+// verify checks the header signature against the validator's public key
+func (h SignedHeader) verify(publicKey PublicKey) error {
+	if len(h.Signature) == 0 {
+		return errors.New("header is not signed")
+	}
+	if err := checkSigningDomain(h.ChainID, h.SigScheme); err != nil {
+		return err
+	}
+	digest, err := signingDigest(blockSigningDomain, h.SigScheme, h.ChainID, blockSigningPayload(h.Index, h.Hash))
+	if err != nil {
+		return err
+	}
+	if !publicKey.Verify(digest, h.Signature) {
+		return errors.New("signature does not match header")
+	}
+	return nil
+}
+
+// Validator returns the validator the evidence accuses
+func (e *DoubleSignEvidence) Validator() string {
+	return e.First.Validator
+}
+
+// Height returns the height at which the validator signed twice
+func (e *DoubleSignEvidence) Height() uint64 {
+	return e.First.Index
+}
+
+// ID returns a stable identifier of the offence. Evidence for the same validator
+// and height has the same ID regardless of which two headers prove it.
+func (e *DoubleSignEvidence) ID() string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", e.Validator(), e.Height())))
+	return hex.EncodeToString(hash[:])
+}
+
+// NewDoubleSignEvidenceTransaction creates the transaction that submits evidence.
+// Any node may report; the reporter is only recorded, it does not need a balance.
+func NewDoubleSignEvidenceTransaction(reporter string, evidence *DoubleSignEvidence) (*Transaction, error) {
+	if reporter == "" {
+		return nil, errors.New("reporter is required")
+	}
+	data, err := json.Marshal(evidence)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := NewTransaction(
+		fmt.Sprintf("evidence_%s", evidence.ID()),
+		reporter,
+		evidence.Validator(),
+		0, // evidence carries no value
+		data,
+	)
+	tx.Type = SlashEvidenceTxType
+	return tx, nil
+}
+
+// ParseDoubleSignEvidence decodes the payload of an evidence transaction and checks
+// that it describes two different headers of one validator at one height.
+// Signatures are checked by the blockchain, which knows the validator keys.
+func ParseDoubleSignEvidence(tx *Transaction) (*DoubleSignEvidence, error) {
+	if tx == nil || tx.Type != SlashEvidenceTxType {
+		return nil, fmt.Errorf("%w: not an evidence transaction", ErrInvalidEvidence)
+	}
+
+	var evidence DoubleSignEvidence
+	if err := json.Unmarshal(tx.Data, &evidence); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvidence, err)
+	}
+	first, second := evidence.First, evidence.Second
+	switch {
+	case first.Validator == "" || first.Validator != second.Validator:
+		return nil, fmt.Errorf("%w: headers must come from the same validator", ErrInvalidEvidence)
+	case first.Index != second.Index:
+		return nil, fmt.Errorf("%w: headers are at heights %d and %d", ErrInvalidEvidence, first.Index, second.Index)
+	case first.Hash == second.Hash:
+		return nil, fmt.Errorf("%w: headers are identical", ErrInvalidEvidence)
+	case tx.To != first.Validator:
+		return nil, fmt.Errorf("%w: transaction must be addressed to the accused validator", ErrInvalidEvidence)
+	}
+	return &evidence, nil
+}
+
+// VerifyEvidence checks the evidence carried by tx, including both signatures
+func (bc *Blockchain) VerifyEvidence(tx *Transaction) (*DoubleSignEvidence, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.verifyEvidenceLocked(tx)
+}
+
+// verifyEvidenceLocked is VerifyEvidence for callers that hold bc.mu
+func (bc *Blockchain) verifyEvidenceLocked(tx *Transaction) (*DoubleSignEvidence, error) {
+	evidence, err := ParseDoubleSignEvidence(tx)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := bc.evidence[evidence.ID()]; exists {
+		return nil, fmt.Errorf("%w: %s at height %d", ErrEvidenceExists, evidence.Validator(), evidence.Height())
+	}
+
+	keyPair, exists := bc.keyPairs[evidence.Validator()]
+	if !exists || keyPair.Public() == nil {
+		return nil, fmt.Errorf("%w: public key of %s not found", ErrInvalidEvidence, evidence.Validator())
+	}
+	for _, header := range []SignedHeader{evidence.First, evidence.Second} {
+		if err := header.verify(keyPair.Public()); err != nil {
+			return nil, fmt.Errorf("%w: header %s: %v", ErrInvalidEvidence, header.Hash, err)
+		}
+	}
+	return evidence, nil
+}
+
+// applyEvidenceLocked records the evidence carried by tx, slashes the validator's
+// stake and suspends it by removing it from the validator set. The caller must hold bc.mu.
+func (bc *Blockchain) applyEvidenceLocked(tx *Transaction, block *Block) error {
+	evidence, err := bc.verifyEvidenceLocked(tx)
+	if err != nil {
+		return err
+	}
+	bc.recordEvidenceLocked(evidence, tx, block)
+	bc.slashForEvidenceLocked(evidence.Validator(), tx)
+
+	if bc.validators[evidence.Validator()] {
+		delete(bc.validators, evidence.Validator())
+		bc.RecordEvent(EventValidatorRemoved, evidence.Validator(), map[string]string{
+			"reason":   "double-signing",
+			"evidence": tx.ID,
+		})
+		log.Printf("Validator %s suspended for double-signing at height %d (evidence %s)",
+			evidence.Validator(), evidence.Height(), tx.ID)
+	}
+	return nil
+}
+
+// recordEvidenceLocked adds evidence to the registry. The caller must hold bc.mu.
+func (bc *Blockchain) recordEvidenceLocked(evidence *DoubleSignEvidence, tx *Transaction, block *Block) {
+	if bc.evidence == nil {
+		bc.evidence = make(map[string]*EvidenceRecord)
+	}
+	bc.evidence[evidence.ID()] = &EvidenceRecord{
+		ID:         evidence.ID(),
+		Validator:  evidence.Validator(),
+		Height:     evidence.Height(),
+		Evidence:   *evidence,
+		Reporter:   tx.From,
+		TxID:       tx.ID,
+		BlockIndex: block.Index,
+		BlockHash:  block.Hash,
+		Timestamp:  tx.Timestamp,
+	}
+}
+
+// rebuildEvidenceLocked replays every confirmed evidence transaction of the chain.
+// Signatures were verified when the blocks were accepted. The caller must hold bc.mu.
+func (bc *Blockchain) rebuildEvidenceLocked() {
+	bc.evidence = make(map[string]*EvidenceRecord)
+	for _, block := range bc.Blocks {
+		for _, tx := range block.Transactions {
+			if tx.Type != SlashEvidenceTxType || tx.Status == "failed" {
+				continue
+			}
+			evidence, err := ParseDoubleSignEvidence(tx)
+			if err != nil {
+				log.Printf("Warning: Skipping invalid evidence transaction %s: %v", tx.ID, err)
+				continue
+			}
+			bc.recordEvidenceLocked(evidence, tx, block)
+		}
+	}
+}
+
+// GetEvidence returns all recorded double-signing evidence ordered by block index
+func (bc *Blockchain) GetEvidence() []EvidenceRecord {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	records := make([]EvidenceRecord, 0, len(bc.evidence))
+	for _, record := range bc.evidence {
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].BlockIndex != records[j].BlockIndex {
+			return records[i].BlockIndex < records[j].BlockIndex
+		}
+		return records[i].ID < records[j].ID
+	})
+	return records
+}
+
+// ReportDoubleSign checks whether block conflicts with the block this node holds at
+// the same height from the same validator and, if so, submits evidence to the pool.
+// It returns the evidence transaction, or nil when the block is not a double-sign.
+func (bc *Blockchain) ReportDoubleSign(block *Block, reporter string) (*Transaction, error) {
+	if block == nil || len(block.Signature) == 0 {
+		return nil, nil
+	}
+	local, err := bc.GetBlockByIndex(block.Index)
+	if err != nil || local.Validator != block.Validator || local.Hash == block.Hash {
+		return nil, nil
+	}
+
+	evidence := &DoubleSignEvidence{First: local.SignedHeader(), Second: block.SignedHeader()}
+	tx, err := NewDoubleSignEvidenceTransaction(reporter, evidence)
+	if err != nil {
+		return nil, err
+	}
+	if err := bc.AddTransaction(tx); err != nil {
+		if errors.Is(err, ErrTxExists) || errors.Is(err, ErrEvidenceExists) {
+			return nil, nil // already reported
+		}
+		return nil, err
+	}
+	log.Printf("Detected double-signing by %s at height %d, submitted evidence %s", block.Validator, block.Index, tx.ID)
+	return tx, nil
+}
@@ -0,0 +1,262 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Feature names a consensus rule that takes effect at an activation height.
+// New rules (fees, nonces, transaction types, ...) are gated on their feature
+// so every node switches at the same block instead of at a flag-day restart.
+type Feature string
+
+// GenesisActivationsTxType is the transaction type recording the activation
+// schedule in the genesis block
+const GenesisActivationsTxType = "genesis_activations"
+
+// activationsFile holds the activations scheduled by governance
+const activationsFile = "activations.json"
+
+var (
+	// ErrUnknownFeature is returned when scheduling a feature this node does not implement
+	ErrUnknownFeature = errors.New("unknown feature")
+	// ErrInvalidActivation is returned for an activation that cannot be scheduled
+	ErrInvalidActivation = errors.New("invalid feature activation")
+)
+
+var (
+	featuresMu sync.RWMutex
+	// features maps every rule this node implements to its description. Rules
+	// register themselves with RegisterFeature as they are introduced.
+	features = map[Feature]string{}
+
+	// genesisActivations is written into the genesis block of a new chain
+	genesisActivations = map[Feature]uint64{}
+)
+
+// RegisterFeature declares a rule this node implements
+func RegisterFeature(feature Feature, description string) {
+	featuresMu.Lock()
+	defer featuresMu.Unlock()
+	features[feature] = description
+}
+
+// FeatureKnown reports whether this node implements a feature
+func FeatureKnown(feature Feature) bool {
+	featuresMu.RLock()
+	defer featuresMu.RUnlock()
+	_, known := features[feature]
+	return known
+}
+
+// SetGenesisActivations sets the activation heights recorded in the genesis
+// block of a new chain. Like the chain ID it must match across the network and
+// be set before the blockchain is created.
+func SetGenesisActivations(activations map[Feature]uint64) error {
+	for feature := range activations {
+		if !FeatureKnown(feature) {
+			return fmt.Errorf("%w: %s", ErrUnknownFeature, feature)
+		}
+	}
+	featuresMu.Lock()
+	defer featuresMu.Unlock()
+	genesisActivations = make(map[Feature]uint64, len(activations))
+	for feature, height := range activations {
+		genesisActivations[feature] = height
+	}
+	return nil
+}
+
+// Activation is when a feature takes effect and who decided it
+type Activation struct {
+	Feature     Feature `json:"feature"`
+	Description string  `json:"description,omitempty"`
+	Height      uint64  `json:"height"`
+	Source      string  `json:"source"` // "genesis" or "governance"
+	ProposalID  string  `json:"proposalId,omitempty"`
+	Active      bool    `json:"active"` // active for the next block
+	Known       bool    `json:"known"`  // implemented by this node
+}
+
+// attachGenesisActivations records the configured activation schedule in the genesis block
+func attachGenesisActivations(genesis *Block) error {
+	featuresMu.RLock()
+	defer featuresMu.RUnlock()
+	if len(genesisActivations) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(genesisActivations)
+	if err != nil {
+		return err
+	}
+	tx := NewTransaction("genesis_activations", GenesisSender, GenesisSender, 0, data)
+	tx.Type = GenesisActivationsTxType
+	tx.Timestamp = genesis.Timestamp
+	tx.Status = "confirmed"
+	tx.BlockIndex = 0
+	genesis.Transactions = append(genesis.Transactions, tx)
+	return nil
+}
+
+// applyGenesisActivationsLocked loads the activation schedule recorded in the
+// genesis block. The caller must hold bc.mu.
+func (bc *Blockchain) applyGenesisActivationsLocked(genesis *Block) error {
+	if bc.activations == nil {
+		bc.activations = make(map[Feature]Activation)
+	}
+	for _, tx := range genesis.Transactions {
+		if tx.Type != GenesisActivationsTxType {
+			continue
+		}
+		var schedule map[Feature]uint64
+		if err := json.Unmarshal(tx.Data, &schedule); err != nil {
+			return fmt.Errorf("%w: genesis schedule: %v", ErrInvalidActivation, err)
+		}
+		for feature, height := range schedule {
+			bc.activations[feature] = Activation{Feature: feature, Height: height, Source: "genesis"}
+		}
+	}
+	return nil
+}
+
+// ScheduleActivation activates a feature at a future height, as decided by governance
+func (bc *Blockchain) ScheduleActivation(feature Feature, height uint64, proposalID string) error {
+	if !FeatureKnown(feature) {
+		return fmt.Errorf("%w: %s", ErrUnknownFeature, feature)
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	next := uint64(len(bc.Blocks))
+	if height < next {
+		return fmt.Errorf("%w: activation height %d is below the next block %d", ErrInvalidActivation, height, next)
+	}
+	if existing, exists := bc.activations[feature]; exists && existing.Height <= next {
+		return fmt.Errorf("%w: %s is already active since height %d", ErrInvalidActivation, feature, existing.Height)
+	}
+
+	bc.activations[feature] = Activation{Feature: feature, Height: height, Source: "governance", ProposalID: proposalID}
+	if err := bc.saveActivationsLocked(GetBlockchainDataPath()); err != nil {
+		return err
+	}
+	log.Printf("Feature %s scheduled to activate at height %d", feature, height)
+	return nil
+}
+
+// FeatureActive reports whether a feature's rules apply to the block at height
+func (bc *Blockchain) FeatureActive(feature Feature, height uint64) bool {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.featureActiveLocked(feature, height)
+}
+
+// featureActiveLocked is FeatureActive for callers holding bc.mu
+func (bc *Blockchain) featureActiveLocked(feature Feature, height uint64) bool {
+	activation, exists := bc.activations[feature]
+	return exists && height >= activation.Height
+}
+
+// Activations returns every known or scheduled feature ordered by activation
+// height; features without an activation have height 0 and are inactive
+func (bc *Blockchain) Activations() []Activation {
+	bc.mu.RLock()
+	next := uint64(len(bc.Blocks))
+	result := make([]Activation, 0, len(bc.activations))
+	scheduled := make(map[Feature]bool, len(bc.activations))
+	for feature, activation := range bc.activations {
+		activation.Active = next >= activation.Height
+		result = append(result, activation)
+		scheduled[feature] = true
+	}
+	bc.mu.RUnlock()
+
+	featuresMu.RLock()
+	for feature := range features {
+		if !scheduled[feature] {
+			result = append(result, Activation{Feature: feature})
+		}
+	}
+	for i := range result {
+		description, known := features[result[i].Feature]
+		result[i].Description, result[i].Known = description, known
+	}
+	featuresMu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Source == "" || result[j].Source == "" {
+			return result[i].Source != "" // scheduled features first
+		}
+		if result[i].Height != result[j].Height {
+			return result[i].Height < result[j].Height
+		}
+		return result[i].Feature < result[j].Feature
+	})
+	return result
+}
+
+// checkActivationsLocked refuses blocks at or past the activation of a feature
+// this node does not implement: it cannot apply their rules. The caller must hold bc.mu.
+func (bc *Blockchain) checkActivationsLocked(height uint64) error {
+	for feature, activation := range bc.activations {
+		if height >= activation.Height && !FeatureKnown(feature) {
+			return fmt.Errorf("%w: feature %s is active from height %d and this node (version %s) does not implement it",
+				ErrUpgradeRequired, feature, activation.Height, NodeVersion)
+		}
+	}
+	return nil
+}
+
+// saveActivationsLocked writes the governance activations to dir; genesis
+// activations live in the genesis block. The caller must hold bc.mu.
+func (bc *Blockchain) saveActivationsLocked(dir string) error {
+	scheduled := make([]Activation, 0, len(bc.activations))
+	for _, activation := range bc.activations {
+		if activation.Source == "governance" {
+			scheduled = append(scheduled, activation)
+		}
+	}
+	data, err := json.MarshalIndent(scheduled, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal feature activations: %v", err)
+	}
+
+	path := filepath.Join(dir, activationsFile)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write feature activations: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadActivationsLocked reads the governance activations from dir. Genesis
+// activations take precedence. The caller must hold bc.mu.
+func (bc *Blockchain) loadActivationsLocked(dir string) {
+	raw, err := ioutil.ReadFile(filepath.Join(dir, activationsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to read feature activations: %v", err)
+		}
+		return
+	}
+	var scheduled []Activation
+	if err := json.Unmarshal(raw, &scheduled); err != nil {
+		log.Printf("Warning: Failed to parse feature activations: %v", err)
+		return
+	}
+	for _, activation := range scheduled {
+		if existing, exists := bc.activations[activation.Feature]; exists && existing.Source == "genesis" {
+			continue
+		}
+		activation.Source = "governance"
+		bc.activations[activation.Feature] = activation
+	}
+}
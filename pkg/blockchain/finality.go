@@ -0,0 +1,277 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// FeatureCommitCertificates lets blocks carry the commit certificate of their
+// parent: the pre-commits of more than two thirds of the validators, which
+// make the parent final
+const FeatureCommitCertificates Feature = "commit_certificates"
+
+// Vote types of the validator voting round of a block
+const (
+	VotePrevote   = "prevote"   // the validator accepted the block
+	VotePrecommit = "precommit" // the validator saw a pre-vote quorum and commits to the block
+)
+
+// voteSigningDomain separates consensus votes from other signatures
+const voteSigningDomain = "confirmix/vote"
+
+var (
+	// ErrInvalidVote is returned for consensus votes that fail validation
+	ErrInvalidVote = errors.New("invalid consensus vote")
+	// ErrInvalidCommitCertificate is returned for commit certificates that fail validation
+	ErrInvalidCommitCertificate = errors.New("invalid commit certificate")
+)
+
+func init() {
+	RegisterFeature(FeatureCommitCertificates, "blocks carry the validators' commit certificate of their parent, making it final")
+}
+
+// ConsensusVote is a validator's signed pre-vote or pre-commit for the block
+// at a height. Signature is hex over VoteSigningHash.
+type ConsensusVote struct {
+	Type      string `json:"type"`
+	Height    uint64 `json:"height"`
+	BlockHash string `json:"blockHash"`
+	Validator string `json:"validator"`
+	Signature string `json:"signature"`
+}
+
+// CommitCertificate proves that a quorum of validators pre-committed to a
+// block. Precommits maps validator addresses to their hex pre-commit signatures.
+type CommitCertificate struct {
+	Height     uint64            `json:"height"`
+	BlockHash  string            `json:"blockHash"`
+	Precommits map[string]string `json:"precommits"`
+}
+
+// FinalityStatus describes the newest final block known to the node
+type FinalityStatus struct {
+	Active          bool               `json:"active"` // blocks may carry commit certificates
+	Finalized       bool               `json:"finalized"`
+	FinalizedHeight uint64             `json:"finalizedHeight"`
+	FinalizedHash   string             `json:"finalizedHash,omitempty"`
+	Certificate     *CommitCertificate `json:"certificate,omitempty"`
+	Quorum          int                `json:"quorum"` // pre-commits a certificate needs
+}
+
+// finalityState is the newest certificate known to the node. Certificates
+// collected from votes are final at once; the next proposer records them in
+// its block so every node learns of them from the chain.
+type finalityState struct {
+	latest *CommitCertificate
+}
+
+// VoteQuorum is the number of votes out of validators that make a quorum:
+// more than two thirds
+func VoteQuorum(validators int) int {
+	return validators*2/3 + 1
+}
+
+// VoteSigningHash returns the digest a validator signs to vote for the block
+// with hash at height. It is bound to the vote type and the chain ID.
+func VoteSigningHash(voteType string, height uint64, blockHash string) ([]byte, error) {
+	if voteType != VotePrevote && voteType != VotePrecommit {
+		return nil, fmt.Errorf("%w: unknown vote type %q", ErrInvalidVote, voteType)
+	}
+	payload := append([]byte(voteType+"/"), blockSigningPayload(height, blockHash)...)
+	return signingDigest(voteSigningDomain, CurrentSigScheme, ChainID(), payload)
+}
+
+// NewConsensusVote creates the vote of validator for the block with hash at
+// height, signed with key
+func NewConsensusVote(voteType string, height uint64, blockHash, validator string, key PrivateKey) (*ConsensusVote, error) {
+	if key == nil {
+		return nil, errors.New("no private key to sign with")
+	}
+	digest, err := VoteSigningHash(voteType, height, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := key.Sign(digest)
+	if err != nil {
+		return nil, err
+	}
+	return &ConsensusVote{
+		Type:      voteType,
+		Height:    height,
+		BlockHash: blockHash,
+		Validator: validator,
+		Signature: hex.EncodeToString(signature),
+	}, nil
+}
+
+// verifyVoteSignatureLocked checks that validator is in the validator set and
+// signed a vote for the block with hash at height. The caller must hold bc.mu.
+func (bc *Blockchain) verifyVoteSignatureLocked(voteType string, height uint64, blockHash, validator, signature string) error {
+	if !bc.validators[validator] {
+		return fmt.Errorf("%w: %s is not a validator", ErrInvalidVote, validator)
+	}
+	keyPair, exists := bc.keyPairs[validator]
+	if !exists || keyPair.Public() == nil {
+		return fmt.Errorf("%w: the key of %s is unknown", ErrInvalidVote, validator)
+	}
+	digest, err := VoteSigningHash(voteType, height, blockHash)
+	if err != nil {
+		return err
+	}
+	sigBytes, err := hex.DecodeString(signature)
+	if err != nil || !keyPair.Public().Verify(digest, sigBytes) {
+		return fmt.Errorf("%w: %s vote of %s does not match block %d", ErrInvalidSignature, voteType, validator, height)
+	}
+	return nil
+}
+
+// VerifyVote checks the signature of a vote and that its validator is in the validator set
+func (bc *Blockchain) VerifyVote(vote *ConsensusVote) error {
+	if vote == nil || vote.BlockHash == "" {
+		return fmt.Errorf("%w: block hash is required", ErrInvalidVote)
+	}
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.verifyVoteSignatureLocked(vote.Type, vote.Height, vote.BlockHash, vote.Validator, vote.Signature)
+}
+
+// verifyCommitCertificateLocked checks that cert certifies a block of the
+// chain with valid pre-commits of a quorum of the current validators.
+// Pre-commits of validators outside the set do not count. The caller must
+// hold bc.mu.
+func (bc *Blockchain) verifyCommitCertificateLocked(cert *CommitCertificate) error {
+	if cert.Height >= uint64(len(bc.Blocks)) {
+		return fmt.Errorf("%w: block %d is not on the chain", ErrInvalidCommitCertificate, cert.Height)
+	}
+	if block := bc.Blocks[cert.Height]; block.Hash != cert.BlockHash {
+		return fmt.Errorf("%w: block %d is %s, not %s", ErrInvalidCommitCertificate, cert.Height, block.Hash, cert.BlockHash)
+	}
+	committed := 0
+	for validator, signature := range cert.Precommits {
+		if !bc.validators[validator] {
+			continue
+		}
+		if err := bc.verifyVoteSignatureLocked(VotePrecommit, cert.Height, cert.BlockHash, validator, signature); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidCommitCertificate, err)
+		}
+		committed++
+	}
+	if quorum := VoteQuorum(len(bc.validators)); committed < quorum {
+		return fmt.Errorf("%w: %d pre-commits, %d required", ErrInvalidCommitCertificate, committed, quorum)
+	}
+	return nil
+}
+
+// checkBlockCommitLocked validates the commit certificate a block carries,
+// which must certify its parent. The caller must hold bc.mu.
+func (bc *Blockchain) checkBlockCommitLocked(block *Block) error {
+	if block.Commit == nil {
+		return nil
+	}
+	if !bc.featureActiveLocked(FeatureCommitCertificates, block.Index) {
+		return fmt.Errorf("%w: commit certificates are not active at height %d", ErrInvalidCommitCertificate, block.Index)
+	}
+	if block.Index == 0 || block.Commit.Height != block.Index-1 || block.Commit.BlockHash != block.PrevHash {
+		return fmt.Errorf("%w: block %d must certify its parent", ErrInvalidCommitCertificate, block.Index)
+	}
+	return bc.verifyCommitCertificateLocked(block.Commit)
+}
+
+// recordFinalityLocked keeps cert if it finalizes a higher block than the
+// newest certificate known. The caller must hold bc.mu.
+func (bc *Blockchain) recordFinalityLocked(cert *CommitCertificate) {
+	if latest := bc.finality.latest; latest != nil && latest.Height >= cert.Height {
+		return
+	}
+	bc.finality.latest = copyCommitCertificate(cert)
+}
+
+// rebuildFinalityLocked finds the newest commit certificate recorded on the
+// chain. The caller must hold bc.mu.
+func (bc *Blockchain) rebuildFinalityLocked() {
+	bc.finality = finalityState{}
+	for i := len(bc.Blocks) - 1; i >= 0; i-- {
+		if cert := bc.Blocks[i].Commit; cert != nil {
+			bc.finality.latest = copyCommitCertificate(cert)
+			return
+		}
+	}
+}
+
+// RecordCommitCertificate verifies a certificate collected from validator
+// votes and marks its block final. The next block this node proposes carries
+// it when it certifies the block's parent.
+func (bc *Blockchain) RecordCommitCertificate(cert *CommitCertificate) error {
+	if cert == nil {
+		return fmt.Errorf("%w: certificate is required", ErrInvalidCommitCertificate)
+	}
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if err := bc.verifyCommitCertificateLocked(cert); err != nil {
+		return err
+	}
+	bc.recordFinalityLocked(cert)
+	return nil
+}
+
+// AttachCommitCertificate embeds the commit certificate of the block's parent,
+// when the node has one and certificates are active, and rehashes the block.
+// Call it before signing.
+func (bc *Blockchain) AttachCommitCertificate(block *Block) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	latest := bc.finality.latest
+	if latest == nil || block.Index == 0 || latest.Height != block.Index-1 || latest.BlockHash != block.PrevHash {
+		return
+	}
+	if !bc.featureActiveLocked(FeatureCommitCertificates, block.Index) {
+		return
+	}
+	block.Commit = copyCommitCertificate(latest)
+	block.Hash = block.CalculateHash()
+}
+
+// IsFinal reports whether the block at height is final: it is at or below the
+// newest block certified by a quorum of validators
+func (bc *Blockchain) IsFinal(height uint64) bool {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	latest := bc.finality.latest
+	return latest != nil && height <= latest.Height
+}
+
+// Finality returns the newest final block known to the node
+func (bc *Blockchain) Finality() FinalityStatus {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	status := FinalityStatus{
+		Active: bc.featureActiveLocked(FeatureCommitCertificates, uint64(len(bc.Blocks))),
+		Quorum: VoteQuorum(len(bc.validators)),
+	}
+	if latest := bc.finality.latest; latest != nil {
+		status.Finalized = true
+		status.FinalizedHeight = latest.Height
+		status.FinalizedHash = latest.BlockHash
+		status.Certificate = copyCommitCertificate(latest)
+	}
+	return status
+}
+
+// copyCommitCertificate returns a deep copy of cert
+func copyCommitCertificate(cert *CommitCertificate) *CommitCertificate {
+	copied := *cert
+	copied.Precommits = make(map[string]string, len(cert.Precommits))
+	for validator, signature := range cert.Precommits {
+		copied.Precommits[validator] = signature
+	}
+	return &copied
+}
+
+// commitBytes is the form in which a commit certificate enters the block
+// hash. JSON sorts the pre-commits by validator, so it is deterministic.
+func commitBytes(cert *CommitCertificate) []byte {
+	data, _ := json.Marshal(cert)
+	return data
+}
@@ -0,0 +1,173 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// GenesisAllocationTxType is the transaction type that credits initial supply in the genesis block
+const GenesisAllocationTxType = "genesis_allocation"
+
+// GenesisSender is the symbolic sender of genesis allocations
+const GenesisSender = "genesis"
+
+// ErrInvalidGenesisAllocation is returned for malformed allocations or allocations outside block 0
+var ErrInvalidGenesisAllocation = errors.New("invalid genesis allocation")
+
+// GenesisAllocation is the payload (Transaction.Data) of a genesis allocation transaction.
+// Amounts are decimal strings because initial balances exceed the range of Transaction.Value.
+type GenesisAllocation struct {
+	Address string `json:"address"`
+	Amount  string `json:"amount"`
+	Label   string `json:"label,omitempty"`
+}
+
+// GenesisInfo describes block 0 and the initial supply it allocated
+type GenesisInfo struct {
+	Hash        string              `json:"hash"`
+	Timestamp   int64               `json:"timestamp"`
+	Validator   string              `json:"validator"`
+	Allocations []GenesisAllocation `json:"allocations"`
+	TotalSupply string              `json:"totalSupply"`
+	Activations map[Feature]uint64  `json:"activations,omitempty"`
+	// Emission schedule of the chain, the default one when genesis records none
+	RewardSchedule RewardSchedule `json:"rewardSchedule"`
+}
+
+// NewGenesisAllocationTransaction creates the transaction that credits amount to address at genesis
+func NewGenesisAllocationTransaction(index int, allocation GenesisAllocation, timestamp int64) (*Transaction, error) {
+	if _, err := allocation.amount(); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(allocation)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := NewTransaction(
+		fmt.Sprintf("genesis_%d_%s", index, allocation.Address),
+		GenesisSender,
+		allocation.Address,
+		0, // the amount is carried in the payload
+		data,
+	)
+	tx.Type = GenesisAllocationTxType
+	tx.Timestamp = timestamp
+	tx.Status = "confirmed"
+	tx.BlockIndex = 0
+	return tx, nil
+}
+
+// amount parses and validates the allocated amount
+func (a GenesisAllocation) amount() (*big.Int, error) {
+	if a.Address == "" {
+		return nil, fmt.Errorf("%w: address is required", ErrInvalidGenesisAllocation)
+	}
+	amount, ok := new(big.Int).SetString(a.Amount, 10)
+	if !ok || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: amount for %s must be a positive integer, got %q", ErrInvalidGenesisAllocation, a.Address, a.Amount)
+	}
+	return amount, nil
+}
+
+// ParseGenesisAllocation decodes and validates the payload of a genesis allocation transaction
+func ParseGenesisAllocation(tx *Transaction) (*GenesisAllocation, *big.Int, error) {
+	if tx == nil || tx.Type != GenesisAllocationTxType {
+		return nil, nil, fmt.Errorf("%w: not a genesis allocation transaction", ErrInvalidGenesisAllocation)
+	}
+
+	var allocation GenesisAllocation
+	if err := json.Unmarshal(tx.Data, &allocation); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidGenesisAllocation, err)
+	}
+	if allocation.Address != tx.To {
+		return nil, nil, fmt.Errorf("%w: payload address %s does not match recipient %s", ErrInvalidGenesisAllocation, allocation.Address, tx.To)
+	}
+	amount, err := allocation.amount()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &allocation, amount, nil
+}
+
+// attachGenesisAllocations adds one allocation transaction per entry to the genesis block
+func attachGenesisAllocations(genesis *Block, allocations []GenesisAllocation) error {
+	for i, allocation := range allocations {
+		tx, err := NewGenesisAllocationTransaction(i, allocation, genesis.Timestamp)
+		if err != nil {
+			return err
+		}
+		genesis.Transactions = append(genesis.Transactions, tx)
+	}
+	return nil
+}
+
+// applyGenesisLocked credits the allocations of the genesis block to their accounts.
+// The caller must hold bc.mu.
+func (bc *Blockchain) applyGenesisLocked(genesis *Block) error {
+	bc.genesisSupply = big.NewInt(0)
+	for _, tx := range genesis.Transactions {
+		if tx.Type != GenesisAllocationTxType {
+			continue
+		}
+		allocation, amount, err := ParseGenesisAllocation(tx)
+		if err != nil {
+			return err
+		}
+		balance, exists := bc.accounts[allocation.Address]
+		if !exists {
+			balance = big.NewInt(0)
+		}
+		bc.accounts[allocation.Address] = new(big.Int).Add(balance, amount)
+		bc.richIndex.touch(allocation.Address)
+		bc.genesisSupply.Add(bc.genesisSupply, amount)
+	}
+	if err := bc.applyGenesisActivationsLocked(genesis); err != nil {
+		return err
+	}
+	if err := bc.applyGenesisRewardScheduleLocked(genesis); err != nil {
+		return err
+	}
+	return bc.checkGenesisSupplyLocked()
+}
+
+// GetGenesisInfo returns the genesis block and the allocations it made
+func (bc *Blockchain) GetGenesisInfo() (*GenesisInfo, error) {
+	genesis, err := bc.GetBlockByIndex(0)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &GenesisInfo{
+		Hash:        genesis.Hash,
+		Timestamp:   genesis.Timestamp,
+		Validator:   genesis.Validator,
+		Allocations: []GenesisAllocation{},
+	}
+	total := big.NewInt(0)
+	for _, tx := range genesis.Transactions {
+		if tx.Type == GenesisActivationsTxType {
+			if err := json.Unmarshal(tx.Data, &info.Activations); err != nil {
+				return nil, fmt.Errorf("%w: genesis schedule: %v", ErrInvalidActivation, err)
+			}
+			continue
+		}
+		if tx.Type != GenesisAllocationTxType {
+			continue
+		}
+		allocation, amount, err := ParseGenesisAllocation(tx)
+		if err != nil {
+			return nil, err
+		}
+		info.Allocations = append(info.Allocations, *allocation)
+		total.Add(total, amount)
+	}
+	info.TotalSupply = total.String()
+	if info.RewardSchedule, err = genesisRewardScheduleOf(genesis); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
@@ -0,0 +1,51 @@
+package blockchain
+
+// BlockHeader is the compact view of a block without transaction bodies
+type BlockHeader struct {
+	Index     uint64 `json:"index"`
+	Hash      string `json:"hash"`
+	PrevHash  string `json:"prevHash"`
+	Validator string `json:"validator"`
+	Timestamp int64  `json:"timestamp"`
+	TxCount   int    `json:"txCount"`
+
+	Commit *CommitCertificate `json:"commit,omitempty"` // Commit certificate of the parent block
+}
+
+// Header returns the compact header of the block
+func (b *Block) Header() BlockHeader {
+	txCount := len(b.Transactions)
+	if b.IsPruned() {
+		txCount = b.TxCount
+	}
+	return BlockHeader{
+		Index:     b.Index,
+		Hash:      b.Hash,
+		PrevHash:  b.PrevHash,
+		Validator: b.Validator,
+		Timestamp: b.Timestamp,
+		TxCount:   txCount,
+		Commit:    b.Commit,
+	}
+}
+
+// GetHeaders returns up to count headers starting at block index from
+func (bc *Blockchain) GetHeaders(from uint64, count int) []BlockHeader {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if count <= 0 || from >= uint64(len(bc.Blocks)) {
+		return []BlockHeader{}
+	}
+
+	end := from + uint64(count)
+	if end > uint64(len(bc.Blocks)) {
+		end = uint64(len(bc.Blocks))
+	}
+
+	headers := make([]BlockHeader, 0, end-from)
+	for _, block := range bc.Blocks[from:end] {
+		headers = append(headers, block.Header())
+	}
+	return headers
+}
@@ -0,0 +1,296 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// HumanProofTxType is the transaction type that records a human proof on chain
+const HumanProofTxType = "human_proof"
+
+// humanProofSigningDomain separates human proof attestations from other signatures
+const humanProofSigningDomain = "confirmix/human-proof"
+
+// HumanProofRegistration is the payload (Transaction.Data) of a human proof transaction.
+// Attester is the validator that checked the proof with the provider, and
+// Attestation its hex signature over HumanProofAttestationHash.
+type HumanProofRegistration struct {
+	Address     string `json:"address"`
+	Proof       string `json:"proof"`
+	Provider    string `json:"provider,omitempty"`
+	Attester    string `json:"attester,omitempty"`
+	Attestation string `json:"attestation,omitempty"`
+}
+
+// HumanProofRecord is an entry of the on-chain human proof registry
+type HumanProofRecord struct {
+	Address    string `json:"address"`
+	Proof      string `json:"proof"`
+	Provider   string `json:"provider,omitempty"`
+	TxID       string `json:"txId"`
+	BlockIndex uint64 `json:"blockIndex"`
+	BlockHash  string `json:"blockHash"`
+	Timestamp  int64  `json:"timestamp"`
+}
+
+// HumanProofAttestationHash returns the digest a validator signs to attest
+// that proof of address was checked with provider. It is bound to the chain
+// ID, so an attestation cannot be replayed on another network.
+func HumanProofAttestationHash(address, proof, provider string) ([]byte, error) {
+	payload, err := json.Marshal(struct {
+		Address  string `json:"address"`
+		Proof    string `json:"proof"`
+		Provider string `json:"provider"`
+	}{address, proof, provider})
+	if err != nil {
+		return nil, err
+	}
+	return signingDigest(humanProofSigningDomain, CurrentSigScheme, ChainID(), payload)
+}
+
+// NewHumanProofTransaction creates a transaction that records an attested registration.
+// The registration is made by the address itself, so the transaction must be signed with its key.
+func NewHumanProofTransaction(reg HumanProofRegistration, keyPair *KeyPair) (*Transaction, error) {
+	if reg.Address == "" {
+		return nil, errors.New("address is required")
+	}
+	if reg.Proof == "" {
+		return nil, ErrHumanProofRequired
+	}
+	if keyPair == nil || keyPair.Signer() == nil {
+		return nil, fmt.Errorf("%w: the registration of %s must be signed with its own key", ErrKeyPairNotFound, reg.Address)
+	}
+
+	data, err := json.Marshal(reg)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := NewTransaction(
+		fmt.Sprintf("human_proof_%s_%d", reg.Address, time.Now().UnixNano()),
+		reg.Address,
+		reg.Address,
+		0, // registrations carry no value
+		data,
+	)
+	tx.Type = HumanProofTxType
+
+	if err := tx.SignWith(keyPair.Signer()); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// ParseHumanProofRegistration decodes and validates the payload of a human proof transaction
+func ParseHumanProofRegistration(tx *Transaction) (*HumanProofRegistration, error) {
+	if tx == nil || tx.Type != HumanProofTxType {
+		return nil, errors.New("not a human proof transaction")
+	}
+
+	var reg HumanProofRegistration
+	if err := json.Unmarshal(tx.Data, &reg); err != nil {
+		return nil, fmt.Errorf("invalid human proof payload: %v", err)
+	}
+	if reg.Proof == "" {
+		return nil, ErrHumanProofRequired
+	}
+	if reg.Address != tx.From {
+		return nil, fmt.Errorf("human proof for %s must be registered by the address itself, not %s", reg.Address, tx.From)
+	}
+	return &reg, nil
+}
+
+// checkHumanProofLocked validates a human proof transaction for inclusion in
+// the next block: the payload must be well formed and attested by a current
+// validator other than the registrant. The caller must hold bc.mu.
+func (bc *Blockchain) checkHumanProofLocked(tx *Transaction) error {
+	reg, err := ParseHumanProofRegistration(tx)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidHumanProof, err)
+	}
+	if reg.Attester == "" || reg.Attestation == "" {
+		return fmt.Errorf("%w: the proof of %s is not attested by a validator", ErrInvalidHumanProof, reg.Address)
+	}
+	if reg.Attester == reg.Address {
+		return fmt.Errorf("%w: %s cannot attest its own proof", ErrInvalidHumanProof, reg.Address)
+	}
+	if !bc.validators[reg.Attester] {
+		return fmt.Errorf("%w: attester %s is not a validator", ErrInvalidHumanProof, reg.Attester)
+	}
+	keyPair, exists := bc.keyPairs[reg.Attester]
+	if !exists || keyPair.Public() == nil {
+		return fmt.Errorf("%w: no public key for attester %s", ErrKeyPairNotFound, reg.Attester)
+	}
+
+	digest, err := HumanProofAttestationHash(reg.Address, reg.Proof, reg.Provider)
+	if err != nil {
+		return err
+	}
+	signature, err := hex.DecodeString(reg.Attestation)
+	if err != nil || !keyPair.Public().Verify(digest, signature) {
+		return fmt.Errorf("%w: attestation of %s does not match the proof", ErrInvalidSignature, reg.Attester)
+	}
+	return nil
+}
+
+// attestHumanProofLocked signs reg as a validator whose key this node holds,
+// other than the registrant. The caller must hold bc.mu.
+func (bc *Blockchain) attestHumanProofLocked(reg *HumanProofRegistration) error {
+	validators := make([]string, 0, len(bc.validators))
+	for validator, active := range bc.validators {
+		if active && validator != reg.Address {
+			validators = append(validators, validator)
+		}
+	}
+	sort.Strings(validators)
+
+	for _, validator := range validators {
+		keyPair, exists := bc.keyPairs[validator]
+		if !exists || keyPair.Signer() == nil {
+			continue
+		}
+		digest, err := HumanProofAttestationHash(reg.Address, reg.Proof, reg.Provider)
+		if err != nil {
+			return err
+		}
+		signature, err := keyPair.Signer().Sign(digest)
+		if err != nil {
+			return err
+		}
+		reg.Attester = validator
+		reg.Attestation = hex.EncodeToString(signature)
+		return nil
+	}
+	return fmt.Errorf("no other validator's key on this node to attest the proof of %s", reg.Address)
+}
+
+// queueHumanProofLocked records proof for address on chain, so it reaches
+// every node with the next block. This node attests the proof, which it has
+// checked, and submits the registration when it also holds the key of
+// address. Otherwise the attested registration is kept for the validator to
+// sign and submit itself (see GetHumanProofAttestation).
+// The caller must hold bc.mu.
+func (bc *Blockchain) queueHumanProofLocked(address, proof string) {
+	reg := HumanProofRegistration{Address: address, Proof: proof}
+	if err := bc.attestHumanProofLocked(&reg); err != nil {
+		log.Printf("Warning: Human proof of %s stays local to this node: %v", address, err)
+		return
+	}
+
+	keyPair, exists := bc.keyPairs[address]
+	if !exists || keyPair.Signer() == nil {
+		if bc.humanProofAttestations == nil {
+			bc.humanProofAttestations = make(map[string]*HumanProofRegistration)
+		}
+		bc.humanProofAttestations[address] = &reg
+		log.Printf("Human proof of %s attested by %s, waiting for the validator to submit it", address, reg.Attester)
+		return
+	}
+
+	tx, err := NewHumanProofTransaction(reg, keyPair)
+	if err != nil {
+		log.Printf("Warning: Failed to create human proof registration for %s: %v", address, err)
+		return
+	}
+	if err := bc.addTransactionLocked(tx); err != nil {
+		log.Printf("Warning: Failed to queue human proof registration for %s: %v", address, err)
+	}
+}
+
+// GetHumanProofAttestation returns the registration this node attested for
+// address, for the validator to sign with its own key and submit
+func (bc *Blockchain) GetHumanProofAttestation(address string) (*HumanProofRegistration, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	reg, exists := bc.humanProofAttestations[address]
+	if !exists {
+		return nil, false
+	}
+	copied := *reg
+	return &copied, true
+}
+
+// applyHumanProofLocked records the registration carried by tx in the registry.
+// The caller must hold bc.mu.
+func (bc *Blockchain) applyHumanProofLocked(tx *Transaction, block *Block) error {
+	reg, err := ParseHumanProofRegistration(tx)
+	if err != nil {
+		return err
+	}
+
+	bc.humanProofRegistry[reg.Address] = &HumanProofRecord{
+		Address:    reg.Address,
+		Proof:      reg.Proof,
+		Provider:   reg.Provider,
+		TxID:       tx.ID,
+		BlockIndex: block.Index,
+		BlockHash:  block.Hash,
+		Timestamp:  tx.Timestamp,
+	}
+	bc.humanProofs[reg.Address] = reg.Proof
+	delete(bc.humanProofAttestations, reg.Address)
+	return nil
+}
+
+// rebuildHumanProofRegistryLocked replays every human proof transaction of the chain.
+// Proofs recorded on chain take precedence over node-local registrations.
+// The caller must hold bc.mu.
+func (bc *Blockchain) rebuildHumanProofRegistryLocked() {
+	bc.humanProofRegistry = make(map[string]*HumanProofRecord)
+	for _, block := range bc.Blocks {
+		for _, tx := range block.Transactions {
+			if tx.Type != HumanProofTxType || tx.Status == "failed" {
+				continue
+			}
+			if err := bc.applyHumanProofLocked(tx, block); err != nil {
+				log.Printf("Warning: Skipping invalid human proof transaction %s: %v", tx.ID, err)
+			}
+		}
+	}
+}
+
+// humanProofForLocked returns the proof block validation expects for address,
+// preferring the on-chain record over a node-local registration. The caller must hold bc.mu.
+func (bc *Blockchain) humanProofForLocked(address string) string {
+	if record, exists := bc.humanProofRegistry[address]; exists {
+		return record.Proof
+	}
+	return bc.humanProofs[address]
+}
+
+// GetHumanProofRecord returns the on-chain human proof record of an address
+func (bc *Blockchain) GetHumanProofRecord(address string) (*HumanProofRecord, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	record, exists := bc.humanProofRegistry[address]
+	if !exists {
+		return nil, false
+	}
+	copied := *record
+	return &copied, true
+}
+
+// GetHumanProofRegistry returns all on-chain human proof records ordered by block index
+func (bc *Blockchain) GetHumanProofRegistry() []HumanProofRecord {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	records := make([]HumanProofRecord, 0, len(bc.humanProofRegistry))
+	for _, record := range bc.humanProofRegistry {
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].BlockIndex != records[j].BlockIndex {
+			return records[i].BlockIndex < records[j].BlockIndex
+		}
+		return records[i].Address < records[j].Address
+	})
+	return records
+}
@@ -0,0 +1,337 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// stateChecksumsFile holds the sha256 of each of StateFiles as last saved
+const stateChecksumsFile = "checksums.json"
+
+// CorruptionReportFile is where the last failed integrity check is reported
+const CorruptionReportFile = "corruption_report.json"
+
+// maxBadRecords caps the invalid records listed per file in a report
+const maxBadRecords = 20
+
+// ErrStorageCorrupted is returned when the persisted state fails its integrity check
+var ErrStorageCorrupted = errors.New("storage is corrupted")
+
+// File states in a StorageReport
+const (
+	FileOK               = "ok"
+	FileMissing          = "missing"
+	FileUnreadable       = "unreadable"
+	FileUnparseable      = "unparseable"
+	FileChecksumMismatch = "checksum_mismatch"
+	FileInvalidRecords   = "invalid_records"
+)
+
+// FileIntegrity is the result of checking one state file
+type FileIntegrity struct {
+	Name       string   `json:"name"`
+	Status     string   `json:"status"`
+	Detail     string   `json:"detail,omitempty"`
+	BadRecords []string `json:"badRecords,omitempty"` // keys of invalid records, at most maxBadRecords
+}
+
+// StorageReport is the integrity of the persisted chain state and what can
+// be done about it
+type StorageReport struct {
+	DataDir   string          `json:"dataDir"`
+	CheckedAt int64           `json:"checkedAt"`
+	Corrupted bool            `json:"corrupted"`
+	Verified  bool            `json:"verified"` // checksums were on disk to compare with
+	Files     []FileIntegrity `json:"files"`
+	Recovery  []string        `json:"recovery,omitempty"`
+}
+
+// Summary lists the files that failed the check
+func (r *StorageReport) Summary() string {
+	var failed []string
+	for _, file := range r.Files {
+		if file.Status != FileOK && file.Status != FileMissing {
+			failed = append(failed, fmt.Sprintf("%s: %s", file.Name, strings.Replace(file.Status, "_", " ", -1)))
+		}
+	}
+	return strings.Join(failed, ", ")
+}
+
+// fileStatus returns the status of the named file
+func (r *StorageReport) fileStatus(name string) string {
+	for _, file := range r.Files {
+		if file.Name == name {
+			return file.Status
+		}
+	}
+	return FileMissing
+}
+
+// checksumState returns the sha256 of each state file's contents
+func checksumState(files map[string][]byte) map[string]string {
+	sums := make(map[string]string, len(files))
+	for name, content := range files {
+		sum := sha256.Sum256(content)
+		sums[name] = hex.EncodeToString(sum[:])
+	}
+	return sums
+}
+
+// writeStateChecksums records the checksums of the state files just written
+func writeStateChecksums(dataDir string, files map[string][]byte) error {
+	data, err := json.MarshalIndent(checksumState(files), "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dataDir, stateChecksumsFile), data)
+}
+
+// WriteStateChecksums records the checksums of the state files as they are
+// now in dataDir, e.g. after restoring them from a backup
+func WriteStateChecksums(dataDir string) error {
+	files := make(map[string][]byte, len(StateFiles))
+	for _, name := range StateFiles {
+		content, err := ioutil.ReadFile(filepath.Join(dataDir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		files[name] = content
+	}
+	return writeStateChecksums(dataDir, files)
+}
+
+// writeFileAtomic replaces path with data, so a crash leaves the old or the new file
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// CheckStorage verifies the state files in dataDir: each must match the
+// checksum recorded when it was saved, parse, and hold valid records. It
+// reads the files only and can run before the node starts.
+func CheckStorage(dataDir string) *StorageReport {
+	report := &StorageReport{DataDir: dataDir, CheckedAt: time.Now().Unix()}
+
+	var sums map[string]string
+	if data, err := ioutil.ReadFile(filepath.Join(dataDir, stateChecksumsFile)); err == nil {
+		if json.Unmarshal(data, &sums) == nil {
+			report.Verified = true
+		}
+	}
+
+	for _, name := range StateFiles {
+		file := FileIntegrity{Name: name, Status: FileOK}
+		content, err := ioutil.ReadFile(filepath.Join(dataDir, name))
+		switch {
+		case os.IsNotExist(err):
+			file.Status = FileMissing
+			if sums[name] != "" {
+				// It was saved before, so it went missing since
+				file.Status = FileUnreadable
+				file.Detail = "file was saved but is gone"
+			}
+		case err != nil:
+			file.Status = FileUnreadable
+			file.Detail = err.Error()
+		default:
+			checkStateFile(name, content, &file)
+			if file.Status == FileOK && sums[name] != "" {
+				if sum := sha256.Sum256(content); hex.EncodeToString(sum[:]) != sums[name] {
+					file.Status = FileChecksumMismatch
+					file.Detail = "contents changed since the node saved them"
+				}
+			}
+		}
+		if file.Status != FileOK && file.Status != FileMissing {
+			report.Corrupted = true
+		}
+		report.Files = append(report.Files, file)
+	}
+
+	if report.Corrupted {
+		report.Recovery = recoveryOptions(report)
+	}
+	return report
+}
+
+// checkStateFile parses a state file and validates its records
+func checkStateFile(name string, content []byte, file *FileIntegrity) {
+	var err error
+	switch name {
+	case "blocks.json":
+		var blocks []*Block
+		if err = json.Unmarshal(content, &blocks); err == nil {
+			file.BadRecords = checkBlockLinks(blocks)
+		}
+	case "accounts.json":
+		var accounts map[string]string
+		if err = json.Unmarshal(content, &accounts); err == nil {
+			file.BadRecords = checkBalances(accounts)
+		}
+	case "validators.json":
+		var validators map[string]string
+		err = json.Unmarshal(content, &validators)
+	case "multisig.json":
+		var wallets map[string]*MultiSigWallet
+		err = json.Unmarshal(content, &wallets)
+	}
+	if err != nil {
+		file.Status = FileUnparseable
+		file.Detail = err.Error()
+		return
+	}
+	if len(file.BadRecords) > 0 {
+		file.Status = FileInvalidRecords
+		if len(file.BadRecords) > maxBadRecords {
+			file.Detail = fmt.Sprintf("%d invalid records, the first %d are listed", len(file.BadRecords), maxBadRecords)
+			file.BadRecords = file.BadRecords[:maxBadRecords]
+		}
+	}
+}
+
+// checkBlockLinks returns the blocks that do not follow their predecessor
+func checkBlockLinks(blocks []*Block) []string {
+	var bad []string
+	for i, block := range blocks {
+		switch {
+		case block == nil:
+			bad = append(bad, fmt.Sprintf("block %d: empty", i))
+		case block.Index != uint64(i):
+			bad = append(bad, fmt.Sprintf("block %d: has index %d", i, block.Index))
+		case i > 0 && blocks[i-1] != nil && block.PrevHash != blocks[i-1].Hash:
+			bad = append(bad, fmt.Sprintf("block %d: previous hash does not match block %d", i, i-1))
+		}
+	}
+	return bad
+}
+
+// checkBalances returns the accounts whose balance is not a non-negative integer
+func checkBalances(accounts map[string]string) []string {
+	var bad []string
+	for address, balance := range accounts {
+		value, ok := new(big.Int).SetString(balance, 10)
+		if !ok || value.Sign() < 0 {
+			bad = append(bad, address)
+		}
+	}
+	sort.Strings(bad)
+	return bad
+}
+
+// recoveryOptions suggests how to repair the state of a corrupted report
+func recoveryOptions(report *StorageReport) []string {
+	var options []string
+	if report.fileStatus("blocks.json") == FileOK {
+		options = append(options, "rebuild the account balances from the blocks: blockchain storage reindex")
+	}
+	options = append(options, "restore the newest backup: blockchain backup restore")
+	return options
+}
+
+// saveCorruptionReport writes report to the data directory for operators
+func saveCorruptionReport(dataDir string, report *StorageReport) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := ioutil.WriteFile(filepath.Join(dataDir, CorruptionReportFile), data, 0644); err != nil {
+		log.Printf("Failed to write the corruption report: %v", err)
+	}
+}
+
+// ReindexStorage rebuilds the account balances in dataDir by replaying the
+// blocks, for when accounts.json is corrupted but blocks.json is intact.
+// Node-local files that fail their check are skipped. The repaired state is
+// saved with fresh checksums and returned. The node must not be running.
+func ReindexStorage(dataDir string) (*Blockchain, error) {
+	bc := newBlockchainState()
+	bc.mu.Lock()
+	err := bc.loadFromDiskLocked(dataDir, true)
+	bc.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := bc.SaveToDisk(); err != nil {
+		return nil, err
+	}
+	os.Remove(filepath.Join(dataDir, CorruptionReportFile))
+	return bc, nil
+}
+
+// reindexAccountsLocked recomputes every balance from the genesis allocations
+// and the confirmed transactions of the chain. Contract state is not
+// persisted and is not replayed. The caller must hold bc.mu.
+func (bc *Blockchain) reindexAccountsLocked() error {
+	bc.accounts = make(map[string]*big.Int)
+	bc.richIndex.reset()
+	bc.staking = newStakingLedger()
+	bc.lockedBalances = make(map[string]*big.Int)
+	if len(bc.Blocks) == 0 {
+		return nil
+	}
+
+	for _, tx := range bc.Blocks[0].Transactions {
+		if tx.Type != GenesisAllocationTxType {
+			continue
+		}
+		allocation, amount, err := ParseGenesisAllocation(tx)
+		if err != nil {
+			return err
+		}
+		bc.creditLocked(allocation.Address, amount)
+	}
+
+	for _, block := range bc.Blocks[1:] {
+		bc.completeUnbondingsLocked(block.Index, true)
+		for _, tx := range block.Transactions {
+			if tx.Status != "confirmed" {
+				continue
+			}
+			var err error
+			switch {
+			case tx.Type == HumanProofTxType, tx.Type == SlashEvidenceTxType, tx.Type == ValidatorSlashTxType, tx.Type == CircuitBreakerTxType, tx.Type == ValidatorCommissionTxType, isOracleTransaction(tx):
+				// Registry changes, no balances move
+			case isStakingTransaction(tx):
+				// Stake moves with the staking ledger below
+			case tx.Type == RewardTxType:
+				bc.creditLocked(tx.To, new(big.Int).SetUint64(tx.Value))
+			case tx.Type == EpochRewardTxType:
+				err = bc.applyEpochRewardLocked(tx)
+			default:
+				err = bc.UpdateBalances(tx)
+			}
+			if err != nil {
+				return fmt.Errorf("block %d, transaction %s: %v", block.Index, tx.ID, err)
+			}
+			bc.replayStakingLocked(tx, block, true)
+		}
+	}
+	return nil
+}
+
+// creditLocked adds amount to address. The caller must hold bc.mu.
+func (bc *Blockchain) creditLocked(address string, amount *big.Int) {
+	balance, exists := bc.accounts[address]
+	if !exists {
+		balance = big.NewInt(0)
+	}
+	bc.accounts[address] = new(big.Int).Add(balance, amount)
+	bc.richIndex.touch(address)
+}
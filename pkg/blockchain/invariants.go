@@ -0,0 +1,99 @@
+package blockchain
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// Invariants checked by CheckInvariants
+const (
+	InvariantNonNegativeBalance = "non_negative_balance" // no account or locked balance is below zero
+	InvariantSingleTip          = "single_tip"           // the blocks form one chain ending in the latest block
+)
+
+// maxInvariantViolations caps the violations reported per invariant
+const maxInvariantViolations = 10
+
+// InvariantViolation is a broken chain invariant
+type InvariantViolation struct {
+	Invariant string `json:"invariant"`
+	Height    uint64 `json:"height"` // chain height when the check ran
+	Detail    string `json:"detail"`
+}
+
+// CheckInvariants verifies properties that must hold whatever blocks,
+// messages or faults the node has seen: balances are never negative and every
+// block links to the one before it, so the node has a single chain tip. Soak
+// tests run it while faults are injected, see the chaos package.
+func (bc *Blockchain) CheckInvariants() []InvariantViolation {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	height := uint64(0)
+	if len(bc.Blocks) > 0 {
+		height = uint64(len(bc.Blocks)) - 1
+	}
+	violations := []InvariantViolation{}
+	add := func(invariant, format string, args ...interface{}) {
+		violations = append(violations, InvariantViolation{
+			Invariant: invariant,
+			Height:    height,
+			Detail:    fmt.Sprintf(format, args...),
+		})
+	}
+
+	negative := 0
+	for _, balances := range []struct {
+		kind     string
+		accounts map[string]*big.Int
+	}{
+		{"balance", bc.accounts},
+		{"locked balance", bc.lockedBalances},
+	} {
+		addresses := make([]string, 0)
+		for address, balance := range balances.accounts {
+			if balance != nil && balance.Sign() < 0 {
+				addresses = append(addresses, address)
+			}
+		}
+		sort.Strings(addresses)
+		for _, address := range addresses {
+			if negative++; negative > maxInvariantViolations {
+				break
+			}
+			add(InvariantNonNegativeBalance, "%s of %s is %s", balances.kind, address, balances.accounts[address])
+		}
+	}
+
+	if len(bc.Blocks) == 0 {
+		add(InvariantSingleTip, "the chain has no genesis block")
+		return violations
+	}
+	broken := 0
+	seen := make(map[string]uint64, len(bc.Blocks))
+	for i, block := range bc.Blocks {
+		var detail string
+		switch {
+		case block == nil:
+			detail = fmt.Sprintf("block %d is missing", i)
+		case block.Index != uint64(i):
+			detail = fmt.Sprintf("block at position %d has index %d", i, block.Index)
+		case i > 0 && bc.Blocks[i-1] != nil && block.PrevHash != bc.Blocks[i-1].Hash:
+			detail = fmt.Sprintf("block %d links to %s instead of block %d (%s)", i, block.PrevHash, i-1, bc.Blocks[i-1].Hash)
+		default:
+			if first, exists := seen[block.Hash]; exists {
+				detail = fmt.Sprintf("blocks %d and %d have the same hash %s", first, i, block.Hash)
+			}
+			seen[block.Hash] = uint64(i)
+		}
+		if detail == "" {
+			continue
+		}
+		if broken++; broken > maxInvariantViolations {
+			break
+		}
+		add(InvariantSingleTip, "%s", detail)
+	}
+	return violations
+}
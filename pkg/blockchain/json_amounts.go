@@ -0,0 +1,66 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"confirmix/pkg/units"
+)
+
+// The API renders amounts as decimal strings of base units while the node
+// stores them and sends them to peers as JSON numbers. The decoders below
+// accept both, so API clients can read responses into these types.
+
+// UnmarshalJSON decodes a transaction whose value is a number or a string
+func (tx *Transaction) UnmarshalJSON(data []byte) error {
+	type plain Transaction
+	aux := struct {
+		*plain
+		Value json.RawMessage `json:"value"`
+	}{plain: (*plain)(tx)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	value, err := units.ParseJSONUint64(aux.Value)
+	if err != nil {
+		return fmt.Errorf("transaction value: %w", err)
+	}
+	tx.Value = value
+	return nil
+}
+
+// UnmarshalJSON decodes a block whose reward is a number or a string
+func (b *Block) UnmarshalJSON(data []byte) error {
+	type plain Block
+	aux := struct {
+		*plain
+		Reward json.RawMessage `json:"reward"`
+	}{plain: (*plain)(b)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	reward, err := units.ParseJSONUint64(aux.Reward)
+	if err != nil {
+		return fmt.Errorf("block reward: %w", err)
+	}
+	b.Reward = reward
+	return nil
+}
+
+// UnmarshalJSON decodes a block utilization whose fees are a number or a string
+func (u *BlockUtilization) UnmarshalJSON(data []byte) error {
+	type plain BlockUtilization
+	aux := struct {
+		*plain
+		Fees json.RawMessage `json:"fees"`
+	}{plain: (*plain)(u)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	fees, err := units.ParseJSONUint64(aux.Fees)
+	if err != nil {
+		return fmt.Errorf("block fees: %w", err)
+	}
+	u.Fees = fees
+	return nil
+}
@@ -0,0 +1,314 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Reasons a key held by the node is still needed
+const (
+	KeyUseValidator     = "validator"      // signs blocks
+	KeyUseAdmin         = "admin"          // signs administrative requests
+	KeyUseMultiSigOwner = "multisig_owner" // co-signs for a multi-signature wallet
+	KeyUseCustodial     = "custodial"      // wallet protected by a passphrase or controls
+	KeyUseFunded        = "funded"         // holds a balance or locked tokens
+	KeyUseExempt        = "exempt"         // kept by the operator, see SetAccountGCExemptions
+)
+
+// ErrKeyInUse is returned when pruning a key the node still needs
+var ErrKeyInUse = errors.New("key is still in use")
+
+// KeyAuditEntry describes a private key held by the node, in memory, in a
+// key file of the data directory, or both
+type KeyAuditEntry struct {
+	Address     string   `json:"address"`
+	KeyType     string   `json:"keyType"`
+	InMemory    bool     `json:"inMemory"`
+	File        string   `json:"file,omitempty"` // key file in the data directory
+	Private     bool     `json:"private"`        // false for verify-only keys
+	Uses        []string `json:"uses"`
+	Unnecessary bool     `json:"unnecessary"` // no use left, safe to migrate or delete
+}
+
+// KeyAudit inventories the keys held by the node
+type KeyAudit struct {
+	Total       int             `json:"total"`
+	Private     int             `json:"private"`
+	Unnecessary int             `json:"unnecessary"`
+	Entries     []KeyAuditEntry `json:"entries"`
+}
+
+// KeyPruneReport lists the keys removed from the node by PruneKeys
+type KeyPruneReport struct {
+	ExportDir string   `json:"exportDir,omitempty"`
+	Migrated  []string `json:"migrated"` // written to ExportDir before removal
+	Deleted   []string `json:"deleted"`
+}
+
+// keyFileData is the content of a key file written by KeyPair.SaveToFile
+type keyFileData struct {
+	Address    string `json:"address"`
+	KeyType    string `json:"key_type"`
+	PrivateKey string `json:"private_key"`
+	PublicKey  string `json:"public_key"`
+}
+
+// readKeyFiles returns the key files of the data directory by address
+func readKeyFiles(dataDir string) (map[string]string, map[string]keyFileData, error) {
+	matches, err := filepath.Glob(filepath.Join(dataDir, "key_*.json"))
+	if err != nil {
+		return nil, nil, err
+	}
+	paths := make(map[string]string, len(matches))
+	files := make(map[string]keyFileData, len(matches))
+	for _, path := range matches {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		var file keyFileData
+		if err := json.Unmarshal(data, &file); err != nil {
+			log.Printf("Warning: Skipping unreadable key file %s: %v", path, err)
+			continue
+		}
+		if file.Address == "" {
+			file.Address = strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "key_"), ".json")
+		}
+		paths[file.Address] = path
+		files[file.Address] = file
+	}
+	return paths, files, nil
+}
+
+// RegisterKeyUse registers a use of node-held keys tracked outside the chain
+// state, such as the admins of the validator manager. AuditKeys reports use
+// for the keys inUse reports true for, and PruneKeys keeps them.
+func (bc *Blockchain) RegisterKeyUse(use string, inUse func(address string) bool) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if bc.keyUses == nil {
+		bc.keyUses = make(map[string]func(address string) bool)
+	}
+	bc.keyUses[use] = inUse
+}
+
+// keyUsesLocked returns what the key of address is still needed for, apart
+// from custodial protections. The caller must hold bc.mu and bc.mutex.
+func (bc *Blockchain) keyUsesLocked(address string, admins, owners map[string]bool) []string {
+	uses := []string{}
+	if bc.validators[address] {
+		uses = append(uses, KeyUseValidator)
+	}
+	if admins[address] {
+		uses = append(uses, KeyUseAdmin)
+	}
+	if owners[address] {
+		uses = append(uses, KeyUseMultiSigOwner)
+	}
+	funded := false
+	if balance, exists := bc.accounts[address]; exists && balance.Sign() > 0 {
+		funded = true
+	}
+	if locked, exists := bc.lockedBalances[address]; exists && locked.Sign() > 0 {
+		funded = true
+	}
+	if funded {
+		uses = append(uses, KeyUseFunded)
+	}
+	if bc.gcExempt[address] {
+		uses = append(uses, KeyUseExempt)
+	}
+	return uses
+}
+
+// hasKeyUse reports whether uses holds use
+func hasKeyUse(uses []string, use string) bool {
+	for _, u := range uses {
+		if u == use {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditKeys inventories the private keys the node holds, in memory and in
+// key files of the data directory, and what each is still needed for. Keys
+// with no use left are marked unnecessary; PruneKeys migrates or deletes them.
+func (bc *Blockchain) AuditKeys() (*KeyAudit, error) {
+	paths, files, err := readKeyFiles(GetBlockchainDataPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key files: %v", err)
+	}
+
+	bc.mu.RLock()
+	bc.mutex.RLock()
+	admins := make(map[string]bool, len(bc.Admins))
+	for _, admin := range bc.Admins {
+		admins[admin] = true
+	}
+	owners := make(map[string]bool)
+	for _, wallet := range bc.multiSigWallets {
+		for _, owner := range wallet.Owners {
+			owners[owner] = true
+		}
+	}
+
+	entries := make(map[string]*KeyAuditEntry, len(bc.keyPairs)+len(files))
+	for address, keyPair := range bc.keyPairs {
+		entries[address] = &KeyAuditEntry{
+			Address:  address,
+			KeyType:  keyPair.Type().String(),
+			InMemory: true,
+			Private:  keyPair.Signer() != nil,
+		}
+	}
+	for address, file := range files {
+		entry, exists := entries[address]
+		if !exists {
+			entry = &KeyAuditEntry{Address: address, KeyType: file.KeyType}
+			entries[address] = entry
+		}
+		entry.File = paths[address]
+		entry.Private = entry.Private || file.PrivateKey != ""
+	}
+	for address, entry := range entries {
+		entry.Uses = bc.keyUsesLocked(address, admins, owners)
+	}
+	registered := make(map[string]func(address string) bool, len(bc.keyUses))
+	for use, inUse := range bc.keyUses {
+		registered[use] = inUse
+	}
+	bc.mutex.RUnlock()
+	bc.mu.RUnlock()
+
+	audit := &KeyAudit{Entries: make([]KeyAuditEntry, 0, len(entries))}
+	for address, entry := range entries {
+		_, controlled := bc.GetWalletControls(address)
+		if controlled || bc.HasWalletPassphrase(address) {
+			entry.Uses = append(entry.Uses, KeyUseCustodial)
+		}
+		for use, inUse := range registered {
+			if inUse(address) && !hasKeyUse(entry.Uses, use) {
+				entry.Uses = append(entry.Uses, use)
+			}
+		}
+		sort.Strings(entry.Uses)
+		entry.Unnecessary = len(entry.Uses) == 0
+		audit.Entries = append(audit.Entries, *entry)
+
+		audit.Total++
+		if entry.Private {
+			audit.Private++
+		}
+		if entry.Unnecessary {
+			audit.Unnecessary++
+		}
+	}
+	sort.Slice(audit.Entries, func(i, j int) bool {
+		return audit.Entries[i].Address < audit.Entries[j].Address
+	})
+	return audit, nil
+}
+
+// PruneKeys removes unnecessary keys from memory and from the data directory.
+// Without addresses every key the audit marks unnecessary is removed. With an
+// exportDir the keys are first written there as key files, so the operator can
+// move them to offline storage; otherwise they are deleted for good. Keys that
+// are still in use are refused with ErrKeyInUse and nothing is removed.
+func (bc *Blockchain) PruneKeys(addresses []string, exportDir string) (*KeyPruneReport, error) {
+	audit, err := bc.AuditKeys()
+	if err != nil {
+		return nil, err
+	}
+	byAddress := make(map[string]KeyAuditEntry, len(audit.Entries))
+	for _, entry := range audit.Entries {
+		byAddress[entry.Address] = entry
+	}
+
+	var prune []KeyAuditEntry
+	if len(addresses) == 0 {
+		for _, entry := range audit.Entries {
+			if entry.Unnecessary {
+				prune = append(prune, entry)
+			}
+		}
+	}
+	for _, address := range addresses {
+		entry, exists := byAddress[address]
+		if !exists {
+			return nil, fmt.Errorf("%w: %s", ErrKeyPairNotFound, address)
+		}
+		if !entry.Unnecessary {
+			return nil, fmt.Errorf("%w: %s is needed as %s", ErrKeyInUse, address, strings.Join(entry.Uses, ", "))
+		}
+		prune = append(prune, entry)
+	}
+
+	report := &KeyPruneReport{ExportDir: exportDir, Migrated: []string{}, Deleted: []string{}}
+	if exportDir != "" && len(prune) > 0 {
+		if err := os.MkdirAll(exportDir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create export directory: %v", err)
+		}
+	}
+
+	for _, entry := range prune {
+		if exportDir != "" {
+			if err := bc.exportKey(entry, exportDir); err != nil {
+				return report, err
+			}
+			report.Migrated = append(report.Migrated, entry.Address)
+		}
+
+		bc.mu.Lock()
+		delete(bc.keyPairs, entry.Address)
+		bc.mu.Unlock()
+		if entry.File != "" {
+			if err := os.Remove(entry.File); err != nil && !os.IsNotExist(err) {
+				return report, fmt.Errorf("failed to delete key file of %s: %v", entry.Address, err)
+			}
+		}
+		report.Deleted = append(report.Deleted, entry.Address)
+	}
+
+	if len(report.Deleted) > 0 {
+		log.Printf("Pruned %d unused keys (%d migrated to %s)", len(report.Deleted), len(report.Migrated), exportDir)
+	}
+	return report, nil
+}
+
+// exportKey writes the key of an audit entry to dir as a key file. A key
+// held only on disk is copied from its file.
+func (bc *Blockchain) exportKey(entry KeyAuditEntry, dir string) error {
+	var data []byte
+	if keyPair, exists := bc.GetKeyPair(entry.Address); exists {
+		encoded, err := json.MarshalIndent(keyFileData{
+			Address:    entry.Address,
+			KeyType:    keyPair.Type().String(),
+			PrivateKey: keyPair.GetPrivateKeyString(),
+			PublicKey:  keyPair.GetPublicKeyString(),
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		data = encoded
+	} else {
+		content, err := ioutil.ReadFile(entry.File)
+		if err != nil {
+			return fmt.Errorf("failed to read key file of %s: %v", entry.Address, err)
+		}
+		data = content
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("key_%s.json", entry.Address))
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to export key of %s: %v", entry.Address, err)
+	}
+	return nil
+}
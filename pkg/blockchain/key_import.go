@@ -0,0 +1,220 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// KeyFormat identifies the encoding of an imported private key
+type KeyFormat string
+
+const (
+	KeyFormatAuto KeyFormat = "auto"
+	KeyFormatHex  KeyFormat = "hex"
+	KeyFormatPEM  KeyFormat = "pem"
+	KeyFormatWIF  KeyFormat = "wif"
+)
+
+// ErrUnknownKeyFormat is returned when a key encoding cannot be detected
+var ErrUnknownKeyFormat = errors.New("unrecognized private key format")
+
+// wifVersion is the version byte used by WIF-style keys
+const wifVersion = 0x80
+
+// base58Alphabet is the Bitcoin base58 alphabet used by WIF keys
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// DetectKeyFormat guesses the encoding of a private key string
+func DetectKeyFormat(encoded string) KeyFormat {
+	s := strings.TrimSpace(encoded)
+	switch {
+	case strings.HasPrefix(s, "-----BEGIN"):
+		return KeyFormatPEM
+	case isHexKey(s):
+		return KeyFormatHex
+	case isBase58(s):
+		return KeyFormatWIF
+	default:
+		return KeyFormatAuto
+	}
+}
+
+// ImportPrivateKeyWithFormat decodes a private key in the given format.
+// With KeyFormatAuto (or an empty format) the encoding is detected automatically.
+// It returns the key together with the format that was actually used.
+//
+// All keys are reconstructed on the P-256 curve used by this chain; WIF-style keys from
+// other chains only carry the 32-byte scalar, so the resulting address differs from the source chain.
+func ImportPrivateKeyWithFormat(encoded string, format KeyFormat) (*ecdsa.PrivateKey, KeyFormat, error) {
+	encoded = strings.TrimSpace(encoded)
+	if format == "" || format == KeyFormatAuto {
+		format = DetectKeyFormat(encoded)
+	}
+
+	var (
+		key *ecdsa.PrivateKey
+		err error
+	)
+	switch format {
+	case KeyFormatHex:
+		key, err = importHexKey(encoded)
+	case KeyFormatPEM:
+		key, err = importPEMKey(encoded)
+	case KeyFormatWIF:
+		key, err = importWIFKey(encoded)
+	default:
+		return nil, format, ErrUnknownKeyFormat
+	}
+	if err != nil {
+		return nil, format, err
+	}
+
+	return key, format, nil
+}
+
+// importHexKey decodes a raw hex scalar, with or without a 0x prefix
+func importHexKey(s string) (*ecdsa.PrivateKey, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	privateKeyBytes, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key format: %v", err)
+	}
+	return privateKeyFromScalar(privateKeyBytes)
+}
+
+// importPEMKey decodes an "EC PRIVATE KEY" (SEC 1) or "PRIVATE KEY" (PKCS#8) PEM block
+func importPEMKey(s string) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block containing private key")
+	}
+
+	var key *ecdsa.PrivateKey
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		k, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC private key: %v", err)
+		}
+		key = k
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PKCS#8 private key: %v", err)
+		}
+		k, ok := parsed.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("PKCS#8 key is not an ECDSA key")
+		}
+		key = k
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+
+	if key.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("unsupported curve %s, only P-256 is supported", key.Curve.Params().Name)
+	}
+	return key, nil
+}
+
+// importWIFKey decodes a WIF-style key: base58check(0x80 || key[32] || [0x01])
+func importWIFKey(s string) (*ecdsa.PrivateKey, error) {
+	decoded, err := base58Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) != 37 && len(decoded) != 38 {
+		return nil, fmt.Errorf("invalid WIF key length: %d", len(decoded))
+	}
+
+	payload, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		return nil, errors.New("invalid WIF checksum")
+	}
+
+	if payload[0] != wifVersion {
+		return nil, fmt.Errorf("unsupported WIF version byte: 0x%02x", payload[0])
+	}
+
+	scalar := payload[1:33]
+	if len(payload) == 34 && payload[33] != 0x01 {
+		return nil, errors.New("invalid WIF compression flag")
+	}
+
+	return privateKeyFromScalar(scalar)
+}
+
+// privateKeyFromScalar builds a P-256 private key from its scalar bytes
+func privateKeyFromScalar(privateKeyBytes []byte) (*ecdsa.PrivateKey, error) {
+	curve := elliptic.P256()
+	d := new(big.Int).SetBytes(privateKeyBytes)
+	if d.Sign() == 0 || d.Cmp(curve.Params().N) >= 0 {
+		return nil, errors.New("private key is out of range for P-256")
+	}
+
+	privateKey := new(ecdsa.PrivateKey)
+	privateKey.PublicKey.Curve = curve
+	privateKey.D = d
+	privateKey.PublicKey.X, privateKey.PublicKey.Y = curve.ScalarBaseMult(d.Bytes())
+	return privateKey, nil
+}
+
+// isHexKey reports whether s looks like a hex-encoded 32-byte scalar
+func isHexKey(s string) bool {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(s) == 0 || len(s) > 64 || len(s)%2 != 0 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// isBase58 reports whether s only contains base58 characters
+func isBase58(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune(base58Alphabet, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// base58Decode decodes a base58 string using the Bitcoin alphabet
+func base58Decode(s string) ([]byte, error) {
+	result := big.NewInt(0)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		idx := strings.IndexRune(base58Alphabet, c)
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", c)
+		}
+		result.Mul(result, radix)
+		result.Add(result, big.NewInt(int64(idx)))
+	}
+
+	decoded := result.Bytes()
+
+	// Leading '1' characters encode leading zero bytes
+	leadingZeros := 0
+	for _, c := range s {
+		if c != '1' {
+			break
+		}
+		leadingZeros++
+	}
+	return append(make([]byte, leadingZeros), decoded...), nil
+}
@@ -0,0 +1,168 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// labelsFile holds the address labels kept by this node
+const labelsFile = "labels.json"
+
+// Limits on label fields, in bytes
+const (
+	MaxLabelLength      = 64
+	MaxLabelCategory    = 32
+	MaxLabelDescription = 256
+	MaxLabelURLLength   = 256
+	LabelSourceAdmin    = "admin"
+)
+
+var (
+	// ErrLabelNotFound is returned when an address has no label
+	ErrLabelNotFound = errors.New("address label not found")
+	// ErrInvalidLabel is returned for labels that fail validation
+	ErrInvalidLabel = errors.New("invalid address label")
+)
+
+// AddressLabel is public metadata attached to an address, such as "Treasury" or
+// "Exchange hot wallet". Labels are kept by the node for explorers and are not
+// part of consensus.
+type AddressLabel struct {
+	Address     string `json:"address"`
+	Label       string `json:"label"`
+	Category    string `json:"category,omitempty"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Source      string `json:"source"`    // who set the label, currently only "admin"
+	UpdatedBy   string `json:"updatedBy"` // address that set the label
+	UpdatedAt   int64  `json:"updatedAt"`
+}
+
+// validate checks the label fields
+func (l *AddressLabel) validate() error {
+	l.Label = strings.TrimSpace(l.Label)
+	l.Category = strings.ToLower(strings.TrimSpace(l.Category))
+	switch {
+	case l.Address == "":
+		return fmt.Errorf("%w: address is required", ErrInvalidLabel)
+	case l.Label == "":
+		return fmt.Errorf("%w: label is required", ErrInvalidLabel)
+	case len(l.Label) > MaxLabelLength:
+		return fmt.Errorf("%w: label exceeds %d bytes", ErrInvalidLabel, MaxLabelLength)
+	case len(l.Category) > MaxLabelCategory:
+		return fmt.Errorf("%w: category exceeds %d bytes", ErrInvalidLabel, MaxLabelCategory)
+	case len(l.Description) > MaxLabelDescription:
+		return fmt.Errorf("%w: description exceeds %d bytes", ErrInvalidLabel, MaxLabelDescription)
+	case len(l.URL) > MaxLabelURLLength:
+		return fmt.Errorf("%w: url exceeds %d bytes", ErrInvalidLabel, MaxLabelURLLength)
+	case l.URL != "" && !strings.HasPrefix(l.URL, "https://") && !strings.HasPrefix(l.URL, "http://"):
+		return fmt.Errorf("%w: url must start with http:// or https://", ErrInvalidLabel)
+	}
+	return nil
+}
+
+// labelStore holds the address labels. It has its own lock because labels are
+// node metadata and never change together with chain state.
+type labelStore struct {
+	mu     sync.RWMutex
+	labels map[string]AddressLabel
+}
+
+// SetAddressLabel attaches or replaces the label of an address and persists it
+func (bc *Blockchain) SetAddressLabel(label AddressLabel) (AddressLabel, error) {
+	if err := label.validate(); err != nil {
+		return label, err
+	}
+	if label.Source == "" {
+		label.Source = LabelSourceAdmin
+	}
+	label.UpdatedAt = time.Now().Unix()
+
+	bc.labels.mu.Lock()
+	defer bc.labels.mu.Unlock()
+	if bc.labels.labels == nil {
+		bc.labels.labels = make(map[string]AddressLabel)
+	}
+	bc.labels.labels[label.Address] = label
+	return label, bc.saveLabelsLocked(GetBlockchainDataPath())
+}
+
+// RemoveAddressLabel deletes the label of an address
+func (bc *Blockchain) RemoveAddressLabel(address string) error {
+	bc.labels.mu.Lock()
+	defer bc.labels.mu.Unlock()
+	if _, exists := bc.labels.labels[address]; !exists {
+		return fmt.Errorf("%w: %s", ErrLabelNotFound, address)
+	}
+	delete(bc.labels.labels, address)
+	return bc.saveLabelsLocked(GetBlockchainDataPath())
+}
+
+// GetAddressLabel returns the label of an address
+func (bc *Blockchain) GetAddressLabel(address string) (AddressLabel, bool) {
+	bc.labels.mu.RLock()
+	defer bc.labels.mu.RUnlock()
+	label, exists := bc.labels.labels[address]
+	return label, exists
+}
+
+// AddressLabels returns every label, optionally only those of one category,
+// ordered by address
+func (bc *Blockchain) AddressLabels(category string) []AddressLabel {
+	category = strings.ToLower(strings.TrimSpace(category))
+
+	bc.labels.mu.RLock()
+	result := make([]AddressLabel, 0, len(bc.labels.labels))
+	for _, label := range bc.labels.labels {
+		if category == "" || label.Category == category {
+			result = append(result, label)
+		}
+	}
+	bc.labels.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Address < result[j].Address })
+	return result
+}
+
+// saveLabelsLocked writes the labels to dir. The caller must hold bc.labels.mu.
+func (bc *Blockchain) saveLabelsLocked(dir string) error {
+	data, err := json.MarshalIndent(bc.labels.labels, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal address labels: %v", err)
+	}
+
+	path := filepath.Join(dir, labelsFile)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write address labels: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadLabels reads the labels from dir
+func (bc *Blockchain) loadLabels(dir string) {
+	bc.labels.mu.Lock()
+	defer bc.labels.mu.Unlock()
+
+	bc.labels.labels = make(map[string]AddressLabel)
+	raw, err := ioutil.ReadFile(filepath.Join(dir, labelsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to read address labels: %v", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(raw, &bc.labels.labels); err != nil {
+		log.Printf("Warning: Failed to parse address labels: %v", err)
+		bc.labels.labels = make(map[string]AddressLabel)
+	}
+}
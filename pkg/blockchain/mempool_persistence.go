@@ -0,0 +1,116 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// mempoolFile holds the pending transactions saved at shutdown
+const mempoolFile = "mempool.json"
+
+// SetPersistMempool enables saving the transaction pool with SaveMempool and
+// restoring it with LoadMempool, so transactions submitted shortly before a
+// restart are not lost
+func (bc *Blockchain) SetPersistMempool(enabled bool) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.persistMempool = enabled
+}
+
+// SaveMempool writes the pending transactions to the data directory in pool
+// order. It does nothing unless persistence is enabled.
+func (bc *Blockchain) SaveMempool() error {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	if !bc.persistMempool {
+		return nil
+	}
+
+	data, err := json.Marshal(bc.pendingTxs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the transaction pool: %v", err)
+	}
+	path := filepath.Join(GetBlockchainDataPath(), mempoolFile)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write the transaction pool: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	log.Printf("Saved %d pending transactions to %s", len(bc.pendingTxs), path)
+	return nil
+}
+
+// LoadMempool puts the transactions saved by SaveMempool back into the pool.
+// Each one is validated again as if it were submitted now: transactions
+// confirmed in the meantime are skipped, and those that no longer pass
+// admission or carry an invalid signature are recorded as rejected. The file
+// is removed once read. It does nothing unless persistence is enabled.
+func (bc *Blockchain) LoadMempool() (restored, dropped int, err error) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if !bc.persistMempool {
+		return 0, 0, nil
+	}
+
+	dataDir := GetBlockchainDataPath()
+	path := filepath.Join(dataDir, mempoolFile)
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, 0, nil
+		}
+		return 0, 0, fmt.Errorf("failed to read the transaction pool: %v", err)
+	}
+	var saved []*Transaction
+	if err := json.Unmarshal(raw, &saved); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse the transaction pool: %v", err)
+	}
+
+	now := time.Now().Unix()
+	for _, tx := range saved {
+		if tx == nil {
+			continue
+		}
+		if _, confirmed := bc.txIndex[tx.ID]; confirmed {
+			continue
+		}
+		if _, pending := bc.txPool[tx.ID]; pending {
+			continue
+		}
+		tx.Status = TxStatusPending
+		if err := bc.addTransactionLocked(tx); err != nil {
+			bc.recordRejectionLocked(tx, fmt.Sprintf("dropped on restart: %v", err), now)
+			dropped++
+			continue
+		}
+		restored++
+	}
+
+	if dropped > 0 {
+		if err := bc.saveRejectedTxsLocked(dataDir); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		log.Printf("Warning: Failed to remove %s: %v", path, err)
+	}
+	return restored, dropped, nil
+}
+
+// verifyPooledSignatureLocked checks the signature of a transaction entering the
+// pool like block import does, so blocks built from the pool pass verification.
+// The caller must hold bc.mu.
+func (bc *Blockchain) verifyPooledSignatureLocked(tx *Transaction) error {
+	checks, err := bc.collectSignatureChecksLocked(&Block{Transactions: []*Transaction{tx}})
+	if err != nil {
+		return err
+	}
+	return verifySignatureChecks(checks, 1)
+}
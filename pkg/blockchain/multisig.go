@@ -1,8 +1,11 @@
 package blockchain
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math/big"
+	"sort"
 	"sync"
 	"time"
 )
@@ -13,6 +16,7 @@ type MultiSigWallet struct {
 	Owners          []string
 	RequiredSigs    int
 	PendingTxs      map[string]*MultiSigTransaction
+	ThresholdKey    string // hex compressed group key for aggregated threshold signatures, if enabled
 	mutex           sync.RWMutex
 }
 
@@ -27,6 +31,8 @@ type MultiSigTransaction struct {
 	Signatures  map[string]string
 	Status      string
 	CreatedAt   int64
+	ProposalID  string // governance proposal that created the transaction, if any
+	ThresholdSignature string // hex aggregated owner signature, replacing Signatures
 }
 
 // NewMultiSigWallet creates a new multi-signature wallet
@@ -50,15 +56,8 @@ func (w *MultiSigWallet) CreateTransaction(from, to string, value string, data [
 	defer w.mutex.Unlock()
 
 	// Verify sender is an owner
-	isOwner := false
-	for _, owner := range w.Owners {
-		if owner == from {
-			isOwner = true
-			break
-		}
-	}
-	if !isOwner {
-		return nil, fmt.Errorf("sender %s is not an owner of this wallet", from)
+	if !w.IsOwner(from) {
+		return nil, fmt.Errorf("%w: sender %s", ErrNotOwner, from)
 	}
 
 	// Convert value string to big.Int
@@ -83,37 +82,133 @@ func (w *MultiSigWallet) CreateTransaction(from, to string, value string, data [
 	return tx, nil
 }
 
-// SignTransaction adds a signature to a pending transaction
-func (w *MultiSigWallet) SignTransaction(txID string, signer string, signature string) error {
+// CreateProposalTransaction creates a transaction spending from the wallet
+// itself for an approved governance proposal. The owners then sign it as any
+// other pending transaction.
+func (w *MultiSigWallet) CreateProposalTransaction(proposalID, to string, value *big.Int) (*MultiSigTransaction, error) {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
 
-	// Verify signer is an owner
-	isOwner := false
+	if value == nil || value.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid value for proposal %s", proposalID)
+	}
+	for _, tx := range w.PendingTxs {
+		if tx.ProposalID == proposalID {
+			return nil, fmt.Errorf("%w: proposal %s already has transaction %s", ErrTxExists, proposalID, tx.ID)
+		}
+	}
+
+	tx := &MultiSigTransaction{
+		ID:         fmt.Sprintf("multisig_%d", time.Now().UnixNano()),
+		From:       w.Address,
+		To:         to,
+		Value:      new(big.Int).Set(value),
+		Type:       GovernanceTxType,
+		Signatures: make(map[string]string),
+		Status:     "pending",
+		CreatedAt:  time.Now().Unix(),
+		ProposalID: proposalID,
+	}
+
+	w.PendingTxs[tx.ID] = tx
+	return tx, nil
+}
+
+// SigningHash returns the digest owners sign to approve the transaction. It
+// covers the wallet, every field of the transaction and the chain ID, so a
+// signature cannot be reused for another transaction, wallet or network.
+func (tx *MultiSigTransaction) SigningHash(walletAddress string) ([]byte, error) {
+	value := "0"
+	if tx.Value != nil {
+		value = tx.Value.String()
+	}
+	payload, err := json.Marshal(struct {
+		Wallet     string `json:"wallet"`
+		ID         string `json:"id"`
+		From       string `json:"from"`
+		To         string `json:"to"`
+		Value      string `json:"value"`
+		Data       []byte `json:"data"`
+		Type       string `json:"type"`
+		CreatedAt  int64  `json:"createdAt"`
+		ProposalID string `json:"proposalId"`
+	}{walletAddress, tx.ID, tx.From, tx.To, value, tx.Data, tx.Type, tx.CreatedAt, tx.ProposalID})
+	if err != nil {
+		return nil, err
+	}
+	return signingDigest(multiSigSigningDomain, CurrentSigScheme, ChainID(), payload)
+}
+
+// IsOwner reports whether address is an owner of the wallet
+func (w *MultiSigWallet) IsOwner(address string) bool {
 	for _, owner := range w.Owners {
-		if owner == signer {
-			isOwner = true
-			break
+		if owner == address {
+			return true
 		}
 	}
-	if !isOwner {
-		return fmt.Errorf("signer %s is not an owner of this wallet", signer)
+	return false
+}
+
+// SignTransaction adds an owner's signature to a pending transaction. The
+// signature is the hex encoding of the owner's signature over SigningHash and
+// is verified with the owner's public key.
+func (w *MultiSigWallet) SignTransaction(txID string, signer string, signature string, publicKey PublicKey) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	// Verify signer is an owner
+	if !w.IsOwner(signer) {
+		return fmt.Errorf("%w: signer %s", ErrNotOwner, signer)
 	}
 
 	tx, exists := w.PendingTxs[txID]
 	if !exists {
-		return fmt.Errorf("transaction %s not found", txID)
+		return fmt.Errorf("%w: %s", ErrTxNotFound, txID)
 	}
 
 	// Check if already signed by this owner
 	if _, exists := tx.Signatures[signer]; exists {
-		return fmt.Errorf("transaction already signed by %s", signer)
+		return fmt.Errorf("%w: %s", ErrAlreadySigned, signer)
+	}
+
+	// Verify the signature covers this transaction
+	if publicKey == nil {
+		return fmt.Errorf("%w: signer %s", ErrKeyPairNotFound, signer)
+	}
+	sigBytes, err := hex.DecodeString(signature)
+	if err != nil || len(sigBytes) == 0 {
+		return fmt.Errorf("%w: signature must be hex encoded", ErrInvalidSignature)
+	}
+	hash, err := tx.SigningHash(w.Address)
+	if err != nil {
+		return err
+	}
+	if !publicKey.Verify(hash, sigBytes) {
+		return fmt.Errorf("%w: signature of %s does not match transaction %s", ErrInvalidSignature, signer, txID)
 	}
 
 	tx.Signatures[signer] = signature
 	return nil
 }
 
+// Signers returns the owners who signed a pending transaction, sorted, and
+// whether the transaction is still pending
+func (w *MultiSigWallet) Signers(txID string) ([]string, bool) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	tx, exists := w.PendingTxs[txID]
+	if !exists {
+		return nil, false
+	}
+	signers := make([]string, 0, len(tx.Signatures))
+	for signer := range tx.Signatures {
+		signers = append(signers, signer)
+	}
+	sort.Strings(signers)
+	return signers, true
+}
+
 // GetTransactionStatus returns the current status of a transaction
 func (w *MultiSigWallet) GetTransactionStatus(txID string) (string, error) {
 	w.mutex.RLock()
@@ -121,7 +216,7 @@ func (w *MultiSigWallet) GetTransactionStatus(txID string) (string, error) {
 
 	tx, exists := w.PendingTxs[txID]
 	if !exists {
-		return "", fmt.Errorf("transaction %s not found", txID)
+		return "", fmt.Errorf("%w: %s", ErrTxNotFound, txID)
 	}
 
 	return tx.Status, nil
@@ -146,12 +241,12 @@ func (w *MultiSigWallet) ExecuteTransaction(txID string) (*Transaction, error) {
 
 	tx, exists := w.PendingTxs[txID]
 	if !exists {
-		return nil, fmt.Errorf("transaction %s not found", txID)
+		return nil, fmt.Errorf("%w: %s", ErrTxNotFound, txID)
 	}
 
-	// Check if we have enough signatures
-	if len(tx.Signatures) < w.RequiredSigs {
-		return nil, fmt.Errorf("not enough signatures: got %d, need %d", 
+	// Check if we have enough signatures; a threshold signature stands for all of them
+	if tx.ThresholdSignature == "" && len(tx.Signatures) < w.RequiredSigs {
+		return nil, fmt.Errorf("%w: got %d, need %d", ErrNotEnoughSignatures,
 			len(tx.Signatures), w.RequiredSigs)
 	}
 
@@ -178,7 +273,7 @@ func (w *MultiSigWallet) RejectTransaction(txID string) error {
 	defer w.mutex.Unlock()
 
 	if _, exists := w.PendingTxs[txID]; !exists {
-		return fmt.Errorf("transaction %s not found", txID)
+		return fmt.Errorf("%w: %s", ErrTxNotFound, txID)
 	}
 
 	delete(w.PendingTxs, txID)
@@ -0,0 +1,130 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// A multi-signature wallet can register a threshold group key shared by its
+// owners. Its transactions can then be approved with one aggregated threshold
+// signature over SigningHash, produced off-chain by RequiredSigs owners,
+// instead of RequiredSigs separate signatures. Owner signatures keep working.
+
+// ThresholdKeyHash returns the digest the owners sign to register groupKey as
+// the wallet's threshold key
+func (w *MultiSigWallet) ThresholdKeyHash(groupKey string) ([]byte, error) {
+	payload, err := json.Marshal(struct {
+		Wallet       string `json:"wallet"`
+		ThresholdKey string `json:"thresholdKey"`
+	}{w.Address, groupKey})
+	if err != nil {
+		return nil, err
+	}
+	return signingDigest(multiSigSigningDomain, CurrentSigScheme, ChainID(), payload)
+}
+
+// GetThresholdKey returns the wallet's hex threshold group key, or an empty
+// string when threshold signing is not enabled
+func (w *MultiSigWallet) GetThresholdKey() string {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	return w.ThresholdKey
+}
+
+// SubmitThresholdSignature approves a pending transaction with an aggregated
+// threshold signature, hex encoded, over the transaction's SigningHash
+func (w *MultiSigWallet) SubmitThresholdSignature(txID string, signature string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.ThresholdKey == "" {
+		return fmt.Errorf("%w: %s", ErrThresholdKeyNotSet, w.Address)
+	}
+	tx, exists := w.PendingTxs[txID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrTxNotFound, txID)
+	}
+	if tx.ThresholdSignature != "" {
+		return fmt.Errorf("%w: transaction %s already has a threshold signature", ErrAlreadySigned, txID)
+	}
+
+	groupKey, err := hex.DecodeString(w.ThresholdKey)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidThresholdKey, err)
+	}
+	sigBytes, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: signature must be hex encoded", ErrInvalidSignature)
+	}
+	hash, err := tx.SigningHash(w.Address)
+	if err != nil {
+		return err
+	}
+	if !VerifyThresholdSignature(groupKey, hash, sigBytes) {
+		return fmt.Errorf("%w: threshold signature does not match transaction %s", ErrInvalidSignature, txID)
+	}
+
+	tx.ThresholdSignature = signature
+	return nil
+}
+
+// SetMultiSigThresholdKey enables threshold signing for a wallet. approvals
+// maps owners to their hex signatures over ThresholdKeyHash; RequiredSigs
+// distinct owners must approve, since the key will stand for that many of
+// them. The owners must have generated the key with that threshold.
+func (bc *Blockchain) SetMultiSigThresholdKey(walletAddress, groupKey string, approvals map[string]string) error {
+	wallet, err := bc.GetMultiSigWallet(walletAddress)
+	if err != nil {
+		return err
+	}
+	key, err := hex.DecodeString(groupKey)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidThresholdKey, err)
+	}
+	if err := ParseThresholdKey(key); err != nil {
+		return err
+	}
+	hash, err := wallet.ThresholdKeyHash(groupKey)
+	if err != nil {
+		return err
+	}
+
+	approved := 0
+	for owner, signature := range approvals {
+		if !wallet.IsOwner(owner) {
+			return fmt.Errorf("%w: signer %s", ErrNotOwner, owner)
+		}
+		keyPair, exists := bc.GetKeyPair(owner)
+		if !exists {
+			return fmt.Errorf("%w: owner %s has no registered key", ErrKeyPairNotFound, owner)
+		}
+		sigBytes, err := hex.DecodeString(signature)
+		if err != nil || !keyPair.Public().Verify(hash, sigBytes) {
+			return fmt.Errorf("%w: approval of %s does not match the threshold key", ErrInvalidSignature, owner)
+		}
+		approved++
+	}
+	if approved < wallet.GetRequiredSignatures() {
+		return fmt.Errorf("%w: got %d approvals, need %d", ErrNotEnoughSignatures, approved, wallet.GetRequiredSignatures())
+	}
+
+	wallet.mutex.Lock()
+	wallet.ThresholdKey = groupKey
+	wallet.mutex.Unlock()
+
+	log.Printf("Threshold signing enabled for multisig wallet %s", walletAddress)
+	go bc.SaveToDisk()
+	return nil
+}
+
+// SubmitMultiSigThresholdSignature approves a multi-signature transaction with
+// an aggregated threshold signature of the wallet's owners
+func (bc *Blockchain) SubmitMultiSigThresholdSignature(walletAddress, txID, signature string) error {
+	wallet, err := bc.GetMultiSigWallet(walletAddress)
+	if err != nil {
+		return err
+	}
+	return wallet.SubmitThresholdSignature(txID, signature)
+}
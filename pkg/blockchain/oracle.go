@@ -0,0 +1,366 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FeatureOracle enables the oracle: validators authorize oracle accounts,
+// which post externally observed data points such as price feeds. Contracts
+// read the data through the host API.
+const FeatureOracle Feature = "oracle"
+
+// Transaction types of the oracle
+const (
+	OracleAuthorizeTxType = "oracle_authorize" // a validator grants or revokes an oracle account
+	OracleDataTxType      = "oracle_data"      // an authorized oracle posts a data point
+)
+
+// Limits on oracle payloads, in bytes
+const (
+	MaxOracleFeed  = 64
+	MaxOracleValue = 256
+)
+
+// MaxOracleHistory is the number of data points kept per feed; older points
+// remain on chain but are no longer served
+const MaxOracleHistory = 10000
+
+var (
+	// ErrInvalidOracleData is returned for oracle transactions that fail validation
+	ErrInvalidOracleData = errors.New("invalid oracle data")
+	// ErrOracleNotAuthorized is returned for data posted by an account that may not post to the feed
+	ErrOracleNotAuthorized = errors.New("oracle not authorized")
+	// ErrOracleFeedNotFound is returned for a feed no data was posted to
+	ErrOracleFeedNotFound = errors.New("oracle feed not found")
+)
+
+func init() {
+	RegisterFeature(FeatureOracle, "validators authorize oracle accounts that post external data points for contracts")
+	RegisterTxLane(OracleAuthorizeTxType, LaneSystem)
+	RegisterTxLane(OracleDataTxType, LaneSystem)
+}
+
+// OracleAuthorization is the payload of an oracle authorization transaction.
+// Feeds restricts the oracle to the listed feeds; an empty list allows all.
+type OracleAuthorization struct {
+	Oracle string   `json:"oracle"`
+	Feeds  []string `json:"feeds,omitempty"`
+	Revoke bool     `json:"revoke,omitempty"`
+}
+
+// OracleDataPoint is the payload of an oracle data transaction. Value is kept
+// as posted, e.g. a decimal price; ObservedAt is when the oracle observed it
+// off chain, in Unix seconds.
+type OracleDataPoint struct {
+	Feed       string `json:"feed"`
+	Value      string `json:"value"`
+	ObservedAt int64  `json:"observedAt"`
+}
+
+// OracleInfo is an authorized oracle account
+type OracleInfo struct {
+	Oracle       string   `json:"oracle"`
+	Feeds        []string `json:"feeds,omitempty"` // empty when the oracle may post to every feed
+	AuthorizedBy string   `json:"authorizedBy"`
+	TxID         string   `json:"txId"`
+	BlockIndex   uint64   `json:"blockIndex"`
+}
+
+// OracleRecord is a data point confirmed on chain
+type OracleRecord struct {
+	Feed       string `json:"feed"`
+	Value      string `json:"value"`
+	ObservedAt int64  `json:"observedAt"`
+	Oracle     string `json:"oracle"`
+	TxID       string `json:"txId"`
+	BlockIndex uint64 `json:"blockIndex"`
+	Timestamp  int64  `json:"timestamp"` // of the block confirming the point
+}
+
+// OracleFeedSummary describes a feed by its latest data point
+type OracleFeedSummary struct {
+	Feed   string       `json:"feed"`
+	Latest OracleRecord `json:"latest"`
+	Points int          `json:"points"` // data points kept for the feed
+}
+
+// checkOracleFeed validates a feed name. Feed names appear in URL paths, so
+// they are limited to lower-case letters, digits, '-', '_' and '.'.
+func checkOracleFeed(feed string) error {
+	if feed == "" {
+		return fmt.Errorf("%w: feed is required", ErrInvalidOracleData)
+	}
+	if len(feed) > MaxOracleFeed {
+		return fmt.Errorf("%w: feed exceeds %d bytes", ErrInvalidOracleData, MaxOracleFeed)
+	}
+	for _, r := range feed {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return fmt.Errorf("%w: feed %q may only contain a-z, 0-9, '-', '_' and '.'", ErrInvalidOracleData, feed)
+		}
+	}
+	return nil
+}
+
+// isOracleTransaction reports whether tx is an oracle transaction
+func isOracleTransaction(tx *Transaction) bool {
+	return tx.Type == OracleAuthorizeTxType || tx.Type == OracleDataTxType
+}
+
+// NewOracleAuthorizeTransaction creates an unsigned transaction by which the
+// validator grants or revokes an oracle account
+func NewOracleAuthorizeTransaction(id, validator string, auth OracleAuthorization) (*Transaction, error) {
+	data, err := json.Marshal(auth)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(id, validator, auth.Oracle, 0, data)
+	tx.Type = OracleAuthorizeTxType
+	if _, err := ParseOracleAuthorization(tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// NewOracleDataTransaction creates an unsigned transaction by which oracle
+// posts a data point
+func NewOracleDataTransaction(id, oracle string, point OracleDataPoint) (*Transaction, error) {
+	data, err := json.Marshal(point)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(id, oracle, oracle, 0, data)
+	tx.Type = OracleDataTxType
+	if _, err := ParseOracleDataPoint(tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// ParseOracleAuthorization decodes and validates the payload of an oracle authorization transaction
+func ParseOracleAuthorization(tx *Transaction) (*OracleAuthorization, error) {
+	if tx == nil || tx.Type != OracleAuthorizeTxType {
+		return nil, fmt.Errorf("%w: not an oracle authorization", ErrInvalidOracleData)
+	}
+	var auth OracleAuthorization
+	if err := json.Unmarshal(tx.Data, &auth); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOracleData, err)
+	}
+	if auth.Oracle == "" {
+		return nil, fmt.Errorf("%w: oracle is required", ErrInvalidOracleData)
+	}
+	if auth.Oracle != tx.To {
+		return nil, fmt.Errorf("%w: authorization of %s must be addressed to it, not %s", ErrInvalidOracleData, auth.Oracle, tx.To)
+	}
+	for _, feed := range auth.Feeds {
+		if err := checkOracleFeed(feed); err != nil {
+			return nil, err
+		}
+	}
+	return &auth, nil
+}
+
+// ParseOracleDataPoint decodes and validates the payload of an oracle data transaction
+func ParseOracleDataPoint(tx *Transaction) (*OracleDataPoint, error) {
+	if tx == nil || tx.Type != OracleDataTxType {
+		return nil, fmt.Errorf("%w: not an oracle data transaction", ErrInvalidOracleData)
+	}
+	var point OracleDataPoint
+	if err := json.Unmarshal(tx.Data, &point); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOracleData, err)
+	}
+	if err := checkOracleFeed(point.Feed); err != nil {
+		return nil, err
+	}
+	switch {
+	case strings.TrimSpace(point.Value) == "":
+		return nil, fmt.Errorf("%w: value is required", ErrInvalidOracleData)
+	case len(point.Value) > MaxOracleValue:
+		return nil, fmt.Errorf("%w: value exceeds %d bytes", ErrInvalidOracleData, MaxOracleValue)
+	case point.ObservedAt <= 0:
+		return nil, fmt.Errorf("%w: observedAt is required", ErrInvalidOracleData)
+	}
+	return &point, nil
+}
+
+// checkOracleTransactionLocked validates an oracle transaction for inclusion
+// at height. Authorizations must be sent by a validator and data points by an
+// oracle authorized for the feed; both must be signed, so the data is
+// attributable to its oracle. Authorizations take effect for the blocks after
+// the one confirming them. The caller must hold bc.mu.
+func (bc *Blockchain) checkOracleTransactionLocked(tx *Transaction, height uint64) error {
+	if !isOracleTransaction(tx) {
+		return nil
+	}
+	if !bc.featureActiveLocked(FeatureOracle, height) {
+		return fmt.Errorf("%w: the oracle is not active at height %d", ErrInvalidOracleData, height)
+	}
+	if tx.Value != 0 {
+		return fmt.Errorf("%w: oracle transactions carry no value", ErrInvalidOracleData)
+	}
+	if len(tx.Signature) == 0 {
+		return fmt.Errorf("%w: oracle transactions must be signed", ErrInvalidSignature)
+	}
+
+	if tx.Type == OracleAuthorizeTxType {
+		if _, err := ParseOracleAuthorization(tx); err != nil {
+			return err
+		}
+		if !bc.validators[tx.From] {
+			return fmt.Errorf("%w: %s is not a validator", ErrOracleNotAuthorized, tx.From)
+		}
+		return nil
+	}
+
+	point, err := ParseOracleDataPoint(tx)
+	if err != nil {
+		return err
+	}
+	oracle, exists := bc.oracles[tx.From]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrOracleNotAuthorized, tx.From)
+	}
+	if len(oracle.Feeds) == 0 {
+		return nil
+	}
+	for _, feed := range oracle.Feeds {
+		if feed == point.Feed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s may not post to %s", ErrOracleNotAuthorized, tx.From, point.Feed)
+}
+
+// applyOracleTransactionLocked records an authorization or a data point.
+// The caller must hold bc.mu.
+func (bc *Blockchain) applyOracleTransactionLocked(tx *Transaction, block *Block) error {
+	if err := bc.checkOracleTransactionLocked(tx, block.Index); err != nil {
+		return err
+	}
+	bc.recordOracleTransactionLocked(tx, block)
+	return nil
+}
+
+// recordOracleTransactionLocked applies a validated oracle transaction to the
+// oracle registry and feeds. The caller must hold bc.mu.
+func (bc *Blockchain) recordOracleTransactionLocked(tx *Transaction, block *Block) {
+	if tx.Type == OracleAuthorizeTxType {
+		auth, err := ParseOracleAuthorization(tx)
+		if err != nil {
+			return
+		}
+		if auth.Revoke {
+			delete(bc.oracles, auth.Oracle)
+			return
+		}
+		bc.oracles[auth.Oracle] = &OracleInfo{
+			Oracle:       auth.Oracle,
+			Feeds:        append([]string(nil), auth.Feeds...),
+			AuthorizedBy: tx.From,
+			TxID:         tx.ID,
+			BlockIndex:   block.Index,
+		}
+		return
+	}
+
+	point, err := ParseOracleDataPoint(tx)
+	if err != nil {
+		return
+	}
+	history := append(bc.oracleFeeds[point.Feed], OracleRecord{
+		Feed:       point.Feed,
+		Value:      point.Value,
+		ObservedAt: point.ObservedAt,
+		Oracle:     tx.From,
+		TxID:       tx.ID,
+		BlockIndex: block.Index,
+		Timestamp:  block.Timestamp,
+	})
+	if len(history) > MaxOracleHistory {
+		history = history[len(history)-MaxOracleHistory:]
+	}
+	bc.oracleFeeds[point.Feed] = history
+}
+
+// rebuildOraclesLocked replays the confirmed oracle transactions of the
+// chain. The caller must hold bc.mu.
+func (bc *Blockchain) rebuildOraclesLocked() {
+	bc.oracles = make(map[string]*OracleInfo)
+	bc.oracleFeeds = make(map[string][]OracleRecord)
+	for _, block := range bc.Blocks {
+		for _, tx := range block.Transactions {
+			if isOracleTransaction(tx) && tx.Status == "confirmed" {
+				bc.recordOracleTransactionLocked(tx, block)
+			}
+		}
+	}
+}
+
+// oracleValueAtLocked returns the latest data point of feed confirmed at or
+// below height. The caller must hold bc.mu.
+func (bc *Blockchain) oracleValueAtLocked(feed string, height uint64) (OracleRecord, error) {
+	history := bc.oracleFeeds[feed]
+	i := sort.Search(len(history), func(i int) bool { return history[i].BlockIndex > height })
+	if i == 0 {
+		return OracleRecord{}, fmt.Errorf("%w: %s has no data at height %d", ErrOracleFeedNotFound, feed, height)
+	}
+	return history[i-1], nil
+}
+
+// VerifyOracleTransaction checks an oracle transaction against the oracle
+// registry for inclusion in the next block
+func (bc *Blockchain) VerifyOracleTransaction(tx *Transaction) error {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	if !isOracleTransaction(tx) {
+		return fmt.Errorf("%w: not an oracle transaction", ErrInvalidOracleData)
+	}
+	return bc.checkOracleTransactionLocked(tx, uint64(len(bc.Blocks)))
+}
+
+// Oracles returns the authorized oracle accounts ordered by address
+func (bc *Blockchain) Oracles() []OracleInfo {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	oracles := make([]OracleInfo, 0, len(bc.oracles))
+	for _, oracle := range bc.oracles {
+		info := *oracle
+		info.Feeds = append([]string(nil), oracle.Feeds...)
+		oracles = append(oracles, info)
+	}
+	sort.Slice(oracles, func(i, j int) bool { return oracles[i].Oracle < oracles[j].Oracle })
+	return oracles
+}
+
+// OracleFeeds returns every feed with its latest data point, ordered by name
+func (bc *Blockchain) OracleFeeds() []OracleFeedSummary {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	feeds := make([]OracleFeedSummary, 0, len(bc.oracleFeeds))
+	for feed, history := range bc.oracleFeeds {
+		feeds = append(feeds, OracleFeedSummary{Feed: feed, Latest: history[len(history)-1], Points: len(history)})
+	}
+	sort.Slice(feeds, func(i, j int) bool { return feeds[i].Feed < feeds[j].Feed })
+	return feeds
+}
+
+// OracleHistory returns up to limit data points of feed, newest first
+func (bc *Blockchain) OracleHistory(feed string, limit int) ([]OracleRecord, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	history, exists := bc.oracleFeeds[feed]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrOracleFeedNotFound, feed)
+	}
+	if limit <= 0 || limit > len(history) {
+		limit = len(history)
+	}
+	records := make([]OracleRecord, 0, limit)
+	for i := len(history) - 1; i >= len(history)-limit; i-- {
+		records = append(records, history[i])
+	}
+	return records, nil
+}
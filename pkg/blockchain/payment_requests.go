@@ -0,0 +1,343 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// paymentRequestsFile holds the payment requests kept by this node
+const paymentRequestsFile = "payment_requests.json"
+
+// PaymentRequestDataPrefix starts the data of a transaction paying a request,
+// followed by the request ID
+const PaymentRequestDataPrefix = "payreq:"
+
+// PaymentRequestURIScheme is the scheme of shareable payment request URIs
+const PaymentRequestURIScheme = "confirmix"
+
+// Payment request statuses
+const (
+	PaymentRequestPending   = "pending"   // waiting for payment
+	PaymentRequestPartial   = "partial"   // paid in part before expiry
+	PaymentRequestPaid      = "paid"      // paid in full
+	PaymentRequestExpired   = "expired"   // expired before it was paid in full
+	PaymentRequestCancelled = "cancelled" // cancelled by its creator
+)
+
+// Limits on payment requests
+const (
+	MaxPaymentRequestMemo     = 256
+	MaxOpenPaymentRequests    = 10000
+	DefaultPaymentRequestTTL  = 24 * time.Hour
+	MaxPaymentRequestLifetime = 30 * 24 * time.Hour
+)
+
+var (
+	// ErrPaymentRequestNotFound is returned for unknown payment request IDs
+	ErrPaymentRequestNotFound = errors.New("payment request not found")
+	// ErrInvalidPaymentRequest is returned for payment requests that fail validation
+	ErrInvalidPaymentRequest = errors.New("invalid payment request")
+	// ErrPaymentRequestClosed is returned when changing a paid, expired or cancelled request
+	ErrPaymentRequestClosed = errors.New("payment request is closed")
+)
+
+// PaymentMatch is a confirmed transaction that paid a payment request
+type PaymentMatch struct {
+	TxID       string `json:"txId"`
+	From       string `json:"from"`
+	Value      uint64 `json:"value"`
+	BlockIndex uint64 `json:"blockIndex"`
+	Timestamp  int64  `json:"timestamp"`
+}
+
+// PaymentRequest asks for a payment to Recipient. A payer pays it with a
+// regular transaction to Recipient whose data is PaymentRequestDataPrefix
+// followed by the request ID, or whose TxMemo names the request; the node
+// matches such transactions as their blocks are applied. Payment requests are node metadata, not consensus
+// state, so only the node that created a request tracks it.
+type PaymentRequest struct {
+	ID        string         `json:"id"`
+	Recipient string         `json:"recipient"`
+	Amount    uint64         `json:"amount"`
+	Memo      string         `json:"memo,omitempty"`
+	CreatedBy string         `json:"-"` // owner allowed to cancel the request
+	CreatedAt int64          `json:"createdAt"`
+	ExpiresAt int64          `json:"expiresAt"`
+	Status    string         `json:"status"`
+	Paid      uint64         `json:"paid"` // sum of the payments made before expiry
+	PaidAt    int64          `json:"paidAt,omitempty"`
+	Payments  []PaymentMatch `json:"payments"`
+}
+
+// paymentRequestRecord is the persisted form of a request, keeping its owner
+type paymentRequestRecord struct {
+	PaymentRequest
+	CreatedBy string `json:"createdBy"`
+}
+
+// Data returns the transaction data that pays the request
+func (p *PaymentRequest) Data() string {
+	return PaymentRequestDataPrefix + p.ID
+}
+
+// URI returns the shareable form of the request, e.g.
+// confirmix:<recipient>?amount=100&request=<id>&memo=Invoice%2042
+func (p *PaymentRequest) URI() string {
+	query := url.Values{}
+	query.Set("amount", strconv.FormatUint(p.Amount, 10))
+	query.Set("request", p.ID)
+	if p.Memo != "" {
+		query.Set("memo", p.Memo)
+	}
+	return PaymentRequestURIScheme + ":" + p.Recipient + "?" + query.Encode()
+}
+
+// open reports whether the request still accepts payments at time now
+func (p *PaymentRequest) open(now int64) bool {
+	return (p.Status == PaymentRequestPending || p.Status == PaymentRequestPartial) && now <= p.ExpiresAt
+}
+
+// statusAt returns the status of the request at time now; open requests past
+// their expiry are reported as expired
+func (p *PaymentRequest) statusAt(now int64) string {
+	if (p.Status == PaymentRequestPending || p.Status == PaymentRequestPartial) && now > p.ExpiresAt {
+		return PaymentRequestExpired
+	}
+	return p.Status
+}
+
+// paymentRequestStore holds the payment requests by ID. It has its own lock
+// and is taken under bc.mu while blocks are applied, so its methods must not
+// take bc.mu while holding it.
+type paymentRequestStore struct {
+	mu       sync.RWMutex
+	requests map[string]*PaymentRequest
+}
+
+// CreatePaymentRequest records a request for amount to recipient, open for ttl
+// (DefaultPaymentRequestTTL when zero). owner identifies who may cancel it.
+func (bc *Blockchain) CreatePaymentRequest(owner, recipient string, amount uint64, memo string, ttl time.Duration) (PaymentRequest, error) {
+	recipient = strings.TrimSpace(recipient)
+	memo = strings.TrimSpace(memo)
+	if ttl == 0 {
+		ttl = DefaultPaymentRequestTTL
+	}
+	switch {
+	case recipient == "":
+		return PaymentRequest{}, fmt.Errorf("%w: recipient is required", ErrInvalidPaymentRequest)
+	case amount == 0:
+		return PaymentRequest{}, fmt.Errorf("%w: amount must be positive", ErrInvalidPaymentRequest)
+	case len(memo) > MaxPaymentRequestMemo:
+		return PaymentRequest{}, fmt.Errorf("%w: memo exceeds %d bytes", ErrInvalidPaymentRequest, MaxPaymentRequestMemo)
+	case ttl < 0 || ttl > MaxPaymentRequestLifetime:
+		return PaymentRequest{}, fmt.Errorf("%w: expiry must be within %s", ErrInvalidPaymentRequest, MaxPaymentRequestLifetime)
+	}
+
+	now := time.Now()
+	bc.payments.mu.Lock()
+	defer bc.payments.mu.Unlock()
+	if bc.payments.requests == nil {
+		bc.payments.requests = make(map[string]*PaymentRequest)
+	}
+	open := 0
+	for _, request := range bc.payments.requests {
+		if request.open(now.Unix()) {
+			open++
+		}
+	}
+	if open >= MaxOpenPaymentRequests {
+		return PaymentRequest{}, fmt.Errorf("%w: the node already tracks %d open requests", ErrInvalidPaymentRequest, MaxOpenPaymentRequests)
+	}
+
+	request := &PaymentRequest{
+		ID:        uuid.New().String(),
+		Recipient: recipient,
+		Amount:    amount,
+		Memo:      memo,
+		CreatedBy: owner,
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+		Status:    PaymentRequestPending,
+		Payments:  []PaymentMatch{},
+	}
+	bc.payments.requests[request.ID] = request
+	return copyPaymentRequest(request, now.Unix()), bc.savePaymentRequestsLocked(GetBlockchainDataPath())
+}
+
+// GetPaymentRequest returns a payment request with its fulfillment status
+func (bc *Blockchain) GetPaymentRequest(id string) (PaymentRequest, error) {
+	bc.payments.mu.RLock()
+	defer bc.payments.mu.RUnlock()
+	request, exists := bc.payments.requests[id]
+	if !exists {
+		return PaymentRequest{}, fmt.Errorf("%w: %s", ErrPaymentRequestNotFound, id)
+	}
+	return copyPaymentRequest(request, time.Now().Unix()), nil
+}
+
+// PaymentRequests returns the requests to recipient, or every request when
+// recipient is empty, optionally only those of one status, newest first
+func (bc *Blockchain) PaymentRequests(recipient, status string) []PaymentRequest {
+	now := time.Now().Unix()
+	bc.payments.mu.RLock()
+	result := make([]PaymentRequest, 0)
+	for _, request := range bc.payments.requests {
+		if recipient != "" && request.Recipient != recipient {
+			continue
+		}
+		if status != "" && request.statusAt(now) != status {
+			continue
+		}
+		result = append(result, copyPaymentRequest(request, now))
+	}
+	bc.payments.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CreatedAt != result[j].CreatedAt {
+			return result[i].CreatedAt > result[j].CreatedAt
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// CancelPaymentRequest closes an open request. Only its creator may cancel it.
+func (bc *Blockchain) CancelPaymentRequest(owner, id string) (PaymentRequest, error) {
+	now := time.Now().Unix()
+	bc.payments.mu.Lock()
+	defer bc.payments.mu.Unlock()
+	request, exists := bc.payments.requests[id]
+	if !exists || request.CreatedBy != owner {
+		return PaymentRequest{}, fmt.Errorf("%w: %s", ErrPaymentRequestNotFound, id)
+	}
+	if !request.open(now) {
+		return PaymentRequest{}, fmt.Errorf("%w: %s is %s", ErrPaymentRequestClosed, id, request.statusAt(now))
+	}
+	request.Status = PaymentRequestCancelled
+	return copyPaymentRequest(request, now), bc.savePaymentRequestsLocked(GetBlockchainDataPath())
+}
+
+// matchPaymentRequestsLocked records the confirmed transactions of a block
+// that pay open requests. Payments made after a request expired or was
+// cancelled are not counted. The caller must hold bc.mu.
+func (bc *Blockchain) matchPaymentRequestsLocked(block *Block) {
+	bc.payments.mu.Lock()
+	defer bc.payments.mu.Unlock()
+	if len(bc.payments.requests) == 0 {
+		return
+	}
+
+	matched := false
+	for _, tx := range block.Transactions {
+		if tx.Type != "regular" || tx.Status != "confirmed" {
+			continue
+		}
+		memo, ok, err := ParseTxMemo(tx)
+		if err != nil || !ok || memo.Request == "" {
+			continue
+		}
+		request, exists := bc.payments.requests[memo.Request]
+		if !exists || tx.To != request.Recipient || !request.open(block.Timestamp) || request.hasPayment(tx.ID) {
+			continue
+		}
+		request.Payments = append(request.Payments, PaymentMatch{
+			TxID:       tx.ID,
+			From:       tx.From,
+			Value:      tx.Value,
+			BlockIndex: block.Index,
+			Timestamp:  block.Timestamp,
+		})
+		request.Paid += tx.Value
+		if request.Paid >= request.Amount {
+			request.Status = PaymentRequestPaid
+			request.PaidAt = block.Timestamp
+		} else {
+			request.Status = PaymentRequestPartial
+		}
+		matched = true
+	}
+	if matched {
+		if err := bc.savePaymentRequestsLocked(GetBlockchainDataPath()); err != nil {
+			log.Printf("Warning: Failed to save payment requests: %v", err)
+		}
+	}
+}
+
+// hasPayment reports whether the transaction was already matched to the request
+func (p *PaymentRequest) hasPayment(txID string) bool {
+	for _, payment := range p.Payments {
+		if payment.TxID == txID {
+			return true
+		}
+	}
+	return false
+}
+
+// copyPaymentRequest returns a copy of request with its status at time now
+func copyPaymentRequest(request *PaymentRequest, now int64) PaymentRequest {
+	result := *request
+	result.Status = request.statusAt(now)
+	result.Payments = append([]PaymentMatch{}, request.Payments...)
+	return result
+}
+
+// savePaymentRequestsLocked writes the payment requests to dir. The caller
+// must hold bc.payments.mu.
+func (bc *Blockchain) savePaymentRequestsLocked(dir string) error {
+	records := make(map[string]paymentRequestRecord, len(bc.payments.requests))
+	for id, request := range bc.payments.requests {
+		records[id] = paymentRequestRecord{PaymentRequest: *request, CreatedBy: request.CreatedBy}
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal payment requests: %v", err)
+	}
+
+	path := filepath.Join(dir, paymentRequestsFile)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write payment requests: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadPaymentRequests reads the payment requests from dir
+func (bc *Blockchain) loadPaymentRequests(dir string) {
+	bc.payments.mu.Lock()
+	defer bc.payments.mu.Unlock()
+
+	bc.payments.requests = make(map[string]*PaymentRequest)
+	raw, err := ioutil.ReadFile(filepath.Join(dir, paymentRequestsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to read payment requests: %v", err)
+		}
+		return
+	}
+	records := make(map[string]paymentRequestRecord)
+	if err := json.Unmarshal(raw, &records); err != nil {
+		log.Printf("Warning: Failed to parse payment requests: %v", err)
+		return
+	}
+	for id, record := range records {
+		request := record.PaymentRequest
+		request.CreatedBy = record.CreatedBy
+		if request.Payments == nil {
+			request.Payments = []PaymentMatch{}
+		}
+		bc.payments.requests[id] = &request
+	}
+}
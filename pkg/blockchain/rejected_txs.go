@@ -0,0 +1,142 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// rejectedTxsFile holds the transactions dropped by the block builder
+const rejectedTxsFile = "rejected_txs.json"
+
+// MaxRejectedTransactions is how many rejected transactions are kept; the oldest are evicted first
+const MaxRejectedTransactions = 10000
+
+// TxRejection is a pending transaction the block builder dropped, and why
+type TxRejection struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// RejectedTransaction is a dropped transaction kept so its sender can find out what happened to it
+type RejectedTransaction struct {
+	Transaction *Transaction `json:"transaction"`
+	Reason      string       `json:"reason"`
+	RejectedAt  int64        `json:"rejectedAt"`
+	Height      uint64       `json:"height"` // chain height when it was dropped
+}
+
+// RejectTransactions removes transactions from the pool and records them with
+// the reason they were dropped. Transactions no longer pending are skipped.
+func (bc *Blockchain) RejectTransactions(rejections []TxRejection) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	now := time.Now().Unix()
+	recorded := 0
+	for _, rejection := range rejections {
+		tx, exists := bc.txPool[rejection.ID]
+		if !exists {
+			continue
+		}
+		delete(bc.txPool, rejection.ID)
+		bc.dropLifecycleLocked(rejection.ID)
+		for i, pending := range bc.pendingTxs {
+			if pending.ID == rejection.ID {
+				bc.pendingTxs = append(bc.pendingTxs[:i], bc.pendingTxs[i+1:]...)
+				break
+			}
+		}
+
+		bc.recordRejectionLocked(tx, rejection.Reason, now)
+		recorded++
+	}
+	if recorded == 0 {
+		return nil
+	}
+	return bc.saveRejectedTxsLocked(GetBlockchainDataPath())
+}
+
+// recordRejectionLocked keeps a dropped transaction with the reason, evicting
+// the oldest beyond MaxRejectedTransactions. The caller must hold bc.mu.
+func (bc *Blockchain) recordRejectionLocked(tx *Transaction, reason string, at int64) {
+	tx.Status = TxStatusRejected
+	bc.rejectedTxs = append(bc.rejectedTxs, RejectedTransaction{
+		Transaction: tx,
+		Reason:      reason,
+		RejectedAt:  at,
+		Height:      uint64(len(bc.Blocks)),
+	})
+	if excess := len(bc.rejectedTxs) - MaxRejectedTransactions; excess > 0 {
+		bc.rejectedTxs = append([]RejectedTransaction(nil), bc.rejectedTxs[excess:]...)
+	}
+}
+
+// RejectedTransactions returns rejected transactions, newest first, optionally
+// only those sent by or to address. limit <= 0 returns them all.
+func (bc *Blockchain) RejectedTransactions(address string, limit int) []RejectedTransaction {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	result := []RejectedTransaction{}
+	for i := len(bc.rejectedTxs) - 1; i >= 0; i-- {
+		rejected := bc.rejectedTxs[i]
+		if address != "" && rejected.Transaction.From != address && rejected.Transaction.To != address {
+			continue
+		}
+		result = append(result, rejected)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
+
+// GetRejectedTransaction returns the most recent rejection of a transaction
+func (bc *Blockchain) GetRejectedTransaction(txID string) (RejectedTransaction, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	for i := len(bc.rejectedTxs) - 1; i >= 0; i-- {
+		if bc.rejectedTxs[i].Transaction.ID == txID {
+			return bc.rejectedTxs[i], true
+		}
+	}
+	return RejectedTransaction{}, false
+}
+
+// saveRejectedTxsLocked writes the rejected transactions to dir. The caller must hold bc.mu.
+func (bc *Blockchain) saveRejectedTxsLocked(dir string) error {
+	data, err := json.Marshal(bc.rejectedTxs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rejected transactions: %v", err)
+	}
+
+	path := filepath.Join(dir, rejectedTxsFile)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write rejected transactions: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadRejectedTxsLocked reads the rejected transactions from dir. The caller must hold bc.mu.
+func (bc *Blockchain) loadRejectedTxsLocked(dir string) {
+	raw, err := ioutil.ReadFile(filepath.Join(dir, rejectedTxsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to read rejected transactions: %v", err)
+		}
+		return
+	}
+	var rejected []RejectedTransaction
+	if err := json.Unmarshal(raw, &rejected); err != nil {
+		log.Printf("Warning: Failed to parse rejected transactions: %v", err)
+		return
+	}
+	bc.rejectedTxs = rejected
+}
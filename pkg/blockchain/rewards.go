@@ -0,0 +1,192 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// GenesisRewardScheduleTxType is the transaction type recording the emission
+// schedule in the genesis block
+const GenesisRewardScheduleTxType = "genesis_reward_schedule"
+
+// ErrInvalidRewardSchedule is returned for an emission schedule that cannot be used
+var ErrInvalidRewardSchedule = errors.New("invalid reward schedule")
+
+// RewardSchedule is the block reward emission: InitialReward halves every
+// HalvingInterval blocks and never drops below TailEmission. Rewards stop once
+// the total supply, genesis allocations included, reaches MaxSupply. Amounts
+// are decimal strings in base units (18 decimals).
+type RewardSchedule struct {
+	InitialReward   string `json:"initial_reward"`
+	HalvingInterval uint64 `json:"halving_interval"`        // 0 disables halving
+	TailEmission    string `json:"tail_emission,omitempty"` // floor of the reward, empty or "0" for none
+	MaxSupply       string `json:"max_supply,omitempty"`    // cap of the total supply, empty for none
+}
+
+// DefaultRewardSchedule returns the emission of chains whose genesis block
+// records no schedule: 50 tokens halving every 210,000 blocks, without tail
+func DefaultRewardSchedule() RewardSchedule {
+	return RewardSchedule{
+		InitialReward:   "50000000000000000000", // 50 tokens with 18 decimals
+		HalvingInterval: 210000,
+	}
+}
+
+// parse validates the schedule and returns its amounts
+func (s RewardSchedule) parse() (initial, tail *big.Int, err error) {
+	initial, ok := new(big.Int).SetString(s.InitialReward, 10)
+	if !ok || initial.Sign() < 0 {
+		return nil, nil, fmt.Errorf("%w: initial_reward must be a non-negative integer, got %q", ErrInvalidRewardSchedule, s.InitialReward)
+	}
+	tail = new(big.Int)
+	if s.TailEmission != "" {
+		if tail, ok = new(big.Int).SetString(s.TailEmission, 10); !ok || tail.Sign() < 0 {
+			return nil, nil, fmt.Errorf("%w: tail_emission must be a non-negative integer, got %q", ErrInvalidRewardSchedule, s.TailEmission)
+		}
+	}
+	if tail.Cmp(initial) > 0 {
+		return nil, nil, fmt.Errorf("%w: tail_emission exceeds initial_reward", ErrInvalidRewardSchedule)
+	}
+	if s.MaxSupply != "" {
+		if max, ok := new(big.Int).SetString(s.MaxSupply, 10); !ok || max.Sign() < 0 {
+			return nil, nil, fmt.Errorf("%w: max_supply must be a non-negative integer, got %q", ErrInvalidRewardSchedule, s.MaxSupply)
+		}
+	}
+	return initial, tail, nil
+}
+
+// maxSupply returns the cap of the total supply, or nil when there is none
+func (s RewardSchedule) maxSupply() *big.Int {
+	if s.MaxSupply == "" {
+		return nil
+	}
+	max, ok := new(big.Int).SetString(s.MaxSupply, 10)
+	if !ok || max.Sign() < 0 {
+		return nil
+	}
+	return max
+}
+
+// Validate checks that the schedule can be used
+func (s RewardSchedule) Validate() error {
+	_, _, err := s.parse()
+	return err
+}
+
+// Reward returns the reward for the block at height
+func (s RewardSchedule) Reward(height uint64) *big.Int {
+	initial, tail, err := s.parse()
+	if err != nil {
+		return big.NewInt(0)
+	}
+
+	reward := initial
+	if s.HalvingInterval > 0 {
+		if epoch := height / s.HalvingInterval; epoch >= 256 {
+			reward = big.NewInt(0)
+		} else {
+			reward = initial.Rsh(initial, uint(epoch))
+		}
+	}
+	if reward.Cmp(tail) < 0 {
+		return tail
+	}
+	return reward
+}
+
+var (
+	rewardScheduleMu sync.RWMutex
+	// genesisRewardSchedule is written into the genesis block of a new chain
+	genesisRewardSchedule = DefaultRewardSchedule()
+)
+
+// SetGenesisRewardSchedule sets the emission schedule recorded in the genesis
+// block of a new chain. Like the chain ID it must match across the network and
+// be set before the blockchain is created; existing chains keep the schedule
+// of their genesis block.
+func SetGenesisRewardSchedule(schedule RewardSchedule) error {
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	rewardScheduleMu.Lock()
+	defer rewardScheduleMu.Unlock()
+	genesisRewardSchedule = schedule
+	return nil
+}
+
+// attachGenesisRewardSchedule records a non-default emission schedule in the
+// genesis block. Chains on the default schedule keep the genesis they always had.
+func attachGenesisRewardSchedule(genesis *Block) error {
+	rewardScheduleMu.RLock()
+	schedule := genesisRewardSchedule
+	rewardScheduleMu.RUnlock()
+	if schedule == DefaultRewardSchedule() {
+		return nil
+	}
+
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return err
+	}
+	tx := NewTransaction("genesis_reward_schedule", GenesisSender, GenesisSender, 0, data)
+	tx.Type = GenesisRewardScheduleTxType
+	tx.Timestamp = genesis.Timestamp
+	tx.Status = "confirmed"
+	tx.BlockIndex = 0
+	genesis.Transactions = append(genesis.Transactions, tx)
+	return nil
+}
+
+// genesisRewardScheduleOf returns the schedule recorded in a genesis block, or
+// the default schedule when it records none
+func genesisRewardScheduleOf(genesis *Block) (RewardSchedule, error) {
+	for _, tx := range genesis.Transactions {
+		if tx.Type != GenesisRewardScheduleTxType {
+			continue
+		}
+		var schedule RewardSchedule
+		if err := json.Unmarshal(tx.Data, &schedule); err != nil {
+			return RewardSchedule{}, fmt.Errorf("%w: genesis schedule: %v", ErrInvalidRewardSchedule, err)
+		}
+		return schedule, schedule.Validate()
+	}
+	return DefaultRewardSchedule(), nil
+}
+
+// applyGenesisRewardScheduleLocked adopts the emission schedule of the genesis
+// block. The caller must hold bc.mu.
+func (bc *Blockchain) applyGenesisRewardScheduleLocked(genesis *Block) error {
+	schedule, err := genesisRewardScheduleOf(genesis)
+	if err != nil {
+		return err
+	}
+	bc.rewardSchedule = schedule
+	return nil
+}
+
+// RewardSchedule returns the emission schedule of this chain
+func (bc *Blockchain) RewardSchedule() RewardSchedule {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.rewardSchedule
+}
+
+// BlockReward returns the reward for the block at the given height under the
+// chain's emission schedule, capped by the supply left to issue at the tip
+func (bc *Blockchain) BlockReward(height uint64) *big.Int {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.blockRewardLocked(height)
+}
+
+// blockRewardLocked is BlockReward for callers holding bc.mu
+func (bc *Blockchain) blockRewardLocked(height uint64) *big.Int {
+	reward := bc.rewardSchedule.Reward(height)
+	if left := bc.issuableLocked(); left != nil && reward.Cmp(left) > 0 {
+		return left
+	}
+	return reward
+}
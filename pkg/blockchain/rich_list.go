@@ -0,0 +1,192 @@
+package blockchain
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+)
+
+// MaxRichListSize caps the accounts returned by RichList
+const MaxRichListSize = 1000
+
+// RichListEntry is one account of the rich list
+type RichListEntry struct {
+	Rank    int     `json:"rank"`
+	Address string  `json:"address"`
+	Balance string  `json:"balance"`
+	Share   float64 `json:"share"`           // Balance / total supply
+	Label   string  `json:"label,omitempty"` // public address label, see labels.go
+}
+
+// RichList is the top accounts by balance. Amounts are decimal strings in base units.
+type RichList struct {
+	Height      uint64          `json:"height"`
+	TotalSupply string          `json:"totalSupply"`
+	Holders     int             `json:"holders"` // accounts with a balance, excluded ones included
+	Excluded    []string        `json:"excluded,omitempty"`
+	Accounts    []RichListEntry `json:"accounts"`
+}
+
+// richIndex keeps the accounts with a balance ordered by balance, so the rich
+// list is read from the top instead of sorting every account per request.
+// Balance writers mark the addresses they change and the index re-sorts only
+// those when it is next read. It has its own lock, always taken after bc.mu.
+type richIndex struct {
+	mu       sync.Mutex
+	stale    bool                // rebuild from all accounts on the next read
+	dirty    map[string]bool     // addresses changed since the last read
+	balances map[string]*big.Int // indexed balance of every ranked address
+	ranked   []string            // addresses by balance, highest first, then by address
+	excluded map[string]bool     // system and treasury addresses left out of the list
+}
+
+// touch marks addresses whose balance changed
+func (ri *richIndex) touch(addresses ...string) {
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+	if ri.dirty == nil {
+		ri.dirty = make(map[string]bool)
+	}
+	for _, address := range addresses {
+		ri.dirty[address] = true
+	}
+}
+
+// reset makes the next read rebuild the index, after the accounts were replaced
+func (ri *richIndex) reset() {
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+	ri.stale = true
+	ri.dirty = nil
+}
+
+// richLess orders a before b: higher balance first, then by address
+func richLess(a string, aBalance *big.Int, b string, bBalance *big.Int) bool {
+	if c := aBalance.Cmp(bBalance); c != 0 {
+		return c > 0
+	}
+	return a < b
+}
+
+// refreshLocked applies the pending changes, reading balances from accounts.
+// The caller must hold ri.mu and bc.mu.
+func (ri *richIndex) refreshLocked(accounts map[string]*big.Int) {
+	if ri.stale || ri.balances == nil {
+		ri.balances = make(map[string]*big.Int, len(accounts))
+		ri.ranked = ri.ranked[:0]
+		for address, balance := range accounts {
+			if balance != nil && balance.Sign() > 0 {
+				ri.balances[address] = new(big.Int).Set(balance)
+				ri.ranked = append(ri.ranked, address)
+			}
+		}
+		sort.Slice(ri.ranked, func(i, j int) bool {
+			return richLess(ri.ranked[i], ri.balances[ri.ranked[i]], ri.ranked[j], ri.balances[ri.ranked[j]])
+		})
+		ri.stale, ri.dirty = false, nil
+		return
+	}
+
+	for address := range ri.dirty {
+		ri.remove(address)
+		if balance, exists := accounts[address]; exists && balance != nil && balance.Sign() > 0 {
+			ri.insert(address, new(big.Int).Set(balance))
+		}
+	}
+	ri.dirty = nil
+}
+
+// search returns the position of address with balance in ranked, or where it would go
+func (ri *richIndex) search(address string, balance *big.Int) int {
+	return sort.Search(len(ri.ranked), func(i int) bool {
+		other := ri.ranked[i]
+		return !richLess(other, ri.balances[other], address, balance)
+	})
+}
+
+// remove drops address from the index
+func (ri *richIndex) remove(address string) {
+	balance, exists := ri.balances[address]
+	if !exists {
+		return
+	}
+	if i := ri.search(address, balance); i < len(ri.ranked) && ri.ranked[i] == address {
+		ri.ranked = append(ri.ranked[:i], ri.ranked[i+1:]...)
+	}
+	delete(ri.balances, address)
+}
+
+// insert adds address with balance to the index
+func (ri *richIndex) insert(address string, balance *big.Int) {
+	i := ri.search(address, balance)
+	ri.ranked = append(ri.ranked, "")
+	copy(ri.ranked[i+1:], ri.ranked[i:])
+	ri.ranked[i] = address
+	ri.balances[address] = balance
+}
+
+// SetRichListExclusions sets the system and treasury addresses left out of the
+// rich list. They still count towards the total supply the shares are taken of.
+func (bc *Blockchain) SetRichListExclusions(addresses []string) {
+	bc.richIndex.mu.Lock()
+	defer bc.richIndex.mu.Unlock()
+	bc.richIndex.excluded = make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		if address != "" {
+			bc.richIndex.excluded[address] = true
+		}
+	}
+}
+
+// RichList returns up to limit accounts with the highest balances and their
+// share of the total supply, leaving out the excluded addresses
+func (bc *Blockchain) RichList(limit int) RichList {
+	if limit <= 0 || limit > MaxRichListSize {
+		limit = MaxRichListSize
+	}
+
+	bc.mu.RLock()
+	supply := bc.totalSupplyLocked()
+	list := RichList{
+		Height:      uint64(len(bc.Blocks)) - 1,
+		TotalSupply: supply.String(),
+		Accounts:    []RichListEntry{},
+	}
+
+	bc.richIndex.mu.Lock()
+	bc.richIndex.refreshLocked(bc.accounts)
+	bc.mu.RUnlock()
+
+	list.Holders = len(bc.richIndex.ranked)
+	for address := range bc.richIndex.excluded {
+		list.Excluded = append(list.Excluded, address)
+	}
+	sort.Strings(list.Excluded)
+	total := new(big.Float).SetInt(supply)
+	for _, address := range bc.richIndex.ranked {
+		if len(list.Accounts) >= limit {
+			break
+		}
+		if bc.richIndex.excluded[address] {
+			continue
+		}
+		balance := bc.richIndex.balances[address]
+		entry := RichListEntry{
+			Rank:    len(list.Accounts) + 1,
+			Address: address,
+			Balance: balance.String(),
+		}
+		if supply.Sign() > 0 {
+			entry.Share, _ = new(big.Float).Quo(new(big.Float).SetInt(balance), total).Float64()
+		}
+		list.Accounts = append(list.Accounts, entry)
+	}
+	bc.richIndex.mu.Unlock()
+
+	for i := range list.Accounts {
+		if label, exists := bc.GetAddressLabel(list.Accounts[i].Address); exists {
+			list.Accounts[i].Label = label.Label
+		}
+	}
+	return list
+}
@@ -0,0 +1,276 @@
+package blockchain
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// KeyType identifies the signature algorithm of a key. It doubles as the version
+// byte of addresses derived from keys of that type.
+type KeyType byte
+
+// Supported key types
+const (
+	KeyTypeP256    KeyType = 0x01 // ECDSA on NIST P-256, the original scheme
+	KeyTypeEd25519 KeyType = 0x02 // Ed25519, faster to verify
+)
+
+// ErrUnsupportedKeyType is returned for key types without a registered scheme
+var ErrUnsupportedKeyType = errors.New("unsupported key type")
+
+// String returns the name of the key type
+func (t KeyType) String() string {
+	switch t {
+	case KeyTypeP256:
+		return "p256"
+	case KeyTypeEd25519:
+		return "ed25519"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(t))
+	}
+}
+
+// ParseKeyType parses a key type name; an empty name selects P-256
+func ParseKeyType(name string) (KeyType, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "p256", "p-256", "ecdsa":
+		return KeyTypeP256, nil
+	case "ed25519":
+		return KeyTypeEd25519, nil
+	default:
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedKeyType, name)
+	}
+}
+
+// PublicKey verifies signatures of one signature scheme
+type PublicKey interface {
+	Type() KeyType
+	Bytes() []byte
+	Verify(digest, signature []byte) bool
+}
+
+// PrivateKey signs digests with one signature scheme
+type PrivateKey interface {
+	Type() KeyType
+	Public() PublicKey
+	Bytes() []byte
+	Sign(digest []byte) ([]byte, error)
+}
+
+// SignatureScheme creates and decodes the keys of one algorithm
+type SignatureScheme interface {
+	Type() KeyType
+	GenerateKey() (PrivateKey, error)
+	ParsePublicKey(data []byte) (PublicKey, error)
+	ParsePrivateKey(data []byte) (PrivateKey, error)
+}
+
+var (
+	schemesMu sync.RWMutex
+	schemes   = map[KeyType]SignatureScheme{
+		KeyTypeP256:    p256Scheme{},
+		KeyTypeEd25519: ed25519Scheme{},
+	}
+)
+
+// RegisterSignatureScheme makes a signature scheme available for keys of its type
+func RegisterSignatureScheme(scheme SignatureScheme) {
+	schemesMu.Lock()
+	defer schemesMu.Unlock()
+	schemes[scheme.Type()] = scheme
+}
+
+// SchemeFor returns the signature scheme of a key type
+func SchemeFor(t KeyType) (SignatureScheme, error) {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+	scheme, exists := schemes[t]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKeyType, t)
+	}
+	return scheme, nil
+}
+
+// ParsePublicKey decodes a public key of any registered scheme. The encodings do
+// not overlap: P-256 keys are 65-byte uncompressed points, Ed25519 keys are 32 bytes.
+func ParsePublicKey(data []byte) (PublicKey, error) {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+	for _, scheme := range schemes {
+		if key, err := scheme.ParsePublicKey(data); err == nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unrecognized public key of %d bytes", ErrUnsupportedKeyType, len(data))
+}
+
+// AddressFromPublicKey derives the address of a key. P-256 addresses keep their
+// original form, 0x followed by the last 20 bytes of sha256(public key); other
+// schemes prefix those 20 bytes with their key type as a version byte.
+func AddressFromPublicKey(key PublicKey) string {
+	hash := sha256.Sum256(key.Bytes())
+	body := hash[len(hash)-20:]
+	if key.Type() == KeyTypeP256 {
+		return "0x" + hex.EncodeToString(body)
+	}
+	return "0x" + hex.EncodeToString(append([]byte{byte(key.Type())}, body...))
+}
+
+// AddressKeyType returns the key type encoded in an address. Addresses without
+// a version byte belong to P-256 keys.
+func AddressKeyType(address string) KeyType {
+	raw, err := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+	if err != nil || len(raw) != 21 {
+		return KeyTypeP256
+	}
+	if _, err := SchemeFor(KeyType(raw[0])); err != nil {
+		return KeyTypeP256
+	}
+	return KeyType(raw[0])
+}
+
+// p256Scheme is ECDSA over NIST P-256 with fixed-size r||s signatures
+type p256Scheme struct{}
+
+type p256PublicKey struct{ key *ecdsa.PublicKey }
+
+type p256PrivateKey struct{ key *ecdsa.PrivateKey }
+
+func (p256Scheme) Type() KeyType { return KeyTypeP256 }
+
+func (p256Scheme) GenerateKey() (PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return p256PrivateKey{key}, nil
+}
+
+func (p256Scheme) ParsePublicKey(data []byte) (PublicKey, error) {
+	x, y := elliptic.Unmarshal(elliptic.P256(), data)
+	if x == nil {
+		return nil, errors.New("invalid P-256 public key")
+	}
+	return p256PublicKey{&ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}}, nil
+}
+
+func (p256Scheme) ParsePrivateKey(data []byte) (PrivateKey, error) {
+	key, err := privateKeyFromScalar(data)
+	if err != nil {
+		return nil, err
+	}
+	return p256PrivateKey{key}, nil
+}
+
+func (k p256PublicKey) Type() KeyType { return KeyTypeP256 }
+
+func (k p256PublicKey) Bytes() []byte {
+	return elliptic.Marshal(elliptic.P256(), k.key.X, k.key.Y)
+}
+
+// Verify accepts r||s signatures as produced by Sign as well as ASN.1 DER
+// signatures, which external tools produce for signed API requests
+func (k p256PublicKey) Verify(digest, signature []byte) bool {
+	if len(signature) == 0 {
+		return false
+	}
+	if ecdsa.VerifyASN1(k.key, digest, signature) {
+		return true
+	}
+	r := new(big.Int).SetBytes(signature[:len(signature)/2])
+	s := new(big.Int).SetBytes(signature[len(signature)/2:])
+	return ecdsa.Verify(k.key, digest, r, s)
+}
+
+func (k p256PrivateKey) Type() KeyType { return KeyTypeP256 }
+
+func (k p256PrivateKey) Public() PublicKey { return p256PublicKey{&k.key.PublicKey} }
+
+func (k p256PrivateKey) Bytes() []byte { return k.key.D.FillBytes(make([]byte, 32)) }
+
+func (k p256PrivateKey) Sign(digest []byte) ([]byte, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, k.key, digest)
+	if err != nil {
+		return nil, err
+	}
+	// Pad both halves so the signature can always be split in the middle
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signature, nil
+}
+
+// ed25519Scheme is Ed25519 as specified in RFC 8032
+type ed25519Scheme struct{}
+
+type ed25519PublicKey struct{ key ed25519.PublicKey }
+
+type ed25519PrivateKey struct{ key ed25519.PrivateKey }
+
+func (ed25519Scheme) Type() KeyType { return KeyTypeEd25519 }
+
+func (ed25519Scheme) GenerateKey() (PrivateKey, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519PrivateKey{key}, nil
+}
+
+func (ed25519Scheme) ParsePublicKey(data []byte) (PublicKey, error) {
+	if len(data) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid Ed25519 public key")
+	}
+	return ed25519PublicKey{ed25519.PublicKey(append([]byte(nil), data...))}, nil
+}
+
+// ParsePrivateKey accepts the 32-byte seed or the 64-byte expanded key
+func (ed25519Scheme) ParsePrivateKey(data []byte) (PrivateKey, error) {
+	switch len(data) {
+	case ed25519.SeedSize:
+		return ed25519PrivateKey{ed25519.NewKeyFromSeed(data)}, nil
+	case ed25519.PrivateKeySize:
+		return ed25519PrivateKey{ed25519.PrivateKey(append([]byte(nil), data...))}, nil
+	default:
+		return nil, errors.New("invalid Ed25519 private key")
+	}
+}
+
+func (k ed25519PublicKey) Type() KeyType { return KeyTypeEd25519 }
+
+func (k ed25519PublicKey) Bytes() []byte { return []byte(k.key) }
+
+func (k ed25519PublicKey) Verify(digest, signature []byte) bool {
+	return len(signature) == ed25519.SignatureSize && ed25519.Verify(k.key, digest, signature)
+}
+
+func (k ed25519PrivateKey) Type() KeyType { return KeyTypeEd25519 }
+
+func (k ed25519PrivateKey) Public() PublicKey {
+	return ed25519PublicKey{k.key.Public().(ed25519.PublicKey)}
+}
+
+func (k ed25519PrivateKey) Bytes() []byte { return k.key.Seed() }
+
+func (k ed25519PrivateKey) Sign(digest []byte) ([]byte, error) {
+	return ed25519.Sign(k.key, digest), nil
+}
+
+// P256PrivateKey wraps an ECDSA P-256 key as a PrivateKey
+func P256PrivateKey(key *ecdsa.PrivateKey) PrivateKey {
+	return p256PrivateKey{key}
+}
+
+// P256PublicKey wraps an ECDSA P-256 key as a PublicKey
+func P256PublicKey(key *ecdsa.PublicKey) PublicKey {
+	return p256PublicKey{key}
+}
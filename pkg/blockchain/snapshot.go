@@ -0,0 +1,122 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StateFiles are the files that make up the persisted chain state
+var StateFiles = []string{"blocks.json", "validators.json", "accounts.json", "multisig.json"}
+
+// StateExport is a consistent, in-memory copy of the persisted chain state
+type StateExport struct {
+	Height    uint64
+	Hash      string
+	CreatedAt time.Time
+	Files     map[string][]byte // file name -> contents, keyed by StateFiles
+}
+
+// exportStateLocked marshals the chain state into the contents of StateFiles.
+// The caller must hold bc.mu.
+func (bc *Blockchain) exportStateLocked() (map[string][]byte, error) {
+	files := make(map[string][]byte, len(StateFiles))
+
+	// Blocks
+	blocksData, err := json.MarshalIndent(bc.Blocks, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal blocks: %v", err)
+	}
+	files["blocks.json"] = blocksData
+
+	// Validators with their human proofs
+	validatorsMap := make(map[string]string)
+	for addr := range bc.validators {
+		validatorsMap[addr] = bc.humanProofs[addr]
+	}
+	validatorsData, err := json.MarshalIndent(validatorsMap, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal validators: %v", err)
+	}
+	files["validators.json"] = validatorsData
+
+	// Accounts
+	accountsMap := make(map[string]string)
+	for addr, balance := range bc.accounts {
+		accountsMap[addr] = balance.String()
+	}
+	accountsData, err := json.MarshalIndent(accountsMap, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal accounts: %v", err)
+	}
+	files["accounts.json"] = accountsData
+
+	// Multi-signature wallets
+	multiSigData, err := json.MarshalIndent(bc.multiSigWallets, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal multi-signature wallets: %v", err)
+	}
+	files["multisig.json"] = multiSigData
+
+	return files, nil
+}
+
+// ExportState returns a consistent copy of the chain state taken under a single lock,
+// suitable for backups while the node keeps running
+func (bc *Blockchain) ExportState() (*StateExport, error) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	files, err := bc.exportStateLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	latest := bc.Blocks[len(bc.Blocks)-1]
+	return &StateExport{
+		Height:    latest.Index,
+		Hash:      latest.Hash,
+		CreatedAt: time.Now(),
+		Files:     files,
+	}, nil
+}
+
+// Snapshot copies a consistent export of the state into a timestamped directory
+// under data/snapshots, compacting the accounts first when enabled. It returns
+// the snapshot directory.
+func (bc *Blockchain) Snapshot() (string, error) {
+	bc.mu.RLock()
+	compact := bc.gcOnSnapshot
+	bc.mu.RUnlock()
+	if compact {
+		if _, err := bc.CompactAccounts(); err != nil {
+			return "", fmt.Errorf("failed to compact accounts before snapshot: %v", err)
+		}
+	}
+
+	export, err := bc.ExportState()
+	if err != nil {
+		return "", fmt.Errorf("failed to export state for snapshot: %v", err)
+	}
+
+	snapshotDir := filepath.Join(GetBlockchainDataPath(), "snapshots", export.CreatedAt.Format("20060102-150405"))
+	if err := os.MkdirAll(snapshotDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %v", err)
+	}
+
+	for _, name := range StateFiles {
+		if err := ioutil.WriteFile(filepath.Join(snapshotDir, name), export.Files[name], 0644); err != nil {
+			return "", fmt.Errorf("failed to write %s to snapshot: %v", name, err)
+		}
+	}
+	if err := writeStateChecksums(snapshotDir, export.Files); err != nil {
+		return "", fmt.Errorf("failed to write snapshot checksums: %v", err)
+	}
+
+	log.Printf("Blockchain snapshot created: %s", snapshotDir)
+	return snapshotDir, nil
+}
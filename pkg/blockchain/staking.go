@@ -0,0 +1,675 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// Transaction types by which token holders bond stake to validators. The
+// delegator signs them and numbers them with its staking nonce, so every
+// request is confirmed at most once and on every node alike.
+const (
+	StakingDelegateTxType   = "staking_delegate"   // bonds part of the sender's balance to a validator
+	StakingUndelegateTxType = "staking_undelegate" // starts unbonding part of a delegation
+	StakingClaimTxType      = "staking_claim"      // pays the sender its unclaimed rewards
+)
+
+// StakingPoolAddress holds the delegators' rewards until they are claimed.
+// Only claims move funds out of it.
+const StakingPoolAddress = "confirmix_staking_pool"
+
+var (
+	// ErrInvalidStaking is returned for staking transactions that fail validation
+	ErrInvalidStaking = errors.New("invalid staking transaction")
+	// ErrStakingNonceUsed is returned for a staking request whose nonce the
+	// delegator already used
+	ErrStakingNonceUsed = errors.New("staking nonce already used")
+	// ErrDelegationNotFound is returned for an unknown delegator and validator pair
+	ErrDelegationNotFound = errors.New("delegation not found")
+	// ErrNoRewards is returned when a delegator has no rewards to claim
+	ErrNoRewards = errors.New("no rewards to claim")
+)
+
+// StakingConfig holds the delegation rules. It is a consensus parameter:
+// blocks are applied by it, so all nodes must agree on it.
+type StakingConfig struct {
+	UnbondingEpochs uint64   // full epochs undelegated tokens stay locked
+	MinDelegation   *big.Int // smallest amount that can be delegated at once; nil allows any
+}
+
+// DefaultStakingConfig returns the default delegation rules
+func DefaultStakingConfig() StakingConfig {
+	return StakingConfig{UnbondingEpochs: 7}
+}
+
+// StakingRequest is the payload of a staking transaction. Claims name no
+// validator or amount. Nonce must be above the delegator's last confirmed one.
+type StakingRequest struct {
+	Validator string `json:"validator,omitempty"`
+	Amount    string `json:"amount,omitempty"` // in base units
+	Nonce     uint64 `json:"nonce"`
+}
+
+// Delegation is the stake a token holder bonded to a validator
+type Delegation struct {
+	Delegator string   `json:"delegator"`
+	Validator string   `json:"validator"`
+	Amount    *big.Int `json:"amount"`  // bonded tokens, locked in the delegator's account
+	Rewards   *big.Int `json:"rewards"` // earned and not claimed yet, held by the staking pool
+	Claimed   *big.Int `json:"claimed"` // rewards paid out so far
+}
+
+// Unbonding is undelegated stake waiting for the end of the unbonding period
+type Unbonding struct {
+	Delegator        string   `json:"delegator"`
+	Validator        string   `json:"validator"`
+	Amount           *big.Int `json:"amount"`
+	CreatedHeight    uint64   `json:"createdHeight"`
+	CompletionHeight uint64   `json:"completionHeight"` // the tokens are unlocked when this block is applied
+	TxID             string   `json:"txId"`
+}
+
+// stakingLedger is the staking state confirmed on chain. It is rebuilt from
+// the blocks on load, see rebuildStakingLocked.
+type stakingLedger struct {
+	delegations map[string]map[string]*Delegation // validator -> delegator -> delegation
+	unbondings  []*Unbonding                      // in the order they were confirmed
+	nonces      map[string]uint64                 // last confirmed staking nonce per delegator
+	commission  map[string]*big.Int               // commission earned per validator
+}
+
+// newStakingLedger returns an empty staking ledger
+func newStakingLedger() stakingLedger {
+	return stakingLedger{
+		delegations: make(map[string]map[string]*Delegation),
+		nonces:      make(map[string]uint64),
+		commission:  make(map[string]*big.Int),
+	}
+}
+
+// delegation returns the delegation of delegator to validator, creating it
+// when create is set
+func (l *stakingLedger) delegation(delegator, validator string, create bool) *Delegation {
+	delegations, exists := l.delegations[validator]
+	if !exists {
+		if !create {
+			return nil
+		}
+		delegations = make(map[string]*Delegation)
+		l.delegations[validator] = delegations
+	}
+	delegation, exists := delegations[delegator]
+	if !exists && create {
+		delegation = &Delegation{
+			Delegator: delegator,
+			Validator: validator,
+			Amount:    big.NewInt(0),
+			Rewards:   big.NewInt(0),
+			Claimed:   big.NewInt(0),
+		}
+		delegations[delegator] = delegation
+	}
+	return delegation
+}
+
+// prune forgets a delegation with nothing bonded and nothing to claim
+func (l *stakingLedger) prune(delegation *Delegation) {
+	if delegation.Amount.Sign() > 0 || delegation.Rewards.Sign() > 0 {
+		return
+	}
+	delete(l.delegations[delegation.Validator], delegation.Delegator)
+	if len(l.delegations[delegation.Validator]) == 0 {
+		delete(l.delegations, delegation.Validator)
+	}
+}
+
+// SetStakingConfig sets the delegation rules
+func (bc *Blockchain) SetStakingConfig(config StakingConfig) error {
+	if config.MinDelegation != nil && config.MinDelegation.Sign() < 0 {
+		return errors.New("the minimum delegation cannot be negative")
+	}
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.stakingConfig = config
+	return nil
+}
+
+// StakingConfig returns the delegation rules
+func (bc *Blockchain) StakingConfig() StakingConfig {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.stakingConfig
+}
+
+// isStakingTransaction reports whether tx is a delegator's staking request
+func isStakingTransaction(tx *Transaction) bool {
+	return tx.Type == StakingDelegateTxType || tx.Type == StakingUndelegateTxType || tx.Type == StakingClaimTxType
+}
+
+// StakingTxID is the ID of a delegator's staking request with nonce, so a
+// nonce can only be queued once
+func StakingTxID(delegator string, nonce uint64) string {
+	return fmt.Sprintf("staking_%s_%d", delegator, nonce)
+}
+
+// NewStakingTransaction creates the unsigned staking request of a delegator
+func NewStakingTransaction(txType, delegator string, request StakingRequest) (*Transaction, error) {
+	if txType != StakingDelegateTxType && txType != StakingUndelegateTxType && txType != StakingClaimTxType {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidStaking, txType)
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	to := request.Validator
+	if txType == StakingClaimTxType {
+		to = delegator
+	}
+	tx := NewTransaction(StakingTxID(delegator, request.Nonce), delegator, to, 0, data)
+	tx.Type = txType
+	return tx, nil
+}
+
+// ParseStakingRequest decodes and validates the payload of a staking
+// transaction and returns it with its amount, nil for claims
+func ParseStakingRequest(tx *Transaction) (*StakingRequest, *big.Int, error) {
+	if tx == nil || !isStakingTransaction(tx) {
+		return nil, nil, fmt.Errorf("%w: not a staking transaction", ErrInvalidStaking)
+	}
+	var request StakingRequest
+	if err := json.Unmarshal(tx.Data, &request); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidStaking, err)
+	}
+	if request.Nonce == 0 {
+		return nil, nil, fmt.Errorf("%w: nonces start at 1", ErrInvalidStaking)
+	}
+	if tx.Type == StakingClaimTxType {
+		return &request, nil, nil
+	}
+	if request.Validator == "" {
+		return nil, nil, fmt.Errorf("%w: the validator is required", ErrInvalidStaking)
+	}
+	amount, ok := new(big.Int).SetString(request.Amount, 10)
+	if !ok || amount.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("%w: amount must be a positive integer", ErrInvalidStaking)
+	}
+	return &request, amount, nil
+}
+
+// checkStakingTransactionLocked validates a staking request for inclusion in
+// a block: it must carry no value, be identified by its nonce and use a nonce
+// above the delegator's last confirmed one. Delegations must go to an active
+// validator and meet the minimum. The generic signature check makes sure the
+// delegator sent it. The caller must hold bc.mu.
+func (bc *Blockchain) checkStakingTransactionLocked(tx *Transaction) error {
+	if !isStakingTransaction(tx) {
+		return nil
+	}
+	request, amount, err := ParseStakingRequest(tx)
+	if err != nil {
+		return err
+	}
+	if tx.Value != 0 {
+		return fmt.Errorf("%w: staking transactions carry no value", ErrInvalidStaking)
+	}
+	if id := StakingTxID(tx.From, request.Nonce); tx.ID != id {
+		return fmt.Errorf("%w: the request with nonce %d must have ID %s", ErrInvalidStaking, request.Nonce, id)
+	}
+	if last := bc.staking.nonces[tx.From]; request.Nonce <= last {
+		return fmt.Errorf("%w: %s already used nonce %d", ErrStakingNonceUsed, tx.From, last)
+	}
+	if tx.Type != StakingDelegateTxType {
+		return nil
+	}
+	if !bc.validators[request.Validator] {
+		return fmt.Errorf("%w: %s is not accepting delegations", ErrUnknownValidator, request.Validator)
+	}
+	if min := bc.stakingConfig.MinDelegation; min != nil && amount.Cmp(min) < 0 {
+		return fmt.Errorf("%w: delegation amount must be at least %s", ErrInvalidStaking, min.String())
+	}
+	return nil
+}
+
+// admitStakingTransactionLocked checks a staking request before it enters
+// the pool: besides the block rules, the delegator must hold what it
+// delegates, have bonded what it undelegates and have rewards to claim. The
+// caller must hold bc.mu.
+func (bc *Blockchain) admitStakingTransactionLocked(tx *Transaction) error {
+	if err := bc.checkStakingTransactionLocked(tx); err != nil || !isStakingTransaction(tx) {
+		return err
+	}
+	request, amount, _ := ParseStakingRequest(tx)
+	switch tx.Type {
+	case StakingDelegateTxType:
+		if balance := bc.accounts[tx.From]; balance == nil || balance.Cmp(amount) < 0 {
+			return fmt.Errorf("%w: %s cannot delegate %s", ErrInsufficientBalance, tx.From, amount.String())
+		}
+	case StakingUndelegateTxType:
+		delegation := bc.staking.delegation(tx.From, request.Validator, false)
+		if delegation == nil {
+			return fmt.Errorf("%w: %s to %s", ErrDelegationNotFound, tx.From, request.Validator)
+		}
+		if amount.Cmp(delegation.Amount) > 0 {
+			return fmt.Errorf("%w: %s delegated, trying to undelegate %s", ErrInsufficientLocked, delegation.Amount.String(), amount.String())
+		}
+	case StakingClaimTxType:
+		if bc.unclaimedRewardsLocked(tx.From).Sign() == 0 {
+			return fmt.Errorf("%w: %s", ErrNoRewards, tx.From)
+		}
+	}
+	return nil
+}
+
+// applyStakingTransactionLocked applies a confirmed staking request. The
+// caller must hold bc.mu.
+func (bc *Blockchain) applyStakingTransactionLocked(tx *Transaction, block *Block) error {
+	if err := bc.checkStakingTransactionLocked(tx); err != nil {
+		return err
+	}
+	return bc.recordStakingTransactionLocked(tx, block, true)
+}
+
+// recordStakingTransactionLocked applies a staking request to the ledger and,
+// with balances, moves the funds: delegated tokens are locked in the
+// delegator's account and claimed rewards move out of the staking pool.
+// Undelegated tokens stay locked until the unbonding completes. The nonce is
+// used once the request succeeded. The caller must hold bc.mu.
+func (bc *Blockchain) recordStakingTransactionLocked(tx *Transaction, block *Block, balances bool) error {
+	request, amount, err := ParseStakingRequest(tx)
+	if err != nil {
+		return err
+	}
+
+	switch tx.Type {
+	case StakingDelegateTxType:
+		if balances {
+			if err := bc.lockStakeLocked(tx.From, amount); err != nil {
+				return err
+			}
+		}
+		delegation := bc.staking.delegation(tx.From, request.Validator, true)
+		delegation.Amount = new(big.Int).Add(delegation.Amount, amount)
+
+	case StakingUndelegateTxType:
+		delegation := bc.staking.delegation(tx.From, request.Validator, false)
+		if delegation == nil {
+			return fmt.Errorf("%w: %s to %s", ErrDelegationNotFound, tx.From, request.Validator)
+		}
+		if amount.Cmp(delegation.Amount) > 0 {
+			return fmt.Errorf("%w: %s delegated, trying to undelegate %s", ErrInsufficientLocked, delegation.Amount.String(), amount.String())
+		}
+		delegation.Amount = new(big.Int).Sub(delegation.Amount, amount)
+		length := bc.epochRewards.EpochLength
+		bc.staking.unbondings = append(bc.staking.unbondings, &Unbonding{
+			Delegator:        tx.From,
+			Validator:        request.Validator,
+			Amount:           new(big.Int).Set(amount),
+			CreatedHeight:    block.Index,
+			CompletionHeight: (block.Index/length + bc.stakingConfig.UnbondingEpochs + 1) * length,
+			TxID:             tx.ID,
+		})
+		bc.staking.prune(delegation)
+
+	case StakingClaimTxType:
+		total := bc.unclaimedRewardsLocked(tx.From)
+		if total.Sign() == 0 {
+			return fmt.Errorf("%w: %s", ErrNoRewards, tx.From)
+		}
+		if balances {
+			if err := bc.payStakingRewardLocked(tx.From, total); err != nil {
+				return err
+			}
+		}
+		for _, delegations := range bc.staking.delegations {
+			if delegation, exists := delegations[tx.From]; exists && delegation.Rewards.Sign() > 0 {
+				delegation.Claimed = new(big.Int).Add(delegation.Claimed, delegation.Rewards)
+				delegation.Rewards = big.NewInt(0)
+				bc.staking.prune(delegation)
+			}
+		}
+	}
+
+	bc.staking.nonces[tx.From] = request.Nonce
+	return nil
+}
+
+// unclaimedRewardsLocked returns the rewards of all delegations of a
+// delegator. The caller must hold bc.mu.
+func (bc *Blockchain) unclaimedRewardsLocked(delegator string) *big.Int {
+	total := big.NewInt(0)
+	for _, delegations := range bc.staking.delegations {
+		if delegation, exists := delegations[delegator]; exists {
+			total.Add(total, delegation.Rewards)
+		}
+	}
+	return total
+}
+
+// completeUnbondingsLocked ends the unbondings that complete at height and,
+// with balances, unlocks their tokens. Stake burned in the meantime is not
+// unlocked. The caller must hold bc.mu.
+func (bc *Blockchain) completeUnbondingsLocked(height uint64, balances bool) {
+	remaining := bc.staking.unbondings[:0]
+	for _, unbonding := range bc.staking.unbondings {
+		if unbonding.CompletionHeight > height {
+			remaining = append(remaining, unbonding)
+			continue
+		}
+		if balances {
+			bc.unlockStakeLocked(unbonding.Delegator, unbonding.Amount)
+		}
+	}
+	bc.staking.unbondings = remaining
+}
+
+// splitRewardLocked shares amount, earned by validator at height, among the
+// stake bonded to it on chain. A validator's own stake counts once it
+// delegates it to itself, and its part stays with it. The other delegators'
+// part, minus the validator's commission, is credited in proportion to their
+// delegations; what rounds down stays with the validator. It returns the
+// credit per delegator, their sum and the commission. The caller must hold bc.mu.
+func (bc *Blockchain) splitRewardLocked(validator string, height uint64, amount *big.Int) (credits map[string]*big.Int, paid, commission *big.Int) {
+	credits, paid, commission = make(map[string]*big.Int), big.NewInt(0), big.NewInt(0)
+	delegations := bc.staking.delegations[validator]
+	if len(delegations) == 0 || amount.Sign() == 0 {
+		return credits, paid, commission
+	}
+
+	bonded, others := big.NewInt(0), big.NewInt(0)
+	for delegator, delegation := range delegations {
+		bonded.Add(bonded, delegation.Amount)
+		if delegator != validator {
+			others.Add(others, delegation.Amount)
+		}
+	}
+	if others.Sign() == 0 {
+		return credits, paid, commission
+	}
+
+	share := new(big.Int).Mul(amount, others)
+	share.Quo(share, bonded)
+	commission, pool := bc.splitCommissionLocked(validator, height, share)
+	for delegator, delegation := range delegations {
+		if delegator == validator {
+			continue
+		}
+		credit := new(big.Int).Mul(pool, delegation.Amount)
+		credit.Quo(credit, others)
+		if credit.Sign() > 0 {
+			credits[delegator] = credit
+			paid.Add(paid, credit)
+		}
+	}
+	return credits, paid, commission
+}
+
+// SplitReward shares amount, earned by validator at height, with its
+// delegators by the stake bonded on chain, see splitRewardLocked
+func (bc *Blockchain) SplitReward(validator string, height uint64, amount *big.Int) RewardSplit {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	credits, _, commission := bc.splitRewardLocked(validator, height, amount)
+	return RewardSplit{Delegators: credits, Commission: commission}
+}
+
+// shareBlockRewardLocked credits the delegators of a block's validator with
+// their share of the block reward minted to it by tx and, with balances,
+// moves that share to the staking pool. Once epoch rewards are active the
+// reward goes to the epoch reward pool instead and is shared when it is
+// distributed. The caller must hold bc.mu.
+func (bc *Blockchain) shareBlockRewardLocked(block *Block, tx *Transaction, balances bool) {
+	if tx.To != block.Validator || tx.Value == 0 {
+		return
+	}
+	credits, paid, commission := bc.splitRewardLocked(block.Validator, block.Index, new(big.Int).SetUint64(tx.Value))
+	if paid.Sign() == 0 {
+		return
+	}
+	if balances {
+		// The validator was just minted at least paid, so the move cannot fail
+		bc.mutex.Lock()
+		bc.accounts[block.Validator] = new(big.Int).Sub(bc.accounts[block.Validator], paid)
+		pool, exists := bc.accounts[StakingPoolAddress]
+		if !exists {
+			pool = big.NewInt(0)
+		}
+		bc.accounts[StakingPoolAddress] = new(big.Int).Add(pool, paid)
+		bc.richIndex.touch(block.Validator, StakingPoolAddress)
+		bc.mutex.Unlock()
+	}
+	for delegator, credit := range credits {
+		delegation := bc.staking.delegation(delegator, block.Validator, false)
+		delegation.Rewards = new(big.Int).Add(delegation.Rewards, credit)
+	}
+	bc.addCommissionLocked(block.Validator, commission)
+}
+
+// recordEpochPayoutLocked books a confirmed epoch reward payout: what a
+// delegator was paid counts as claimed and a validator's commission as
+// earned. The caller must hold bc.mu.
+func (bc *Blockchain) recordEpochPayoutLocked(tx *Transaction) {
+	reward, amount, err := ParseEpochReward(tx)
+	if err != nil {
+		return
+	}
+	switch reward.Role {
+	case EpochRewardDelegator:
+		if delegation := bc.staking.delegation(tx.To, reward.Validator, false); delegation != nil {
+			delegation.Claimed = new(big.Int).Add(delegation.Claimed, amount)
+		}
+	case EpochRewardValidator:
+		if commission, ok := new(big.Int).SetString(reward.Commission, 10); ok {
+			bc.addCommissionLocked(reward.Validator, commission)
+		}
+	}
+}
+
+// addCommissionLocked adds to the commission a validator earned. The caller must hold bc.mu.
+func (bc *Blockchain) addCommissionLocked(validator string, commission *big.Int) {
+	if commission == nil || commission.Sign() <= 0 {
+		return
+	}
+	earned, exists := bc.staking.commission[validator]
+	if !exists {
+		earned = big.NewInt(0)
+	}
+	bc.staking.commission[validator] = new(big.Int).Add(earned, commission)
+}
+
+// lockStakeLocked moves amount of the spendable balance of address into its
+// locked balance. The caller must hold bc.mu.
+func (bc *Blockchain) lockStakeLocked(address string, amount *big.Int) error {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	balance := bc.accounts[address]
+	if balance == nil || balance.Cmp(amount) < 0 {
+		return fmt.Errorf("%w: %s cannot lock %s", ErrInsufficientBalance, address, amount.String())
+	}
+	bc.accounts[address] = new(big.Int).Sub(balance, amount)
+	locked, exists := bc.lockedBalances[address]
+	if !exists {
+		locked = big.NewInt(0)
+	}
+	bc.lockedBalances[address] = new(big.Int).Add(locked, amount)
+	bc.richIndex.touch(address)
+	return nil
+}
+
+// unlockStakeLocked moves up to amount of the locked balance of address back
+// into its spendable balance. The caller must hold bc.mu.
+func (bc *Blockchain) unlockStakeLocked(address string, amount *big.Int) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	locked := bc.lockedBalances[address]
+	if locked == nil || locked.Sign() == 0 {
+		return
+	}
+	if amount.Cmp(locked) > 0 {
+		amount = locked
+	}
+	bc.lockedBalances[address] = new(big.Int).Sub(locked, amount)
+	balance, exists := bc.accounts[address]
+	if !exists {
+		balance = big.NewInt(0)
+	}
+	bc.accounts[address] = new(big.Int).Add(balance, amount)
+	bc.richIndex.touch(address)
+}
+
+// payStakingRewardLocked moves claimed rewards from the staking pool to the
+// delegator. The caller must hold bc.mu.
+func (bc *Blockchain) payStakingRewardLocked(delegator string, amount *big.Int) error {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	pool := bc.accounts[StakingPoolAddress]
+	if pool == nil || pool.Cmp(amount) < 0 {
+		return fmt.Errorf("%w: the staking pool cannot pay %s", ErrInsufficientBalance, amount.String())
+	}
+	bc.accounts[StakingPoolAddress] = new(big.Int).Sub(pool, amount)
+	balance, exists := bc.accounts[delegator]
+	if !exists {
+		balance = big.NewInt(0)
+	}
+	bc.accounts[delegator] = new(big.Int).Add(balance, amount)
+	bc.richIndex.touch(StakingPoolAddress, delegator)
+	return nil
+}
+
+// replayStakingLocked applies the staking effects of a confirmed transaction
+// of block without checking it again: staking requests, the delegators'
+// share of block rewards and epoch reward payouts. The caller must hold bc.mu.
+func (bc *Blockchain) replayStakingLocked(tx *Transaction, block *Block, balances bool) {
+	switch {
+	case isStakingTransaction(tx):
+		bc.recordStakingTransactionLocked(tx, block, balances)
+	case tx.Type == RewardTxType:
+		bc.shareBlockRewardLocked(block, tx, balances)
+	case tx.Type == EpochRewardTxType:
+		bc.recordEpochPayoutLocked(tx)
+	}
+}
+
+// rebuildStakingLocked replays the commission schedules and the staking
+// ledger from the confirmed transactions of the chain. Balances are loaded
+// with the stake already taken out, so only the locked balances are restored.
+// The caller must hold bc.mu.
+func (bc *Blockchain) rebuildStakingLocked() {
+	bc.staking = newStakingLedger()
+	bc.commissions = make(map[string][]CommissionChange)
+	for _, block := range bc.Blocks {
+		bc.completeUnbondingsLocked(block.Index, false)
+		for _, tx := range block.Transactions {
+			if tx.Status != "confirmed" {
+				continue
+			}
+			if tx.Type == ValidatorCommissionTxType {
+				bc.applyCommissionTransactionLocked(tx, block)
+				continue
+			}
+			bc.replayStakingLocked(tx, block, false)
+		}
+	}
+
+	for _, delegations := range bc.staking.delegations {
+		for _, delegation := range delegations {
+			bc.addLockedLocked(delegation.Delegator, delegation.Amount)
+		}
+	}
+	for _, unbonding := range bc.staking.unbondings {
+		bc.addLockedLocked(unbonding.Delegator, unbonding.Amount)
+	}
+}
+
+// addLockedLocked adds amount to the locked balance of address. The caller must hold bc.mu.
+func (bc *Blockchain) addLockedLocked(address string, amount *big.Int) {
+	locked, exists := bc.lockedBalances[address]
+	if !exists {
+		locked = big.NewInt(0)
+	}
+	bc.lockedBalances[address] = new(big.Int).Add(locked, amount)
+}
+
+// NextStakingNonce returns the nonce of the next staking request of a
+// delegator: one above its last, confirmed or waiting in the pool
+func (bc *Blockchain) NextStakingNonce(delegator string) uint64 {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	last := bc.staking.nonces[delegator]
+	for _, tx := range bc.pendingTxs {
+		if tx.From != delegator || !isStakingTransaction(tx) {
+			continue
+		}
+		if request, _, err := ParseStakingRequest(tx); err == nil && request.Nonce > last {
+			last = request.Nonce
+		}
+	}
+	return last + 1
+}
+
+// VerifyStakingTransaction checks a staking request for inclusion in the next block
+func (bc *Blockchain) VerifyStakingTransaction(tx *Transaction) error {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	if tx == nil || !isStakingTransaction(tx) {
+		return fmt.Errorf("%w: not a staking transaction", ErrInvalidStaking)
+	}
+	return bc.checkStakingTransactionLocked(tx)
+}
+
+// DelegatorStakes returns the delegations of a delegator ordered by validator
+// and its pending unbondings
+func (bc *Blockchain) DelegatorStakes(delegator string) ([]Delegation, []Unbonding) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	delegations := []Delegation{}
+	for _, byDelegator := range bc.staking.delegations {
+		if delegation, exists := byDelegator[delegator]; exists {
+			delegations = append(delegations, copyDelegation(delegation))
+		}
+	}
+	sort.Slice(delegations, func(i, j int) bool { return delegations[i].Validator < delegations[j].Validator })
+
+	unbondings := []Unbonding{}
+	for _, unbonding := range bc.staking.unbondings {
+		if unbonding.Delegator == delegator {
+			copied := *unbonding
+			copied.Amount = new(big.Int).Set(unbonding.Amount)
+			unbondings = append(unbondings, copied)
+		}
+	}
+	return delegations, unbondings
+}
+
+// ValidatorDelegations returns the delegations to a validator ordered by
+// delegator and the commission it earned on them
+func (bc *Blockchain) ValidatorDelegations(validator string) ([]Delegation, *big.Int) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	delegations := make([]Delegation, 0, len(bc.staking.delegations[validator]))
+	for _, delegation := range bc.staking.delegations[validator] {
+		delegations = append(delegations, copyDelegation(delegation))
+	}
+	sort.Slice(delegations, func(i, j int) bool { return delegations[i].Delegator < delegations[j].Delegator })
+
+	commission := big.NewInt(0)
+	if earned := bc.staking.commission[validator]; earned != nil {
+		commission.Set(earned)
+	}
+	return delegations, commission
+}
+
+// copyDelegation returns a delegation whose amounts are not shared with the ledger
+func copyDelegation(d *Delegation) Delegation {
+	return Delegation{
+		Delegator: d.Delegator,
+		Validator: d.Validator,
+		Amount:    new(big.Int).Set(d.Amount),
+		Rewards:   new(big.Int).Set(d.Rewards),
+		Claimed:   new(big.Int).Set(d.Claimed),
+	}
+}
@@ -0,0 +1,56 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+// StateDigest fingerprints the state of the node at a height so that nodes can
+// be compared without exchanging the full state
+type StateDigest struct {
+	Height     uint64   `json:"height"`
+	BlockHash  string   `json:"blockHash"`
+	StateHash  string   `json:"stateHash"` // SHA-256 over the non-zero balances and the validator set
+	Accounts   int      `json:"accounts"`  // non-zero balances included in StateHash
+	Validators []string `json:"validators"`
+}
+
+// StateDigest computes the digest of the current state. Zero balances are
+// left out, since nodes compact empty accounts at different times.
+func (bc *Blockchain) StateDigest() StateDigest {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	digest := StateDigest{Validators: make([]string, 0, len(bc.validators))}
+	if len(bc.Blocks) > 0 {
+		latest := bc.Blocks[len(bc.Blocks)-1]
+		digest.Height = latest.Index
+		digest.BlockHash = latest.Hash
+	}
+
+	addresses := make([]string, 0, len(bc.accounts))
+	for address, balance := range bc.accounts {
+		if balance != nil && balance.Sign() != 0 {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+	for address, active := range bc.validators {
+		if active {
+			digest.Validators = append(digest.Validators, address)
+		}
+	}
+	sort.Strings(digest.Validators)
+
+	h := sha256.New()
+	for _, address := range addresses {
+		h.Write([]byte("account:" + address + "=" + bc.accounts[address].String() + "\n"))
+	}
+	for _, address := range digest.Validators {
+		h.Write([]byte("validator:" + address + "\n"))
+	}
+	digest.StateHash = hex.EncodeToString(h.Sum(nil))
+	digest.Accounts = len(addresses)
+	return digest
+}
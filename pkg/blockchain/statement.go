@@ -0,0 +1,169 @@
+package blockchain
+
+import (
+	"errors"
+	"math/big"
+)
+
+// StatementEntry is a single credit or debit in an account statement
+type StatementEntry struct {
+	Timestamp    int64  `json:"timestamp"`
+	BlockIndex   uint64 `json:"blockIndex"`
+	BlockHash    string `json:"blockHash"`
+	TxID         string `json:"txId"`
+	Type         string `json:"type"`
+	Counterparty string `json:"counterparty"`
+	Category     string `json:"category,omitempty"` // category tag of the transfer's memo
+	Credit       string `json:"credit"`
+	Debit        string `json:"debit"`
+	Balance      string `json:"balance"` // running balance after this entry
+}
+
+// AccountStatement is a chronological statement for one address over a time range
+type AccountStatement struct {
+	Address        string           `json:"address"`
+	From           int64            `json:"from"`
+	To             int64            `json:"to"`
+	OpeningBalance string           `json:"openingBalance"`
+	TotalCredits   string           `json:"totalCredits"`
+	TotalDebits    string           `json:"totalDebits"`
+	ClosingBalance string           `json:"closingBalance"`
+	Entries        []StatementEntry `json:"entries"`
+	Label          *AddressLabel    `json:"label,omitempty"`
+}
+
+// txBalanceEffect returns the balance change a transaction causes for address,
+// following the same rules as UpdateBalances. Only confirmed transactions move
+// funds; failed ones leave the balance as it was.
+func txBalanceEffect(tx *Transaction, address string) *big.Int {
+	value := new(big.Int).SetUint64(tx.Value)
+	effect := big.NewInt(0)
+	if tx.Status != "confirmed" {
+		return effect
+	}
+
+	if tx.Type == "reward" {
+		if tx.To == address {
+			effect.Add(effect, value)
+		}
+		return effect
+	}
+
+	// Self transfers are rejected by UpdateBalances and do not move funds
+	if tx.From == tx.To {
+		return effect
+	}
+	if tx.From == address {
+		effect.Sub(effect, value)
+	}
+	if tx.To == address {
+		effect.Add(effect, value)
+	}
+	return effect
+}
+
+// GetAccountStatement builds a statement for address covering blocks with
+// from <= timestamp <= to (unix seconds; to == 0 means up to now).
+//
+// Balances are anchored on the current account balance and walked back through
+// the chain, so allocations made outside of transactions (such as the genesis
+// supply) are reflected in the opening balance.
+func (bc *Blockchain) GetAccountStatement(address string, from, to int64) (*AccountStatement, error) {
+	if address == "" {
+		return nil, errors.New("address is required")
+	}
+	if to != 0 && to < from {
+		return nil, errors.New("statement end must not be before its start")
+	}
+
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	current := big.NewInt(0)
+	if balance, exists := bc.accounts[address]; exists {
+		current.Set(balance)
+	}
+
+	inRange := func(ts int64) bool {
+		return ts >= from && (to == 0 || ts <= to)
+	}
+
+	// Net change after the end of the range gives the closing balance
+	afterRange := big.NewInt(0)
+	var entries []StatementEntry
+	totalCredits := big.NewInt(0)
+	totalDebits := big.NewInt(0)
+
+	for _, block := range bc.Blocks {
+		for _, tx := range block.Transactions {
+			effect := txBalanceEffect(tx, address)
+			if effect.Sign() == 0 {
+				continue
+			}
+
+			if to != 0 && block.Timestamp > to {
+				afterRange.Add(afterRange, effect)
+				continue
+			}
+			if !inRange(block.Timestamp) {
+				continue
+			}
+
+			entry := StatementEntry{
+				Timestamp:  block.Timestamp,
+				BlockIndex: block.Index,
+				BlockHash:  block.Hash,
+				TxID:       tx.ID,
+				Type:       tx.Type,
+				Credit:     "0",
+				Debit:      "0",
+			}
+			if effect.Sign() > 0 {
+				entry.Credit = effect.String()
+				entry.Counterparty = tx.From
+				totalCredits.Add(totalCredits, effect)
+			} else {
+				debit := new(big.Int).Neg(effect)
+				entry.Debit = debit.String()
+				entry.Counterparty = tx.To
+				totalDebits.Add(totalDebits, debit)
+			}
+			if entry.Type == "" {
+				entry.Type = "regular"
+			}
+			if memo, ok, err := ParseTxMemo(tx); err == nil && ok {
+				entry.Category = memo.Category
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	closing := new(big.Int).Sub(current, afterRange)
+	opening := new(big.Int).Sub(closing, totalCredits)
+	opening.Add(opening, totalDebits)
+
+	// Fill in the running balance
+	running := new(big.Int).Set(opening)
+	for i := range entries {
+		credit, _ := new(big.Int).SetString(entries[i].Credit, 10)
+		debit, _ := new(big.Int).SetString(entries[i].Debit, 10)
+		running.Add(running, credit)
+		running.Sub(running, debit)
+		entries[i].Balance = running.String()
+	}
+
+	if entries == nil {
+		entries = []StatementEntry{}
+	}
+
+	return &AccountStatement{
+		Address:        address,
+		From:           from,
+		To:             to,
+		OpeningBalance: opening.String(),
+		TotalCredits:   totalCredits.String(),
+		TotalDebits:    totalDebits.String(),
+		ClosingBalance: closing.String(),
+		Entries:        entries,
+	}, nil
+}
@@ -0,0 +1,343 @@
+package blockchain
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// Threshold signatures are FROST-style Schnorr signatures over P-256. The
+// owners of a key split it into shares so that any threshold of them can sign
+// together in two rounds, producing one signature that verifies against the
+// group key like a single-signer signature. The chain only verifies; key
+// generation and signing happen off-chain with the helpers below.
+
+// ThresholdSignatureSize is the size of a threshold signature: the compressed
+// group commitment R followed by the 32-byte response z
+const ThresholdSignatureSize = 33 + 32
+
+// Domain separators of the hashes used by threshold signatures
+const (
+	thresholdChallengeDomain = "confirmix/frost/challenge"
+	thresholdBindingDomain   = "confirmix/frost/binding"
+)
+
+// ErrInvalidThresholdKey is returned for a group key that is not a compressed P-256 point
+var ErrInvalidThresholdKey = errors.New("invalid threshold group key")
+
+// ThresholdKeyShare is one owner's share of a threshold key. Secret must
+// never leave the owner; PublicShare and GroupKey can be published.
+type ThresholdKeyShare struct {
+	Index       uint32   `json:"index"` // participant index, starting at 1
+	Threshold   int      `json:"threshold"`
+	Secret      *big.Int `json:"secret"`
+	PublicShare []byte   `json:"publicShare"` // compressed Secret*G
+	GroupKey    []byte   `json:"groupKey"`    // compressed group public key
+}
+
+// ThresholdCommitment is the public half of a signing nonce, sent to the
+// other signers in the first round
+type ThresholdCommitment struct {
+	Index   uint32 `json:"index"`
+	Hiding  []byte `json:"hiding"`  // compressed d*G
+	Binding []byte `json:"binding"` // compressed e*G
+}
+
+// ThresholdNonce is a signer's secret nonce pair for one signature. It is
+// cleared when used and must never be reused.
+type ThresholdNonce struct {
+	index   uint32
+	hiding  *big.Int
+	binding *big.Int
+}
+
+// ThresholdSignatureShare is one signer's response in the second round
+type ThresholdSignatureShare struct {
+	Index uint32   `json:"index"`
+	Z     *big.Int `json:"z"`
+}
+
+// SplitThresholdKey generates a key and splits it into total shares, any
+// threshold of which can sign. It acts as a trusted dealer: whoever runs it
+// sees the whole key, so it should run on a machine the owners trust and the
+// shares should be handed out and erased.
+func SplitThresholdKey(threshold, total int) ([]*ThresholdKeyShare, error) {
+	if threshold < 1 || threshold > total {
+		return nil, fmt.Errorf("threshold %d must be between 1 and the %d participants", threshold, total)
+	}
+	curve := elliptic.P256()
+	n := curve.Params().N
+
+	// A random polynomial of degree threshold-1 whose constant term is the key
+	coefficients := make([]*big.Int, threshold)
+	for i := range coefficients {
+		c, err := randomScalar()
+		if err != nil {
+			return nil, err
+		}
+		coefficients[i] = c
+	}
+	gx, gy := curve.ScalarBaseMult(scalarBytes(coefficients[0]))
+	groupKey := elliptic.MarshalCompressed(curve, gx, gy)
+
+	shares := make([]*ThresholdKeyShare, total)
+	for i := 0; i < total; i++ {
+		x := big.NewInt(int64(i + 1))
+		secret := new(big.Int)
+		for j := len(coefficients) - 1; j >= 0; j-- {
+			secret.Mul(secret, x)
+			secret.Add(secret, coefficients[j])
+			secret.Mod(secret, n)
+		}
+		px, py := curve.ScalarBaseMult(scalarBytes(secret))
+		shares[i] = &ThresholdKeyShare{
+			Index:       uint32(i + 1),
+			Threshold:   threshold,
+			Secret:      secret,
+			PublicShare: elliptic.MarshalCompressed(curve, px, py),
+			GroupKey:    groupKey,
+		}
+	}
+	return shares, nil
+}
+
+// NewThresholdNonce draws the nonce pair of a signer for one signature
+func NewThresholdNonce(index uint32) (*ThresholdNonce, *ThresholdCommitment, error) {
+	hiding, err := randomScalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	binding, err := randomScalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	curve := elliptic.P256()
+	dx, dy := curve.ScalarBaseMult(scalarBytes(hiding))
+	ex, ey := curve.ScalarBaseMult(scalarBytes(binding))
+	return &ThresholdNonce{index: index, hiding: hiding, binding: binding},
+		&ThresholdCommitment{
+			Index:   index,
+			Hiding:  elliptic.MarshalCompressed(curve, dx, dy),
+			Binding: elliptic.MarshalCompressed(curve, ex, ey),
+		}, nil
+}
+
+// Sign produces the signature share of this key share over digest. The
+// commitments are those of every participating signer, including this one.
+// The nonce is consumed.
+func (share *ThresholdKeyShare) Sign(nonce *ThresholdNonce, commitments []ThresholdCommitment, digest []byte) (*ThresholdSignatureShare, error) {
+	if nonce == nil || nonce.hiding == nil {
+		return nil, errors.New("threshold nonce is missing or was already used")
+	}
+	if nonce.index != share.Index {
+		return nil, fmt.Errorf("nonce of participant %d used by participant %d", nonce.index, share.Index)
+	}
+	session, err := newThresholdSession(share.GroupKey, commitments, digest)
+	if err != nil {
+		return nil, err
+	}
+	if len(session.commitments) < share.Threshold {
+		return nil, fmt.Errorf("%d signers are fewer than the threshold of %d", len(session.commitments), share.Threshold)
+	}
+	rho, participating := session.binding[share.Index]
+	if !participating {
+		return nil, fmt.Errorf("participant %d has no commitment in the signing set", share.Index)
+	}
+
+	// z = d + e*rho + lambda*s*c
+	n := elliptic.P256().Params().N
+	z := new(big.Int).Mul(nonce.binding, rho)
+	z.Add(z, nonce.hiding)
+	term := new(big.Int).Mul(session.lagrange(share.Index), share.Secret)
+	term.Mul(term, session.challenge)
+	z.Add(z, term)
+	z.Mod(z, n)
+
+	nonce.hiding, nonce.binding = nil, nil
+	return &ThresholdSignatureShare{Index: share.Index, Z: z}, nil
+}
+
+// AggregateThresholdSignature combines the signature shares of every signer
+// in commitments into one signature and checks it against the group key
+func AggregateThresholdSignature(groupKey []byte, commitments []ThresholdCommitment, shares []ThresholdSignatureShare, digest []byte) ([]byte, error) {
+	session, err := newThresholdSession(groupKey, commitments, digest)
+	if err != nil {
+		return nil, err
+	}
+	if len(shares) != len(session.commitments) {
+		return nil, fmt.Errorf("got %d signature shares for %d signers", len(shares), len(session.commitments))
+	}
+
+	n := elliptic.P256().Params().N
+	z := new(big.Int)
+	seen := make(map[uint32]bool, len(shares))
+	for _, share := range shares {
+		if _, exists := session.binding[share.Index]; !exists || seen[share.Index] {
+			return nil, fmt.Errorf("unexpected signature share of participant %d", share.Index)
+		}
+		if share.Z == nil {
+			return nil, fmt.Errorf("empty signature share of participant %d", share.Index)
+		}
+		seen[share.Index] = true
+		z.Add(z, share.Z)
+	}
+	z.Mod(z, n)
+
+	signature := append(append([]byte{}, session.commitment...), scalarBytes(z)...)
+	if !VerifyThresholdSignature(groupKey, digest, signature) {
+		return nil, fmt.Errorf("%w: aggregated threshold signature does not verify", ErrInvalidSignature)
+	}
+	return signature, nil
+}
+
+// VerifyThresholdSignature checks a threshold signature over digest against
+// a compressed group key: z*G must equal R + c*Y
+func VerifyThresholdSignature(groupKey, digest, signature []byte) bool {
+	if len(signature) != ThresholdSignatureSize {
+		return false
+	}
+	curve := elliptic.P256()
+	yx, yy := elliptic.UnmarshalCompressed(curve, groupKey)
+	if yx == nil {
+		return false
+	}
+	rx, ry := elliptic.UnmarshalCompressed(curve, signature[:33])
+	if rx == nil {
+		return false
+	}
+	z := new(big.Int).SetBytes(signature[33:])
+	if z.Cmp(curve.Params().N) >= 0 {
+		return false
+	}
+
+	c := thresholdChallenge(signature[:33], groupKey, digest)
+	lx, ly := curve.ScalarBaseMult(scalarBytes(z))
+	cx, cy := curve.ScalarMult(yx, yy, scalarBytes(c))
+	sx, sy := curve.Add(rx, ry, cx, cy)
+	return lx.Cmp(sx) == 0 && ly.Cmp(sy) == 0
+}
+
+// ParseThresholdKey checks that a group key is a compressed P-256 point
+func ParseThresholdKey(groupKey []byte) error {
+	if x, _ := elliptic.UnmarshalCompressed(elliptic.P256(), groupKey); x == nil {
+		return ErrInvalidThresholdKey
+	}
+	return nil
+}
+
+// thresholdSession holds what every signer of one signature derives from the
+// commitments: the binding factors, the group commitment and the challenge
+type thresholdSession struct {
+	commitments []ThresholdCommitment // sorted by index
+	binding     map[uint32]*big.Int
+	commitment  []byte // compressed group commitment R
+	challenge   *big.Int
+}
+
+func newThresholdSession(groupKey []byte, commitments []ThresholdCommitment, digest []byte) (*thresholdSession, error) {
+	if err := ParseThresholdKey(groupKey); err != nil {
+		return nil, err
+	}
+	if len(commitments) == 0 {
+		return nil, errors.New("no signer commitments")
+	}
+	sorted := append([]ThresholdCommitment{}, commitments...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+
+	// Every binding factor covers the whole signing set, so a signer cannot
+	// pick its nonce after seeing the others
+	encoded := make([]byte, 0, len(sorted)*70)
+	for i, commitment := range sorted {
+		if commitment.Index == 0 || (i > 0 && sorted[i-1].Index == commitment.Index) {
+			return nil, fmt.Errorf("invalid or duplicate signer index %d", commitment.Index)
+		}
+		encoded = binary.BigEndian.AppendUint32(encoded, commitment.Index)
+		encoded = append(encoded, commitment.Hiding...)
+		encoded = append(encoded, commitment.Binding...)
+	}
+
+	curve := elliptic.P256()
+	session := &thresholdSession{commitments: sorted, binding: make(map[uint32]*big.Int, len(sorted))}
+	var rx, ry *big.Int
+	for _, commitment := range sorted {
+		dx, dy := elliptic.UnmarshalCompressed(curve, commitment.Hiding)
+		ex, ey := elliptic.UnmarshalCompressed(curve, commitment.Binding)
+		if dx == nil || ex == nil {
+			return nil, fmt.Errorf("invalid commitment of participant %d", commitment.Index)
+		}
+		rho := thresholdHash(thresholdBindingDomain, groupKey, digest, encoded, binary.BigEndian.AppendUint32(nil, commitment.Index))
+		session.binding[commitment.Index] = rho
+
+		// R += D + rho*E
+		bx, by := curve.ScalarMult(ex, ey, scalarBytes(rho))
+		px, py := curve.Add(dx, dy, bx, by)
+		if rx == nil {
+			rx, ry = px, py
+		} else {
+			rx, ry = curve.Add(rx, ry, px, py)
+		}
+	}
+	if rx.Sign() == 0 && ry.Sign() == 0 {
+		return nil, errors.New("group commitment is the point at infinity")
+	}
+	session.commitment = elliptic.MarshalCompressed(curve, rx, ry)
+	session.challenge = thresholdChallenge(session.commitment, groupKey, digest)
+	return session, nil
+}
+
+// lagrange returns the Lagrange coefficient at zero of participant index
+// within the signing set
+func (s *thresholdSession) lagrange(index uint32) *big.Int {
+	n := elliptic.P256().Params().N
+	num, den := big.NewInt(1), big.NewInt(1)
+	xi := big.NewInt(int64(index))
+	for _, commitment := range s.commitments {
+		if commitment.Index == index {
+			continue
+		}
+		xj := big.NewInt(int64(commitment.Index))
+		num.Mul(num, xj)
+		num.Mod(num, n)
+		diff := new(big.Int).Sub(xj, xi)
+		den.Mul(den, diff)
+		den.Mod(den, n)
+	}
+	return num.Mul(num, den.ModInverse(den, n)).Mod(num, n)
+}
+
+// thresholdChallenge is the Schnorr challenge c = H(R, Y, digest)
+func thresholdChallenge(commitment, groupKey, digest []byte) *big.Int {
+	return thresholdHash(thresholdChallengeDomain, commitment, groupKey, digest)
+}
+
+// thresholdHash hashes length-prefixed fields under a domain into a scalar
+func thresholdHash(domain string, fields ...[]byte) *big.Int {
+	h := sha256.New()
+	for _, field := range append([][]byte{[]byte(domain)}, fields...) {
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(field)))
+		h.Write(size[:])
+		h.Write(field)
+	}
+	return new(big.Int).Mod(new(big.Int).SetBytes(h.Sum(nil)), elliptic.P256().Params().N)
+}
+
+// randomScalar returns a uniformly random non-zero scalar
+func randomScalar() (*big.Int, error) {
+	max := new(big.Int).Sub(elliptic.P256().Params().N, big.NewInt(1))
+	k, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return nil, err
+	}
+	return k.Add(k, big.NewInt(1)), nil
+}
+
+// scalarBytes encodes a scalar as 32 big-endian bytes
+func scalarBytes(k *big.Int) []byte {
+	return k.FillBytes(make([]byte, 32))
+}
@@ -21,6 +21,8 @@ type Transaction struct {
 	Status     string `json:"Status,omitempty"` // "pending" or "confirmed"
 	BlockIndex int64  `json:"BlockIndex,omitempty"`
 	BlockHash  string `json:"BlockHash,omitempty"`
+	ChainID    string `json:"chainId,omitempty"`   // Network the signature is bound to
+	SigScheme  int    `json:"sigScheme,omitempty"` // Signing scheme version, see CurrentSigScheme
 }
 
 // ContractTransaction represents a transaction related to smart contracts
@@ -30,6 +32,8 @@ type ContractTransaction struct {
 	Function        string        `json:"function,omitempty"`
 	Parameters      []interface{} `json:"parameters,omitempty"`
 	Code            string        `json:"code,omitempty"`
+	Permissions     []string      `json:"permissions,omitempty"` // host permissions requested at deployment
+	GasLimit        uint64        `json:"gas_limit,omitempty"`   // 0 means DefaultContractGasLimit
 }
 
 // NewTransaction creates a new transaction
@@ -40,7 +44,7 @@ func NewTransaction(id, from, to string, value uint64, data []byte) *Transaction
 		To:        to,
 		Value:     value,
 		Data:      data,
-		Timestamp: time.Now().Unix(),
+		Timestamp: Now().Unix(),
 		Type:      "regular",
 		Status:    "pending",
 	}
@@ -71,6 +75,7 @@ func NewContractDeploymentTransaction(from string, code string, privateKey *ecds
 		0,  // no value transfer for deployment
 		data,
 	)
+	tx.Type = "contract_deploy"
 	
 	// Sign the transaction
 	if privateKey != nil {
@@ -104,6 +109,7 @@ func NewContractCallTransaction(from string, contractAddress string, function st
 		0, // Value should be 0 for function calls unless specified
 		data,
 	)
+	tx.Type = "contract_call"
 	
 	// Sign the transaction
 	if privateKey != nil {
@@ -143,11 +149,17 @@ func (tx *Transaction) VerifyWithBytes(publicKey []byte) error {
 		return errors.New("no public key provided for verification")
 	}
 	
-	// Calculate hash for verification - use the one from crypto.go
-	hash := tx.CalculateHash()
+	// Reject signatures made for another network or scheme
+	if err := checkSigningDomain(tx.ChainID, tx.SigScheme); err != nil {
+		return err
+	}
+	hash, err := tx.SigningHash()
+	if err != nil {
+		return err
+	}
 	
 	// Verify the signature
-	valid, err := VerifySignature([]byte(hash), tx.Signature, publicKey)
+	valid, err := VerifySignature(hash, tx.Signature, publicKey)
 	if err != nil {
 		return fmt.Errorf("signature verification error: %v", err)
 	}
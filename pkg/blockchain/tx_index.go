@@ -0,0 +1,134 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// txIndexFile holds the transaction index next to the chain state. It is derived
+// data: a missing or stale index is rebuilt from the blocks on startup.
+const txIndexFile = "txindex.json"
+
+// TxLocation is where a confirmed transaction lives in the chain
+type TxLocation struct {
+	BlockIndex uint64 `json:"blockIndex"`
+	Offset     int    `json:"offset"` // position in the block's full transaction list
+}
+
+// txIndexData is the persisted form of the transaction index
+type txIndexData struct {
+	Height  uint64                `json:"height"`
+	TipHash string                `json:"tipHash"` // hash of the newest indexed block
+	Entries map[string]TxLocation `json:"entries"`
+}
+
+// tipHashLocked returns the hash of the newest block. The caller must hold bc.mu.
+func (bc *Blockchain) tipHashLocked() string {
+	if len(bc.Blocks) == 0 {
+		return ""
+	}
+	return bc.Blocks[len(bc.Blocks)-1].Hash
+}
+
+// indexBlockLocked adds the transactions of a block to the index. The caller must hold bc.mu.
+func (bc *Blockchain) indexBlockLocked(block *Block) {
+	if bc.txIndex == nil {
+		bc.txIndex = make(map[string]TxLocation)
+	}
+	for offset, tx := range block.Transactions {
+		bc.txIndex[tx.ID] = TxLocation{BlockIndex: block.Index, Offset: offset}
+	}
+}
+
+// rebuildTxIndexLocked indexes every block from scratch. Offsets of archived blocks
+// are unknown locally, so only the transactions they still carry in full are indexed.
+// The caller must hold bc.mu.
+func (bc *Blockchain) rebuildTxIndexLocked() {
+	bc.txIndex = make(map[string]TxLocation)
+	skipped := 0
+	for _, block := range bc.Blocks {
+		if block.IsPruned() {
+			skipped++
+			continue
+		}
+		bc.indexBlockLocked(block)
+	}
+	if skipped > 0 {
+		log.Printf("Warning: Transaction index rebuilt without %d archived blocks", skipped)
+	}
+}
+
+// saveTxIndexLocked writes the index to dir. The caller must hold bc.mu.
+func (bc *Blockchain) saveTxIndexLocked(dir string) error {
+	data, err := json.Marshal(txIndexData{
+		Height:  uint64(len(bc.Blocks)),
+		TipHash: bc.tipHashLocked(),
+		Entries: bc.txIndex,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal transaction index: %v", err)
+	}
+
+	path := filepath.Join(dir, txIndexFile)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write transaction index: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadTxIndexLocked reads the index from dir and rebuilds it when it is missing
+// or was written for another chain tip, e.g. after a restore. The caller must hold bc.mu.
+func (bc *Blockchain) loadTxIndexLocked(dir string) {
+	raw, err := ioutil.ReadFile(filepath.Join(dir, txIndexFile))
+	if err == nil {
+		var data txIndexData
+		if err := json.Unmarshal(raw, &data); err == nil && data.Height == uint64(len(bc.Blocks)) &&
+			data.TipHash == bc.tipHashLocked() && data.Entries != nil {
+			bc.txIndex = data.Entries
+			return
+		}
+	}
+
+	log.Printf("Rebuilding transaction index for %d blocks", len(bc.Blocks))
+	bc.rebuildTxIndexLocked()
+}
+
+// checkUnconfirmedLocked refuses a transaction whose ID is already confirmed,
+// so no transaction is applied twice. The caller must hold bc.mu.
+func (bc *Blockchain) checkUnconfirmedLocked(tx *Transaction) error {
+	if location, confirmed := bc.txIndex[tx.ID]; confirmed {
+		return fmt.Errorf("%w: %s confirmed in block %d", ErrTxExists, tx.ID, location.BlockIndex)
+	}
+	return nil
+}
+
+// LocateTransaction returns where a confirmed transaction is stored
+func (bc *Blockchain) LocateTransaction(id string) (TxLocation, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	location, exists := bc.txIndex[id]
+	return location, exists
+}
+
+// getIndexedTransaction resolves a confirmed transaction through the index.
+// Archived blocks are fetched from the archive, so the bc.mu lock must not be held.
+func (bc *Blockchain) getIndexedTransaction(id string) (*Transaction, *Block, error) {
+	location, exists := bc.LocateTransaction(id)
+	if !exists {
+		return nil, nil, ErrTxNotFound
+	}
+
+	block, err := bc.GetBlockByIndex(location.BlockIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+	if location.Offset >= len(block.Transactions) || block.Transactions[location.Offset].ID != id {
+		return nil, nil, fmt.Errorf("%w: index entry for %s is stale", ErrTxNotFound, id)
+	}
+	return block.Transactions[location.Offset], block, nil
+}
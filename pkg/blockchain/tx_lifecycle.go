@@ -0,0 +1,188 @@
+package blockchain
+
+import (
+	"sort"
+	"time"
+)
+
+// MaxTxLifecycles is how many lifecycles of included transactions are kept;
+// the oldest are evicted first
+const MaxTxLifecycles = 10000
+
+// LatencyBuckets are the upper bounds, in seconds, of the latency histograms
+var LatencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+// TxLifecycle records when this node first saw a transaction, broadcast it to
+// its peers and applied the block including it. Times are Unix milliseconds
+// of the node's clock; zero means the step has not happened (yet).
+type TxLifecycle struct {
+	ID               string  `json:"id"`
+	FirstSeen        int64   `json:"firstSeen"`
+	BroadcastAt      int64   `json:"broadcastAt,omitempty"`
+	IncludedAt       int64   `json:"includedAt,omitempty"`
+	BlockIndex       *uint64 `json:"blockIndex,omitempty"`
+	BroadcastLatency int64   `json:"broadcastLatencyMs,omitempty"` // firstSeen to broadcastAt
+	InclusionLatency int64   `json:"inclusionLatencyMs,omitempty"` // firstSeen to includedAt
+}
+
+// LatencyHistogram counts latencies in LatencyBuckets. Counts are
+// cumulative: Counts[i] is the number of latencies at most Buckets[i].
+type LatencyHistogram struct {
+	Buckets []float64 `json:"buckets"`
+	Counts  []uint64  `json:"counts"`
+	Sum     float64   `json:"sum"` // seconds
+	Count   uint64    `json:"count"`
+}
+
+// TxLatencyStats are the aggregate latencies of the transactions this node saw
+type TxLatencyStats struct {
+	Inclusion LatencyHistogram `json:"inclusion"`
+	Broadcast LatencyHistogram `json:"broadcast"`
+	Tracked   int              `json:"tracked"` // pending transactions whose lifecycle is open
+}
+
+// txLifecycleStore holds the lifecycles of pending and recently included
+// transactions and the latency histograms. Guarded by bc.mu.
+type txLifecycleStore struct {
+	byID      map[string]*TxLifecycle
+	included  []string // included transaction IDs, oldest first
+	inclusion latencyCounter
+	broadcast latencyCounter
+}
+
+// latencyCounter accumulates one histogram
+type latencyCounter struct {
+	counts []uint64 // per bucket, not cumulative; the last one counts latencies above every bucket
+	sum    float64
+	count  uint64
+}
+
+// observe counts a latency
+func (c *latencyCounter) observe(latency time.Duration) {
+	if c.counts == nil {
+		c.counts = make([]uint64, len(LatencyBuckets)+1)
+	}
+	seconds := latency.Seconds()
+	bucket := sort.SearchFloat64s(LatencyBuckets, seconds)
+	c.counts[bucket]++
+	c.sum += seconds
+	c.count++
+}
+
+// histogram returns the cumulative form of the counter
+func (c *latencyCounter) histogram() LatencyHistogram {
+	h := LatencyHistogram{
+		Buckets: append([]float64(nil), LatencyBuckets...),
+		Counts:  make([]uint64, len(LatencyBuckets)),
+		Sum:     c.sum,
+		Count:   c.count,
+	}
+	var total uint64
+	for i := range LatencyBuckets {
+		if c.counts != nil {
+			total += c.counts[i]
+		}
+		h.Counts[i] = total
+	}
+	return h
+}
+
+// nowMillis is the node clock in Unix milliseconds
+func nowMillis() int64 {
+	return Now().UnixNano() / int64(time.Millisecond)
+}
+
+// recordFirstSeenLocked opens the lifecycle of a transaction entering the
+// pool. The caller must hold bc.mu.
+func (bc *Blockchain) recordFirstSeenLocked(txID string) {
+	if bc.txLifecycles.byID == nil {
+		bc.txLifecycles.byID = make(map[string]*TxLifecycle)
+	}
+	if _, exists := bc.txLifecycles.byID[txID]; exists {
+		return
+	}
+	bc.txLifecycles.byID[txID] = &TxLifecycle{ID: txID, FirstSeen: nowMillis()}
+}
+
+// recordInclusionLocked closes the lifecycles of the transactions of a block
+// applied by this node, evicting the oldest beyond MaxTxLifecycles.
+// Transactions this node never had pending have no lifecycle. The caller must
+// hold bc.mu.
+func (bc *Blockchain) recordInclusionLocked(block *Block) {
+	now := nowMillis()
+	for _, tx := range block.Transactions {
+		lifecycle, exists := bc.txLifecycles.byID[tx.ID]
+		if !exists || lifecycle.IncludedAt != 0 {
+			continue
+		}
+		index := block.Index
+		lifecycle.IncludedAt = now
+		lifecycle.BlockIndex = &index
+		lifecycle.InclusionLatency = now - lifecycle.FirstSeen
+		bc.txLifecycles.inclusion.observe(time.Duration(lifecycle.InclusionLatency) * time.Millisecond)
+		bc.txLifecycles.included = append(bc.txLifecycles.included, tx.ID)
+	}
+	if excess := len(bc.txLifecycles.included) - MaxTxLifecycles; excess > 0 {
+		for _, id := range bc.txLifecycles.included[:excess] {
+			delete(bc.txLifecycles.byID, id)
+		}
+		bc.txLifecycles.included = append([]string(nil), bc.txLifecycles.included[excess:]...)
+	}
+}
+
+// dropLifecycleLocked forgets a transaction that left the pool without being
+// included. The caller must hold bc.mu.
+func (bc *Blockchain) dropLifecycleLocked(txID string) {
+	if lifecycle, exists := bc.txLifecycles.byID[txID]; exists && lifecycle.IncludedAt == 0 {
+		delete(bc.txLifecycles.byID, txID)
+	}
+}
+
+// MarkTransactionBroadcast records that the node sent a pending transaction
+// to its peers. Only the first broadcast counts.
+func (bc *Blockchain) MarkTransactionBroadcast(txID string) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	lifecycle, exists := bc.txLifecycles.byID[txID]
+	if !exists || lifecycle.BroadcastAt != 0 {
+		return
+	}
+	lifecycle.BroadcastAt = nowMillis()
+	lifecycle.BroadcastLatency = lifecycle.BroadcastAt - lifecycle.FirstSeen
+	bc.txLifecycles.broadcast.observe(time.Duration(lifecycle.BroadcastLatency) * time.Millisecond)
+}
+
+// TransactionLifecycle returns the lifecycle of a pending or recently included transaction
+func (bc *Blockchain) TransactionLifecycle(txID string) (TxLifecycle, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	lifecycle, exists := bc.txLifecycles.byID[txID]
+	if !exists {
+		return TxLifecycle{}, false
+	}
+	return *lifecycle, true
+}
+
+// blockLifecyclesLocked returns the lifecycles known for the transactions of
+// block, in block order. The caller must hold bc.mu.
+func (bc *Blockchain) blockLifecyclesLocked(block *Block) []TxLifecycle {
+	var lifecycles []TxLifecycle
+	for _, tx := range block.Transactions {
+		if lifecycle, exists := bc.txLifecycles.byID[tx.ID]; exists {
+			lifecycles = append(lifecycles, *lifecycle)
+		}
+	}
+	return lifecycles
+}
+
+// TxLatencyStats returns the broadcast and inclusion latency histograms of the
+// transactions this node saw since it started
+func (bc *Blockchain) TxLatencyStats() TxLatencyStats {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return TxLatencyStats{
+		Inclusion: bc.txLifecycles.inclusion.histogram(),
+		Broadcast: bc.txLifecycles.broadcast.histogram(),
+		Tracked:   len(bc.txLifecycles.byID) - len(bc.txLifecycles.included),
+	}
+}
@@ -0,0 +1,131 @@
+package blockchain
+
+import (
+	"strings"
+)
+
+// Transaction locations reported by lookups
+const (
+	TxStatusPending   = "pending"
+	TxStatusConfirmed = "confirmed"
+	TxStatusFailed    = "failed"
+	TxStatusRejected  = "rejected" // dropped by the block builder, see rejected_txs.go
+	TxStatusNotFound  = "not_found"
+)
+
+// TxStatus is the status of a single transaction returned by bulk lookups
+type TxStatus struct {
+	ID         string `json:"id"`
+	Status     string `json:"status"`
+	BlockIndex *int64 `json:"blockIndex,omitempty"`
+	BlockHash  string `json:"blockHash,omitempty"`
+	Reason     string `json:"reason,omitempty"` // why a rejected transaction was dropped
+}
+
+// FindTransaction looks a transaction up by ID in the mempool and then in the transaction index
+func (bc *Blockchain) FindTransaction(id string) (*Transaction, error) {
+	bc.mu.RLock()
+	tx, exists := bc.txPool[id]
+	bc.mu.RUnlock()
+	if exists {
+		return tx, nil
+	}
+
+	tx, _, err := bc.getIndexedTransaction(id)
+	return tx, err
+}
+
+// SearchTransactions returns up to limit transactions whose ID or hash starts with prefix (case-insensitive).
+// Pending transactions come first, then confirmed ones from the newest block down.
+func (bc *Blockchain) SearchTransactions(prefix string, limit int) []*Transaction {
+	prefix = strings.ToLower(prefix)
+	matches := func(tx *Transaction) bool {
+		return strings.HasPrefix(strings.ToLower(tx.ID), prefix) || strings.HasPrefix(tx.CalculateHash(), prefix)
+	}
+
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	var result []*Transaction
+	for _, tx := range bc.pendingTxs {
+		if matches(tx) {
+			result = append(result, tx)
+			if len(result) >= limit {
+				return result
+			}
+		}
+	}
+
+	for i := len(bc.Blocks) - 1; i >= 0; i-- {
+		for _, tx := range bc.Blocks[i].Transactions {
+			if matches(tx) {
+				result = append(result, tx)
+				if len(result) >= limit {
+					return result
+				}
+			}
+		}
+	}
+	return result
+}
+
+// GetTransactionStatuses resolves the status of many transactions through the mempool and the transaction index.
+// The result has one entry per requested ID, in request order.
+func (bc *Blockchain) GetTransactionStatuses(ids []string) []TxStatus {
+	statuses := make([]TxStatus, len(ids))
+	wanted := make(map[string][]int, len(ids))
+	for i, id := range ids {
+		statuses[i] = TxStatus{ID: id, Status: TxStatusNotFound}
+		wanted[id] = append(wanted[id], i)
+	}
+
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	for id, positions := range wanted {
+		if _, exists := bc.txPool[id]; exists {
+			for _, i := range positions {
+				statuses[i].Status = TxStatusPending
+			}
+			delete(wanted, id)
+		}
+	}
+
+	for id, positions := range wanted {
+		location, exists := bc.txIndex[id]
+		if !exists || location.BlockIndex >= uint64(len(bc.Blocks)) {
+			continue
+		}
+		block := bc.Blocks[location.BlockIndex]
+
+		// Archived blocks only keep some bodies locally; their transactions count as confirmed
+		status := TxStatusConfirmed
+		if location.Offset < len(block.Transactions) {
+			if tx := block.Transactions[location.Offset]; tx.ID == id && tx.Status == TxStatusFailed {
+				status = TxStatusFailed
+			}
+		}
+		blockIndex := int64(block.Index)
+		for _, i := range positions {
+			statuses[i].Status = status
+			statuses[i].BlockIndex = &blockIndex
+			statuses[i].BlockHash = block.Hash
+		}
+		delete(wanted, id)
+	}
+
+	// Whatever is left may have been dropped by the block builder
+	for r := len(bc.rejectedTxs) - 1; r >= 0 && len(wanted) > 0; r-- {
+		rejected := bc.rejectedTxs[r]
+		positions, exists := wanted[rejected.Transaction.ID]
+		if !exists {
+			continue
+		}
+		for _, i := range positions {
+			statuses[i].Status = TxStatusRejected
+			statuses[i].Reason = rejected.Reason
+		}
+		delete(wanted, rejected.Transaction.ID)
+	}
+	return statuses
+}
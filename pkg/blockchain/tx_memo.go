@@ -0,0 +1,229 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TxMemoDataPrefix marks the data of a transfer carrying a TxMemo
+const TxMemoDataPrefix = "memo:"
+
+// UncategorizedSpend is the category reported for transfers without a tag
+const UncategorizedSpend = "uncategorized"
+
+const (
+	// MaxTxCategory is the longest category tag, in bytes
+	MaxTxCategory = 32
+	// MaxTxMemo is the longest free-form memo, in bytes
+	MaxTxMemo = 256
+)
+
+// ErrInvalidMemo is returned for transaction memos that fail validation
+var ErrInvalidMemo = errors.New("invalid transaction memo")
+
+// TxMemo is the standardized metadata a client attaches to a transfer,
+// stored in its data as TxMemoDataPrefix followed by the JSON encoding
+type TxMemo struct {
+	Category string `json:"category,omitempty"` // bookkeeping tag: lowercase letters, digits, '-' and '_'
+	Memo     string `json:"memo,omitempty"`     // free-form note
+	Request  string `json:"request,omitempty"`  // ID of the payment request the transfer pays
+}
+
+// Validate checks the category tag and the memo length
+func (m TxMemo) Validate() error {
+	if len(m.Category) > MaxTxCategory {
+		return fmt.Errorf("%w: category is longer than %d bytes", ErrInvalidMemo, MaxTxCategory)
+	}
+	for _, c := range m.Category {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return fmt.Errorf("%w: category %q may only hold lowercase letters, digits, '-' and '_'", ErrInvalidMemo, m.Category)
+		}
+	}
+	if len(m.Memo) > MaxTxMemo {
+		return fmt.Errorf("%w: memo is longer than %d bytes", ErrInvalidMemo, MaxTxMemo)
+	}
+	return nil
+}
+
+// NormalizeTxCategory lowercases and trims a category entered by a user
+func NormalizeTxCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+// EncodeTxMemo validates memo and returns the transaction data that carries it
+func EncodeTxMemo(memo TxMemo) ([]byte, error) {
+	if err := memo.Validate(); err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(memo)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(TxMemoDataPrefix), encoded...), nil
+}
+
+// ParseTxMemo returns the memo of a transfer. Data that only references a
+// payment request yields a memo with just Request set. ok is false when the
+// data carries no memo.
+func ParseTxMemo(tx *Transaction) (memo TxMemo, ok bool, err error) {
+	if tx == nil {
+		return TxMemo{}, false, nil
+	}
+	data := string(tx.Data)
+	switch {
+	case strings.HasPrefix(data, TxMemoDataPrefix):
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(data, TxMemoDataPrefix)), &memo); err != nil {
+			return TxMemo{}, false, fmt.Errorf("%w: %v", ErrInvalidMemo, err)
+		}
+		if err := memo.Validate(); err != nil {
+			return TxMemo{}, false, err
+		}
+		return memo, true, nil
+	case strings.HasPrefix(data, PaymentRequestDataPrefix):
+		return TxMemo{Request: strings.TrimPrefix(data, PaymentRequestDataPrefix)}, true, nil
+	}
+	return TxMemo{}, false, nil
+}
+
+// checkTxMemo refuses regular transfers whose memo is malformed, so every
+// memo on chain can be read back by the spend report
+func checkTxMemo(tx *Transaction) error {
+	if tx.Type != "regular" {
+		return nil
+	}
+	_, _, err := ParseTxMemo(tx)
+	return err
+}
+
+// CategorySpend is what an address spent under one category
+type CategorySpend struct {
+	Category     string `json:"category"`
+	Total        string `json:"total"`
+	Transactions int    `json:"transactions"`
+}
+
+// MonthlySpend is what an address spent in one calendar month (UTC), per category
+type MonthlySpend struct {
+	Month      string          `json:"month"` // YYYY-MM
+	Total      string          `json:"total"`
+	Categories []CategorySpend `json:"categories"`
+}
+
+// SpendReport breaks down the outgoing transfers of an address by month and category
+type SpendReport struct {
+	Address    string          `json:"address"`
+	From       int64           `json:"from"`
+	To         int64           `json:"to"`
+	Total      string          `json:"total"`
+	Categories []CategorySpend `json:"categories"` // totals over the whole range
+	Months     []MonthlySpend  `json:"months"`     // oldest first
+}
+
+// spendTally accumulates spend per category
+type spendTally map[string]*struct {
+	total *big.Int
+	count int
+}
+
+func (t spendTally) add(category string, value *big.Int) {
+	entry, exists := t[category]
+	if !exists {
+		entry = &struct {
+			total *big.Int
+			count int
+		}{total: big.NewInt(0)}
+		t[category] = entry
+	}
+	entry.total.Add(entry.total, value)
+	entry.count++
+}
+
+// categories returns the tally sorted by category, and its grand total
+func (t spendTally) categories() ([]CategorySpend, *big.Int) {
+	total := big.NewInt(0)
+	categories := make([]CategorySpend, 0, len(t))
+	for category, entry := range t {
+		total.Add(total, entry.total)
+		categories = append(categories, CategorySpend{
+			Category:     category,
+			Total:        entry.total.String(),
+			Transactions: entry.count,
+		})
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].Category < categories[j].Category
+	})
+	return categories, total
+}
+
+// SpendByCategory totals the confirmed transfers sent by address in blocks
+// with from <= timestamp <= to (unix seconds; to == 0 means up to now), per
+// calendar month and category tag. Transfers without a category are reported
+// under UncategorizedSpend.
+func (bc *Blockchain) SpendByCategory(address string, from, to int64) (*SpendReport, error) {
+	if address == "" {
+		return nil, errors.New("address is required")
+	}
+	if to != 0 && to < from {
+		return nil, errors.New("report end must not be before its start")
+	}
+
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	overall := make(spendTally)
+	monthly := make(map[string]spendTally)
+	for _, block := range bc.Blocks {
+		if block.Timestamp < from || (to != 0 && block.Timestamp > to) {
+			continue
+		}
+		month := time.Unix(block.Timestamp, 0).UTC().Format("2006-01")
+		for _, tx := range block.Transactions {
+			if tx.From != address || tx.Status != "confirmed" || tx.Type == RewardTxType {
+				continue
+			}
+			effect := txBalanceEffect(tx, address)
+			if effect.Sign() >= 0 {
+				continue
+			}
+			spent := effect.Neg(effect)
+
+			category := UncategorizedSpend
+			if memo, ok, err := ParseTxMemo(tx); err == nil && ok && memo.Category != "" {
+				category = memo.Category
+			}
+			overall.add(category, spent)
+			if monthly[month] == nil {
+				monthly[month] = make(spendTally)
+			}
+			monthly[month].add(category, spent)
+		}
+	}
+
+	categories, total := overall.categories()
+	report := &SpendReport{
+		Address:    address,
+		From:       from,
+		To:         to,
+		Total:      total.String(),
+		Categories: categories,
+		Months:     make([]MonthlySpend, 0, len(monthly)),
+	}
+	for month, tally := range monthly {
+		categories, total := tally.categories()
+		report.Months = append(report.Months, MonthlySpend{
+			Month:      month,
+			Total:      total.String(),
+			Categories: categories,
+		})
+	}
+	sort.Slice(report.Months, func(i, j int) bool {
+		return report.Months[i].Month < report.Months[j].Month
+	})
+	return report, nil
+}
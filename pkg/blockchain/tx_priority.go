@@ -0,0 +1,126 @@
+package blockchain
+
+import (
+	"sort"
+	"sync"
+)
+
+// TxLane is the priority class of a transaction. Block builders fill blocks
+// lane by lane, highest first, so protocol operations are never crowded out by transfers.
+type TxLane int
+
+// Priority lanes, lowest to highest
+const (
+	LaneRegular    TxLane = iota // transfers and contract calls
+	LaneGovernance               // execution of accepted governance proposals
+	LaneSystem                   // validator registration and slashing evidence
+)
+
+// String returns the lane name
+func (l TxLane) String() string {
+	switch l {
+	case LaneSystem:
+		return "system"
+	case LaneGovernance:
+		return "governance"
+	default:
+		return "regular"
+	}
+}
+
+// Transaction types of protocol operations that are included ahead of regular transfers
+const (
+	GovernanceTxType    = "governance"     // executes an accepted governance proposal
+	SlashEvidenceTxType = "slash_evidence" // proves validator misbehaviour
+)
+
+// DefaultMaxBlockTransactions is the number of transactions a block may carry besides the reward
+const DefaultMaxBlockTransactions = 1000
+
+var (
+	txLanesMu sync.RWMutex
+	txLanes   = map[string]TxLane{
+		HumanProofTxType:    LaneSystem,
+		SlashEvidenceTxType: LaneSystem,
+		GovernanceTxType:    LaneGovernance,
+	}
+)
+
+// RegisterTxLane assigns a priority lane to a transaction type
+func RegisterTxLane(txType string, lane TxLane) {
+	txLanesMu.Lock()
+	defer txLanesMu.Unlock()
+	txLanes[txType] = lane
+}
+
+// LaneOf returns the priority lane of a transaction
+func LaneOf(tx *Transaction) TxLane {
+	txLanesMu.RLock()
+	defer txLanesMu.RUnlock()
+	return txLanes[tx.Type]
+}
+
+// SetMaxBlockTransactions sets how many transactions a block may carry besides the reward.
+// It is a consensus parameter: blocks above the limit are rejected, so all nodes must agree on it.
+func (bc *Blockchain) SetMaxBlockTransactions(max int) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if max <= 0 {
+		max = DefaultMaxBlockTransactions
+	}
+	bc.maxBlockTxs = max
+}
+
+// MaxBlockTransactions returns the block capacity in transactions, excluding the reward
+func (bc *Blockchain) MaxBlockTransactions() int {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.maxBlockTxsLocked()
+}
+
+// maxBlockTxsLocked returns the block capacity. The caller must hold bc.mu.
+func (bc *Blockchain) maxBlockTxsLocked() int {
+	if bc.maxBlockTxs <= 0 {
+		return DefaultMaxBlockTransactions
+	}
+	return bc.maxBlockTxs
+}
+
+// SelectTransactions returns pending transactions in inclusion order: by lane,
+// highest first, and by arrival within a lane. At most limit transactions are
+// returned; limit <= 0 returns them all. While the chain is paused only the
+// circuit breaker transactions are returned.
+func (bc *Blockchain) SelectTransactions(limit int) []*Transaction {
+	pending := bc.GetPendingTransactions()
+	if bc.Halted() {
+		actions := pending[:0]
+		for _, tx := range pending {
+			if tx.Type == CircuitBreakerTxType {
+				actions = append(actions, tx)
+			}
+		}
+		pending = actions
+	}
+
+	sort.SliceStable(pending, func(i, j int) bool {
+		return LaneOf(pending[i]) > LaneOf(pending[j])
+	})
+
+	if limit > 0 && len(pending) > limit {
+		pending = pending[:limit]
+	}
+	return pending
+}
+
+// PendingByLane returns the number of pending transactions in each lane
+func (bc *Blockchain) PendingByLane() map[string]int {
+	counts := map[string]int{
+		LaneSystem.String():     0,
+		LaneGovernance.String(): 0,
+		LaneRegular.String():    0,
+	}
+	for _, tx := range bc.GetPendingTransactions() {
+		counts[LaneOf(tx).String()]++
+	}
+	return counts
+}
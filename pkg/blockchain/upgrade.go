@@ -0,0 +1,228 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NodeVersion is the software version of this node. Release builds set it with
+// -ldflags "-X <module>/pkg/blockchain.NodeVersion=1.2.0".
+var NodeVersion = "1.0.0"
+
+// upgradesFile holds the software upgrades scheduled by governance
+const upgradesFile = "upgrades.json"
+
+// UpgradeWarnBlocks is how many blocks before an upgrade activates nodes
+// running an older version start warning about it
+const UpgradeWarnBlocks = 1000
+
+var (
+	// ErrUpgradeRequired is returned for blocks at or past the activation height
+	// of an upgrade this node's version does not satisfy
+	ErrUpgradeRequired = errors.New("software upgrade required")
+	// ErrInvalidUpgrade is returned for an upgrade plan that cannot be scheduled
+	ErrInvalidUpgrade = errors.New("invalid upgrade plan")
+)
+
+// UpgradePlan is a software upgrade approved by governance: from Height on,
+// only nodes running Version or newer validate blocks
+type UpgradePlan struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Height      uint64 `json:"height"`
+	ProposalID  string `json:"proposalId,omitempty"`
+	ScheduledAt int64  `json:"scheduledAt"`
+}
+
+// CompareVersions compares two dotted versions such as "1.4.2" or "v2.0",
+// returning -1, 0 or 1. Missing components count as zero and anything after a
+// '-' or '+' (pre-release or build metadata) is ignored.
+func CompareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for len(pa) < len(pb) {
+		pa = append(pa, 0)
+	}
+	for len(pb) < len(pa) {
+		pb = append(pb, 0)
+	}
+	for i := range pa {
+		switch {
+		case pa[i] < pb[i]:
+			return -1
+		case pa[i] > pb[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// ParseVersion checks that a version has the form CompareVersions understands
+func ParseVersion(version string) error {
+	core := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+	if core == "" {
+		return fmt.Errorf("%w: empty version", ErrInvalidUpgrade)
+	}
+	for _, part := range strings.Split(core, ".") {
+		if _, err := strconv.ParseUint(part, 10, 32); err != nil {
+			return fmt.Errorf("%w: version %q is not of the form 1.2.3", ErrInvalidUpgrade, version)
+		}
+	}
+	return nil
+}
+
+func versionParts(version string) []uint64 {
+	core := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+	var parts []uint64
+	for _, part := range strings.Split(core, ".") {
+		n, _ := strconv.ParseUint(part, 10, 32)
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// ScheduleUpgrade records an approved upgrade and persists the schedule
+func (bc *Blockchain) ScheduleUpgrade(plan UpgradePlan) error {
+	if plan.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidUpgrade)
+	}
+	if err := ParseVersion(plan.Version); err != nil {
+		return err
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if height := uint64(len(bc.Blocks)); plan.Height < height {
+		return fmt.Errorf("%w: activation height %d is below the next block %d", ErrInvalidUpgrade, plan.Height, height)
+	}
+	for _, existing := range bc.upgrades {
+		if existing.Name == plan.Name {
+			return fmt.Errorf("%w: upgrade %q is already scheduled", ErrInvalidUpgrade, plan.Name)
+		}
+	}
+
+	bc.upgrades = append(bc.upgrades, plan)
+	sort.Slice(bc.upgrades, func(i, j int) bool { return bc.upgrades[i].Height < bc.upgrades[j].Height })
+	if err := bc.saveUpgradesLocked(GetBlockchainDataPath()); err != nil {
+		return err
+	}
+
+	log.Printf("Upgrade %s scheduled: version %s activates at height %d (this node runs %s)",
+		plan.Name, plan.Version, plan.Height, NodeVersion)
+	return nil
+}
+
+// Upgrades returns every scheduled upgrade ordered by activation height
+func (bc *Blockchain) Upgrades() []UpgradePlan {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return append([]UpgradePlan(nil), bc.upgrades...)
+}
+
+// NextUpgrade returns the earliest upgrade that has not activated yet
+func (bc *Blockchain) NextUpgrade() (UpgradePlan, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	height := uint64(len(bc.Blocks))
+	for _, plan := range bc.upgrades {
+		if plan.Height >= height {
+			return plan, true
+		}
+	}
+	return UpgradePlan{}, false
+}
+
+// RequiredUpgrade returns the activated upgrade this node is too old for, if any
+func (bc *Blockchain) RequiredUpgrade() (UpgradePlan, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	plan := bc.requiredUpgradeLocked(uint64(len(bc.Blocks)))
+	if plan == nil {
+		return UpgradePlan{}, false
+	}
+	return *plan, true
+}
+
+// requiredUpgradeLocked returns the upgrade active at height that this node's
+// version does not satisfy. The caller must hold bc.mu.
+func (bc *Blockchain) requiredUpgradeLocked(height uint64) *UpgradePlan {
+	for i := len(bc.upgrades) - 1; i >= 0; i-- {
+		plan := &bc.upgrades[i]
+		if plan.Height <= height && CompareVersions(NodeVersion, plan.Version) < 0 {
+			return plan
+		}
+	}
+	return nil
+}
+
+// checkUpgradeLocked refuses blocks this node's version may no longer
+// validate. The caller must hold bc.mu.
+func (bc *Blockchain) checkUpgradeLocked(height uint64) error {
+	if plan := bc.requiredUpgradeLocked(height); plan != nil {
+		return fmt.Errorf("%w: upgrade %s requires version %s from height %d, this node runs %s",
+			ErrUpgradeRequired, plan.Name, plan.Version, plan.Height, NodeVersion)
+	}
+	return nil
+}
+
+// warnUpcomingUpgrade logs a warning while an upgrade this node is too old for
+// approaches, every 100 blocks and then on each of the last 10
+func (bc *Blockchain) warnUpcomingUpgrade(height uint64) {
+	plan, ok := bc.NextUpgrade()
+	if !ok || CompareVersions(NodeVersion, plan.Version) >= 0 || plan.Height <= height {
+		return
+	}
+	remaining := plan.Height - height
+	if remaining > UpgradeWarnBlocks || (remaining > 10 && remaining%100 != 0) {
+		return
+	}
+	log.Printf("Warning: Upgrade %s activates in %d blocks (height %d) and requires version %s; this node runs %s and will stop validating",
+		plan.Name, remaining, plan.Height, plan.Version, NodeVersion)
+}
+
+// saveUpgradesLocked writes the upgrade schedule to dir. The caller must hold bc.mu.
+func (bc *Blockchain) saveUpgradesLocked(dir string) error {
+	data, err := json.MarshalIndent(bc.upgrades, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal upgrade schedule: %v", err)
+	}
+
+	path := filepath.Join(dir, upgradesFile)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write upgrade schedule: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadUpgradesLocked reads the upgrade schedule from dir. The caller must hold bc.mu.
+func (bc *Blockchain) loadUpgradesLocked(dir string) {
+	raw, err := ioutil.ReadFile(filepath.Join(dir, upgradesFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to read upgrade schedule: %v", err)
+		}
+		return
+	}
+	var upgrades []UpgradePlan
+	if err := json.Unmarshal(raw, &upgrades); err != nil {
+		log.Printf("Warning: Failed to parse upgrade schedule: %v", err)
+		return
+	}
+	sort.Slice(upgrades, func(i, j int) bool { return upgrades[i].Height < upgrades[j].Height })
+	bc.upgrades = upgrades
+}
@@ -3,12 +3,34 @@ package blockchain
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"sync/atomic"
 	"time"
 )
 
+// nodeClock holds the clock set with SetClock
+var nodeClock atomic.Value // func() time.Time
+
+// SetClock replaces the clock that block and transaction timestamps and clock
+// offset measurements are taken from, e.g. to inject clock skew in chaos
+// tests. nil restores the system clock.
+func SetClock(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	nodeClock.Store(now)
+}
+
+// Now returns the time of the node's clock
+func Now() time.Time {
+	if now, ok := nodeClock.Load().(func() time.Time); ok {
+		return now()
+	}
+	return time.Now()
+}
+
 // CurrentTimestamp returns the current Unix timestamp
 func CurrentTimestamp() int64 {
-	return time.Now().Unix()
+	return Now().Unix()
 }
 
 // GenerateHash generates a SHA-256 hash of the given data
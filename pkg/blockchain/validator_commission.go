@@ -0,0 +1,245 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ValidatorCommissionTxType is the transaction type by which a validator
+// announces a new commission on its delegators' rewards. The validator signs
+// it, so the commission schedule is the same on every node.
+const ValidatorCommissionTxType = "validator_commission"
+
+// MaxCommissionRate is a commission of 100%. Rates are in basis points.
+const MaxCommissionRate = 10000
+
+var (
+	// ErrCommissionTooHigh is returned for a commission above the maximum
+	ErrCommissionTooHigh = errors.New("commission rate exceeds the maximum")
+	// ErrCommissionReplayed is returned for a commission request no later
+	// than one the validator already made
+	ErrCommissionReplayed = errors.New("commission request was already used")
+)
+
+func init() {
+	RegisterTxLane(ValidatorCommissionTxType, LaneSystem)
+}
+
+// CommissionConfig bounds the commission validators take from their
+// delegators' rewards. It is a consensus parameter: the rates and effective
+// heights of the schedule follow from it, so all nodes must agree on it.
+type CommissionConfig struct {
+	MaxRate      uint64 `json:"maxRate"`      // highest commission in basis points
+	NoticeEpochs uint64 `json:"noticeEpochs"` // full epochs between announcing a raise and it taking effect
+}
+
+// DefaultCommissionConfig returns the default commission bounds
+func DefaultCommissionConfig() CommissionConfig {
+	return CommissionConfig{
+		MaxRate:      2000, // 20%
+		NoticeEpochs: 1,
+	}
+}
+
+// CommissionRequest is the payload of a validator commission transaction.
+// RequestedAt is the request's nonce: it must be later than that of the
+// validator's previous request.
+type CommissionRequest struct {
+	Rate        uint64 `json:"rate"` // basis points
+	RequestedAt int64  `json:"requestedAt"`
+}
+
+// CommissionChange is an entry of a validator's commission schedule
+type CommissionChange struct {
+	Rate            uint64 `json:"rate"`        // basis points
+	RequestedAt     int64  `json:"requestedAt"` // nonce of the request
+	AnnouncedAt     int64  `json:"announcedAt"` // timestamp of the block confirming it
+	AnnouncedHeight uint64 `json:"announcedHeight"`
+	EffectiveHeight uint64 `json:"effectiveHeight"` // first block the rate applies to
+	TxID            string `json:"txId"`
+}
+
+// NewCommissionTransaction creates the unsigned transaction by which a
+// validator announces rate
+func NewCommissionTransaction(validator string, request CommissionRequest) (*Transaction, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction(commissionTxID(validator, request.RequestedAt), validator, validator, 0, data)
+	tx.Type = ValidatorCommissionTxType
+	return tx, nil
+}
+
+// commissionTxID is the ID of a validator's commission request, so each
+// request can only be queued and confirmed once
+func commissionTxID(validator string, requestedAt int64) string {
+	return fmt.Sprintf("validator_commission_%s_%d", validator, requestedAt)
+}
+
+// ParseCommissionRequest decodes the payload of a validator commission transaction
+func ParseCommissionRequest(tx *Transaction) (*CommissionRequest, error) {
+	if tx == nil || tx.Type != ValidatorCommissionTxType {
+		return nil, errors.New("not a validator commission transaction")
+	}
+	var request CommissionRequest
+	if err := json.Unmarshal(tx.Data, &request); err != nil {
+		return nil, fmt.Errorf("invalid commission request: %v", err)
+	}
+	return &request, nil
+}
+
+// SetCommissionConfig replaces the commission bounds
+func (bc *Blockchain) SetCommissionConfig(config CommissionConfig) error {
+	if config.MaxRate > MaxCommissionRate {
+		return fmt.Errorf("maximum commission %d exceeds %d basis points", config.MaxRate, MaxCommissionRate)
+	}
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.commissionConfig = config
+	return nil
+}
+
+// CommissionConfig returns the commission bounds
+func (bc *Blockchain) CommissionConfig() CommissionConfig {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.commissionConfig
+}
+
+// lastCommissionRequestLocked returns the nonce of the validator's last
+// confirmed commission request, 0 when it made none. The caller must hold bc.mu.
+func (bc *Blockchain) lastCommissionRequestLocked(validator string) int64 {
+	history := bc.commissions[validator]
+	if len(history) == 0 {
+		return 0
+	}
+	return history[len(history)-1].RequestedAt
+}
+
+// LastCommissionRequest returns the nonce of the validator's last commission
+// request, confirmed or waiting in the pool
+func (bc *Blockchain) LastCommissionRequest(validator string) int64 {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	last := bc.lastCommissionRequestLocked(validator)
+	for _, tx := range bc.pendingTxs {
+		if tx.Type != ValidatorCommissionTxType || tx.From != validator {
+			continue
+		}
+		if request, err := ParseCommissionRequest(tx); err == nil && request.RequestedAt > last {
+			last = request.RequestedAt
+		}
+	}
+	return last
+}
+
+// checkCommissionTransactionLocked validates a commission transaction: it
+// must carry no value, stay within the maximum and be later than the
+// validator's last confirmed request. The generic signature check makes sure
+// the validator itself sent it. The caller must hold bc.mu.
+func (bc *Blockchain) checkCommissionTransactionLocked(tx *Transaction) error {
+	if tx.Type != ValidatorCommissionTxType {
+		return nil
+	}
+	request, err := ParseCommissionRequest(tx)
+	if err != nil {
+		return err
+	}
+	if tx.Value != 0 {
+		return errors.New("commission transactions carry no value")
+	}
+	if tx.ID != commissionTxID(tx.From, request.RequestedAt) {
+		return fmt.Errorf("commission transaction of %s must have ID %s", tx.From, commissionTxID(tx.From, request.RequestedAt))
+	}
+	if request.Rate > bc.commissionConfig.MaxRate {
+		return fmt.Errorf("%w: %d basis points, the maximum is %d", ErrCommissionTooHigh, request.Rate, bc.commissionConfig.MaxRate)
+	}
+	if last := bc.lastCommissionRequestLocked(tx.From); request.RequestedAt <= last {
+		return fmt.Errorf("%w: sign a request dated after %d", ErrCommissionReplayed, last)
+	}
+	return nil
+}
+
+// applyCommissionTransactionLocked adds a confirmed commission request to the
+// validator's schedule. A lower rate applies from the next block; a higher
+// one only after the notice period, so delegators can leave before it
+// applies. A change that has not taken effect yet is replaced. The caller
+// must hold bc.mu.
+func (bc *Blockchain) applyCommissionTransactionLocked(tx *Transaction, block *Block) error {
+	if err := bc.checkCommissionTransactionLocked(tx); err != nil {
+		return err
+	}
+	request, _ := ParseCommissionRequest(tx)
+
+	change := CommissionChange{
+		Rate:            request.Rate,
+		RequestedAt:     request.RequestedAt,
+		AnnouncedAt:     block.Timestamp,
+		AnnouncedHeight: block.Index,
+		EffectiveHeight: block.Index + 1,
+		TxID:            tx.ID,
+	}
+	if request.Rate > bc.commissionAtLocked(tx.From, block.Index) {
+		length := bc.epochRewards.EpochLength
+		change.EffectiveHeight = (block.Index/length + bc.commissionConfig.NoticeEpochs + 1) * length
+	}
+
+	history := bc.commissions[tx.From]
+	if n := len(history); n > 0 && history[n-1].EffectiveHeight > block.Index {
+		history = history[:n-1]
+	}
+	bc.commissions[tx.From] = append(history, change)
+	return nil
+}
+
+// commissionAtLocked returns the commission of a validator at height, capped
+// at the current maximum. The caller must hold bc.mu.
+func (bc *Blockchain) commissionAtLocked(validator string, height uint64) uint64 {
+	var rate uint64
+	for _, change := range bc.commissions[validator] {
+		if change.EffectiveHeight > height {
+			break
+		}
+		rate = change.Rate
+	}
+	if rate > bc.commissionConfig.MaxRate {
+		rate = bc.commissionConfig.MaxRate
+	}
+	return rate
+}
+
+// CommissionAt returns the commission rate of a validator at height in basis points
+func (bc *Blockchain) CommissionAt(validator string, height uint64) uint64 {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.commissionAtLocked(validator, height)
+}
+
+// CommissionHistory returns the confirmed commission changes of a validator,
+// oldest first, including a change that has not taken effect yet
+func (bc *Blockchain) CommissionHistory(validator string) []CommissionChange {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return append([]CommissionChange{}, bc.commissions[validator]...)
+}
+
+// splitCommissionLocked splits the delegators' share of a validator's reward
+// at height into the validator's commission and what is left for the
+// delegators. The caller must hold bc.mu.
+func (bc *Blockchain) splitCommissionLocked(validator string, height uint64, amount *big.Int) (commission, delegators *big.Int) {
+	rate := bc.commissionAtLocked(validator, height)
+	commission = new(big.Int).Mul(amount, new(big.Int).SetUint64(rate))
+	commission.Quo(commission, big.NewInt(MaxCommissionRate))
+	return commission, new(big.Int).Sub(amount, commission)
+}
+
+// VerifyCommissionTransaction checks a validator commission transaction for
+// inclusion in the next block
+func (bc *Blockchain) VerifyCommissionTransaction(tx *Transaction) error {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.checkCommissionTransactionLocked(tx)
+}
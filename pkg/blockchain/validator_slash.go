@@ -0,0 +1,196 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// ValidatorSlashTxType is the transaction type that burns part of a
+// validator's locked stake. A validator sends it on behalf of an admin, so the
+// stake is burned on every node when the transaction is confirmed.
+const ValidatorSlashTxType = "validator_slash"
+
+// EvidenceSlashBasisPoints is the share of its locked stake, in basis points,
+// a validator loses when double-signing evidence against it is confirmed
+const EvidenceSlashBasisPoints = 500
+
+// MaxSlashReason is the longest reason a slash may give, in bytes
+const MaxSlashReason = 512
+
+// ErrInvalidSlash is returned for slash transactions that fail validation
+var ErrInvalidSlash = errors.New("invalid validator slash")
+
+func init() {
+	RegisterTxLane(ValidatorSlashTxType, LaneSystem)
+}
+
+// ValidatorSlash is the payload of a validator slash transaction
+type ValidatorSlash struct {
+	Validator string `json:"validator"`       // whose stake is burned
+	Amount    string `json:"amount"`          // in base units, capped at the locked stake
+	Reason    string `json:"reason"`          // why, for operators and auditors
+	Admin     string `json:"admin,omitempty"` // admin who ordered the slash through the sender's node
+}
+
+// ParseValidatorSlash decodes and validates the payload of a validator slash
+// transaction and returns it with its amount
+func ParseValidatorSlash(tx *Transaction) (*ValidatorSlash, *big.Int, error) {
+	if tx == nil || tx.Type != ValidatorSlashTxType {
+		return nil, nil, fmt.Errorf("%w: not a validator slash transaction", ErrInvalidSlash)
+	}
+	var slash ValidatorSlash
+	if err := json.Unmarshal(tx.Data, &slash); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidSlash, err)
+	}
+	if slash.Validator == "" {
+		return nil, nil, fmt.Errorf("%w: the validator is required", ErrInvalidSlash)
+	}
+	amount, ok := new(big.Int).SetString(slash.Amount, 10)
+	if !ok || amount.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("%w: amount must be a positive integer", ErrInvalidSlash)
+	}
+	if slash.Reason == "" {
+		return nil, nil, fmt.Errorf("%w: a reason is required", ErrInvalidSlash)
+	}
+	if len(slash.Reason) > MaxSlashReason {
+		return nil, nil, fmt.Errorf("%w: reason is longer than %d bytes", ErrInvalidSlash, MaxSlashReason)
+	}
+	return &slash, amount, nil
+}
+
+// checkValidatorSlashLocked validates a slash transaction for inclusion in a
+// block: it must be sent by a validator other than the one it slashes, carry
+// no value and leave the slashed validator some stake to burn. The caller
+// must hold bc.mu.
+func (bc *Blockchain) checkValidatorSlashLocked(tx *Transaction) error {
+	slash, _, err := ParseValidatorSlash(tx)
+	if err != nil {
+		return err
+	}
+	if tx.Value != 0 {
+		return fmt.Errorf("%w: slash transactions carry no value", ErrInvalidSlash)
+	}
+	if !bc.validators[tx.From] {
+		return fmt.Errorf("%w: %s is not a validator", ErrInvalidSlash, tx.From)
+	}
+	if tx.From == slash.Validator {
+		return fmt.Errorf("%w: %s cannot slash itself", ErrInvalidSlash, tx.From)
+	}
+	if locked, exists := bc.lockedBalances[slash.Validator]; !exists || locked.Sign() == 0 {
+		return fmt.Errorf("%w: validator %s has no stake to slash", ErrInsufficientLocked, slash.Validator)
+	}
+	return nil
+}
+
+// applyValidatorSlashLocked burns the stake a confirmed slash transaction
+// names. The caller must hold bc.mu.
+func (bc *Blockchain) applyValidatorSlashLocked(tx *Transaction) error {
+	if err := bc.checkValidatorSlashLocked(tx); err != nil {
+		return err
+	}
+	slash, amount, _ := ParseValidatorSlash(tx)
+	bc.burnStakeLocked(slash.Validator, amount, map[string]string{
+		"by":     tx.From,
+		"admin":  slash.Admin,
+		"reason": slash.Reason,
+		"txId":   tx.ID,
+	})
+	return nil
+}
+
+// slashForEvidenceLocked burns EvidenceSlashBasisPoints of the locked stake
+// of a validator convicted of double-signing by tx. The caller must hold bc.mu.
+func (bc *Blockchain) slashForEvidenceLocked(validator string, tx *Transaction) {
+	locked, exists := bc.lockedBalances[validator]
+	if !exists || locked.Sign() == 0 {
+		return
+	}
+	amount := new(big.Int).Mul(locked, big.NewInt(EvidenceSlashBasisPoints))
+	amount.Div(amount, big.NewInt(10000))
+	if amount.Sign() == 0 {
+		return
+	}
+	bc.burnStakeLocked(validator, amount, map[string]string{
+		"reason":   "double-signing",
+		"evidence": tx.ID,
+	})
+}
+
+// burnStakeLocked burns up to amount of the locked balance of validator and
+// records the slash with data. Locked balances are only changed while bc.mu is
+// held when blocks are applied, so the burn is the same on every node.
+// The caller must hold bc.mu.
+func (bc *Blockchain) burnStakeLocked(validator string, amount *big.Int, data map[string]string) {
+	locked, exists := bc.lockedBalances[validator]
+	if !exists || locked.Sign() == 0 {
+		return
+	}
+	if amount.Cmp(locked) > 0 {
+		amount = new(big.Int).Set(locked)
+	}
+	bc.lockedBalances[validator] = new(big.Int).Sub(locked, amount)
+
+	event := map[string]string{"amount": amount.String()}
+	for key, value := range data {
+		if value != "" {
+			event[key] = value
+		}
+	}
+	bc.RecordEvent(EventValidatorSlashed, validator, event)
+}
+
+// ProposeSlash queues a transaction burning up to amount of the locked stake
+// of validator, sent by another validator whose key this node holds. The stake
+// is burned on every node once the transaction is confirmed.
+func (bc *Blockchain) ProposeSlash(validator string, amount *big.Int, reason, admin string) (*Transaction, error) {
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidSlash)
+	}
+	data, err := json.Marshal(ValidatorSlash{
+		Validator: validator,
+		Amount:    amount.String(),
+		Reason:    reason,
+		Admin:     admin,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	senders := make([]string, 0, len(bc.validators))
+	for address, active := range bc.validators {
+		if active && address != validator {
+			senders = append(senders, address)
+		}
+	}
+	sort.Strings(senders)
+	for _, sender := range senders {
+		keyPair, exists := bc.keyPairs[sender]
+		if !exists || keyPair.Signer() == nil {
+			continue
+		}
+		tx := NewTransaction(fmt.Sprintf("validator_slash_%s_%d", validator, time.Now().UnixNano()), sender, sender, 0, data)
+		tx.Type = ValidatorSlashTxType
+		if err := tx.SignWith(keyPair.Signer()); err != nil {
+			return nil, err
+		}
+		if err := bc.addTransactionLocked(tx); err != nil {
+			return nil, err
+		}
+		return tx, nil
+	}
+	return nil, fmt.Errorf("%w: this node holds the key of no other validator to send the slash", ErrInvalidSlash)
+}
+
+// VerifyValidatorSlash checks a validator slash transaction for inclusion in the next block
+func (bc *Blockchain) VerifyValidatorSlash(tx *Transaction) error {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	return bc.checkValidatorSlashLocked(tx)
+}
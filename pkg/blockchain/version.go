@@ -0,0 +1,49 @@
+package blockchain
+
+import "runtime"
+
+// Build metadata of this node. Release builds set them with
+// -ldflags "-X <module>/pkg/blockchain.GitCommit=... -X <module>/pkg/blockchain.BuildDate=...",
+// see the Makefile.
+var (
+	GitCommit = "unknown"
+	BuildDate = "unknown"
+)
+
+// Peer protocol versions. Every peer message announces ProtocolVersion;
+// messages announcing a version below MinProtocolVersion are dropped.
+const (
+	ProtocolVersion    = 1
+	MinProtocolVersion = 1
+)
+
+// BuildInfo describes the software a node runs
+type BuildInfo struct {
+	Version            string `json:"version"` // semantic version, compared against governance upgrades
+	GitCommit          string `json:"gitCommit"`
+	BuildDate          string `json:"buildDate"`
+	GoVersion          string `json:"goVersion"`
+	ProtocolVersion    int    `json:"protocolVersion"`
+	MinProtocolVersion int    `json:"minProtocolVersion"` // oldest peer protocol still accepted
+}
+
+// Build returns the version and build metadata of this node
+func Build() BuildInfo {
+	return BuildInfo{
+		Version:            NodeVersion,
+		GitCommit:          GitCommit,
+		BuildDate:          BuildDate,
+		GoVersion:          runtime.Version(),
+		ProtocolVersion:    ProtocolVersion,
+		MinProtocolVersion: MinProtocolVersion,
+	}
+}
+
+// ProtocolSupported reports whether this node talks to peers announcing
+// protocol. Peers that announce none predate protocol versions and count as 1.
+func ProtocolSupported(protocol int) bool {
+	if protocol == 0 {
+		protocol = 1
+	}
+	return protocol >= MinProtocolVersion
+}
@@ -5,8 +5,6 @@ import (
 	"crypto/sha256"
 	"crypto/elliptic"
 	"encoding/hex"
-	"fmt"
-	"math/big"
 )
 
 // Wallet represents a user's wallet with a key pair
@@ -31,20 +29,28 @@ func CreateWallet() (*Wallet, error) {
 	return wallet, nil
 }
 
-// ImportPrivateKey reconstructs a private key from a hex string
-func ImportPrivateKey(privateKeyHex string) (*ecdsa.PrivateKey, error) {
-	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
+// CreateWalletOfType creates a new wallet whose key uses the given signature scheme.
+// P-256 wallets keep the original address format; others use AddressFromPublicKey.
+func CreateWalletOfType(keyType KeyType) (*Wallet, error) {
+	if keyType == KeyTypeP256 {
+		return CreateWallet()
+	}
+
+	keyPair, err := NewKeyPairOfType(keyType)
 	if err != nil {
-		return nil, fmt.Errorf("invalid private key format: %v", err)
+		return nil, err
 	}
+	return &Wallet{
+		Address: keyPair.GetAddress(),
+		KeyPair: keyPair,
+	}, nil
+}
 
-	curve := elliptic.P256()
-	privateKey := new(ecdsa.PrivateKey)
-	privateKey.PublicKey.Curve = curve
-	privateKey.D = new(big.Int).SetBytes(privateKeyBytes)
-	privateKey.PublicKey.X, privateKey.PublicKey.Y = curve.ScalarBaseMult(privateKeyBytes)
-	
-	return privateKey, nil
+// ImportPrivateKey reconstructs a private key from a hex, PEM or WIF-style string.
+// The encoding is detected automatically; see ImportPrivateKeyWithFormat.
+func ImportPrivateKey(encodedKey string) (*ecdsa.PrivateKey, error) {
+	privateKey, _, err := ImportPrivateKeyWithFormat(encodedKey, KeyFormatAuto)
+	return privateKey, err
 }
 
 // GenerateAddress generates a blockchain address from a public key
@@ -0,0 +1,255 @@
+package blockchain
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// walletControlsFile holds the loss controls of custodied wallets
+const walletControlsFile = "wallet_controls.json"
+
+// TOTP parameters (RFC 6238 defaults understood by authenticator apps)
+const (
+	totpPeriod = 30 // seconds per code
+	totpDigits = 6
+	totpSkew   = 1 // codes of the neighbouring periods are accepted for clock drift
+)
+
+var (
+	// ErrSpendingLimit is returned when a transfer would exceed the daily limit of a wallet
+	ErrSpendingLimit = errors.New("daily spending limit exceeded")
+	// ErrSecondFactorRequired is returned when a wallet needs a one-time code that was not given
+	ErrSecondFactorRequired = errors.New("second factor required")
+	// ErrSecondFactorFailed is returned for a wrong or reused code or a denied approval
+	ErrSecondFactorFailed = errors.New("second factor verification failed")
+	// ErrInvalidWalletControls is returned for controls that fail validation
+	ErrInvalidWalletControls = errors.New("invalid wallet controls")
+)
+
+// WalletControls are the loss controls the node enforces before spending from
+// a custodied wallet: a daily limit and an optional second factor, either a
+// TOTP code or an approval callback.
+type WalletControls struct {
+	Address     string `json:"address"`
+	DailyLimit  uint64 `json:"dailyLimit,omitempty"`  // value per UTC day; 0 is unlimited
+	TOTPSecret  string `json:"totpSecret,omitempty"`  // base32 secret; empty disables TOTP
+	ApprovalURL string `json:"approvalUrl,omitempty"` // must answer 2xx for a spend to proceed
+	UpdatedBy   string `json:"updatedBy"`
+	UpdatedAt   int64  `json:"updatedAt"`
+
+	// Spending ledger of the current UTC day
+	SpentDay     string `json:"spentDay,omitempty"`
+	Spent        uint64 `json:"spent,omitempty"`
+	LastTOTPStep int64  `json:"lastTotpStep,omitempty"` // last accepted code, refused on reuse
+}
+
+// SpentToday returns the value spent during the current UTC day
+func (c WalletControls) SpentToday() uint64 {
+	if c.SpentDay != time.Now().UTC().Format("2006-01-02") {
+		return 0
+	}
+	return c.Spent
+}
+
+// walletControlStore holds the wallet controls. It has its own lock because
+// the controls are node policy and never change together with chain state.
+type walletControlStore struct {
+	mu       sync.Mutex
+	controls map[string]*WalletControls
+}
+
+// GenerateTOTPSecret returns a new random base32 TOTP secret
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret), nil
+}
+
+// totpCode computes the code of a TOTP secret for a time step
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// decodeTOTPSecret decodes a base32 secret, with or without padding
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.TrimRight(strings.TrimSpace(secret), "="))
+	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+}
+
+// SetWalletControls sets the controls of a wallet whose key the node holds.
+// The spending ledger and replay state are kept across updates.
+func (bc *Blockchain) SetWalletControls(controls WalletControls) (WalletControls, error) {
+	if _, exists := bc.GetKeyPair(controls.Address); !exists {
+		return WalletControls{}, fmt.Errorf("%w: %s", ErrKeyPairNotFound, controls.Address)
+	}
+	if controls.TOTPSecret != "" {
+		if _, err := decodeTOTPSecret(controls.TOTPSecret); err != nil {
+			return WalletControls{}, fmt.Errorf("%w: totp secret is not base32", ErrInvalidWalletControls)
+		}
+	}
+	if controls.ApprovalURL != "" && !strings.HasPrefix(controls.ApprovalURL, "https://") && !strings.HasPrefix(controls.ApprovalURL, "http://") {
+		return WalletControls{}, fmt.Errorf("%w: approval url must start with http:// or https://", ErrInvalidWalletControls)
+	}
+	controls.UpdatedAt = time.Now().Unix()
+
+	bc.walletControls.mu.Lock()
+	defer bc.walletControls.mu.Unlock()
+	if bc.walletControls.controls == nil {
+		bc.walletControls.controls = make(map[string]*WalletControls)
+	}
+	if existing, exists := bc.walletControls.controls[controls.Address]; exists {
+		controls.SpentDay, controls.Spent = existing.SpentDay, existing.Spent
+		controls.LastTOTPStep = existing.LastTOTPStep
+	}
+	bc.walletControls.controls[controls.Address] = &controls
+	if err := bc.saveWalletControlsLocked(GetBlockchainDataPath()); err != nil {
+		return WalletControls{}, err
+	}
+	return controls, nil
+}
+
+// RemoveWalletControls removes every control of a wallet
+func (bc *Blockchain) RemoveWalletControls(address string) error {
+	bc.walletControls.mu.Lock()
+	defer bc.walletControls.mu.Unlock()
+	delete(bc.walletControls.controls, address)
+	return bc.saveWalletControlsLocked(GetBlockchainDataPath())
+}
+
+// GetWalletControls returns the controls of a wallet
+func (bc *Blockchain) GetWalletControls(address string) (WalletControls, bool) {
+	bc.walletControls.mu.Lock()
+	defer bc.walletControls.mu.Unlock()
+	controls, exists := bc.walletControls.controls[address]
+	if !exists {
+		return WalletControls{}, false
+	}
+	return *controls, true
+}
+
+// VerifyWalletTOTP checks a one-time code of a wallet with TOTP enabled. A
+// code is accepted once.
+func (bc *Blockchain) VerifyWalletTOTP(address, code string) error {
+	bc.walletControls.mu.Lock()
+	defer bc.walletControls.mu.Unlock()
+
+	controls, exists := bc.walletControls.controls[address]
+	if !exists || controls.TOTPSecret == "" {
+		return nil
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return fmt.Errorf("%w: a one-time code is required to spend from %s", ErrSecondFactorRequired, address)
+	}
+	key, err := decodeTOTPSecret(controls.TOTPSecret)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWalletControls, err)
+	}
+
+	now := time.Now().Unix() / totpPeriod
+	for step := now - totpSkew; step <= now+totpSkew; step++ {
+		if !hmac.Equal([]byte(totpCode(key, step)), []byte(code)) {
+			continue
+		}
+		if step <= controls.LastTOTPStep {
+			return fmt.Errorf("%w: code already used", ErrSecondFactorFailed)
+		}
+		controls.LastTOTPStep = step
+		return bc.saveWalletControlsLocked(GetBlockchainDataPath())
+	}
+	return fmt.Errorf("%w: wrong one-time code", ErrSecondFactorFailed)
+}
+
+// ReserveWalletSpend counts value against the daily limit of a wallet. The
+// returned release function gives the reservation back when the spend fails.
+func (bc *Blockchain) ReserveWalletSpend(address string, value uint64) (func(), error) {
+	bc.walletControls.mu.Lock()
+	defer bc.walletControls.mu.Unlock()
+
+	controls, exists := bc.walletControls.controls[address]
+	if !exists || controls.DailyLimit == 0 {
+		return func() {}, nil
+	}
+	today := time.Now().UTC().Format("2006-01-02")
+	if controls.SpentDay != today {
+		controls.SpentDay, controls.Spent = today, 0
+	}
+	if value > controls.DailyLimit || controls.Spent > controls.DailyLimit-value {
+		return nil, fmt.Errorf("%w: %d spent of %d today, %d requested", ErrSpendingLimit, controls.Spent, controls.DailyLimit, value)
+	}
+	controls.Spent += value
+	if err := bc.saveWalletControlsLocked(GetBlockchainDataPath()); err != nil {
+		controls.Spent -= value
+		return nil, err
+	}
+
+	return func() {
+		bc.walletControls.mu.Lock()
+		defer bc.walletControls.mu.Unlock()
+		if controls.SpentDay == today && controls.Spent >= value {
+			controls.Spent -= value
+			if err := bc.saveWalletControlsLocked(GetBlockchainDataPath()); err != nil {
+				log.Printf("Warning: Failed to save wallet controls: %v", err)
+			}
+		}
+	}, nil
+}
+
+// saveWalletControlsLocked writes the controls to dir. The caller must hold
+// bc.walletControls.mu.
+func (bc *Blockchain) saveWalletControlsLocked(dir string) error {
+	data, err := json.MarshalIndent(bc.walletControls.controls, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal wallet controls: %v", err)
+	}
+
+	path := filepath.Join(dir, walletControlsFile)
+	tmp := path + ".tmp"
+	// TOTP secrets are stored here
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write wallet controls: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadWalletControls reads the controls from dir
+func (bc *Blockchain) loadWalletControls(dir string) {
+	bc.walletControls.mu.Lock()
+	defer bc.walletControls.mu.Unlock()
+
+	bc.walletControls.controls = make(map[string]*WalletControls)
+	raw, err := ioutil.ReadFile(filepath.Join(dir, walletControlsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to read wallet controls: %v", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(raw, &bc.walletControls.controls); err != nil {
+		log.Printf("Warning: Failed to parse wallet controls: %v", err)
+		bc.walletControls.controls = make(map[string]*WalletControls)
+	}
+}
@@ -0,0 +1,224 @@
+package blockchain
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// walletPassphrasesFile holds the passphrase hashes of custodied wallets
+const walletPassphrasesFile = "wallet_passphrases.json"
+
+// Unlock windows and passphrase rules
+const (
+	MinWalletPassphrase   = 8
+	DefaultUnlockDuration = 5 * time.Minute
+	MaxUnlockDuration     = time.Hour
+	passphraseIterations  = 100000
+)
+
+var (
+	// ErrWalletLocked is returned when a custodied wallet signs without being unlocked
+	ErrWalletLocked = errors.New("wallet is locked")
+	// ErrWrongPassphrase is returned for a passphrase that does not match
+	ErrWrongPassphrase = errors.New("wrong wallet passphrase")
+	// ErrNoPassphrase is returned when unlocking a wallet that has no passphrase yet
+	ErrNoPassphrase = errors.New("wallet has no passphrase")
+	// ErrInvalidPassphrase is returned for a passphrase that is too weak to set
+	ErrInvalidPassphrase = errors.New("invalid wallet passphrase")
+)
+
+// walletPassphrase is the stored PBKDF2 hash of a wallet passphrase
+type walletPassphrase struct {
+	Salt       string `json:"salt"`
+	Hash       string `json:"hash"`
+	Iterations int    `json:"iterations"`
+	UpdatedAt  int64  `json:"updatedAt"`
+}
+
+// matches reports whether passphrase hashes to the stored value
+func (p *walletPassphrase) matches(passphrase string) bool {
+	salt, err := hex.DecodeString(p.Salt)
+	if err != nil {
+		return false
+	}
+	want, err := hex.DecodeString(p.Hash)
+	if err != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, passphrase, salt, p.Iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// walletLockStore holds passphrase hashes and the unlock windows of custodied
+// wallets. Unlocks live only in memory, so a restart locks every wallet.
+type walletLockStore struct {
+	mu          sync.Mutex
+	passphrases map[string]*walletPassphrase
+	unlocked    map[string]time.Time // address -> end of the unlock window
+}
+
+// HasWalletPassphrase reports whether a custodied wallet has a passphrase
+func (bc *Blockchain) HasWalletPassphrase(address string) bool {
+	bc.walletLocks.mu.Lock()
+	defer bc.walletLocks.mu.Unlock()
+	_, exists := bc.walletLocks.passphrases[address]
+	return exists
+}
+
+// SetWalletPassphrase sets the passphrase of a wallet whose key the node holds.
+// Changing an existing passphrase requires the current one; setting the first
+// passphrase is authorized by the caller, e.g. by proving the private key.
+// The wallet is locked afterwards.
+func (bc *Blockchain) SetWalletPassphrase(address, passphrase, current string) error {
+	if _, exists := bc.GetKeyPair(address); !exists {
+		return fmt.Errorf("%w: %s", ErrKeyPairNotFound, address)
+	}
+	if len(passphrase) < MinWalletPassphrase {
+		return fmt.Errorf("%w: must be at least %d characters", ErrInvalidPassphrase, MinWalletPassphrase)
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %v", err)
+	}
+	hash, err := pbkdf2.Key(sha256.New, passphrase, salt, passphraseIterations, 32)
+	if err != nil {
+		return err
+	}
+
+	bc.walletLocks.mu.Lock()
+	defer bc.walletLocks.mu.Unlock()
+	if existing, exists := bc.walletLocks.passphrases[address]; exists && !existing.matches(current) {
+		return ErrWrongPassphrase
+	}
+	if bc.walletLocks.passphrases == nil {
+		bc.walletLocks.passphrases = make(map[string]*walletPassphrase)
+	}
+	bc.walletLocks.passphrases[address] = &walletPassphrase{
+		Salt:       hex.EncodeToString(salt),
+		Hash:       hex.EncodeToString(hash),
+		Iterations: passphraseIterations,
+		UpdatedAt:  time.Now().Unix(),
+	}
+	delete(bc.walletLocks.unlocked, address)
+	return bc.saveWalletPassphrasesLocked(GetBlockchainDataPath())
+}
+
+// UnlockWallet lets the node sign for address until the returned time. A zero
+// duration unlocks for DefaultUnlockDuration; longer windows are capped at
+// MaxUnlockDuration.
+func (bc *Blockchain) UnlockWallet(address, passphrase string, duration time.Duration) (time.Time, error) {
+	switch {
+	case duration <= 0:
+		duration = DefaultUnlockDuration
+	case duration > MaxUnlockDuration:
+		duration = MaxUnlockDuration
+	}
+
+	bc.walletLocks.mu.Lock()
+	defer bc.walletLocks.mu.Unlock()
+	stored, exists := bc.walletLocks.passphrases[address]
+	if !exists {
+		return time.Time{}, fmt.Errorf("%w: set one before unlocking %s", ErrNoPassphrase, address)
+	}
+	if !stored.matches(passphrase) {
+		return time.Time{}, ErrWrongPassphrase
+	}
+
+	until := time.Now().Add(duration)
+	if bc.walletLocks.unlocked == nil {
+		bc.walletLocks.unlocked = make(map[string]time.Time)
+	}
+	bc.walletLocks.unlocked[address] = until
+	log.Printf("Wallet %s unlocked until %s", address, until.Format(time.RFC3339))
+	return until, nil
+}
+
+// LockWallet ends the unlock window of a wallet
+func (bc *Blockchain) LockWallet(address string) {
+	bc.walletLocks.mu.Lock()
+	defer bc.walletLocks.mu.Unlock()
+	delete(bc.walletLocks.unlocked, address)
+}
+
+// WalletUnlockedUntil returns the end of the unlock window of a wallet, if it is unlocked
+func (bc *Blockchain) WalletUnlockedUntil(address string) (time.Time, bool) {
+	bc.walletLocks.mu.Lock()
+	defer bc.walletLocks.mu.Unlock()
+	until, exists := bc.walletLocks.unlocked[address]
+	if !exists {
+		return time.Time{}, false
+	}
+	if time.Now().After(until) {
+		delete(bc.walletLocks.unlocked, address)
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// SignWithUnlockedWallet signs tx with the custodied key of tx.From. The
+// wallet must be inside an unlock window.
+func (bc *Blockchain) SignWithUnlockedWallet(tx *Transaction) error {
+	if tx == nil {
+		return ErrNilTransaction
+	}
+	keyPair, exists := bc.GetKeyPair(tx.From)
+	if !exists {
+		return fmt.Errorf("%w: the node does not hold the key of %s", ErrKeyPairNotFound, tx.From)
+	}
+	if _, unlocked := bc.WalletUnlockedUntil(tx.From); !unlocked {
+		return fmt.Errorf("%w: unlock %s with its passphrase first", ErrWalletLocked, tx.From)
+	}
+	return tx.SignWith(keyPair.Signer())
+}
+
+// saveWalletPassphrasesLocked writes the passphrase hashes to dir. The caller
+// must hold bc.walletLocks.mu.
+func (bc *Blockchain) saveWalletPassphrasesLocked(dir string) error {
+	data, err := json.MarshalIndent(bc.walletLocks.passphrases, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal wallet passphrases: %v", err)
+	}
+
+	path := filepath.Join(dir, walletPassphrasesFile)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write wallet passphrases: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadWalletPassphrases reads the passphrase hashes from dir
+func (bc *Blockchain) loadWalletPassphrases(dir string) {
+	bc.walletLocks.mu.Lock()
+	defer bc.walletLocks.mu.Unlock()
+
+	bc.walletLocks.passphrases = make(map[string]*walletPassphrase)
+	bc.walletLocks.unlocked = make(map[string]time.Time)
+	raw, err := ioutil.ReadFile(filepath.Join(dir, walletPassphrasesFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Failed to read wallet passphrases: %v", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(raw, &bc.walletLocks.passphrases); err != nil {
+		log.Printf("Warning: Failed to parse wallet passphrases: %v", err)
+		bc.walletLocks.passphrases = make(map[string]*walletPassphrase)
+	}
+}
@@ -0,0 +1,301 @@
+// Package chaos injects faults into a node for soak tests on development
+// networks: it delays disk writes, drops received P2P messages and skews the
+// node's clock at random, while periodically checking the chain invariants.
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// ErrMainnet is returned when chaos injection is started on the main network
+var ErrMainnet = errors.New("chaos injection is only allowed on development networks")
+
+// maxViolationHistory is the number of invariant violations kept for the status report
+const maxViolationHistory = 50
+
+// Config controls which faults are injected and how often
+type Config struct {
+	Seed              int64         // seeds the fault schedule; 0 picks one, logged so a run can be repeated
+	DiskDelayRate     float64       // share of state file writes that are delayed, 0 to 1
+	MaxDiskDelay      time.Duration // longest delay of a state file write
+	MessageDropRate   float64       // share of received P2P messages that are dropped, 0 to 1
+	MaxClockSkew      time.Duration // largest offset of the node clock, either way
+	SkewInterval      time.Duration // time between changes of the clock offset
+	InvariantInterval time.Duration // time between invariant checks
+	HaltOnViolation   bool          // exit the process on the first violated invariant
+}
+
+// DefaultConfig returns the default chaos configuration
+func DefaultConfig() *Config {
+	return &Config{
+		DiskDelayRate:     0.1,
+		MaxDiskDelay:      2 * time.Second,
+		MessageDropRate:   0.05,
+		MaxClockSkew:      5 * time.Second,
+		SkewInterval:      time.Minute,
+		InvariantInterval: 10 * time.Second,
+	}
+}
+
+// Validate checks that the configuration is usable
+func (c *Config) Validate() error {
+	if c.DiskDelayRate < 0 || c.DiskDelayRate > 1 || c.MessageDropRate < 0 || c.MessageDropRate > 1 {
+		return errors.New("chaos rates must be between 0 and 1")
+	}
+	if c.MaxDiskDelay < 0 || c.MaxClockSkew < 0 {
+		return errors.New("chaos delays and clock skew must not be negative")
+	}
+	if c.MaxClockSkew > 0 && c.SkewInterval <= 0 {
+		return errors.New("the clock skew interval must be positive")
+	}
+	if c.InvariantInterval <= 0 {
+		return errors.New("the invariant check interval must be positive")
+	}
+	return nil
+}
+
+// Network is the part of the P2P node faults are injected into
+type Network interface {
+	SetMessageDropper(drop func(from, msgType string) bool)
+}
+
+// Stats counts the injected faults and invariant checks
+type Stats struct {
+	Seed            int64                           `json:"seed"`
+	DelayedWrites   uint64                          `json:"delayedWrites"`
+	DiskDelay       string                          `json:"diskDelay"` // total time writes were held up
+	DroppedMessages map[string]uint64               `json:"droppedMessages"`
+	ClockSkew       string                          `json:"clockSkew"` // current offset of the node clock
+	InvariantChecks uint64                          `json:"invariantChecks"`
+	LastCheckAt     int64                           `json:"lastCheckAt,omitempty"`
+	Violations      []blockchain.InvariantViolation `json:"violations"` // most recent last
+	ViolationsTotal uint64                          `json:"violationsTotal"`
+}
+
+// Injector injects faults into a node and checks its invariants
+type Injector struct {
+	blockchain *blockchain.Blockchain
+	config     *Config
+
+	mu          sync.Mutex
+	rng         *rand.Rand
+	seed        int64
+	skew        time.Duration
+	delayed     uint64
+	delayTotal  time.Duration
+	dropped     map[string]uint64
+	checks      uint64
+	lastCheckAt time.Time
+	violations  []blockchain.InvariantViolation
+	violated    uint64
+	stopCh      chan struct{}
+}
+
+// New creates an injector for the chain
+func New(bc *blockchain.Blockchain, config *Config) (*Injector, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Injector{
+		blockchain: bc,
+		config:     config,
+		rng:        rand.New(rand.NewSource(seed)),
+		seed:       seed,
+		dropped:    make(map[string]uint64),
+	}, nil
+}
+
+// Start installs the fault hooks into the chain, the network (which may be
+// nil) and the node clock, and begins checking invariants. network must not
+// be started yet. Chaos injection refuses to run on the main network.
+func (in *Injector) Start(network Network) error {
+	if blockchain.ChainID() == blockchain.DefaultChainID {
+		return fmt.Errorf("%w: set a chain ID other than %q", ErrMainnet, blockchain.DefaultChainID)
+	}
+	in.mu.Lock()
+	if in.stopCh != nil {
+		in.mu.Unlock()
+		return errors.New("chaos injection already running")
+	}
+	stopCh := make(chan struct{})
+	in.stopCh = stopCh
+	if in.config.MaxClockSkew > 0 {
+		in.skew = in.drawSkewLocked()
+	}
+	in.mu.Unlock()
+
+	// The hooks run under the chain lock and take in.mu, so they are
+	// installed without holding it
+	if in.config.DiskDelayRate > 0 && in.config.MaxDiskDelay > 0 {
+		in.blockchain.SetDiskWriteHook(in.delayWrite)
+	}
+	if in.config.MessageDropRate > 0 && network != nil {
+		network.SetMessageDropper(in.dropMessage)
+	}
+	if in.config.MaxClockSkew > 0 {
+		blockchain.SetClock(in.now)
+	}
+
+	go in.run(stopCh)
+	log.Printf("Chaos injection started (seed %d): %.0f%% of disk writes delayed up to %s, %.0f%% of P2P messages dropped, clock skew up to %s",
+		in.seed, in.config.DiskDelayRate*100, in.config.MaxDiskDelay, in.config.MessageDropRate*100, in.config.MaxClockSkew)
+	return nil
+}
+
+// Stop removes the disk and clock faults and stops checking invariants.
+// Dropped messages stop only when the network is restarted.
+func (in *Injector) Stop() {
+	in.mu.Lock()
+	if in.stopCh == nil {
+		in.mu.Unlock()
+		return
+	}
+	close(in.stopCh)
+	in.stopCh = nil
+	in.skew = 0
+	in.mu.Unlock()
+
+	in.blockchain.SetDiskWriteHook(nil)
+	blockchain.SetClock(nil)
+
+	stats := in.Stats()
+	dropped := uint64(0)
+	for _, count := range stats.DroppedMessages {
+		dropped += count
+	}
+	log.Printf("Chaos injection stopped (seed %d): %d writes delayed by %s in total, %d messages dropped, %d invariant violations in %d checks",
+		stats.Seed, stats.DelayedWrites, stats.DiskDelay, dropped, stats.ViolationsTotal, stats.InvariantChecks)
+}
+
+// run changes the clock skew and checks the invariants until stopped
+func (in *Injector) run(stopCh chan struct{}) {
+	checks := time.NewTicker(in.config.InvariantInterval)
+	defer checks.Stop()
+	var skewChanges <-chan time.Time
+	if in.config.MaxClockSkew > 0 {
+		ticker := time.NewTicker(in.config.SkewInterval)
+		defer ticker.Stop()
+		skewChanges = ticker.C
+	}
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-skewChanges:
+			in.mu.Lock()
+			in.skew = in.drawSkewLocked()
+			skew := in.skew
+			in.mu.Unlock()
+			log.Printf("Chaos: node clock now %s off", skew)
+		case <-checks.C:
+			in.CheckInvariants()
+		}
+	}
+}
+
+// chanceLocked reports whether an event of probability rate happens. The caller must hold in.mu.
+func (in *Injector) chanceLocked(rate float64) bool {
+	return rate > 0 && in.rng.Float64() < rate
+}
+
+// drawSkewLocked picks a clock offset in [-MaxClockSkew, MaxClockSkew]. The caller must hold in.mu.
+func (in *Injector) drawSkewLocked() time.Duration {
+	max := int64(in.config.MaxClockSkew)
+	return time.Duration(in.rng.Int63n(2*max+1) - max)
+}
+
+// delayWrite holds up some state file writes
+func (in *Injector) delayWrite(name string) {
+	in.mu.Lock()
+	if !in.chanceLocked(in.config.DiskDelayRate) {
+		in.mu.Unlock()
+		return
+	}
+	delay := time.Duration(in.rng.Int63n(int64(in.config.MaxDiskDelay)) + 1)
+	in.delayed++
+	in.delayTotal += delay
+	in.mu.Unlock()
+
+	log.Printf("Chaos: delaying the write of %s by %s", name, delay)
+	time.Sleep(delay)
+}
+
+// dropMessage discards some received messages
+func (in *Injector) dropMessage(from, msgType string) bool {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if !in.chanceLocked(in.config.MessageDropRate) {
+		return false
+	}
+	in.dropped[msgType]++
+	return true
+}
+
+// now is the skewed node clock
+func (in *Injector) now() time.Time {
+	in.mu.Lock()
+	skew := in.skew
+	in.mu.Unlock()
+	return time.Now().Add(skew)
+}
+
+// CheckInvariants checks the chain invariants once and records the violations
+func (in *Injector) CheckInvariants() []blockchain.InvariantViolation {
+	violations := in.blockchain.CheckInvariants()
+
+	in.mu.Lock()
+	in.checks++
+	in.lastCheckAt = time.Now()
+	in.violated += uint64(len(violations))
+	in.violations = append(in.violations, violations...)
+	if len(in.violations) > maxViolationHistory {
+		in.violations = in.violations[len(in.violations)-maxViolationHistory:]
+	}
+	in.mu.Unlock()
+
+	for _, violation := range violations {
+		log.Printf("Chaos: invariant %s violated at height %d: %s", violation.Invariant, violation.Height, violation.Detail)
+	}
+	if len(violations) > 0 && in.config.HaltOnViolation {
+		log.Fatalf("Chaos: halting after %d invariant violations (seed %d)", len(violations), in.seed)
+	}
+	return violations
+}
+
+// Stats returns the injected faults and the invariant violations found so far
+func (in *Injector) Stats() Stats {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	stats := Stats{
+		Seed:            in.seed,
+		DelayedWrites:   in.delayed,
+		DiskDelay:       in.delayTotal.String(),
+		DroppedMessages: make(map[string]uint64, len(in.dropped)),
+		ClockSkew:       in.skew.String(),
+		InvariantChecks: in.checks,
+		Violations:      append([]blockchain.InvariantViolation{}, in.violations...),
+		ViolationsTotal: in.violated,
+	}
+	for msgType, count := range in.dropped {
+		stats.DroppedMessages[msgType] = count
+	}
+	if !in.lastCheckAt.IsZero() {
+		stats.LastCheckAt = in.lastCheckAt.Unix()
+	}
+	return stats
+}
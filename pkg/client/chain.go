@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/consensus"
+	"confirmix/pkg/network"
+)
+
+// Status is the node's summary from /api/status
+type Status struct {
+	Status          string                  `json:"status"` // "online", "upgrade_required" or "halted"
+	Height          uint64                  `json:"height"`
+	Version         string                  `json:"version"`
+	NodeType        string                  `json:"nodeType"` // "validator" or "observer"
+	ChainID         string                  `json:"chainId"`
+	NextUpgrade     *blockchain.UpgradePlan `json:"nextUpgrade,omitempty"`
+	UpgradeRequired bool                    `json:"upgradeRequired"`
+	Halted          bool                    `json:"halted"` // the circuit breaker pauses the chain
+}
+
+// Version is the node's build and enabled features from /api/version
+type Version struct {
+	Build    blockchain.BuildInfo `json:"build"`
+	ChainID  string               `json:"chainId"`
+	Features struct {
+		Governance  bool   `json:"governance"`
+		Contracts   bool   `json:"contracts"`
+		Staking     bool   `json:"staking"`
+		BFT         bool   `json:"bft"`
+		Observer    bool   `json:"observer"`
+		Replica     bool   `json:"replica"`
+		PoHProvider string `json:"pohProvider,omitempty"`
+	} `json:"features"`
+}
+
+// BlockSummary is a block as listed by /api/blocks
+type BlockSummary struct {
+	Index        uint64 `json:"Index"`
+	Timestamp    int64  `json:"Timestamp"`
+	Hash         string `json:"Hash"`
+	PrevHash     string `json:"PrevHash"`
+	Validator    string `json:"Validator"`
+	Transactions int    `json:"Transactions"` // transaction count
+}
+
+// BlockPage is a page of blocks
+type BlockPage struct {
+	Blocks     []BlockSummary
+	NextCursor string // empty on the last page
+}
+
+// Block is a full block with its utilization
+type Block struct {
+	blockchain.Block
+	Utilization blockchain.BlockUtilization `json:"Utilization"`
+}
+
+// Headers is a range of block headers and the chain height
+type Headers struct {
+	Height  uint64                   `json:"height"`
+	Headers []blockchain.BlockHeader `json:"headers"`
+}
+
+// Finality is the newest final block and the voting rounds in progress
+type Finality struct {
+	Finality blockchain.FinalityStatus `json:"finality"`
+	Voting   bool                      `json:"voting"` // the node runs the voting layer
+	Rounds   []consensus.BFTRound      `json:"rounds,omitempty"`
+}
+
+// EventPage is a page of chain events, oldest first
+type EventPage struct {
+	FromBlock  uint64                  `json:"fromBlock"`
+	Events     []blockchain.ChainEvent `json:"events"`
+	NextCursor string                  `json:"nextCursor,omitempty"`
+}
+
+// Status returns the node's status
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var status Status
+	if _, err := c.get(ctx, "/api/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Version returns the node's build information and enabled features
+func (c *Client) Version(ctx context.Context) (*Version, error) {
+	var version Version
+	if _, err := c.get(ctx, "/api/version", nil, &version); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// Blocks returns a page of blocks, newest first unless opts says otherwise
+func (c *Client) Blocks(ctx context.Context, opts *ListOptions) (*BlockPage, error) {
+	var page BlockPage
+	header, err := c.get(ctx, "/api/blocks", opts.values(), &page.Blocks)
+	if err != nil {
+		return nil, err
+	}
+	page.NextCursor = header.Get(nextCursorHeader)
+	return &page, nil
+}
+
+// Block returns the block at index
+func (c *Client) Block(ctx context.Context, index uint64) (*Block, error) {
+	var block Block
+	if _, err := c.get(ctx, "/api/blocks/"+strconv.FormatUint(index, 10), nil, &block); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// Headers returns count headers starting at from
+func (c *Client) Headers(ctx context.Context, from uint64, count int) (*Headers, error) {
+	query := url.Values{}
+	query.Set("from", strconv.FormatUint(from, 10))
+	if count > 0 {
+		query.Set("count", strconv.Itoa(count))
+	}
+	var headers Headers
+	if _, err := c.get(ctx, "/api/headers", query, &headers); err != nil {
+		return nil, err
+	}
+	return &headers, nil
+}
+
+// Finality returns the newest final block known to the node
+func (c *Client) Finality(ctx context.Context) (*Finality, error) {
+	var finality Finality
+	if _, err := c.get(ctx, "/api/finality", nil, &finality); err != nil {
+		return nil, err
+	}
+	return &finality, nil
+}
+
+// Events returns a page of chain events from fromBlock, optionally of one type
+func (c *Client) Events(ctx context.Context, fromBlock uint64, eventType string, opts *ListOptions) (*EventPage, error) {
+	query := opts.values()
+	query.Set("fromBlock", strconv.FormatUint(fromBlock, 10))
+	if eventType != "" {
+		query.Set("type", eventType)
+	}
+	var page EventPage
+	if _, err := c.get(ctx, "/api/events", query, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// CircuitBreaker returns whether the chain is paused and its pauses and resumes
+func (c *Client) CircuitBreaker(ctx context.Context) (*blockchain.CircuitBreakerStatus, error) {
+	var status blockchain.CircuitBreakerStatus
+	if _, err := c.get(ctx, "/api/circuit-breaker", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// NetworkStats returns the traffic and latency of each peer of the node, slowest first
+func (c *Client) NetworkStats(ctx context.Context) (*network.NetworkStats, error) {
+	var stats network.NetworkStats
+	if _, err := c.get(ctx, "/api/network/stats", nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
@@ -0,0 +1,300 @@
+// Package client is a typed Go client for the node's HTTP API. It covers
+// blocks, transactions, wallets, validators, governance and the header
+// stream, retries requests the node refused or failed to answer, and takes a
+// context for every call.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIKeyHeader carries the client's API key
+const APIKeyHeader = "X-API-Key"
+
+// nextCursorHeader carries the continuation token of paged lists
+const nextCursorHeader = "X-Next-Cursor"
+
+// maxErrorBody bounds how much of an error response is read
+const maxErrorBody = 64 << 10
+
+// Options configures a Client
+type Options struct {
+	APIKey       string        // sent as X-API-Key when set
+	Timeout      time.Duration // per attempt; 0 leaves it to the context
+	MaxRetries   int           // extra attempts after a retryable failure
+	RetryBackoff time.Duration // first wait between attempts, doubled each time
+	MaxBackoff   time.Duration // longest wait between attempts
+	HTTPClient   *http.Client  // nil uses a client without a global timeout
+}
+
+// DefaultOptions returns the default timeout and retry settings
+func DefaultOptions() *Options {
+	return &Options{
+		Timeout:      10 * time.Second,
+		MaxRetries:   3,
+		RetryBackoff: 250 * time.Millisecond,
+		MaxBackoff:   5 * time.Second,
+	}
+}
+
+// Client talks to one node
+type Client struct {
+	baseURL string
+	options Options
+	http    *http.Client
+
+	mu      sync.Mutex
+	session string // token of the last wallet unlock, see UnlockWallet
+}
+
+// New creates a client of the node at baseURL, e.g. http://localhost:8080.
+// A nil options uses DefaultOptions.
+func New(baseURL string, options *Options) (*Client, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid node URL %q", baseURL)
+	}
+	if options == nil {
+		options = DefaultOptions()
+	}
+	opts := *options
+	defaults := DefaultOptions()
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaults.RetryBackoff
+	}
+	if opts.MaxBackoff < opts.RetryBackoff {
+		opts.MaxBackoff = defaults.MaxBackoff
+	}
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		// Streams stay open, so timeouts are set per request instead
+		httpClient = &http.Client{}
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		options: opts,
+		http:    httpClient,
+	}, nil
+}
+
+// APIError is a failure reported by the node. Code is the node's stable
+// error code, e.g. TX_NOT_FOUND or INSUFFICIENT_BALANCE.
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"error"`
+	Detail     string `json:"detail,omitempty"`
+}
+
+// Error describes the failure
+func (e *APIError) Error() string {
+	msg := e.Message
+	if e.Detail != "" {
+		msg = e.Detail
+	}
+	if e.Code == "" {
+		return fmt.Sprintf("node answered %d: %s", e.StatusCode, msg)
+	}
+	return fmt.Sprintf("node answered %d %s: %s", e.StatusCode, e.Code, msg)
+}
+
+// IsCode reports whether err is an APIError with code
+func IsCode(err error, code string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// IsNotFound reports whether err is a 404 from the node
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// ListOptions selects a page of a chain list. Cursor is the NextCursor of
+// the previous page; it keeps that page's order.
+type ListOptions struct {
+	Limit  int
+	Order  string // "asc" or "desc"; empty uses the endpoint's default
+	Cursor string
+}
+
+// values returns the query parameters of the page
+func (o *ListOptions) values() url.Values {
+	query := url.Values{}
+	if o == nil {
+		return query
+	}
+	if o.Limit > 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Order != "" {
+		query.Set("order", o.Order)
+	}
+	if o.Cursor != "" {
+		query.Set("cursor", o.Cursor)
+	}
+	return query
+}
+
+// get sends a GET request and decodes the response into out
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) (http.Header, error) {
+	return c.do(ctx, http.MethodGet, path, query, nil, out)
+}
+
+// post sends body as JSON and decodes the response into out
+func (c *Client) post(ctx context.Context, path string, body, out interface{}) (http.Header, error) {
+	return c.do(ctx, http.MethodPost, path, nil, body, out)
+}
+
+// do sends a request, retrying it while the failure is retryable and the
+// context allows, and decodes a successful response into out when not nil
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) (http.Header, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	wait := c.options.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		header, retryAfter, err := c.attempt(ctx, method, path, query, payload, out)
+		if err == nil || attempt >= c.options.MaxRetries || !retryable(method, err) {
+			return header, err
+		}
+
+		// The node's Retry-After wins over the backoff
+		delay := wait
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		if wait *= 2; wait > c.options.MaxBackoff {
+			wait = c.options.MaxBackoff
+		}
+	}
+}
+
+// attempt sends a request once. retryAfter is the wait the node asked for.
+func (c *Client) attempt(ctx context.Context, method, path string, query url.Values, payload []byte, out interface{}) (header http.Header, retryAfter time.Duration, err error) {
+	if c.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.options.Timeout)
+		defer cancel()
+	}
+
+	req, err := c.newRequest(ctx, method, path, query, payload)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp.Header, parseRetryAfter(resp.Header), decodeError(resp)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.Header, 0, fmt.Errorf("malformed response from %s: %w", path, err)
+		}
+	}
+	return resp.Header, 0, nil
+}
+
+// newRequest builds a request to the node, authenticated when the client has a key
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, payload []byte) (*http.Request, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.options.APIKey != "" {
+		req.Header.Set(APIKeyHeader, c.options.APIKey)
+	}
+	c.mu.Lock()
+	session := c.session
+	c.mu.Unlock()
+	if session != "" {
+		req.Header.Set("Authorization", "Bearer "+session)
+	}
+	return req, nil
+}
+
+// decodeError reads the node's error response
+func decodeError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if err := json.Unmarshal(data, apiErr); err != nil || (apiErr.Code == "" && apiErr.Message == "") {
+		apiErr.Message = strings.TrimSpace(string(data))
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+	}
+	return apiErr
+}
+
+// retryable reports whether a failed request may be sent again. Reads are
+// retried on transport errors and on overload or gateway failures. Writes
+// are only retried when the node refused them before handling them: rate
+// limited, or unavailable with a Retry-After (maintenance).
+func retryable(method string, err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return method == http.MethodGet
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusServiceUnavailable:
+		return method == http.MethodGet || apiErr.Code == "MAINTENANCE" || apiErr.Code == "RATE_LIMITED"
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return method == http.MethodGet
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header in seconds
+func parseRetryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
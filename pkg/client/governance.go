@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"net/url"
+
+	"confirmix/pkg/consensus"
+)
+
+// ProposalRequest creates a governance proposal
+type ProposalRequest struct {
+	Creator     string            `json:"creator"`
+	Type        string            `json:"type"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Data        map[string]string `json:"data,omitempty"`
+	Signature   string            `json:"signature,omitempty"`
+}
+
+// Proposals returns the governance proposals, optionally only those with status
+func (c *Client) Proposals(ctx context.Context, status string) ([]*consensus.Proposal, error) {
+	var query url.Values
+	if status != "" {
+		query = url.Values{"status": {status}}
+	}
+	var response struct {
+		Proposals []*consensus.Proposal `json:"proposals"`
+	}
+	if _, err := c.get(ctx, "/api/proposals", query, &response); err != nil {
+		return nil, err
+	}
+	return response.Proposals, nil
+}
+
+// Proposal returns one governance proposal
+func (c *Client) Proposal(ctx context.Context, id string) (*consensus.Proposal, error) {
+	var response struct {
+		Proposal *consensus.Proposal `json:"proposal"`
+	}
+	if _, err := c.get(ctx, "/api/proposals/"+url.PathEscape(id), nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Proposal, nil
+}
+
+// ProposalTally returns the weighted vote count of a proposal
+func (c *Client) ProposalTally(ctx context.Context, id string) (*consensus.ProposalTally, error) {
+	var tally consensus.ProposalTally
+	if _, err := c.get(ctx, "/api/proposals/"+url.PathEscape(id)+"/tally", nil, &tally); err != nil {
+		return nil, err
+	}
+	return &tally, nil
+}
+
+// CreateProposal submits a proposal and returns its ID
+func (c *Client) CreateProposal(ctx context.Context, req ProposalRequest) (string, error) {
+	var response struct {
+		ProposalID string `json:"proposalID"`
+	}
+	if _, err := c.post(ctx, "/api/proposals/create", req, &response); err != nil {
+		return "", err
+	}
+	return response.ProposalID, nil
+}
+
+// Vote casts voter's vote on a proposal
+func (c *Client) Vote(ctx context.Context, proposalID, voter string, inFavor bool, signature string) error {
+	req := struct {
+		Voter      string `json:"voter"`
+		ProposalID string `json:"proposalId"`
+		InFavor    bool   `json:"inFavor"`
+		Signature  string `json:"signature,omitempty"`
+	}{voter, proposalID, inFavor, signature}
+	_, err := c.post(ctx, "/api/proposals/vote", req, nil)
+	return err
+}
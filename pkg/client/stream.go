@@ -0,0 +1,126 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// maxStreamBacklog is the most headers the node replays when a stream opens
+const maxStreamBacklog = 100
+
+// FollowHeaders calls handle with the latest headers and then with every new
+// block header as the node adds blocks, in chain order. A dropped stream is
+// reopened with backoff, without repeating headers already handled. It
+// returns when ctx ends, with ctx's error, or with the first error of handle.
+func (c *Client) FollowHeaders(ctx context.Context, latest int, handle func(blockchain.BlockHeader) error) error {
+	if latest <= 0 || latest > maxStreamBacklog {
+		latest = maxStreamBacklog
+	}
+	var next uint64 // index of the next header to hand over
+	started := false
+	wait := c.options.RetryBackoff
+
+	for {
+		handled, err := c.streamHeaders(ctx, latest, func(header blockchain.BlockHeader) error {
+			if started && header.Index < next {
+				return nil
+			}
+			if err := handle(header); err != nil {
+				return &handlerError{err}
+			}
+			started = true
+			next = header.Index + 1
+			return nil
+		})
+		var handlerErr *handlerError
+		if errors.As(err, &handlerErr) {
+			return handlerErr.err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !retryable(http.MethodGet, err) {
+			return err
+		}
+		if handled > 0 {
+			wait = c.options.RetryBackoff
+		}
+
+		// Catch up on everything missed while disconnected
+		latest = maxStreamBacklog
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > c.options.MaxBackoff {
+			wait = c.options.MaxBackoff
+		}
+	}
+}
+
+// handlerError marks an error returned by the caller's handler
+type handlerError struct {
+	err error
+}
+
+func (e *handlerError) Error() string { return e.err.Error() }
+
+// streamHeaders reads one header stream until it ends, returning the number of
+// headers passed to handle
+func (c *Client) streamHeaders(ctx context.Context, latest int, handle func(blockchain.BlockHeader) error) (int, error) {
+	query := url.Values{"latest": {strconv.Itoa(latest)}}
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/headers/stream", query, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, decodeError(resp)
+	}
+
+	handled := 0
+	scanner := bufio.NewScanner(resp.Body)
+	var event, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if event == "header" {
+				var header blockchain.BlockHeader
+				if err := json.Unmarshal([]byte(data), &header); err != nil {
+					return handled, fmt.Errorf("malformed header from node: %w", err)
+				}
+				if err := handle(header); err != nil {
+					return handled, err
+				}
+				handled++
+			}
+			event, data = "", ""
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return handled, err
+	}
+	return handled, errors.New("node closed the stream")
+}
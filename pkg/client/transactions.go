@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/units"
+)
+
+// TransactionRequest submits a transaction to the node. Without a signature
+// the node signs it with the sender's custodied key, which must have been
+// unlocked (see UnlockWallet). A transaction signed by the client, see
+// SignedTransactionRequest, is verified against the sender's key.
+type TransactionRequest struct {
+	From  string       `json:"from"`
+	To    string       `json:"to"`
+	Value units.Uint64 `json:"value"` // base units, sent as a string
+	Data  string       `json:"data,omitempty"`
+	// Category and Memo are stored as a blockchain.TxMemo; Data may then only
+	// reference a payment request
+	Category string `json:"category,omitempty"`
+	Memo     string `json:"memo,omitempty"`
+	OTP      string `json:"otp,omitempty"` // one-time code for wallets with TOTP enabled
+
+	ID        string `json:"id,omitempty"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+	Signature []byte `json:"signature,omitempty"`
+	ChainID   string `json:"chainId,omitempty"`
+	SigScheme int    `json:"sigScheme,omitempty"`
+}
+
+// SignedTransactionRequest submits a regular transaction the client signed,
+// e.g. with tx.SignWith. Its data is sent as it is, so it must not carry a
+// category or memo the node would encode again.
+func SignedTransactionRequest(tx *blockchain.Transaction) TransactionRequest {
+	return TransactionRequest{
+		From:      tx.From,
+		To:        tx.To,
+		Value:     units.Uint64(tx.Value),
+		Data:      string(tx.Data),
+		ID:        tx.ID,
+		Timestamp: tx.Timestamp,
+		Signature: tx.Signature,
+		ChainID:   tx.ChainID,
+		SigScheme: tx.SigScheme,
+	}
+}
+
+// TransactionPage is a page of confirmed transactions, in chain order
+type TransactionPage struct {
+	Transactions []*blockchain.Transaction
+	NextCursor   string // empty on the last page
+}
+
+// SubmitTransaction adds a transaction to the node's pool and returns it with its ID
+func (c *Client) SubmitTransaction(ctx context.Context, req TransactionRequest) (*blockchain.Transaction, error) {
+	var tx blockchain.Transaction
+	if _, err := c.post(ctx, "/api/transactions", req, &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// Transaction returns a pending or confirmed transaction by ID
+func (c *Client) Transaction(ctx context.Context, id string) (*blockchain.Transaction, error) {
+	var tx blockchain.Transaction
+	if _, err := c.get(ctx, "/api/transactions/"+url.PathEscape(id), nil, &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// TransactionStatuses returns the status of up to 100 transactions, in the order of ids
+func (c *Client) TransactionStatuses(ctx context.Context, ids []string) ([]blockchain.TxStatus, error) {
+	var response struct {
+		Statuses []blockchain.TxStatus `json:"statuses"`
+	}
+	if _, err := c.post(ctx, "/api/transactions/status", map[string][]string{"ids": ids}, &response); err != nil {
+		return nil, err
+	}
+	return response.Statuses, nil
+}
+
+// PendingTransactions returns up to limit transactions waiting in the pool;
+// limit 0 uses the node's default
+func (c *Client) PendingTransactions(ctx context.Context, limit int) ([]*blockchain.Transaction, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var txs []*blockchain.Transaction
+	if _, err := c.get(ctx, "/api/transactions/pending", query, &txs); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// ConfirmedTransactions returns a page of confirmed transactions, newest first
+// unless opts says otherwise
+func (c *Client) ConfirmedTransactions(ctx context.Context, opts *ListOptions) (*TransactionPage, error) {
+	var page TransactionPage
+	header, err := c.get(ctx, "/api/transactions/confirmed", opts.values(), &page.Transactions)
+	if err != nil {
+		return nil, err
+	}
+	page.NextCursor = header.Get(nextCursorHeader)
+	return &page, nil
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// ValidatorStatus is the health view of one validator from /api/validators/status
+type ValidatorStatus struct {
+	Address          string     `json:"address"`
+	Registered       bool       `json:"registered"`
+	Status           string     `json:"status"` // registration status, "unregistered" when unknown
+	InActiveSet      bool       `json:"inActiveSet"`
+	ApprovedBy       string     `json:"approvedBy,omitempty"`
+	JoinedAt         *time.Time `json:"joinedAt,omitempty"`
+	PerformanceScore float64    `json:"performanceScore"`
+	TotalBlocks      uint64     `json:"totalBlocks"`
+	QuotaViolations  uint64     `json:"quotaViolations"`
+	LastActive       *time.Time `json:"lastActive,omitempty"`
+	HumanProof       struct {
+		Verified         bool   `json:"verified"`
+		ExpiresAt        int64  `json:"expiresAt,omitempty"`
+		SecondsRemaining int64  `json:"secondsRemaining,omitempty"`
+		OnChain          bool   `json:"onChain"`
+		Provider         string `json:"provider,omitempty"`
+		RecordedAt       uint64 `json:"recordedAt,omitempty"`
+	} `json:"humanProof"`
+	NextSlot *struct {
+		TurnsAway  int   `json:"turnsAway"`
+		EtaSeconds int64 `json:"etaSeconds"`
+	} `json:"nextSlot,omitempty"`
+	LastBlock *struct {
+		Index     uint64 `json:"index"`
+		Hash      string `json:"hash"`
+		Timestamp int64  `json:"timestamp"`
+		Age       int64  `json:"ageSeconds"`
+	} `json:"lastBlock,omitempty"`
+	ChainHeight uint64 `json:"chainHeight"`
+	IsLocalNode bool   `json:"isLocalNode"`
+}
+
+// Validators returns the validator set
+func (c *Client) Validators(ctx context.Context) ([]blockchain.ValidatorInfo, error) {
+	var validators []blockchain.ValidatorInfo
+	if _, err := c.get(ctx, "/api/validators", nil, &validators); err != nil {
+		return nil, err
+	}
+	return validators, nil
+}
+
+// ValidatorStatus returns the registration, proof and production status of a validator
+func (c *Client) ValidatorStatus(ctx context.Context, address string) (*ValidatorStatus, error) {
+	var status ValidatorStatus
+	if _, err := c.get(ctx, "/api/validators/status/"+url.PathEscape(address), nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// BlockProduction returns the blocks each validator produced in an epoch
+// against its block quota
+func (c *Client) BlockProduction(ctx context.Context, epoch uint64) (*blockchain.BlockProduction, error) {
+	var production blockchain.BlockProduction
+	if _, err := c.get(ctx, "/api/validators/production/"+strconv.FormatUint(epoch, 10), nil, &production); err != nil {
+		return nil, err
+	}
+	return &production, nil
+}
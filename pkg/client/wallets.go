@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"time"
+
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/units"
+)
+
+// Wallet is a key pair created by the node. PrivateKey is only returned once.
+type Wallet struct {
+	Address    string       `json:"address"`
+	PublicKey  string       `json:"publicKey"`
+	PrivateKey string       `json:"privateKey"`
+	KeyType    string       `json:"keyType"`
+	Balance    units.Uint64 `json:"balance"`
+}
+
+// TransferRequest moves value from a custodied wallet the node unlocked
+type TransferRequest struct {
+	From      string       `json:"from"`
+	To        string       `json:"to,omitempty"`
+	ToContact string       `json:"toContact,omitempty"` // name of the recipient in From's address book instead of To
+	Value     units.Uint64 `json:"value"`               // base units, sent as a string
+	OTP       string       `json:"otp,omitempty"`       // one-time code for wallets with TOTP enabled
+	Category  string       `json:"category,omitempty"`  // bookkeeping tag stored in the transaction memo
+	Memo      string       `json:"memo,omitempty"`
+}
+
+// CreateWallet has the node create a key pair; keyType may be empty for the default
+func (c *Client) CreateWallet(ctx context.Context, keyType string) (*Wallet, error) {
+	path := "/api/wallet/create"
+	if keyType != "" {
+		path += "?keyType=" + url.QueryEscape(keyType)
+	}
+	var wallet Wallet
+	if _, err := c.post(ctx, path, nil, &wallet); err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// Balance returns the confirmed balance of address
+func (c *Client) Balance(ctx context.Context, address string) (*big.Int, error) {
+	return c.balance(ctx, address, nil)
+}
+
+// BalanceAt returns the balance of address after the block at height
+func (c *Client) BalanceAt(ctx context.Context, address string, height uint64) (*big.Int, error) {
+	return c.balance(ctx, address, url.Values{"height": {strconv.FormatUint(height, 10)}})
+}
+
+// balance reads a balance, which the node sends as a decimal string
+func (c *Client) balance(ctx context.Context, address string, query url.Values) (*big.Int, error) {
+	var response struct {
+		Balance string `json:"balance"`
+	}
+	if _, err := c.get(ctx, "/api/wallet/balance/"+url.PathEscape(address), query, &response); err != nil {
+		return nil, err
+	}
+	balance, ok := new(big.Int).SetString(response.Balance, 10)
+	if !ok {
+		return nil, fmt.Errorf("malformed balance %q", response.Balance)
+	}
+	return balance, nil
+}
+
+// Transfer sends value from a wallet unlocked on the node and returns the
+// pending transaction
+func (c *Client) Transfer(ctx context.Context, req TransferRequest) (*blockchain.Transaction, error) {
+	var tx blockchain.Transaction
+	if _, err := c.post(ctx, "/api/wallet/transfer", req, &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// UnlockWallet lets the node sign with a custodied wallet for duration (0 for
+// the node's default) and returns when the unlock ends. The client keeps the
+// session token of the unlock and sends it with every later request, so it
+// acts for the wallet it unlocked last.
+func (c *Client) UnlockWallet(ctx context.Context, address, passphrase string, duration time.Duration) (time.Time, error) {
+	req := struct {
+		Address    string `json:"address"`
+		Passphrase string `json:"passphrase"`
+		Duration   int64  `json:"duration,omitempty"`
+	}{address, passphrase, int64(duration / time.Second)}
+	var response struct {
+		UnlockedUntil int64  `json:"unlockedUntil"`
+		SessionToken  string `json:"sessionToken"`
+	}
+	if _, err := c.post(ctx, "/api/wallet/unlock", req, &response); err != nil {
+		return time.Time{}, err
+	}
+	c.mu.Lock()
+	c.session = response.SessionToken
+	c.mu.Unlock()
+	return time.Unix(response.UnlockedUntil, 0), nil
+}
+
+// LockWallet ends the unlock of a custodied wallet early
+func (c *Client) LockWallet(ctx context.Context, address string) error {
+	_, err := c.post(ctx, "/api/wallet/lock", map[string]string{"address": address}, nil)
+	if err == nil {
+		c.mu.Lock()
+		c.session = ""
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// SpendByCategory returns the outgoing transfers of address per month and
+// category tag, for blocks between from and to (zero times leave the range open)
+func (c *Client) SpendByCategory(ctx context.Context, address string, from, to time.Time) (*blockchain.SpendReport, error) {
+	query := url.Values{}
+	if !from.IsZero() {
+		query.Set("from", strconv.FormatInt(from.Unix(), 10))
+	}
+	if !to.IsZero() {
+		query.Set("to", strconv.FormatInt(to.Unix(), 10))
+	}
+	var report blockchain.SpendReport
+	if _, err := c.get(ctx, "/api/address/"+url.PathEscape(address)+"/spending", query, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// BalanceProof returns every balance-affecting event of address between from
+// and to (zero times leave the range open), signed by the node. The signature
+// is checked before the proof is returned; compare PublicKey with the key of
+// the node you expect.
+func (c *Client) BalanceProof(ctx context.Context, address string, from, to time.Time) (*blockchain.BalanceProof, error) {
+	query := url.Values{}
+	if !from.IsZero() {
+		query.Set("from", strconv.FormatInt(from.Unix(), 10))
+	}
+	if !to.IsZero() {
+		query.Set("to", strconv.FormatInt(to.Unix(), 10))
+	}
+	var proof blockchain.BalanceProof
+	if _, err := c.get(ctx, "/api/address/"+url.PathEscape(address)+"/balance-proof", query, &proof); err != nil {
+		return nil, err
+	}
+	if err := proof.Verify(); err != nil {
+		return nil, fmt.Errorf("balance proof signature: %w", err)
+	}
+	return &proof, nil
+}
@@ -0,0 +1,302 @@
+package consensus
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// BFTConfig configures the validator voting layer
+type BFTConfig struct {
+	PollInterval time.Duration // time between checks for a new chain tip to vote on
+	KeepHeights  uint64        // voting rounds kept below the tip, older votes are dropped
+}
+
+// DefaultBFTConfig returns the default voting settings
+func DefaultBFTConfig() *BFTConfig {
+	return &BFTConfig{
+		PollInterval: 500 * time.Millisecond,
+		KeepHeights:  16,
+	}
+}
+
+// BFTRound is the state of the vote on one height
+type BFTRound struct {
+	Height    uint64              `json:"height"`
+	Tally     map[string]BFTTally `json:"tally"` // block hash -> votes
+	Finalized string              `json:"finalized,omitempty"`
+}
+
+// BFTTally counts the votes for one block of a round
+type BFTTally struct {
+	Prevotes   int `json:"prevotes"`
+	Precommits int `json:"precommits"`
+}
+
+// voteRound collects the votes on one height. Each validator's first vote of
+// each type counts; a second vote for another block is ignored.
+type voteRound struct {
+	prevotes     map[string]*blockchain.ConsensusVote // validator -> vote
+	precommits   map[string]*blockchain.ConsensusVote
+	prevoted     bool
+	precommitted bool
+	finalized    string // hash of the block that gained a commit quorum
+}
+
+// BFT is an optional voting layer on top of the proposer schedule. For every
+// block, validators broadcast a pre-vote once they have the block, and a
+// pre-commit once they see pre-votes of more than two thirds of the
+// validators for it. Pre-commits of more than two thirds make the block final
+// at once; the certificate they form is recorded in the next block.
+// Nodes that are not validators only collect votes.
+type BFT struct {
+	blockchain *blockchain.Blockchain
+	address    string
+	key        blockchain.PrivateKey // signs this node's votes, nil for non-validators
+	broadcast  func(vote *blockchain.ConsensusVote) error
+	config     *BFTConfig
+
+	mu      sync.Mutex
+	rounds  map[uint64]*voteRound
+	stop    chan struct{}
+	running bool
+}
+
+// NewBFT creates the voting layer of a node. key signs the votes of address
+// and may be nil on nodes that do not validate; broadcast sends a vote to the
+// peers.
+func NewBFT(bc *blockchain.Blockchain, address string, key blockchain.PrivateKey, broadcast func(*blockchain.ConsensusVote) error, config *BFTConfig) *BFT {
+	if config == nil {
+		config = DefaultBFTConfig()
+	}
+	return &BFT{
+		blockchain: bc,
+		address:    address,
+		key:        key,
+		broadcast:  broadcast,
+		config:     config,
+		rounds:     make(map[uint64]*voteRound),
+	}
+}
+
+// Start begins voting on new blocks
+func (b *BFT) Start() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.running {
+		return
+	}
+	b.running = true
+	b.stop = make(chan struct{})
+	go b.voteLoop(b.stop)
+}
+
+// Stop ends voting
+func (b *BFT) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.running {
+		close(b.stop)
+		b.running = false
+	}
+}
+
+// voteLoop pre-votes for each new chain tip
+func (b *BFT) voteLoop(stop chan struct{}) {
+	ticker := time.NewTicker(b.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			tip := b.blockchain.GetLatestBlock()
+			if tip == nil || tip.Index == 0 {
+				continue
+			}
+			if err := b.prevote(tip); err != nil {
+				log.Printf("Failed to pre-vote for block %d: %v", tip.Index, err)
+			}
+		}
+	}
+}
+
+// canVote reports whether this node votes: it has a key and is in the validator set
+func (b *BFT) canVote() bool {
+	return b.key != nil && b.blockchain.IsValidator(b.address)
+}
+
+// roundLocked returns the round of height, creating it. The caller must hold b.mu.
+func (b *BFT) roundLocked(height uint64) *voteRound {
+	round, exists := b.rounds[height]
+	if !exists {
+		round = &voteRound{
+			prevotes:   make(map[string]*blockchain.ConsensusVote),
+			precommits: make(map[string]*blockchain.ConsensusVote),
+		}
+		b.rounds[height] = round
+	}
+	return round
+}
+
+// pruneLocked drops the rounds more than KeepHeights below height. The caller must hold b.mu.
+func (b *BFT) pruneLocked(height uint64) {
+	for h := range b.rounds {
+		if h+b.config.KeepHeights < height {
+			delete(b.rounds, h)
+		}
+	}
+}
+
+// prevote casts this node's pre-vote for block, once per height
+func (b *BFT) prevote(block *blockchain.Block) error {
+	if !b.canVote() {
+		return nil
+	}
+	b.mu.Lock()
+	b.pruneLocked(block.Index)
+	round := b.roundLocked(block.Index)
+	if round.prevoted {
+		b.mu.Unlock()
+		return nil
+	}
+	round.prevoted = true
+	b.mu.Unlock()
+	return b.cast(blockchain.VotePrevote, block.Index, block.Hash)
+}
+
+// cast signs a vote, counts it and sends it to the peers
+func (b *BFT) cast(voteType string, height uint64, blockHash string) error {
+	vote, err := blockchain.NewConsensusVote(voteType, height, blockHash, b.address, b.key)
+	if err != nil {
+		return err
+	}
+	if err := b.HandleVote(vote); err != nil {
+		return err
+	}
+	if b.broadcast == nil {
+		return nil
+	}
+	return b.broadcast(vote)
+}
+
+// HandleVote counts a vote received from a peer or cast by this node. A
+// pre-vote quorum for a block this node has makes it pre-commit; a
+// pre-commit quorum finalizes the block.
+func (b *BFT) HandleVote(vote *blockchain.ConsensusVote) error {
+	if err := b.blockchain.VerifyVote(vote); err != nil {
+		return err
+	}
+	tip := b.blockchain.GetChainHeight()
+	if vote.Height+b.config.KeepHeights < tip || vote.Height > tip+1 {
+		return fmt.Errorf("%w: height %d is outside the voting window of tip %d", blockchain.ErrInvalidVote, vote.Height, tip)
+	}
+	quorum := blockchain.VoteQuorum(len(b.blockchain.ValidatorSet()))
+
+	b.mu.Lock()
+	round := b.roundLocked(vote.Height)
+	votes := round.prevotes
+	if vote.Type == blockchain.VotePrecommit {
+		votes = round.precommits
+	}
+	if _, voted := votes[vote.Validator]; voted {
+		b.mu.Unlock()
+		return nil
+	}
+	votes[vote.Validator] = vote
+
+	var prevoteQuorum string
+	var cert *blockchain.CommitCertificate
+	switch vote.Type {
+	case blockchain.VotePrevote:
+		if !round.precommitted && countVotes(round.prevotes, vote.BlockHash) >= quorum {
+			prevoteQuorum = vote.BlockHash
+		}
+	case blockchain.VotePrecommit:
+		if round.finalized == "" && countVotes(round.precommits, vote.BlockHash) >= quorum {
+			round.finalized = vote.BlockHash
+			cert = &blockchain.CommitCertificate{Height: vote.Height, BlockHash: vote.BlockHash, Precommits: make(map[string]string)}
+			for validator, precommit := range round.precommits {
+				if precommit.BlockHash == vote.BlockHash {
+					cert.Precommits[validator] = precommit.Signature
+				}
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	if cert != nil {
+		if err := b.blockchain.RecordCommitCertificate(cert); err != nil {
+			// e.g. the block has not arrived yet; the next pre-commit tries again
+			b.mu.Lock()
+			round.finalized = ""
+			b.mu.Unlock()
+			return err
+		}
+		log.Printf("Block %d (%s) is final with %d pre-commits", cert.Height, cert.BlockHash, len(cert.Precommits))
+	}
+	if prevoteQuorum != "" {
+		return b.precommit(vote.Height, prevoteQuorum)
+	}
+	return nil
+}
+
+// precommit casts this node's pre-commit for the block with hash at height,
+// once per height and only if this node has the block. Otherwise the quorum
+// is checked again when this node pre-votes for the block after receiving it.
+func (b *BFT) precommit(height uint64, blockHash string) error {
+	if !b.canVote() {
+		return nil
+	}
+	block, err := b.blockchain.GetBlockByIndex(height)
+	if err != nil || block.Hash != blockHash {
+		return nil
+	}
+	b.mu.Lock()
+	round := b.roundLocked(height)
+	if round.precommitted {
+		b.mu.Unlock()
+		return nil
+	}
+	round.precommitted = true
+	b.mu.Unlock()
+	return b.cast(blockchain.VotePrecommit, height, blockHash)
+}
+
+// countVotes returns the votes for blockHash
+func countVotes(votes map[string]*blockchain.ConsensusVote, blockHash string) int {
+	count := 0
+	for _, vote := range votes {
+		if vote.BlockHash == blockHash {
+			count++
+		}
+	}
+	return count
+}
+
+// Rounds returns the vote tallies of the rounds in progress
+func (b *BFT) Rounds() []BFTRound {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	rounds := make([]BFTRound, 0, len(b.rounds))
+	for height, round := range b.rounds {
+		tally := make(map[string]BFTTally)
+		for _, vote := range round.prevotes {
+			t := tally[vote.BlockHash]
+			t.Prevotes++
+			tally[vote.BlockHash] = t
+		}
+		for _, vote := range round.precommits {
+			t := tally[vote.BlockHash]
+			t.Precommits++
+			tally[vote.BlockHash] = t
+		}
+		rounds = append(rounds, BFTRound{Height: height, Tally: tally, Finalized: round.finalized})
+	}
+	sort.Slice(rounds, func(i, j int) bool { return rounds[i].Height < rounds[j].Height })
+	return rounds
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"math/big"
+	"strconv"
 	"sync"
 	"time"
 	
@@ -12,6 +13,9 @@ import (
 	"github.com/google/uuid"
 )
 
+// ErrProposalNotFound is returned for an unknown proposal ID
+var ErrProposalNotFound = errors.New("proposal not found")
+
 // ProposalStatus represents the status of a governance proposal
 type ProposalStatus string
 
@@ -33,6 +37,7 @@ const (
 	ProposalTypeChangeParameter ProposalType = "change_parameter" // Change system parameter
 	ProposalTypeUpgradeSoftware ProposalType = "upgrade_software" // Protocol upgrade
 	ProposalTypeTransferFunds   ProposalType = "transfer_funds"   // Transfer from treasury
+	ProposalTypeActivateFeature ProposalType = "activate_feature" // Activate a consensus rule at a height
 )
 
 // Vote represents a vote on a proposal
@@ -59,6 +64,8 @@ type Proposal struct {
 	NoVotes     *big.Int          // Total voting power against
 	ExecutedAt  time.Time         // When it was executed (if applicable)
 	Result      string            // Result message after execution
+	FastTrack   bool              // Created in emergency mode: short vote, no execution delay
+	MultiSigTxID string           // Multisig transaction paying out a transfer from a multisig wallet
 }
 
 // GovernanceConfig represents governance system configuration
@@ -68,6 +75,7 @@ type GovernanceConfig struct {
 	QuorumPercentage uint64        // Required participation (0-100)
 	ApprovalThreshold uint64       // Required approval percentage (0-100)
 	MinProposalDeposit *big.Int    // Minimum tokens required to create proposal
+	EmergencyVotingPeriod time.Duration // Voting period of add_validator proposals in emergency mode
 }
 
 // Governance represents the governance/DAO system
@@ -114,6 +122,7 @@ func DefaultGovernanceConfig() GovernanceConfig {
 		QuorumPercentage:  33,                  // 33% participation required
 		ApprovalThreshold: 60,                  // 60% yes votes required
 		MinProposalDeposit: minDeposit,
+		EmergencyVotingPeriod: time.Hour,
 	}
 }
 
@@ -124,6 +133,33 @@ func (g *Governance) CreateProposal(creator string, proposalType ProposalType, t
 		return "", errors.New("governance is not yet enabled for non-validators")
 	}
 	
+	// Reject malformed upgrade proposals before any deposit is locked
+	if proposalType == ProposalTypeUpgradeSoftware {
+		if _, err := parseUpgradeProposal(data); err != nil {
+			return "", err
+		}
+	}
+	if proposalType == ProposalTypeActivateFeature {
+		if _, _, err := parseActivationProposal(data); err != nil {
+			return "", err
+		}
+	}
+	if proposalType == ProposalTypeTransferFunds {
+		if _, _, err := parseTransferProposal(data); err != nil {
+			return "", err
+		}
+		if walletAddress := data["wallet"]; walletAddress != "" {
+			if _, err := g.blockchain.GetMultiSigWallet(walletAddress); err != nil {
+				return "", fmt.Errorf("%w: %s", err, walletAddress)
+			}
+		}
+	}
+	if proposalType == ProposalTypeChangeParameter {
+		if _, _, err := parseParameterProposal(data); err != nil {
+			return "", err
+		}
+	}
+	
 	// Check minimum deposit requirement
 	balance, err := g.tokenSystem.GetBalance(creator)
 	if err != nil {
@@ -162,6 +198,13 @@ func (g *Governance) CreateProposal(creator string, proposalType ProposalType, t
 		NoVotes:     big.NewInt(0),
 	}
 	
+	// Restoring the validator set cannot wait a full voting period
+	if proposalType == ProposalTypeAddValidator && g.validatorManager.InEmergency() && g.config.EmergencyVotingPeriod > 0 {
+		proposal.FastTrack = true
+		proposal.ExpiresAt = proposal.CreatedAt.Add(g.config.EmergencyVotingPeriod)
+		log.Printf("Emergency mode: proposal %s fast-tracked, voting closes at %s", proposalID, proposal.ExpiresAt.Format(time.RFC3339))
+	}
+	
 	g.proposals[proposalID] = proposal
 	
 	log.Printf("Proposal created: %s - %s (by %s)", proposalID, title, creator)
@@ -176,7 +219,7 @@ func (g *Governance) CastVote(proposalID string, voter string, inFavor bool) err
 	// Find the proposal
 	proposal, exists := g.proposals[proposalID]
 	if !exists {
-		return errors.New("proposal not found")
+		return ErrProposalNotFound
 	}
 	
 	// Check if proposal is still pending
@@ -240,42 +283,35 @@ func (g *Governance) checkAndFinalizeProposal(proposal *Proposal) {
 		return
 	}
 	
-	// Calculate total votes
-	totalVotes := new(big.Int).Add(proposal.YesVotes, proposal.NoVotes)
-	
-	// Get total token supply for quorum calculation
-	totalSupply, err := g.getTotalTokenSupply()
+	// Measure the votes against the quorum and approval thresholds
+	tally, err := g.tallyLocked(proposal, time.Now())
 	if err != nil {
-		log.Printf("Error getting total token supply: %v", err)
+		log.Printf("Error tallying proposal %s: %v", proposal.ID, err)
 		return
 	}
 	
-	// Calculate quorum percentage
-	quorumRatio := new(big.Int).Mul(totalVotes, big.NewInt(100))
-	quorumRatio.Div(quorumRatio, totalSupply)
-	
 	// Check if quorum is reached
-	if quorumRatio.Uint64() < g.config.QuorumPercentage {
+	if !tally.QuorumReached {
 		// Not enough participation yet
 		return
 	}
 	
-	// Calculate approval percentage
-	approvalRatio := new(big.Int).Mul(proposal.YesVotes, big.NewInt(100))
-	approvalRatio.Div(approvalRatio, totalVotes)
-	
 	// Determine result
-	if approvalRatio.Uint64() >= g.config.ApprovalThreshold {
+	if tally.ProjectedOutcome == OutcomeApproved {
 		proposal.Status = ProposalStatusApproved
 		log.Printf("Proposal %s approved (%d%% in favor, %d%% participation)", 
-			proposal.ID, approvalRatio.Uint64(), quorumRatio.Uint64())
+			proposal.ID, tally.ApprovalPercent, tally.ParticipationPercent)
 		
-		// Schedule execution after delay
-		go g.scheduleProposalExecution(proposal.ID, g.config.ExecutionDelay)
+		// Schedule execution after delay; fast-tracked proposals execute at once
+		delay := g.config.ExecutionDelay
+		if proposal.FastTrack {
+			delay = 0
+		}
+		go g.scheduleProposalExecution(proposal.ID, delay)
 	} else {
 		proposal.Status = ProposalStatusRejected
 		log.Printf("Proposal %s rejected (%d%% in favor, %d%% participation)", 
-			proposal.ID, approvalRatio.Uint64(), quorumRatio.Uint64())
+			proposal.ID, tally.ApprovalPercent, tally.ParticipationPercent)
 		
 		// Return deposit to creator
 		go g.returnProposalDeposit(proposal.Creator)
@@ -295,6 +331,9 @@ func (g *Governance) scheduleProposalExecution(proposalID string, delay time.Dur
 	}
 	
 	err := g.executeProposal(proposal)
+	if proposal.Type == ProposalTypeAddValidator || proposal.Type == ProposalTypeRemoveValidator {
+		g.validatorManager.CheckEmergency()
+	}
 	
 	g.mutex.Lock()
 	defer g.mutex.Unlock()
@@ -311,6 +350,14 @@ func (g *Governance) scheduleProposalExecution(proposalID string, delay time.Dur
 	} else {
 		proposal.Status = ProposalStatusExecuted
 		proposal.Result = "Execution successful"
+		if proposal.MultiSigTxID != "" {
+			proposal.Result = fmt.Sprintf("Multisig transaction %s created, awaiting owner signatures", proposal.MultiSigTxID)
+		}
+		g.blockchain.RecordEvent(blockchain.EventGovernanceExecuted, proposal.ID, map[string]string{
+			"type":   string(proposal.Type),
+			"title":  proposal.Title,
+			"result": proposal.Result,
+		})
 		log.Printf("Proposal %s executed successfully", proposalID)
 		
 		// Return deposit to creator on success
@@ -345,30 +392,52 @@ func (g *Governance) executeProposal(proposal *Proposal) error {
 		return g.blockchain.RemoveValidator(address)
 		
 	case ProposalTypeChangeParameter:
-		// Change parameter proposal
-		// Implementation depends on what parameters are configurable
-		return errors.New("parameter change proposals not yet implemented")
+		// Change parameter proposal; only the commission bounds are configurable so far
+		name, value, err := parseParameterProposal(proposal.Data)
+		if err != nil {
+			return err
+		}
+		return g.validatorManager.setCommissionParameter(name, value)
 		
 	case ProposalTypeUpgradeSoftware:
-		// Software upgrade proposal
-		// This would typically involve a coordinated upgrade process
-		return errors.New("software upgrade proposals not yet implemented")
+		// Software upgrade proposal: nodes older than the target version stop
+		// validating at the activation height
+		plan, err := parseUpgradeProposal(proposal.Data)
+		if err != nil {
+			return err
+		}
+		plan.ProposalID = proposal.ID
+		plan.ScheduledAt = time.Now().Unix()
+		return g.blockchain.ScheduleUpgrade(plan)
 		
-	case ProposalTypeTransferFunds:
-		// Treasury transfer proposal
-		to, exists := proposal.Data["to"]
-		if !exists {
-			return errors.New("recipient address missing from proposal data")
+	case ProposalTypeActivateFeature:
+		// Feature activation proposal: the rule applies from the activation height on
+		feature, height, err := parseActivationProposal(proposal.Data)
+		if err != nil {
+			return err
 		}
+		return g.blockchain.ScheduleActivation(feature, height, proposal.ID)
 		
-		amountStr, exists := proposal.Data["amount"]
-		if !exists {
-			return errors.New("amount missing from proposal data")
+	case ProposalTypeTransferFunds:
+		// Treasury transfer proposal
+		to, amount, err := parseTransferProposal(proposal.Data)
+		if err != nil {
+			return err
 		}
 		
-		amount := new(big.Int)
-		if _, success := amount.SetString(amountStr, 10); !success {
-			return errors.New("invalid amount format")
+		// Spending from a multisig wallet, e.g. the genesis multisig, still
+		// needs its owners: the proposal creates the wallet transaction and
+		// the owners sign and execute it through the multisig API
+		if walletAddress := proposal.Data["wallet"]; walletAddress != "" {
+			tx, err := g.blockchain.CreateMultiSigProposalTransaction(walletAddress, proposal.ID, to, amount)
+			if err != nil {
+				return fmt.Errorf("failed to create multisig transaction: %w", err)
+			}
+			g.mutex.Lock()
+			proposal.MultiSigTxID = tx.ID
+			g.mutex.Unlock()
+			log.Printf("Proposal %s created multisig transaction %s on wallet %s", proposal.ID, tx.ID, walletAddress)
+			return nil
 		}
 		
 		treasuryAddress := "confirmix_treasury" // Replace with actual treasury address
@@ -379,6 +448,101 @@ func (g *Governance) executeProposal(proposal *Proposal) error {
 	}
 }
 
+// parseUpgradeProposal reads the upgrade plan of a software upgrade proposal.
+// Data: {"name": "...", "version": "1.2.0", "height": "<activation height>"}
+func parseUpgradeProposal(data map[string]string) (blockchain.UpgradePlan, error) {
+	plan := blockchain.UpgradePlan{Name: data["name"], Version: data["version"]}
+	if plan.Name == "" {
+		return plan, fmt.Errorf("%w: name missing from proposal data", blockchain.ErrInvalidUpgrade)
+	}
+	if err := blockchain.ParseVersion(plan.Version); err != nil {
+		return plan, err
+	}
+	height, err := strconv.ParseUint(data["height"], 10, 64)
+	if err != nil {
+		return plan, fmt.Errorf("%w: activation height missing or invalid in proposal data", blockchain.ErrInvalidUpgrade)
+	}
+	plan.Height = height
+	return plan, nil
+}
+
+// GovernanceParameter returns a governance parameter as a string, for the contract host API
+func (g *Governance) GovernanceParameter(name string) (string, error) {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	
+	switch name {
+	case "voting_period":
+		return strconv.FormatInt(int64(g.config.VotingPeriod.Seconds()), 10), nil
+	case "execution_delay":
+		return strconv.FormatInt(int64(g.config.ExecutionDelay.Seconds()), 10), nil
+	case "quorum_percentage":
+		return strconv.FormatUint(g.config.QuorumPercentage, 10), nil
+	case "approval_threshold":
+		return strconv.FormatUint(g.config.ApprovalThreshold, 10), nil
+	case "min_proposal_deposit":
+		return g.config.MinProposalDeposit.String(), nil
+	case "enabled":
+		return strconv.FormatBool(g.defaultGovernance), nil
+	case ParamMaxCommissionRate:
+		return strconv.FormatUint(g.validatorManager.CommissionConfig().MaxRate, 10), nil
+	case ParamCommissionNoticeEpochs:
+		return strconv.FormatUint(g.validatorManager.CommissionConfig().NoticeEpochs, 10), nil
+	default:
+		return "", fmt.Errorf("unknown governance parameter: %s", name)
+	}
+}
+
+// parseActivationProposal reads the feature and height of a feature activation proposal.
+// Data: {"feature": "...", "height": "<activation height>"}
+func parseActivationProposal(data map[string]string) (blockchain.Feature, uint64, error) {
+	feature := blockchain.Feature(data["feature"])
+	if feature == "" {
+		return feature, 0, fmt.Errorf("%w: feature missing from proposal data", blockchain.ErrInvalidActivation)
+	}
+	if !blockchain.FeatureKnown(feature) {
+		return feature, 0, fmt.Errorf("%w: %s", blockchain.ErrUnknownFeature, feature)
+	}
+	height, err := strconv.ParseUint(data["height"], 10, 64)
+	if err != nil {
+		return feature, 0, fmt.Errorf("%w: activation height missing or invalid in proposal data", blockchain.ErrInvalidActivation)
+	}
+	return feature, height, nil
+}
+
+// parseTransferProposal reads the recipient and amount of a transfer proposal.
+// Data: {"to": "...", "amount": "<base units>", "wallet": "<optional multisig wallet>"}
+func parseTransferProposal(data map[string]string) (string, *big.Int, error) {
+	to := data["to"]
+	if to == "" {
+		return "", nil, errors.New("recipient address missing from proposal data")
+	}
+	amount, ok := new(big.Int).SetString(data["amount"], 10)
+	if !ok || amount.Sign() <= 0 {
+		return "", nil, errors.New("amount missing or invalid in proposal data")
+	}
+	return to, amount, nil
+}
+
+// parseParameterProposal reads the parameter and value of a parameter change proposal.
+// Data: {"parameter": "max_commission_rate", "value": "1500"}
+func parseParameterProposal(data map[string]string) (string, string, error) {
+	name, value := data["parameter"], data["value"]
+	switch name {
+	case ParamMaxCommissionRate, ParamCommissionNoticeEpochs:
+	default:
+		return "", "", fmt.Errorf("unknown or missing parameter in proposal data: %q", name)
+	}
+	number, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid value %q for %s", value, name)
+	}
+	if name == ParamMaxCommissionRate && number > MaxCommissionRate {
+		return "", "", fmt.Errorf("maximum commission %d exceeds %d basis points", number, MaxCommissionRate)
+	}
+	return name, value, nil
+}
+
 // returnProposalDeposit returns the deposit to the proposal creator
 func (g *Governance) returnProposalDeposit(address string) {
 	if err := g.tokenSystem.Unlock(address, g.config.MinProposalDeposit); err != nil {
@@ -395,7 +559,7 @@ func (g *Governance) GetProposal(proposalID string) (*Proposal, error) {
 	
 	proposal, exists := g.proposals[proposalID]
 	if !exists {
-		return nil, errors.New("proposal not found")
+		return nil, ErrProposalNotFound
 	}
 	
 	return proposal, nil
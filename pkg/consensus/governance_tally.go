@@ -0,0 +1,146 @@
+package consensus
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// Projected outcomes of a proposal under the votes cast so far
+const (
+	OutcomeApproved      = "approved"
+	OutcomeRejected      = "rejected"
+	OutcomeQuorumMissing = "quorum_not_reached"
+)
+
+// ProposalTally measures the votes of a proposal against the quorum and
+// approval thresholds. Percentages use the same truncating integer math that
+// finalizes proposals, so a tally showing the quorum reached is exactly the
+// point where the node decides.
+type ProposalTally struct {
+	ProposalID           string         `json:"proposalId"`
+	Status               ProposalStatus `json:"status"`
+	YesPower             string         `json:"yesPower"`
+	NoPower              string         `json:"noPower"`
+	TotalPower           string         `json:"totalPower"`  // voting power cast
+	TotalSupply          string         `json:"totalSupply"` // base of the quorum
+	Voters               int            `json:"voters"`
+	YesVoters            int            `json:"yesVoters"`
+	NoVoters             int            `json:"noVoters"`
+	ParticipationPercent uint64         `json:"participationPercent"`
+	ApprovalPercent      uint64         `json:"approvalPercent"`
+	QuorumPercentage     uint64         `json:"quorumPercentage"`
+	ApprovalThreshold    uint64         `json:"approvalThreshold"`
+	QuorumReached        bool           `json:"quorumReached"`
+	QuorumPowerNeeded    string         `json:"quorumPowerNeeded"` // power still missing for the quorum
+	ExpiresAt            time.Time      `json:"expiresAt"`
+	SecondsRemaining     int64          `json:"secondsRemaining"`
+	VotingOpen           bool           `json:"votingOpen"`
+	ProjectedOutcome     string         `json:"projectedOutcome"`
+}
+
+// tallyLocked computes the tally of a proposal. The caller must hold g.mutex.
+func (g *Governance) tallyLocked(proposal *Proposal, now time.Time) (*ProposalTally, error) {
+	totalSupply, err := g.getTotalTokenSupply()
+	if err != nil {
+		return nil, err
+	}
+	if totalSupply.Sign() <= 0 {
+		return nil, fmt.Errorf("total token supply is %s", totalSupply.String())
+	}
+
+	totalVotes := new(big.Int).Add(proposal.YesVotes, proposal.NoVotes)
+	tally := &ProposalTally{
+		ProposalID:        proposal.ID,
+		Status:            proposal.Status,
+		YesPower:          proposal.YesVotes.String(),
+		NoPower:           proposal.NoVotes.String(),
+		TotalPower:        totalVotes.String(),
+		TotalSupply:       totalSupply.String(),
+		Voters:            len(proposal.Votes),
+		QuorumPercentage:  g.config.QuorumPercentage,
+		ApprovalThreshold: g.config.ApprovalThreshold,
+		ExpiresAt:         proposal.ExpiresAt,
+	}
+	for _, vote := range proposal.Votes {
+		if vote.InFavor {
+			tally.YesVoters++
+		} else {
+			tally.NoVoters++
+		}
+	}
+
+	participation := new(big.Int).Mul(totalVotes, big.NewInt(100))
+	participation.Div(participation, totalSupply)
+	tally.ParticipationPercent = participation.Uint64()
+	tally.QuorumReached = tally.ParticipationPercent >= g.config.QuorumPercentage
+	if totalVotes.Sign() > 0 {
+		approval := new(big.Int).Mul(proposal.YesVotes, big.NewInt(100))
+		approval.Div(approval, totalVotes)
+		tally.ApprovalPercent = approval.Uint64()
+	}
+
+	// The quorum needs ceil(quorum * supply / 100) voting power
+	needed := new(big.Int).Mul(totalSupply, new(big.Int).SetUint64(g.config.QuorumPercentage))
+	needed.Add(needed, big.NewInt(99))
+	needed.Div(needed, big.NewInt(100))
+	needed.Sub(needed, totalVotes)
+	if needed.Sign() < 0 {
+		needed.SetInt64(0)
+	}
+	tally.QuorumPowerNeeded = needed.String()
+
+	if remaining := proposal.ExpiresAt.Sub(now); remaining > 0 && proposal.Status == ProposalStatusPending {
+		tally.VotingOpen = true
+		tally.SecondsRemaining = int64(remaining / time.Second)
+	}
+
+	switch {
+	case !tally.QuorumReached:
+		tally.ProjectedOutcome = OutcomeQuorumMissing
+	case tally.ApprovalPercent >= g.config.ApprovalThreshold:
+		tally.ProjectedOutcome = OutcomeApproved
+	default:
+		tally.ProjectedOutcome = OutcomeRejected
+	}
+	return tally, nil
+}
+
+// Tally returns the vote tally of a proposal
+func (g *Governance) Tally(proposalID string) (*ProposalTally, error) {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	proposal, exists := g.proposals[proposalID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrProposalNotFound, proposalID)
+	}
+	return g.tallyLocked(proposal, time.Now())
+}
+
+// ProposalVotes returns the votes cast on a proposal, largest voting power first
+func (g *Governance) ProposalVotes(proposalID string) ([]Vote, error) {
+	g.mutex.RLock()
+	proposal, exists := g.proposals[proposalID]
+	if !exists {
+		g.mutex.RUnlock()
+		return nil, fmt.Errorf("%w: %s", ErrProposalNotFound, proposalID)
+	}
+	votes := make([]Vote, 0, len(proposal.Votes))
+	for _, vote := range proposal.Votes {
+		votes = append(votes, *vote)
+	}
+	g.mutex.RUnlock()
+
+	sort.Slice(votes, func(i, j int) bool {
+		if c := votes[i].VotingPower.Cmp(votes[j].VotingPower); c != 0 {
+			return c > 0
+		}
+		if !votes[i].VotedAt.Equal(votes[j].VotedAt) {
+			return votes[i].VotedAt.Before(votes[j].VotedAt)
+		}
+		return votes[i].Voter < votes[j].Voter
+	})
+	return votes, nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"confirmix/pkg/blockchain"
+	"confirmix/pkg/signer"
 )
 
 // HybridConsensus combines Proof of Authority and Proof of Humanity
@@ -13,6 +14,7 @@ type HybridConsensus struct {
 	poaConsensus      *PoAConsensus
 	pohVerifier       *ProofOfHumanity
 	externalPohVerifier *ExternalPoHVerifier
+	pohSessions       *PoHSessionManager
 	useExternalPoh    bool
 	blockchain        *blockchain.Blockchain
 	address           string
@@ -27,6 +29,7 @@ type HybridConsensusConfig struct {
 	UsePoHSimulator     bool
 	PoHSimulatorPort    int
 	BlockTime           time.Duration
+	PoHSessions         *PoHSessionConfig // Challenge-response session rules
 }
 
 // DefaultHybridConsensusConfig returns the default configuration
@@ -36,6 +39,7 @@ func DefaultHybridConsensusConfig() *HybridConsensusConfig {
 		UsePoHSimulator:  true,
 		PoHSimulatorPort: 8080,
 		BlockTime:        15 * time.Second,
+		PoHSessions:      DefaultPoHSessionConfig(),
 	}
 }
 
@@ -69,10 +73,25 @@ func NewHybridConsensusWithConfig(bc *blockchain.Blockchain, privateKey *ecdsa.P
 		}
 	}
 	
+	// Interactive challenge-response sessions, backed by the external provider when enabled
+	var provider PoHProvider
+	if externalPohVerifier != nil {
+		provider = externalPohVerifier
+	}
+	pohSessions := NewPoHSessionManager(config.PoHSessions, provider, func(addr string) ([]byte, bool) {
+		keyPair, exists := bc.GetKeyPair(addr)
+		if !exists {
+			return nil, false
+		}
+		return keyPair.PublicKeyBytes, true
+	})
+	pohSessions.OnVerified(pohVerifier.MarkVerified)
+	
 	return &HybridConsensus{
 		poaConsensus:       NewPoAConsensus(bc, privateKey, address, config.BlockTime, ""),
 		pohVerifier:        pohVerifier,
 		externalPohVerifier: externalPohVerifier,
+		pohSessions:        pohSessions,
 		useExternalPoh:     config.UseExternalPoh,
 		blockchain:         bc,
 		address:            address,
@@ -189,6 +208,21 @@ func (hc *HybridConsensus) GetVerificationURL() (string, error) {
 	return hc.externalPohVerifier.GetVerificationURL(hc.address, hc.poaConsensus.humanProof), nil
 }
 
+// PoHSessions returns the challenge-response verification sessions of this node
+func (hc *HybridConsensus) PoHSessions() *PoHSessionManager {
+	return hc.pohSessions
+}
+
+// IsAddressVerified checks if an arbitrary address has passed human verification on this node
+func (hc *HybridConsensus) IsAddressVerified(address string) bool {
+	return hc.pohVerifier.IsHumanVerified(address)
+}
+
+// SetSigner makes the node sign its blocks with s, e.g. a key held by an HSM or KMS
+func (hc *HybridConsensus) SetSigner(s signer.Signer) {
+	hc.poaConsensus.SetSigner(s)
+}
+
 // StartMining starts the block production process
 func (hc *HybridConsensus) StartMining() error {
 	// Check if node is a validator
@@ -213,9 +247,31 @@ func (hc *HybridConsensus) StopMining() {
 	hc.poaConsensus.StopMining()
 }
 
-// UpdateValidatorList updates the list of authorized validators
-func (hc *HybridConsensus) UpdateValidatorList(validators []string) {
-	hc.poaConsensus.UpdateValidatorList(validators)
+// IsMining reports whether this node is currently producing blocks
+func (hc *HybridConsensus) IsMining() bool {
+	return hc.poaConsensus.IsMining()
+}
+
+// ValidatorList returns the authorized validators in proposer order. The
+// list follows the chain's validator set and needs no manual updates.
+func (hc *HybridConsensus) ValidatorList() []string {
+	return hc.poaConsensus.ValidatorList()
+}
+
+// HumanVerification returns the Proof of Humanity verification of an address
+// known to this node
+func (hc *HybridConsensus) HumanVerification(address string) (HumanVerification, bool) {
+	return hc.pohVerifier.Verification(address)
+}
+
+// NextTurn returns the turns and estimated time until address produces a block
+func (hc *HybridConsensus) NextTurn(address string) (int, time.Duration, bool) {
+	return hc.poaConsensus.NextTurn(address)
+}
+
+// SetEmergencyMode switches the degraded block production of emergency mode
+func (hc *HybridConsensus) SetEmergencyMode(enabled bool) {
+	hc.poaConsensus.SetEmergencyMode(enabled)
 }
 
 // VerifyBlock verifies that a block is valid according to the hybrid rules
@@ -254,4 +310,17 @@ func (hc *HybridConsensus) validateHumanProof(validator string, proof string) bo
 // GetNodeAddress returns the address of this node
 func (hc *HybridConsensus) GetNodeAddress() string {
 	return hc.address
+}
+
+// PoHProviderName names the proof of humanity backend: "external" for a
+// provider service, "simulator" for the built-in simulator and "local" when
+// proofs are only checked against the chain
+func (hc *HybridConsensus) PoHProviderName() string {
+	switch {
+	case hc.externalPohVerifier == nil:
+		return "local"
+	case hc.externalPohVerifier.localSimulator != nil:
+		return "simulator"
+	}
+	return "external"
 } 
\ No newline at end of file
@@ -2,19 +2,22 @@ package consensus
 
 import (
 	"crypto/ecdsa"
-	"crypto/rand"
-	"crypto/sha256"
 	"errors"
+	"fmt"
+	"log"
 	"sync"
 	"time"
 
 	"confirmix/pkg/blockchain"
+	"confirmix/pkg/signer"
 )
 
 // PoAConsensus implements a Proof of Authority consensus mechanism
 type PoAConsensus struct {
 	blockchain      *blockchain.Blockchain
 	privateKey      *ecdsa.PrivateKey
+	signer          signer.Signer // signs blocks instead of privateKey when set, e.g. an HSM
+	signerMutex     sync.RWMutex
 	address         string
 	validatorList   []string
 	validatorIndex  int
@@ -25,15 +28,18 @@ type PoAConsensus struct {
 	blockMutex      sync.Mutex
 	stopMining      chan struct{}
 	miningActive    bool
+	emergencyMode   bool // Active validators take over turns of inactive ones
 }
 
-// NewPoAConsensus creates a new Proof of Authority consensus engine
+// NewPoAConsensus creates a new Proof of Authority consensus engine. Its
+// validator list follows the validator set of the chain: it is refreshed
+// whenever a validator is added to or removed from the set.
 func NewPoAConsensus(bc *blockchain.Blockchain, privateKey *ecdsa.PrivateKey, address string, blockTime time.Duration, humanProof string) *PoAConsensus {
-	return &PoAConsensus{
+	poa := &PoAConsensus{
 		blockchain:     bc,
 		privateKey:     privateKey,
 		address:        address,
-		validatorList:  []string{},
+		validatorList:  bc.ValidatorSet(),
 		validatorIndex: 0,
 		blockTime:      blockTime,
 		isValidator:    false,
@@ -41,6 +47,10 @@ func NewPoAConsensus(bc *blockchain.Blockchain, privateKey *ecdsa.PrivateKey, ad
 		stopMining:     make(chan struct{}),
 		miningActive:   false,
 	}
+	bc.OnEvent(func(blockchain.ChainEvent) {
+		poa.refreshValidatorList()
+	}, blockchain.EventValidatorAdded, blockchain.EventValidatorRemoved)
+	return poa
 }
 
 // RegisterAsValidator registers this node as a validator
@@ -58,12 +68,27 @@ func (poa *PoAConsensus) RegisterAsValidator() error {
 	return nil
 }
 
-// UpdateValidatorList updates the list of authorized validators
-func (poa *PoAConsensus) UpdateValidatorList(validators []string) {
+// refreshValidatorList reloads the list of authorized validators from the chain's validator set
+func (poa *PoAConsensus) refreshValidatorList() {
+	validators := poa.blockchain.ValidatorSet()
+	
 	poa.validatorMutex.Lock()
 	defer poa.validatorMutex.Unlock()
 	
 	poa.validatorList = validators
+	if len(validators) > 0 {
+		poa.validatorIndex %= len(validators)
+	} else {
+		poa.validatorIndex = 0
+	}
+	log.Printf("Validator list updated from the chain: %d validators", len(validators))
+}
+
+// ValidatorList returns the authorized validators in proposer order
+func (poa *PoAConsensus) ValidatorList() []string {
+	poa.validatorMutex.Lock()
+	defer poa.validatorMutex.Unlock()
+	return append([]string(nil), poa.validatorList...)
 }
 
 // getCurrentValidator gets the current validator who should create a block
@@ -76,13 +101,86 @@ func (poa *PoAConsensus) getCurrentValidator() string {
 	}
 	
 	// Simple round-robin selection
-	validator := poa.validatorList[poa.validatorIndex]
+	validator := RoundRobinProposer(poa.validatorList, uint64(poa.validatorIndex))
 	poa.validatorIndex = (poa.validatorIndex + 1) % len(poa.validatorList)
 	return validator
 }
 
-// StartMining starts the block production process
+// RoundRobinProposer returns the validator that owns a turn of the
+// round-robin schedule, or "" for an empty validator list
+func RoundRobinProposer(validators []string, turn uint64) string {
+	if len(validators) == 0 {
+		return ""
+	}
+	return validators[turn%uint64(len(validators))]
+}
+
+// NextTurn returns how many turns remain until address produces a block and
+// the estimated time until then. ok is false when address has no slot in the
+// round-robin schedule.
+func (poa *PoAConsensus) NextTurn(address string) (turns int, eta time.Duration, ok bool) {
+	poa.validatorMutex.Lock()
+	defer poa.validatorMutex.Unlock()
+	
+	count := len(poa.validatorList)
+	for i := 0; i < count; i++ {
+		if poa.validatorList[(poa.validatorIndex+i)%count] == address {
+			// The ticker fires once per block time and takes the turn at validatorIndex
+			return i, time.Duration(i+1) * poa.blockTime, true
+		}
+	}
+	return 0, 0, false
+}
+
+// SetEmergencyMode lets this validator produce the blocks of turns owned by
+// validators that left the active set, so a shrunken set keeps the chain moving
+func (poa *PoAConsensus) SetEmergencyMode(enabled bool) {
+	poa.validatorMutex.Lock()
+	defer poa.validatorMutex.Unlock()
+	poa.emergencyMode = enabled
+}
+
+// takeOverTurn reports whether this validator produces the block of a turn
+// owned by another one. Only in emergency mode, and only for turns of
+// validators that are no longer in the active set.
+func (poa *PoAConsensus) takeOverTurn(owner string) bool {
+	poa.validatorMutex.Lock()
+	emergency := poa.emergencyMode
+	poa.validatorMutex.Unlock()
+	if !emergency || (owner != "" && poa.blockchain.IsValidator(owner)) {
+		return false
+	}
+	if !poa.blockchain.IsValidator(poa.address) {
+		log.Printf("Emergency mode: turn of inactive validator %q skipped, this node is not in the active set", owner)
+		return false
+	}
+	log.Printf("Emergency mode: producing the block for the turn of inactive validator %q", owner)
+	return true
+}
+
+// SetSigner makes the consensus sign blocks with s instead of its private key
+func (poa *PoAConsensus) SetSigner(s signer.Signer) {
+	poa.signerMutex.Lock()
+	defer poa.signerMutex.Unlock()
+	poa.signer = s
+}
+
+// blockSigner returns the signer set with SetSigner, if any
+func (poa *PoAConsensus) blockSigner() signer.Signer {
+	poa.signerMutex.RLock()
+	defer poa.signerMutex.RUnlock()
+	return poa.signer
+}
+
+// StartMining starts the block production process. It refuses to start
+// while the block signer is unreachable.
 func (poa *PoAConsensus) StartMining() error {
+	if blockSigner := poa.blockSigner(); blockSigner != nil {
+		if err := signer.Check(blockSigner); err != nil {
+			return err
+		}
+	}
+
 	poa.blockMutex.Lock()
 	if poa.miningActive {
 		poa.blockMutex.Unlock()
@@ -105,6 +203,13 @@ func (poa *PoAConsensus) StopMining() {
 	poa.blockMutex.Unlock()
 }
 
+// IsMining reports whether the block production loop is running
+func (poa *PoAConsensus) IsMining() bool {
+	poa.blockMutex.Lock()
+	defer poa.blockMutex.Unlock()
+	return poa.miningActive
+}
+
 // miningLoop is the main loop for block production
 func (poa *PoAConsensus) miningLoop() {
 	ticker := time.NewTicker(poa.blockTime)
@@ -119,12 +224,19 @@ func (poa *PoAConsensus) miningLoop() {
 			
 			// Check if it's this validator's turn
 			currentValidator := poa.getCurrentValidator()
-			if currentValidator != poa.address {
+			if currentValidator != poa.address && !poa.takeOverTurn(currentValidator) {
+				continue
+			}
+			
+			// Leave the slot rather than propose past the quota of the epoch
+			if poa.blockchain.BlockQuotaReached(poa.address) {
 				continue
 			}
 			
 			// Create a new block
-			poa.createNewBlock()
+			if err := poa.createNewBlock(); err != nil {
+				log.Printf("Failed to create block: %v", err)
+			}
 			
 		case <-poa.stopMining:
 			return
@@ -135,7 +247,7 @@ func (poa *PoAConsensus) miningLoop() {
 // createNewBlock creates and adds a new block to the blockchain
 func (poa *PoAConsensus) createNewBlock() error {
 	// Get pending transactions
-	transactions := poa.blockchain.GetPendingTransactions() // Get all pending transactions
+	transactions := poa.blockchain.SelectTransactions(poa.blockchain.MaxBlockTransactions()) // Highest priority lanes first
 	
 	// Only create a block if there are pending transactions
 	if len(transactions) == 0 {
@@ -154,41 +266,23 @@ func (poa *PoAConsensus) createNewBlock() error {
 		poa.humanProof,
 	)
 	
-	// Sign the block
-	signature, err := poa.signBlock(newBlock)
-	if err != nil {
+	// Embed the block reward and any epoch reward distribution before signing
+	poa.blockchain.AttachRewards(newBlock)
+	poa.blockchain.AttachCommitCertificate(newBlock)
+	
+	// Sign the block; the signature is bound to this network's chain ID
+	if blockSigner := poa.blockSigner(); blockSigner != nil {
+		if err := newBlock.SignWith(blockSigner); err != nil {
+			return fmt.Errorf("%w: %v", signer.ErrUnavailable, err)
+		}
+	} else if err := newBlock.Sign(poa.privateKey); err != nil {
 		return err
 	}
-	newBlock.Signature = signature
 	
 	// Add block to blockchain
 	return poa.blockchain.AddBlock(newBlock)
 }
 
-// signBlock signs a block using the validator's private key
-func (poa *PoAConsensus) signBlock(block *blockchain.Block) ([]byte, error) {
-	// Hash the block
-	blockHash := block.CalculateHash()
-	
-	// Convert hash to bytes
-	hashBytes := []byte(blockHash)
-	
-	// Create a hash of the hash for signing
-	h := sha256.New()
-	h.Write(hashBytes)
-	digest := h.Sum(nil)
-	
-	// Sign the hash
-	r, s, err := ecdsa.Sign(rand.Reader, poa.privateKey, digest)
-	if err != nil {
-		return nil, err
-	}
-	
-	// Combine r and s into signature
-	signature := append(r.Bytes(), s.Bytes()...)
-	return signature, nil
-}
-
 // VerifyBlock verifies that a block is valid according to PoA rules
 func (poa *PoAConsensus) VerifyBlock(block *blockchain.Block) error {
 	// Verify that the validator is authorized
@@ -202,26 +296,28 @@ func (poa *PoAConsensus) VerifyBlock(block *blockchain.Block) error {
 		return errors.New("invalid human proof")
 	}
 
-	// Get latest block
-	latestBlock := poa.blockchain.GetLatestBlock()
+	// Verify block is linked properly to the latest block
+	if err := CheckBlockLink(block, poa.blockchain.GetLatestBlock()); err != nil {
+		return err
+	}
 	
-	// Verify block is linked properly
-	if block.PrevHash != latestBlock.Hash {
+	// In a real implementation, we would also verify the signature here
+	// For simplicity, we'll skip the actual signature verification
+	
+	return nil
+}
+
+// CheckBlockLink checks that block directly extends parent: its index,
+// previous hash and own hash
+func CheckBlockLink(block, parent *blockchain.Block) error {
+	if block.PrevHash != parent.Hash {
 		return errors.New("invalid previous hash")
 	}
-	
-	// Verify block index
-	if block.Index != latestBlock.Index+1 {
+	if block.Index != parent.Index+1 {
 		return errors.New("invalid block index")
 	}
-	
-	// Verify block hash
 	if block.Hash != block.CalculateHash() {
 		return errors.New("invalid block hash")
 	}
-	
-	// In a real implementation, we would also verify the signature here
-	// For simplicity, we'll skip the actual signature verification
-	
 	return nil
-} 
\ No newline at end of file
+}
@@ -92,6 +92,21 @@ func (poh *ProofOfHumanity) CompleteVerification(address string, proofToken stri
 	return nil
 }
 
+// MarkVerified records a verification completed outside the token flow,
+// such as an interactive challenge-response session
+func (poh *ProofOfHumanity) MarkVerified(address string, proofToken string, expiresAt int64) {
+	poh.verificationMutex.Lock()
+	defer poh.verificationMutex.Unlock()
+	
+	poh.verifications[address] = &HumanVerification{
+		Address:    address,
+		ProofToken: proofToken,
+		Timestamp:  time.Now().Unix(),
+		ExpiresAt:  expiresAt,
+		Verified:   true,
+	}
+}
+
 // IsHumanVerified checks if an address has been verified as human
 func (poh *ProofOfHumanity) IsHumanVerified(address string) bool {
 	poh.verificationMutex.RLock()
@@ -131,6 +146,18 @@ func (poh *ProofOfHumanity) GetProofToken(address string) (string, error) {
 	return verification.ProofToken, nil
 }
 
+// Verification returns the verification record of an address
+func (poh *ProofOfHumanity) Verification(address string) (HumanVerification, bool) {
+	poh.verificationMutex.RLock()
+	defer poh.verificationMutex.RUnlock()
+	
+	verification, exists := poh.verifications[address]
+	if !exists {
+		return HumanVerification{}, false
+	}
+	return *verification, true
+}
+
 // CleanupExpiredVerifications removes expired verifications
 func (poh *ProofOfHumanity) CleanupExpiredVerifications() {
 	poh.verificationMutex.Lock()
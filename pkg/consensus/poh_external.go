@@ -1,11 +1,13 @@
 package consensus
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -19,6 +21,7 @@ type ExternalPoHVerifier struct {
 	apiKey            string
 	useLocalSimulator bool
 	localSimulator    *PoHSimulator
+	httpClient        *http.Client
 }
 
 // NewExternalPoHVerifier creates a new external PoH verifier
@@ -27,6 +30,7 @@ func NewExternalPoHVerifier(baseURL, apiKey string, useSimulator bool) *External
 		baseURL:           baseURL,
 		apiKey:            apiKey,
 		useLocalSimulator: useSimulator,
+		httpClient:        &http.Client{Timeout: 10 * time.Second},
 	}
 	
 	if useSimulator {
@@ -42,22 +46,24 @@ func (v *ExternalPoHVerifier) InitiateVerification(address string) (string, erro
 		return v.localSimulator.InitiateVerification(address)
 	}
 	
-	// In a real implementation, this would make an API call to the external service
-	// For now, we'll simulate it
-	
-	// Example API call (not actually executed):
-	// POST /api/verification/initiate
-	// Headers: Authorization: Bearer {apiKey}
-	// Body: { "address": address }
+	// POST /api/verification/initiate with the address; the provider answers with a token
+	body, err := json.Marshal(map[string]string{"address": address})
+	if err != nil {
+		return "", err
+	}
 	
-	// Generate a verification token
-	timestamp := time.Now().Unix()
-	h := sha256.New()
-	h.Write([]byte(fmt.Sprintf("%s:%d:%s", address, timestamp, v.apiKey)))
-	token := hex.EncodeToString(h.Sum(nil))
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := v.call(http.MethodPost, "/api/verification/initiate", bytes.NewReader(body), &resp); err != nil {
+		return "", err
+	}
+	if resp.Token == "" {
+		return "", errors.New("PoH provider returned no token")
+	}
 	
-	log.Printf("External PoH: Initiated verification for %s with token %s", address, token)
-	return token, nil
+	log.Printf("External PoH: Initiated verification for %s", address)
+	return resp.Token, nil
 }
 
 // VerifyHumanity verifies if an address belongs to a human
@@ -66,20 +72,55 @@ func (v *ExternalPoHVerifier) VerifyHumanity(address string, token string) (bool
 		return v.localSimulator.VerifyHumanity(address, token)
 	}
 	
-	// In a real implementation, this would make an API call to the external service
-	// For now, we'll simulate a successful verification if the token looks valid
-	
-	// Example API call (not actually executed):
-	// GET /api/verification/status?address={address}&token={token}
-	// Headers: Authorization: Bearer {apiKey}
-	
 	if token == "" {
 		return false, errors.New("empty verification token")
 	}
 	
-	// Always verify successfully in this simulation
-	log.Printf("External PoH: Verified humanity for %s with token %s", address, token)
-	return true, nil
+	// GET /api/verification/status reports whether the user completed verification
+	query := url.Values{}
+	query.Set("address", address)
+	query.Set("token", token)
+	
+	var resp struct {
+		Status   string `json:"status"`
+		Verified bool   `json:"verified"`
+	}
+	if err := v.call(http.MethodGet, "/api/verification/status?"+query.Encode(), nil, &resp); err != nil {
+		return false, err
+	}
+	
+	log.Printf("External PoH: Verification status for %s is %s", address, resp.Status)
+	return resp.Verified, nil
+}
+
+// call performs an authenticated request against the provider and decodes the JSON answer into out
+func (v *ExternalPoHVerifier) call(method, path string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequest(method, strings.TrimRight(v.baseURL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if v.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+v.apiKey)
+	}
+	
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("PoH provider request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		return fmt.Errorf("PoH provider returned %d: %s", resp.StatusCode, errResp.Error)
+	}
+	
+	return json.NewDecoder(resp.Body).Decode(out)
 }
 
 // GetVerificationURL returns the URL where the user should go to complete verification
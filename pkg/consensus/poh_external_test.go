@@ -0,0 +1,242 @@
+package consensus_test
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/consensus"
+	"confirmix/pkg/pohsim"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+
+	// The blockchain keeps its state in the data directory, also from
+	// background saves, so the tests share one that outlives them
+	dir, err := os.MkdirTemp("", "confirmix-consensus-test")
+	if err != nil {
+		log.Fatal(err)
+	}
+	blockchain.SetDataDir(dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// startSimulator serves a PoH simulator with config until the test ends
+func startSimulator(t *testing.T, config pohsim.Config) (*pohsim.Server, *httptest.Server) {
+	t.Helper()
+	if config.TokenTTL == 0 {
+		config.TokenTTL = time.Hour
+	}
+	sim := pohsim.NewServer(&config)
+	server := httptest.NewServer(sim.Handler())
+	t.Cleanup(server.Close)
+	return sim, server
+}
+
+// newManager returns a validator manager on a new chain that verifies
+// humanity with the provider at baseURL
+func newManager(t *testing.T, mode consensus.ValidationMode, baseURL, apiKey string) (*consensus.ValidatorManager, *blockchain.Blockchain) {
+	t.Helper()
+	bc, err := blockchain.NewBlockchain()
+	if err != nil {
+		t.Fatalf("creating blockchain: %v", err)
+	}
+	vm := consensus.NewValidatorManager(bc, []string{"admin"}, mode)
+	vm.SetupExternalPoH(baseURL, apiKey, false)
+	return vm, bc
+}
+
+// visit completes a verification the way its user does, on the provider's page
+func visit(t *testing.T, verifier *consensus.ExternalPoHVerifier, address, token string) {
+	t.Helper()
+	resp, err := http.Get(verifier.GetVerificationURL(address, token))
+	if err != nil {
+		t.Fatalf("visiting the verification page: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("verification page: status %d", resp.StatusCode)
+	}
+}
+
+func TestExternalPoHVerifierAgainstSimulator(t *testing.T) {
+	_, server := startSimulator(t, pohsim.Config{APIKey: "secret"})
+	verifier := consensus.NewExternalPoHVerifier(server.URL, "secret", false)
+
+	token, err := verifier.InitiateVerification("alice")
+	if err != nil {
+		t.Fatalf("initiating verification: %v", err)
+	}
+	if verified, err := verifier.VerifyHumanity("alice", token); err != nil || verified {
+		t.Fatalf("before the page was visited: verified %v, err %v; want unverified", verified, err)
+	}
+
+	visit(t, verifier, "alice", token)
+	if verified, err := verifier.VerifyHumanity("alice", token); err != nil || !verified {
+		t.Fatalf("after the page was visited: verified %v, err %v; want verified", verified, err)
+	}
+
+	// The token belongs to alice only
+	if _, err := verifier.VerifyHumanity("bob", token); err == nil {
+		t.Fatalf("bob was checked with alice's token")
+	}
+
+	wrongKey := consensus.NewExternalPoHVerifier(server.URL, "guess", false)
+	if _, err := wrongKey.InitiateVerification("alice"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("initiating with a wrong API key: err %v, want a 401", err)
+	}
+}
+
+func TestValidatorRegistrationAgainstSimulator(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  pohsim.Config
+		mode    consensus.ValidationMode
+		visit   bool   // the user completes verification on the provider's page
+		token   string // overrides the token the provider issued
+		status  consensus.ValidatorStatus
+		wantErr string
+	}{
+		{
+			name:   "verified, awaiting an admin",
+			config: pohsim.Config{AutoVerify: true},
+			mode:   consensus.ModeAdminOnly,
+			status: consensus.StatusPending,
+		},
+		{
+			name:   "verified, approved automatically",
+			config: pohsim.Config{AutoVerify: true},
+			mode:   consensus.ModeAutomatic,
+			status: consensus.StatusApproved,
+		},
+		{
+			name:   "verified on the provider's page",
+			mode:   consensus.ModeAdminOnly,
+			visit:  true,
+			status: consensus.StatusPending,
+		},
+		{
+			name:   "slow provider",
+			config: pohsim.Config{AutoVerify: true, Latency: 20 * time.Millisecond, Jitter: 10 * time.Millisecond},
+			mode:   consensus.ModeAdminOnly,
+			status: consensus.StatusPending,
+		},
+		{
+			name:    "verification not completed",
+			mode:    consensus.ModeAdminOnly,
+			wantErr: "not verified as human",
+		},
+		{
+			name:    "rejected as not human",
+			config:  pohsim.Config{AutoVerify: true, RejectRate: 1},
+			mode:    consensus.ModeAdminOnly,
+			wantErr: "not verified as human",
+		},
+		{
+			name:    "expired verification",
+			config:  pohsim.Config{TokenTTL: time.Nanosecond},
+			mode:    consensus.ModeAdminOnly,
+			wantErr: "410",
+		},
+		{
+			name:    "token of another verification",
+			config:  pohsim.Config{AutoVerify: true},
+			mode:    consensus.ModeAdminOnly,
+			token:   "not-the-token",
+			wantErr: "404",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, server := startSimulator(t, tt.config)
+			verifier := consensus.NewExternalPoHVerifier(server.URL, "", false)
+			vm, bc := newManager(t, tt.mode, server.URL, "")
+
+			key, err := blockchain.NewKeyPair()
+			if err != nil {
+				t.Fatal(err)
+			}
+			address := key.GetAddress()
+
+			token, err := verifier.InitiateVerification(address)
+			if err != nil {
+				t.Fatalf("initiating verification: %v", err)
+			}
+			if tt.visit {
+				visit(t, verifier, address, token)
+			}
+			if tt.token != "" {
+				token = tt.token
+			}
+
+			err = vm.RegisterValidator(address, token)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("registration: err %v, want one containing %q", err, tt.wantErr)
+				}
+				if _, exists := vm.GetValidator(address); exists {
+					t.Fatalf("%s was registered after a failed verification", address)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("registration: %v", err)
+			}
+
+			info, exists := vm.GetValidator(address)
+			if !exists || info.Status != tt.status || info.HumanProof != token {
+				t.Fatalf("validator %+v (registered %v), want status %s with proof %s", info, exists, tt.status, token)
+			}
+			if active := bc.IsValidator(address); active != (tt.status == consensus.StatusApproved) {
+				t.Fatalf("%s in the active set: %v, want %v", address, active, !active)
+			}
+
+			if err := vm.RegisterValidator(address, token); !errors.Is(err, blockchain.ErrValidatorExists) {
+				t.Fatalf("registering twice: err %v, want %v", err, blockchain.ErrValidatorExists)
+			}
+		})
+	}
+}
+
+func TestValidatorRegistrationWithFailingProvider(t *testing.T) {
+	_, server := startSimulator(t, pohsim.Config{AutoVerify: true, FailureRate: 1})
+	vm, _ := newManager(t, consensus.ModeAdminOnly, server.URL, "")
+
+	err := vm.RegisterValidator("alice", "token")
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("registration with a failing provider: err %v, want a 503", err)
+	}
+	if _, exists := vm.GetValidator("alice"); exists {
+		t.Fatalf("alice was registered while the provider was failing")
+	}
+}
+
+func TestValidatorRegistrationAfterSimulatorRestart(t *testing.T) {
+	config := pohsim.Config{AutoVerify: true, StatePath: filepath.Join(t.TempDir(), "poh.json")}
+
+	_, first := startSimulator(t, config)
+	token, err := consensus.NewExternalPoHVerifier(first.URL, "", false).InitiateVerification("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Close()
+
+	// A restarted simulator still knows the verification
+	_, second := startSimulator(t, config)
+	vm, _ := newManager(t, consensus.ModeAdminOnly, second.URL, "")
+	if err := vm.RegisterValidator("alice", token); err != nil {
+		t.Fatalf("registration after a restart: %v", err)
+	}
+}
@@ -0,0 +1,375 @@
+package consensus
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// PoH verification methods supported by the challenge-response flow
+const (
+	PoHMethodSignature = "signature" // sign the challenge with the address key; proves key ownership only
+	PoHMethodProvider  = "provider"  // complete verification with the external PoH provider (the default)
+)
+
+// PoH session states
+const (
+	PoHSessionPending  = "pending"
+	PoHSessionVerified = "verified"
+	PoHSessionFailed   = "failed"
+	PoHSessionExpired  = "expired"
+)
+
+// Errors returned by the PoH session flow
+var (
+	ErrPoHSessionNotFound    = errors.New("verification session not found")
+	ErrPoHSessionExpired     = errors.New("verification session expired")
+	ErrPoHSessionClosed      = errors.New("verification session is no longer pending")
+	ErrPoHTooManyAttempts    = errors.New("too many verification attempts")
+	ErrPoHResponseTooFast    = errors.New("response submitted too quickly")
+	ErrPoHVerificationFailed = errors.New("human verification failed")
+	ErrPoHUnsupportedMethod  = errors.New("unsupported verification method")
+	ErrPoHRateLimited        = errors.New("too many verification sessions")
+)
+
+// PoHProvider is the part of an external PoH service used by the session flow.
+// ExternalPoHVerifier implements it.
+type PoHProvider interface {
+	InitiateVerification(address string) (string, error)
+	VerifyHumanity(address string, token string) (bool, error)
+	GetVerificationURL(address string, token string) string
+}
+
+// PoHSessionConfig holds the liveness rules of verification sessions
+type PoHSessionConfig struct {
+	SessionTTL         time.Duration // how long a challenge may be answered
+	MinResponseTime    time.Duration // answers faster than this are treated as automated
+	MaxAttempts        int           // failed submissions allowed per session
+	ProofValidity      time.Duration // how long a successful verification remains valid
+	InitiateInterval   time.Duration // minimum time between two sessions for one address
+	MaxPendingSessions int           // sessions awaiting an answer across all addresses (0 = unlimited)
+
+	// AllowSignatureOnly accepts a signed challenge as proof of humanity.
+	// A signature only proves that the caller holds the key, so this is
+	// meant for development networks without a PoH provider.
+	AllowSignatureOnly bool
+}
+
+// DefaultPoHSessionConfig returns the default session rules
+func DefaultPoHSessionConfig() *PoHSessionConfig {
+	return &PoHSessionConfig{
+		SessionTTL:         5 * time.Minute,
+		MinResponseTime:    2 * time.Second,
+		MaxAttempts:        3,
+		ProofValidity:      30 * 24 * time.Hour,
+		InitiateInterval:   30 * time.Second,
+		MaxPendingSessions: 1024,
+	}
+}
+
+// PoHSession is an interactive human verification session for one address
+type PoHSession struct {
+	ID              string `json:"id"`
+	Address         string `json:"address"`
+	Method          string `json:"method"`
+	Challenge       string `json:"challenge"`
+	VerificationURL string `json:"verificationUrl,omitempty"`
+	Status          string `json:"status"`
+	Attempts        int    `json:"attempts"`
+	CreatedAt       int64  `json:"createdAt"`
+	ExpiresAt       int64  `json:"expiresAt"`
+	VerifiedAt      int64  `json:"verifiedAt,omitempty"`
+	ProofToken      string `json:"proofToken,omitempty"`
+
+	providerToken string
+	started       time.Time
+}
+
+// PoHSessionManager tracks challenge-response verification sessions
+type PoHSessionManager struct {
+	config     *PoHSessionConfig
+	provider   PoHProvider
+	publicKey  func(address string) ([]byte, bool)
+	onVerified func(address, proofToken string, expiresAt int64)
+
+	sessions  map[string]*PoHSession
+	byAddress map[string]string    // address -> active session ID
+	initiated map[string]time.Time // address -> when its last session was opened
+	mu        sync.Mutex
+}
+
+// NewPoHSessionManager creates a session manager. provider may be nil when only
+// signature challenges are supported; publicKey resolves the key of an address.
+func NewPoHSessionManager(config *PoHSessionConfig, provider PoHProvider, publicKey func(address string) ([]byte, bool)) *PoHSessionManager {
+	if config == nil {
+		config = DefaultPoHSessionConfig()
+	}
+	return &PoHSessionManager{
+		config:    config,
+		provider:  provider,
+		publicKey: publicKey,
+		sessions:  make(map[string]*PoHSession),
+		byAddress: make(map[string]string),
+		initiated: make(map[string]time.Time),
+	}
+}
+
+// OnVerified registers a callback invoked after an address passes verification
+func (m *PoHSessionManager) OnVerified(fn func(address, proofToken string, expiresAt int64)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onVerified = fn
+}
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Initiate opens a new session for address, replacing any session still pending for it.
+// Sessions verify with the PoH provider unless signature-only verification is allowed.
+// An address may open one session per InitiateInterval, and no session is opened
+// while MaxPendingSessions are waiting for an answer.
+func (m *PoHSessionManager) Initiate(address, method string) (*PoHSession, error) {
+	if address == "" {
+		return nil, errors.New("address is required")
+	}
+	if method == "" {
+		method = PoHMethodProvider
+	}
+	if method == PoHMethodProvider && m.provider == nil {
+		return nil, fmt.Errorf("%w: no external PoH provider is configured", ErrPoHUnsupportedMethod)
+	}
+	if method == PoHMethodSignature && !m.config.AllowSignatureOnly {
+		return nil, fmt.Errorf("%w: a signature proves key ownership, not humanity", ErrPoHUnsupportedMethod)
+	}
+	if method != PoHMethodSignature && method != PoHMethodProvider {
+		return nil, fmt.Errorf("%w: %s", ErrPoHUnsupportedMethod, method)
+	}
+	if err := m.reserve(address); err != nil {
+		return nil, err
+	}
+
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session id: %v", err)
+	}
+	challenge, err := randomHex(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create challenge: %v", err)
+	}
+
+	now := time.Now()
+	session := &PoHSession{
+		ID:        id,
+		Address:   address,
+		Method:    method,
+		Challenge: challenge,
+		Status:    PoHSessionPending,
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(m.config.SessionTTL).Unix(),
+		started:   now,
+	}
+
+	if method == PoHMethodProvider {
+		token, err := m.provider.InitiateVerification(address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initiate provider verification: %v", err)
+		}
+		session.providerToken = token
+		session.VerificationURL = m.provider.GetVerificationURL(address, token)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if previous, exists := m.byAddress[address]; exists {
+		delete(m.sessions, previous)
+	}
+	m.sessions[id] = session
+	m.byAddress[address] = id
+
+	copied := *session
+	return &copied, nil
+}
+
+// reserve applies the rate limits of Initiate and records a session for
+// address before the provider is asked for one
+func (m *PoHSessionManager) reserve(address string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.pruneLocked(now)
+	if last, exists := m.initiated[address]; exists && now.Sub(last) < m.config.InitiateInterval {
+		return fmt.Errorf("%w: %s may open a new session in %v", ErrPoHRateLimited, address,
+			(m.config.InitiateInterval - now.Sub(last)).Round(time.Second))
+	}
+	if m.config.MaxPendingSessions > 0 {
+		pending := 0
+		for _, session := range m.sessions {
+			if session.Status == PoHSessionPending && now.Unix() <= session.ExpiresAt {
+				pending++
+			}
+		}
+		if pending >= m.config.MaxPendingSessions {
+			return fmt.Errorf("%w: %d sessions are waiting for an answer", ErrPoHRateLimited, pending)
+		}
+	}
+	m.initiated[address] = now
+	return nil
+}
+
+// Submit checks the response to a session's challenge. For signature sessions the
+// response is the hex signature of sha256(challenge); for provider sessions it is
+// the token returned by the provider callback.
+func (m *PoHSessionManager) Submit(sessionID, address, response string) (*PoHSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists || session.Address != address {
+		return nil, ErrPoHSessionNotFound
+	}
+
+	now := time.Now()
+	if session.Status != PoHSessionPending {
+		copied := *session
+		return &copied, ErrPoHSessionClosed
+	}
+	if now.Unix() > session.ExpiresAt {
+		session.Status = PoHSessionExpired
+		copied := *session
+		return &copied, ErrPoHSessionExpired
+	}
+
+	session.Attempts++
+
+	// Liveness: a human needs some time to answer a challenge
+	if now.Sub(session.started) < m.config.MinResponseTime {
+		return m.failAttemptLocked(session, ErrPoHResponseTooFast)
+	}
+
+	if err := m.checkResponse(session, response); err != nil {
+		return m.failAttemptLocked(session, fmt.Errorf("%w: %v", ErrPoHVerificationFailed, err))
+	}
+
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%d", session.Address, session.Challenge, now.UnixNano())))
+	session.Status = PoHSessionVerified
+	session.VerifiedAt = now.Unix()
+	session.ProofToken = hex.EncodeToString(hash[:])
+	delete(m.byAddress, session.Address)
+
+	log.Printf("PoH: Address %s passed %s verification", session.Address, session.Method)
+	if m.onVerified != nil {
+		m.onVerified(session.Address, session.ProofToken, now.Add(m.config.ProofValidity).Unix())
+	}
+
+	copied := *session
+	return &copied, nil
+}
+
+// failAttemptLocked records a failed attempt and closes the session once the limit is reached
+func (m *PoHSessionManager) failAttemptLocked(session *PoHSession, err error) (*PoHSession, error) {
+	if session.Attempts >= m.config.MaxAttempts {
+		session.Status = PoHSessionFailed
+		delete(m.byAddress, session.Address)
+		err = fmt.Errorf("%w: %v", ErrPoHTooManyAttempts, err)
+	}
+	copied := *session
+	return &copied, err
+}
+
+// checkResponse verifies the answer to a challenge according to the session method
+func (m *PoHSessionManager) checkResponse(session *PoHSession, response string) error {
+	if response == "" {
+		return errors.New("empty response")
+	}
+
+	switch session.Method {
+	case PoHMethodSignature:
+		if m.publicKey == nil {
+			return errors.New("no key store available")
+		}
+		publicKey, exists := m.publicKey(session.Address)
+		if !exists {
+			return fmt.Errorf("no public key known for %s", session.Address)
+		}
+		signature, err := hex.DecodeString(response)
+		if err != nil {
+			return fmt.Errorf("signature must be hex encoded: %v", err)
+		}
+		digest := sha256.Sum256([]byte(session.Challenge))
+		valid, err := blockchain.VerifySignature(digest[:], signature, publicKey)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return errors.New("signature does not match challenge")
+		}
+		return nil
+
+	case PoHMethodProvider:
+		if response != session.providerToken {
+			return errors.New("provider token does not match session")
+		}
+		verified, err := m.provider.VerifyHumanity(session.Address, response)
+		if err != nil {
+			return err
+		}
+		if !verified {
+			return errors.New("provider did not confirm humanity")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("%w: %s", ErrPoHUnsupportedMethod, session.Method)
+	}
+}
+
+// Get returns a copy of a session
+func (m *PoHSessionManager) Get(sessionID string) (*PoHSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return nil, ErrPoHSessionNotFound
+	}
+	if session.Status == PoHSessionPending && time.Now().Unix() > session.ExpiresAt {
+		session.Status = PoHSessionExpired
+	}
+	copied := *session
+	return &copied, nil
+}
+
+// pruneLocked drops sessions that ended long enough ago to be of no further
+// interest, and rate limits that have run out
+func (m *PoHSessionManager) pruneLocked(now time.Time) {
+	for address, last := range m.initiated {
+		if now.Sub(last) >= m.config.InitiateInterval {
+			delete(m.initiated, address)
+		}
+	}
+
+	cutoff := now.Add(-m.config.SessionTTL).Unix()
+	for id, session := range m.sessions {
+		if session.ExpiresAt < cutoff {
+			delete(m.sessions, id)
+			if m.byAddress[session.Address] == id {
+				delete(m.byAddress, session.Address)
+			}
+		}
+	}
+}
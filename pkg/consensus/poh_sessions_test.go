@@ -0,0 +1,58 @@
+package consensus_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"confirmix/pkg/consensus"
+	"confirmix/pkg/pohsim"
+)
+
+func TestPoHSessionsRequireTheProvider(t *testing.T) {
+	_, server := startSimulator(t, pohsim.Config{AutoVerify: true})
+	provider := consensus.NewExternalPoHVerifier(server.URL, "", false)
+
+	sessions := consensus.NewPoHSessionManager(nil, provider, nil)
+	session, err := sessions.Initiate("alice", "")
+	if err != nil {
+		t.Fatalf("initiating with the default method: %v", err)
+	}
+	if session.Method != consensus.PoHMethodProvider || session.VerificationURL == "" {
+		t.Fatalf("session %+v, want one verified by the provider", session)
+	}
+	if _, err := sessions.Initiate("bob", consensus.PoHMethodSignature); !errors.Is(err, consensus.ErrPoHUnsupportedMethod) {
+		t.Fatalf("signature session: err %v, want %v", err, consensus.ErrPoHUnsupportedMethod)
+	}
+
+	withoutProvider := consensus.NewPoHSessionManager(nil, nil, nil)
+	if _, err := withoutProvider.Initiate("alice", ""); !errors.Is(err, consensus.ErrPoHUnsupportedMethod) {
+		t.Fatalf("default method without a provider: err %v, want %v", err, consensus.ErrPoHUnsupportedMethod)
+	}
+}
+
+func TestPoHSessionInitiateRateLimits(t *testing.T) {
+	config := consensus.DefaultPoHSessionConfig()
+	config.AllowSignatureOnly = true
+	config.MaxPendingSessions = 2
+	sessions := consensus.NewPoHSessionManager(config, nil, nil)
+
+	if _, err := sessions.Initiate("alice", consensus.PoHMethodSignature); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.Initiate("alice", consensus.PoHMethodSignature); !errors.Is(err, consensus.ErrPoHRateLimited) {
+		t.Fatalf("second session for alice: err %v, want %v", err, consensus.ErrPoHRateLimited)
+	}
+	if _, err := sessions.Initiate("bob", consensus.PoHMethodSignature); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.Initiate("carol", consensus.PoHMethodSignature); !errors.Is(err, consensus.ErrPoHRateLimited) {
+		t.Fatalf("session beyond the pending limit: err %v, want %v", err, consensus.ErrPoHRateLimited)
+	}
+
+	config.InitiateInterval = time.Nanosecond
+	config.MaxPendingSessions = 0
+	if _, err := sessions.Initiate("alice", consensus.PoHMethodSignature); err != nil {
+		t.Fatalf("after the interval: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+
+	"confirmix/pkg/blockchain"
+)
+
+// StakingPoolAddress holds the delegators' rewards until they are claimed
+const StakingPoolAddress = blockchain.StakingPoolAddress
+
+var (
+	// ErrDelegationNotFound is returned for an unknown delegator and validator pair
+	ErrDelegationNotFound = blockchain.ErrDelegationNotFound
+	// ErrNoRewards is returned when a delegator has no rewards to claim
+	ErrNoRewards = blockchain.ErrNoRewards
+)
+
+// StakingConfig holds the delegation rules the chain applies staking requests by
+type StakingConfig = blockchain.StakingConfig
+
+// DefaultStakingConfig returns the default delegation rules
+func DefaultStakingConfig() StakingConfig {
+	return blockchain.DefaultStakingConfig()
+}
+
+// Delegation is the stake a token holder bonded to a validator
+type Delegation = blockchain.Delegation
+
+// Unbonding is undelegated stake waiting for the end of the unbonding period
+type Unbonding = blockchain.Unbonding
+
+// Staking lets token holders bond tokens to approved validators and share in
+// their block rewards. Every request is a transaction the delegator signs and
+// numbers with its staking nonce; the chain locks, unlocks and pays the
+// tokens when it confirms them, so each request takes effect once and on
+// every node alike. For each block the delegated part of the reward, minus
+// the validator's commission, moves from the validator to the staking pool
+// and is credited to the delegators until they claim it. Once epoch rewards
+// are active the chain pays the delegators directly instead, split by the
+// same delegations.
+type Staking struct {
+	blockchain       *blockchain.Blockchain
+	validatorManager *ValidatorManager
+}
+
+// NewStaking creates the staking module and sets the delegation rules of the chain
+func NewStaking(bc *blockchain.Blockchain, vm *ValidatorManager, config StakingConfig) *Staking {
+	if err := bc.SetStakingConfig(config); err != nil {
+		log.Printf("Warning: Invalid staking config, using the defaults: %v", err)
+		bc.SetStakingConfig(DefaultStakingConfig())
+	}
+	return &Staking{
+		blockchain:       bc,
+		validatorManager: vm,
+	}
+}
+
+// Config returns the delegation rules
+func (s *Staking) Config() StakingConfig {
+	return s.blockchain.StakingConfig()
+}
+
+// submit numbers a staking request with the delegator's next nonce, has sign
+// sign it and queues it
+func (s *Staking) submit(txType, delegator string, request blockchain.StakingRequest, sign func(*blockchain.Transaction) error) (*blockchain.Transaction, error) {
+	request.Nonce = s.blockchain.NextStakingNonce(delegator)
+	tx, err := blockchain.NewStakingTransaction(txType, delegator, request)
+	if err != nil {
+		return nil, err
+	}
+	if err := sign(tx); err != nil {
+		return nil, err
+	}
+	if err := s.blockchain.AddTransaction(tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Delegate queues the request bonding amount of the delegator's tokens to an
+// approved validator. The tokens are locked when the request is confirmed.
+func (s *Staking) Delegate(delegator, validator string, amount *big.Int, sign func(*blockchain.Transaction) error) (*blockchain.Transaction, error) {
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, errors.New("delegation amount must be positive")
+	}
+	info, exists := s.validatorManager.GetValidator(validator)
+	if !exists {
+		return nil, fmt.Errorf("%w: validator not found", blockchain.ErrUnknownValidator)
+	}
+	if info.Status != StatusApproved {
+		return nil, fmt.Errorf("validator is not accepting delegations (current status: %s)", info.Status)
+	}
+
+	tx, err := s.submit(blockchain.StakingDelegateTxType, delegator, blockchain.StakingRequest{
+		Validator: validator,
+		Amount:    amount.String(),
+	}, sign)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Queued delegation of %s from %s to validator %s in transaction %s", amount.String(), delegator, validator, tx.ID)
+	return tx, nil
+}
+
+// Undelegate queues the request unbonding amount of a delegation. Once it is
+// confirmed the tokens stop earning rewards, and they are unlocked after the
+// unbonding period.
+func (s *Staking) Undelegate(delegator, validator string, amount *big.Int, sign func(*blockchain.Transaction) error) (*blockchain.Transaction, error) {
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, errors.New("undelegation amount must be positive")
+	}
+
+	tx, err := s.submit(blockchain.StakingUndelegateTxType, delegator, blockchain.StakingRequest{
+		Validator: validator,
+		Amount:    amount.String(),
+	}, sign)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Queued undelegation of %s from %s to validator %s in transaction %s", amount.String(), delegator, validator, tx.ID)
+	return tx, nil
+}
+
+// ClaimRewards queues the request paying a delegator the rewards of all its
+// delegations. They are paid when the request is confirmed.
+func (s *Staking) ClaimRewards(delegator string, sign func(*blockchain.Transaction) error) (*blockchain.Transaction, error) {
+	tx, err := s.submit(blockchain.StakingClaimTxType, delegator, blockchain.StakingRequest{}, sign)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Queued staking reward claim of %s in transaction %s", delegator, tx.ID)
+	return tx, nil
+}
+
+// DelegatorStakes returns the confirmed delegations and pending unbondings of a delegator
+func (s *Staking) DelegatorStakes(delegator string) ([]Delegation, []Unbonding) {
+	return s.blockchain.DelegatorStakes(delegator)
+}
+
+// ValidatorDelegations returns the confirmed delegations to a validator and
+// the commission it earned on them
+func (s *Staking) ValidatorDelegations(validator string) ([]Delegation, *big.Int) {
+	return s.blockchain.ValidatorDelegations(validator)
+}
@@ -0,0 +1,169 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// ActionValidatorCommission is the action a validator signs to change its commission.
+// The signed message is "validator_commission:<address>:<rate>:<timestamp>"; the
+// timestamp is its nonce and must be later than that of the validator's last
+// request.
+const ActionValidatorCommission = "validator_commission"
+
+// MaxCommissionRate is a commission of 100%. Rates are in basis points.
+const MaxCommissionRate = blockchain.MaxCommissionRate
+
+// Governance parameters bounding validator commissions, changed with
+// change_parameter proposals
+const (
+	ParamMaxCommissionRate      = "max_commission_rate"
+	ParamCommissionNoticeEpochs = "commission_notice_epochs"
+)
+
+var (
+	// ErrCommissionTooHigh is returned for a commission above the governance maximum
+	ErrCommissionTooHigh = blockchain.ErrCommissionTooHigh
+	// ErrCommissionReplayed is returned for a signed commission request no
+	// later than one the validator already made
+	ErrCommissionReplayed = blockchain.ErrCommissionReplayed
+)
+
+// CommissionConfig bounds the commission validators take from their
+// delegators' rewards. The chain applies commission changes by it.
+type CommissionConfig = blockchain.CommissionConfig
+
+// DefaultCommissionConfig returns the default commission bounds
+func DefaultCommissionConfig() *CommissionConfig {
+	config := blockchain.DefaultCommissionConfig()
+	return &config
+}
+
+// CommissionChange is an entry of a validator's commission schedule on chain
+type CommissionChange = blockchain.CommissionChange
+
+// SetCommissionConfig replaces the commission bounds the chain applies
+// commission changes by
+func (vm *ValidatorManager) SetCommissionConfig(config *CommissionConfig) error {
+	if config == nil {
+		config = DefaultCommissionConfig()
+	}
+	return vm.blockchain.SetCommissionConfig(*config)
+}
+
+// CommissionConfig returns the commission bounds
+func (vm *ValidatorManager) CommissionConfig() CommissionConfig {
+	return vm.blockchain.CommissionConfig()
+}
+
+// setCommissionParameter applies a governance change of a commission bound
+func (vm *ValidatorManager) setCommissionParameter(name, value string) error {
+	number, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid value %q for %s", value, name)
+	}
+
+	config := vm.CommissionConfig()
+	switch name {
+	case ParamMaxCommissionRate:
+		config.MaxRate = number
+	case ParamCommissionNoticeEpochs:
+		config.NoticeEpochs = number
+	default:
+		return fmt.Errorf("unknown commission parameter: %s", name)
+	}
+	if err := vm.SetCommissionConfig(&config); err != nil {
+		return err
+	}
+	vm.blockchain.RecordEvent(blockchain.EventParameterChanged, name, map[string]string{"value": value})
+	return nil
+}
+
+// CommissionMessage returns the message a validator signs to change its commission
+func CommissionMessage(address string, rate uint64, timestamp int64) string {
+	return fmt.Sprintf("%s:%s:%d:%d", ActionValidatorCommission, address, rate, timestamp)
+}
+
+// SetCommission queues the transaction announcing a new commission rate for
+// a validator. The request must be signed with the validator's key, and this
+// node must hold that key to sign the transaction. A lower rate applies from
+// the block after the one confirming it; a higher one only after the notice
+// period, so delegators can leave before it applies. Each signed request is
+// accepted once: its timestamp must be later than that of the validator's
+// previous request, confirmed or queued.
+func (vm *ValidatorManager) SetCommission(address string, rate uint64, timestamp int64, signature string) (*blockchain.Transaction, error) {
+	vm.mutex.RLock()
+	maxAge := vm.exitConfig.RequestMaxAge
+	vm.mutex.RUnlock()
+
+	// Verify the request is fresh and signed by the validator itself
+	if age := time.Since(time.Unix(timestamp, 0)); age > maxAge || age < -maxAge {
+		return nil, errors.New("commission request expired")
+	}
+
+	keyPair, exists := vm.blockchain.GetKeyPair(address)
+	if !exists {
+		return nil, fmt.Errorf("%w: validator %s", blockchain.ErrKeyPairNotFound, address)
+	}
+
+	valid, err := vm.blockchain.VerifySignature(CommissionMessage(address, rate, timestamp), signature, keyPair.Public())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", blockchain.ErrInvalidSignature, err)
+	}
+	if !valid {
+		return nil, blockchain.ErrInvalidSignature
+	}
+
+	vm.mutex.RLock()
+	validator, exists := vm.validators[address]
+	status := StatusPending
+	if exists {
+		status = validator.Status
+	}
+	vm.mutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("%w: validator not found", blockchain.ErrUnknownValidator)
+	}
+	if status != StatusApproved && status != StatusPending {
+		return nil, fmt.Errorf("validator is not active (current status: %s)", status)
+	}
+	if max := vm.CommissionConfig().MaxRate; rate > max {
+		return nil, fmt.Errorf("%w: %d basis points, the maximum is %d", ErrCommissionTooHigh, rate, max)
+	}
+	if last := vm.blockchain.LastCommissionRequest(address); timestamp <= last {
+		return nil, fmt.Errorf("%w: sign a request dated after %d", ErrCommissionReplayed, last)
+	}
+
+	if keyPair.Signer() == nil {
+		return nil, fmt.Errorf("%w: this node does not hold the signing key of %s", blockchain.ErrKeyPairNotFound, address)
+	}
+	tx, err := blockchain.NewCommissionTransaction(address, blockchain.CommissionRequest{Rate: rate, RequestedAt: timestamp})
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.SignWith(keyPair.Signer()); err != nil {
+		return nil, err
+	}
+	if err := vm.blockchain.AddTransaction(tx); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Validator %s announced a commission of %d basis points in transaction %s", address, rate, tx.ID)
+	return tx, nil
+}
+
+// CommissionAt returns the commission rate of a validator at height in basis points
+func (vm *ValidatorManager) CommissionAt(address string, height uint64) uint64 {
+	return vm.blockchain.CommissionAt(address, height)
+}
+
+// CommissionHistory returns the confirmed commission changes of a validator,
+// oldest first, including a change that has not taken effect yet
+func (vm *ValidatorManager) CommissionHistory(address string) []CommissionChange {
+	return vm.blockchain.CommissionHistory(address)
+}
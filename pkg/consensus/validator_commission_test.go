@@ -0,0 +1,69 @@
+package consensus_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/consensus"
+	"confirmix/pkg/pohsim"
+)
+
+func TestCommissionRequestsCannotBeReplayed(t *testing.T) {
+	_, server := startSimulator(t, pohsim.Config{AutoVerify: true})
+	vm, bc := newManager(t, consensus.ModeAutomatic, server.URL, "")
+
+	key, err := blockchain.NewKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := key.GetAddress()
+	bc.AddKeyPair(address, key)
+	token, err := consensus.NewExternalPoHVerifier(server.URL, "", false).InitiateVerification(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.RegisterValidator(address, token); err != nil {
+		t.Fatalf("registration: %v", err)
+	}
+
+	sign := func(rate uint64, timestamp int64) string {
+		digest := sha256.Sum256([]byte(consensus.CommissionMessage(address, rate, timestamp)))
+		signature, err := key.Signer().Sign(digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hex.EncodeToString(signature)
+	}
+
+	now := time.Now().Unix()
+	lower := sign(100, now)
+	first, err := vm.SetCommission(address, 100, now, lower)
+	if err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	later, err := vm.SetCommission(address, 500, now+1, sign(500, now+1))
+	if err != nil {
+		t.Fatalf("later request: %v", err)
+	}
+
+	// Sending the first request again must not queue its rate again
+	if _, err := vm.SetCommission(address, 100, now, lower); !errors.Is(err, consensus.ErrCommissionReplayed) {
+		t.Fatalf("replayed request: err %v, want %v", err, consensus.ErrCommissionReplayed)
+	}
+	queued := 0
+	for _, tx := range bc.GetPendingTransactions() {
+		if tx.Type == blockchain.ValidatorCommissionTxType {
+			queued++
+		}
+	}
+	if queued != 2 || first.ID == later.ID {
+		t.Fatalf("%d commission transactions queued as %s and %s, want two distinct ones", queued, first.ID, later.ID)
+	}
+	if err := bc.VerifyCommissionTransaction(later); err != nil {
+		t.Fatalf("later request does not verify for the next block: %v", err)
+	}
+}
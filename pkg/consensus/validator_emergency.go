@@ -0,0 +1,107 @@
+package consensus
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// EmergencyStatus reports whether the active validator set has fallen below
+// the configured minimum. While it is active, admin approvals join the active
+// set at once, add_validator proposals are fast-tracked and block production
+// lets any active validator take over turns of inactive ones.
+type EmergencyStatus struct {
+	Active           bool       `json:"active"`
+	ActiveValidators int        `json:"activeValidators"`
+	MinValidators    int        `json:"minValidators"`   // 0 disables the safeguard
+	Since            *time.Time `json:"since,omitempty"` // when emergency mode was entered
+}
+
+// emergencyState tracks the emergency mode of a ValidatorManager. It has its
+// own lock so the check can run while vm.mutex is held.
+type emergencyState struct {
+	mu        sync.Mutex
+	status    EmergencyStatus
+	listeners []func(EmergencyStatus)
+}
+
+// SetMinValidators sets the active validator count below which the node enters
+// emergency mode. 0 disables the safeguard.
+func (vm *ValidatorManager) SetMinValidators(n int) {
+	if n < 0 {
+		n = 0
+	}
+	vm.emergency.mu.Lock()
+	vm.emergency.status.MinValidators = n
+	vm.emergency.mu.Unlock()
+	vm.CheckEmergency()
+}
+
+// OnEmergencyChange registers fn to be called whenever the node enters or
+// leaves emergency mode
+func (vm *ValidatorManager) OnEmergencyChange(fn func(EmergencyStatus)) {
+	vm.emergency.mu.Lock()
+	defer vm.emergency.mu.Unlock()
+	vm.emergency.listeners = append(vm.emergency.listeners, fn)
+}
+
+// EmergencyStatus returns the emergency state as of the last check
+func (vm *ValidatorManager) EmergencyStatus() EmergencyStatus {
+	vm.emergency.mu.Lock()
+	defer vm.emergency.mu.Unlock()
+	return vm.emergency.status
+}
+
+// InEmergency reports whether the node is in emergency mode
+func (vm *ValidatorManager) InEmergency() bool {
+	return vm.EmergencyStatus().Active
+}
+
+// CheckEmergency counts the validators in the active set, enters or leaves
+// emergency mode accordingly and returns the resulting status
+func (vm *ValidatorManager) CheckEmergency() EmergencyStatus {
+	active := len(vm.blockchain.GetValidators())
+
+	vm.emergency.mu.Lock()
+	status := &vm.emergency.status
+	status.ActiveValidators = active
+	entered := status.MinValidators > 0 && active < status.MinValidators
+	if entered == status.Active {
+		current := *status
+		vm.emergency.mu.Unlock()
+		return current
+	}
+
+	status.Active = entered
+	if entered {
+		now := time.Now()
+		status.Since = &now
+		log.Printf("EMERGENCY MODE: %d active validators, below the minimum of %d; "+
+			"admin approvals and add_validator proposals are fast-tracked", active, status.MinValidators)
+		if active == 0 {
+			log.Printf("EMERGENCY MODE: no active validators, block production is halted until one is approved")
+		}
+	} else {
+		status.Since = nil
+		log.Printf("Emergency mode cleared: %d active validators (minimum %d)", active, status.MinValidators)
+	}
+	current := *status
+	listeners := append([]func(EmergencyStatus){}, vm.emergency.listeners...)
+	vm.emergency.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(current)
+	}
+	return current
+}
+
+// StartEmergencyMonitor periodically re-checks the active validator count, so
+// validators leaving the set through exits, slashing or governance are noticed
+func (vm *ValidatorManager) StartEmergencyMonitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			vm.CheckEmergency()
+		}
+	}()
+}
@@ -0,0 +1,44 @@
+package consensus
+
+import (
+	"log"
+	"time"
+)
+
+// ProcessEvidence suspends validators with double-signing evidence recorded on chain.
+// The chain already removed them from the validator set when the evidence was included;
+// this brings the manager's view in line. It returns the addresses suspended by this call.
+func (vm *ValidatorManager) ProcessEvidence() []string {
+	records := vm.blockchain.GetEvidence()
+
+	vm.mutex.Lock()
+	defer vm.mutex.Unlock()
+
+	var suspended []string
+	for _, record := range records {
+		validator, exists := vm.validators[record.Validator]
+		if !exists {
+			continue
+		}
+		if validator.Status != StatusApproved && validator.Status != StatusExiting {
+			continue
+		}
+
+		validator.Status = StatusSuspended
+		vm.recordStatus(record.Validator, StatusSuspended, map[string]string{"reason": "double-signing", "evidence": record.TxID})
+		suspended = append(suspended, record.Validator)
+		log.Printf("Validator suspended: %s - Reason: double-signing at height %d (evidence tx %s in block %d)",
+			record.Validator, record.Height, record.TxID, record.BlockIndex)
+	}
+	return suspended
+}
+
+// StartEvidenceProcessor periodically applies recorded double-signing evidence
+func (vm *ValidatorManager) StartEvidenceProcessor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			vm.ProcessEvidence()
+		}
+	}()
+}
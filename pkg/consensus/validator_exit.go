@@ -0,0 +1,145 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// ActionValidatorExit is the action a validator signs to request leaving the validator set.
+// The signed message is "validator_exit:<address>:<timestamp>".
+const ActionValidatorExit = "validator_exit"
+
+// ExitConfig controls when exiting validators leave the active set
+type ExitConfig struct {
+	EpochLength    uint64        // blocks per epoch; validators are removed at epoch boundaries
+	CooldownEpochs uint64        // full epochs a validator keeps validating after requesting exit
+	RequestMaxAge  time.Duration // how old a signed exit request may be
+}
+
+// DefaultExitConfig returns the default exit rules
+func DefaultExitConfig() *ExitConfig {
+	return &ExitConfig{
+		EpochLength:    100,
+		CooldownEpochs: 1,
+		RequestMaxAge:  5 * time.Minute,
+	}
+}
+
+// SetExitConfig replaces the exit rules
+func (vm *ValidatorManager) SetExitConfig(config *ExitConfig) {
+	vm.mutex.Lock()
+	defer vm.mutex.Unlock()
+
+	if config == nil {
+		config = DefaultExitConfig()
+	}
+	if config.EpochLength == 0 {
+		config.EpochLength = 1
+	}
+	vm.exitConfig = config
+}
+
+// epochLength returns the number of blocks per epoch
+func (vm *ValidatorManager) epochLength() uint64 {
+	vm.mutex.RLock()
+	defer vm.mutex.RUnlock()
+	return vm.exitConfig.EpochLength
+}
+
+// ExitMessage returns the message a validator signs to request an exit
+func ExitMessage(address string, timestamp int64) string {
+	return fmt.Sprintf("%s:%s:%d", ActionValidatorExit, address, timestamp)
+}
+
+// RequestExit starts a self-initiated exit. The request must be signed with the validator's key.
+// The validator keeps validating during the cool-down and is removed at the start of its exit epoch.
+func (vm *ValidatorManager) RequestExit(address string, timestamp int64, signature string) (*ValidatorInfo, error) {
+	vm.mutex.RLock()
+	config := vm.exitConfig
+	vm.mutex.RUnlock()
+
+	// Verify the request is fresh and signed by the validator itself
+	if age := time.Since(time.Unix(timestamp, 0)); age > config.RequestMaxAge || age < -config.RequestMaxAge {
+		return nil, errors.New("exit request expired")
+	}
+
+	keyPair, exists := vm.blockchain.GetKeyPair(address)
+	if !exists {
+		return nil, fmt.Errorf("%w: validator %s", blockchain.ErrKeyPairNotFound, address)
+	}
+
+	valid, err := vm.blockchain.VerifySignature(ExitMessage(address, timestamp), signature, keyPair.Public())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", blockchain.ErrInvalidSignature, err)
+	}
+	if !valid {
+		return nil, blockchain.ErrInvalidSignature
+	}
+
+	vm.mutex.Lock()
+	defer vm.mutex.Unlock()
+
+	validator, exists := vm.validators[address]
+	if !exists {
+		return nil, fmt.Errorf("%w: validator not found", blockchain.ErrUnknownValidator)
+	}
+	if validator.Status != StatusApproved {
+		return nil, fmt.Errorf("validator is not active (current status: %s)", validator.Status)
+	}
+
+	currentEpoch := vm.blockchain.GetChainHeight() / config.EpochLength
+	validator.Status = StatusExiting
+	validator.ExitRequestedAt = time.Now()
+	validator.ExitEpoch = currentEpoch + config.CooldownEpochs + 1
+	vm.recordStatus(address, StatusExiting, map[string]string{"exitEpoch": strconv.FormatUint(validator.ExitEpoch, 10)})
+
+	log.Printf("Validator %s requested exit; leaving the active set at epoch %d (block %d)",
+		address, validator.ExitEpoch, validator.ExitEpoch*config.EpochLength)
+
+	copied := *validator
+	return &copied, nil
+}
+
+// ProcessExits removes exiting validators whose exit epoch has started and releases their stake.
+// It returns the addresses that left the active set.
+func (vm *ValidatorManager) ProcessExits(height uint64) []string {
+	vm.mutex.Lock()
+	defer vm.mutex.Unlock()
+
+	var exited []string
+	for address, validator := range vm.validators {
+		if validator.Status != StatusExiting || height < validator.ExitEpoch*vm.exitConfig.EpochLength {
+			continue
+		}
+
+		if err := vm.blockchain.RemoveValidator(address); err != nil && !errors.Is(err, blockchain.ErrUnknownValidator) {
+			log.Printf("Failed to remove exiting validator %s: %v", address, err)
+			continue
+		}
+
+		if _, err := vm.releaseStake(validator); err != nil {
+			log.Printf("Warning: Validator %s exited but its stake could not be released: %v", address, err)
+		}
+
+		validator.Status = StatusExited
+		vm.recordStatus(address, StatusExited, nil)
+		exited = append(exited, address)
+		log.Printf("Validator exited: %s at height %d", address, height)
+	}
+	return exited
+}
+
+// StartExitProcessor periodically removes validators that reached their exit epoch
+func (vm *ValidatorManager) StartExitProcessor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			vm.ProcessExits(vm.blockchain.GetChainHeight())
+		}
+	}()
+}
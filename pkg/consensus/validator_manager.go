@@ -3,6 +3,7 @@ package consensus
 import (
 	"errors"
 	"log"
+	"math/big"
 	"sync"
 	"time"
 	"fmt"
@@ -19,6 +20,8 @@ const (
 	StatusApproved  ValidatorStatus = "approved"  // Approved by admin or governance
 	StatusRejected  ValidatorStatus = "rejected"  // Rejected
 	StatusSuspended ValidatorStatus = "suspended" // Temporarily suspended
+	StatusExiting   ValidatorStatus = "exiting"   // Requested exit, still validating during cool-down
+	StatusExited    ValidatorStatus = "exited"    // Left the validator set
 )
 
 // ValidatorInfo contains validator information
@@ -31,6 +34,11 @@ type ValidatorInfo struct {
 	PerformanceScore float64    // 0-100 score based on performance metrics
 	TotalBlocks uint64          // Total blocks produced
 	LastActive  time.Time       // Last activity timestamp
+	Stake       *big.Int        // Balance locked when the validator was approved
+	Slashed     *big.Int        // Part of the stake burned as a penalty
+	ExitRequestedAt time.Time   // When the validator asked to leave
+	ExitEpoch   uint64          // Epoch at whose start the validator leaves the active set
+	QuotaViolations uint64      // Blocks proposed past the validator's quota of an epoch
 }
 
 // ValidationMode defines how validators are approved
@@ -54,6 +62,9 @@ type ValidatorManager struct {
 	externalVerifier *ExternalPoHVerifier
 	useExternalPoh   bool
 	admins           map[string]bool
+	minStake         *big.Int // Stake locked on approval; nil disables staking
+	exitConfig       *ExitConfig
+	emergency        emergencyState // Minimum validator count safeguard
 }
 
 // NewValidatorManager creates a new validator manager
@@ -70,6 +81,7 @@ func NewValidatorManager(bc *blockchain.Blockchain, initialAdmins []string, mode
 		mode:           mode,
 		pohVerifier:    NewProofOfHumanity(30 * 24 * time.Hour), // 30 days expiration
 		admins:         make(map[string]bool),
+		exitConfig:     DefaultExitConfig(),
 	}
 	
 	// Initialize with existing validators from blockchain
@@ -86,6 +98,15 @@ func NewValidatorManager(bc *blockchain.Blockchain, initialAdmins []string, mode
 		}
 	}
 	
+	// Blocks proposed past a validator's quota count against its score
+	bc.OnEvent(vm.penalizeQuotaViolation, blockchain.EventBlockQuotaExceeded)
+	
+	// Stake burned by confirmed slashes and evidence counts against the validator
+	bc.OnEvent(vm.recordSlash, blockchain.EventValidatorSlashed)
+	
+	// The key audit must keep the keys of admins, which only this manager knows
+	bc.RegisterKeyUse(blockchain.KeyUseAdmin, vm.IsAdmin)
+	
 	return vm
 }
 
@@ -207,7 +228,7 @@ func (vm *ValidatorManager) RegisterValidator(address, humanProof string) error
 	
 	// Check if already registered
 	if _, exists := vm.validators[address]; exists {
-		return errors.New("validator already registered")
+		return fmt.Errorf("%w: validator already registered", blockchain.ErrValidatorExists)
 	}
 	
 	// Create new validator with pending status
@@ -222,6 +243,10 @@ func (vm *ValidatorManager) RegisterValidator(address, humanProof string) error
 	
 	// If automatic mode, approve immediately
 	if vm.mode == ModeAutomatic {
+		if err := vm.lockStake(validator); err != nil {
+			return err
+		}
+		
 		validator.Status = StatusApproved
 		validator.JoinedAt = time.Now()
 		validator.ApprovedBy = "automatic"
@@ -229,6 +254,7 @@ func (vm *ValidatorManager) RegisterValidator(address, humanProof string) error
 		
 		// Register with blockchain
 		if err := vm.blockchain.RegisterValidator(address, humanProof); err != nil {
+			vm.releaseStake(validator)
 			return fmt.Errorf("blockchain registration failed: %v", err)
 		}
 	}
@@ -248,7 +274,7 @@ func (vm *ValidatorManager) ApproveValidator(adminAddress, validatorAddress stri
 	// Check if validator exists
 	validator, exists := vm.validators[validatorAddress]
 	if !exists {
-		return fmt.Errorf("validator %s not found", validatorAddress)
+		return fmt.Errorf("%w: validator %s not found", blockchain.ErrUnknownValidator, validatorAddress)
 	}
 
 	// Check if validator is already approved
@@ -256,11 +282,28 @@ func (vm *ValidatorManager) ApproveValidator(adminAddress, validatorAddress stri
 		return fmt.Errorf("validator %s is already approved", validatorAddress)
 	}
 
+	// Lock the required stake before the validator becomes active
+	if err := vm.lockStake(validator); err != nil {
+		return err
+	}
+
 	// Update validator status
 	validator.Status = StatusApproved
 	validator.ApprovedBy = adminAddress
 	validator.JoinedAt = time.Now()
 
+	// In emergency mode the approval is fast-tracked into the active set
+	// instead of waiting for the validator to join it
+	if vm.InEmergency() {
+		if err := vm.blockchain.RegisterValidator(validatorAddress, validator.HumanProof); err != nil && !errors.Is(err, blockchain.ErrValidatorExists) {
+			return fmt.Errorf("failed to add validator to the active set: %v", err)
+		}
+		log.Printf("Emergency mode: validator %s fast-tracked into the active set (by %s)", validatorAddress, adminAddress)
+		vm.CheckEmergency()
+	}
+
+	vm.recordStatus(validatorAddress, StatusApproved, map[string]string{"by": adminAddress})
+
 	// Save to blockchain
 	if err := vm.blockchain.SaveToDisk(); err != nil {
 		return fmt.Errorf("failed to save validator status: %v", err)
@@ -286,7 +329,7 @@ func (vm *ValidatorManager) SuspendValidator(requesterAddress, validatorAddress,
 	// Check if validator exists and is approved
 	validator, exists := vm.validators[validatorAddress]
 	if !exists {
-		return errors.New("validator not found")
+		return fmt.Errorf("%w: validator not found", blockchain.ErrUnknownValidator)
 	}
 	
 	if validator.Status != StatusApproved {
@@ -302,10 +345,24 @@ func (vm *ValidatorManager) SuspendValidator(requesterAddress, validatorAddress,
 		return fmt.Errorf("failed to remove validator from blockchain: %v", err)
 	}
 	
+	vm.recordStatus(validatorAddress, StatusSuspended, map[string]string{"by": requesterAddress, "reason": reason})
 	log.Printf("Validator suspended: %s (by %s) - Reason: %s", validatorAddress, requesterAddress, reason)
+	vm.CheckEmergency()
 	return nil
 }
 
+// GetValidator returns a copy of a validator's record
+func (vm *ValidatorManager) GetValidator(address string) (ValidatorInfo, bool) {
+	vm.mutex.RLock()
+	defer vm.mutex.RUnlock()
+	
+	validator, exists := vm.validators[address]
+	if !exists {
+		return ValidatorInfo{}, false
+	}
+	return *validator, true
+}
+
 // GetValidators returns all validators with optional filtering
 func (vm *ValidatorManager) GetValidators(statusFilter ...ValidatorStatus) []*ValidatorInfo {
 	vm.mutex.RLock()
@@ -347,7 +404,8 @@ func (vm *ValidatorManager) IsValidator(address string) bool {
 		return false
 	}
 	
-	return validator.Status == StatusApproved
+	// Exiting validators keep validating until their exit epoch
+	return validator.Status == StatusApproved || validator.Status == StatusExiting
 }
 
 // UpdateValidatorMode changes the validator approval mode
@@ -404,7 +462,7 @@ func (vm *ValidatorManager) RejectValidator(validatorAddress, requesterAddress,
 	// Check if validator exists and is pending
 	validator, exists := vm.validators[validatorAddress]
 	if !exists {
-		return errors.New("validator not found")
+		return fmt.Errorf("%w: validator not found", blockchain.ErrUnknownValidator)
 	}
 	
 	if validator.Status != StatusPending {
@@ -413,6 +471,7 @@ func (vm *ValidatorManager) RejectValidator(validatorAddress, requesterAddress,
 	
 	// Update validator status
 	validator.Status = StatusRejected
+	vm.recordStatus(validatorAddress, StatusRejected, map[string]string{"by": requesterAddress, "reason": reason})
 	
 	log.Printf("Validator rejected: %s (by %s) - Reason: %s", validatorAddress, requesterAddress, reason)
 	return nil
@@ -426,14 +485,20 @@ func (vm *ValidatorManager) VerifySignature(req *types.SignedRequest) (bool, err
 		return false, fmt.Errorf("admin key pair not found")
 	}
 
-	// Create the message to verify
-	message := fmt.Sprintf("%s:%s:%d", req.Action, req.AdminAddress, req.Timestamp)
-	
-	// Verify the signature
-	valid, err := vm.blockchain.VerifySignature(message, req.Signature, keyPair.PublicKey)
+	// The signed message covers the request data, so it cannot be swapped
+	valid, err := vm.blockchain.VerifySignature(req.SigningMessage(), req.Signature, keyPair.Public())
 	if err != nil {
 		return false, fmt.Errorf("signature verification failed: %v", err)
 	}
 
 	return valid, nil
-} 
\ No newline at end of file
+} 
+
+// recordStatus journals a validator status change as a chain event
+func (vm *ValidatorManager) recordStatus(address string, status ValidatorStatus, data map[string]string) {
+	if data == nil {
+		data = make(map[string]string)
+	}
+	data["status"] = string(status)
+	vm.blockchain.RecordEvent(blockchain.EventValidatorStatus, address, data)
+}
@@ -0,0 +1,34 @@
+package consensus
+
+import (
+	"log"
+
+	"confirmix/pkg/blockchain"
+)
+
+// QuotaViolationPenalty is taken off a validator's performance score for
+// every block it proposes past its quota of an epoch
+const QuotaViolationPenalty = 10.0
+
+// penalizeQuotaViolation lowers the score of a validator whose block was
+// rejected for exceeding its quota. Repeated violations drive the score below
+// the auto-suspension threshold of UpdateValidatorPerformance.
+func (vm *ValidatorManager) penalizeQuotaViolation(event blockchain.ChainEvent) {
+	vm.mutex.Lock()
+	validator, exists := vm.validators[event.Subject]
+	if !exists {
+		vm.mutex.Unlock()
+		return
+	}
+	validator.QuotaViolations++
+	violations := validator.QuotaViolations
+	score := validator.PerformanceScore - QuotaViolationPenalty
+	vm.mutex.Unlock()
+
+	if score < 0 {
+		score = 0
+	}
+	log.Printf("Validator %s exceeded its block quota at block %s (%d violations); performance score lowered to %.2f",
+		event.Subject, event.Data["blockIndex"], violations, score)
+	vm.UpdateValidatorPerformance(event.Subject, score)
+}
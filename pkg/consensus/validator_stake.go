@@ -0,0 +1,137 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+
+	"confirmix/pkg/blockchain"
+)
+
+// SetMinStake sets the balance a validator must lock when it is approved.
+// A nil or zero amount disables the stake requirement.
+func (vm *ValidatorManager) SetMinStake(amount *big.Int) {
+	vm.mutex.Lock()
+	defer vm.mutex.Unlock()
+
+	if amount == nil || amount.Sign() <= 0 {
+		vm.minStake = nil
+		return
+	}
+	vm.minStake = new(big.Int).Set(amount)
+}
+
+// MinStake returns the stake required from new validators, or zero when staking is disabled
+func (vm *ValidatorManager) MinStake() *big.Int {
+	vm.mutex.RLock()
+	defer vm.mutex.RUnlock()
+
+	if vm.minStake == nil {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(vm.minStake)
+}
+
+// lockStake locks the minimum stake from the validator's balance (reusing Blockchain.Lock).
+// It is a no-op when no stake is required or the validator already has a stake.
+func (vm *ValidatorManager) lockStake(validator *ValidatorInfo) error {
+	if vm.minStake == nil || validator.Stake != nil && validator.Stake.Sign() > 0 {
+		return nil
+	}
+
+	if err := vm.blockchain.Lock(validator.Address, vm.minStake); err != nil {
+		return fmt.Errorf("failed to lock validator stake of %s: %w", vm.minStake.String(), err)
+	}
+
+	validator.Stake = new(big.Int).Set(vm.minStake)
+	validator.Slashed = big.NewInt(0)
+	log.Printf("Locked stake of %s for validator %s", vm.minStake.String(), validator.Address)
+	return nil
+}
+
+// releaseStake unlocks what is left of a validator's stake after slashing and returns the amount released
+func (vm *ValidatorManager) releaseStake(validator *ValidatorInfo) (*big.Int, error) {
+	remaining := validator.RemainingStake()
+	if remaining.Sign() > 0 {
+		if err := vm.blockchain.Unlock(validator.Address, remaining); err != nil {
+			return nil, fmt.Errorf("failed to release validator stake: %w", err)
+		}
+	}
+
+	validator.Stake = big.NewInt(0)
+	log.Printf("Released stake of %s for validator %s (slashed: %s)", remaining.String(), validator.Address, validator.Slashed)
+	return remaining, nil
+}
+
+// RemainingStake returns the part of the stake that has not been slashed
+func (v *ValidatorInfo) RemainingStake() *big.Int {
+	if v.Stake == nil {
+		return big.NewInt(0)
+	}
+	remaining := new(big.Int).Set(v.Stake)
+	if v.Slashed != nil {
+		remaining.Sub(remaining, v.Slashed)
+	}
+	if remaining.Sign() < 0 {
+		return big.NewInt(0)
+	}
+	return remaining
+}
+
+// SlashValidator queues a transaction burning part of a validator's stake,
+// capped at the remaining stake. The stake is burned on every node once the
+// transaction is confirmed, and recordSlash then updates the validator.
+func (vm *ValidatorManager) SlashValidator(requesterAddress, validatorAddress string, amount *big.Int, reason string) (*blockchain.Transaction, error) {
+	vm.mutex.Lock()
+	defer vm.mutex.Unlock()
+
+	if !vm.adminAddresses[requesterAddress] {
+		return nil, errors.New("only admins can slash validators")
+	}
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, errors.New("slash amount must be positive")
+	}
+
+	validator, exists := vm.validators[validatorAddress]
+	if !exists {
+		return nil, fmt.Errorf("%w: validator not found", blockchain.ErrUnknownValidator)
+	}
+
+	remaining := validator.RemainingStake()
+	if remaining.Sign() == 0 {
+		return nil, fmt.Errorf("%w: validator %s has no stake to slash", blockchain.ErrInsufficientLocked, validatorAddress)
+	}
+	if amount.Cmp(remaining) > 0 {
+		amount = remaining
+	}
+
+	tx, err := vm.blockchain.ProposeSlash(validatorAddress, amount, reason, requesterAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Validator slash queued: %s by %s (by %s, transaction %s) - Reason: %s", validatorAddress, amount.String(), requesterAddress, tx.ID, reason)
+	return tx, nil
+}
+
+// recordSlash adds a stake burn confirmed on chain to the validator's slashed amount
+func (vm *ValidatorManager) recordSlash(event blockchain.ChainEvent) {
+	amount, ok := new(big.Int).SetString(event.Data["amount"], 10)
+	if !ok {
+		return
+	}
+
+	vm.mutex.Lock()
+	defer vm.mutex.Unlock()
+
+	validator, exists := vm.validators[event.Subject]
+	if !exists {
+		return
+	}
+	if validator.Slashed == nil {
+		validator.Slashed = big.NewInt(0)
+	}
+	validator.Slashed = new(big.Int).Add(validator.Slashed, amount)
+	log.Printf("Validator slashed: %s by %s - Reason: %s", event.Subject, amount.String(), event.Data["reason"])
+}
@@ -0,0 +1,70 @@
+package consensussim
+
+import (
+	"container/heap"
+	"time"
+)
+
+// Clock is a virtual clock. Time only moves when the simulation runs the next
+// scheduled event, so scenarios run instantly and always in the same order.
+type Clock struct {
+	now    time.Time
+	seq    uint64
+	events eventQueue
+}
+
+// NewClock returns a clock standing at start
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the virtual time
+func (c *Clock) Now() time.Time {
+	return c.now
+}
+
+// Schedule runs fn after d of virtual time. Events due at the same time run
+// in the order they were scheduled.
+func (c *Clock) Schedule(d time.Duration, fn func()) {
+	if d < 0 {
+		d = 0
+	}
+	c.seq++
+	heap.Push(&c.events, &event{at: c.now.Add(d), seq: c.seq, fn: fn})
+}
+
+// RunUntil runs every event due up to end and leaves the clock at end
+func (c *Clock) RunUntil(end time.Time) {
+	for len(c.events) > 0 && !c.events[0].at.After(end) {
+		next := heap.Pop(&c.events).(*event)
+		c.now = next.at
+		next.fn()
+	}
+	c.now = end
+}
+
+// event is a callback scheduled on the clock
+type event struct {
+	at  time.Time
+	seq uint64
+	fn  func()
+}
+
+// eventQueue orders events by time, then by scheduling order
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at.Before(q[j].at)
+}
+func (q eventQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*event)) }
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return last
+}
@@ -0,0 +1,106 @@
+package consensussim
+
+import (
+	"math/rand"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// Message kinds, named after the pkg/network messages they stand for
+const (
+	MsgBlock        = "block"
+	MsgSyncRequest  = "sync_request"
+	MsgSyncResponse = "sync_response"
+)
+
+// Message is a message between simulated nodes
+type Message struct {
+	Kind   string
+	From   string
+	Block  *blockchain.Block   // MsgBlock
+	Blocks []*blockchain.Block // MsgSyncResponse, the sender's chain
+}
+
+// Network is an in-memory transport. Messages are delivered after a latency
+// on the virtual clock, unless the receiver is offline or on the other side
+// of a partition when the message arrives.
+type Network struct {
+	clock     *Clock
+	rng       *rand.Rand
+	latency   time.Duration
+	jitter    time.Duration
+	nodes     map[string]*Node
+	order     []string       // node addresses in creation order, for deterministic broadcasts
+	partition map[string]int // node -> group; nodes of different groups cannot reach each other
+
+	Delivered int
+	Dropped   int
+}
+
+// newNetwork returns an empty network on clock
+func newNetwork(clock *Clock, rng *rand.Rand, latency, jitter time.Duration) *Network {
+	return &Network{
+		clock:   clock,
+		rng:     rng,
+		latency: latency,
+		jitter:  jitter,
+		nodes:   make(map[string]*Node),
+	}
+}
+
+// add attaches a node to the network
+func (n *Network) add(node *Node) {
+	n.nodes[node.Address] = node
+	n.order = append(n.order, node.Address)
+}
+
+// Partition splits the network into groups of addresses. Nodes left out of
+// every group form one more group.
+func (n *Network) Partition(groups ...[]string) {
+	n.partition = make(map[string]int)
+	for i, group := range groups {
+		for _, address := range group {
+			n.partition[address] = i + 1
+		}
+	}
+}
+
+// Heal removes every partition
+func (n *Network) Heal() {
+	n.partition = nil
+}
+
+// Reachable reports whether a message from a can reach b now
+func (n *Network) Reachable(a, b string) bool {
+	if n.partition == nil {
+		return true
+	}
+	return n.partition[a] == n.partition[b]
+}
+
+// Send delivers msg to the node at address to
+func (n *Network) Send(to string, msg Message) {
+	delay := n.latency
+	if n.jitter > 0 {
+		delay += time.Duration(n.rng.Int63n(int64(n.jitter)))
+	}
+	n.clock.Schedule(delay, func() {
+		receiver, exists := n.nodes[to]
+		if !exists || !receiver.Online() || !n.Reachable(msg.From, to) {
+			n.Dropped++
+			return
+		}
+		n.Delivered++
+		receiver.receive(msg)
+	})
+}
+
+// Broadcast sends msg to every node but its sender
+func (n *Network) Broadcast(msg Message) {
+	for _, address := range n.order {
+		if address != msg.From {
+			n.Send(address, msg)
+		}
+	}
+}
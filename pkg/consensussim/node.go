@@ -0,0 +1,173 @@
+package consensussim
+
+import (
+	"fmt"
+
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/consensus"
+)
+
+// Node is a simulated validator. It follows the PoA rules of pkg/consensus:
+// round-robin turns, blocks linked with consensus.CheckBlockLink, the longest
+// valid chain wins and the first block seen at a height is kept.
+type Node struct {
+	Address string
+
+	sim        *Simulation
+	chain      []*blockchain.Block
+	online     bool
+	equivocate bool // the next own block is proposed twice, see Simulation.Equivocate
+
+	Produced   int // blocks proposed
+	Forks      int // conflicting blocks seen at a height already filled
+	Reorgs     int // times the node switched to another branch
+	MaxReorg   int // most blocks reverted by one switch
+	Rejections int // blocks refused as invalid
+}
+
+// Online reports whether the node is running
+func (n *Node) Online() bool {
+	return n.online
+}
+
+// Tip returns the last block of the node's chain
+func (n *Node) Tip() *blockchain.Block {
+	return n.chain[len(n.chain)-1]
+}
+
+// Height returns the index of the node's last block
+func (n *Node) Height() uint64 {
+	return n.Tip().Index
+}
+
+// BlockAt returns the node's block at height, if it has one
+func (n *Node) BlockAt(height uint64) (*blockchain.Block, bool) {
+	if height >= uint64(len(n.chain)) {
+		return nil, false
+	}
+	return n.chain[height], true
+}
+
+// tick runs a block production turn
+func (n *Node) tick(turn uint64) {
+	if !n.online || consensus.RoundRobinProposer(n.sim.validators, turn) != n.Address {
+		return
+	}
+
+	block := n.propose(turn, "")
+	if !n.equivocate {
+		n.Produced++
+		n.chain = append(n.chain, block)
+		n.sim.network.Broadcast(Message{Kind: MsgBlock, From: n.Address, Block: block})
+		return
+	}
+
+	// Send one block to half of the peers and a conflicting one to the rest
+	n.equivocate = false
+	twin := n.propose(turn, "twin")
+	n.Produced += 2
+	n.chain = append(n.chain, block)
+	n.sim.logf("%s equivocates at height %d: %.12s / %.12s", n.Address, block.Index, block.Hash, twin.Hash)
+	peers := make([]string, 0, len(n.sim.network.order))
+	for _, address := range n.sim.network.order {
+		if address != n.Address {
+			peers = append(peers, address)
+		}
+	}
+	for i, address := range peers {
+		msg := Message{Kind: MsgBlock, From: n.Address, Block: block}
+		if i >= len(peers)/2 {
+			msg.Block = twin
+		}
+		n.sim.network.Send(address, msg)
+	}
+}
+
+// propose builds a block for turn on top of the node's chain. variant makes a
+// conflicting block for the same turn.
+func (n *Node) propose(turn uint64, variant string) *blockchain.Block {
+	tip := n.Tip()
+	tx := &blockchain.Transaction{
+		ID:        fmt.Sprintf("%s-%d%s", n.Address, turn, variant),
+		From:      n.Address,
+		To:        n.Address,
+		Timestamp: n.sim.turnTime(turn).Unix(),
+		Type:      "regular",
+	}
+	block := &blockchain.Block{
+		Index:        tip.Index + 1,
+		Timestamp:    n.sim.turnTime(turn).Unix(),
+		Transactions: []*blockchain.Transaction{tx},
+		PrevHash:     tip.Hash,
+		Validator:    n.Address,
+		HumanProof:   "sim-proof-" + n.Address,
+	}
+	block.Hash = block.CalculateHash()
+	return block
+}
+
+// receive handles a message from the network
+func (n *Node) receive(msg Message) {
+	switch msg.Kind {
+	case MsgBlock:
+		n.receiveBlock(msg.From, msg.Block)
+	case MsgSyncRequest:
+		blocks := make([]*blockchain.Block, len(n.chain))
+		copy(blocks, n.chain)
+		n.sim.network.Send(msg.From, Message{Kind: MsgSyncResponse, From: n.Address, Blocks: blocks})
+	case MsgSyncResponse:
+		n.adopt(msg.Blocks)
+	}
+}
+
+// receiveBlock appends a block extending the tip and relays it. Blocks at a
+// filled height are counted as forks; blocks that do not link to the tip
+// make the node sync with the sender.
+func (n *Node) receiveBlock(from string, block *blockchain.Block) {
+	if known, exists := n.BlockAt(block.Index); exists {
+		if known.Hash != block.Hash {
+			n.Forks++
+			n.sim.forks++
+		}
+		return
+	}
+	if err := n.sim.checkProposer(block); err != nil {
+		n.Rejections++
+		return
+	}
+	if consensus.CheckBlockLink(block, n.Tip()) != nil {
+		n.sim.network.Send(from, Message{Kind: MsgSyncRequest, From: n.Address})
+		return
+	}
+
+	n.chain = append(n.chain, block)
+	n.sim.network.Broadcast(Message{Kind: MsgBlock, From: n.Address, Block: block})
+}
+
+// adopt switches to blocks when they form a longer valid chain from the same genesis
+func (n *Node) adopt(blocks []*blockchain.Block) {
+	if len(blocks) <= len(n.chain) || blocks[0].Hash != n.chain[0].Hash {
+		return
+	}
+	for i := 1; i < len(blocks); i++ {
+		if consensus.CheckBlockLink(blocks[i], blocks[i-1]) != nil || n.sim.checkProposer(blocks[i]) != nil {
+			n.Rejections++
+			return
+		}
+	}
+
+	common := 0
+	for common+1 < len(n.chain) && n.chain[common+1].Hash == blocks[common+1].Hash {
+		common++
+	}
+	if reverted := len(n.chain) - 1 - common; reverted > 0 {
+		n.Reorgs++
+		if reverted > n.MaxReorg {
+			n.MaxReorg = reverted
+		}
+		n.sim.reverted(n, reverted)
+	}
+
+	n.chain = append([]*blockchain.Block(nil), blocks...)
+	n.sim.network.Broadcast(Message{Kind: MsgBlock, From: n.Address, Block: n.Tip()})
+}
@@ -0,0 +1,92 @@
+package consensussim
+
+import (
+	"time"
+)
+
+// Scenarios returns the built-in scenarios
+func Scenarios() []Scenario {
+	return []Scenario{
+		{
+			Name:          "validator-offline",
+			Description:   "one of four validators goes offline for two minutes; the others keep producing and it catches up after restarting",
+			Validators:    4,
+			BlockTime:     5 * time.Second,
+			Latency:       200 * time.Millisecond,
+			Jitter:        100 * time.Millisecond,
+			Confirmations: 3,
+			Duration:      4 * time.Minute,
+			Seed:          1,
+			Steps: []Step{
+				{At: 30 * time.Second, Name: "validator-3 goes offline", Do: func(s *Simulation) {
+					s.Offline(3)
+					s.Mark("offline")
+				}},
+				// 24 turns, 18 of them owned by the remaining validators
+				{At: 2*time.Minute + 30*time.Second, Name: "check progress without validator-3", Do: func(s *Simulation) {
+					s.ExpectGrowth("offline", 16)
+				}},
+				{At: 2*time.Minute + 30*time.Second + time.Millisecond, Name: "validator-3 comes back", Do: func(s *Simulation) {
+					s.Online(3)
+				}},
+				{At: 4 * time.Minute, Name: "check convergence", Do: func(s *Simulation) {
+					s.ExpectConverged()
+				}},
+			},
+		},
+		{
+			Name:          "double-proposal",
+			Description:   "a validator proposes two conflicting blocks at one height; the fork is detected and resolved within the confirmation depth",
+			Validators:    4,
+			BlockTime:     5 * time.Second,
+			Latency:       200 * time.Millisecond,
+			Jitter:        300 * time.Millisecond,
+			Confirmations: 3,
+			Duration:      2 * time.Minute,
+			Seed:          2,
+			Steps: []Step{
+				{At: 22 * time.Second, Name: "validator-1 turns byzantine", Do: func(s *Simulation) {
+					s.Equivocate(1)
+				}},
+				{At: 2 * time.Minute, Name: "check fork detection and convergence", Do: func(s *Simulation) {
+					s.ExpectForks(1)
+					s.ExpectConverged()
+				}},
+			},
+		},
+		{
+			Name:          "partition-heal",
+			Description:   "five validators split 3/2 for thirty seconds; after healing the minority adopts the majority chain without reverting confirmed blocks",
+			Validators:    5,
+			BlockTime:     5 * time.Second,
+			Latency:       200 * time.Millisecond,
+			Jitter:        100 * time.Millisecond,
+			Confirmations: 6,
+			Duration:      3 * time.Minute,
+			Seed:          3,
+			Steps: []Step{
+				{At: 31 * time.Second, Name: "partition {0,1,2} | {3,4}", Do: func(s *Simulation) {
+					s.Partition([]int{0, 1, 2}, []int{3, 4})
+					s.Mark("partition")
+				}},
+				{At: 61 * time.Second, Name: "heal", Do: func(s *Simulation) {
+					s.ExpectGrowth("partition", 3)
+					s.Heal()
+				}},
+				{At: 3 * time.Minute, Name: "check convergence", Do: func(s *Simulation) {
+					s.ExpectConverged()
+				}},
+			},
+		},
+	}
+}
+
+// Lookup returns the built-in scenario called name
+func Lookup(name string) (Scenario, bool) {
+	for _, scenario := range Scenarios() {
+		if scenario.Name == name {
+			return scenario, true
+		}
+	}
+	return Scenario{}, false
+}
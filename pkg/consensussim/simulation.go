@@ -0,0 +1,358 @@
+// Package consensussim is a deterministic simulation harness for the PoA
+// consensus of pkg/consensus. Validators run on a virtual clock and talk over
+// an in-memory transport, so scenarios such as a validator going offline, two
+// proposals at one height or a network partition can be scripted and checked
+// for safety and liveness without sleeps or sockets.
+package consensussim
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"confirmix/pkg/blockchain"
+	"confirmix/pkg/consensus"
+)
+
+// Properties a violation is reported against
+const (
+	PropertySafety   = "safety"
+	PropertyLiveness = "liveness"
+)
+
+// simEpoch is the virtual start time of every simulation, fixed so that
+// block hashes are the same on every run
+var simEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Step is a scripted action of a scenario, run at an offset from the start
+type Step struct {
+	At   time.Duration
+	Name string
+	Do   func(s *Simulation)
+}
+
+// Scenario describes a simulation run
+type Scenario struct {
+	Name        string
+	Description string
+	Validators  int
+	BlockTime   time.Duration // whole seconds, as block timestamps are
+	Latency     time.Duration
+	Jitter      time.Duration
+	// Confirmations is the depth after which a block must never be reverted
+	Confirmations int
+	Duration      time.Duration
+	Seed          int64
+	Steps         []Step
+}
+
+// Violation is a broken safety or liveness property
+type Violation struct {
+	At       time.Duration `json:"at"`
+	Property string        `json:"property"`
+	Detail   string        `json:"detail"`
+}
+
+// NodeResult is the final state of a simulated node
+type NodeResult struct {
+	Address  string `json:"address"`
+	Online   bool   `json:"online"`
+	Height   uint64 `json:"height"`
+	Tip      string `json:"tip"`
+	Produced int    `json:"produced"`
+	Forks    int    `json:"forks"`
+	Reorgs   int    `json:"reorgs"`
+	MaxReorg int    `json:"maxReorg"`
+}
+
+// Result is the outcome of a simulation run
+type Result struct {
+	Scenario   string        `json:"scenario"`
+	Duration   time.Duration `json:"duration"`
+	Nodes      []NodeResult  `json:"nodes"`
+	Forks      int           `json:"forks"`
+	Delivered  int           `json:"delivered"`
+	Dropped    int           `json:"dropped"`
+	Violations []Violation   `json:"violations"`
+}
+
+// Passed reports whether no property was violated
+func (r *Result) Passed() bool {
+	return len(r.Violations) == 0
+}
+
+// Simulation runs a scenario
+type Simulation struct {
+	scenario   Scenario
+	clock      *Clock
+	network    *Network
+	nodes      []*Node
+	validators []string
+	marks      map[string]uint64
+	forks      int
+	violations []Violation
+
+	// Logf receives a trace of the run when set
+	Logf func(format string, args ...interface{})
+}
+
+// New prepares a simulation of scenario
+func New(scenario Scenario) (*Simulation, error) {
+	if scenario.Validators < 1 {
+		return nil, errors.New("a scenario needs at least one validator")
+	}
+	if scenario.BlockTime < time.Second || scenario.BlockTime%time.Second != 0 {
+		return nil, errors.New("block time must be a whole number of seconds")
+	}
+	if scenario.Duration <= 0 {
+		return nil, errors.New("scenario duration must be positive")
+	}
+
+	clock := NewClock(simEpoch)
+	s := &Simulation{
+		scenario: scenario,
+		clock:    clock,
+		network:  newNetwork(clock, rand.New(rand.NewSource(scenario.Seed)), scenario.Latency, scenario.Jitter),
+		marks:    make(map[string]uint64),
+	}
+
+	genesis := &blockchain.Block{Index: 0, Timestamp: simEpoch.Unix(), Validator: "genesis"}
+	genesis.Hash = genesis.CalculateHash()
+	for i := 0; i < scenario.Validators; i++ {
+		node := &Node{
+			Address: fmt.Sprintf("validator-%d", i),
+			sim:     s,
+			chain:   []*blockchain.Block{genesis},
+			online:  true,
+		}
+		s.nodes = append(s.nodes, node)
+		s.validators = append(s.validators, node.Address)
+		s.network.add(node)
+	}
+	return s, nil
+}
+
+// Run plays the scenario to its end and checks the final state
+func (s *Simulation) Run() *Result {
+	blockTime := s.scenario.BlockTime
+	// No turn at the very end, so the last blocks can spread before the final checks
+	for turn := uint64(1); time.Duration(turn)*blockTime < s.scenario.Duration; turn++ {
+		turn := turn
+		s.clock.Schedule(time.Duration(turn)*blockTime, func() {
+			for _, node := range s.nodes {
+				node.tick(turn)
+			}
+		})
+	}
+	for _, step := range s.scenario.Steps {
+		step := step
+		s.clock.Schedule(step.At, func() {
+			s.logf("step: %s", step.Name)
+			step.Do(s)
+		})
+	}
+
+	s.clock.RunUntil(simEpoch.Add(s.scenario.Duration))
+	s.checkConfirmedAgreement()
+
+	result := &Result{
+		Scenario:   s.scenario.Name,
+		Duration:   s.scenario.Duration,
+		Forks:      s.forks,
+		Delivered:  s.network.Delivered,
+		Dropped:    s.network.Dropped,
+		Violations: s.violations,
+	}
+	for _, node := range s.nodes {
+		result.Nodes = append(result.Nodes, NodeResult{
+			Address:  node.Address,
+			Online:   node.online,
+			Height:   node.Height(),
+			Tip:      node.Tip().Hash,
+			Produced: node.Produced,
+			Forks:    node.Forks,
+			Reorgs:   node.Reorgs,
+			MaxReorg: node.MaxReorg,
+		})
+	}
+	if result.Violations == nil {
+		result.Violations = []Violation{}
+	}
+	return result
+}
+
+// Elapsed returns the virtual time since the start of the run
+func (s *Simulation) Elapsed() time.Duration {
+	return s.clock.Now().Sub(simEpoch)
+}
+
+// Node returns the i-th validator
+func (s *Simulation) Node(i int) *Node {
+	return s.nodes[i]
+}
+
+// Offline stops the given validators: they neither produce nor receive
+func (s *Simulation) Offline(indexes ...int) {
+	for _, i := range indexes {
+		s.nodes[i].online = false
+	}
+}
+
+// Online restarts the given validators with the chain they had
+func (s *Simulation) Online(indexes ...int) {
+	for _, i := range indexes {
+		s.nodes[i].online = true
+	}
+}
+
+// Partition splits the validators into groups that cannot reach each other
+func (s *Simulation) Partition(groups ...[]int) {
+	addressGroups := make([][]string, len(groups))
+	for g, group := range groups {
+		for _, i := range group {
+			addressGroups[g] = append(addressGroups[g], s.nodes[i].Address)
+		}
+	}
+	s.network.Partition(addressGroups...)
+}
+
+// Heal removes every partition
+func (s *Simulation) Heal() {
+	s.network.Heal()
+}
+
+// Equivocate makes validator i propose two conflicting blocks on its next turn
+func (s *Simulation) Equivocate(i int) {
+	s.nodes[i].equivocate = true
+}
+
+// Mark remembers the highest chain among online validators under name
+func (s *Simulation) Mark(name string) {
+	s.marks[name] = s.highest()
+}
+
+// ExpectGrowth is a liveness check: the highest chain among online validators
+// grew by at least blocks since Mark(name)
+func (s *Simulation) ExpectGrowth(name string, blocks uint64) {
+	if grown := s.highest() - s.marks[name]; grown < blocks {
+		s.violate(PropertyLiveness, "chain grew %d blocks since %q, expected at least %d", grown, name, blocks)
+	}
+}
+
+// ExpectConverged is a liveness check: every online validator has the same tip
+func (s *Simulation) ExpectConverged() {
+	var tip *blockchain.Block
+	for _, node := range s.nodes {
+		if !node.online {
+			continue
+		}
+		if tip == nil {
+			tip = node.Tip()
+		} else if node.Tip().Hash != tip.Hash {
+			s.violate(PropertyLiveness, "validators did not converge: %s at %d (%.12s), %s at %d (%.12s)",
+				s.firstOnline().Address, tip.Index, tip.Hash, node.Address, node.Height(), node.Tip().Hash)
+			return
+		}
+	}
+}
+
+// ExpectForks checks that validators saw at least n conflicting blocks, for
+// scenarios that inject them
+func (s *Simulation) ExpectForks(n int) {
+	if s.forks < n {
+		s.violate(PropertySafety, "%d conflicting blocks detected, expected at least %d", s.forks, n)
+	}
+}
+
+// highest returns the height of the longest chain among online validators
+func (s *Simulation) highest() uint64 {
+	var height uint64
+	for _, node := range s.nodes {
+		if node.online && node.Height() > height {
+			height = node.Height()
+		}
+	}
+	return height
+}
+
+// firstOnline returns the first online validator
+func (s *Simulation) firstOnline() *Node {
+	for _, node := range s.nodes {
+		if node.online {
+			return node
+		}
+	}
+	return s.nodes[0]
+}
+
+// checkProposer checks that block was proposed by the owner of its turn
+func (s *Simulation) checkProposer(block *blockchain.Block) error {
+	turn := uint64(block.Timestamp-simEpoch.Unix()) / uint64(s.scenario.BlockTime/time.Second)
+	if owner := consensus.RoundRobinProposer(s.validators, turn); owner != block.Validator {
+		return fmt.Errorf("block %d proposed by %s in the turn of %s", block.Index, block.Validator, owner)
+	}
+	return nil
+}
+
+// turnTime returns the virtual time of a turn
+func (s *Simulation) turnTime(turn uint64) time.Time {
+	return simEpoch.Add(time.Duration(turn) * s.scenario.BlockTime)
+}
+
+// reverted records a chain switch; reverting a confirmed block breaks safety
+func (s *Simulation) reverted(node *Node, blocks int) {
+	s.logf("%s reverted %d block(s)", node.Address, blocks)
+	if s.scenario.Confirmations > 0 && blocks >= s.scenario.Confirmations {
+		s.violate(PropertySafety, "%s reverted %d blocks, %d confirmations were considered final",
+			node.Address, blocks, s.scenario.Confirmations)
+	}
+}
+
+// checkConfirmedAgreement checks that online validators agree on every block
+// buried under the confirmation depth on both sides
+func (s *Simulation) checkConfirmedAgreement() {
+	depth := uint64(s.scenario.Confirmations)
+	var online []*Node
+	for _, node := range s.nodes {
+		if node.online {
+			online = append(online, node)
+		}
+	}
+	sort.Slice(online, func(i, j int) bool { return online[i].Address < online[j].Address })
+
+	for i := 0; i < len(online); i++ {
+		for j := i + 1; j < len(online); j++ {
+			a, b := online[i], online[j]
+			top := a.Height()
+			if b.Height() < top {
+				top = b.Height()
+			}
+			if top < depth {
+				continue
+			}
+			for height := uint64(1); height <= top-depth; height++ {
+				if a.chain[height].Hash != b.chain[height].Hash {
+					s.violate(PropertySafety, "%s and %s hold different confirmed blocks at height %d",
+						a.Address, b.Address, height)
+					return
+				}
+			}
+		}
+	}
+}
+
+// violate records a broken property
+func (s *Simulation) violate(property, format string, args ...interface{}) {
+	v := Violation{At: s.Elapsed(), Property: property, Detail: fmt.Sprintf(format, args...)}
+	s.logf("VIOLATION (%s): %s", property, v.Detail)
+	s.violations = append(s.violations, v)
+}
+
+// logf traces the run when Logf is set
+func (s *Simulation) logf(format string, args ...interface{}) {
+	if s.Logf != nil {
+		s.Logf("[%8s] "+format, append([]interface{}{s.Elapsed()}, args...)...)
+	}
+}
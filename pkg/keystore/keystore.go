@@ -0,0 +1,370 @@
+// Package keystore manages the keys a node operates with. Each key has a role:
+// the node key identifies the node on the P2P network, the validator key signs
+// blocks and the admin key signs administrative API requests. The roles can
+// share one key (the historical setup) but are generated, imported and rotated
+// independently.
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// Role is the purpose a key is used for
+type Role string
+
+const (
+	RoleNode      Role = "node"      // P2P identity
+	RoleValidator Role = "validator" // block signing
+	RoleAdmin     Role = "admin"     // administrative API requests
+)
+
+// Roles lists every key role
+var Roles = []Role{RoleNode, RoleValidator, RoleAdmin}
+
+var (
+	// ErrKeyNotFound is returned when a role has no key yet
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrKeyExists is returned when generating or importing over an existing key; rotate it instead
+	ErrKeyExists = errors.New("key already exists")
+	// ErrUnknownRole is returned for a role name that is not one of Roles
+	ErrUnknownRole = errors.New("unknown key role")
+)
+
+// ParseRole converts a role name to a Role
+func ParseRole(name string) (Role, error) {
+	for _, role := range Roles {
+		if strings.EqualFold(name, string(role)) {
+			return role, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q (expected node, validator or admin)", ErrUnknownRole, name)
+}
+
+// Key is a role's private key with the identities derived from it
+type Key struct {
+	Role       Role
+	PrivateKey *ecdsa.PrivateKey
+	CreatedAt  time.Time
+}
+
+// Address returns the account address of the key, as used for admins and wallets
+func (k *Key) Address() string {
+	return blockchain.AddressFromPublicKey(blockchain.P256PublicKey(&k.PrivateKey.PublicKey))
+}
+
+// NodeAddress returns the short identity nodes have always used for themselves
+// and their validator: the first 10 bytes of the public key in hex
+func (k *Key) NodeAddress() string {
+	return NodeAddress(blockchain.P256PublicKey(&k.PrivateKey.PublicKey))
+}
+
+// NodeAddress returns the short node identity of any public key, including
+// keys held by an HSM or KMS
+func NodeAddress(publicKey blockchain.PublicKey) string {
+	return fmt.Sprintf("%x", publicKey.Bytes()[:10])
+}
+
+// PublicKeyHex returns the uncompressed public key with a 0x prefix
+func (k *Key) PublicKeyHex() string {
+	return "0x" + hex.EncodeToString(elliptic.Marshal(k.PrivateKey.Curve, k.PrivateKey.X, k.PrivateKey.Y))
+}
+
+// KeyPair returns the key as a blockchain key pair, e.g. to register it with the chain
+func (k *Key) KeyPair() *blockchain.KeyPair {
+	return &blockchain.KeyPair{
+		PrivateKey:     k.PrivateKey,
+		PublicKey:      &k.PrivateKey.PublicKey,
+		PublicKeyBytes: elliptic.Marshal(k.PrivateKey.Curve, k.PrivateKey.X, k.PrivateKey.Y),
+		Key:            blockchain.P256PrivateKey(k.PrivateKey),
+	}
+}
+
+// Info describes a stored key without its private part
+type Info struct {
+	Role        Role      `json:"role"`
+	Address     string    `json:"address"`
+	NodeAddress string    `json:"nodeAddress"`
+	PublicKey   string    `json:"publicKey"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Info returns the public description of the key
+func (k *Key) Info() Info {
+	return Info{
+		Role:        k.Role,
+		Address:     k.Address(),
+		NodeAddress: k.NodeAddress(),
+		PublicKey:   k.PublicKeyHex(),
+		CreatedAt:   k.CreatedAt,
+	}
+}
+
+// keyFile is the on-disk form of a key
+type keyFile struct {
+	Role          Role       `json:"role"`
+	Address       string     `json:"address"`
+	PublicKey     string     `json:"public_key"`
+	PrivateKeyPEM string     `json:"private_key_pem"`
+	CreatedAt     time.Time  `json:"created_at"`
+	RetiredAt     *time.Time `json:"retired_at,omitempty"`
+}
+
+// Keystore stores one active key per role in a directory:
+// <dir>/<role>.json, with rotated keys kept under <dir>/retired/.
+type Keystore struct {
+	dir string
+}
+
+// DefaultDir returns the keystore directory inside the node's data directory
+func DefaultDir() string {
+	return filepath.Join(blockchain.GetBlockchainDataPath(), "keys")
+}
+
+// Open returns the keystore in dir, creating the directory if needed
+func Open(dir string) (*Keystore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "retired"), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create keystore directory: %v", err)
+	}
+	return &Keystore{dir: dir}, nil
+}
+
+// Dir returns the directory of the keystore
+func (ks *Keystore) Dir() string {
+	return ks.dir
+}
+
+func (ks *Keystore) path(role Role) string {
+	return filepath.Join(ks.dir, string(role)+".json")
+}
+
+// Load returns the active key of a role
+func (ks *Keystore) Load(role Role) (*Key, error) {
+	data, err := ioutil.ReadFile(ks.path(role))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: no %s key in %s", ErrKeyNotFound, role, ks.dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s key: %v", role, err)
+	}
+	privateKey, _, err := blockchain.ImportPrivateKeyWithFormat(file.PrivateKeyPEM, blockchain.KeyFormatPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s key: %v", role, err)
+	}
+	return &Key{Role: role, PrivateKey: privateKey, CreatedAt: file.CreatedAt}, nil
+}
+
+// Has reports whether a role has an active key
+func (ks *Keystore) Has(role Role) bool {
+	_, err := os.Stat(ks.path(role))
+	return err == nil
+}
+
+// Generate creates a new key for a role that has none
+func (ks *Keystore) Generate(role Role) (*Key, error) {
+	if ks.Has(role) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyExists, role)
+	}
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return ks.store(role, privateKey)
+}
+
+// Import stores an existing private key (hex, PEM or WIF) for a role that has none
+func (ks *Keystore) Import(role Role, encoded string, format blockchain.KeyFormat) (*Key, error) {
+	if ks.Has(role) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyExists, role)
+	}
+	privateKey, _, err := blockchain.ImportPrivateKeyWithFormat(encoded, format)
+	if err != nil {
+		return nil, err
+	}
+	return ks.store(role, privateKey)
+}
+
+// LoadOrGenerate returns the key of a role, generating one on first use
+func (ks *Keystore) LoadOrGenerate(role Role) (*Key, error) {
+	key, err := ks.Load(role)
+	if errors.Is(err, ErrKeyNotFound) {
+		return ks.Generate(role)
+	}
+	return key, err
+}
+
+// ImportMissing stores one existing private key for every role that has no key
+// yet and returns those roles. Nodes that predate key roles used a single key
+// for everything; importing it keeps their addresses unchanged.
+func (ks *Keystore) ImportMissing(encoded string, format blockchain.KeyFormat) ([]Role, error) {
+	privateKey, _, err := blockchain.ImportPrivateKeyWithFormat(encoded, format)
+	if err != nil {
+		return nil, err
+	}
+
+	imported := []Role{}
+	for _, role := range Roles {
+		if ks.Has(role) {
+			continue
+		}
+		if _, err := ks.store(role, privateKey); err != nil {
+			return imported, err
+		}
+		imported = append(imported, role)
+	}
+	return imported, nil
+}
+
+// Rotate replaces the key of a role with a new one and moves the old key to
+// the retired directory. It returns the retired and the new key.
+func (ks *Keystore) Rotate(role Role) (*Key, *Key, error) {
+	old, err := ks.Load(role)
+	if err != nil {
+		return nil, nil, err
+	}
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := ks.retire(old); err != nil {
+		return nil, nil, err
+	}
+	key, err := ks.store(role, privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return old, key, nil
+}
+
+// List returns the active keys, in role order
+func (ks *Keystore) List() ([]Info, error) {
+	infos := make([]Info, 0, len(Roles))
+	for _, role := range Roles {
+		key, err := ks.Load(role)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, key.Info())
+	}
+	return infos, nil
+}
+
+// Retired returns the descriptions of rotated-out keys, oldest first
+func (ks *Keystore) Retired() ([]Info, error) {
+	matches, err := filepath.Glob(filepath.Join(ks.dir, "retired", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+
+	infos := make([]Info, 0, len(matches))
+	for _, path := range matches {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var file keyFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+		privateKey, _, err := blockchain.ImportPrivateKeyWithFormat(file.PrivateKeyPEM, blockchain.KeyFormatPEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+		}
+		key := &Key{Role: file.Role, PrivateKey: privateKey, CreatedAt: file.CreatedAt}
+		infos = append(infos, key.Info())
+	}
+	return infos, nil
+}
+
+// PurgeRetired deletes the rotated-out keys and returns their descriptions.
+// Nothing verifies signatures against retired keys, so their private parts
+// only add to what a compromise of the node would leak.
+func (ks *Keystore) PurgeRetired() ([]Info, error) {
+	infos, err := ks.Retired()
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(filepath.Join(ks.dir, "retired", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range matches {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to delete retired key %s: %v", filepath.Base(path), err)
+		}
+	}
+	return infos, nil
+}
+
+// store writes the key of a role atomically
+func (ks *Keystore) store(role Role, privateKey *ecdsa.PrivateKey) (*Key, error) {
+	key := &Key{Role: role, PrivateKey: privateKey, CreatedAt: time.Now().UTC()}
+	data, err := encodeKeyFile(key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp := ks.path(role) + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write %s key: %v", role, err)
+	}
+	if err := os.Rename(tmp, ks.path(role)); err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("failed to write %s key: %v", role, err)
+	}
+	return key, nil
+}
+
+// retire moves a key to the retired directory
+func (ks *Keystore) retire(key *Key) error {
+	retiredAt := time.Now().UTC()
+	data, err := encodeKeyFile(key, &retiredAt)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.json", retiredAt.Format("20060102T150405.000000000Z"), key.Role)
+	if err := ioutil.WriteFile(filepath.Join(ks.dir, "retired", name), data, 0600); err != nil {
+		return fmt.Errorf("failed to retire %s key: %v", key.Role, err)
+	}
+	return nil
+}
+
+func encodeKeyFile(key *Key, retiredAt *time.Time) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(keyFile{
+		Role:          key.Role,
+		Address:       key.Address(),
+		PublicKey:     key.PublicKeyHex(),
+		PrivateKeyPEM: string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})),
+		CreatedAt:     key.CreatedAt,
+		RetiredAt:     retiredAt,
+	}, "", "  ")
+}
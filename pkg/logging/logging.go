@@ -0,0 +1,161 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Level is the minimum severity that is written to the log
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// ErrNoLogFile is returned by Rotate when logs are not written to a file
+var ErrNoLogFile = errors.New("logging is not configured to write to a file")
+
+var (
+	currentLevel int32 = int32(LevelInfo)
+
+	fileMu  sync.Mutex
+	logPath string
+	logFile *os.File
+)
+
+// String returns the lower-case name of the level
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int32(l))
+	}
+}
+
+// ParseLevel converts a level name (debug, info, warn, error) into a Level
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level: %s", name)
+	}
+}
+
+// SetLevel changes the minimum log level at runtime
+func SetLevel(level Level) {
+	atomic.StoreInt32(&currentLevel, int32(level))
+}
+
+// GetLevel returns the current minimum log level
+func GetLevel() Level {
+	return Level(atomic.LoadInt32(&currentLevel))
+}
+
+// Enabled reports whether messages at the given level are currently written
+func Enabled(level Level) bool {
+	return level >= GetLevel()
+}
+
+// Debugf logs a debug message
+func Debugf(format string, args ...interface{}) {
+	logf(LevelDebug, format, args...)
+}
+
+// Infof logs an informational message
+func Infof(format string, args ...interface{}) {
+	logf(LevelInfo, format, args...)
+}
+
+// Warnf logs a warning
+func Warnf(format string, args ...interface{}) {
+	logf(LevelWarn, format, args...)
+}
+
+// Errorf logs an error
+func Errorf(format string, args ...interface{}) {
+	logf(LevelError, format, args...)
+}
+
+func logf(level Level, format string, args ...interface{}) {
+	if !Enabled(level) {
+		return
+	}
+	log.Printf("[%s] %s", strings.ToUpper(level.String()), fmt.Sprintf(format, args...))
+}
+
+// SetOutputFile redirects the standard logger to the given file (appending).
+// Once a file is configured, Rotate can be used to start a fresh one.
+func SetOutputFile(path string) error {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+
+	if logFile != nil {
+		logFile.Close()
+	}
+	logFile = f
+	logPath = path
+	log.SetOutput(f)
+	return nil
+}
+
+// OutputFile returns the path of the current log file, or "" when logging to stderr
+func OutputFile() string {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	return logPath
+}
+
+// Rotate renames the current log file with a timestamp suffix and reopens a new
+// one at the original path. It returns the name of the rotated file.
+func Rotate() (string, error) {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+
+	if logFile == nil {
+		return "", ErrNoLogFile
+	}
+
+	rotated := fmt.Sprintf("%s.%s", logPath, time.Now().Format("20060102-150405"))
+	if err := os.Rename(logPath, rotated); err != nil {
+		return "", fmt.Errorf("failed to rename log file: %v", err)
+	}
+
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		// Keep writing to the renamed file rather than losing logs
+		return "", fmt.Errorf("failed to reopen log file: %v", err)
+	}
+
+	log.SetOutput(f)
+	logFile.Close()
+	logFile = f
+	return rotated, nil
+}
@@ -0,0 +1,202 @@
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// Kinds of objects a peer can be asked for with getdata
+const (
+	DataKindTransaction = "transaction" // a pending or confirmed transaction by ID
+	DataKindBlock       = "block"       // a block by hash
+)
+
+// maxGetDataItems bounds the objects asked for in one getdata request
+const maxGetDataItems = 64
+
+// ErrDataNotFound is returned when no peer has the requested object
+var ErrDataNotFound = errors.New("no peer has the requested data")
+
+// InventoryItem identifies an object a peer may hold
+type InventoryItem struct {
+	Kind string `json:"kind"`
+	Hash string `json:"hash"`
+}
+
+// GetDataMessage asks a peer for objects by hash. The peer answers on the
+// same connection with a "data" message carrying a DataMessage.
+type GetDataMessage struct {
+	Items []InventoryItem `json:"items"`
+}
+
+// DataMessage answers a getdata request with the objects the peer has; the
+// others are listed in NotFound
+type DataMessage struct {
+	Transactions []*blockchain.Transaction `json:"transactions,omitempty"`
+	Blocks       []*blockchain.Block       `json:"blocks,omitempty"`
+	NotFound     []InventoryItem           `json:"not_found,omitempty"`
+}
+
+// requestHandler handles a message that is answered on the connection it
+// arrived on, returning the type and payload of the reply
+type requestHandler func(from string, payload []byte) (string, interface{}, error)
+
+// RegisterRequestHandler registers a handler for messages answered on the
+// connection they arrived on
+func (node *P2PNode) RegisterRequestHandler(msgType string, handler func(from string, payload []byte) (string, interface{}, error)) {
+	node.reqHandlers[msgType] = handler
+}
+
+// request sends a message to peer and waits for the reply on the same
+// connection, for at most AckTimeout
+func (node *P2PNode) request(peer, msgType string, payload interface{}) (*PeerMessage, error) {
+	conn, err := net.DialTimeout("tcp", peer, node.config.AckTimeout)
+	if err != nil {
+		node.peerStore.RecordFailure(peer)
+		node.recordError(peer)
+		return nil, fmt.Errorf("failed to connect to peer %s: %v", peer, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(node.config.AckTimeout))
+
+	sent := time.Now()
+	if err := node.sendMessage(conn, peer, msgType, payload); err != nil {
+		node.recordError(peer)
+		return nil, fmt.Errorf("failed to send %s to %s: %v", msgType, peer, err)
+	}
+
+	var reader io.Reader = conn
+	if node.config.MaxMessageSize > 0 {
+		reader = &limitedReader{r: conn, n: node.config.MaxMessageSize}
+	}
+	counter := &countingReader{r: reader}
+	var msg PeerMessage
+	if err := json.NewDecoder(counter).Decode(&msg); err != nil {
+		node.peerStore.RecordFailure(peer)
+		node.recordError(peer)
+		return nil, fmt.Errorf("no answer to %s from %s: %v", msgType, peer, err)
+	}
+	node.recordRTT(peer, time.Since(sent))
+	node.recordReceived(peer, counter.n)
+	return &msg, nil
+}
+
+// handleGetData looks up the requested objects
+func (node *P2PNode) handleGetData(from string, payload []byte) (string, interface{}, error) {
+	var req GetDataMessage
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return "", nil, fmt.Errorf("failed to unmarshal getdata request: %v", err)
+	}
+	if len(req.Items) > maxGetDataItems {
+		return "", nil, fmt.Errorf("getdata request from %s asks for %d objects, the limit is %d", from, len(req.Items), maxGetDataItems)
+	}
+
+	reply := DataMessage{}
+	for _, item := range req.Items {
+		switch item.Kind {
+		case DataKindTransaction:
+			if tx, exists := node.blockchain.GetTransaction(item.Hash); exists {
+				reply.Transactions = append(reply.Transactions, tx)
+				continue
+			}
+		case DataKindBlock:
+			if block, err := node.blockchain.GetBlock(item.Hash); err == nil {
+				reply.Blocks = append(reply.Blocks, block)
+				continue
+			}
+		}
+		reply.NotFound = append(reply.NotFound, item)
+	}
+	return "data", reply, nil
+}
+
+// GetData asks peer for objects and waits for its answer. Objects that were
+// not asked for and blocks whose content does not match their hash are
+// dropped, so a peer cannot substitute data.
+func (node *P2PNode) GetData(peer string, items ...InventoryItem) (*DataMessage, error) {
+	if len(items) == 0 || len(items) > maxGetDataItems {
+		return nil, fmt.Errorf("a getdata request asks for 1 to %d objects, got %d", maxGetDataItems, len(items))
+	}
+	if node.IsBanned(peer) {
+		return nil, fmt.Errorf("peer %s is banned", peer)
+	}
+
+	msg, err := node.request(peer, "getdata", GetDataMessage{Items: items})
+	if err != nil {
+		return nil, err
+	}
+	if msg.Type != "data" {
+		return nil, fmt.Errorf("unexpected reply type from %s: %s", peer, msg.Type)
+	}
+	var data DataMessage
+	if err := json.Unmarshal(msg.Payload, &data); err != nil {
+		return nil, fmt.Errorf("invalid data message from %s: %v", peer, err)
+	}
+	node.peerStore.RecordSuccess(peer)
+	return filterData(peer, items, &data), nil
+}
+
+// filterData keeps the objects of data that answer one of items
+func filterData(peer string, items []InventoryItem, data *DataMessage) *DataMessage {
+	requested := make(map[InventoryItem]bool, len(items))
+	for _, item := range items {
+		requested[item] = true
+	}
+
+	filtered := &DataMessage{NotFound: data.NotFound}
+	for _, tx := range data.Transactions {
+		if tx != nil && requested[InventoryItem{Kind: DataKindTransaction, Hash: tx.ID}] {
+			filtered.Transactions = append(filtered.Transactions, tx)
+		}
+	}
+	for _, block := range data.Blocks {
+		if block == nil || !requested[InventoryItem{Kind: DataKindBlock, Hash: block.Hash}] {
+			continue
+		}
+		if block.CalculateHash() != block.Hash {
+			log.Printf("Peer %s sent block %s whose content does not match its hash, dropping it", peer, block.Hash)
+			continue
+		}
+		filtered.Blocks = append(filtered.Blocks, block)
+	}
+	return filtered
+}
+
+// FetchTransaction asks the peers in turn for a transaction until one has it
+func (node *P2PNode) FetchTransaction(id string) (*blockchain.Transaction, error) {
+	item := InventoryItem{Kind: DataKindTransaction, Hash: id}
+	for _, peer := range node.GetPeers() {
+		data, err := node.GetData(peer, item)
+		if err != nil {
+			log.Printf("Failed to fetch transaction %s from %s: %v", id, peer, err)
+			continue
+		}
+		if len(data.Transactions) > 0 {
+			return data.Transactions[0], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: transaction %s", ErrDataNotFound, id)
+}
+
+// FetchBlock asks the peers in turn for a block until one has it
+func (node *P2PNode) FetchBlock(hash string) (*blockchain.Block, error) {
+	item := InventoryItem{Kind: DataKindBlock, Hash: hash}
+	for _, peer := range node.GetPeers() {
+		data, err := node.GetData(peer, item)
+		if err != nil {
+			log.Printf("Failed to fetch block %s from %s: %v", hash, peer, err)
+			continue
+		}
+		if len(data.Blocks) > 0 {
+			return data.Blocks[0], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: block %s", ErrDataNotFound, hash)
+}
@@ -0,0 +1,253 @@
+package network
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// P2PConfig holds the resource limits of a P2P node
+type P2PConfig struct {
+	MaxInboundConns   int     // concurrent inbound connections across all peers
+	MaxInboundPerHost int     // concurrent inbound connections from a single host
+	MaxOutboundPeers  int     // peers this node keeps in its peer list
+	MaxMessageSize    int64   // maximum size of a single encoded message in bytes
+	MessagesPerSecond float64 // sustained message rate allowed per host
+	MessageBurst      int     // short bursts allowed above the sustained rate
+	ReadTimeout       time.Duration
+
+	// Acknowledged broadcast settings
+	AckTimeout     time.Duration // time to wait for an acknowledgement
+	MaxRetries     int           // delivery attempts per message before giving up
+	RetryBaseDelay time.Duration // first retry delay, doubled on every attempt
+	RetryMaxDelay  time.Duration // upper bound for the retry delay
+	OutboxSize     int           // messages queued per peer
+
+	// MaxClockOffset is the largest clock difference to the peers tolerated
+	// before warning and refusing validator mode (0 disables the check)
+	MaxClockOffset time.Duration
+}
+
+// DefaultP2PConfig returns the default P2P limits
+func DefaultP2PConfig() *P2PConfig {
+	return &P2PConfig{
+		MaxInboundConns:   128,
+		MaxInboundPerHost: 8,
+		MaxOutboundPeers:  32,
+		MaxMessageSize:    4 << 20, // 4 MiB, enough for a full block
+		MessagesPerSecond: 20,
+		MessageBurst:      40,
+		ReadTimeout:       time.Minute,
+		AckTimeout:        10 * time.Second,
+		MaxRetries:        8,
+		RetryBaseDelay:    time.Second,
+		RetryMaxDelay:     time.Minute,
+		OutboxSize:        256,
+		MaxClockOffset:    5 * time.Second,
+	}
+}
+
+// applyAckDefaults fills unset acknowledged-broadcast settings with their defaults
+func (c *P2PConfig) applyAckDefaults() {
+	defaults := DefaultP2PConfig()
+	if c.AckTimeout <= 0 {
+		c.AckTimeout = defaults.AckTimeout
+	}
+	if c.MaxRetries <= 0 {
+		c.MaxRetries = defaults.MaxRetries
+	}
+	if c.RetryBaseDelay <= 0 {
+		c.RetryBaseDelay = defaults.RetryBaseDelay
+	}
+	if c.RetryMaxDelay < c.RetryBaseDelay {
+		c.RetryMaxDelay = c.RetryBaseDelay
+	}
+	if c.OutboxSize <= 0 {
+		c.OutboxSize = defaults.OutboxSize
+	}
+}
+
+// ErrMessageTooLarge is returned when a peer sends a message above MaxMessageSize
+var ErrMessageTooLarge = errors.New("message exceeds maximum size")
+
+// limitedReader reads at most n bytes and then fails with ErrMessageTooLarge,
+// so oversized payloads are rejected instead of being buffered in memory
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		return 0, ErrMessageTooLarge
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// RateLimits are the P2P limits that can be changed while the node is running
+type RateLimits struct {
+	MaxInboundConns   int     `json:"max_inbound"`
+	MaxInboundPerHost int     `json:"max_inbound_per_host"`
+	MessagesPerSecond float64 `json:"message_rate"`
+	MessageBurst      int     `json:"message_burst"`
+}
+
+// Validate checks that the limits are usable
+func (l RateLimits) Validate() error {
+	if l.MaxInboundConns < 0 || l.MaxInboundPerHost < 0 {
+		return errors.New("inbound connection limits must not be negative")
+	}
+	if l.MessagesPerSecond < 0 {
+		return errors.New("message rate must not be negative")
+	}
+	if l.MessagesPerSecond > 0 && l.MessageBurst < 1 {
+		return errors.New("message burst must be at least 1 when rate limiting is enabled")
+	}
+	return nil
+}
+
+// rateLimits returns the reloadable part of the config
+func (c *P2PConfig) rateLimits() RateLimits {
+	return RateLimits{
+		MaxInboundConns:   c.MaxInboundConns,
+		MaxInboundPerHost: c.MaxInboundPerHost,
+		MessagesPerSecond: c.MessagesPerSecond,
+		MessageBurst:      c.MessageBurst,
+	}
+}
+
+// SetRateLimits validates limits and swaps them in. Existing connections are kept;
+// the new limits apply to the next connection and message.
+func (node *P2PNode) SetRateLimits(limits RateLimits) error {
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+	node.limiter.setLimits(limits)
+	return nil
+}
+
+// RateLimits returns the limits currently enforced
+func (node *P2PNode) RateLimits() RateLimits {
+	return node.limiter.currentLimits()
+}
+
+// tokenBucket is a simple per-host rate limiter
+type tokenBucket struct {
+	tokens   float64
+	lastFill time.Time
+}
+
+// connLimiter tracks inbound connection counts and message rates per host
+type connLimiter struct {
+	limits  RateLimits
+	mu      sync.Mutex
+	total   int
+	perHost map[string]int
+	buckets map[string]*tokenBucket
+}
+
+func newConnLimiter(config *P2PConfig) *connLimiter {
+	return &connLimiter{
+		limits:  config.rateLimits(),
+		perHost: make(map[string]int),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// setLimits replaces the enforced limits
+func (cl *connLimiter) setLimits(limits RateLimits) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	cl.limits = limits
+}
+
+// currentLimits returns the enforced limits
+func (cl *connLimiter) currentLimits() RateLimits {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return cl.limits
+}
+
+// acquire reserves an inbound connection slot for host; release must be called when done
+func (cl *connLimiter) acquire(host string) bool {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	if cl.limits.MaxInboundConns > 0 && cl.total >= cl.limits.MaxInboundConns {
+		return false
+	}
+	if cl.limits.MaxInboundPerHost > 0 && cl.perHost[host] >= cl.limits.MaxInboundPerHost {
+		return false
+	}
+	cl.total++
+	cl.perHost[host]++
+	return true
+}
+
+// release frees an inbound connection slot for host
+func (cl *connLimiter) release(host string) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	cl.total--
+	cl.perHost[host]--
+	if cl.perHost[host] <= 0 {
+		delete(cl.perHost, host)
+	}
+}
+
+// allowMessage consumes one token from the host's bucket
+func (cl *connLimiter) allowMessage(host string) bool {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	if cl.limits.MessagesPerSecond <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	burst := float64(cl.limits.MessageBurst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	bucket, exists := cl.buckets[host]
+	if !exists {
+		bucket = &tokenBucket{tokens: burst, lastFill: now}
+		cl.buckets[host] = bucket
+	}
+
+	bucket.tokens += now.Sub(bucket.lastFill).Seconds() * cl.limits.MessagesPerSecond
+	if bucket.tokens > burst {
+		bucket.tokens = burst
+	}
+	bucket.lastFill = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// pruneBuckets drops rate limiter state for hosts that have been idle long enough to be full again
+func (cl *connLimiter) pruneBuckets() {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	idle := time.Minute
+	if cl.limits.MessagesPerSecond > 0 {
+		idle = time.Duration(float64(cl.limits.MessageBurst)/cl.limits.MessagesPerSecond*float64(time.Second)) + time.Minute
+	}
+	for host, bucket := range cl.buckets {
+		if time.Since(bucket.lastFill) > idle {
+			delete(cl.buckets, host)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// AckMessage is sent back on the same connection for messages that request an acknowledgement
+type AckMessage struct {
+	ID    string `json:"id"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// outboxItem is a message waiting to be delivered to a peer
+type outboxItem struct {
+	msg      PeerMessage
+	attempts int
+}
+
+// peerOutbox delivers acknowledged messages to a single peer in order
+type peerOutbox struct {
+	peer  string
+	queue chan *outboxItem
+	stop  chan struct{} // closed when the peer is dropped
+}
+
+// outboxes holds the per-peer outboxes of a node
+type outboxes struct {
+	mu    sync.Mutex
+	boxes map[string]*peerOutbox
+}
+
+// sendWithAck sends a message to peer and waits for its acknowledgement.
+// A peer that received the message but rejected it (e.g. a block it already has)
+// still counts as delivered: retrying would not change its answer.
+func (node *P2PNode) sendWithAck(peer string, msg PeerMessage) error {
+	conn, err := net.DialTimeout("tcp", peer, node.config.AckTimeout)
+	if err != nil {
+		return fmt.Errorf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(node.config.AckTimeout))
+
+	if err := node.writeMessage(conn, peer, msg); err != nil {
+		return fmt.Errorf("send failed: %v", err)
+	}
+
+	var reply PeerMessage
+	counter := &countingReader{r: &limitedReader{r: conn, n: 64 << 10}}
+	if err := json.NewDecoder(counter).Decode(&reply); err != nil {
+		return fmt.Errorf("no acknowledgement: %v", err)
+	}
+	node.recordReceived(peer, counter.n)
+	if reply.Type != "ack" {
+		return fmt.Errorf("unexpected reply type: %s", reply.Type)
+	}
+
+	var ack AckMessage
+	if err := json.Unmarshal(reply.Payload, &ack); err != nil {
+		return fmt.Errorf("invalid acknowledgement: %v", err)
+	}
+	if ack.ID != msg.ID {
+		return fmt.Errorf("acknowledgement for %s does not match %s", ack.ID, msg.ID)
+	}
+	if !ack.OK {
+		log.Printf("Peer %s rejected message %s: %s", peer, msg.ID, ack.Error)
+	}
+	return nil
+}
+
+// sendAck replies to an acknowledged message of peer on the incoming connection
+func (node *P2PNode) sendAck(conn net.Conn, peer, id string, handlerErr error) {
+	ack := AckMessage{ID: id, OK: handlerErr == nil}
+	if handlerErr != nil {
+		ack.Error = handlerErr.Error()
+	}
+	if err := node.sendMessage(conn, peer, "ack", ack); err != nil {
+		log.Printf("Failed to send acknowledgement for %s: %v", id, err)
+	}
+}
+
+// getOutbox returns the outbox for peer, starting its delivery worker on first use
+func (node *P2PNode) getOutbox(peer string) *peerOutbox {
+	node.outboxes.mu.Lock()
+	defer node.outboxes.mu.Unlock()
+
+	box, exists := node.outboxes.boxes[peer]
+	if !exists {
+		box = &peerOutbox{
+			peer:  peer,
+			queue: make(chan *outboxItem, node.config.OutboxSize),
+			stop:  make(chan struct{}),
+		}
+		node.outboxes.boxes[peer] = box
+		go node.runOutbox(box)
+	}
+	return box
+}
+
+// dropOutboxes stops the delivery workers of peer, or of every peer on it
+// when peer is a bare host, and discards their queued messages
+func (node *P2PNode) dropOutboxes(peer string) {
+	node.outboxes.mu.Lock()
+	defer node.outboxes.mu.Unlock()
+
+	for address, box := range node.outboxes.boxes {
+		host, _, err := net.SplitHostPort(address)
+		if address != peer && (err != nil || host != peer) {
+			continue
+		}
+		close(box.stop)
+		delete(node.outboxes.boxes, address)
+	}
+}
+
+// enqueueReliable queues a message for acknowledged delivery to peer
+func (node *P2PNode) enqueueReliable(peer string, msg PeerMessage) error {
+	box := node.getOutbox(peer)
+	select {
+	case box.queue <- &outboxItem{msg: msg}:
+		return nil
+	default:
+		return fmt.Errorf("outbox for %s is full, dropping %s", peer, msg.ID)
+	}
+}
+
+// runOutbox delivers queued messages to one peer in order, retrying with exponential backoff
+func (node *P2PNode) runOutbox(box *peerOutbox) {
+	for {
+		select {
+		case <-node.stopChan:
+			return
+		case <-box.stop:
+			return
+		case item := <-box.queue:
+			node.deliver(box, item)
+		}
+	}
+}
+
+// deliver sends a single item until it is acknowledged, the retry budget is
+// spent, the peer is dropped or the node stops
+func (node *P2PNode) deliver(box *peerOutbox, item *outboxItem) {
+	peer := box.peer
+	delay := node.config.RetryBaseDelay
+	for {
+		if node.IsBanned(peer) {
+			return
+		}
+
+		item.attempts++
+		err := node.sendWithAck(peer, item.msg)
+		if err == nil {
+			node.peerStore.RecordSuccess(peer)
+			return
+		}
+
+		node.peerStore.RecordFailure(peer)
+		node.recordError(peer)
+		if item.attempts >= node.config.MaxRetries {
+			log.Printf("Giving up on %s to %s after %d attempts: %v", item.msg.ID, peer, item.attempts, err)
+			return
+		}
+
+		log.Printf("Delivery of %s to %s failed (attempt %d), retrying in %v: %v", item.msg.ID, peer, item.attempts, delay, err)
+		select {
+		case <-node.stopChan:
+			return
+		case <-box.stop:
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > node.config.RetryMaxDelay {
+			delay = node.config.RetryMaxDelay
+		}
+	}
+}
+
+// BroadcastReliable queues a message with acknowledgement and retry for every known peer
+func (node *P2PNode) BroadcastReliable(msgType, id string, payload interface{}) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %v", err)
+	}
+
+	msg := PeerMessage{
+		Type:        msgType,
+		From:        fmt.Sprintf("%s:%d", node.address, node.port),
+		Payload:     payloadBytes,
+		ID:          id,
+		AckRequired: true,
+		NodeID:      node.nodeID,
+		Version:     blockchain.NodeVersion,
+		Protocol:    blockchain.ProtocolVersion,
+	}
+
+	node.peersMutex.RLock()
+	peers := make([]string, 0, len(node.peerAddresses))
+	for peerAddr := range node.peerAddresses {
+		peers = append(peers, peerAddr)
+	}
+	node.peersMutex.RUnlock()
+
+	var errs []error
+	for _, peerAddr := range peers {
+		if err := node.enqueueReliable(peerAddr, msg); err != nil {
+			log.Printf("%v", err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// OutboxSizes returns the number of messages waiting for each peer
+func (node *P2PNode) OutboxSizes() map[string]int {
+	node.outboxes.mu.Lock()
+	defer node.outboxes.mu.Unlock()
+
+	sizes := make(map[string]int, len(node.outboxes.boxes))
+	for peer, box := range node.outboxes.boxes {
+		sizes[peer] = len(box.queue)
+	}
+	return sizes
+}
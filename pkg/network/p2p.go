@@ -2,9 +2,12 @@ package network
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,9 +16,14 @@ import (
 
 // PeerMessage represents a message in the P2P network
 type PeerMessage struct {
-	Type    string          `json:"type"`
-	From    string          `json:"from"`
-	Payload json.RawMessage `json:"payload"`
+	Type        string          `json:"type"`
+	From        string          `json:"from"`
+	Payload     json.RawMessage `json:"payload"`
+	ID          string          `json:"id,omitempty"`           // set for messages that need an acknowledgement
+	AckRequired bool            `json:"ack_required,omitempty"` // receiver replies with an "ack" message
+	NodeID      string          `json:"node_id,omitempty"`      // sender's identity, derived from its node key
+	Version     string          `json:"version,omitempty"`      // sender's software version
+	Protocol    int             `json:"protocol,omitempty"`     // sender's peer protocol version, see blockchain.ProtocolVersion
 }
 
 // BlockMessage represents a serialized block
@@ -28,6 +36,11 @@ type TransactionMessage struct {
 	Transaction *blockchain.Transaction `json:"transaction"`
 }
 
+// VoteMessage carries a validator's pre-vote or pre-commit, see consensus.BFT
+type VoteMessage struct {
+	Vote *blockchain.ConsensusVote `json:"vote"`
+}
+
 // DiscoveryMessage represents peer discovery information
 type DiscoveryMessage struct {
 	PeerAddresses []string `json:"peer_addresses"`
@@ -44,10 +57,42 @@ type P2PNode struct {
 	stopChan      chan struct{}
 	isRunning     bool
 	msgHandlers   map[string]func(from string, payload []byte) error
+	reqHandlers   map[string]requestHandler // answered on the same connection, see getdata.go
+	bannedPeers   map[string]time.Time      // peer address or host -> ban expiry (zero = permanent)
+	peerStore     *PeerStore                // persistent address book (data/peers.json)
+	config        *P2PConfig
+	limiter       *connLimiter
+	outboxes      outboxes                        // per-peer queues for acknowledged broadcasts
+	dials         chan struct{}                   // slots for dials to peers learned through discovery
+	nodeID        string                          // identity derived from the node key, see SetNodeID
+	signals       chainSignals                    // fork and peer height indications from block gossip
+	clocks        clockOffsets                    // measured peer clock offsets, see timesync.go
+	traffic       peerStats                       // per-peer traffic and latency, see peerstats.go
+	dropMessage   func(from, msgType string) bool // discards received messages in chaos tests, see SetMessageDropper
 }
 
-// NewP2PNode creates a new P2P network node
+// maxReconnectPeers is the number of stored peers dialed on startup
+const maxReconnectPeers = 16
+
+const (
+	// maxDiscoveredAddresses is the number of addresses read from one discovery message
+	maxDiscoveredAddresses = 64
+	// maxDiscoveryDials is the number of dials to discovered peers in flight at once
+	maxDiscoveryDials = 4
+)
+
+// NewP2PNode creates a new P2P network node with the default limits
 func NewP2PNode(address string, port int, blockchain *blockchain.Blockchain) *P2PNode {
+	return NewP2PNodeWithConfig(address, port, blockchain, DefaultP2PConfig())
+}
+
+// NewP2PNodeWithConfig creates a new P2P network node with custom limits
+func NewP2PNodeWithConfig(address string, port int, blockchain *blockchain.Blockchain, config *P2PConfig) *P2PNode {
+	if config == nil {
+		config = DefaultP2PConfig()
+	}
+	config.applyAckDefaults()
+
 	node := &P2PNode{
 		address:       address,
 		port:          port,
@@ -56,12 +101,24 @@ func NewP2PNode(address string, port int, blockchain *blockchain.Blockchain) *P2
 		stopChan:      make(chan struct{}),
 		isRunning:     false,
 		msgHandlers:   make(map[string]func(from string, payload []byte) error),
+		reqHandlers:   make(map[string]requestHandler),
+		bannedPeers:   make(map[string]time.Time),
+		peerStore:     NewPeerStore(defaultPeerStorePath()),
+		config:        config,
+		limiter:       newConnLimiter(config),
+		outboxes:      outboxes{boxes: make(map[string]*peerOutbox)},
+		dials:         make(chan struct{}, maxDiscoveryDials),
+		clocks:        clockOffsets{offsets: make(map[string]time.Duration)},
+		traffic:       peerStats{since: time.Now(), peers: make(map[string]*PeerTraffic)},
 	}
 
 	// Register default message handlers
 	node.RegisterHandler("block", node.handleBlockMessage)
 	node.RegisterHandler("transaction", node.handleTransactionMessage)
 	node.RegisterHandler("discovery", node.handleDiscoveryMessage)
+	node.RegisterHandler("sync_request", node.handleSyncRequest)
+	node.RegisterRequestHandler("getdata", node.handleGetData)
+	node.RegisterRequestHandler("time_request", node.handleTimeRequest)
 
 	return node
 }
@@ -91,6 +148,10 @@ func (node *P2PNode) Stop() {
 		close(node.stopChan)
 		node.listener.Close()
 		node.isRunning = false
+
+		if err := node.peerStore.Save(); err != nil {
+			log.Printf("Failed to save peer store: %v", err)
+		}
 	}
 }
 
@@ -102,29 +163,93 @@ func (node *P2PNode) RegisterHandler(msgType string, handler func(from string, p
 // ConnectToPeer connects to a peer node
 func (node *P2PNode) ConnectToPeer(peerAddress string) error {
 	node.peersMutex.Lock()
-	defer node.peersMutex.Unlock()
 
 	// Skip if already connected
 	if node.peerAddresses[peerAddress] {
+		node.peersMutex.Unlock()
 		return nil
 	}
 
-	// Establish connection
-	conn, err := net.Dial("tcp", peerAddress)
+	// Refuse banned peers
+	if node.isBannedLocked(peerAddress) {
+		node.peersMutex.Unlock()
+		return fmt.Errorf("peer %s is banned", peerAddress)
+	}
+
+	// Respect the outbound peer limit
+	if node.config.MaxOutboundPeers > 0 && len(node.peerAddresses) >= node.config.MaxOutboundPeers {
+		node.peersMutex.Unlock()
+		node.peerStore.Add(peerAddress)
+		return fmt.Errorf("outbound peer limit reached (%d), %s kept in address book", node.config.MaxOutboundPeers, peerAddress)
+	}
+	node.peersMutex.Unlock()
+
+	// Establish connection (without holding the lock, sendDiscoveryMessage needs it)
+	conn, err := net.DialTimeout("tcp", peerAddress, 10*time.Second)
 	if err != nil {
+		node.peerStore.RecordFailure(peerAddress)
+		node.recordError(peerAddress)
 		return fmt.Errorf("failed to connect to peer %s: %v", peerAddress, err)
 	}
 	defer conn.Close()
 
 	// Add to peer list
+	node.peersMutex.Lock()
 	node.peerAddresses[peerAddress] = true
+	node.peersMutex.Unlock()
+	node.peerStore.RecordSuccess(peerAddress)
 
 	// Send discovery message to peer
-	node.sendDiscoveryMessage(conn)
+	node.sendDiscoveryMessage(conn, peerAddress)
+
+	// Compare clocks; peers running an older version do not answer
+	if _, err := node.MeasureClockOffset(peerAddress); err != nil {
+		log.Printf("Could not measure the clock offset of peer %s: %v", peerAddress, err)
+	}
 
 	return nil
 }
 
+// ReconnectKnownPeers dials the healthiest peers from the persistent address book
+// and falls back to the bootstrap list when none of them can be reached.
+// It returns the number of peers connected.
+func (node *P2PNode) ReconnectKnownPeers(bootstrap []string) int {
+	connected := 0
+	for _, peerAddr := range node.peerStore.HealthyPeers(maxReconnectPeers) {
+		if err := node.ConnectToPeer(peerAddr); err != nil {
+			log.Printf("Failed to reconnect to stored peer %s: %v", peerAddr, err)
+			continue
+		}
+		connected++
+	}
+
+	if connected > 0 {
+		log.Printf("Reconnected to %d stored peers", connected)
+	} else {
+		for _, peerAddr := range bootstrap {
+			peerAddr = strings.TrimSpace(peerAddr)
+			if peerAddr == "" {
+				continue
+			}
+			if err := node.ConnectToPeer(peerAddr); err != nil {
+				log.Printf("Failed to connect to bootstrap peer %s: %v", peerAddr, err)
+				continue
+			}
+			connected++
+		}
+	}
+
+	if err := node.peerStore.Save(); err != nil {
+		log.Printf("Failed to save peer store: %v", err)
+	}
+	return connected
+}
+
+// PeerStore returns the persistent peer address book
+func (node *P2PNode) PeerStore() *PeerStore {
+	return node.peerStore
+}
+
 // Broadcast sends a message to all peers
 func (node *P2PNode) Broadcast(msgType string, payload interface{}) error {
 	node.peersMutex.RLock()
@@ -134,29 +259,61 @@ func (node *P2PNode) Broadcast(msgType string, payload interface{}) error {
 		conn, err := net.Dial("tcp", peerAddr)
 		if err != nil {
 			log.Printf("Failed to connect to peer %s: %v", peerAddr, err)
+			node.peerStore.RecordFailure(peerAddr)
+			node.recordError(peerAddr)
 			continue
 		}
 
-		err = node.sendMessage(conn, msgType, payload)
+		err = node.sendMessage(conn, peerAddr, msgType, payload)
 		conn.Close()
 		if err != nil {
 			log.Printf("Failed to send message to peer %s: %v", peerAddr, err)
+			node.peerStore.RecordFailure(peerAddr)
+			node.recordError(peerAddr)
+			continue
 		}
+		node.peerStore.RecordSuccess(peerAddr)
 	}
 
 	return nil
 }
 
-// BroadcastBlock broadcasts a new block to all peers
+// BroadcastBlock announces a new block to all peers.
+// Delivery is acknowledged and retried with backoff through each peer's outbox.
 func (node *P2PNode) BroadcastBlock(block *blockchain.Block) error {
 	blockMsg := BlockMessage{Block: block}
-	return node.Broadcast("block", blockMsg)
+	return node.BroadcastReliable("block", fmt.Sprintf("block-%d-%s", block.Index, block.Hash), blockMsg)
 }
 
-// BroadcastTransaction broadcasts a new transaction to all peers
+// BroadcastTransaction broadcasts a new transaction to all peers and records
+// the time in the transaction's lifecycle
 func (node *P2PNode) BroadcastTransaction(tx *blockchain.Transaction) error {
 	txMsg := TransactionMessage{Transaction: tx}
-	return node.Broadcast("transaction", txMsg)
+	if err := node.Broadcast("transaction", txMsg); err != nil {
+		return err
+	}
+	node.blockchain.MarkTransactionBroadcast(tx.ID)
+	return nil
+}
+
+// BroadcastVote sends a consensus vote to all peers
+func (node *P2PNode) BroadcastVote(vote *blockchain.ConsensusVote) error {
+	return node.Broadcast("vote", VoteMessage{Vote: vote})
+}
+
+// OnVote registers the handler for consensus votes received from peers.
+// Votes are ignored until one is registered.
+func (node *P2PNode) OnVote(handle func(vote *blockchain.ConsensusVote) error) {
+	node.RegisterHandler("vote", func(from string, payload []byte) error {
+		var voteMsg VoteMessage
+		if err := json.Unmarshal(payload, &voteMsg); err != nil {
+			return fmt.Errorf("failed to unmarshal vote message: %v", err)
+		}
+		if voteMsg.Vote == nil {
+			return errors.New("vote message without a vote")
+		}
+		return handle(voteMsg.Vote)
+	})
 }
 
 // acceptConnections accepts incoming connections
@@ -181,16 +338,91 @@ func (node *P2PNode) acceptConnections() {
 func (node *P2PNode) handleConnection(conn net.Conn) {
 	defer conn.Close()
 
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		host = conn.RemoteAddr().String()
+	}
+
+	// Drop connections from banned hosts before reading anything
+	if node.IsBanned(host) {
+		return
+	}
+
+	// Enforce inbound connection limits
+	if !node.limiter.acquire(host) {
+		log.Printf("Inbound connection limit reached, dropping connection from %s", host)
+		return
+	}
+	defer node.limiter.release(host)
+
+	// Enforce the per-host message rate
+	if !node.limiter.allowMessage(host) {
+		log.Printf("Rate limit exceeded for %s, dropping message", host)
+		return
+	}
+
 	// Set read deadline to prevent hanging
-	conn.SetReadDeadline(time.Now().Add(time.Minute))
+	if node.config.ReadTimeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(node.config.ReadTimeout))
+	}
 
-	// Decode message
+	// Decode message, never reading more than MaxMessageSize bytes
 	var msg PeerMessage
-	decoder := json.NewDecoder(conn)
+	var reader io.Reader = conn
+	if node.config.MaxMessageSize > 0 {
+		reader = &limitedReader{r: conn, n: node.config.MaxMessageSize}
+	}
+	counter := &countingReader{r: reader}
+	decoder := json.NewDecoder(counter)
 	if err := decoder.Decode(&msg); err != nil {
+		if errors.Is(err, ErrMessageTooLarge) {
+			log.Printf("Message from %s exceeds %d bytes, dropping", host, node.config.MaxMessageSize)
+			node.peerStore.RecordHostFailure(host)
+			node.recordError(host)
+			return
+		}
 		log.Printf("Failed to decode message: %v", err)
 		return
 	}
+	if msg.From != "" {
+		node.recordReceived(msg.From, counter.n)
+	} else {
+		node.recordReceived(host, counter.n)
+	}
+
+	// The sender names itself; scores go to the address it connected from
+	sender := senderAddress(msg.From, host)
+
+	// Ignore messages from banned peers
+	if node.IsBanned(msg.From) {
+		return
+	}
+	if !blockchain.ProtocolSupported(msg.Protocol) {
+		log.Printf("Peer %s speaks protocol %d, older than the minimum %d; dropping %s message", msg.From, msg.Protocol, blockchain.MinProtocolVersion, msg.Type)
+		return
+	}
+	if msg.Version != "" && sender != "" {
+		node.peerStore.RecordVersion(sender, msg.Version, msg.Protocol)
+	}
+
+	// Messages discarded here look lost in transit: requests go unanswered
+	// and acknowledged broadcasts are retried
+	if node.dropMessage != nil && node.dropMessage(msg.From, msg.Type) {
+		return
+	}
+
+	// Requests are answered on the connection they arrived on
+	if handler, exists := node.reqHandlers[msg.Type]; exists {
+		replyType, reply, err := handler(msg.From, msg.Payload)
+		if err != nil {
+			log.Printf("Error handling %s request: %v", msg.Type, err)
+			return
+		}
+		if err := node.sendMessage(conn, msg.From, replyType, reply); err != nil {
+			log.Printf("Failed to answer %s request from %s: %v", msg.Type, msg.From, err)
+		}
+		return
+	}
 
 	// Handle message based on type
 	handler, exists := node.msgHandlers[msg.Type]
@@ -200,13 +432,35 @@ func (node *P2PNode) handleConnection(conn net.Conn) {
 	}
 
 	// Process message
-	if err := handler(msg.From, msg.Payload); err != nil {
-		log.Printf("Error handling message: %v", err)
+	handlerErr := handler(msg.From, msg.Payload)
+
+	// Acknowledge receipt, including the handler result, when the sender asked for it
+	if msg.AckRequired {
+		node.sendAck(conn, msg.From, msg.ID, handlerErr)
 	}
+
+	if handlerErr != nil {
+		log.Printf("Error handling message: %v", handlerErr)
+		return
+	}
+	if sender != "" {
+		node.peerStore.RecordSuccess(sender)
+	}
+}
+
+// senderAddress returns the peer address of the sender of a message: the
+// listening port it announces in from, on the host the connection came from.
+// It returns "" when from has no port.
+func senderAddress(from, remoteHost string) string {
+	_, port, err := net.SplitHostPort(from)
+	if err != nil || port == "" {
+		return ""
+	}
+	return net.JoinHostPort(remoteHost, port)
 }
 
-// sendMessage sends a message to a peer
-func (node *P2PNode) sendMessage(conn net.Conn, msgType string, payload interface{}) error {
+// sendMessage sends a message to peer over conn
+func (node *P2PNode) sendMessage(conn net.Conn, peer, msgType string, payload interface{}) error {
 	// Encode payload
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
@@ -215,18 +469,52 @@ func (node *P2PNode) sendMessage(conn net.Conn, msgType string, payload interfac
 
 	// Create message
 	msg := PeerMessage{
-		Type:    msgType,
-		From:    fmt.Sprintf("%s:%d", node.address, node.port),
-		Payload: payloadBytes,
+		Type:     msgType,
+		From:     fmt.Sprintf("%s:%d", node.address, node.port),
+		Payload:  payloadBytes,
+		NodeID:   node.nodeID,
+		Version:  blockchain.NodeVersion,
+		Protocol: blockchain.ProtocolVersion,
 	}
 
-	// Send message
-	encoder := json.NewEncoder(conn)
-	return encoder.Encode(msg)
+	return node.writeMessage(conn, peer, msg)
+}
+
+// writeMessage writes an encoded message to peer over conn and counts it in
+// the peer's traffic
+func (node *P2PNode) writeMessage(conn net.Conn, peer string, msg PeerMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %v", err)
+	}
+	data = append(data, '\n')
+	if _, err := conn.Write(data); err != nil {
+		return err
+	}
+	node.recordSent(peer, len(data))
+	return nil
+}
+
+// SetMessageDropper sets a function deciding which received messages are
+// discarded unhandled, e.g. to drop messages at random in chaos tests.
+// It must be called before Start.
+func (node *P2PNode) SetMessageDropper(drop func(from, msgType string) bool) {
+	node.dropMessage = drop
+}
+
+// SetNodeID sets the identity the node announces in its messages.
+// It must be called before Start.
+func (node *P2PNode) SetNodeID(id string) {
+	node.nodeID = id
+}
+
+// NodeID returns the identity the node announces in its messages
+func (node *P2PNode) NodeID() string {
+	return node.nodeID
 }
 
-// sendDiscoveryMessage sends a discovery message to a peer
-func (node *P2PNode) sendDiscoveryMessage(conn net.Conn) error {
+// sendDiscoveryMessage sends a discovery message to peer
+func (node *P2PNode) sendDiscoveryMessage(conn net.Conn, peer string) error {
 	// Get all known peers
 	node.peersMutex.RLock()
 	peerAddresses := make([]string, 0, len(node.peerAddresses))
@@ -243,7 +531,7 @@ func (node *P2PNode) sendDiscoveryMessage(conn net.Conn) error {
 	discoveryMsg := DiscoveryMessage{PeerAddresses: peerAddresses}
 
 	// Send message
-	return node.sendMessage(conn, "discovery", discoveryMsg)
+	return node.sendMessage(conn, peer, "discovery", discoveryMsg)
 }
 
 // discoveryRoutine periodically sends discovery messages to all peers
@@ -261,13 +549,31 @@ func (node *P2PNode) discoveryRoutine() {
 				conn, err := net.Dial("tcp", peerAddr)
 				if err != nil {
 					log.Printf("Failed to connect to peer %s: %v", peerAddr, err)
+					node.peerStore.RecordFailure(peerAddr)
+					node.recordError(peerAddr)
 					continue
 				}
 
-				node.sendDiscoveryMessage(conn)
+				node.sendDiscoveryMessage(conn, peerAddr)
 				conn.Close()
+				node.peerStore.RecordSuccess(peerAddr)
 			}
 			node.peersMutex.RUnlock()
+
+			// Refresh the peers' clock offsets and latencies
+			for _, peerAddr := range node.GetPeers() {
+				if _, err := node.MeasureClockOffset(peerAddr); err != nil {
+					log.Printf("Could not measure the clock offset of peer %s: %v", peerAddr, err)
+				}
+			}
+
+			// Forget rate limiter state of idle hosts
+			node.limiter.pruneBuckets()
+
+			// Persist the address book so a restarted node remembers the network
+			if err := node.peerStore.Save(); err != nil {
+				log.Printf("Failed to save peer store: %v", err)
+			}
 		}
 	}
 }
@@ -280,7 +586,18 @@ func (node *P2PNode) handleBlockMessage(from string, payload []byte) error {
 	}
 
 	// Add block to blockchain
-	return node.blockchain.AddBlock(blockMsg.Block)
+	err := node.blockchain.AddBlock(blockMsg.Block)
+	node.recordBlock(blockMsg.Block, err)
+	if err != nil && errors.Is(err, blockchain.ErrInvalidBlockIndex) {
+		// A second block for a height we already have may prove double-signing
+		reporter := fmt.Sprintf("%s:%d", node.address, node.port)
+		if tx, reportErr := node.blockchain.ReportDoubleSign(blockMsg.Block, reporter); reportErr != nil {
+			log.Printf("Failed to report double-signing from %s: %v", from, reportErr)
+		} else if tx != nil {
+			node.BroadcastTransaction(tx)
+		}
+	}
+	return err
 }
 
 // handleTransactionMessage processes a received transaction
@@ -301,13 +618,124 @@ func (node *P2PNode) handleDiscoveryMessage(from string, payload []byte) error {
 		return fmt.Errorf("failed to unmarshal discovery message: %v", err)
 	}
 
-	// Connect to new peers
-	for _, peerAddr := range discoveryMsg.PeerAddresses {
-		ownAddr := fmt.Sprintf("%s:%d", node.address, node.port)
-		if peerAddr != ownAddr {
-			go node.ConnectToPeer(peerAddr)
+	// Remember new peers and dial them while dial slots are free; the others
+	// are dialed on a later announcement or once the node needs more peers
+	addresses := discoveryMsg.PeerAddresses
+	if len(addresses) > maxDiscoveredAddresses {
+		addresses = addresses[:maxDiscoveredAddresses]
+	}
+	ownAddr := fmt.Sprintf("%s:%d", node.address, node.port)
+	for _, peerAddr := range addresses {
+		if peerAddr == ownAddr || node.isPeer(peerAddr) {
+			continue
+		}
+		if host, port, err := net.SplitHostPort(peerAddr); err != nil || host == "" || port == "" {
+			continue
+		}
+		if !node.peerStore.Add(peerAddr) {
+			continue
+		}
+		select {
+		case node.dials <- struct{}{}:
+			go func(peerAddr string) {
+				defer func() { <-node.dials }()
+				node.ConnectToPeer(peerAddr)
+			}(peerAddr)
+		default:
 		}
 	}
 
 	return nil
-} 
\ No newline at end of file
+}
+
+// isPeer reports whether the node is connected to peerAddress
+func (node *P2PNode) isPeer(peerAddress string) bool {
+	node.peersMutex.RLock()
+	defer node.peersMutex.RUnlock()
+	return node.peerAddresses[peerAddress]
+}
+
+// BanPeer disconnects a peer and refuses further connections from it.
+// The address may be a host:port peer address or a bare host. A zero duration bans permanently.
+func (node *P2PNode) BanPeer(peerAddress string, duration time.Duration) {
+	node.peersMutex.Lock()
+	defer node.peersMutex.Unlock()
+
+	var expiry time.Time
+	if duration > 0 {
+		expiry = time.Now().Add(duration)
+	}
+	node.bannedPeers[peerAddress] = expiry
+	delete(node.peerAddresses, peerAddress)
+	node.peerStore.Remove(peerAddress)
+	node.forgetClockOffset(peerAddress)
+	node.dropOutboxes(peerAddress)
+
+	log.Printf("Peer %s banned (duration: %v)", peerAddress, duration)
+}
+
+// UnbanPeer lifts a ban on a peer address or host
+func (node *P2PNode) UnbanPeer(peerAddress string) bool {
+	node.peersMutex.Lock()
+	defer node.peersMutex.Unlock()
+
+	if _, exists := node.bannedPeers[peerAddress]; !exists {
+		return false
+	}
+	delete(node.bannedPeers, peerAddress)
+	return true
+}
+
+// IsBanned reports whether a peer address or its host is currently banned
+func (node *P2PNode) IsBanned(peerAddress string) bool {
+	node.peersMutex.Lock()
+	defer node.peersMutex.Unlock()
+	return node.isBannedLocked(peerAddress)
+}
+
+// isBannedLocked checks the ban list; the caller must hold peersMutex
+func (node *P2PNode) isBannedLocked(peerAddress string) bool {
+	keys := []string{peerAddress}
+	if host, _, err := net.SplitHostPort(peerAddress); err == nil {
+		keys = append(keys, host)
+	}
+
+	for _, key := range keys {
+		expiry, exists := node.bannedPeers[key]
+		if !exists {
+			continue
+		}
+		if expiry.IsZero() || time.Now().Before(expiry) {
+			return true
+		}
+		// Ban expired
+		delete(node.bannedPeers, key)
+	}
+	return false
+}
+
+// BannedPeers returns the current ban list with expiry times (zero = permanent)
+func (node *P2PNode) BannedPeers() map[string]time.Time {
+	node.peersMutex.RLock()
+	defer node.peersMutex.RUnlock()
+
+	bans := make(map[string]time.Time, len(node.bannedPeers))
+	for addr, expiry := range node.bannedPeers {
+		if expiry.IsZero() || time.Now().Before(expiry) {
+			bans[addr] = expiry
+		}
+	}
+	return bans
+}
+
+// GetPeers returns the addresses of all known peers
+func (node *P2PNode) GetPeers() []string {
+	node.peersMutex.RLock()
+	defer node.peersMutex.RUnlock()
+
+	peers := make([]string, 0, len(node.peerAddresses))
+	for addr := range node.peerAddresses {
+		peers = append(peers, addr)
+	}
+	return peers
+}
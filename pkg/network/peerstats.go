@@ -0,0 +1,168 @@
+package network
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rttSmoothing is the weight of a new round-trip sample in the smoothed
+// latency, as in TCP's SRTT
+const rttSmoothing = 0.125
+
+// PeerTraffic is what this node exchanged with one peer since it started.
+// Latencies are round trips of requests answered on the same connection,
+// such as the clock measurements, in milliseconds.
+type PeerTraffic struct {
+	Peer        string  `json:"peer"`
+	Connected   bool    `json:"connected"` // in the current peer list
+	BytesIn     uint64  `json:"bytesIn"`
+	BytesOut    uint64  `json:"bytesOut"`
+	MessagesIn  uint64  `json:"messagesIn"`
+	MessagesOut uint64  `json:"messagesOut"`
+	Errors      uint64  `json:"errors"` // failed dials, sends and unanswered requests
+	RTTSamples  uint64  `json:"rttSamples"`
+	LastRTT     float64 `json:"lastRttMs"`
+	AvgRTT      float64 `json:"avgRttMs"` // smoothed, recent samples weigh more
+	MinRTT      float64 `json:"minRttMs"`
+	MaxRTT      float64 `json:"maxRttMs"`
+	LastSeen    int64   `json:"lastSeen,omitempty"` // unix time of the last message received
+}
+
+// NetworkStats sums the traffic of all peers
+type NetworkStats struct {
+	Since       time.Time     `json:"since"`
+	Peers       int           `json:"peers"` // peers with traffic
+	BytesIn     uint64        `json:"bytesIn"`
+	BytesOut    uint64        `json:"bytesOut"`
+	MessagesIn  uint64        `json:"messagesIn"`
+	MessagesOut uint64        `json:"messagesOut"`
+	PeerTraffic []PeerTraffic `json:"peerTraffic"` // slowest peers first
+}
+
+// maxTrackedPeers bounds the peers with counters of their own. Peer addresses
+// of inbound messages are chosen by the sender, so traffic of further peers is
+// pooled under otherPeers.
+const maxTrackedPeers = 1024
+
+// otherPeers collects the traffic of peers past maxTrackedPeers
+const otherPeers = "other"
+
+// peerStats holds the traffic counters of each peer
+type peerStats struct {
+	mu    sync.Mutex
+	since time.Time
+	peers map[string]*PeerTraffic
+}
+
+// peerLocked returns the counters of peer, creating them on first use.
+// The caller must hold s.mu.
+func (s *peerStats) peerLocked(peer string) *PeerTraffic {
+	traffic, exists := s.peers[peer]
+	if !exists {
+		if len(s.peers) >= maxTrackedPeers && peer != otherPeers {
+			return s.peerLocked(otherPeers)
+		}
+		traffic = &PeerTraffic{Peer: peer}
+		s.peers[peer] = traffic
+	}
+	return traffic
+}
+
+// recordSent counts a message of size bytes sent to peer
+func (node *P2PNode) recordSent(peer string, size int) {
+	node.traffic.mu.Lock()
+	defer node.traffic.mu.Unlock()
+	traffic := node.traffic.peerLocked(peer)
+	traffic.BytesOut += uint64(size)
+	traffic.MessagesOut++
+}
+
+// recordReceived counts a message of size bytes received from peer
+func (node *P2PNode) recordReceived(peer string, size int64) {
+	node.traffic.mu.Lock()
+	defer node.traffic.mu.Unlock()
+	traffic := node.traffic.peerLocked(peer)
+	traffic.BytesIn += uint64(size)
+	traffic.MessagesIn++
+	traffic.LastSeen = time.Now().Unix()
+}
+
+// recordError counts a failed exchange with peer
+func (node *P2PNode) recordError(peer string) {
+	node.traffic.mu.Lock()
+	defer node.traffic.mu.Unlock()
+	node.traffic.peerLocked(peer).Errors++
+}
+
+// recordRTT adds a round-trip sample of peer
+func (node *P2PNode) recordRTT(peer string, rtt time.Duration) {
+	ms := float64(rtt) / float64(time.Millisecond)
+
+	node.traffic.mu.Lock()
+	defer node.traffic.mu.Unlock()
+	traffic := node.traffic.peerLocked(peer)
+	if traffic.RTTSamples == 0 {
+		traffic.AvgRTT, traffic.MinRTT, traffic.MaxRTT = ms, ms, ms
+	} else {
+		traffic.AvgRTT += rttSmoothing * (ms - traffic.AvgRTT)
+		if ms < traffic.MinRTT {
+			traffic.MinRTT = ms
+		}
+		if ms > traffic.MaxRTT {
+			traffic.MaxRTT = ms
+		}
+	}
+	traffic.LastRTT = ms
+	traffic.RTTSamples++
+}
+
+// NetworkStats returns the traffic and latency of every peer this node
+// exchanged messages with, slowest first; peers without a latency sample
+// come last. Inbound traffic is attributed to the listen address a peer
+// announces, so it adds up with the traffic of connections we dial.
+func (node *P2PNode) NetworkStats() NetworkStats {
+	connected := make(map[string]bool)
+	for _, peer := range node.GetPeers() {
+		connected[peer] = true
+	}
+
+	node.traffic.mu.Lock()
+	stats := NetworkStats{Since: node.traffic.since, PeerTraffic: make([]PeerTraffic, 0, len(node.traffic.peers))}
+	for _, traffic := range node.traffic.peers {
+		peer := *traffic
+		peer.Connected = connected[peer.Peer]
+		stats.PeerTraffic = append(stats.PeerTraffic, peer)
+		stats.BytesIn += peer.BytesIn
+		stats.BytesOut += peer.BytesOut
+		stats.MessagesIn += peer.MessagesIn
+		stats.MessagesOut += peer.MessagesOut
+	}
+	node.traffic.mu.Unlock()
+
+	stats.Peers = len(stats.PeerTraffic)
+	sort.Slice(stats.PeerTraffic, func(i, j int) bool {
+		a, b := stats.PeerTraffic[i], stats.PeerTraffic[j]
+		if (a.RTTSamples > 0) != (b.RTTSamples > 0) {
+			return a.RTTSamples > 0
+		}
+		if a.AvgRTT != b.AvgRTT {
+			return a.AvgRTT > b.AvgRTT
+		}
+		return a.Peer < b.Peer
+	})
+	return stats
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
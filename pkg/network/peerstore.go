@@ -0,0 +1,270 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+const (
+	// initialPeerScore is the score given to newly learned peers. It is below
+	// minHealthyPeerScore, so an address only advertised by other peers is not
+	// redialed on startup until the node has reached it itself.
+	initialPeerScore = 15
+	// maxPeerScore caps the score of reliable peers
+	maxPeerScore = 100
+	// minHealthyPeerScore is the lowest score a peer may have to be reconnected on startup
+	minHealthyPeerScore = 20
+	// peerStaleAfter is how long a peer may go unseen before it is no longer considered healthy
+	peerStaleAfter = 7 * 24 * time.Hour
+	// maxStoredPeers caps the address book, which peers fill through discovery
+	maxStoredPeers = 1024
+)
+
+// defaultPeerStorePath returns the location of the peer address book in the data directory
+func defaultPeerStorePath() string {
+	return filepath.Join(blockchain.GetBlockchainDataPath(), "peers.json")
+}
+
+// PeerRecord is an entry of the persistent peer address book
+type PeerRecord struct {
+	Address   string    `json:"address"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Score     int       `json:"score"`
+	Failures  int       `json:"failures"`           // consecutive failed dials
+	Version   string    `json:"version,omitempty"`  // software version announced in the peer's messages
+	Protocol  int       `json:"protocol,omitempty"` // peer protocol version announced with it
+}
+
+// PeerStore is a peer address book persisted as JSON in the data directory
+type PeerStore struct {
+	path  string
+	peers map[string]*PeerRecord
+	mu    sync.RWMutex
+}
+
+// NewPeerStore creates a peer store backed by the given file and loads any existing entries
+func NewPeerStore(path string) *PeerStore {
+	ps := &PeerStore{
+		path:  path,
+		peers: make(map[string]*PeerRecord),
+	}
+	if err := ps.Load(); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to load peer store %s: %v", path, err)
+	}
+	return ps
+}
+
+// Load reads the peer address book from disk
+func (ps *PeerStore) Load() error {
+	data, err := ioutil.ReadFile(ps.path)
+	if err != nil {
+		return err
+	}
+
+	var records []*PeerRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("failed to unmarshal peer store: %v", err)
+	}
+
+	// Keep the best records of an address book written before it was capped
+	sort.Slice(records, func(i, j int) bool { return records[i].Score > records[j].Score })
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	for _, rec := range records {
+		if len(ps.peers) >= maxStoredPeers {
+			break
+		}
+		if rec.Address != "" {
+			ps.peers[rec.Address] = rec
+		}
+	}
+	return nil
+}
+
+// Save writes the peer address book to disk
+func (ps *PeerStore) Save() error {
+	records := ps.List()
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal peer store: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(ps.path), 0755); err != nil {
+		return fmt.Errorf("failed to create peer store directory: %v", err)
+	}
+
+	// Write to a temp file first so a crash never leaves a truncated address book
+	tmp := ps.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write peer store: %v", err)
+	}
+	return os.Rename(tmp, ps.path)
+}
+
+// getOrCreate returns the record for address, creating it if needed; caller must hold mu.
+// When the address book is full, the new record replaces the worst one if that
+// one scores no better than a new peer; otherwise getOrCreate returns nil.
+func (ps *PeerStore) getOrCreate(address string) *PeerRecord {
+	rec, exists := ps.peers[address]
+	if !exists {
+		if len(ps.peers) >= maxStoredPeers && !ps.evictLocked() {
+			return nil
+		}
+		rec = &PeerRecord{
+			Address:   address,
+			FirstSeen: time.Now(),
+			Score:     initialPeerScore,
+		}
+		ps.peers[address] = rec
+	}
+	return rec
+}
+
+// evictLocked removes the lowest scored record, least recently seen first,
+// unless even that one scores better than a new peer; caller must hold mu
+func (ps *PeerStore) evictLocked() bool {
+	var worst *PeerRecord
+	for _, rec := range ps.peers {
+		if worst == nil || rec.Score < worst.Score ||
+			(rec.Score == worst.Score && rec.LastSeen.Before(worst.LastSeen)) {
+			worst = rec
+		}
+	}
+	if worst == nil || worst.Score > initialPeerScore {
+		return false
+	}
+	delete(ps.peers, worst.Address)
+	return true
+}
+
+// Add records a peer learned through discovery without changing its score.
+// It reports whether the peer is in the address book, which is false when
+// the book is full of better peers.
+func (ps *PeerStore) Add(address string) bool {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.getOrCreate(address) != nil
+}
+
+// RecordSuccess marks a successful interaction with a peer and raises its score
+func (ps *PeerStore) RecordSuccess(address string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	rec := ps.getOrCreate(address)
+	if rec == nil {
+		return
+	}
+	rec.LastSeen = time.Now()
+	rec.Failures = 0
+	rec.Score += 5
+	if rec.Score > maxPeerScore {
+		rec.Score = maxPeerScore
+	}
+}
+
+// RecordFailure marks a failed dial or bad message from a peer and lowers its score
+func (ps *PeerStore) RecordFailure(address string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	rec := ps.getOrCreate(address)
+	if rec == nil {
+		return
+	}
+	rec.Failures++
+	rec.Score -= 10
+	if rec.Score < 0 {
+		rec.Score = 0
+	}
+}
+
+// RecordHostFailure lowers the score of every peer on host, for bad messages
+// from a connection that did not say which of them it is
+func (ps *PeerStore) RecordHostFailure(host string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for address, rec := range ps.peers {
+		if peerHost, _, err := net.SplitHostPort(address); err != nil || peerHost != host {
+			continue
+		}
+		rec.Failures++
+		rec.Score -= 10
+		if rec.Score < 0 {
+			rec.Score = 0
+		}
+	}
+}
+
+// RecordVersion stores the software and protocol versions a peer announced
+func (ps *PeerStore) RecordVersion(address, version string, protocol int) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	record := ps.getOrCreate(address)
+	if record == nil {
+		return
+	}
+	record.Version = version
+	record.Protocol = protocol
+}
+
+// Remove deletes a peer from the address book
+func (ps *PeerStore) Remove(address string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	delete(ps.peers, address)
+}
+
+// List returns a copy of all records sorted by score (highest first)
+func (ps *PeerStore) List() []*PeerRecord {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	records := make([]*PeerRecord, 0, len(ps.peers))
+	for _, rec := range ps.peers {
+		copied := *rec
+		records = append(records, &copied)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Score != records[j].Score {
+			return records[i].Score > records[j].Score
+		}
+		return records[i].LastSeen.After(records[j].LastSeen)
+	})
+	return records
+}
+
+// HealthyPeers returns up to limit peers worth reconnecting to, best first.
+// A peer is healthy when its score is high enough and it was seen recently;
+// peers the node has never reached itself are not.
+func (ps *PeerStore) HealthyPeers(limit int) []string {
+	var healthy []string
+	for _, rec := range ps.List() {
+		if rec.Score < minHealthyPeerScore {
+			continue
+		}
+		if rec.LastSeen.IsZero() || time.Since(rec.LastSeen) > peerStaleAfter {
+			continue
+		}
+		healthy = append(healthy, rec.Address)
+		if limit > 0 && len(healthy) >= limit {
+			break
+		}
+	}
+	return healthy
+}
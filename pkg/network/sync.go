@@ -0,0 +1,139 @@
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+
+	"confirmix/pkg/blockchain"
+)
+
+// maxSyncBlocks is the number of blocks a peer sends in answer to one sync request
+const maxSyncBlocks = 500
+
+// SyncRequestMessage asks a peer to send the blocks from FromHeight on
+type SyncRequestMessage struct {
+	FromHeight uint64 `json:"from_height"`
+}
+
+// chainSignals counts what block gossip reveals about the local chain
+type chainSignals struct {
+	mu                sync.Mutex
+	conflictingBlocks uint64 // blocks that do not extend or match the local chain
+	peerHeight        uint64 // highest block index announced by a peer
+}
+
+// recordBlock classifies a block received from a peer after AddBlock returned err
+func (node *P2PNode) recordBlock(block *blockchain.Block, err error) {
+	if block == nil {
+		return
+	}
+	conflict := errors.Is(err, blockchain.ErrInvalidPrevHash)
+	if errors.Is(err, blockchain.ErrInvalidBlockIndex) {
+		// A different block at a height we already have means the peer is on another branch
+		if local, lookupErr := node.blockchain.GetBlockByIndex(block.Index); lookupErr == nil && local.Hash != block.Hash {
+			conflict = true
+		}
+	}
+
+	node.signals.mu.Lock()
+	defer node.signals.mu.Unlock()
+	if conflict {
+		node.signals.conflictingBlocks++
+	}
+	if block.Index > node.signals.peerHeight {
+		node.signals.peerHeight = block.Index
+	}
+}
+
+// ConflictingBlocks returns the number of received blocks that conflicted with
+// the local chain. A count that keeps growing indicates a persistent fork.
+func (node *P2PNode) ConflictingBlocks() uint64 {
+	node.signals.mu.Lock()
+	defer node.signals.mu.Unlock()
+	return node.signals.conflictingBlocks
+}
+
+// PeerHeight returns the highest block index announced by a peer
+func (node *P2PNode) PeerHeight() uint64 {
+	node.signals.mu.Lock()
+	defer node.signals.mu.Unlock()
+	return node.signals.peerHeight
+}
+
+// RequestSync asks every peer for the blocks following the local chain head
+func (node *P2PNode) RequestSync() error {
+	if len(node.GetPeers()) == 0 {
+		return errors.New("no peers to sync from")
+	}
+	from := node.blockchain.GetChainHeight() + 1
+	log.Printf("Requesting blocks from height %d from peers", from)
+	return node.Broadcast("sync_request", SyncRequestMessage{FromHeight: from})
+}
+
+// handleSyncRequest sends the requested blocks back to the peer, one message each
+func (node *P2PNode) handleSyncRequest(from string, payload []byte) error {
+	var req SyncRequestMessage
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("failed to unmarshal sync request: %v", err)
+	}
+
+	height := node.blockchain.GetChainHeight()
+	if req.FromHeight > height {
+		return nil
+	}
+	last := height
+	if last-req.FromHeight >= maxSyncBlocks {
+		last = req.FromHeight + maxSyncBlocks - 1
+	}
+
+	go func() {
+		for index := req.FromHeight; index <= last; index++ {
+			block, err := node.blockchain.GetBlockByIndex(index)
+			if err != nil {
+				log.Printf("Sync for %s stopped at block %d: %v", from, index, err)
+				return
+			}
+			conn, err := net.Dial("tcp", from)
+			if err != nil {
+				log.Printf("Sync for %s stopped, failed to connect: %v", from, err)
+				node.peerStore.RecordFailure(from)
+				return
+			}
+			err = node.sendMessage(conn, from, "block", BlockMessage{Block: block})
+			conn.Close()
+			if err != nil {
+				log.Printf("Sync for %s stopped at block %d: %v", from, index, err)
+				return
+			}
+		}
+		log.Printf("Sent blocks %d-%d to %s", req.FromHeight, last, from)
+	}()
+	return nil
+}
+
+// RotatePeers drops the current peers and reconnects to the healthiest peers of
+// the address book. The old peers are kept when none of the stored ones can be
+// reached. It returns the number of peers connected.
+func (node *P2PNode) RotatePeers() int {
+	node.peersMutex.Lock()
+	previous := node.peerAddresses
+	node.peerAddresses = make(map[string]bool)
+	node.peersMutex.Unlock()
+
+	connected := node.ReconnectKnownPeers(nil)
+	if connected == 0 {
+		node.peersMutex.Lock()
+		for addr := range previous {
+			node.peerAddresses[addr] = true
+		}
+		node.peersMutex.Unlock()
+		log.Printf("Peer rotation found no reachable stored peers, keeping %d current peers", len(previous))
+		return 0
+	}
+	log.Printf("Rotated peers: dropped %d, connected %d", len(previous), connected)
+	return connected
+}
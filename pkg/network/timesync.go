@@ -0,0 +1,140 @@
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// ErrClockSkew is returned when the local clock is too far from the peers' clocks
+var ErrClockSkew = errors.New("local clock is out of sync with the peers")
+
+// TimeSyncMessage carries the timestamps of a clock offset measurement.
+// The requester sets Sent; the peer echoes it and adds its own time.
+type TimeSyncMessage struct {
+	Sent     int64 `json:"sent"`                // requester clock, Unix nanoseconds
+	PeerTime int64 `json:"peer_time,omitempty"` // peer clock when answering, Unix nanoseconds
+}
+
+// clockOffsets holds the last measured clock offset of each peer. A positive
+// offset means the peer's clock is ahead of ours.
+type clockOffsets struct {
+	mu      sync.RWMutex
+	offsets map[string]time.Duration
+}
+
+// handleTimeRequest answers a clock offset measurement with the local time
+func (node *P2PNode) handleTimeRequest(from string, payload []byte) (string, interface{}, error) {
+	var req TimeSyncMessage
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return "", nil, fmt.Errorf("failed to unmarshal time request: %v", err)
+	}
+	req.PeerTime = blockchain.Now().UnixNano()
+	return "time_reply", req, nil
+}
+
+// MeasureClockOffset asks peer for its time and estimates how far its clock
+// is from ours, assuming the request and the reply took equally long. The
+// offset is remembered for ClockOffset, and a warning is logged when it
+// exceeds MaxClockOffset.
+func (node *P2PNode) MeasureClockOffset(peer string) (time.Duration, error) {
+	sent := blockchain.Now()
+	msg, err := node.request(peer, "time_request", TimeSyncMessage{Sent: sent.UnixNano()})
+	if err != nil {
+		return 0, err
+	}
+	received := blockchain.Now()
+	if msg.Type != "time_reply" {
+		return 0, fmt.Errorf("unexpected reply type from %s: %s", peer, msg.Type)
+	}
+	var reply TimeSyncMessage
+	if err := json.Unmarshal(msg.Payload, &reply); err != nil {
+		return 0, fmt.Errorf("invalid time reply from %s: %v", peer, err)
+	}
+	if reply.Sent != sent.UnixNano() || reply.PeerTime == 0 {
+		return 0, fmt.Errorf("time reply from %s does not answer our request", peer)
+	}
+
+	midpoint := sent.Add(received.Sub(sent) / 2)
+	offset := time.Unix(0, reply.PeerTime).Sub(midpoint)
+
+	node.clocks.mu.Lock()
+	node.clocks.offsets[peer] = offset
+	node.clocks.mu.Unlock()
+
+	if max := node.config.MaxClockOffset; max > 0 && absDuration(offset) > max {
+		log.Printf("Warning: clock of peer %s is %s off ours (round trip %s), more than the allowed %s",
+			peer, offset, received.Sub(sent), max)
+	}
+	return offset, nil
+}
+
+// ClockOffsets returns the last measured clock offset of each peer
+func (node *P2PNode) ClockOffsets() map[string]time.Duration {
+	node.clocks.mu.RLock()
+	defer node.clocks.mu.RUnlock()
+	offsets := make(map[string]time.Duration, len(node.clocks.offsets))
+	for peer, offset := range node.clocks.offsets {
+		offsets[peer] = offset
+	}
+	return offsets
+}
+
+// ClockOffset returns the median of the measured peer clock offsets and the
+// number of peers it is based on. The median keeps a single peer with a wrong
+// clock from making ours look wrong.
+func (node *P2PNode) ClockOffset() (time.Duration, int) {
+	node.clocks.mu.RLock()
+	offsets := make([]time.Duration, 0, len(node.clocks.offsets))
+	for _, offset := range node.clocks.offsets {
+		offsets = append(offsets, offset)
+	}
+	node.clocks.mu.RUnlock()
+
+	if len(offsets) == 0 {
+		return 0, 0
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	mid := len(offsets) / 2
+	if len(offsets)%2 == 0 {
+		return (offsets[mid-1] + offsets[mid]) / 2, len(offsets)
+	}
+	return offsets[mid], len(offsets)
+}
+
+// CheckClock returns ErrClockSkew when the median peer clock offset exceeds
+// MaxClockOffset. Block timestamp rules and proof of humanity expiries assume
+// roughly synchronized clocks, so a validator should not produce blocks with
+// a skewed clock. It passes when no offset was measured or the check is
+// disabled.
+func (node *P2PNode) CheckClock() error {
+	max := node.config.MaxClockOffset
+	if max <= 0 {
+		return nil
+	}
+	offset, peers := node.ClockOffset()
+	if peers == 0 || absDuration(offset) <= max {
+		return nil
+	}
+	return fmt.Errorf("%w: peers are %s off on median over %d peers, the limit is %s", ErrClockSkew, offset, peers, max)
+}
+
+// forgetClockOffset drops the measured offset of a peer
+func (node *P2PNode) forgetClockOffset(peer string) {
+	node.clocks.mu.Lock()
+	delete(node.clocks.offsets, peer)
+	node.clocks.mu.Unlock()
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
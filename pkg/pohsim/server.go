@@ -0,0 +1,338 @@
+// Package pohsim implements a standalone Proof of Humanity provider simulator.
+// It speaks the same HTTP protocol as consensus.ExternalPoHVerifier expects from
+// a real provider, with configurable latency and failure injection.
+package pohsim
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	mathrand "math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Verification states
+const (
+	StatusPending  = "pending"
+	StatusVerified = "verified"
+	StatusRejected = "rejected"
+)
+
+// Config controls the behaviour of the simulator
+type Config struct {
+	Port        int
+	APIKey      string        // required as a bearer token on /api/ routes when set
+	Latency     time.Duration // added to every request
+	Jitter      time.Duration // random extra latency up to this value
+	FailureRate float64       // fraction of API requests answered with 503
+	RejectRate  float64       // fraction of verifications that are rejected as not human
+	AutoVerify  bool          // verify on initiate, without visiting the verification page
+	TokenTTL    time.Duration // how long an unfinished verification stays valid
+	StatePath   string        // JSON file the verification records are persisted to; empty disables persistence
+}
+
+// DefaultConfig returns a simulator configuration without latency or failures
+func DefaultConfig() *Config {
+	return &Config{
+		Port:      8090,
+		TokenTTL:  time.Hour,
+		StatePath: filepath.Join("data", "poh-simulator.json"),
+	}
+}
+
+// Record is a verification tracked by the simulator
+type Record struct {
+	Address    string    `json:"address"`
+	Token      string    `json:"token"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	VerifiedAt time.Time `json:"verified_at,omitempty"`
+}
+
+// Server is the PoH simulator HTTP service
+type Server struct {
+	config  *Config
+	records map[string]*Record // token -> record
+	mu      sync.Mutex
+	rng     *mathrand.Rand
+	rngMu   sync.Mutex
+}
+
+// NewServer creates a simulator and loads persisted verification state
+func NewServer(config *Config) *Server {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	s := &Server{
+		config:  config,
+		records: make(map[string]*Record),
+		rng:     mathrand.New(mathrand.NewSource(time.Now().UnixNano())),
+	}
+	if err := s.load(); err != nil && !os.IsNotExist(err) {
+		log.Printf("PoH simulator: failed to load state %s: %v", config.StatePath, err)
+	}
+	return s
+}
+
+// Handler returns the HTTP handler of the simulator
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/verification/initiate", s.handleInitiate)
+	mux.HandleFunc("/api/verification/status", s.handleStatus)
+	mux.HandleFunc("/api/verification/records", s.handleRecords)
+	mux.HandleFunc("/verify", s.handleVerify)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return s.middleware(mux)
+}
+
+// ListenAndServe serves the simulator on the configured port
+func (s *Server) ListenAndServe() error {
+	addr := fmt.Sprintf(":%d", s.config.Port)
+	log.Printf("PoH simulator listening on %s (latency %v, jitter %v, failure rate %.2f, reject rate %.2f)",
+		addr, s.config.Latency, s.config.Jitter, s.config.FailureRate, s.config.RejectRate)
+	return http.ListenAndServe(addr, s.Handler())
+}
+
+// middleware applies latency, failure injection and API key checks
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if delay := s.delay(); delay > 0 {
+			time.Sleep(delay)
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			if s.config.APIKey != "" && r.Header.Get("Authorization") != "Bearer "+s.config.APIKey {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid api key"})
+				return
+			}
+			if s.chance(s.config.FailureRate) {
+				writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "simulated provider failure"})
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// delay returns the latency to add to the current request
+func (s *Server) delay() time.Duration {
+	d := s.config.Latency
+	if s.config.Jitter > 0 {
+		s.rngMu.Lock()
+		d += time.Duration(s.rng.Int63n(int64(s.config.Jitter)))
+		s.rngMu.Unlock()
+	}
+	return d
+}
+
+// chance returns true with probability p
+func (s *Server) chance(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	s.rngMu.Lock()
+	defer s.rngMu.Unlock()
+	return s.rng.Float64() < p
+}
+
+// handleInitiate handles POST /api/verification/initiate {"address": "..."}
+func (s *Server) handleInitiate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	var req struct {
+		Address string `json:"address"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Address == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "address is required"})
+		return
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create token"})
+		return
+	}
+
+	record := &Record{
+		Address:   req.Address,
+		Token:     hex.EncodeToString(buf),
+		Status:    StatusPending,
+		CreatedAt: time.Now(),
+	}
+	if s.config.AutoVerify {
+		s.complete(record)
+	}
+
+	s.mu.Lock()
+	s.records[record.Token] = record
+	s.mu.Unlock()
+	s.persist()
+
+	log.Printf("PoH simulator: initiated verification for %s", req.Address)
+	writeJSON(w, http.StatusCreated, map[string]string{
+		"token":  record.Token,
+		"status": record.Status,
+	})
+}
+
+// handleStatus handles GET /api/verification/status?address=&token=
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	record, status, errMsg := s.lookup(r.URL.Query().Get("address"), r.URL.Query().Get("token"))
+	if record == nil {
+		writeJSON(w, status, map[string]string{"error": errMsg})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"address":  record.Address,
+		"status":   record.Status,
+		"verified": record.Status == StatusVerified,
+	})
+}
+
+// handleVerify handles GET /verify?address=&token=, the page a user visits to complete verification
+func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
+	record, status, errMsg := s.lookup(r.URL.Query().Get("address"), r.URL.Query().Get("token"))
+	if record == nil {
+		writeJSON(w, status, map[string]string{"error": errMsg})
+		return
+	}
+
+	s.mu.Lock()
+	if record.Status == StatusPending {
+		s.complete(record)
+	}
+	result := *record
+	s.mu.Unlock()
+	s.persist()
+
+	log.Printf("PoH simulator: verification for %s is %s", result.Address, result.Status)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"address":  result.Address,
+		"status":   result.Status,
+		"verified": result.Status == StatusVerified,
+	})
+}
+
+// handleRecords handles GET /api/verification/records and lists all known verifications
+func (s *Server) handleRecords(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Records())
+}
+
+// complete decides the outcome of a pending verification according to the reject rate
+func (s *Server) complete(record *Record) {
+	if s.chance(s.config.RejectRate) {
+		record.Status = StatusRejected
+		return
+	}
+	record.Status = StatusVerified
+	record.VerifiedAt = time.Now()
+}
+
+// lookup finds the record for a token and checks it belongs to address and has not expired
+func (s *Server) lookup(address, token string) (*Record, int, string) {
+	if address == "" || token == "" {
+		return nil, http.StatusBadRequest, "address and token are required"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, exists := s.records[token]
+	if !exists || record.Address != address {
+		return nil, http.StatusNotFound, "verification not found"
+	}
+	if record.Status == StatusPending && s.config.TokenTTL > 0 && time.Since(record.CreatedAt) > s.config.TokenTTL {
+		return nil, http.StatusGone, "verification expired"
+	}
+	return record, http.StatusOK, ""
+}
+
+// Records returns a copy of all verification records, newest first
+func (s *Server) Records() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]Record, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+	return records
+}
+
+// load reads persisted verification records
+func (s *Server) load() error {
+	if s.config.StatePath == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(s.config.StatePath)
+	if err != nil {
+		return err
+	}
+
+	var records []*Record
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("failed to unmarshal state: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, record := range records {
+		s.records[record.Token] = record
+	}
+	log.Printf("PoH simulator: loaded %d verification records", len(records))
+	return nil
+}
+
+// persist writes the verification records to disk
+func (s *Server) persist() {
+	if s.config.StatePath == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(s.Records(), "", "  ")
+	if err != nil {
+		log.Printf("PoH simulator: failed to marshal state: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(s.config.StatePath), 0755); err != nil {
+		log.Printf("PoH simulator: failed to create state directory: %v", err)
+		return
+	}
+
+	// Write to a temp file first so a crash never leaves truncated state
+	tmp := s.config.StatePath + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		log.Printf("PoH simulator: failed to write state: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, s.config.StatePath); err != nil {
+		log.Printf("PoH simulator: failed to replace state: %v", err)
+	}
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,273 @@
+package replica
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// StreamPath is the primary's block stream, served as Server-Sent Events
+const StreamPath = "/api/replica/blocks"
+
+// APIKeyHeader carries the replica's API key to the primary
+const APIKeyHeader = "X-API-Key"
+
+// maxEventSize is the largest stream line accepted, which bounds the size of a block
+const maxEventSize = 32 << 20
+
+// Config selects the primary a replica follows
+type Config struct {
+	PrimaryURL       string        `json:"primary_url"` // base URL of the primary's API
+	APIKey           string        `json:"api_key"`     // key with the replica scope on the primary
+	RetryInterval    time.Duration `json:"-"`           // first wait before reconnecting
+	MaxRetryInterval time.Duration `json:"-"`           // longest wait before reconnecting
+}
+
+// DefaultConfig returns the default reconnect settings; the primary and key must be set
+func DefaultConfig() *Config {
+	return &Config{
+		RetryInterval:    time.Second,
+		MaxRetryInterval: time.Minute,
+	}
+}
+
+// Status reports how far the replica follows its primary
+type Status struct {
+	Primary       string `json:"primary"`
+	Connected     bool   `json:"connected"`
+	Height        uint64 `json:"height"`                  // local chain height
+	PrimaryHeight uint64 `json:"primaryHeight,omitempty"` // newest block announced by the primary
+	Lag           uint64 `json:"lag"`                     // blocks behind the primary
+	LastBlockAt   int64  `json:"lastBlockAt,omitempty"`   // when the last block was applied
+	Reconnects    int    `json:"reconnects"`
+	LastError     string `json:"lastError,omitempty"`
+}
+
+// Replica applies the blocks of a trusted primary, received over the
+// primary's authenticated block stream instead of gossip. Every block is
+// still verified by the local chain. A replica serves reads only.
+type Replica struct {
+	blockchain *blockchain.Blockchain
+	config     *Config
+	client     *http.Client
+
+	mu            sync.Mutex
+	connected     bool
+	primaryHeight uint64
+	lastBlockAt   time.Time
+	reconnects    int
+	lastError     string
+	stopCh        chan struct{}
+	resp          *http.Response // current stream, closed by Stop
+}
+
+// New creates a replica of the primary in config
+func New(bc *blockchain.Blockchain, config *Config) (*Replica, error) {
+	if config == nil || config.PrimaryURL == "" {
+		return nil, errors.New("replica needs the URL of its primary")
+	}
+	if _, err := url.Parse(config.PrimaryURL); err != nil {
+		return nil, fmt.Errorf("invalid primary URL: %v", err)
+	}
+	if config.APIKey == "" {
+		return nil, errors.New("replica needs an API key with the replica scope on its primary")
+	}
+	defaults := DefaultConfig()
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = defaults.RetryInterval
+	}
+	if config.MaxRetryInterval < config.RetryInterval {
+		config.MaxRetryInterval = defaults.MaxRetryInterval
+	}
+	return &Replica{
+		blockchain: bc,
+		config:     config,
+		// No timeout: the stream stays open while the primary produces blocks
+		client: &http.Client{},
+	}, nil
+}
+
+// Start begins following the primary
+func (r *Replica) Start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopCh != nil {
+		return errors.New("replica already running")
+	}
+	stopCh := make(chan struct{})
+	r.stopCh = stopCh
+	go r.run(stopCh)
+	log.Printf("Replica following %s", r.config.PrimaryURL)
+	return nil
+}
+
+// Stop disconnects from the primary
+func (r *Replica) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopCh == nil {
+		return
+	}
+	close(r.stopCh)
+	r.stopCh = nil
+	if r.resp != nil {
+		r.resp.Body.Close()
+	}
+}
+
+// run keeps a stream to the primary open, reconnecting with backoff
+func (r *Replica) run(stopCh chan struct{}) {
+	wait := r.config.RetryInterval
+	for {
+		applied, err := r.follow(stopCh)
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+		if applied > 0 {
+			wait = r.config.RetryInterval
+		}
+
+		r.mu.Lock()
+		r.connected = false
+		r.reconnects++
+		if err != nil {
+			r.lastError = err.Error()
+		}
+		r.mu.Unlock()
+		if err != nil {
+			log.Printf("Replica stream from %s ended: %v; reconnecting in %s", r.config.PrimaryURL, err, wait)
+		}
+
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > r.config.MaxRetryInterval {
+			wait = r.config.MaxRetryInterval
+		}
+	}
+}
+
+// follow streams blocks from the one after the local tip until the stream
+// ends, returning the number of blocks applied
+func (r *Replica) follow(stopCh chan struct{}) (int, error) {
+	from := r.blockchain.GetChainHeight() + 1
+	streamURL := strings.TrimRight(r.config.PrimaryURL, "/") + StreamPath + "?from=" + strconv.FormatUint(from, 10)
+	req, err := http.NewRequest(http.MethodGet, streamURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set(APIKeyHeader, r.config.APIKey)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("primary answered %s", resp.Status)
+	}
+
+	r.mu.Lock()
+	if r.stopCh != stopCh {
+		r.mu.Unlock()
+		return 0, nil
+	}
+	r.resp = resp
+	r.connected = true
+	r.lastError = ""
+	r.mu.Unlock()
+
+	applied := 0
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxEventSize)
+	var event, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if err := r.handleEvent(event, data); err != nil {
+				return applied, err
+			}
+			if event == "block" {
+				applied++
+			}
+			event, data = "", ""
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return applied, err
+	}
+	return applied, errors.New("primary closed the stream")
+}
+
+// handleEvent applies a streamed block or records the primary's height
+func (r *Replica) handleEvent(event, data string) error {
+	switch event {
+	case "block":
+		var block blockchain.Block
+		if err := json.Unmarshal([]byte(data), &block); err != nil {
+			return fmt.Errorf("malformed block from primary: %v", err)
+		}
+		if err := r.blockchain.AddBlock(&block); err != nil {
+			return fmt.Errorf("block %d from primary rejected: %w", block.Index, err)
+		}
+		r.mu.Lock()
+		r.lastBlockAt = time.Now()
+		if block.Index > r.primaryHeight {
+			r.primaryHeight = block.Index
+		}
+		r.mu.Unlock()
+	case "height":
+		height, err := strconv.ParseUint(data, 10, 64)
+		if err != nil {
+			return fmt.Errorf("malformed height from primary: %v", err)
+		}
+		r.mu.Lock()
+		r.primaryHeight = height
+		r.mu.Unlock()
+	case "error":
+		return fmt.Errorf("primary: %s", data)
+	}
+	return nil
+}
+
+// Status reports the connection to the primary and how far behind it the replica is
+func (r *Replica) Status() Status {
+	height := r.blockchain.GetChainHeight()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := Status{
+		Primary:       r.config.PrimaryURL,
+		Connected:     r.connected,
+		Height:        height,
+		PrimaryHeight: r.primaryHeight,
+		Reconnects:    r.reconnects,
+		LastError:     r.lastError,
+	}
+	if r.primaryHeight > height {
+		status.Lag = r.primaryHeight - height
+	}
+	if !r.lastBlockAt.IsZero() {
+		status.LastBlockAt = r.lastBlockAt.Unix()
+	}
+	return status
+}
@@ -0,0 +1,188 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// awsKMSSigner signs with an ECC_NIST_P256 key in AWS KMS through its JSON API,
+// with requests signed using AWS Signature V4
+type awsKMSSigner struct {
+	remoteKey
+	config     Config
+	endpoint   *url.URL
+	httpClient *http.Client
+}
+
+func newAWSKMSSigner(config Config) (*awsKMSSigner, error) {
+	if config.KeyID == "" {
+		return nil, errors.New("awskms signer requires key_id, the KMS key ID or ARN")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://kms." + config.Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid kms endpoint %q", config.Endpoint)
+	}
+
+	s := &awsKMSSigner{
+		config:     config,
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: config.timeout()},
+	}
+
+	var resp struct {
+		PublicKey string `json:"PublicKey"`
+		KeySpec   string `json:"KeySpec"`
+	}
+	if err := s.call("GetPublicKey", map[string]string{"KeyId": config.KeyID}, &resp); err != nil {
+		return nil, fmt.Errorf("%w: failed to read public key: %v", ErrUnavailable, err)
+	}
+	if resp.KeySpec != "ECC_NIST_P256" {
+		return nil, fmt.Errorf("kms key %s has spec %s, expected ECC_NIST_P256", config.KeyID, resp.KeySpec)
+	}
+	der, err := base64.StdEncoding.DecodeString(resp.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode kms public key: %v", err)
+	}
+	if s.public, err = parsePublicKeyDER(der); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *awsKMSSigner) Driver() string { return DriverAWSKMS }
+
+// Sign signs a digest in KMS
+func (s *awsKMSSigner) Sign(digest []byte) ([]byte, error) {
+	var resp struct {
+		Signature string `json:"Signature"`
+	}
+	err := s.call("Sign", map[string]string{
+		"KeyId":            s.config.KeyID,
+		"Message":          base64.StdEncoding.EncodeToString(digest),
+		"MessageType":      "DIGEST",
+		"SigningAlgorithm": "ECDSA_SHA_256",
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	der, err := base64.StdEncoding.DecodeString(resp.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode kms signature: %v", err)
+	}
+	return derToRS(der)
+}
+
+// call invokes a KMS action and decodes its response into out
+func (s *awsKMSSigner) call(action string, input interface{}, out interface{}) error {
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required")
+	}
+
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.endpoint.String()+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	s.sign(req, body, accessKey, secretKey, time.Now().UTC())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("kms request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("kms %s failed with status %d: %s", action, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, out)
+}
+
+// sign adds AWS Signature Version 4 headers to req
+func (s *awsKMSSigner) sign(req *http.Request, body []byte, accessKey, secretKey string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	// Signed headers must be listed in sorted order
+	headers := [][2]string{
+		{"content-type", req.Header.Get("Content-Type")},
+		{"host", req.URL.Host},
+		{"x-amz-date", amzDate},
+	}
+	if token := req.Header.Get("X-Amz-Security-Token"); token != "" {
+		headers = append(headers, [2]string{"x-amz-security-token", token})
+	}
+	headers = append(headers, [2]string{"x-amz-target", req.Header.Get("X-Amz-Target")})
+
+	names := make([]string, len(headers))
+	canonicalHeaders := ""
+	for i, h := range headers {
+		names[i] = h[0]
+		canonicalHeaders += h[0] + ":" + h[1] + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/kms/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "kms")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,169 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gcpMetadataTokenURL is where a node running on Google Cloud obtains access
+// tokens for its service account
+const gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// gcpKMSSigner signs with an EC_SIGN_P256_SHA256 key version in Google Cloud
+// KMS through its REST API
+type gcpKMSSigner struct {
+	remoteKey
+	config     Config
+	httpClient *http.Client
+
+	tokenMu     sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+func newGCPKMSSigner(config Config) (*gcpKMSSigner, error) {
+	if !strings.Contains(config.KeyID, "/cryptoKeyVersions/") {
+		return nil, errors.New("gcpkms signer requires key_id, the key version resource name (projects/.../cryptoKeyVersions/N)")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://cloudkms.googleapis.com"
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+
+	s := &gcpKMSSigner{
+		config:     config,
+		httpClient: &http.Client{Timeout: config.timeout()},
+	}
+
+	var resp struct {
+		PEM       string `json:"pem"`
+		Algorithm string `json:"algorithm"`
+	}
+	if err := s.call(http.MethodGet, "/publicKey", nil, &resp); err != nil {
+		return nil, fmt.Errorf("%w: failed to read public key: %v", ErrUnavailable, err)
+	}
+	if resp.Algorithm != "EC_SIGN_P256_SHA256" {
+		return nil, fmt.Errorf("kms key %s has algorithm %s, expected EC_SIGN_P256_SHA256", config.KeyID, resp.Algorithm)
+	}
+	block, _ := pem.Decode([]byte(resp.PEM))
+	if block == nil {
+		return nil, errors.New("failed to decode kms public key PEM")
+	}
+	public, err := parsePublicKeyDER(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	s.public = public
+	return s, nil
+}
+
+func (s *gcpKMSSigner) Driver() string { return DriverGCPKMS }
+
+// Sign signs a digest in KMS
+func (s *gcpKMSSigner) Sign(digest []byte) ([]byte, error) {
+	input := map[string]interface{}{
+		"digest": map[string]string{"sha256": base64.StdEncoding.EncodeToString(digest)},
+	}
+	var resp struct {
+		Signature string `json:"signature"`
+	}
+	if err := s.call(http.MethodPost, ":asymmetricSign", input, &resp); err != nil {
+		return nil, err
+	}
+	der, err := base64.StdEncoding.DecodeString(resp.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode kms signature: %v", err)
+	}
+	return derToRS(der)
+}
+
+// call sends an authorized request for the key version and decodes the
+// response into out. suffix is appended to the key version URL.
+func (s *gcpKMSSigner) call(method, suffix string, input interface{}, out interface{}) error {
+	token, err := s.accessToken()
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	if input != nil {
+		if body, err = json.Marshal(input); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, s.config.Endpoint+"/v1/"+s.config.KeyID+suffix, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("kms request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("kms request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, out)
+}
+
+// accessToken returns GCP_ACCESS_TOKEN, or a cached token from the metadata server
+func (s *gcpKMSSigner) accessToken() (string, error) {
+	if token := os.Getenv("GCP_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+
+	if s.token != "" && time.Now().Before(s.tokenExpiry) {
+		return s.token, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, gcpMetadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("no GCP_ACCESS_TOKEN set and the metadata server is unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata token request failed with status %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode metadata token: %v", err)
+	}
+
+	s.token = token.AccessToken
+	// Refresh a minute early so requests never go out with an expired token
+	s.tokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return s.token, nil
+}
@@ -0,0 +1,94 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// pkcs11Signer signs with a key on an HSM or token through its PKCS#11 module.
+// The module is driven by OpenSC's pkcs11-tool, which keeps the node free of
+// cgo and lets any vendor module be used without rebuilding it.
+type pkcs11Signer struct {
+	remoteKey
+	config Config
+	tool   string
+}
+
+func newPKCS11Signer(config Config) (*pkcs11Signer, error) {
+	if config.PKCS11Module == "" {
+		return nil, errors.New("pkcs11 signer requires pkcs11_module")
+	}
+	if _, err := hex.DecodeString(config.KeyID); err != nil || config.KeyID == "" {
+		return nil, errors.New("pkcs11 signer requires key_id, the hex object ID of the key")
+	}
+	tool := config.PKCS11Tool
+	if tool == "" {
+		tool = "pkcs11-tool"
+	}
+
+	s := &pkcs11Signer{config: config, tool: tool}
+	der, err := s.run(nil, "--read-object", "--type", "pubkey", "--id", config.KeyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read public key: %v", ErrUnavailable, err)
+	}
+	public, err := parsePublicKeyDER(der)
+	if err != nil {
+		return nil, err
+	}
+	s.public = public
+	return s, nil
+}
+
+func (s *pkcs11Signer) Driver() string { return DriverPKCS11 }
+
+// Sign signs a digest on the device. CKM_ECDSA signs prehashed input and
+// pkcs11-tool returns the raw r||s form.
+func (s *pkcs11Signer) Sign(digest []byte) ([]byte, error) {
+	if os.Getenv("PKCS11_PIN") == "" {
+		return nil, errors.New("PKCS11_PIN is not set")
+	}
+	signature, err := s.run(digest, "--login", "--pin", "env:PKCS11_PIN", "--sign", "--mechanism", "ECDSA", "--id", s.config.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(signature) != 64 {
+		// Some modules return DER even for raw mechanisms
+		return derToRS(signature)
+	}
+	return signature, nil
+}
+
+// run invokes pkcs11-tool with input on stdin and returns its stdout
+func (s *pkcs11Signer) run(input []byte, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.timeout())
+	defer cancel()
+
+	args = append([]string{"--module", s.config.PKCS11Module}, args...)
+	if s.config.PKCS11Slot != "" {
+		args = append(args, "--slot", s.config.PKCS11Slot)
+	}
+	cmd := exec.CommandContext(ctx, s.tool, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("pkcs11-tool timed out after %v", time.Since(start).Round(time.Millisecond))
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("pkcs11-tool failed: %v: %s", err, msg)
+		}
+		return nil, fmt.Errorf("pkcs11-tool failed: %v", err)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,164 @@
+// Package signer produces validator block signatures with keys that may live
+// outside the node: in the local keystore, in an HSM reached through PKCS#11,
+// or in AWS KMS or Google Cloud KMS. Remote keys never leave their device; the
+// node only sends block digests to be signed.
+package signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// Signer drivers
+const (
+	DriverLocal  = "local"  // key from the node's keystore
+	DriverPKCS11 = "pkcs11" // HSM or token reached through a PKCS#11 module
+	DriverAWSKMS = "awskms" // AWS Key Management Service
+	DriverGCPKMS = "gcpkms" // Google Cloud Key Management Service
+)
+
+var (
+	// ErrUnavailable is returned when the signer cannot be reached or does not
+	// produce valid signatures; mining does not start while it persists
+	ErrUnavailable = errors.New("validator signer unavailable")
+	// ErrUnknownDriver is returned for a driver name that is not supported
+	ErrUnknownDriver = errors.New("unknown signer driver")
+)
+
+// Signer signs block digests with the validator key. It is a
+// blockchain.PrivateKey whose Bytes are empty for keys held by a device.
+type Signer interface {
+	blockchain.PrivateKey
+	// Driver returns the name of the driver backing the signer
+	Driver() string
+}
+
+// Config selects and configures the validator signer. Credentials are read from
+// the environment so they never appear in config files or the process list:
+// PKCS11_PIN for PKCS#11, AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN for AWS KMS, and GCP_ACCESS_TOKEN for Google Cloud KMS
+// (without it a token is requested from the GCE metadata server).
+type Config struct {
+	Driver string `json:"driver"`           // local (default), pkcs11, awskms or gcpkms
+	KeyID  string `json:"key_id,omitempty"` // PKCS#11 object ID (hex), AWS KMS key ID or ARN, or GCP key version resource name
+
+	PKCS11Module string `json:"pkcs11_module,omitempty"` // PKCS#11 library, e.g. /usr/lib/softhsm/libsofthsm2.so
+	PKCS11Slot   string `json:"pkcs11_slot,omitempty"`   // slot ID; empty uses the first slot with a token
+	PKCS11Tool   string `json:"pkcs11_tool,omitempty"`   // OpenSC pkcs11-tool binary, default "pkcs11-tool"
+
+	Region   string `json:"region,omitempty"`   // AWS region
+	Endpoint string `json:"endpoint,omitempty"` // overrides the KMS API endpoint, e.g. for a local emulator
+
+	TimeoutMs int `json:"timeout_ms,omitempty"` // per signing request; default 5s
+}
+
+// DefaultConfig returns the configuration of the local keystore signer
+func DefaultConfig() Config {
+	return Config{Driver: DriverLocal, TimeoutMs: 5000}
+}
+
+// timeout returns the configured request timeout
+func (c Config) timeout() time.Duration {
+	if c.TimeoutMs <= 0 {
+		return 5 * time.Second
+	}
+	return time.Duration(c.TimeoutMs) * time.Millisecond
+}
+
+// New creates the signer described by config. localKey is the validator key
+// from the keystore, used by the local driver.
+func New(config Config, localKey *ecdsa.PrivateKey) (Signer, error) {
+	switch strings.ToLower(config.Driver) {
+	case "", DriverLocal:
+		if localKey == nil {
+			return nil, errors.New("local signer requires a validator key")
+		}
+		return localSigner{blockchain.P256PrivateKey(localKey)}, nil
+	case DriverPKCS11:
+		return newPKCS11Signer(config)
+	case DriverAWSKMS:
+		return newAWSKMSSigner(config)
+	case DriverGCPKMS:
+		return newGCPKMSSigner(config)
+	default:
+		return nil, fmt.Errorf("%w: %q (expected local, pkcs11, awskms or gcpkms)", ErrUnknownDriver, config.Driver)
+	}
+}
+
+// Check signs a random probe digest and verifies the signature, proving the
+// signer is reachable and holds the key it claims to
+func Check(s Signer) error {
+	digest := make([]byte, 32)
+	if _, err := rand.Read(digest); err != nil {
+		return err
+	}
+	signature, err := s.Sign(digest)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrUnavailable, s.Driver(), err)
+	}
+	if !s.Public().Verify(digest, signature) {
+		return fmt.Errorf("%w: %s signer returned a signature that does not match its public key", ErrUnavailable, s.Driver())
+	}
+	return nil
+}
+
+// localSigner signs with a key held in memory
+type localSigner struct {
+	blockchain.PrivateKey
+}
+
+func (localSigner) Driver() string { return DriverLocal }
+
+// remoteKey holds the public half of a P-256 key kept by a device. Only P-256
+// is used for remote keys since every driver supports it.
+type remoteKey struct {
+	public *ecdsa.PublicKey
+}
+
+func (k remoteKey) Type() blockchain.KeyType { return blockchain.KeyTypeP256 }
+
+func (k remoteKey) Public() blockchain.PublicKey { return blockchain.P256PublicKey(k.public) }
+
+// Bytes is empty: the private key never leaves the device
+func (k remoteKey) Bytes() []byte { return nil }
+
+// parsePublicKeyDER decodes a DER SubjectPublicKeyInfo holding a P-256 key
+func parsePublicKeyDER(der []byte) (*ecdsa.PublicKey, error) {
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %v", err)
+	}
+	key, ok := parsed.(*ecdsa.PublicKey)
+	if !ok || key.Curve != elliptic.P256() {
+		return nil, errors.New("validator key must be an ECDSA P-256 key")
+	}
+	return key, nil
+}
+
+// derToRS converts an ASN.1 DER ECDSA signature into the fixed-size r||s form
+// produced by local keys
+func derToRS(der []byte) ([]byte, error) {
+	var sig struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, fmt.Errorf("invalid ECDSA signature: %v", err)
+	}
+	if sig.R.Sign() <= 0 || sig.S.Sign() <= 0 || sig.R.BitLen() > 256 || sig.S.BitLen() > 256 {
+		return nil, errors.New("invalid ECDSA signature")
+	}
+	signature := make([]byte, 64)
+	sig.R.FillBytes(signature[:32])
+	sig.S.FillBytes(signature[32:])
+	return signature, nil
+}
@@ -1,5 +1,10 @@
 package types
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 // SignedRequest represents a request signed by an admin
 type SignedRequest struct {
 	Action       string            `json:"action"`
@@ -7,4 +12,17 @@ type SignedRequest struct {
 	AdminAddress string            `json:"adminAddress"`
 	Signature    string            `json:"signature"`
 	Timestamp    int64             `json:"timestamp"`
-} 
\ No newline at end of file
+	Nonce        string            `json:"nonce,omitempty"` // tells apart identical requests signed in the same second
+}
+
+// SigningMessage returns the message the admin signs:
+// action:adminAddress:timestamp:nonce:data, with data as JSON. Map keys are
+// encoded in sorted order, so the encoding is canonical.
+func (r *SignedRequest) SigningMessage() string {
+	data := r.Data
+	if data == nil {
+		data = map[string]string{}
+	}
+	encoded, _ := json.Marshal(data) // a map of strings always encodes
+	return fmt.Sprintf("%s:%s:%d:%s:%s", r.Action, r.AdminAddress, r.Timestamp, r.Nonce, encoded)
+}
@@ -0,0 +1,154 @@
+// Package units converts between base units, the integer amounts the chain
+// stores, and human-readable ConX amounts. One ConX is 10^18 base units.
+//
+// The API sends every amount as a decimal string of base units, because
+// amounts routinely exceed the integers JavaScript can represent exactly.
+// ParseJSONUint64 reads either form so Go clients accept both.
+package units
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Symbol is the ticker of the native token
+const Symbol = "ConX"
+
+// Decimals is the number of decimal places of one ConX in base units
+const Decimals = 18
+
+// ErrInvalidAmount is returned for amounts that cannot be parsed
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// one is 10^Decimals, the base units of one ConX
+var one = new(big.Int).Exp(big.NewInt(10), big.NewInt(Decimals), nil)
+
+// OneConX returns the base units of one ConX
+func OneConX() *big.Int {
+	return new(big.Int).Set(one)
+}
+
+// Format renders base units as a ConX amount with up to decimals fractional
+// digits, trailing zeros removed: 1500000000000000000 is "1.5". Digits past
+// decimals are truncated. A negative decimals keeps every digit.
+func Format(amount *big.Int, decimals int) string {
+	if amount == nil {
+		return "0"
+	}
+	if decimals < 0 || decimals > Decimals {
+		decimals = Decimals
+	}
+
+	abs := new(big.Int).Abs(amount)
+	whole, frac := new(big.Int).QuoRem(abs, one, new(big.Int))
+	fraction := frac.String()
+	fraction = (strings.Repeat("0", Decimals-len(fraction)) + fraction)[:decimals]
+	fraction = strings.TrimRight(fraction, "0")
+
+	result := whole.String()
+	if fraction != "" {
+		result += "." + fraction
+	}
+	if amount.Sign() < 0 {
+		result = "-" + result
+	}
+	return result
+}
+
+// FormatUint64 is Format for the uint64 values carried by transactions
+func FormatUint64(amount uint64, decimals int) string {
+	return Format(new(big.Int).SetUint64(amount), decimals)
+}
+
+// Parse converts a ConX amount such as "1.5" or "1.5 ConX" to base units.
+// More fractional digits than Decimals are refused rather than rounded.
+func Parse(s string) (*big.Int, error) {
+	value := strings.TrimSpace(s)
+	if strings.HasSuffix(strings.ToLower(value), strings.ToLower(Symbol)) {
+		value = strings.TrimSpace(value[:len(value)-len(Symbol)])
+	}
+	value = strings.ReplaceAll(value, "_", "")
+
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+	whole, fraction := value, ""
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		whole, fraction = value[:i], value[i+1:]
+	}
+	if whole == "" && fraction == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if len(fraction) > Decimals {
+		return nil, fmt.Errorf("%w: %q has more than %d decimals", ErrInvalidAmount, s, Decimals)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	digits := whole + fraction + strings.Repeat("0", Decimals-len(fraction))
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+		}
+	}
+	amount, _ := new(big.Int).SetString(digits, 10)
+	if negative {
+		amount.Neg(amount)
+	}
+	return amount, nil
+}
+
+// ParseBaseUnits converts a decimal string of base units to an integer
+func ParseBaseUnits(s string) (*big.Int, error) {
+	amount, ok := new(big.Int).SetString(strings.TrimSpace(s), 10)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not an integer amount of base units", ErrInvalidAmount, s)
+	}
+	return amount, nil
+}
+
+// ParseJSONUint64 reads a uint64 amount sent either as a JSON number or as a
+// decimal string of base units. An empty or null value is zero.
+func ParseJSONUint64(raw json.RawMessage) (uint64, error) {
+	text := strings.TrimSpace(string(raw))
+	if text == "" || text == "null" {
+		return 0, nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return 0, err
+		}
+	}
+	amount, err := ParseBaseUnits(text)
+	if err != nil {
+		return 0, err
+	}
+	if amount.Sign() < 0 || !amount.IsUint64() {
+		return 0, fmt.Errorf("%w: %s does not fit in 64 bits", ErrInvalidAmount, text)
+	}
+	return amount.Uint64(), nil
+}
+
+// Uint64 is a uint64 amount of base units that encodes as a JSON string and
+// decodes from a string or a number. API requests use it so clients can
+// send amounts larger than JavaScript numbers hold.
+type Uint64 uint64
+
+// MarshalJSON encodes the amount as a decimal string
+func (a Uint64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatUint(uint64(a), 10))
+}
+
+// UnmarshalJSON accepts a decimal string or a number
+func (a *Uint64) UnmarshalJSON(data []byte) error {
+	value, err := ParseJSONUint64(data)
+	if err != nil {
+		return err
+	}
+	*a = Uint64(value)
+	return nil
+}
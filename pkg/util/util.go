@@ -7,21 +7,21 @@ import (
 	"time"
 )
 
-// PublicKeyToAddress ECDSA public key'den adres oluşturur
+// PublicKeyToAddress derives an address from an ECDSA public key
 func PublicKeyToAddress(publicKey *ecdsa.PublicKey) string {
-	// Public key'in x ve y koordinatlarını birleştir
+	// Concatenate the x and y coordinates of the public key
 	pubBytes := append(publicKey.X.Bytes(), publicKey.Y.Bytes()...)
 	
 	// SHA256 hash hesapla
 	hash := sha256.Sum256(pubBytes)
 	
-	// Hash'i hexadecimal string'e çevir
-	address := hex.EncodeToString(hash[:20]) // İlk 20 byte'ı kullan
+	// Convert the hash to a hexadecimal string
+	address := hex.EncodeToString(hash[:20]) // Use the first 20 bytes
 	
 	return address
 }
 
-// CurrentTimestamp Unix timestamp döndürür
+// CurrentTimestamp returns the current Unix timestamp
 func CurrentTimestamp() int64 {
 	return time.Now().Unix()
 } 
\ No newline at end of file
@@ -43,6 +43,14 @@ type Validator struct {
 	stopChan     chan struct{}
 	wg           sync.WaitGroup
 	client       *http.Client
+	maxBatch     int // transactions per mined block, 0 = the node's block capacity
+}
+
+// MineResult is the outcome of one mining round
+type MineResult struct {
+	Block     Block `json:"block"`
+	Invalid   int   `json:"invalidTransactions"`
+	Remaining int   `json:"remainingTransactions"` // still pending after the round
 }
 
 // NewValidator creates a new validator instance
@@ -56,6 +64,15 @@ func NewValidator(apiBaseURL string, address string, interval time.Duration) *Va
 	}
 }
 
+// SetMaxBatch bounds how many transactions each mined block takes from the
+// mempool; larger mempools are drained over several rounds
+func (v *Validator) SetMaxBatch(n int) {
+	if n < 0 {
+		n = 0
+	}
+	v.maxBatch = n
+}
+
 // Start begins the validation process
 func (v *Validator) Start() error {
 	if v.isRunning {
@@ -118,20 +135,32 @@ func (v *Validator) validatePendingTransactions() {
 
 	log.Printf("Found %d pending transactions to validate", len(pendingTxs))
 	
-	// İşlemlerin Transaction.ID değerlerini saklayalım
+	// Keep the Transaction.ID values of the transactions
 	txIDs := make([]string, len(pendingTxs))
 	for i, tx := range pendingTxs {
 		txIDs[i] = tx.ID
 	}
 
-	// Mine a new block
-	err = v.mineBlock()
-	if err != nil {
-		log.Printf("Error mining block: %v", err)
-		return
-	}
+	// Mine bounded batches until the mempool is drained or the validator stops
+	for round := 1; ; round++ {
+		result, err := v.mineBlock()
+		if err != nil {
+			log.Printf("Error mining block: %v", err)
+			return
+		}
 
-	log.Printf("Successfully validated transactions and created a new block")
+		log.Printf("Successfully validated transactions and created block #%d (round %d, %d transactions remaining)",
+			result.Block.Index, round, result.Remaining)
+		if result.Remaining == 0 {
+			return
+		}
+
+		select {
+		case <-v.stopChan:
+			return
+		default:
+		}
+	}
 }
 
 // getPendingTransactions retrieves pending transactions from the API
@@ -154,14 +183,15 @@ func (v *Validator) getPendingTransactions() ([]Transaction, error) {
 	return transactions, nil
 }
 
-// mineBlock requests the API to mine a new block
-func (v *Validator) mineBlock() error {
+// mineBlock requests the API to mine a new block of at most maxBatch transactions
+func (v *Validator) mineBlock() (*MineResult, error) {
 	// Create request body
-	reqBody, err := json.Marshal(map[string]string{
-		"validator": v.address,
+	reqBody, err := json.Marshal(map[string]interface{}{
+		"validator":       v.address,
+		"maxTransactions": v.maxBatch,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to marshal request body: %w", err)
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
 	// Send request to mine endpoint
@@ -171,7 +201,7 @@ func (v *Validator) mineBlock() error {
 		bytes.NewBuffer(reqBody),
 	)
 	if err != nil {
-		return fmt.Errorf("failed to send mine request: %w", err)
+		return nil, fmt.Errorf("failed to send mine request: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -185,10 +215,14 @@ func (v *Validator) mineBlock() error {
 		log.Printf("Mining response body: %s", string(respBody))
 		
 		if err := json.Unmarshal(respBody, &errMsg); err == nil && errMsg.Error != "" {
-			return fmt.Errorf("mining failed: %s", errMsg.Error)
+			return nil, fmt.Errorf("mining failed: %s", errMsg.Error)
 		}
-		return fmt.Errorf("mining failed with status: %d", resp.StatusCode)
+		return nil, fmt.Errorf("mining failed with status: %d", resp.StatusCode)
 	}
 
-	return nil
+	var result MineResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode mining response: %w", err)
+	}
+	return &result, nil
 } 
\ No newline at end of file
@@ -0,0 +1,311 @@
+package watchdog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"confirmix/pkg/blockchain"
+)
+
+// Alert kinds
+const (
+	AlertStall           = "stall"            // no block while transactions are pending
+	AlertFork            = "fork"             // peers keep sending blocks of another branch
+	AlertMempoolOverflow = "mempool_overflow" // too many pending transactions
+	AlertRecovery        = "recovery"         // an automated recovery action was taken
+)
+
+// maxAlertHistory is the number of alerts kept for the status report
+const maxAlertHistory = 50
+
+// Config controls what the watchdog considers unhealthy and how it reacts
+type Config struct {
+	CheckInterval time.Duration // time between health checks
+	BlockTime     time.Duration // expected time between blocks
+	StallFactor   int           // production is stalled after StallFactor block times without a block
+	ForkChecks    int           // consecutive checks with conflicting blocks before a fork alert
+	MaxMempool    int           // pending transactions counted as an overflow; 0 disables the check
+	AlertWebhook  string        // URL receiving alerts as JSON POSTs; empty only logs them
+	AutoRecover   bool          // resync and rotate peers on stalls and forks
+}
+
+// DefaultConfig returns the default watchdog configuration
+func DefaultConfig() *Config {
+	return &Config{
+		CheckInterval: 30 * time.Second,
+		BlockTime:     15 * time.Second,
+		StallFactor:   10,
+		ForkChecks:    3,
+		MaxMempool:    10000,
+	}
+}
+
+// Network is the part of the P2P node the watchdog observes and recovers through
+type Network interface {
+	ConflictingBlocks() uint64
+	PeerHeight() uint64
+	RequestSync() error
+	RotatePeers() int
+}
+
+// Alert is a detected problem or its resolution
+type Alert struct {
+	Kind     string `json:"kind"`
+	Message  string `json:"message"`
+	Height   uint64 `json:"height"`
+	Resolved bool   `json:"resolved"`         // the condition cleared
+	Action   string `json:"action,omitempty"` // recovery action taken
+	Time     int64  `json:"time"`
+}
+
+// Status reports the state of the watchdog
+type Status struct {
+	Running     bool     `json:"running"`
+	AutoRecover bool     `json:"autoRecover"`
+	Active      []string `json:"active"` // alert kinds currently raised
+	LastCheckAt int64    `json:"lastCheckAt,omitempty"`
+	Alerts      []Alert  `json:"alerts"` // most recent last
+}
+
+// Watchdog periodically checks block production, forks and the mempool,
+// raising alerts and optionally attempting recovery
+type Watchdog struct {
+	blockchain *blockchain.Blockchain
+	network    Network // nil on a node without peers: fork checks and recovery are skipped
+	config     *Config
+	client     *http.Client
+
+	mu            sync.Mutex
+	active        map[string]bool
+	alerts        []Alert
+	lastCheckAt   time.Time
+	lastConflicts uint64
+	forkStreak    int
+	recoveries    int // recovery attempts during the current stall or fork
+	stopCh        chan struct{}
+}
+
+// New creates a watchdog for the chain. network may be nil.
+func New(bc *blockchain.Blockchain, network Network, config *Config) *Watchdog {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &Watchdog{
+		blockchain: bc,
+		network:    network,
+		config:     config,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		active:     make(map[string]bool),
+	}
+}
+
+// Start begins periodic health checks
+func (w *Watchdog) Start() error {
+	if w.config.CheckInterval <= 0 {
+		return errors.New("watchdog check interval must be positive")
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopCh != nil {
+		return errors.New("watchdog already running")
+	}
+	stopCh := make(chan struct{})
+	w.stopCh = stopCh
+
+	go func() {
+		ticker := time.NewTicker(w.config.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				w.Check()
+			}
+		}
+	}()
+	log.Printf("Chain watchdog started (every %s, auto-recovery %v)", w.config.CheckInterval, w.config.AutoRecover)
+	return nil
+}
+
+// Stop ends periodic health checks
+func (w *Watchdog) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopCh != nil {
+		close(w.stopCh)
+		w.stopCh = nil
+	}
+}
+
+// Check runs all health checks once
+func (w *Watchdog) Check() {
+	now := time.Now()
+	latest := w.blockchain.GetLatestBlock()
+	pending := len(w.blockchain.GetPendingTransactions())
+
+	var conflicts uint64
+	if w.network != nil {
+		conflicts = w.network.ConflictingBlocks()
+	}
+
+	w.mu.Lock()
+	w.lastCheckAt = now
+	var raised []Alert
+
+	// Blocks are only produced when transactions are pending, so an idle
+	// chain is not stalled
+	stallAfter := w.config.BlockTime * time.Duration(w.config.StallFactor)
+	idle := now.Sub(time.Unix(latest.Timestamp, 0))
+	stalled := stallAfter > 0 && pending > 0 && idle > stallAfter
+	if alert, changed := w.setLocked(AlertStall, stalled, latest.Index,
+		fmt.Sprintf("no block for %s with %d pending transactions (expected every %s)",
+			idle.Round(time.Second), pending, w.config.BlockTime)); changed {
+		raised = append(raised, alert)
+	}
+
+	if conflicts > w.lastConflicts {
+		w.forkStreak++
+	} else {
+		w.forkStreak = 0
+	}
+	w.lastConflicts = conflicts
+	forked := w.config.ForkChecks > 0 && w.forkStreak >= w.config.ForkChecks
+	if alert, changed := w.setLocked(AlertFork, forked, latest.Index,
+		fmt.Sprintf("peers sent blocks of another branch in %d consecutive checks (%d conflicting blocks)",
+			w.forkStreak, conflicts)); changed {
+		raised = append(raised, alert)
+	}
+
+	overflow := w.config.MaxMempool > 0 && pending > w.config.MaxMempool
+	if alert, changed := w.setLocked(AlertMempoolOverflow, overflow, latest.Index,
+		fmt.Sprintf("%d pending transactions, above the limit of %d", pending, w.config.MaxMempool)); changed {
+		raised = append(raised, alert)
+	}
+
+	if !w.active[AlertStall] && !w.active[AlertFork] {
+		w.recoveries = 0
+	}
+	recovering := w.config.AutoRecover && w.network != nil && (w.active[AlertStall] || w.active[AlertFork])
+	w.mu.Unlock()
+
+	for _, alert := range raised {
+		w.notify(alert)
+	}
+	if recovering {
+		w.recover(latest.Index)
+	}
+}
+
+// setLocked raises or resolves an alert kind. It returns the alert and true
+// when the state changed. The caller must hold w.mu.
+func (w *Watchdog) setLocked(kind string, unhealthy bool, height uint64, message string) (Alert, bool) {
+	if unhealthy == w.active[kind] {
+		return Alert{}, false
+	}
+	alert := Alert{Kind: kind, Height: height, Time: time.Now().Unix()}
+	if unhealthy {
+		w.active[kind] = true
+		alert.Message = message
+	} else {
+		delete(w.active, kind)
+		alert.Resolved = true
+		alert.Message = kind + " resolved"
+	}
+	w.recordLocked(alert)
+	return alert, true
+}
+
+// recordLocked appends an alert to the history. The caller must hold w.mu.
+func (w *Watchdog) recordLocked(alert Alert) {
+	w.alerts = append(w.alerts, alert)
+	if len(w.alerts) > maxAlertHistory {
+		w.alerts = w.alerts[len(w.alerts)-maxAlertHistory:]
+	}
+}
+
+// recover asks peers for the missing blocks first and rotates peers when
+// that did not help by the next check
+func (w *Watchdog) recover(height uint64) {
+	w.mu.Lock()
+	attempt := w.recoveries
+	w.recoveries++
+	w.mu.Unlock()
+
+	alert := Alert{Kind: AlertRecovery, Height: height, Time: time.Now().Unix()}
+	if attempt%2 == 0 {
+		alert.Action = "resync"
+		if err := w.network.RequestSync(); err != nil {
+			alert.Message = fmt.Sprintf("resync failed: %v", err)
+		} else {
+			alert.Message = fmt.Sprintf("requested blocks after height %d (peers are at %d)", height, w.network.PeerHeight())
+		}
+	} else {
+		alert.Action = "rotate_peers"
+		alert.Message = fmt.Sprintf("connected to %d stored peers", w.network.RotatePeers())
+	}
+
+	w.mu.Lock()
+	w.recordLocked(alert)
+	w.mu.Unlock()
+	w.notify(alert)
+}
+
+// notify logs an alert and delivers it to the webhook
+func (w *Watchdog) notify(alert Alert) {
+	switch {
+	case alert.Action != "":
+		log.Printf("Watchdog recovery (%s): %s", alert.Action, alert.Message)
+	case alert.Resolved:
+		log.Printf("Watchdog: %s", alert.Message)
+	default:
+		log.Printf("WATCHDOG ALERT [%s] at height %d: %s", alert.Kind, alert.Height, alert.Message)
+	}
+
+	if w.config.AlertWebhook == "" {
+		return
+	}
+	go func() {
+		body, err := json.Marshal(alert)
+		if err != nil {
+			return
+		}
+		resp, err := w.client.Post(w.config.AlertWebhook, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("Failed to deliver watchdog alert: %v", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("Watchdog alert webhook answered %s", resp.Status)
+		}
+	}()
+}
+
+// Status returns the state of the watchdog
+func (w *Watchdog) Status() *Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	status := &Status{
+		Running:     w.stopCh != nil,
+		AutoRecover: w.config.AutoRecover,
+		Active:      make([]string, 0, len(w.active)),
+		Alerts:      append([]Alert{}, w.alerts...),
+	}
+	for _, kind := range []string{AlertStall, AlertFork, AlertMempoolOverflow} {
+		if w.active[kind] {
+			status.Active = append(status.Active, kind)
+		}
+	}
+	if !w.lastCheckAt.IsZero() {
+		status.LastCheckAt = w.lastCheckAt.Unix()
+	}
+	return status
+}
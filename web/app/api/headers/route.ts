@@ -0,0 +1,23 @@
+import { NextRequest, NextResponse } from 'next/server';
+
+const BACKEND_API_URL = process.env.NEXT_PUBLIC_BACKEND_API_URL || 'http://localhost:8080/api';
+
+export async function GET(request: NextRequest) {
+  try {
+    const response = await fetch(`${BACKEND_API_URL}/headers${request.nextUrl.search}`);
+    const data = await response.json().catch(() => null);
+    if (!response.ok) {
+      return NextResponse.json(
+        { error: data?.error || 'Could not retrieve block headers', code: data?.code },
+        { status: response.status }
+      );
+    }
+    return NextResponse.json(data);
+  } catch (error) {
+    console.error('Headers endpoint error:', error);
+    return NextResponse.json(
+      { error: 'Could not retrieve block headers' },
+      { status: 500 }
+    );
+  }
+}
@@ -1,168 +1,94 @@
-import { BlockchainStatus, Block, Transaction, ValidatorInfo } from './lib/types';
+import { BlockchainStatus, Block, HeaderRange, Transaction, ValidatorInfo } from './lib/types';
+
+// The node publishes a schema only for the responses it can encode as
+// protobuf: pkg/api/confirmix.proto covers block summaries, transactions and
+// headers. The types of those responses in ./lib/types follow it; the other
+// routes have no schema yet, so their types are written by hand until it
+// covers them and the SDK can be generated from it.
+
+// Error reported by the node, carrying its stable error code when it sent one
+export class ApiError extends Error {
+  constructor(message: string, public status: number, public code?: string) {
+    super(message);
+    this.name = 'ApiError';
+  }
+}
+
+// Sends a request to the API and returns its JSON body. Failed requests and
+// bodies carrying an error are thrown as ApiError, with the node's message
+// when it sent one and fallback otherwise.
+async function request<T>(path: string, fallback: string, body?: unknown): Promise<T> {
+  const init: RequestInit = body === undefined
+    ? {}
+    : { method: 'POST', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify(body) };
+  const response = await fetch(path, init);
+  const data = await response.json().catch(() => null);
+  if (!response.ok || (data && data.error)) {
+    throw new ApiError(data?.error || fallback, response.status, data?.code);
+  }
+  return data as T;
+}
+
+const post = <T>(path: string, fallback: string, body: unknown = {}) => request<T>(path, fallback, body);
 
 export const api = {
-  getStatus: async (): Promise<BlockchainStatus> => {
-    const response = await fetch('/api/status');
-    if (!response.ok) {
-      throw new Error('API connection error');
-    }
-    const data = await response.json();
-    if (data.error) {
-      throw new Error(data.error);
-    }
-    return data;
-  },
+  getStatus: (): Promise<BlockchainStatus> => request('/api/status', 'API connection error'),
 
-  getBlocks: async (): Promise<Block[]> => {
-    const response = await fetch('/api/blocks');
-    if (!response.ok) {
-      throw new Error('API connection error');
-    }
-    const data = await response.json();
-    if (data.error) {
-      throw new Error(data.error);
-    }
-    return data;
-  },
+  getBlocks: (): Promise<Block[]> => request('/api/blocks', 'API connection error'),
 
-  getTransactions: async (): Promise<Transaction[]> => {
-    const response = await fetch('/api/transactions');
-    if (!response.ok) {
-      throw new Error('API connection error');
-    }
-    const data = await response.json();
-    if (data.error) {
-      throw new Error(data.error);
-    }
-    return data;
+  // Headers from a block index, the latest ones when from is omitted
+  getHeaders: (from?: number, count?: number): Promise<HeaderRange> => {
+    const query = new URLSearchParams();
+    if (from !== undefined) query.set('from', String(from));
+    if (count !== undefined) query.set('count', String(count));
+    return request(`/api/headers?${query}`, 'Could not retrieve block headers');
   },
 
-  getPendingTransactions: async (): Promise<Transaction[]> => {
-    const response = await fetch('/api/transactions/pending');
-    if (!response.ok) {
-      throw new Error('API connection error');
-    }
-    const data = await response.json();
-    if (data.error) {
-      throw new Error(data.error);
-    }
-    return data;
-  },
+  getTransactions: (): Promise<Transaction[]> => request('/api/transactions', 'API connection error'),
 
-  getConfirmedTransactions: async (): Promise<Transaction[]> => {
-    const response = await fetch('/api/transactions/confirmed');
-    if (!response.ok) {
-      throw new Error('API connection error');
-    }
-    const data = await response.json();
-    if (data.error) {
-      throw new Error(data.error);
-    }
-    return data;
-  },
+  getPendingTransactions: (): Promise<Transaction[]> => request('/api/transactions/pending', 'API connection error'),
 
-  getValidators: async (): Promise<ValidatorInfo[]> => {
-    const response = await fetch('/api/validators');
-    if (!response.ok) {
-      throw new Error('API connection error');
-    }
-    const data = await response.json();
-    if (data.error) {
-      throw new Error(data.error);
-    }
-    return data;
-  },
+  getConfirmedTransactions: (): Promise<Transaction[]> => request('/api/transactions/confirmed', 'API connection error'),
 
-  registerValidator: async (address: string, humanProof: string): Promise<ValidatorInfo> => {
-    const response = await fetch('/api/validator/register', {
-      method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
-      body: JSON.stringify({ address, humanProof })
-    });
-    if (!response.ok) {
-      const errorData = await response.json();
-      throw new Error(errorData.error || 'API connection error');
-    }
-    const data = await response.json();
-    if (data.error) {
-      throw new Error(data.error);
-    }
-    return data;
-  },
+  getValidators: (): Promise<ValidatorInfo[]> => request('/api/validators', 'API connection error'),
 
-  createWallet: async (): Promise<{ address: string; publicKey: string; privateKey: string }> => {
-    const response = await fetch('/api/wallet/create', {
-      method: 'POST',
-      headers: { 'Content-Type': 'application/json' }
-    });
-    if (!response.ok) {
-      const errorData = await response.json();
-      throw new Error(errorData.error || 'API connection error');
-    }
-    const data = await response.json();
-    if (data.error) {
-      throw new Error(data.error);
-    }
-    return data;
-  },
+  registerValidator: (address: string, humanProof: string): Promise<ValidatorInfo> =>
+    post('/api/validator/register', 'API connection error', { address, humanProof }),
 
-  importWallet: async (privateKey: string): Promise<{ address: string; publicKey: string; privateKey: string; exists: boolean }> => {
-    const response = await fetch('/api/wallet/import', {
-      method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
-      body: JSON.stringify({ privateKey })
-    });
-    if (!response.ok) {
-      const errorData = await response.json();
-      throw new Error(errorData.error || 'API connection error');
-    }
-    const data = await response.json();
-    if (data.error) {
-      throw new Error(data.error);
-    }
-    return data;
-  },
+  createWallet: (): Promise<{ address: string; publicKey: string; privateKey: string }> =>
+    post('/api/wallet/create', 'API connection error'),
+
+  importWallet: (privateKey: string): Promise<{ address: string; publicKey: string; privateKey: string; exists: boolean }> =>
+    post('/api/wallet/import', 'API connection error', { privateKey }),
 
   async getBalance(address: string): Promise<string> {
     try {
-      console.log(`Requesting balance for address: ${address}`);
-      const response = await fetch(`/api/wallet/balance/${address}`);
-      if (!response.ok) {
-        throw new Error('Could not retrieve balance information');
-      }
-      
-      const data = await response.json();
-      console.log("Raw API balance response:", data);
-      
-      if (!data || data.balance === null || data.balance === undefined) {
-        console.log("API returned null/undefined balance, returning '0'");
+      const data = await request<{ balance?: string | number | null }>(
+        `/api/wallet/balance/${address}`, 'Could not retrieve balance information');
+      // The node sends balances as decimal strings; older responses used numbers
+      if (data.balance === null || data.balance === undefined) {
         return "0";
       }
-      
-      if (typeof data.balance !== 'string') {
-        console.log(`API returned non-string balance: ${typeof data.balance}, value: ${data.balance}`);
-        // Try to convert to string
-        return String(data.balance);
-      }
-      
-      return data.balance;
+      return String(data.balance);
     } catch (error) {
       console.error('Balance query error:', error);
       throw new Error('Could not retrieve balance information');
     }
   },
 
-  transfer: async (from: string, to: string, value: string): Promise<any> => {
-    const response = await fetch('/api/transaction', {
-      method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
-      body: JSON.stringify({ from, to, value })
-    });
-    
-    const data = await response.json();
-    if (!response.ok || data.error) {
-      throw new Error(data.error || 'Transfer transaction failed');
-    }
-    return data;
-  }
+  transfer: (from: string, to: string, value: string): Promise<any> =>
+    post('/api/transaction', 'Transfer transaction failed', { from, to, value }),
+
+  // Address book kept by the node for an unlocked wallet
+  getContacts: async (wallet: string): Promise<{ name: string; address: string; note?: string }[]> => {
+    const data = await request<{ contacts: { name: string; address: string; note?: string }[] }>(
+      `/api/wallet/contacts?wallet=${encodeURIComponent(wallet)}`, 'Could not retrieve contacts');
+    return data.contacts;
+  },
+
+  saveContact: (wallet: string, name: string, address: string, note?: string): Promise<any> =>
+    post('/api/wallet/contacts', 'Could not save contact', { wallet, name, address, note }),
+
+  removeContact: (wallet: string, name: string): Promise<any> =>
+    post('/api/wallet/contacts/remove', 'Could not remove contact', { wallet, name })
 }; 
\ No newline at end of file
@@ -31,6 +31,23 @@ export interface Transaction {
     BlockHash?: string;
 }
 
+// Block header as served by GET /api/headers. It mirrors the BlockHeader
+// message of pkg/api/confirmix.proto, with the JSON field names of the node.
+export interface BlockHeader {
+    index: number;
+    hash: string;
+    prevHash: string;
+    validator: string;
+    timestamp: number;
+    txCount: number;
+}
+
+// Mirrors the HeaderRange message of pkg/api/confirmix.proto
+export interface HeaderRange {
+    height: number;
+    headers: BlockHeader[];
+}
+
 export interface ValidatorInfo {
     address: string;
     humanProof: string;